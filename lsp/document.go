@@ -0,0 +1,289 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/kengibson1111/go-uml-statemachine-models/models"
+)
+
+// node is a JSON value of a model file together with its location. Paths use
+// the JSON keys of the model, e.g. "regions[0].states[1].name"; the root
+// object has the empty path.
+type node struct {
+	Path     string
+	Key      string // Object key or "" for array items and the root
+	KeyStart int    // Byte offset of the key's opening quote, or -1
+	Start    int    // Byte offset of the value's first byte
+	End      int    // Byte offset just past the value
+	Kind     byte   // '{', '[', '"' or 'v' for other scalars
+	Text     string // Decoded value of strings
+}
+
+// document is an open model file
+type document struct {
+	URI     string
+	Version int
+	Text    string
+	Nodes   []*node // In document order; parents precede their children
+	byPath  map[string]*node
+
+	Machine *models.StateMachine // Decoded model, nil if the file does not decode
+	Err     *syntaxError         // Why the file does not decode, nil if it does
+}
+
+// syntaxError is a JSON syntax error, or a value of the wrong type, at a
+// byte offset
+type syntaxError struct {
+	Offset  int
+	End     int // Byte offset just past the offending value, if known
+	Message string
+}
+
+func (e *syntaxError) Error() string {
+	return e.Message
+}
+
+// newDocument indexes and decodes the text of a model file
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{URI: uri, Version: version, Text: text, byPath: make(map[string]*node)}
+	s := &scanner{src: text}
+	if err := s.document(func(n *node) {
+		doc.Nodes = append(doc.Nodes, n)
+		doc.byPath[n.Path] = n
+	}); err != nil {
+		doc.Err = err
+		return doc
+	}
+	sm, err := models.DecodeStateMachine(strings.NewReader(text), models.ResourceLimits{})
+	if err != nil {
+		doc.Err = &syntaxError{Message: err.Error()}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			// The offset of a type error lies just past the value
+			if n := doc.nodeAt(int(typeErr.Offset) - 1); n != nil {
+				doc.Err.Offset, doc.Err.End = n.Start, n.End
+			}
+		}
+		return doc
+	}
+	doc.Machine = sm
+	return doc
+}
+
+// node returns the node at a path
+func (d *document) node(path string) *node {
+	return d.byPath[path]
+}
+
+// nodeAt returns the innermost node whose value or key contains the offset
+func (d *document) nodeAt(offset int) *node {
+	var found *node
+	for _, n := range d.Nodes {
+		inKey := n.KeyStart >= 0 && offset >= n.KeyStart && offset < n.Start
+		if inKey || (offset >= n.Start && offset < n.End) {
+			found = n
+		}
+	}
+	return found
+}
+
+// position converts a byte offset into an LSP position, whose character is
+// counted in UTF-16 code units
+func (d *document) position(offset int) Position {
+	offset = min(max(offset, 0), len(d.Text))
+	line := strings.Count(d.Text[:offset], "\n")
+	lineStart := strings.LastIndexByte(d.Text[:offset], '\n') + 1
+	character := 0
+	for _, r := range d.Text[lineStart:offset] {
+		character += len(utf16.Encode([]rune{r}))
+	}
+	return Position{Line: line, Character: character}
+}
+
+// offset converts an LSP position into a byte offset
+func (d *document) offset(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(d.Text[offset:], '\n')
+		if next < 0 {
+			return len(d.Text)
+		}
+		offset += next + 1
+	}
+	for character := 0; character < pos.Character && offset < len(d.Text); {
+		r, size := utf8.DecodeRuneInString(d.Text[offset:])
+		if r == '\n' {
+			break
+		}
+		character += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+// span returns the range of the bytes [start, end)
+func (d *document) span(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// valueRange returns the range of a node's value
+func (d *document) valueRange(n *node) Range {
+	return d.span(n.Start, n.End)
+}
+
+// scanner is a JSON parser that reports the location of every value
+type scanner struct {
+	src string
+	pos int
+}
+
+// document parses a single JSON value followed by white space
+func (s *scanner) document(emit func(*node)) *syntaxError {
+	s.space()
+	if err := s.value("", "", -1, emit); err != nil {
+		return err
+	}
+	s.space()
+	if s.pos < len(s.src) {
+		return s.errorf("unexpected data after the state machine")
+	}
+	return nil
+}
+
+func (s *scanner) errorf(format string, args ...any) *syntaxError {
+	return &syntaxError{Offset: s.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *scanner) space() {
+	for s.pos < len(s.src) && strings.IndexByte(" \t\r\n", s.src[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+func (s *scanner) value(path, key string, keyStart int, emit func(*node)) *syntaxError {
+	if s.pos >= len(s.src) {
+		return s.errorf("unexpected end of input")
+	}
+	n := &node{Path: path, Key: key, KeyStart: keyStart, Start: s.pos}
+	switch c := s.src[s.pos]; {
+	case c == '{':
+		n.Kind = '{'
+		emit(n)
+		s.pos++
+		s.space()
+		if s.peek('}') {
+			break
+		}
+		for {
+			s.space()
+			childKeyStart := s.pos
+			childKey, err := s.string()
+			if err != nil {
+				return err
+			}
+			s.space()
+			if !s.peek(':') {
+				return s.errorf("expected ':' after object key")
+			}
+			s.space()
+			childPath := childKey
+			if path != "" {
+				childPath = path + "." + childKey
+			}
+			if err := s.value(childPath, childKey, childKeyStart, emit); err != nil {
+				return err
+			}
+			s.space()
+			if s.peek('}') {
+				break
+			}
+			if !s.peek(',') {
+				return s.errorf("expected ',' or '}' in object")
+			}
+		}
+	case c == '[':
+		n.Kind = '['
+		emit(n)
+		s.pos++
+		s.space()
+		if s.peek(']') {
+			break
+		}
+		for i := 0; ; i++ {
+			s.space()
+			if err := s.value(fmt.Sprintf("%s[%d]", path, i), "", -1, emit); err != nil {
+				return err
+			}
+			s.space()
+			if s.peek(']') {
+				break
+			}
+			if !s.peek(',') {
+				return s.errorf("expected ',' or ']' in array")
+			}
+		}
+	case c == '"':
+		n.Kind = '"'
+		text, err := s.string()
+		if err != nil {
+			return err
+		}
+		n.Text = text
+		emit(n)
+	default:
+		n.Kind = 'v'
+		start := s.pos
+		for s.pos < len(s.src) && strings.IndexByte(",}] \t\r\n", s.src[s.pos]) < 0 {
+			s.pos++
+		}
+		literal := s.src[start:s.pos]
+		if _, err := strconv.ParseFloat(literal, 64); err != nil && literal != "true" && literal != "false" && literal != "null" {
+			s.pos = start
+			return s.errorf("invalid value %q", literal)
+		}
+		emit(n)
+	}
+	n.End = s.pos
+	return nil
+}
+
+// peek consumes c if it is the next byte
+func (s *scanner) peek(c byte) bool {
+	if s.pos < len(s.src) && s.src[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// string parses a JSON string and returns its decoded value
+func (s *scanner) string() (string, *syntaxError) {
+	start := s.pos
+	if !s.peek('"') {
+		return "", s.errorf("expected string")
+	}
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			var text string
+			if err := json.Unmarshal([]byte(s.src[start:s.pos]), &text); err != nil {
+				s.pos = start
+				return "", s.errorf("invalid string")
+			}
+			return text, nil
+		default:
+			s.pos++
+		}
+	}
+	s.pos = start
+	return "", s.errorf("unterminated string")
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+)
+
+// at returns the position just inside the n-th occurrence (from 0) of
+// needle in the document
+func at(t *testing.T, doc *document, needle string, n int) Position {
+	t.Helper()
+	offset := -1
+	for i := 0; i <= n; i++ {
+		next := strings.Index(doc.Text[offset+1:], needle)
+		if next < 0 {
+			t.Fatalf("occurrence %d of %q not found", n, needle)
+		}
+		offset += next + 1
+	}
+	return doc.position(offset + 1)
+}
+
+// textAt returns the text of a range of the document
+func textAt(doc *document, r Range) string {
+	return doc.Text[doc.offset(r.Start):doc.offset(r.End)]
+}
+
+func TestDocumentPositions(t *testing.T) {
+	doc := newDocument("file:///a.json", 1, "{\"name\": \"Tür 😀\",\n  \"id\": \"x\"}")
+	tests := []struct {
+		offset int
+		want   Position
+	}{
+		{offset: 0, want: Position{Line: 0, Character: 0}},
+		{offset: strings.Index(doc.Text, "😀"), want: Position{Line: 0, Character: 14}},
+		{offset: strings.Index(doc.Text, "😀") + len("😀"), want: Position{Line: 0, Character: 16}},
+		{offset: strings.Index(doc.Text, `"id"`), want: Position{Line: 1, Character: 2}},
+	}
+	for _, tt := range tests {
+		if got := doc.position(tt.offset); got != tt.want {
+			t.Errorf("position(%d) = %v, want %v", tt.offset, got, tt.want)
+		}
+		if got := doc.offset(tt.want); got != tt.offset {
+			t.Errorf("offset(%v) = %d, want %d", tt.want, got, tt.offset)
+		}
+	}
+	if n := doc.node("name"); n == nil || n.Text != "Tür 😀" {
+		t.Errorf("node(name) = %v", n)
+	}
+}
@@ -0,0 +1,357 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/kengibson1111/go-uml-statemachine-models/models"
+)
+
+// diagnosticSource names the server in diagnostics
+const diagnosticSource = "uml-statemachine"
+
+// elementKinds maps the JSON keys holding model elements to their kinds
+var elementKinds = map[string]string{
+	"regions":             "Region",
+	"states":              "State",
+	"vertices":            "Vertex",
+	"transitions":         "Transition",
+	"events":              "Event",
+	"connection_points":   "Pseudostate",
+	"connections":         "ConnectionPointReference",
+	"triggers":            "Trigger",
+	"deferrable_triggers": "Trigger",
+	"entry":               "Behavior",
+	"exit":                "Behavior",
+	"do_activity":         "Behavior",
+	"effect":              "Behavior",
+	"guard":               "Constraint",
+	"event":               "Event",
+	"source":              "Vertex",
+	"target":              "Vertex",
+}
+
+// vertexDefinition matches the paths of states, pseudostates and final
+// states defined by the model, as opposed to copies held as transition
+// endpoints
+var vertexDefinition = regexp.MustCompile(`(^|\.)(states|vertices|connection_points)\[\d+\]$`)
+
+// lastKey returns the last key of a path without its index, e.g. "states"
+// for "regions[0].states[1]"
+func lastKey(path string) string {
+	key := path[strings.LastIndexByte(path, '.')+1:]
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
+
+// parentPath returns the path of the value containing the value at path
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		return path[:strings.LastIndexByte(path, '[')]
+	}
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// inSubmachine reports whether a path lies inside an embedded submachine,
+// which is a separate model
+func inSubmachine(path string) bool {
+	return path == "submachine" || strings.HasPrefix(path, "submachine.") || strings.Contains(path, ".submachine.") || strings.HasSuffix(path, ".submachine")
+}
+
+// text returns the string child of an object node, or ""
+func (d *document) text(objectPath, key string) string {
+	path := key
+	if objectPath != "" {
+		path = objectPath + "." + key
+	}
+	if n := d.node(path); n != nil && n.Kind == '"' {
+		return n.Text
+	}
+	return ""
+}
+
+// element returns the innermost model element containing the node: an
+// object with an "id" whose key is one of elementKinds, or the root machine
+func (d *document) element(n *node) (path, kind string) {
+	for path := n.Path; ; path = parentPath(path) {
+		if o := d.node(path); o != nil && o.Kind == '{' {
+			if path == "" {
+				return "", "StateMachine"
+			}
+			if kind, known := elementKinds[lastKey(path)]; known && d.node(path+".id") != nil && !inSubmachine(path) {
+				return path, kind
+			}
+		}
+		if path == "" {
+			return "", ""
+		}
+	}
+}
+
+// definition returns the node defining the vertex or event with the given
+// ID
+func (d *document) definition(kind, id string) *node {
+	for _, n := range d.Nodes {
+		if n.Kind != '{' || inSubmachine(n.Path) || d.text(n.Path, "id") != id {
+			continue
+		}
+		switch {
+		case kind == "Vertex" && vertexDefinition.MatchString(n.Path):
+			return n
+		case kind == "Event" && lastKey(n.Path) == "events" && parentPath(n.Path) == "events":
+			return n
+		}
+	}
+	return nil
+}
+
+// Definition resolves transition endpoints to the states and vertices they
+// refer to, and trigger event references to the event catalog
+func (d *document) Definition(pos Position) []Location {
+	n := d.nodeAt(d.offset(pos))
+	if n == nil {
+		return nil
+	}
+	var target *node
+	if n.Key == "event_id" && n.Kind == '"' {
+		target = d.definition("Event", n.Text)
+	} else if path, kind := d.element(n); kind == "Vertex" && !vertexDefinition.MatchString(path) {
+		target = d.definition("Vertex", d.text(path, "id"))
+	} else if kind == "Event" && lastKey(path) == "event" {
+		target = d.definition("Event", d.text(path, "id"))
+	}
+	if target == nil {
+		return nil
+	}
+	return []Location{{URI: d.URI, Range: d.valueRange(d.node(target.Path + ".id"))}}
+}
+
+// Hover summarizes the model element at the position
+func (d *document) Hover(pos Position) *Hover {
+	n := d.nodeAt(d.offset(pos))
+	if n == nil {
+		return nil
+	}
+	path, kind := d.element(n)
+	if kind == "" {
+		return nil
+	}
+	// Endpoints and embedded events describe the element they refer to
+	if (kind == "Vertex" && !vertexDefinition.MatchString(path)) || (kind == "Event" && lastKey(path) == "event") {
+		if target := d.definition(kind, d.text(path, "id")); target != nil {
+			path = target.Path
+		}
+	}
+	if kind == "Vertex" || kind == "State" {
+		kind = vertexKind(d, path)
+	}
+
+	id, name := d.text(path, "id"), d.text(path, "name")
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "**%s** `%s`", kind, id)
+	if name != "" {
+		fmt.Fprintf(&summary, " (%s)", name)
+	}
+
+	switch kind {
+	case "Transition":
+		fmt.Fprintf(&summary, "\n\n`%s` → `%s`", d.text(path+".source", "id"), d.text(path+".target", "id"))
+		if transitionKind := d.text(path, "kind"); transitionKind != "" {
+			fmt.Fprintf(&summary, " (%s)", transitionKind)
+		}
+	case "Behavior", "Constraint":
+		if specification := d.text(path, "specification"); specification != "" {
+			fmt.Fprintf(&summary, "\n\n```%s\n%s\n```", d.text(path, "language"), specification)
+		}
+	}
+	if d.Machine != nil && id != "" && kind != "StateMachine" {
+		counts := make(map[models.UsageKind]int)
+		for _, usage := range models.Usages(d.Machine, id) {
+			counts[usage.Kind]++
+		}
+		switch kind {
+		case "State", "Pseudostate", "FinalState":
+			fmt.Fprintf(&summary, "\n\nOutgoing transitions: %d, incoming transitions: %d",
+				counts[models.UsageTransitionSource], counts[models.UsageTransitionTarget])
+		case "Event":
+			fmt.Fprintf(&summary, "\n\nTriggers: %d", counts[models.UsageTriggerEvent])
+		}
+	}
+
+	hoverRange := d.valueRange(n)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: summary.String()}, Range: &hoverRange}
+}
+
+// vertexKind names the kind of the vertex defined at path
+func vertexKind(d *document, path string) string {
+	switch {
+	case lastKey(path) == "states":
+		return "State"
+	case d.text(path, "type") == "finalstate":
+		return "FinalState"
+	case d.text(path, "type") == "pseudostate" || lastKey(path) == "connection_points":
+		return "Pseudostate"
+	}
+	return "Vertex"
+}
+
+// Rename renames the ID at the position and every reference to it. The
+// rename is refused if the document does not decode or if
+// models.RenameElement refuses it, e.g. because the new ID is taken.
+func (d *document) Rename(pos Position, newID string) (*WorkspaceEdit, error) {
+	n := d.nodeAt(d.offset(pos))
+	if n == nil || n.Kind != '"' || (n.Key != "id" && n.Key != "event_id") || inSubmachine(n.Path) {
+		return nil, fmt.Errorf("no element ID at the position")
+	}
+	if d.Machine == nil {
+		return nil, fmt.Errorf("cannot rename in a document with errors: %v", d.Err)
+	}
+	oldID := n.Text
+	machine, err := models.DecodeStateMachine(strings.NewReader(d.Text), models.ResourceLimits{})
+	if err != nil {
+		return nil, err
+	}
+	if err := models.RenameElement(machine, oldID, newID, ""); err != nil {
+		return nil, err
+	}
+
+	quoted, _ := json.Marshal(newID)
+	var edits []TextEdit
+	for _, candidate := range d.Nodes {
+		if candidate.Kind == '"' && candidate.Text == oldID && (candidate.Key == "id" || candidate.Key == "event_id") && !inSubmachine(candidate.Path) {
+			edits = append(edits, TextEdit{Range: d.valueRange(candidate), NewText: string(quoted)})
+		}
+	}
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{d.URI: edits}}, nil
+}
+
+// Diagnostics reports the syntax error of the document or the findings of
+// validating it with the profile
+func (d *document) Diagnostics(profile *models.ValidationProfile) []Diagnostic {
+	if d.Err != nil {
+		return []Diagnostic{{
+			Range:    d.span(d.Err.Offset, max(d.Err.End, d.Err.Offset+1)),
+			Severity: SeverityError,
+			Code:     "syntax",
+			Source:   diagnosticSource,
+			Message:  d.Err.Message,
+		}}
+	}
+
+	errs := &models.ValidationErrors{}
+	d.Machine.ValidateWithErrors(models.NewValidationContext().WithProfile(profile), errs)
+	errs.Sort()
+	diagnostics := []Diagnostic{}
+	add := func(findings []*models.ValidationError, severity DiagnosticSeverity) {
+		for _, finding := range findings {
+			message := finding.Message
+			if finding.Clause != "" {
+				message += " (" + finding.Clause + ")"
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    d.findingRange(finding),
+				Severity: severity,
+				Code:     finding.Object + "." + finding.Field,
+				Source:   diagnosticSource,
+				Message:  message,
+			})
+		}
+	}
+	add(errs.Errors, SeverityError)
+	add(errs.Warnings, SeverityWarning)
+	add(errs.Infos, SeverityInformation)
+	return diagnostics
+}
+
+// findingRange locates a validation finding: the field it concerns if the
+// document sets it, otherwise the ID of the element, otherwise the element
+func (d *document) findingRange(finding *models.ValidationError) Range {
+	path := jsonPath(finding.Path)
+	for d.node(path) == nil && path != "" {
+		path = parentPath(path)
+	}
+	prefix := path
+	if prefix != "" {
+		prefix += "."
+	}
+	if field := d.node(prefix + jsonKey(finding.Field)); finding.Field != "" && field != nil {
+		return d.valueRange(field)
+	}
+	if id := d.node(prefix + "id"); id != nil {
+		return d.valueRange(id)
+	}
+	if n := d.node(path); n != nil {
+		if n.KeyStart >= 0 {
+			return d.span(n.KeyStart, n.Start)
+		}
+		return d.span(n.Start, n.Start+1)
+	}
+	return d.span(0, 0)
+}
+
+// jsonPath converts a validation path such as ["Regions[0]", "States[1]"]
+// into a document path such as "regions[0].states[1]"
+func jsonPath(segments []string) string {
+	keys := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		name, index, _ := strings.Cut(segment, "[")
+		key := jsonKey(name)
+		if index != "" {
+			key += "[" + index
+		}
+		keys = append(keys, key)
+	}
+	return strings.Join(keys, ".")
+}
+
+var (
+	jsonKeysOnce sync.Once
+	jsonKeys     map[string]string
+)
+
+// jsonKey returns the JSON key of a model field name, e.g. "do_activity" for
+// "DoActivity"
+func jsonKey(field string) string {
+	jsonKeysOnce.Do(func() {
+		jsonKeys = make(map[string]string)
+		seen := make(map[reflect.Type]bool)
+		var collect func(t reflect.Type)
+		collect = func(t reflect.Type) {
+			for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+				t = t.Elem()
+			}
+			if t.Kind() != reflect.Struct || seen[t] {
+				return
+			}
+			seen[t] = true
+			for i := range t.NumField() {
+				f := t.Field(i)
+				if f.Anonymous {
+					collect(f.Type)
+					continue
+				}
+				name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+				if name != "" && name != "-" {
+					if _, exists := jsonKeys[f.Name]; !exists {
+						jsonKeys[f.Name] = name
+					}
+				}
+				collect(f.Type)
+			}
+		}
+		collect(reflect.TypeOf(models.StateMachine{}))
+	})
+	if key, exists := jsonKeys[field]; exists {
+		return key
+	}
+	return field
+}
@@ -0,0 +1,159 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kengibson1111/go-uml-statemachine-models/models"
+)
+
+func TestDocumentDefinition(t *testing.T) {
+	doc := newDocument("file:///door.json", 1, doorMachine)
+	tests := []struct {
+		name   string
+		needle string
+		nth    int
+		want   string // Path of the defining element, "" for none
+	}{
+		{name: "transition source", needle: `"closed"`, nth: 2, want: "regions[0].states[0]"},
+		{name: "transition target", needle: `"opened"`, nth: 1, want: "regions[0].states[1]"},
+		{name: "endpoint name", needle: `"Opened"`, nth: 1, want: "regions[0].states[1]"},
+		{name: "pseudostate source", needle: `"start"`, nth: 1, want: "regions[0].vertices[0]"},
+		{name: "trigger event", needle: `"push"`, nth: 0, want: "events[0]"},
+		{name: "state definition", needle: `"closed"`, nth: 0},
+		{name: "machine name", needle: `"Door"`, nth: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations := doc.Definition(at(t, doc, tt.needle, tt.nth))
+			if tt.want == "" {
+				if len(locations) != 0 {
+					t.Errorf("Definition() = %v, want none", locations)
+				}
+				return
+			}
+			want := doc.valueRange(doc.node(tt.want + ".id"))
+			if len(locations) != 1 || locations[0].Range != want || locations[0].URI != doc.URI {
+				t.Errorf("Definition() = %v, want %v", locations, want)
+			}
+		})
+	}
+}
+
+func TestDocumentHover(t *testing.T) {
+	doc := newDocument("file:///door.json", 1, doorMachine)
+	tests := []struct {
+		name   string
+		needle string
+		nth    int
+		want   string
+	}{
+		{name: "state", needle: `"Closed"`, nth: 0, want: "**State** `closed` (Closed)\n\nOutgoing transitions: 1, incoming transitions: 1"},
+		{name: "endpoint", needle: `"opened"`, nth: 1, want: "**State** `opened` (Opened)\n\nOutgoing transitions: 0, incoming transitions: 1"},
+		{name: "pseudostate", needle: `"start"`, nth: 0, want: "**Pseudostate** `start` (Initial)\n\nOutgoing transitions: 1, incoming transitions: 0"},
+		{name: "transition", needle: `"Open"`, nth: 0, want: "**Transition** `open` (Open)\n\n`closed` → `opened` (external)"},
+		{name: "behavior", needle: `"beep()"`, nth: 0, want: "**Behavior** `beep` (Beep)\n\n```go\nbeep()\n```"},
+		{name: "event", needle: `"signal"`, nth: 0, want: "**Event** `push` (Push)\n\nTriggers: 1"},
+		{name: "machine", needle: `"1.0.0"`, nth: 0, want: "**StateMachine** `door` (Door)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hover := doc.Hover(at(t, doc, tt.needle, tt.nth))
+			if hover == nil || hover.Contents.Value != tt.want {
+				t.Fatalf("Hover() = %+v, want %q", hover, tt.want)
+			}
+			if got := textAt(doc, *hover.Range); got != tt.needle {
+				t.Errorf("Hover() range covers %q, want %q", got, tt.needle)
+			}
+		})
+	}
+}
+
+func TestDocumentRename(t *testing.T) {
+	doc := newDocument("file:///door.json", 1, doorMachine)
+
+	edit, err := doc.Rename(at(t, doc, `"closed"`, 0), "shut")
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	edits := edit.Changes[doc.URI]
+	if len(edits) != 3 {
+		t.Fatalf("Rename() edits = %v, want the definition and two endpoints", edits)
+	}
+	renamed := doc.Text
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		renamed = renamed[:doc.offset(e.Range.Start)] + e.NewText + renamed[doc.offset(e.Range.End):]
+	}
+	if strings.Contains(renamed, `"closed"`) || strings.Count(renamed, `"shut"`) != 3 {
+		t.Errorf("renamed document:\n%s", renamed)
+	}
+	if diagnostics := newDocument(doc.URI, 2, renamed).Diagnostics(nil); len(diagnostics) != 0 {
+		t.Errorf("renamed document has diagnostics: %v", diagnostics)
+	}
+
+	eventEdit, err := doc.Rename(at(t, doc, `"push"`, 0), "press")
+	if err != nil || len(eventEdit.Changes[doc.URI]) != 2 {
+		t.Errorf("Rename(event) = %v, %v, want the reference and the definition", eventEdit, err)
+	}
+
+	failures := []struct {
+		name   string
+		needle string
+		newID  string
+	}{
+		{name: "taken ID", needle: `"closed"`, newID: "opened"},
+		{name: "not an ID", needle: `"Closed"`, newID: "shut"},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := doc.Rename(at(t, doc, tt.needle, 0), tt.newID); err == nil {
+				t.Error("Rename() should fail")
+			}
+		})
+	}
+}
+
+func TestDocumentDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		profile *models.ValidationProfile
+		want    []string // Code and covered text of each diagnostic
+	}{
+		{name: "valid", text: doorMachine},
+		{
+			name: "syntax error",
+			text: `{"id": "door", "regions": [}`,
+			want: []string{"syntax }"},
+		},
+		{
+			name: "type error",
+			text: `{"id": "door", "regions": 3}`,
+			want: []string{"syntax 3"},
+		},
+		{
+			name: "validation error on a field",
+			text: strings.Replace(doorMachine, `"name": "Main",`, `"name": "",`, 1),
+			want: []string{`Region.Name ""`},
+		},
+		{
+			name:    "profile",
+			text:    strings.Replace(doorMachine, `"version": "1.0.0"`, `"version": "v1"`, 1),
+			profile: models.StrictProfile,
+			want:    []string{`StateMachine.Version "v1"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newDocument("file:///door.json", 1, tt.text)
+			var got []string
+			for _, diagnostic := range doc.Diagnostics(tt.profile) {
+				got = append(got, diagnostic.Code+" "+textAt(doc, diagnostic.Range))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Diagnostics() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package lsp
+
+// doorMachine is a small valid model file used by the tests
+const doorMachine = `{
+  "id": "door",
+  "name": "Door",
+  "version": "1.0.0",
+  "regions": [
+    {
+      "id": "main",
+      "name": "Main",
+      "vertices": [
+        {"id": "start", "name": "Initial", "type": "pseudostate"}
+      ],
+      "states": [
+        {"id": "closed", "name": "Closed", "type": "state", "is_simple": true},
+        {"id": "opened", "name": "Opened", "type": "state", "is_simple": true,
+         "entry": {"id": "beep", "name": "Beep", "specification": "beep()", "language": "go"}}
+      ],
+      "transitions": [
+        {"id": "init", "kind": "external",
+         "source": {"id": "start", "name": "Initial", "type": "pseudostate"},
+         "target": {"id": "closed", "name": "Closed", "type": "state"}},
+        {"id": "open", "name": "Open", "kind": "external",
+         "source": {"id": "closed", "name": "Closed", "type": "state"},
+         "target": {"id": "opened", "name": "Opened", "type": "state"},
+         "triggers": [{"id": "on-push", "name": "Push", "event_id": "push"}]}
+      ]
+    }
+  ],
+  "events": [
+    {"id": "push", "name": "Push", "type": "signal"}
+  ],
+  "entities": {},
+  "created_at": "2024-01-01T00:00:00Z"
+}
+`
@@ -0,0 +1,233 @@
+// Package lsp implements a Language Server Protocol backend for state
+// machine model files, the JSON encoding read by models.Load. It offers
+// diagnostics from validation, go-to-definition from transition endpoints
+// to states and from trigger event references to the event catalog, hover
+// summaries of elements and renaming of element IDs.
+//
+// A language server binary only needs to serve standard input and output:
+//
+//	err := lsp.NewServer(models.StrictProfile).Serve(os.Stdin, os.Stdout)
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/kengibson1111/go-uml-statemachine-models/models"
+)
+
+// errNoDocument is returned for requests on documents that are not open
+var errNoDocument = errors.New("document is not open")
+
+// Server is a language server for state machine model files. It keeps the
+// open documents in memory and handles one message at a time.
+type Server struct {
+	profile   *models.ValidationProfile
+	documents map[string]*document
+	out       io.Writer
+	shutdown  bool
+}
+
+// NewServer creates a server that validates documents with the profile; nil
+// uses models.DefaultProfile
+func NewServer(profile *models.ValidationProfile) *Server {
+	return &Server{profile: profile, documents: make(map[string]*document)}
+}
+
+// Serve reads messages from r and writes responses and notifications to w
+// until the client sends exit or closes r. It returns an error if the
+// stream breaks or the client exits without a shutdown request.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.write(errorResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &ResponseError{Code: CodeParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("client exited without shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a message and answers requests
+func (s *Server) handle(req request) error {
+	result, err := s.dispatch(req)
+	if req.ID == nil {
+		// Notifications are not answered; only failures to write matter
+		var rpcErr *ResponseError
+		if err != nil && !errors.As(err, &rpcErr) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		var rpcErr *ResponseError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &ResponseError{Code: CodeRequestFailed, Message: err.Error()}
+		}
+		return s.write(errorResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr})
+	}
+	return s.write(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+// dispatch performs a request or notification and returns its result
+func (s *Server) dispatch(req request) (any, error) {
+	if s.shutdown && req.Method != "shutdown" {
+		return nil, &ResponseError{Code: CodeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		var result InitializeResult
+		result.Capabilities = ServerCapabilities{TextDocumentSync: 1, HoverProvider: true, DefinitionProvider: true, RenameProvider: true}
+		result.ServerInfo.Name = "uml-statemachine-lsp"
+		return result, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.open(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.open(params.TextDocument.URI, params.TextDocument.Version, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		doc, err := s.documentFor(req, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.Definition(params.Position), nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		doc, err := s.documentFor(req, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.Hover(params.Position), nil
+	case "textDocument/rename":
+		var params RenameParams
+		doc, err := s.documentFor(req, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.Rename(params.Position, params.NewName)
+	}
+
+	if req.ID == nil || strings.HasPrefix(req.Method, "$/") {
+		return nil, nil
+	}
+	return nil, &ResponseError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", req.Method)}
+}
+
+// open stores the text of a document and publishes its diagnostics
+func (s *Server) open(uri string, version int, text string) error {
+	doc := newDocument(uri, version, text)
+	s.documents[uri] = doc
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     &doc.Version,
+		Diagnostics: doc.Diagnostics(s.profile),
+	})
+}
+
+// documentFor decodes the params of a request on an open document and
+// returns the document
+func (s *Server) documentFor(req request, params any, id *TextDocumentIdentifier) (*document, error) {
+	if err := decodeParams(req, params); err != nil {
+		return nil, err
+	}
+	doc, exists := s.documents[id.URI]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errNoDocument, id.URI)
+	}
+	return doc, nil
+}
+
+// decodeParams decodes the params of a message
+func decodeParams(req request, params any) error {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return &ResponseError{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params for %s: %v", req.Method, err)}
+	}
+	return nil
+}
+
+// notify sends a notification to the client
+func (s *Server) notify(method string, params any) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends a message with its Content-Length header
+func (s *Server) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// readMessage reads the body of the next message
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,153 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// session frames client messages for Serve
+type session struct {
+	input bytes.Buffer
+	id    int
+}
+
+func (s *session) send(method string, params any, isRequest bool) {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+	if isRequest {
+		s.id++
+		msg["id"] = s.id
+	}
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(&s.input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *session) request(method string, params any) { s.send(method, params, true) }
+func (s *session) notify(method string, params any)  { s.send(method, params, false) }
+
+// received is a message written by the server
+type received struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *ResponseError  `json:"error"`
+}
+
+// readAll parses the messages written by the server
+func readAll(t *testing.T, output []byte) []received {
+	t.Helper()
+	reader := bufio.NewReader(bytes.NewReader(output))
+	var messages []received
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			return messages
+		}
+		var msg received
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid message %s: %v", body, err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func TestServerSession(t *testing.T) {
+	const uri = "file:///door.json"
+	doc := newDocument(uri, 1, doorMachine)
+	position := func(needle string, nth int) map[string]any {
+		return map[string]any{"textDocument": map[string]any{"uri": uri}, "position": at(t, doc, needle, nth)}
+	}
+	broken := strings.Replace(doorMachine, `"name": "Main",`, `"name": "",`, 1)
+
+	var s session
+	s.request("initialize", map[string]any{"capabilities": map[string]any{}})
+	s.notify("initialized", map[string]any{})
+	s.notify("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "version": 1, "languageId": "json", "text": doorMachine}})
+	s.request("textDocument/definition", position(`"opened"`, 1))
+	s.request("textDocument/hover", position(`"Closed"`, 0))
+	s.request("textDocument/rename", map[string]any{"textDocument": map[string]any{"uri": uri}, "position": at(t, doc, `"closed"`, 0), "newName": "shut"})
+	s.request("textDocument/completion", position(`"Closed"`, 0))
+	s.request("textDocument/hover", map[string]any{"textDocument": map[string]any{"uri": "file:///missing.json"}, "position": Position{}})
+	s.notify("textDocument/didChange", map[string]any{"textDocument": map[string]any{"uri": uri, "version": 2}, "contentChanges": []any{map[string]any{"text": broken}}})
+	s.notify("textDocument/didClose", map[string]any{"textDocument": map[string]any{"uri": uri}})
+	s.request("shutdown", nil)
+	s.notify("exit", nil)
+
+	var output bytes.Buffer
+	if err := NewServer(nil).Serve(&s.input, &output); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	messages := readAll(t, output.Bytes())
+	if len(messages) != 10 {
+		t.Fatalf("got %d messages, want 10:\n%s", len(messages), output.String())
+	}
+
+	var initialize InitializeResult
+	if err := json.Unmarshal(messages[0].Result, &initialize); err != nil || !initialize.Capabilities.RenameProvider || initialize.Capabilities.TextDocumentSync != 1 {
+		t.Errorf("initialize result = %s", messages[0].Result)
+	}
+
+	diagnostics := func(msg received) PublishDiagnosticsParams {
+		t.Helper()
+		var params PublishDiagnosticsParams
+		if msg.Method != "textDocument/publishDiagnostics" || json.Unmarshal(msg.Params, &params) != nil {
+			t.Fatalf("message = %+v, want diagnostics", msg)
+		}
+		return params
+	}
+	if opened := diagnostics(messages[1]); len(opened.Diagnostics) != 0 || opened.Version == nil || *opened.Version != 1 {
+		t.Errorf("diagnostics after open = %+v", opened)
+	}
+
+	var locations []Location
+	if err := json.Unmarshal(messages[2].Result, &locations); err != nil || len(locations) != 1 || textAt(doc, locations[0].Range) != `"opened"` {
+		t.Errorf("definition result = %s", messages[2].Result)
+	}
+
+	var hover Hover
+	if err := json.Unmarshal(messages[3].Result, &hover); err != nil || !strings.HasPrefix(hover.Contents.Value, "**State** `closed`") {
+		t.Errorf("hover result = %s", messages[3].Result)
+	}
+
+	var edit WorkspaceEdit
+	if err := json.Unmarshal(messages[4].Result, &edit); err != nil || len(edit.Changes[uri]) != 3 {
+		t.Errorf("rename result = %s", messages[4].Result)
+	}
+
+	if messages[5].Error == nil || messages[5].Error.Code != CodeMethodNotFound {
+		t.Errorf("completion response = %+v, want method not found", messages[5])
+	}
+	if messages[6].Error == nil || messages[6].Error.Code != CodeRequestFailed {
+		t.Errorf("hover on a closed document = %+v, want request failed", messages[6])
+	}
+
+	if changed := diagnostics(messages[7]); len(changed.Diagnostics) != 1 || changed.Diagnostics[0].Code != "Region.Name" {
+		t.Errorf("diagnostics after change = %+v", changed)
+	}
+	if closed := diagnostics(messages[8]); len(closed.Diagnostics) != 0 {
+		t.Errorf("diagnostics after close = %+v", closed)
+	}
+	if string(messages[9].Result) != "null" || messages[9].Error != nil {
+		t.Errorf("shutdown response = %+v", messages[9])
+	}
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	var s session
+	s.request("initialize", map[string]any{})
+	s.notify("exit", nil)
+	if err := NewServer(nil).Serve(&s.input, &bytes.Buffer{}); err == nil {
+		t.Error("Serve() should report an exit without shutdown")
+	}
+}
+
+func TestServerBrokenStream(t *testing.T) {
+	input := strings.NewReader("Content-Length: 100\r\n\r\n{}")
+	if err := NewServer(nil).Serve(input, &bytes.Buffer{}); err == nil {
+		t.Error("Serve() should report a truncated message")
+	}
+}
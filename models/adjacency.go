@@ -0,0 +1,142 @@
+package models
+
+import "slices"
+
+// ResolveOption configures ResolveEndpoints
+type ResolveOption func(*resolveOptions)
+
+// resolveOptions are the settings of ResolveEndpoints
+type resolveOptions struct {
+	adjacency bool
+}
+
+// RecordAdjacency makes ResolveEndpoints record on every vertex the
+// transitions leaving and entering it, so analyses find a vertex's
+// transitions with Vertex.Outgoing and Vertex.Incoming without scanning
+// every region. The lists are not serialized.
+// From then on the edit operations of this package (RetargetTransition,
+// SplitTransition, MergeDuplicateTransitions, DeleteElement,
+// InlineCompositeState, ExtractCompositeState, Sanitize and Repair) keep
+// them up to date, and Clone records them for the copy; after editing
+// transitions directly, call ResolveEndpoints again.
+func RecordAdjacency() ResolveOption {
+	return func(o *resolveOptions) { o.adjacency = true }
+}
+
+// recordAdjacency records the incoming and outgoing transitions of every
+// vertex of the state machine, replacing lists recorded before
+func (sm *StateMachine) recordAdjacency() {
+	sm.adjacency = true
+	for _, vertex := range canonicalVertices(sm) {
+		vertex.incoming, vertex.outgoing = nil, nil
+	}
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		for _, endpoint := range []*Vertex{transition.Source, transition.Target} {
+			if endpoint != nil {
+				endpoint.incoming, endpoint.outgoing = nil, nil
+			}
+		}
+	})
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil {
+			transition.Source.outgoing = append(transition.Source.outgoing, transition)
+		}
+		if transition.Target != nil {
+			transition.Target.incoming = append(transition.Target.incoming, transition)
+		}
+	})
+}
+
+// refreshAdjacency records the transitions of every vertex again after an
+// edit, if ResolveEndpoints recorded them before
+func (sm *StateMachine) refreshAdjacency() {
+	if sm != nil && sm.adjacency {
+		sm.recordAdjacency()
+	}
+}
+
+// clearAdjacency forgets the vertex's recorded transitions
+func (v *Vertex) clearAdjacency() {
+	v.incoming, v.outgoing = nil, nil
+}
+
+// Outgoing returns the transitions leaving the vertex, in model order. They
+// are recorded by ResolveEndpoints with RecordAdjacency; for other machines
+// Outgoing returns nil, and IndexTransitions finds the transitions instead.
+func (v *Vertex) Outgoing() []*Transition {
+	if v == nil {
+		return nil
+	}
+	return slices.Clone(v.outgoing)
+}
+
+// Incoming returns the transitions entering the vertex, in model order,
+// recorded like those of Outgoing
+func (v *Vertex) Incoming() []*Transition {
+	if v == nil {
+		return nil
+	}
+	return slices.Clone(v.incoming)
+}
+
+// TransitionIndex finds the transitions leaving and entering a vertex by
+// the vertex's ID. Unlike Vertex.Outgoing and Vertex.Incoming it needs no
+// resolved endpoints, since copies of a vertex carry its ID. An index is a
+// snapshot: it does not follow later edits of the machine.
+type TransitionIndex struct {
+	outgoing map[string][]*Transition
+	incoming map[string][]*Transition
+}
+
+// IndexTransitions indexes the transitions of all regions of the state
+// machine, including nested regions, in one pass
+func IndexTransitions(sm *StateMachine) *TransitionIndex {
+	index := &TransitionIndex{
+		outgoing: make(map[string][]*Transition),
+		incoming: make(map[string][]*Transition),
+	}
+	if sm == nil {
+		return index
+	}
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil {
+			index.outgoing[transition.Source.ID] = append(index.outgoing[transition.Source.ID], transition)
+		}
+		if transition.Target != nil {
+			index.incoming[transition.Target.ID] = append(index.incoming[transition.Target.ID], transition)
+		}
+	})
+	return index
+}
+
+// Outgoing returns the transitions leaving the vertex with the given ID, in
+// model order
+func (ix *TransitionIndex) Outgoing(vertexID string) []*Transition {
+	if ix == nil {
+		return nil
+	}
+	return slices.Clone(ix.outgoing[vertexID])
+}
+
+// Incoming returns the transitions entering the vertex with the given ID,
+// in model order
+func (ix *TransitionIndex) Incoming(vertexID string) []*Transition {
+	if ix == nil {
+		return nil
+	}
+	return slices.Clone(ix.incoming[vertexID])
+}
+
+// transitionsOf returns the transition index of sm for the rules looking up
+// the transitions of a vertex. Validating a state machine indexes it and
+// each of its submachines once; outside of one, every call indexes sm anew.
+func (vc *ValidationContext) transitionsOf(sm *StateMachine) *TransitionIndex {
+	if index, ok := vc.transitionIndexes[sm]; ok {
+		return index
+	}
+	index := IndexTransitions(sm)
+	if vc.transitionIndexes != nil {
+		vc.transitionIndexes[sm] = index
+	}
+	return index
+}
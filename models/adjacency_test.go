@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// transitionIDs returns the IDs of transitions
+func transitionIDs(transitions []*Transition) []string {
+	return MapSlice(transitions, func(transition *Transition) string { return transition.ID })
+}
+
+// checkAdjacency compares the recorded transitions of the vertex with the
+// given ID against the wanted transition IDs
+func checkAdjacency(t *testing.T, sm *StateMachine, id string, wantIncoming, wantOutgoing []string) {
+	t.Helper()
+	vertex := findVertex(sm, id)
+	if vertex == nil {
+		t.Fatalf("vertex %s not found", id)
+	}
+	if got := transitionIDs(vertex.Incoming()); !reflect.DeepEqual(got, wantIncoming) {
+		t.Errorf("%s incoming = %v, want %v", id, got, wantIncoming)
+	}
+	if got := transitionIDs(vertex.Outgoing()); !reflect.DeepEqual(got, wantOutgoing) {
+		t.Errorf("%s outgoing = %v, want %v", id, got, wantOutgoing)
+	}
+}
+
+func TestRecordAdjacency(t *testing.T) {
+	t.Run("not recorded by default", func(t *testing.T) {
+		sm := createValidStateMachine()
+		if err := ResolveEndpoints(sm); err != nil {
+			t.Fatalf("ResolveEndpoints() error = %v", err)
+		}
+		checkAdjacency(t, sm, "state1", nil, nil)
+	})
+
+	sm := createValidStateMachine()
+	before, _ := json.Marshal(sm)
+	if err := ResolveEndpoints(sm, RecordAdjacency()); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+
+	tests := []struct {
+		id                         string
+		wantIncoming, wantOutgoing []string
+	}{
+		{id: "initial1", wantIncoming: nil, wantOutgoing: []string{"t1"}},
+		{id: "state1", wantIncoming: []string{"t1"}, wantOutgoing: []string{"t2"}},
+		{id: "state2", wantIncoming: []string{"t2"}, wantOutgoing: []string{"t3"}},
+		{id: "final1", wantIncoming: []string{"t3"}, wantOutgoing: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			checkAdjacency(t, sm, tt.id, tt.wantIncoming, tt.wantOutgoing)
+		})
+	}
+
+	t.Run("not serialized", func(t *testing.T) {
+		after, _ := json.Marshal(sm)
+		if string(after) != string(before) {
+			t.Errorf("JSON changed:\n%s\nwant\n%s", after, before)
+		}
+	})
+
+	t.Run("clone records its own", func(t *testing.T) {
+		cloned := sm.Clone()
+		checkAdjacency(t, cloned, "state2", []string{"t2"}, []string{"t3"})
+		if findVertex(cloned, "state2").incoming[0] == findVertex(sm, "state2").incoming[0] {
+			t.Error("clone shares the original's transitions")
+		}
+	})
+}
+
+func TestRecordAdjacency_Edits(t *testing.T) {
+	sm := createValidStateMachine()
+	if err := ResolveEndpoints(sm, RecordAdjacency()); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+
+	if err := RetargetTransition(sm, "t2", "final1"); err != nil {
+		t.Fatalf("RetargetTransition() error = %v", err)
+	}
+	checkAdjacency(t, sm, "state2", nil, []string{"t3"})
+	checkAdjacency(t, sm, "final1", []string{"t2", "t3"}, nil)
+
+	if _, err := SplitTransition(sm, "t1", "choice1"); err != nil {
+		t.Fatalf("SplitTransition() error = %v", err)
+	}
+	checkAdjacency(t, sm, "choice1", []string{"t1"}, []string{"t1_choice1"})
+	checkAdjacency(t, sm, "state1", []string{"t1_choice1"}, []string{"t2"})
+
+	// Direct edits are picked up by resolving again
+	_, t3 := findTransition(sm, "t3")
+	t3.Target = findVertex(sm, "state1")
+	if err := ResolveEndpoints(sm); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+	checkAdjacency(t, sm, "state1", []string{"t3", "t1_choice1"}, []string{"t2"})
+	checkAdjacency(t, sm, "final1", []string{"t2"}, nil)
+}
+
+func TestIndexTransitions(t *testing.T) {
+	sm := createValidStateMachine()
+	index := IndexTransitions(sm)
+
+	tests := []struct {
+		id                         string
+		wantIncoming, wantOutgoing []string
+	}{
+		{id: "initial1", wantOutgoing: []string{"t1"}},
+		{id: "state1", wantIncoming: []string{"t1"}, wantOutgoing: []string{"t2"}},
+		{id: "final1", wantIncoming: []string{"t3"}},
+		{id: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := transitionIDs(index.Incoming(tt.id)); !reflect.DeepEqual(got, tt.wantIncoming) {
+				t.Errorf("Incoming() = %v, want %v", got, tt.wantIncoming)
+			}
+			if got := transitionIDs(index.Outgoing(tt.id)); !reflect.DeepEqual(got, tt.wantOutgoing) {
+				t.Errorf("Outgoing() = %v, want %v", got, tt.wantOutgoing)
+			}
+		})
+	}
+
+	// Endpoints are copies of the declared vertices, which record nothing
+	// until the endpoints are resolved with RecordAdjacency
+	if outgoing := findVertex(sm, "state1").Outgoing(); outgoing != nil {
+		t.Errorf("Vertex.Outgoing() = %v before recording adjacency, want nil", transitionIDs(outgoing))
+	}
+
+	var nilIndex *TransitionIndex
+	var nilVertex *Vertex
+	if nilIndex.Outgoing("state1") != nil || nilVertex.Incoming() != nil || IndexTransitions(nil).Incoming("state1") != nil {
+		t.Error("nil index or vertex returned transitions")
+	}
+}
+
+func TestValidationContext_TransitionsOf(t *testing.T) {
+	sm := createValidStateMachine()
+
+	// Outside of a validation every lookup indexes the machine anew
+	context := NewValidationContext().WithStateMachine(sm)
+	if context.transitionsOf(sm) == context.transitionsOf(sm) {
+		t.Error("transitionsOf() shared an index without a validation")
+	}
+
+	// A validation indexes each machine once for all its rules
+	context.transitionIndexes = make(map[*StateMachine]*TransitionIndex)
+	index := context.WithPath("Regions").transitionsOf(sm)
+	if got := context.WithRegion(sm.Regions[0]).transitionsOf(sm); got != index {
+		t.Error("transitionsOf() indexed the machine again")
+	}
+	if got := transitionIDs(index.Incoming("final1")); !reflect.DeepEqual(got, []string{"t3"}) {
+		t.Errorf("Incoming(final1) = %v, want [t3]", got)
+	}
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// BaselineVersion is the version of the baseline file format
+const BaselineVersion = 1
+
+// Baseline is a set of accepted findings. Exporting the findings of legacy
+// models to a baseline and filtering later validations with it reports only
+// findings that are new since the baseline was taken, so models can be
+// adopted incrementally without fixing every historical warning first.
+//
+// Findings match when they belong to the same state machine and have the
+// same severity, type, object, field and message, and name the same
+// elements (ValidationError.ElementIDs) or, for findings without element
+// IDs, the same path. Matching is by count: a baseline holding a finding
+// once suppresses one occurrence of it.
+type Baseline struct {
+	Version  int               `json:"version"`
+	Findings []BaselineFinding `json:"findings"`
+}
+
+// BaselineFinding is an accepted finding of a baseline
+type BaselineFinding struct {
+	StateMachineID string   `json:"state_machine_id"`
+	Severity       Severity `json:"severity"`
+	Type           string   `json:"type"` // ValidationErrorType.String()
+	Object         string   `json:"object"`
+	Field          string   `json:"field"`
+	Message        string   `json:"message"`
+	Path           string   `json:"path,omitempty"`        // Dot-separated path, kept for readers; ignored when ElementIDs are set
+	ElementIDs     []string `json:"element_ids,omitempty"` // IDs of the elements the finding is about
+}
+
+// NewBaseline creates an empty baseline
+func NewBaseline() *Baseline {
+	return &Baseline{Version: BaselineVersion, Findings: []BaselineFinding{}}
+}
+
+// CaptureBaseline validates the state machines with the given options and
+// returns a baseline accepting all their findings. Options that drop
+// findings, such as MaxErrors and Baseline, are ignored.
+func CaptureBaseline(machines []*StateMachine, opts ...ValidationOption) *Baseline {
+	options := NewValidationOptions(opts...)
+	baseline := NewBaseline()
+	for _, sm := range machines {
+		if sm == nil {
+			continue
+		}
+		errors := &ValidationErrors{}
+		sm.ValidateWithErrors(options.validationContext().WithStateMachine(sm), errors)
+		baseline.Accept(sm.ID, errors)
+	}
+	return baseline
+}
+
+// Accept adds the errors, warnings and infos of a validation of the state
+// machine with the given ID to the baseline
+func (b *Baseline) Accept(stateMachineID string, errors *ValidationErrors) {
+	if errors == nil {
+		return
+	}
+	for _, err := range baselineCandidates(errors) {
+		b.Findings = append(b.Findings, newBaselineFinding(stateMachineID, err))
+	}
+}
+
+// Filter removes the findings accepted by the baseline from a validation of
+// the state machine with the given ID, leaving only new findings, and
+// returns the number of findings it removed
+func (b *Baseline) Filter(stateMachineID string, errors *ValidationErrors) int {
+	if b == nil || errors == nil {
+		return 0
+	}
+	remaining := b.counts(stateMachineID)
+	suppressed := 0
+	keep := func(findings []*ValidationError) []*ValidationError {
+		var kept []*ValidationError
+		for _, err := range findings {
+			if err == nil {
+				kept = append(kept, err)
+				continue
+			}
+			key := newBaselineFinding(stateMachineID, err).key()
+			if remaining[key] > 0 {
+				remaining[key]--
+				suppressed++
+				continue
+			}
+			kept = append(kept, err)
+		}
+		return kept
+	}
+	errors.Errors = keep(errors.Errors)
+	errors.Warnings = keep(errors.Warnings)
+	errors.Infos = keep(errors.Infos)
+	return suppressed
+}
+
+// Stale returns the findings the baseline accepts for the state machine
+// with the given ID that the validation no longer reports, e.g. because
+// they were fixed, so they can be removed from the baseline
+func (b *Baseline) Stale(stateMachineID string, errors *ValidationErrors) []BaselineFinding {
+	if b == nil {
+		return nil
+	}
+	reported := make(map[string]int)
+	if errors != nil {
+		for _, err := range baselineCandidates(errors) {
+			reported[newBaselineFinding(stateMachineID, err).key()]++
+		}
+	}
+	var stale []BaselineFinding
+	for _, finding := range b.Findings {
+		if finding.StateMachineID != stateMachineID {
+			continue
+		}
+		key := finding.key()
+		if reported[key] > 0 {
+			reported[key]--
+			continue
+		}
+		stale = append(stale, finding)
+	}
+	return stale
+}
+
+// WriteJSON writes the baseline as indented JSON, with findings sorted so
+// that baseline files diff cleanly
+func (b *Baseline) WriteJSON(w io.Writer) error {
+	sorted := Baseline{Version: b.Version, Findings: slices.Clone(b.Findings)}
+	if sorted.Version == 0 {
+		sorted.Version = BaselineVersion
+	}
+	if sorted.Findings == nil {
+		sorted.Findings = []BaselineFinding{}
+	}
+	slices.SortStableFunc(sorted.Findings, func(x, y BaselineFinding) int {
+		return strings.Compare(x.StateMachineID+"\x00"+x.key(), y.StateMachineID+"\x00"+y.key())
+	})
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sorted)
+}
+
+// ReadBaseline reads a baseline written by WriteJSON
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var baseline Baseline
+	if err := json.NewDecoder(r).Decode(&baseline); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline: %w", err)
+	}
+	if baseline.Version != BaselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d, want %d", baseline.Version, BaselineVersion)
+	}
+	return &baseline, nil
+}
+
+// WithBaseline drops the findings accepted by baseline from each machine's
+// validation; see Baseline
+func WithBaseline(baseline *Baseline) ValidationOption {
+	return func(o *ValidationOptions) { o.Baseline = baseline }
+}
+
+// counts returns the number of accepted findings of the state machine by
+// key
+func (b *Baseline) counts(stateMachineID string) map[string]int {
+	counts := make(map[string]int)
+	for _, finding := range b.Findings {
+		if finding.StateMachineID == stateMachineID {
+			counts[finding.key()]++
+		}
+	}
+	return counts
+}
+
+// key identifies the finding within its state machine
+func (f BaselineFinding) key() string {
+	location := f.Path
+	if len(f.ElementIDs) > 0 {
+		location = "#" + strings.Join(f.ElementIDs, ",")
+	}
+	return strings.Join([]string{string(f.Severity), f.Type, f.Object, f.Field, f.Message, location}, "\x00")
+}
+
+// newBaselineFinding describes a finding of the state machine for a baseline
+func newBaselineFinding(stateMachineID string, err *ValidationError) BaselineFinding {
+	severity := err.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+	return BaselineFinding{
+		StateMachineID: stateMachineID,
+		Severity:       severity,
+		Type:           err.Type.String(),
+		Object:         err.Object,
+		Field:          err.Field,
+		Message:        err.Message,
+		Path:           strings.Join(err.Path, "."),
+		ElementIDs:     slices.Clone(err.ElementIDs()),
+	}
+}
+
+// baselineCandidates returns the errors, warnings and infos of a validation
+func baselineCandidates(errors *ValidationErrors) []*ValidationError {
+	var findings []*ValidationError
+	for _, err := range slices.Concat(errors.Errors, errors.Warnings, errors.Infos) {
+		if err != nil {
+			findings = append(findings, err)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// newLegacyMachine returns a valid machine with an unnamed state, which
+// validation reports as an error and a best-practice violation
+func newLegacyMachine() *StateMachine {
+	sm := createValidStateMachine()
+	sm.Regions[0].States[0].Name = ""
+	return sm
+}
+
+func TestBaseline_Filter(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(sm *StateMachine)
+		want   []string // Messages of the findings left, errors first
+	}{
+		{
+			name: "unchanged machine reports nothing",
+		},
+		{
+			name: "new findings are reported",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[1].Name = ""
+			},
+			want: []string{
+				"field is required and cannot be empty",
+				"state vertices should have meaningful names (UML best practice)",
+				"target vertex 'state2' of transition 't2' does not match the declared vertex",
+				"source vertex 'state2' of transition 't3' does not match the declared vertex",
+			},
+		},
+		{
+			name: "accepted findings stay suppressed when element indexes shift",
+			modify: func(sm *StateMachine) {
+				region := sm.Regions[0]
+				region.Vertices = append([]*Vertex{{ID: "choice", Name: "Choice", Type: "pseudostate"}}, region.Vertices...)
+				region.States = append([]*State{{Vertex: Vertex{ID: "state0", Name: "State0", Type: "state"}, IsSimple: true}}, region.States...)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline := CaptureBaseline([]*StateMachine{newLegacyMachine()})
+			sm := newLegacyMachine()
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			errors := &ValidationErrors{}
+			sm.ValidateWithErrors(NewValidationContext().WithStateMachine(sm), errors)
+			before := len(baselineCandidates(errors))
+			suppressed := baseline.Filter(sm.ID, errors)
+
+			left := baselineCandidates(errors)
+			if suppressed+len(left) != before {
+				t.Errorf("Filter() suppressed %d and left %d of %d findings", suppressed, len(left), before)
+			}
+			if len(tt.want) == 0 {
+				if len(left) > 0 {
+					t.Errorf("Filter() left %v", left)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(joinFindings(left), want) {
+					t.Errorf("findings left %v do not contain %q", left, want)
+				}
+			}
+			if strings.Contains(joinFindings(left), "'state1'") {
+				t.Errorf("accepted findings about state1 were reported: %v", left)
+			}
+		})
+	}
+}
+
+func joinFindings(findings []*ValidationError) string {
+	return strings.Join(MapSlice(findings, (*ValidationError).Error), "\n")
+}
+
+func TestBaseline_ByCount(t *testing.T) {
+	finding := &ValidationError{Type: ErrorTypeConstraint, Object: "State", Field: "Name", Message: "bad name", Path: []string{"Regions[0]"}}
+	baseline := NewBaseline()
+	baseline.Accept("sm", &ValidationErrors{Errors: []*ValidationError{finding}})
+
+	errors := &ValidationErrors{Errors: []*ValidationError{finding, finding}}
+	if got := baseline.Filter("sm", errors); got != 1 {
+		t.Errorf("Filter() = %d, want 1", got)
+	}
+	if len(errors.Errors) != 1 {
+		t.Errorf("Filter() left %d errors, want 1", len(errors.Errors))
+	}
+
+	other := &ValidationErrors{Errors: []*ValidationError{finding}}
+	if got := baseline.Filter("other", other); got != 0 || len(other.Errors) != 1 {
+		t.Errorf("Filter() of another machine suppressed %d findings", got)
+	}
+
+	var none *Baseline
+	if got := none.Filter("sm", errors); got != 0 {
+		t.Errorf("nil Filter() = %d, want 0", got)
+	}
+}
+
+func TestBaseline_Stale(t *testing.T) {
+	baseline := CaptureBaseline([]*StateMachine{newLegacyMachine()})
+	fixed := createValidStateMachine()
+	errors := &ValidationErrors{}
+	fixed.ValidateWithErrors(NewValidationContext().WithStateMachine(fixed), errors)
+
+	stale := baseline.Stale(fixed.ID, errors)
+	if len(stale) != 4 {
+		t.Fatalf("Stale() = %d findings, want the 4 fixed ones: %v", len(stale), stale)
+	}
+	for _, finding := range stale {
+		if finding.Severity != SeverityError {
+			t.Errorf("Stale() finding %+v, want only the fixed errors", finding)
+		}
+	}
+	if got := baseline.Stale("other", errors); len(got) != 0 {
+		t.Errorf("Stale() of another machine = %v", got)
+	}
+}
+
+func TestBaseline_JSON(t *testing.T) {
+	baseline := CaptureBaseline([]*StateMachine{newLegacyMachine()})
+	var buf bytes.Buffer
+	if err := baseline.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"element_ids": [`) {
+		t.Errorf("WriteJSON() lacks element IDs:\n%s", buf.String())
+	}
+
+	read, err := ReadBaseline(&buf)
+	if err != nil {
+		t.Fatalf("ReadBaseline() error = %v", err)
+	}
+	if len(read.Findings) != len(baseline.Findings) {
+		t.Fatalf("ReadBaseline() = %d findings, want %d", len(read.Findings), len(baseline.Findings))
+	}
+	if err := newLegacyMachine().ValidateWithOptions(WithBaseline(read)); err != nil {
+		t.Errorf("ValidateWithOptions(WithBaseline) = %v, want nil", err)
+	}
+
+	errorTests := []struct {
+		name, input, want string
+	}{
+		{"malformed", "{", "failed to decode baseline"},
+		{"unsupported version", `{"version": 2, "findings": []}`, "unsupported baseline version 2"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadBaseline(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadBaseline() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseline_ValidateAll(t *testing.T) {
+	legacy := newLegacyMachine()
+	broken := createValidStateMachine()
+	broken.ID = "broken"
+	baseline := CaptureBaseline([]*StateMachine{legacy})
+	broken.Regions[0].States[0].Name = ""
+
+	aggregator, err := ValidateAllWithOptions([]*StateMachine{legacy, broken}, WithBaseline(baseline), WithWorkers(1))
+	if err != nil {
+		t.Fatalf("ValidateAllWithOptions() error = %v", err)
+	}
+	results := aggregator.GetResults()
+	if _, ok := results[legacy.ID]; ok {
+		t.Errorf("accepted findings of %s were reported: %v", legacy.ID, results[legacy.ID])
+	}
+	if _, ok := results["broken"]; !ok {
+		t.Errorf("findings of the machine outside the baseline were suppressed")
+	}
+}
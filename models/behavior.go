@@ -36,15 +36,24 @@ func (c *Constraint) ValidateWithErrors(context *ValidationContext, errors *Vali
 
 	// Validate required fields
 	helper.ValidateRequired(c.ID, "ID", "Constraint", context, errors)
+	helper.ValidateID(c.ID, "Constraint", context, errors)
+	helper.ValidateName(c.Name, "Constraint", context, errors)
 	helper.ValidateRequired(c.Specification, "Specification", "Constraint", context, errors)
 }
 
 // Behavior represents a behavior (action/activity)
 type Behavior struct {
-	ID            string `json:"id" validate:"required"`
-	Name          string `json:"name,omitempty"`
-	Specification string `json:"specification" validate:"required"`
-	Language      string `json:"language,omitempty"`
+	ID            string   `json:"id" validate:"required"`
+	Name          string   `json:"name,omitempty"`
+	Specification string   `json:"specification" validate:"required"`
+	Language      string   `json:"language,omitempty"`
+	Entities      []string `json:"entities,omitempty"` // Names of the state machine's entities the behavior uses
+
+	// Sensitive marks a specification holding credentials or proprietary
+	// logic, which must be encrypted (see EncryptSpecifications) before the
+	// behavior is serialized
+	Sensitive bool                    `json:"sensitive,omitempty"`
+	Encrypted *EncryptedSpecification `json:"encrypted,omitempty"` // Envelope-encrypted Specification; the plaintext is not serialized
 }
 
 // Validate validates the Behavior data integrity
@@ -75,7 +84,17 @@ func (b *Behavior) ValidateWithErrors(context *ValidationContext, errors *Valida
 
 	// Validate required fields
 	helper.ValidateRequired(b.ID, "ID", "Behavior", context, errors)
-	helper.ValidateRequired(b.Specification, "Specification", "Behavior", context, errors)
+	helper.ValidateID(b.ID, "Behavior", context, errors)
+	helper.ValidateName(b.Name, "Behavior", context, errors)
+	if !b.hasSpecification() {
+		helper.ValidateRequired(b.Specification, "Specification", "Behavior", context, errors)
+	}
+}
+
+// hasSpecification reports whether the behavior has a specification; an
+// encrypted specification that has not been decrypted counts as one
+func (b *Behavior) hasSpecification() bool {
+	return b.Specification != "" || b.Encrypted != nil
 }
 
 // Effect is an alias for Behavior to maintain semantic clarity
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BoundaryDirection tells whether a transition enters or leaves a state
+type BoundaryDirection string
+
+const (
+	BoundaryEntering BoundaryDirection = "entering"
+	BoundaryLeaving  BoundaryDirection = "leaving"
+)
+
+// BoundaryCrossing is a transition crossing the boundary of a state
+type BoundaryCrossing struct {
+	Transition *Transition
+	Direction  BoundaryDirection
+	Via        *Vertex // Entry or exit point, or connection point reference, the transition crosses through; nil when it targets or leaves the state or its content directly
+}
+
+// String returns a concise one-line description of the crossing
+func (c BoundaryCrossing) String() string {
+	description := fmt.Sprintf("transition '%s' %s", c.Transition.ID, c.Direction)
+	if c.Via != nil {
+		description += fmt.Sprintf(" via '%s'", c.Via.ID)
+	}
+	return description
+}
+
+// BoundaryCrossings returns the transitions of the state machine that cross
+// the boundary of the state with the given ID, in model order: transitions
+// from outside the state to the state itself or to a vertex nested in it at
+// any depth, and transitions in the opposite direction. Transitions that
+// pass through the state's entry and exit points or connection point
+// references name them in Via.
+func BoundaryCrossings(sm *StateMachine, stateID string) ([]BoundaryCrossing, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	state := findState(sm, stateID)
+	if state == nil {
+		return nil, fmt.Errorf("state '%s' not found%s", stateID, didYouMean(stateID, stateIDs(sm)))
+	}
+
+	boundary := newStateBoundary(state)
+	var crossings []BoundaryCrossing
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if crossing, ok := boundary.crossing(transition); ok {
+			crossings = append(crossings, crossing)
+		}
+	})
+	return crossings, nil
+}
+
+// EnteringTransitions returns the transitions entering the state with the
+// given ID from outside; see BoundaryCrossings
+func EnteringTransitions(sm *StateMachine, stateID string) ([]*Transition, error) {
+	return boundaryTransitions(sm, stateID, BoundaryEntering)
+}
+
+// LeavingTransitions returns the transitions leaving the state with the
+// given ID or a vertex nested in it; see BoundaryCrossings
+func LeavingTransitions(sm *StateMachine, stateID string) ([]*Transition, error) {
+	return boundaryTransitions(sm, stateID, BoundaryLeaving)
+}
+
+// boundaryTransitions returns the transitions crossing the state's boundary
+// in the given direction
+func boundaryTransitions(sm *StateMachine, stateID string, direction BoundaryDirection) ([]*Transition, error) {
+	crossings, err := BoundaryCrossings(sm, stateID)
+	if err != nil {
+		return nil, err
+	}
+	var transitions []*Transition
+	for _, crossing := range crossings {
+		if crossing.Direction == direction {
+			transitions = append(transitions, crossing.Transition)
+		}
+	}
+	return transitions, nil
+}
+
+// stateBoundary separates the vertices inside a state from the rest of the
+// state machine
+type stateBoundary struct {
+	state  *State
+	inside map[string]bool    // IDs of the vertices nested in the state at any depth
+	points map[string]*Vertex // Entry and exit points and connection point references of the state, by ID
+}
+
+// newStateBoundary collects the vertices inside the state and on its
+// boundary
+func newStateBoundary(state *State) *stateBoundary {
+	b := &stateBoundary{state: state, inside: make(map[string]bool), points: make(map[string]*Vertex)}
+	walkRegionTree(state.Regions, "", func(region *Region, _ string) {
+		for id := range regionVertices(region) {
+			b.inside[id] = true
+		}
+	})
+	for _, region := range state.Regions {
+		if region == nil {
+			continue
+		}
+		for _, vertex := range contentOf(region).Vertices {
+			if kind := pseudostateKindOf(vertex); kind == PseudostateKindEntryPoint || kind == PseudostateKindExitPoint || isBoundaryPseudostate(vertex) {
+				b.points[vertex.ID] = vertex
+			}
+		}
+	}
+	for _, connection := range state.Connections {
+		if connection == nil {
+			continue
+		}
+		b.points[connection.ID] = &connection.Vertex
+		for _, point := range append(append([]*Pseudostate{}, connection.Entry...), connection.Exit...) {
+			if point != nil {
+				b.points[point.ID] = &point.Vertex
+			}
+		}
+	}
+	for id := range b.points {
+		b.inside[id] = true
+	}
+	return b
+}
+
+// encloses reports whether the vertex is the state itself, nested in it or
+// on its boundary
+func (b *stateBoundary) encloses(vertex *Vertex) bool {
+	return vertex != nil && (vertex.ID == b.state.ID || b.inside[vertex.ID])
+}
+
+// crossing returns the crossing of the boundary by the transition, if it
+// crosses it
+func (b *stateBoundary) crossing(transition *Transition) (BoundaryCrossing, bool) {
+	if transition.Source == nil || transition.Target == nil {
+		return BoundaryCrossing{}, false
+	}
+	sourceInside, targetInside := b.encloses(transition.Source), b.encloses(transition.Target)
+	switch {
+	case !sourceInside && targetInside:
+		return BoundaryCrossing{Transition: transition, Direction: BoundaryEntering, Via: b.points[transition.Target.ID]}, true
+	case sourceInside && !targetInside:
+		return BoundaryCrossing{Transition: transition, Direction: BoundaryLeaving, Via: b.points[transition.Source.ID]}, true
+	default:
+		return BoundaryCrossing{}, false
+	}
+}
+
+// findState returns the state with the given ID, at any depth
+func findState(sm *StateMachine, id string) *State {
+	var found *State
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if found == nil && state != nil && state.ID == id {
+				found = state
+			}
+		}
+	})
+	return found
+}
+
+// stateIDs returns the IDs of the states of the state machine, at any depth
+func stateIDs(sm *StateMachine) map[string]*State {
+	states := make(map[string]*State)
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil {
+				if _, exists := states[state.ID]; !exists {
+					states[state.ID] = state
+				}
+			}
+		}
+	})
+	return states
+}
+
+// validateLocalBoundaries flags local transitions whose source is a
+// composite state but whose target lies outside that state. A local
+// transition does not exit its composite source state, so its target must
+// be the state itself or a vertex nested in it.
+func (sm *StateMachine) validateLocalBoundaries(context *ValidationContext, errors *ValidationErrors) {
+	states := stateIDs(sm)
+	boundaries := make(map[*State]*stateBoundary)
+
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, transition := range region.Transitions {
+			if transition == nil || transition.Kind != TransitionKindLocal || transition.Source == nil || transition.Target == nil {
+				continue
+			}
+			source := states[transition.Source.ID]
+			if source == nil || !source.IsComposite {
+				continue
+			}
+			boundary := boundaries[source]
+			if boundary == nil {
+				boundary = newStateBoundary(source)
+				boundaries[source] = boundary
+			}
+			if boundary.encloses(transition.Target) {
+				continue
+			}
+			errors.AddErrorWithClause(
+				ErrorTypeConstraint,
+				"Transition",
+				"Target",
+				fmt.Sprintf("local transition '%s' leaves its composite source state '%s': target '%s' is not nested in it; use an external transition instead (UML constraint)",
+					transition.ID, source.ID, transition.Target.ID),
+				append(append([]string{}, context.Path...), strings.Split(fmt.Sprintf("%s.Transitions[%d]", path, i), ".")...),
+				ClauseStateIsLocal,
+			)
+		}
+	})
+}
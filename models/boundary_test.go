@@ -0,0 +1,187 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBoundaryCrossings(t *testing.T) {
+	tests := []struct {
+		name    string
+		stateID string
+		modify  func(sm *StateMachine)
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "fork into the regions enters and the completion transition leaves",
+			stateID: "parallel",
+			want:    []string{"transition 'tf1' entering", "transition 'tf2' entering", "transition 'tdone' leaving"},
+		},
+		{
+			name:    "transitions inside the state do not cross its boundary",
+			stateID: "a1",
+			want:    []string{"transition 'tf1' entering", "transition 'ta' leaving"},
+		},
+		{
+			name:    "entry and exit points are named as the crossing's vertex",
+			stateID: "parallel",
+			modify: func(sm *StateMachine) {
+				main, parallel := sm.Regions[0], sm.Regions[0].States[0]
+				regionA := parallel.Regions[0]
+				entry := &Vertex{ID: "in", Name: "entryPoint", Type: "pseudostate"}
+				exit := &Vertex{ID: "out", Name: "exitPoint", Type: "pseudostate"}
+				regionA.Vertices = append(regionA.Vertices, entry, exit)
+				regionA.Transitions = append(regionA.Transitions,
+					&Transition{ID: "tin", Source: entry, Target: &regionA.States[0].Vertex, Kind: TransitionKindExternal},
+					&Transition{ID: "tout", Source: &regionA.States[1].Vertex, Target: exit, Kind: TransitionKindExternal},
+				)
+				main.Transitions = []*Transition{
+					{ID: "tenter", Source: &main.States[1].Vertex, Target: entry, Kind: TransitionKindExternal},
+					{ID: "tleave", Source: exit, Target: &main.States[1].Vertex, Kind: TransitionKindExternal},
+				}
+			},
+			want: []string{"transition 'tenter' entering via 'in'", "transition 'tleave' leaving via 'out'"},
+		},
+		{
+			name:    "connection point references are on the boundary",
+			stateID: "parallel",
+			modify: func(sm *StateMachine) {
+				main, parallel := sm.Regions[0], sm.Regions[0].States[0]
+				cpr := &ConnectionPointReference{Vertex: Vertex{ID: "cpr", Name: "Resume", Type: "connectionpointreference"}}
+				parallel.Connections = append(parallel.Connections, cpr)
+				main.Transitions = []*Transition{
+					{ID: "tresume", Source: &main.States[1].Vertex, Target: &cpr.Vertex, Kind: TransitionKindExternal},
+				}
+			},
+			want: []string{"transition 'tresume' entering via 'cpr'"},
+		},
+		{
+			name:    "unknown state",
+			stateID: "paralel",
+			wantErr: "state 'paralel' not found; did you mean 'parallel'?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createParallelStateMachine()
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			crossings, err := BoundaryCrossings(sm, tt.stateID)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("BoundaryCrossings() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BoundaryCrossings() error = %v", err)
+			}
+			got := MapSlice(crossings, BoundaryCrossing.String)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BoundaryCrossings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := BoundaryCrossings(nil, "parallel"); err == nil {
+		t.Error("BoundaryCrossings(nil) should fail")
+	}
+}
+
+func TestEnteringLeavingTransitions(t *testing.T) {
+	sm := createParallelStateMachine()
+	ids := func(transitions []*Transition) []string {
+		return MapSlice(transitions, func(t *Transition) string { return t.ID })
+	}
+
+	entering, err := EnteringTransitions(sm, "parallel")
+	if err != nil {
+		t.Fatalf("EnteringTransitions() error = %v", err)
+	}
+	if got, want := ids(entering), []string{"tf1", "tf2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EnteringTransitions() = %v, want %v", got, want)
+	}
+
+	leaving, err := LeavingTransitions(sm, "parallel")
+	if err != nil {
+		t.Fatalf("LeavingTransitions() error = %v", err)
+	}
+	if got, want := ids(leaving), []string{"tdone"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LeavingTransitions() = %v, want %v", got, want)
+	}
+
+	if _, err := LeavingTransitions(sm, "missing"); err == nil {
+		t.Error("LeavingTransitions() of an unknown state should fail")
+	}
+}
+
+func TestValidateLocalBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(sm *StateMachine)
+		wantErrs []string
+	}{
+		{
+			name: "external transitions may leave a composite state",
+		},
+		{
+			name: "local transition to a nested state stays within the composite",
+			modify: func(sm *StateMachine) {
+				parallel := sm.Regions[0].States[0]
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, &Transition{
+					ID: "tlocal", Source: &parallel.Vertex, Target: &parallel.Regions[0].States[1].Vertex, Kind: TransitionKindLocal,
+				})
+			},
+		},
+		{
+			name: "local transition leaving its composite source",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Kind = TransitionKindLocal
+			},
+			wantErrs: []string{"local transition 'tdone' leaves its composite source state 'parallel': target 'done' is not nested in it"},
+		},
+		{
+			name: "local transitions from simple states are left to the region checks",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[0].Regions[0].Transitions[0].Kind = TransitionKindLocal
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createParallelStateMachine()
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			errors := &ValidationErrors{}
+			sm.validateLocalBoundaries(NewValidationContext(), errors)
+			if len(errors.Errors) != len(tt.wantErrs) {
+				t.Fatalf("got %d errors, want %d: %v", len(errors.Errors), len(tt.wantErrs), errors.Errors)
+			}
+			for i, want := range tt.wantErrs {
+				if !strings.Contains(errors.Errors[i].Message, want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errors.Errors[i].Message, want)
+				}
+			}
+			if len(tt.wantErrs) > 0 {
+				if got, want := strings.Join(errors.Errors[0].Path, "."), "Regions[0].Transitions[2]"; got != want {
+					t.Errorf("Path = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLocalBoundaries_InValidation(t *testing.T) {
+	sm := createParallelStateMachine()
+	sm.Regions[0].Transitions[2].Kind = TransitionKindLocal
+	err := sm.Validate()
+	if err == nil || !strings.Contains(err.Error(), "leaves its composite source state 'parallel'") {
+		t.Errorf("Validate() = %v, want the local boundary violation", err)
+	}
+}
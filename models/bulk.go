@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// BulkValidationOptions configures ValidateAll
+type BulkValidationOptions struct {
+	Workers  int                // Machines validated concurrently; zero or less uses runtime.GOMAXPROCS(0)
+	FailFast bool               // Stop after the first invalid machine
+	Profile  *ValidationProfile // Profile applied to every machine; nil uses DefaultProfile
+
+	// Metadata is attached to every machine's validation context for custom
+	// rules; see ContextKey
+	Metadata map[string]interface{}
+
+	// RuleProfile records the rule and element timings of all machines; nil
+	// disables profiling
+	RuleProfile *RuleProfile
+
+	// Baseline drops the findings it accepts for each machine, so machines
+	// whose errors are all accepted count as valid; nil reports all findings
+	Baseline *Baseline
+
+	// VertexStore serves the vertex lookups of all machines; nil indexes
+	// each machine in memory
+	VertexStore VertexStore
+}
+
+// ValidateAll validates many state machines concurrently with a pool of
+// workers and aggregates the errors of each invalid machine under its ID,
+// or under "machines[i]" when the ID is empty or repeated. Results are
+// added in input order, so reports do not depend on scheduling.
+//
+// With FailFast, or when ctx is cancelled, no further machines are
+// started; machines that were not validated are left out of the result.
+// ValidateAll returns ctx.Err() if ctx ended the validation early.
+func ValidateAll(ctx context.Context, machines []*StateMachine, opts BulkValidationOptions) (*ValidationResultAggregator, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(machines))
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	results := make([]*ValidationErrors, len(machines))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = validateForBulk(machines[i], opts)
+				if opts.FailFast && results[i].HasErrors() {
+					stop()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range machines {
+		if runCtx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- i:
+		case <-runCtx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	aggregator := NewValidationResultAggregator()
+	seen := make(map[string]bool, len(machines))
+	for i, errors := range results {
+		key := bulkResultKey(machines[i], i, seen)
+		if errors != nil {
+			aggregator.AddResult(key, errors)
+		}
+	}
+	return aggregator, ctx.Err()
+}
+
+// validateForBulk validates one machine of a ValidateAll call
+func validateForBulk(sm *StateMachine, opts BulkValidationOptions) *ValidationErrors {
+	errors := &ValidationErrors{}
+	if sm == nil {
+		errors.AddError(ErrorTypeRequired, "StateMachine", "", "state machine cannot be nil", nil)
+		return errors
+	}
+	context := NewValidationContext().WithStateMachine(sm).WithProfile(opts.Profile).WithRuleProfile(opts.RuleProfile).WithVertexStore(opts.VertexStore)
+	for key, value := range opts.Metadata {
+		context.SetMetadata(key, value)
+	}
+	sm.ValidateWithErrors(context, errors)
+	opts.Baseline.Filter(sm.ID, errors)
+	return errors
+}
+
+// bulkResultKey returns the aggregator key of the i-th machine
+func bulkResultKey(sm *StateMachine, i int, seen map[string]bool) string {
+	if sm == nil || sm.ID == "" || seen[sm.ID] {
+		return fmt.Sprintf("machines[%d]", i)
+	}
+	seen[sm.ID] = true
+	return sm.ID
+}
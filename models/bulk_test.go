@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// bulkMachines returns valid generated machines with invalid ones at the
+// given indices
+func bulkMachines(count int, invalid ...int) []*StateMachine {
+	machines := make([]*StateMachine, count)
+	for i := range machines {
+		machines[i] = GenerateStateMachine(uint64(i), GeneratorOptions{})
+		if slices.Contains(invalid, i) {
+			machines[i].Name = ""
+		}
+	}
+	return machines
+}
+
+func TestValidateAll(t *testing.T) {
+	machines := bulkMachines(20, 3, 11)
+	machines = append(machines, nil, &StateMachine{Name: "no id"}, machines[3])
+
+	for _, workers := range []int{0, 1, 4, 100} {
+		aggregator, err := ValidateAll(context.Background(), machines, BulkValidationOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("ValidateAll(workers=%d) error = %v", workers, err)
+		}
+		var keys []string
+		for key := range aggregator.GetResults() {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		want := []string{"generated-11", "generated-3", "machines[20]", "machines[21]", "machines[22]"}
+		if !reflect.DeepEqual(keys, want) {
+			t.Errorf("ValidateAll(workers=%d) results for %v, want %v", workers, keys, want)
+		}
+	}
+
+	aggregator, err := ValidateAll(context.Background(), nil, BulkValidationOptions{})
+	if err != nil || aggregator.HasErrors() {
+		t.Errorf("ValidateAll(nil) = %v, %v", aggregator.GetResults(), err)
+	}
+}
+
+func TestValidateAll_MatchesValidate(t *testing.T) {
+	machines := []*StateMachine{newPlayerMachine(), createParallelStateMachine(), createValidStateMachine()}
+	aggregator, err := ValidateAll(context.Background(), machines, BulkValidationOptions{})
+	if err != nil {
+		t.Fatalf("ValidateAll() error = %v", err)
+	}
+
+	for _, sm := range machines {
+		t.Run(sm.ID, func(t *testing.T) {
+			var want []string
+			if err := sm.Validate(); err != nil {
+				var validationErrors *ValidationErrors
+				if !errors.As(err, &validationErrors) {
+					t.Fatalf("Validate() error = %v, want *ValidationErrors", err)
+				}
+				for _, finding := range validationErrors.Errors {
+					want = append(want, finding.Error())
+				}
+			}
+			var got []string
+			if result := aggregator.GetResults()[sm.ID]; result != nil {
+				for _, finding := range result.Errors {
+					got = append(got, finding.Error())
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("ValidateAll() findings =\n%v\nwant the findings of Validate()\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateAll_Profile(t *testing.T) {
+	machines := bulkMachines(3)
+	aggregator, err := ValidateAll(context.Background(), machines, BulkValidationOptions{
+		Profile: &ValidationProfile{Name: "semver", Versions: StrictVersionPolicy},
+	})
+	if err != nil {
+		t.Fatalf("ValidateAll() error = %v", err)
+	}
+	// Generated machines use version "1.0", which strict versioning rejects
+	if got := len(aggregator.GetResults()); got != 3 {
+		t.Errorf("ValidateAll() reported %d invalid machines, want 3", got)
+	}
+}
+
+func TestValidateAll_FailFast(t *testing.T) {
+	machines := bulkMachines(200, 0)
+	aggregator, err := ValidateAll(context.Background(), machines, BulkValidationOptions{Workers: 1, FailFast: true})
+	if err != nil {
+		t.Fatalf("ValidateAll() error = %v", err)
+	}
+	if _, found := aggregator.GetResults()["generated-0"]; !found || len(aggregator.GetResults()) != 1 {
+		t.Errorf("ValidateAll() results = %v, want only generated-0", aggregator.GetResults())
+	}
+}
+
+func TestValidateAll_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	aggregator, err := ValidateAll(ctx, bulkMachines(50, 10, 20, 30, 40), BulkValidationOptions{Workers: 2})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ValidateAll() error = %v, want context.Canceled", err)
+	}
+	if aggregator == nil {
+		t.Fatal("ValidateAll() should return the partial results")
+	}
+	if aggregator.HasErrors() {
+		t.Errorf("ValidateAll() validated machines despite the cancelled context: %v", aggregator.GetResults())
+	}
+}
@@ -0,0 +1,599 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// BundleVersion is the version of the bundle format
+const BundleVersion = 1
+
+// bundleManifestName is the archive path of a bundle's manifest
+const bundleManifestName = "manifest.json"
+
+// Bundle is a complete model shipped as one artifact: a main state machine,
+// the submachines its states reference at any depth, the descriptors and
+// optionally the payloads of their entities, and their layouts.
+//
+// Save writes a bundle as a zip archive holding a manifest, one JSON
+// document per machine, one per layout and the entity payloads. Machine
+// documents reference submachines by ID and version, like
+// ModelRepository.ResolveSubmachines; LoadBundle resolves the references
+// across documents again, and Validate reports those that do not resolve
+// together with the findings of the main machine.
+type Bundle struct {
+	Main        *StateMachine
+	Submachines []*StateMachine // Distinct machines referenced by Main's states and theirs, in discovery order
+	Entities    []BundleEntity  // Entities of the bundled machines
+
+	// ContentAddressed pins submachines by content: Save records the digest
+	// of each submachine's document on the states referencing it
+	// (State.SubmachineDigest), and LoadBundle resolves those references by
+	// digest and fails if no document has the recorded bytes, so a parent
+	// runs against the exact submachine it was validated with. Bundles
+	// loaded with pinned references are content-addressed.
+	ContentAddressed bool
+
+	digests    map[*StateMachine]string // SHA-256 of each machine's document, as saved or loaded
+	payloads   map[string][]byte        // Entity payloads by entity path
+	references []bundleReference        // Submachine references as loaded, checked by Validate
+	documents  map[*StateMachine]string // Archive path of each machine
+	extra      []string                 // Documents of the manifest no reference reaches
+}
+
+// BundleEntity describes an entity of a bundled state machine
+type BundleEntity struct {
+	Machine string `json:"machine"` // ID of the state machine whose Entities name the entity
+	Name    string `json:"name"`
+	Path    string `json:"path"`             // Path of the payload in the machine's entity store
+	Size    int64  `json:"size,omitempty"`   // Payload size, when the bundle includes the payload
+	SHA256  string `json:"sha256,omitempty"` // Hex-encoded payload digest, when the bundle includes the payload
+}
+
+// bundleManifest is the table of contents of a bundle archive
+type bundleManifest struct {
+	Version  int               `json:"version"`
+	Main     string            `json:"main"` // Archive path of the main machine's document
+	Machines []bundleDocument  `json:"machines"`
+	Entities []BundleEntity    `json:"entities,omitempty"`
+	Payloads map[string]string `json:"payloads,omitempty"` // Archive paths of entity payloads by entity path
+}
+
+// bundleDocument locates a machine's documents in a bundle archive
+type bundleDocument struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256,omitempty"` // Hex-encoded digest of the document, verified by LoadBundle
+	Layout  string `json:"layout,omitempty"` // Archive path of the machine's layout document
+}
+
+// bundleReference is a state's reference to a submachine document
+type bundleReference struct {
+	document, path string // Document of the referencing machine and path of the state in it
+	state          *State
+	id, version    string
+	resolved       bool
+}
+
+// NewBundle collects main and the submachines its states reference into a
+// bundle, with descriptors of their entities. It fails if two different
+// machines have the same ID and version.
+func NewBundle(main *StateMachine) (*Bundle, error) {
+	if main == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	b := &Bundle{Main: main, payloads: make(map[string][]byte)}
+	seen := map[string]*StateMachine{bundleKey(main): main}
+	queue := []*StateMachine{main}
+	for len(queue) > 0 {
+		machine := queue[0]
+		queue = queue[1:]
+		for _, name := range machine.EntityNames() {
+			b.Entities = append(b.Entities, BundleEntity{Machine: machine.ID, Name: name, Path: machine.Entities[name]})
+		}
+		var err error
+		walkRegionTree(machine.Regions, "", func(region *Region, _ string) {
+			for _, state := range region.States {
+				if err != nil || state == nil || state.Submachine == nil {
+					continue
+				}
+				sub := state.Submachine
+				key := bundleKey(sub)
+				if existing, ok := seen[key]; ok {
+					if existing != sub {
+						err = fmt.Errorf("bundle contains two different state machines '%s' version '%s'", sub.ID, sub.Version)
+					}
+					continue
+				}
+				seen[key] = sub
+				b.Submachines = append(b.Submachines, sub)
+				queue = append(queue, sub)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Machines returns the main machine followed by the submachines
+func (b *Bundle) Machines() []*StateMachine {
+	return append([]*StateMachine{b.Main}, b.Submachines...)
+}
+
+// Digest returns the hex-encoded SHA-256 digest of the document of a bundled
+// machine, as last saved or loaded, or "" if it has none yet
+func (b *Bundle) Digest(machine *StateMachine) string {
+	return b.digests[machine]
+}
+
+// IncludePayloads loads the payload of every entity through resolver so
+// that Save stores it in the bundle, and records its size and digest
+func (b *Bundle) IncludePayloads(resolver EntityResolver) error {
+	if resolver == nil {
+		return fmt.Errorf("no entity resolver")
+	}
+	if b.payloads == nil {
+		b.payloads = make(map[string][]byte)
+	}
+	for i := range b.Entities {
+		entity := &b.Entities[i]
+		payload, err := resolver.Load(entity.Path)
+		if err != nil {
+			return fmt.Errorf("entity '%s' of state machine '%s' at '%s': %w", entity.Name, entity.Machine, entity.Path, err)
+		}
+		b.payloads[entity.Path] = payload
+		entity.Size, entity.SHA256 = bundleDigest(payload)
+	}
+	return nil
+}
+
+// EntityResolver returns a resolver serving the entity payloads included
+// in the bundle, so ResolveEntity and profile entity checks work on a
+// loaded bundle without the original store
+func (b *Bundle) EntityResolver() EntityResolver {
+	return bundleEntityResolver(maps.Clone(b.payloads))
+}
+
+// Save writes the bundle as a zip archive. Entries are written without
+// timestamps in a fixed order, so saving the same bundle twice produces the
+// same bytes.
+func (b *Bundle) Save(w io.Writer) error {
+	if b.Main == nil {
+		return fmt.Errorf("bundle has no main state machine")
+	}
+	manifest := bundleManifest{Version: BundleVersion, Entities: b.Entities}
+	type entry struct {
+		name string
+		data []byte
+	}
+	var entries []entry
+
+	// Documents are encoded submachines first, so pinned references can
+	// record the digests of the documents they reference
+	encoded := make(map[*StateMachine][]byte)
+	digests := make(map[*StateMachine]string)
+	encoding := make(map[*StateMachine]bool)
+	var encodeErr error
+	var encode func(machine *StateMachine) string
+	encode = func(machine *StateMachine) string {
+		if digest, done := digests[machine]; done || encoding[machine] {
+			return digest // A reference closing a submachine cycle cannot be pinned
+		}
+		encoding[machine] = true
+		var pin func(*StateMachine) string
+		if b.ContentAddressed {
+			pin = encode
+		}
+		data, err := json.MarshalIndent(bundleMachineDocument(machine, pin), "", "  ")
+		if err != nil && encodeErr == nil {
+			encodeErr = fmt.Errorf("failed to encode state machine '%s': %w", machine.ID, err)
+		}
+		encoded[machine] = data
+		_, digests[machine] = bundleDigest(data)
+		return digests[machine]
+	}
+
+	documents := make(map[string]string) // Archive path by bundle key
+	for _, machine := range b.Machines() {
+		path := "machines/" + bundleFileName(machine) + ".json"
+		if _, exists := documents[bundleKey(machine)]; exists {
+			return fmt.Errorf("bundle contains two different state machines '%s' version '%s'", machine.ID, machine.Version)
+		}
+		documents[bundleKey(machine)] = path
+		digest := encode(machine)
+		if encodeErr != nil {
+			return encodeErr
+		}
+		document := bundleDocument{ID: machine.ID, Version: machine.Version, Path: path, SHA256: digest}
+		entries = append(entries, entry{path, encoded[machine]})
+		if machine.Layout != nil {
+			document.Layout = "layouts/" + bundleFileName(machine) + ".json"
+			data, err := json.MarshalIndent(machine.Layout, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode the layout of state machine '%s': %w", machine.ID, err)
+			}
+			entries = append(entries, entry{document.Layout, data})
+		}
+		manifest.Machines = append(manifest.Machines, document)
+	}
+	manifest.Main = manifest.Machines[0].Path
+	b.digests = digests
+
+	for _, path := range slices.Sorted(maps.Keys(b.payloads)) {
+		if manifest.Payloads == nil {
+			manifest.Payloads = make(map[string]string)
+		}
+		manifest.Payloads[path] = "entities/" + bundlePathEscape(path)
+		entries = append(entries, entry{manifest.Payloads[path], b.payloads[path]})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle manifest: %w", err)
+	}
+	entries = append([]entry{{bundleManifestName, data}}, entries...)
+
+	archive := zip.NewWriter(w)
+	for _, e := range entries {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate})
+		if err != nil {
+			return fmt.Errorf("failed to write bundle entry '%s': %w", e.name, err)
+		}
+		if _, err := file.Write(e.data); err != nil {
+			return fmt.Errorf("failed to write bundle entry '%s': %w", e.name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// LoadBundle reads a bundle written by Save and resolves the submachine
+// references of its machines against its documents. It fails if the
+// archive, its manifest or a document cannot be read; references that do
+// not resolve are left in place and reported by Validate. The bundle is
+// read with DefaultResourceLimits; see LoadBundleWithLimits.
+func LoadBundle(r io.ReaderAt, size int64) (*Bundle, error) {
+	return LoadBundleWithLimits(r, size, DefaultResourceLimits)
+}
+
+// LoadBundleWithLimits is LoadBundle enforcing the given limits. Every
+// archive entry, payloads included, may hold at most MaxJSONBytes once
+// decompressed: entries declaring a larger size are rejected before they
+// are read, and reading stops at the limit when the declared size is wrong.
+// Each machine is decoded with the limits, and the main machine is checked
+// with ResourceLimits.Check once its submachines are resolved. Zero limits
+// are not enforced, so use them only for trusted bundles.
+func LoadBundleWithLimits(r io.ReaderAt, size int64, limits ResourceLimits) (*Bundle, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	read := func(name string) ([]byte, error) {
+		file := files[name]
+		if file == nil {
+			return nil, fmt.Errorf("bundle entry '%s' not found", name)
+		}
+		maxBytes := limits.MaxJSONBytes
+		if maxBytes > 0 && file.UncompressedSize64 > uint64(maxBytes) {
+			return nil, fmt.Errorf("bundle entry '%s': %w", name, &ResourceLimitError{Limit: LimitJSONBytes, Max: maxBytes, Actual: int64(min(file.UncompressedSize64, math.MaxInt64))})
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bundle entry '%s': %w", name, err)
+		}
+		defer rc.Close()
+		var entry io.Reader = rc
+		if maxBytes > 0 {
+			entry = io.LimitReader(rc, maxBytes+1)
+		}
+		data, err := io.ReadAll(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle entry '%s': %w", name, err)
+		}
+		if maxBytes > 0 && int64(len(data)) > maxBytes {
+			return nil, fmt.Errorf("bundle entry '%s': %w", name, &ResourceLimitError{Limit: LimitJSONBytes, Max: maxBytes, Actual: int64(len(data))})
+		}
+		return data, nil
+	}
+
+	data, err := read(bundleManifestName)
+	if err != nil {
+		return nil, err
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle manifest: %w", err)
+	}
+	if manifest.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d, want %d", manifest.Version, BundleVersion)
+	}
+
+	b := &Bundle{Entities: manifest.Entities, payloads: make(map[string][]byte), documents: make(map[*StateMachine]string)}
+	machines := make(map[string]*StateMachine, len(manifest.Machines)) // By bundle key
+	byPath := make(map[string]*StateMachine, len(manifest.Machines))
+	byDigest := make(map[string]*StateMachine, len(manifest.Machines))
+	b.digests = make(map[*StateMachine]string, len(manifest.Machines))
+	for _, document := range manifest.Machines {
+		data, err := read(document.Path)
+		if err != nil {
+			return nil, err
+		}
+		_, digest := bundleDigest(data)
+		if document.SHA256 != "" && document.SHA256 != digest {
+			return nil, fmt.Errorf("bundle entry '%s' does not match its digest %s", document.Path, document.SHA256)
+		}
+		machine, err := DecodeStateMachine(bytes.NewReader(data), limits)
+		if err != nil {
+			return nil, fmt.Errorf("bundle entry '%s': %w", document.Path, err)
+		}
+		if document.Layout != "" {
+			data, err := read(document.Layout)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &machine.Layout); err != nil {
+				return nil, fmt.Errorf("failed to decode bundle entry '%s': %w", document.Layout, err)
+			}
+		}
+		machines[bundleKey(machine)] = machine
+		byPath[document.Path] = machine
+		byDigest[digest] = machine
+		b.documents[machine] = document.Path
+		b.digests[machine] = digest
+	}
+	b.Main = byPath[manifest.Main]
+	if b.Main == nil {
+		return nil, fmt.Errorf("bundle main document '%s' is not listed in the manifest", manifest.Main)
+	}
+
+	// Resolve references breadth-first from the main machine, so submachines
+	// are listed in the order NewBundle discovers them. Pinned references
+	// resolve by digest and must match a document.
+	var resolveErr error
+	reached := map[*StateMachine]bool{b.Main: true}
+	queue := []*StateMachine{b.Main}
+	for len(queue) > 0 && resolveErr == nil {
+		machine := queue[0]
+		queue = queue[1:]
+		walkRegionTree(machine.Regions, "", func(region *Region, path string) {
+			for i, state := range region.States {
+				if resolveErr != nil || state == nil || state.Submachine == nil {
+					continue
+				}
+				reference := bundleReference{
+					document: b.documents[machine],
+					path:     fmt.Sprintf("%s.States[%d]", path, i),
+					state:    state,
+					id:       state.Submachine.ID,
+					version:  state.Submachine.Version,
+				}
+				resolved := machines[bundleKey(state.Submachine)]
+				if state.SubmachineDigest != "" {
+					b.ContentAddressed = true
+					resolved = byDigest[state.SubmachineDigest]
+					if resolved == nil || resolved.ID != reference.id || resolved.Version != reference.version {
+						resolveErr = fmt.Errorf("state '%s' in bundle entry '%s' pins state machine '%s' version '%s' to digest %s, which no document in the bundle matches",
+							state.ID, reference.document, reference.id, reference.version, state.SubmachineDigest)
+						return
+					}
+				}
+				if resolved != nil {
+					state.Submachine = resolved
+					reference.resolved = true
+					if !reached[resolved] {
+						reached[resolved] = true
+						b.Submachines = append(b.Submachines, resolved)
+						queue = append(queue, resolved)
+					}
+				}
+				b.references = append(b.references, reference)
+			}
+		})
+	}
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	if err := limits.Check(b.Main); err != nil {
+		return nil, fmt.Errorf("bundle main document '%s': %w", manifest.Main, err)
+	}
+	for _, document := range manifest.Machines {
+		if !reached[byPath[document.Path]] {
+			b.extra = append(b.extra, document.Path)
+		}
+	}
+
+	for path, name := range manifest.Payloads {
+		payload, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		b.payloads[path] = payload
+	}
+	return b, nil
+}
+
+// Validate validates the bundle; see ValidateWithErrors
+func (b *Bundle) Validate() error {
+	errors := &ValidationErrors{}
+	b.ValidateWithErrors(NewValidationContext(), errors)
+	return errors.ToError()
+}
+
+// ValidateWithErrors checks the references between the bundle's documents
+// and validates the main machine, which validates the submachines it
+// reaches. Submachine references must resolve to a bundled machine, entity
+// descriptors must name entities of bundled machines, included payloads
+// must match their recorded size and digest, and machines no reference
+// reaches are reported as warnings. Cross-document errors have paths
+// starting with the document they were found in; the findings of the
+// machines have the paths of a standalone validation.
+func (b *Bundle) ValidateWithErrors(context *ValidationContext, errors *ValidationErrors) {
+	if context == nil {
+		context = NewValidationContext()
+	}
+	if errors == nil {
+		return
+	}
+	if b.Main == nil {
+		errors.AddError(ErrorTypeRequired, "Bundle", "Main", "bundle has no main state machine", context.Path)
+		return
+	}
+
+	for _, reference := range b.references {
+		if reference.resolved {
+			continue
+		}
+		errors.AddErrorWithContext(
+			ErrorTypeReference,
+			"State",
+			"Submachine",
+			fmt.Sprintf("state '%s' references state machine '%s' version '%s', which is not in the bundle", reference.state.ID, reference.id, reference.version),
+			append(append([]string{}, context.Path...), append([]string{reference.document}, strings.Split(reference.path, ".")...)...),
+			map[string]interface{}{ElementIDsContextKey: []string{reference.state.ID}},
+		)
+	}
+
+	machines := make(map[string]*StateMachine)
+	for _, machine := range b.Machines() {
+		if _, exists := machines[machine.ID]; !exists {
+			machines[machine.ID] = machine
+		}
+	}
+	entitiesPath := append(append([]string{}, context.Path...), bundleManifestName)
+	for i, entity := range b.Entities {
+		path := append(slices.Clone(entitiesPath), fmt.Sprintf("Entities[%d]", i))
+		machine := machines[entity.Machine]
+		switch {
+		case machine == nil:
+			errors.AddError(ErrorTypeReference, "Bundle", "Entities",
+				fmt.Sprintf("entity '%s' belongs to state machine '%s', which is not in the bundle", entity.Name, entity.Machine), path)
+			continue
+		case machine.Entities[entity.Name] != entity.Path:
+			errors.AddError(ErrorTypeReference, "Bundle", "Entities",
+				fmt.Sprintf("entity '%s' at '%s' is not an entity of state machine '%s'", entity.Name, entity.Path, entity.Machine), path)
+			continue
+		}
+		payload, included := b.payloads[entity.Path]
+		if !included {
+			if entity.SHA256 != "" {
+				errors.AddError(ErrorTypeReference, "Bundle", "Entities",
+					fmt.Sprintf("payload of entity '%s' at '%s' is missing from the bundle", entity.Name, entity.Path), path)
+			}
+			continue
+		}
+		if size, digest := bundleDigest(payload); entity.SHA256 != "" && (size != entity.Size || digest != entity.SHA256) {
+			errors.AddError(ErrorTypeInvalid, "Bundle", "Entities",
+				fmt.Sprintf("payload of entity '%s' at '%s' does not match its recorded size and digest", entity.Name, entity.Path), path)
+		}
+	}
+
+	for _, document := range b.extra {
+		errors.AddWarning(ErrorTypeReference, "Bundle", "Machines",
+			fmt.Sprintf("document '%s' is not reachable from the main state machine", document),
+			append(append([]string{}, context.Path...), document))
+	}
+
+	b.Main.ValidateWithErrors(context.WithStateMachine(b.Main), errors)
+}
+
+// bundleMachineDocument returns a copy of the machine for its bundle
+// document: submachines are replaced by references carrying their ID and
+// version, and the digest pin returns for them when pin is not nil, and the
+// layout is stored separately
+func bundleMachineDocument(machine *StateMachine, pin func(*StateMachine) string) *StateMachine {
+	document := *machine
+	document.Layout = nil
+	document.Regions = cloneRegionsReferencingSubmachines(machine.Regions, pin)
+	return &document
+}
+
+// cloneRegionsReferencingSubmachines copies the regions, replacing the
+// submachines of their states, at any depth, by references pinned to the
+// digests pin returns, or unpinned when pin is nil
+func cloneRegionsReferencingSubmachines(regions []*Region, pin func(*StateMachine) string) []*Region {
+	cloner := newModelCloner()
+	submachines := make(map[*StateMachine]*StateMachine) // Originals by reference
+	walkRegionTree(regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil && state.Submachine != nil {
+				reference := &StateMachine{ID: state.Submachine.ID, Version: state.Submachine.Version}
+				cloner.seen[state.Submachine] = reference
+				submachines[reference] = state.Submachine
+			}
+		}
+	})
+	cloned := cloneSlice(regions, cloner.region)
+	walkRegionTree(cloned, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state == nil || state.Submachine == nil {
+				continue
+			}
+			state.SubmachineDigest = ""
+			if pin != nil {
+				state.SubmachineDigest = pin(submachines[state.Submachine])
+			}
+		}
+	})
+	return cloned
+}
+
+// bundleKey identifies a machine within a bundle
+func bundleKey(machine *StateMachine) string {
+	return machine.ID + "\x00" + machine.Version
+}
+
+// bundleFileName returns the file name, without extension, of a machine's
+// documents
+func bundleFileName(machine *StateMachine) string {
+	name := bundlePathEscape(machine.ID)
+	if machine.Version != "" {
+		name += "@" + bundlePathEscape(machine.Version)
+	}
+	return name
+}
+
+// bundlePathEscape escapes a string for use as one archive path segment
+func bundlePathEscape(s string) string {
+	return url.PathEscape(s)
+}
+
+// bundleDigest returns the size and hex-encoded SHA-256 digest of a
+// document or payload
+func bundleDigest(payload []byte) (int64, string) {
+	sum := sha256.Sum256(payload)
+	return int64(len(payload)), hex.EncodeToString(sum[:])
+}
+
+// bundleEntityResolver serves entity payloads by entity path
+type bundleEntityResolver map[string][]byte
+
+// Exists reports whether the bundle includes the payload at path
+func (r bundleEntityResolver) Exists(path string) (bool, error) {
+	_, ok := r[path]
+	return ok, nil
+}
+
+// Load returns the payload at path
+func (r bundleEntityResolver) Load(path string) ([]byte, error) {
+	payload, ok := r[path]
+	if !ok {
+		return nil, fmt.Errorf("entity payload '%s' is not in the bundle", path)
+	}
+	return slices.Clone(payload), nil
+}
@@ -0,0 +1,396 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// newBundleFixture returns machine A, which includes B, which includes C;
+// A has an entity and B a layout
+func newBundleFixture(t *testing.T) (*StateMachine, fstest.MapFS) {
+	t.Helper()
+	a, b, c := newSubmachineFixture("A"), newSubmachineFixture("B"), newSubmachineFixture("C")
+	includeSubmachine(a, b)
+	includeSubmachine(b, c)
+	for _, sm := range []*StateMachine{a, b, c} {
+		sm.Entities = nil
+	}
+	a.Regions[0].Transitions[1].Entities = []string{"order"}
+	if err := a.AddEntity("order", "payloads/order.json"); err != nil {
+		t.Fatalf("AddEntity() error = %v", err)
+	}
+	b.Layout = &Layout{Direction: LayoutDirection("TB"), Width: 200, Height: 100, Vertices: map[string]Box{"state1": {X: 10, Y: 20, Width: 80, Height: 40}}}
+	return a, fstest.MapFS{"payloads/order.json": {Data: []byte(`{"total": 42}`)}}
+}
+
+// saveBundle saves a bundle of main with its entity payloads
+func saveBundle(t *testing.T, main *StateMachine, store fstest.MapFS) []byte {
+	t.Helper()
+	bundle, err := NewBundle(main)
+	if err != nil {
+		t.Fatalf("NewBundle() error = %v", err)
+	}
+	if err := bundle.IncludePayloads(FSEntityResolver{FS: store}); err != nil {
+		t.Fatalf("IncludePayloads() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := bundle.Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func loadBundle(t *testing.T, data []byte) *Bundle {
+	t.Helper()
+	bundle, err := LoadBundle(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadBundle() error = %v", err)
+	}
+	return bundle
+}
+
+func TestBundle_RoundTrip(t *testing.T) {
+	main, store := newBundleFixture(t)
+	data := saveBundle(t, main, store)
+	if again := saveBundle(t, main, store); !bytes.Equal(data, again) {
+		t.Error("saving the same bundle twice produced different archives")
+	}
+
+	bundle := loadBundle(t, data)
+	ids := MapSlice(bundle.Machines(), func(sm *StateMachine) string { return sm.ID })
+	if want := []string{"A", "B", "C"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Machines() = %v, want %v", ids, want)
+	}
+	a, b, c := bundle.Main, bundle.Submachines[0], bundle.Submachines[1]
+	if a.Regions[0].States[1].Submachine != b || b.Regions[0].States[1].Submachine != c {
+		t.Error("submachine references were not resolved to the bundled machines")
+	}
+	if !reflect.DeepEqual(b.Layout, main.Regions[0].States[1].Submachine.Layout) {
+		t.Errorf("Layout = %+v, want %+v", b.Layout, main.Regions[0].States[1].Submachine.Layout)
+	}
+	if a.Layout != nil || c.Layout != nil {
+		t.Error("machines without a layout got one")
+	}
+
+	wantEntities := []BundleEntity{{Machine: "A", Name: "order", Path: "payloads/order.json"}}
+	wantEntities[0].Size, wantEntities[0].SHA256 = bundleDigest(store["payloads/order.json"].Data)
+	if !reflect.DeepEqual(bundle.Entities, wantEntities) {
+		t.Errorf("Entities = %+v, want %+v", bundle.Entities, wantEntities)
+	}
+	payload, err := a.ResolveEntity("order", bundle.EntityResolver())
+	if err != nil || string(payload) != `{"total": 42}` {
+		t.Errorf("ResolveEntity() = %q, %v", payload, err)
+	}
+
+	if got, want := errorString(bundle.Validate()), errorString(main.Validate()); got != want {
+		t.Errorf("Validate() = %s, want the findings of the original model: %s", got, want)
+	}
+	if !reflect.DeepEqual(Diff(main, a), Diff(main, main)) {
+		t.Errorf("loaded main machine differs from the original: %v", Diff(main, a))
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+func TestNewBundle_Conflicts(t *testing.T) {
+	a, b, other := newSubmachineFixture("A"), newSubmachineFixture("B"), newSubmachineFixture("B")
+	includeSubmachine(a, b)
+	a.Regions[0].States[0].IsSimple = false
+	a.Regions[0].States[0].IsSubmachineState = true
+	a.Regions[0].States[0].Submachine = other
+
+	if _, err := NewBundle(a); err == nil || !strings.Contains(err.Error(), "two different state machines 'B' version '1.0.0'") {
+		t.Errorf("NewBundle() error = %v, want a conflict", err)
+	}
+	if _, err := NewBundle(nil); err == nil {
+		t.Error("NewBundle(nil) should fail")
+	}
+}
+
+// rewriteBundle rewrites the entries of a bundle archive
+func rewriteBundle(t *testing.T, data []byte, edit func(manifest *bundleManifest, files map[string][]byte)) []byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	var names []string
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name], _ = io.ReadAll(rc)
+		rc.Close()
+		names = append(names, file.Name)
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(files[bundleManifestName], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	edit(&manifest, files)
+	files[bundleManifestName], _ = json.Marshal(manifest)
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range names {
+		if content, ok := files[name]; ok {
+			w, _ := writer.Create(name)
+			w.Write(content)
+		}
+	}
+	writer.Close()
+	return buf.Bytes()
+}
+
+func TestBundle_CrossDocumentValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		edit         func(manifest *bundleManifest, files map[string][]byte)
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "intact bundle",
+			edit: func(*bundleManifest, map[string][]byte) {},
+		},
+		{
+			name: "missing submachine document",
+			edit: func(manifest *bundleManifest, _ map[string][]byte) {
+				manifest.Machines = manifest.Machines[:2]
+			},
+			wantErrors: []string{"state 'state2' references state machine 'C' version '1.0.0', which is not in the bundle"},
+		},
+		{
+			name: "corrupted entity payload",
+			edit: func(manifest *bundleManifest, files map[string][]byte) {
+				files[manifest.Payloads["payloads/order.json"]] = []byte(`{"total": 0}`)
+			},
+			wantErrors: []string{"payload of entity 'order' at 'payloads/order.json' does not match its recorded size and digest"},
+		},
+		{
+			name: "missing entity payload",
+			edit: func(manifest *bundleManifest, _ map[string][]byte) {
+				manifest.Payloads = nil
+			},
+			wantErrors: []string{"payload of entity 'order' at 'payloads/order.json' is missing from the bundle"},
+		},
+		{
+			name: "entity descriptors must match the machines",
+			edit: func(manifest *bundleManifest, _ map[string][]byte) {
+				manifest.Entities = append(manifest.Entities,
+					BundleEntity{Machine: "Z", Name: "order", Path: "payloads/order.json"},
+					BundleEntity{Machine: "B", Name: "order", Path: "payloads/order.json"},
+				)
+			},
+			wantErrors: []string{
+				"entity 'order' belongs to state machine 'Z', which is not in the bundle",
+				"entity 'order' at 'payloads/order.json' is not an entity of state machine 'B'",
+			},
+		},
+		{
+			name: "unreachable documents",
+			edit: func(manifest *bundleManifest, _ map[string][]byte) {
+				manifest.Main = manifest.Machines[1].Path
+			},
+			wantErrors:   []string{"entity 'order' belongs to state machine 'A', which is not in the bundle"},
+			wantWarnings: []string{"document 'machines/A@1.0.0.json' is not reachable from the main state machine"},
+		},
+	}
+
+	main, store := newBundleFixture(t)
+	data := saveBundle(t, main, store)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := loadBundle(t, rewriteBundle(t, data, tt.edit))
+			errors := &ValidationErrors{}
+			bundle.ValidateWithErrors(NewValidationContext(), errors)
+
+			var bundleErrors []string
+			for _, err := range errors.Errors {
+				if err.Object == "Bundle" || len(err.Path) > 0 && strings.HasPrefix(err.Path[0], "machines/") {
+					bundleErrors = append(bundleErrors, err.Message)
+				}
+			}
+			var bundleWarnings []string
+			for _, warning := range errors.Warnings {
+				if warning.Object == "Bundle" {
+					bundleWarnings = append(bundleWarnings, warning.Message)
+				}
+			}
+			if !reflect.DeepEqual(bundleErrors, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", bundleErrors, tt.wantErrors)
+			}
+			if !reflect.DeepEqual(bundleWarnings, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", bundleWarnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestLoadBundle_Errors(t *testing.T) {
+	main, store := newBundleFixture(t)
+	data := saveBundle(t, main, store)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not an archive", []byte("not a zip"), "failed to open bundle"},
+		{"unsupported version", rewriteBundle(t, data, func(manifest *bundleManifest, _ map[string][]byte) {
+			manifest.Version = 2
+		}), "unsupported bundle version 2"},
+		{"unknown main document", rewriteBundle(t, data, func(manifest *bundleManifest, _ map[string][]byte) {
+			manifest.Main = "machines/missing.json"
+		}), "bundle main document 'machines/missing.json' is not listed in the manifest"},
+		{"missing document", rewriteBundle(t, data, func(manifest *bundleManifest, files map[string][]byte) {
+			delete(files, manifest.Machines[1].Path)
+		}), "bundle entry 'machines/B@1.0.0.json' not found"},
+		{"tampered document", rewriteBundle(t, data, func(manifest *bundleManifest, files map[string][]byte) {
+			files[manifest.Machines[1].Path] = bytes.Replace(files[manifest.Machines[1].Path], []byte(`"B"`), []byte(`"B "`), 1)
+		}), "bundle entry 'machines/B@1.0.0.json' does not match its digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBundle(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadBundle() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBundleWithLimits(t *testing.T) {
+	main, store := newBundleFixture(t)
+	data := saveBundle(t, main, store)
+	// A document that deflates to a few kilobytes but expands past the
+	// default MaxJSONBytes
+	bomb := rewriteBundle(t, data, func(manifest *bundleManifest, files map[string][]byte) {
+		files[manifest.Machines[1].Path] = bytes.Repeat([]byte(" "), int(DefaultResourceLimits.MaxJSONBytes)+1)
+	})
+	if len(bomb) > 1<<20 {
+		t.Fatalf("bomb bundle is %d bytes, want a small archive", len(bomb))
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		limits ResourceLimits
+		want   string // Empty when the bundle loads
+	}{
+		{"default limits", data, DefaultResourceLimits, ""},
+		{"no limits", data, ResourceLimits{}, ""},
+		{"decompression bomb", bomb, DefaultResourceLimits, "bundle entry 'machines/B@1.0.0.json': resource limit exceeded: json_bytes exceeds the limit of 16777216"},
+		{"entry size", data, ResourceLimits{MaxJSONBytes: 64}, "bundle entry 'manifest.json': resource limit exceeded: json_bytes exceeds the limit of 64"},
+		{"machine elements", data, ResourceLimits{MaxElements: 3}, "resource limit exceeded: elements exceeds the limit of 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := LoadBundleWithLimits(bytes.NewReader(tt.data), int64(len(tt.data)), tt.limits)
+			if tt.want == "" {
+				if err != nil || bundle.Main == nil {
+					t.Errorf("LoadBundleWithLimits() = %v, %v", bundle, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrResourceLimit) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadBundleWithLimits() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadBundle(bytes.NewReader(bomb), int64(len(bomb))); !errors.Is(err, ErrResourceLimit) {
+		t.Errorf("LoadBundle() error = %v, want the default limits enforced", err)
+	}
+}
+
+func TestBundle_ContentAddressed(t *testing.T) {
+	main, store := newBundleFixture(t)
+	bundle, err := NewBundle(main)
+	if err != nil {
+		t.Fatalf("NewBundle() error = %v", err)
+	}
+	if err := bundle.IncludePayloads(FSEntityResolver{FS: store}); err != nil {
+		t.Fatalf("IncludePayloads() error = %v", err)
+	}
+	bundle.ContentAddressed = true
+	var buf bytes.Buffer
+	if err := bundle.Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data := buf.Bytes()
+	if main.Regions[0].States[1].SubmachineDigest != "" {
+		t.Error("Save() pinned the states of the original model")
+	}
+	b, c := main.Regions[0].States[1].Submachine, main.Regions[0].States[1].Submachine.Regions[0].States[1].Submachine
+
+	loaded := loadBundle(t, data)
+	if !loaded.ContentAddressed {
+		t.Error("ContentAddressed = false for a bundle with pinned references")
+	}
+	la, lb, lc := loaded.Main, loaded.Submachines[0], loaded.Submachines[1]
+	tests := []struct {
+		name  string
+		state *State
+		want  string
+	}{
+		{"main pins B", la.Regions[0].States[1], bundle.Digest(b)},
+		{"B pins C", lb.Regions[0].States[1], bundle.Digest(c)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.want == "" || tt.state.SubmachineDigest != tt.want {
+				t.Errorf("SubmachineDigest = %q, want %q", tt.state.SubmachineDigest, tt.want)
+			}
+		})
+	}
+	if loaded.Digest(lb) != bundle.Digest(b) || loaded.Digest(lc) != bundle.Digest(c) {
+		t.Error("loaded digests differ from the saved ones")
+	}
+	if got, want := errorString(loaded.Validate()), errorString(loadBundle(t, saveBundle(t, main, store)).Validate()); got != want {
+		t.Errorf("Validate() = %s, want the findings of the unpinned bundle: %s", got, want)
+	}
+
+	t.Run("unpinned save", func(t *testing.T) {
+		unpinned := loadBundle(t, saveBundle(t, main, store))
+		if unpinned.ContentAddressed || unpinned.Main.Regions[0].States[1].SubmachineDigest != "" {
+			t.Error("a bundle saved without content addressing has pinned references")
+		}
+		var again bytes.Buffer
+		loaded.ContentAddressed = false
+		if err := loaded.Save(&again); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if resaved := loadBundle(t, again.Bytes()); resaved.ContentAddressed {
+			t.Error("re-saving a loaded bundle without content addressing kept its pins")
+		}
+	})
+
+	t.Run("replaced submachine", func(t *testing.T) {
+		replaced := rewriteBundle(t, data, func(manifest *bundleManifest, files map[string][]byte) {
+			path := manifest.Machines[2].Path
+			files[path] = bytes.Replace(files[path], []byte(`"C"`), []byte(`"C "`), 1)
+			_, manifest.Machines[2].SHA256 = bundleDigest(files[path])
+		})
+		_, err := LoadBundle(bytes.NewReader(replaced), int64(len(replaced)))
+		if err == nil || !strings.Contains(err.Error(), "pins state machine 'C' version '1.0.0' to digest") {
+			t.Errorf("LoadBundle() error = %v, want a pin mismatch", err)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UMLFeature is a UML state machine feature that an execution engine may
+// not support
+type UMLFeature string
+
+const (
+	UMLFeatureCompositeStates     UMLFeature = "composite_states"
+	UMLFeatureOrthogonalRegions   UMLFeature = "orthogonal_regions" // Orthogonal states and state machines with several regions
+	UMLFeatureShallowHistory      UMLFeature = "shallow_history"
+	UMLFeatureDeepHistory         UMLFeature = "deep_history"
+	UMLFeatureSubmachines         UMLFeature = "submachines"
+	UMLFeatureConnectionPoints    UMLFeature = "connection_points" // Entry and exit points
+	UMLFeatureForkJoin            UMLFeature = "fork_join"
+	UMLFeatureChoice              UMLFeature = "choice"
+	UMLFeatureJunction            UMLFeature = "junction"
+	UMLFeatureTerminate           UMLFeature = "terminate"
+	UMLFeatureTimeEvents          UMLFeature = "time_events"
+	UMLFeatureChangeEvents        UMLFeature = "change_events"
+	UMLFeatureInternalTransitions UMLFeature = "internal_transitions"
+	UMLFeatureLocalTransitions    UMLFeature = "local_transitions"
+	UMLFeatureGuards              UMLFeature = "guards"
+	UMLFeatureDeferredEvents      UMLFeature = "deferred_events"
+	UMLFeatureDoActivities        UMLFeature = "do_activities"
+)
+
+// UMLFeatures maps each UML feature a state machine uses to the IDs of the
+// elements that use it, in model order
+type UMLFeatures map[UMLFeature][]string
+
+// Uses reports whether the feature is used
+func (f UMLFeatures) Uses(feature UMLFeature) bool {
+	return len(f[feature]) > 0
+}
+
+// List returns the used features in sorted order
+func (f UMLFeatures) List() []UMLFeature {
+	features := make([]UMLFeature, 0, len(f))
+	for feature := range f {
+		features = append(features, feature)
+	}
+	slices.Sort(features)
+	return features
+}
+
+// FeatureIncompatibility is a feature a state machine uses that an engine
+// does not support, with the elements that use it
+type FeatureIncompatibility struct {
+	Feature  UMLFeature `json:"feature"`
+	Elements []string   `json:"elements"`
+}
+
+// String formats the incompatibility as "feature used by a, b"
+func (i FeatureIncompatibility) String() string {
+	return fmt.Sprintf("%s used by %s", i.Feature, strings.Join(i.Elements, ", "))
+}
+
+// Unsupported returns the used features missing from supported, in sorted
+// order; an empty result means an engine supporting those features can run
+// the state machine
+func (f UMLFeatures) Unsupported(supported ...UMLFeature) []FeatureIncompatibility {
+	var incompatibilities []FeatureIncompatibility
+	for _, feature := range f.List() {
+		if !slices.Contains(supported, feature) {
+			incompatibilities = append(incompatibilities, FeatureIncompatibility{Feature: feature, Elements: slices.Clone(f[feature])})
+		}
+	}
+	return incompatibilities
+}
+
+// Features reports which UML features the state machine uses, including the
+// features of its submachines. Elements of submachines are identified as
+// "submachineID/elementID".
+func Features(sm *StateMachine) UMLFeatures {
+	features := make(UMLFeatures)
+	if sm == nil {
+		return features
+	}
+
+	seen := map[*StateMachine]bool{sm: true}
+	var collect func(machine *StateMachine, prefix string)
+	collect = func(machine *StateMachine, prefix string) {
+		add := func(feature UMLFeature, id string) {
+			features[feature] = append(features[feature], prefix+id)
+		}
+		events := make(map[string]EventType, len(machine.Events))
+		for _, event := range machine.Events {
+			if event != nil {
+				events[event.ID] = event.Type
+			}
+		}
+		eventTypes := func(triggers []*Trigger) map[EventType]bool {
+			types := make(map[EventType]bool)
+			for _, trigger := range triggers {
+				switch {
+				case trigger == nil:
+				case trigger.EventID != "":
+					types[events[trigger.EventID]] = true
+				case trigger.Event != nil:
+					types[trigger.Event.Type] = true
+				}
+			}
+			return types
+		}
+
+		if len(nonNilRegions(machine.Regions)) > 1 {
+			add(UMLFeatureOrthogonalRegions, machine.ID)
+		}
+		for _, point := range machine.ConnectionPoints {
+			if point != nil {
+				add(UMLFeatureConnectionPoints, point.ID)
+			}
+		}
+		walkRegionTree(machine.Regions, "", func(region *Region, _ string) {
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				if len(state.Regions) > 0 {
+					add(UMLFeatureCompositeStates, state.ID)
+				}
+				if state.IsOrthogonal || len(nonNilRegions(state.Regions)) > 1 {
+					add(UMLFeatureOrthogonalRegions, state.ID)
+				}
+				if state.DoActivity != nil {
+					add(UMLFeatureDoActivities, state.ID)
+				}
+				if len(state.DeferrableTriggers) > 0 {
+					add(UMLFeatureDeferredEvents, state.ID)
+				}
+				if state.Submachine != nil {
+					add(UMLFeatureSubmachines, state.ID)
+					if submachine := state.Submachine; !seen[submachine] {
+						seen[submachine] = true
+						collect(submachine, prefix+submachine.ID+"/")
+					}
+				}
+			}
+			for _, vertex := range region.Vertices {
+				if vertex == nil || vertex.Type != "pseudostate" {
+					continue
+				}
+				switch pseudostateKindOf(vertex) {
+				case PseudostateKindShallowHistory:
+					add(UMLFeatureShallowHistory, vertex.ID)
+				case PseudostateKindDeepHistory:
+					add(UMLFeatureDeepHistory, vertex.ID)
+				case PseudostateKindFork, PseudostateKindJoin:
+					add(UMLFeatureForkJoin, vertex.ID)
+				case PseudostateKindChoice:
+					add(UMLFeatureChoice, vertex.ID)
+				case PseudostateKindJunction:
+					add(UMLFeatureJunction, vertex.ID)
+				case PseudostateKindTerminate:
+					add(UMLFeatureTerminate, vertex.ID)
+				case PseudostateKindEntryPoint, PseudostateKindExitPoint:
+					add(UMLFeatureConnectionPoints, vertex.ID)
+				}
+			}
+			for _, transition := range region.Transitions {
+				if transition == nil {
+					continue
+				}
+				switch transition.Kind {
+				case TransitionKindInternal:
+					add(UMLFeatureInternalTransitions, transition.ID)
+				case TransitionKindLocal:
+					add(UMLFeatureLocalTransitions, transition.ID)
+				}
+				if transition.Guard != nil {
+					add(UMLFeatureGuards, transition.ID)
+				}
+				types := eventTypes(transition.Triggers)
+				if types[EventTypeTime] {
+					add(UMLFeatureTimeEvents, transition.ID)
+				}
+				if types[EventTypeChange] {
+					add(UMLFeatureChangeEvents, transition.ID)
+				}
+			}
+		})
+	}
+	collect(sm, "")
+	return features
+}
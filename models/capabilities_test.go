@@ -0,0 +1,83 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Events = []*Event{
+		{ID: "tick", Name: "tick", Type: EventTypeTime},
+		{ID: "ready", Name: "ready", Type: EventTypeChange},
+	}
+	main := sm.Regions[0]
+	history := execPseudostate("main-history", "shallowHistory")
+	main.Vertices = append(main.Vertices, history)
+	main.Transitions[2].Guard = &Constraint{ID: "may-stop", Specification: "confirmed"}
+	main.States[0].DoActivity = &Behavior{ID: "wait", Specification: "wait()"}
+	main.States[1].Regions[0].Transitions[2].Kind = TransitionKindInternal // audio-tick
+
+	door := &StateMachine{ID: "door", Regions: []*Region{{
+		ID:       "door-main",
+		Vertices: []*Vertex{execPseudostate("door-choice", "choice")},
+	}}}
+	main.States = append(main.States, &State{Vertex: Vertex{ID: "door-state", Type: "state"}, IsSubmachineState: true, Submachine: door})
+
+	features := Features(sm)
+	want := UMLFeatures{
+		UMLFeatureCompositeStates:     {"playing"},
+		UMLFeatureOrthogonalRegions:   {"playing"},
+		UMLFeatureShallowHistory:      {"main-history"},
+		UMLFeatureSubmachines:         {"door-state"},
+		UMLFeatureChoice:              {"door/door-choice"},
+		UMLFeatureGuards:              {"stop"},
+		UMLFeatureDoActivities:        {"idle"},
+		UMLFeatureDeferredEvents:      {"idle"},
+		UMLFeatureTimeEvents:          {"audio-tick", "video-tick"},
+		UMLFeatureChangeEvents:        {"video-ready"},
+		UMLFeatureInternalTransitions: {"audio-tick"},
+	}
+	for _, feature := range append(want.List(), UMLFeatureDeepHistory, UMLFeatureForkJoin) {
+		if got := features[feature]; !reflect.DeepEqual(got, want[feature]) {
+			t.Errorf("Features()[%s] = %v, want %v", feature, got, want[feature])
+		}
+	}
+	if len(features) != len(want) {
+		t.Errorf("Features() = %v, want %v", features.List(), want.List())
+	}
+	if !features.Uses(UMLFeatureShallowHistory) || features.Uses(UMLFeatureDeepHistory) {
+		t.Error("Uses() disagrees with the detected features")
+	}
+	if got := Features(nil); len(got) != 0 {
+		t.Errorf("Features(nil) = %v, want none", got)
+	}
+}
+
+func TestUMLFeatures_Unsupported(t *testing.T) {
+	features := UMLFeatures{
+		UMLFeatureDeepHistory:       {"h1", "h2"},
+		UMLFeatureOrthogonalRegions: {"parallel"},
+		UMLFeatureGuards:            {"t1"},
+	}
+	tests := []struct {
+		name      string
+		supported []UMLFeature
+		want      []string
+	}{
+		{name: "nothing supported", want: []string{"deep_history used by h1, h2", "guards used by t1", "orthogonal_regions used by parallel"}},
+		{name: "partly supported", supported: []UMLFeature{UMLFeatureGuards, UMLFeatureOrthogonalRegions}, want: []string{"deep_history used by h1, h2"}},
+		{name: "all supported", supported: []UMLFeature{UMLFeatureGuards, UMLFeatureOrthogonalRegions, UMLFeatureDeepHistory}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, incompatibility := range features.Unsupported(tt.supported...) {
+				got = append(got, incompatibility.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
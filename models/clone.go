@@ -0,0 +1,261 @@
+package models
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the state machine. Pointers that are shared
+// within the original graph (for example a *Vertex used both in a region's
+// Vertices and as a transition endpoint, or a submachine referenced by
+// several states) remain shared in the copy, and cyclic submachine
+// references are preserved. Metadata values are copied shallowly. When sm
+// records adjacency (see RecordAdjacency), the copy records its own.
+func (sm *StateMachine) Clone() *StateMachine {
+	return newModelCloner().stateMachine(sm)
+}
+
+// Clone returns a deep copy of the region with the same sharing semantics as
+// StateMachine.Clone
+func (r *Region) Clone() *Region {
+	return newModelCloner().region(r)
+}
+
+// modelCloner deep-copies model objects while preserving pointer identity
+type modelCloner struct {
+	seen map[any]any
+}
+
+func newModelCloner() *modelCloner {
+	return &modelCloner{seen: make(map[any]any)}
+}
+
+func (c *modelCloner) stateMachine(sm *StateMachine) *StateMachine {
+	if sm == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[sm]; ok {
+		return cloned.(*StateMachine)
+	}
+
+	out := &StateMachine{}
+	c.seen[sm] = out
+	*out = *sm
+	out.Regions = cloneSlice(sm.Regions, c.region)
+	out.ConnectionPoints = cloneSlice(sm.ConnectionPoints, c.pseudostate)
+	out.Events = cloneSlice(sm.Events, c.event)
+	out.Entities = maps.Clone(sm.Entities)
+	out.Metadata = maps.Clone(sm.Metadata)
+	out.Layout = sm.Layout.Clone()
+	out.Suppressions = slices.Clone(sm.Suppressions)
+	if out.adjacency {
+		// Endpoints of the original were resolved; resolve the copy's so its
+		// vertices record its transitions. Unresolved endpoints stay as
+		// they were in the original.
+		_ = ResolveEndpoints(out)
+	}
+	return out
+}
+
+func (c *modelCloner) region(r *Region) *Region {
+	if r == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[r]; ok {
+		return cloned.(*Region)
+	}
+
+	out := &Region{}
+	c.seen[r] = out
+	*out = *r
+	out.States = cloneSlice(r.States, c.state)
+	out.Vertices = cloneSlice(r.Vertices, c.vertex)
+	out.Transitions = cloneSlice(r.Transitions, c.transition)
+	out.Suppressions = slices.Clone(r.Suppressions)
+	return out
+}
+
+func (c *modelCloner) state(s *State) *State {
+	if s == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[s]; ok {
+		return cloned.(*State)
+	}
+
+	out := &State{}
+	c.seen[s] = out
+	c.seen[&s.Vertex] = &out.Vertex // Endpoints may point at the embedded vertex
+	*out = *s
+	out.clearAdjacency()
+	out.Suppressions = slices.Clone(out.Suppressions)
+	out.Tags = slices.Clone(out.Tags)
+	out.Regions = cloneSlice(s.Regions, c.region)
+	out.Entry = c.behavior(s.Entry)
+	out.Exit = c.behavior(s.Exit)
+	out.DoActivity = c.behavior(s.DoActivity)
+	out.Submachine = c.stateMachine(s.Submachine)
+	out.Connections = cloneSlice(s.Connections, c.connectionPointReference)
+	out.Features = slices.Clone(s.Features)
+	out.Cost = clonePointer(s.Cost)
+	out.DeferrableTriggers = cloneSlice(s.DeferrableTriggers, c.trigger)
+	return out
+}
+
+func (c *modelCloner) vertex(v *Vertex) *Vertex {
+	if v == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[v]; ok {
+		return cloned.(*Vertex)
+	}
+
+	out := &Vertex{}
+	c.seen[v] = out
+	*out = *v
+	out.clearAdjacency()
+	out.Suppressions = slices.Clone(out.Suppressions)
+	out.Tags = slices.Clone(out.Tags)
+	return out
+}
+
+func (c *modelCloner) pseudostate(ps *Pseudostate) *Pseudostate {
+	if ps == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[ps]; ok {
+		return cloned.(*Pseudostate)
+	}
+
+	out := &Pseudostate{}
+	c.seen[ps] = out
+	c.seen[&ps.Vertex] = &out.Vertex
+	*out = *ps
+	out.clearAdjacency()
+	out.Suppressions = slices.Clone(out.Suppressions)
+	out.Tags = slices.Clone(out.Tags)
+	return out
+}
+
+func (c *modelCloner) connectionPointReference(cpr *ConnectionPointReference) *ConnectionPointReference {
+	if cpr == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[cpr]; ok {
+		return cloned.(*ConnectionPointReference)
+	}
+
+	out := &ConnectionPointReference{}
+	c.seen[cpr] = out
+	c.seen[&cpr.Vertex] = &out.Vertex
+	*out = *cpr
+	out.Entry = cloneSlice(cpr.Entry, c.pseudostate)
+	out.Exit = cloneSlice(cpr.Exit, c.pseudostate)
+	return out
+}
+
+func (c *modelCloner) transition(t *Transition) *Transition {
+	if t == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[t]; ok {
+		return cloned.(*Transition)
+	}
+
+	out := &Transition{}
+	c.seen[t] = out
+	*out = *t
+	out.Source = c.vertex(t.Source)
+	out.Target = c.vertex(t.Target)
+	out.Triggers = cloneSlice(t.Triggers, c.trigger)
+	out.Guard = c.constraint(t.Guard)
+	out.Effect = c.behavior(t.Effect)
+	out.Features = slices.Clone(t.Features)
+	out.Entities = slices.Clone(t.Entities)
+	out.Tags = slices.Clone(t.Tags)
+	out.Probability = clonePointer(t.Probability)
+	out.Cost = clonePointer(t.Cost)
+	out.Suppressions = slices.Clone(t.Suppressions)
+	return out
+}
+
+func (c *modelCloner) trigger(tr *Trigger) *Trigger {
+	if tr == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[tr]; ok {
+		return cloned.(*Trigger)
+	}
+
+	out := &Trigger{}
+	c.seen[tr] = out
+	*out = *tr
+	out.Event = c.event(tr.Event)
+	return out
+}
+
+func (c *modelCloner) event(e *Event) *Event {
+	if e == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[e]; ok {
+		return cloned.(*Event)
+	}
+
+	out := &Event{}
+	c.seen[e] = out
+	*out = *e
+	out.Properties = maps.Clone(e.Properties)
+	return out
+}
+
+func (c *modelCloner) behavior(b *Behavior) *Behavior {
+	if b == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[b]; ok {
+		return cloned.(*Behavior)
+	}
+
+	out := &Behavior{}
+	c.seen[b] = out
+	*out = *b
+	out.Entities = slices.Clone(b.Entities)
+	out.Encrypted = clonePointer(b.Encrypted)
+	return out
+}
+
+func (c *modelCloner) constraint(con *Constraint) *Constraint {
+	if con == nil {
+		return nil
+	}
+	if cloned, ok := c.seen[con]; ok {
+		return cloned.(*Constraint)
+	}
+
+	out := &Constraint{}
+	c.seen[con] = out
+	*out = *con
+	return out
+}
+
+// cloneSlice clones each element of in with fn, keeping nil slices nil
+func cloneSlice[T any](in []*T, fn func(*T) *T) []*T {
+	if in == nil {
+		return nil
+	}
+	out := make([]*T, len(in))
+	for i, item := range in {
+		out[i] = fn(item)
+	}
+	return out
+}
+
+// clonePointer returns a pointer to a copy of the value p points to
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	return &out
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStateMachine_Clone(t *testing.T) {
+	original := createValidStateMachine()
+	sub := &StateMachine{ID: "sub", Name: "Sub", Version: "1.0"}
+	original.Regions[0].States[1].Submachine = sub
+	original.Regions[0].States[1].IsSubmachineState = true
+	sub.Regions = []*Region{{ID: "sub_region", Name: "Sub Region", States: []*State{
+		{Vertex: Vertex{ID: "back", Name: "Back", Type: "state"}, Submachine: sub},
+	}}}
+
+	clone := original.Clone()
+
+	if clone == original || clone.Regions[0] == original.Regions[0] {
+		t.Fatal("Clone() should not share regions with the original")
+	}
+
+	clone.Name = "Changed"
+	clone.Regions[0].States[0].Name = "Changed"
+	clone.Regions[0].Transitions[1].Guard.Specification = "changed"
+	clone.Metadata["author"] = "changed"
+	if original.Name == "Changed" || original.Regions[0].States[0].Name == "Changed" ||
+		original.Regions[0].Transitions[1].Guard.Specification == "changed" || original.Metadata["author"] == "changed" {
+		t.Error("modifying the clone should not affect the original")
+	}
+
+	region := clone.Regions[0]
+	if region.Transitions[0].Source != region.Vertices[0] {
+		t.Error("Clone() should preserve pointers shared between Vertices and transition endpoints")
+	}
+
+	clonedSub := clone.Regions[0].States[1].Submachine
+	if clonedSub == sub {
+		t.Error("Clone() should copy submachines")
+	}
+	if clonedSub.Regions[0].States[0].Submachine != clonedSub {
+		t.Error("Clone() should preserve cyclic submachine references")
+	}
+
+	if (*StateMachine)(nil).Clone() != nil {
+		t.Error("Clone() of nil should return nil")
+	}
+}
+
+func TestStateMachine_Clone_StateEndpoints(t *testing.T) {
+	clone := newPlayerMachine().Clone()
+	main := clone.Regions[0]
+	idle, playing := main.States[0], main.States[1]
+
+	tests := []struct {
+		name     string
+		endpoint *Vertex
+		want     *Vertex
+	}{
+		{name: "target of start", endpoint: main.Transitions[0].Target, want: &idle.Vertex},
+		{name: "source of play", endpoint: main.Transitions[1].Source, want: &idle.Vertex},
+		{name: "target of play", endpoint: main.Transitions[1].Target, want: &playing.Vertex},
+		{name: "nested source", endpoint: playing.Regions[0].Transitions[1].Source, want: &playing.Regions[0].States[0].Vertex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.endpoint != tt.want {
+				t.Error("endpoint is not the embedded vertex of the cloned state")
+			}
+		})
+	}
+
+	// Edits of the cloned state reach its transitions
+	idle.Name = "Waiting"
+	if err := clone.Validate(); err != nil && strings.Contains(err.Error(), "does not match the declared vertex") {
+		t.Errorf("Validate() after renaming the cloned state: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// CompatibilityProfile checks whether a state machine can run on a target
+// platform, such as an SCXML interpreter or a flat runtime. Profiles are
+// registered by name with RegisterCompatibilityProfile and can be attached
+// to a ValidationProfile.
+type CompatibilityProfile interface {
+	// Name returns the registered name of the profile, e.g. "scxml-compatible"
+	Name() string
+
+	// Check returns the features of sm the platform does not support,
+	// together with the elements using them
+	Check(sm *StateMachine) []FeatureIncompatibility
+}
+
+// FeatureCompatibilityProfile is a compatibility profile that supports a
+// fixed set of UML features. Features added to UMLFeature later are
+// unsupported until listed.
+type FeatureCompatibilityProfile struct {
+	name      string
+	supported []UMLFeature
+}
+
+// NewFeatureCompatibilityProfile creates a profile for a platform that
+// supports the given features
+func NewFeatureCompatibilityProfile(name string, supported ...UMLFeature) *FeatureCompatibilityProfile {
+	return &FeatureCompatibilityProfile{name: name, supported: slices.Clone(supported)}
+}
+
+// Name returns the name of the profile
+func (p *FeatureCompatibilityProfile) Name() string {
+	return p.name
+}
+
+// Supported returns the features the platform supports
+func (p *FeatureCompatibilityProfile) Supported() []UMLFeature {
+	return slices.Clone(p.supported)
+}
+
+// Check returns the features sm uses that the platform does not support
+func (p *FeatureCompatibilityProfile) Check(sm *StateMachine) []FeatureIncompatibility {
+	return Features(sm).Unsupported(p.supported...)
+}
+
+// Built-in compatibility profiles
+var (
+	// SCXMLCompatibleProfile accepts what the SCXML exporter can express:
+	// compound and parallel states, history, transient choice and junction
+	// states, and targetless or type="internal" transitions
+	SCXMLCompatibleProfile = NewFeatureCompatibilityProfile("scxml-compatible",
+		UMLFeatureCompositeStates,
+		UMLFeatureOrthogonalRegions,
+		UMLFeatureShallowHistory,
+		UMLFeatureDeepHistory,
+		UMLFeatureChoice,
+		UMLFeatureJunction,
+		UMLFeatureTerminate,
+		UMLFeatureInternalTransitions,
+		UMLFeatureLocalTransitions,
+		UMLFeatureGuards,
+	)
+
+	// ASLCompatibleProfile accepts what Amazon States Language workflows can
+	// express: Parallel branches, Choice rules, Wait states for time events
+	// and Succeed or Fail for termination
+	ASLCompatibleProfile = NewFeatureCompatibilityProfile("asl-compatible",
+		UMLFeatureCompositeStates,
+		UMLFeatureOrthogonalRegions,
+		UMLFeatureChoice,
+		UMLFeatureTerminate,
+		UMLFeatureTimeEvents,
+		UMLFeatureGuards,
+	)
+
+	// FlatOnlyProfile accepts state machines without hierarchy: no composite
+	// or orthogonal states, history, submachines, connection points or
+	// forks and joins
+	FlatOnlyProfile = NewFeatureCompatibilityProfile("flat-only",
+		UMLFeatureChoice,
+		UMLFeatureJunction,
+		UMLFeatureTerminate,
+		UMLFeatureTimeEvents,
+		UMLFeatureChangeEvents,
+		UMLFeatureInternalTransitions,
+		UMLFeatureGuards,
+		UMLFeatureDeferredEvents,
+		UMLFeatureDoActivities,
+	)
+)
+
+var (
+	compatibilityProfilesMu sync.RWMutex
+	compatibilityProfiles   = map[string]CompatibilityProfile{
+		SCXMLCompatibleProfile.Name(): SCXMLCompatibleProfile,
+		ASLCompatibleProfile.Name():   ASLCompatibleProfile,
+		FlatOnlyProfile.Name():        FlatOnlyProfile,
+	}
+)
+
+// RegisterCompatibilityProfile makes a compatibility profile available by
+// name. It returns an error if the name is empty or already registered.
+func RegisterCompatibilityProfile(profile CompatibilityProfile) error {
+	if profile == nil || profile.Name() == "" {
+		return fmt.Errorf("compatibility profile must have a name")
+	}
+	compatibilityProfilesMu.Lock()
+	defer compatibilityProfilesMu.Unlock()
+	if _, exists := compatibilityProfiles[profile.Name()]; exists {
+		return fmt.Errorf("compatibility profile '%s' is already registered", profile.Name())
+	}
+	compatibilityProfiles[profile.Name()] = profile
+	return nil
+}
+
+// LookupCompatibilityProfile returns the compatibility profile registered
+// under name
+func LookupCompatibilityProfile(name string) (CompatibilityProfile, bool) {
+	compatibilityProfilesMu.RLock()
+	defer compatibilityProfilesMu.RUnlock()
+	profile, exists := compatibilityProfiles[name]
+	return profile, exists
+}
+
+// CompatibilityProfiles returns the names of the registered compatibility
+// profiles in sorted order
+func CompatibilityProfiles() []string {
+	compatibilityProfilesMu.RLock()
+	defer compatibilityProfilesMu.RUnlock()
+	names := make([]string, 0, len(compatibilityProfiles))
+	for name := range compatibilityProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unregisterCompatibilityProfile removes a profile; used by tests
+func unregisterCompatibilityProfile(name string) {
+	compatibilityProfilesMu.Lock()
+	defer compatibilityProfilesMu.Unlock()
+	delete(compatibilityProfiles, name)
+}
+
+// CheckCompatibility checks sm against the compatibility profile registered
+// under name
+func CheckCompatibility(sm *StateMachine, name string) ([]FeatureIncompatibility, error) {
+	profile, exists := LookupCompatibilityProfile(name)
+	if !exists {
+		return nil, fmt.Errorf("unknown compatibility profile '%s'; registered profiles: %v", name, CompatibilityProfiles())
+	}
+	return profile.Check(sm), nil
+}
+
+// validateCompatibility reports each element using a feature the
+// compatibility profile of the context's profile does not support. Only the
+// machine at the root of the validation is checked, since the check already
+// covers its submachines.
+func (sm *StateMachine) validateCompatibility(context *ValidationContext, errors *ValidationErrors) {
+	profile := context.ActiveProfile().Compatibility
+	if profile == nil || len(context.machineChain) > 1 {
+		return
+	}
+	for _, incompatibility := range profile.Check(sm) {
+		for _, element := range incompatibility.Elements {
+			errors.AddErrorWithContext(
+				ErrorTypeConstraint,
+				"StateMachine",
+				"Compatibility",
+				fmt.Sprintf("'%s' uses %s, which the %s profile does not support (platform compatibility)", element, incompatibility.Feature, profile.Name()),
+				context.Path,
+				map[string]interface{}{"profile": profile.Name(), "feature": string(incompatibility.Feature), "element": element},
+			)
+		}
+	}
+}
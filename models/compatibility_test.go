@@ -0,0 +1,94 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompatibilityProfiles_BuiltIn(t *testing.T) {
+	sm := newPlayerMachine()
+	main := sm.Regions[0]
+	main.Vertices = append(main.Vertices, execPseudostate("main-history", "deepHistory"))
+	main.Transitions[2].Guard = &Constraint{ID: "may-stop", Specification: "confirmed"}
+
+	tests := []struct {
+		profile string
+		want    []string
+	}{
+		{profile: "scxml-compatible", want: []string{"deferred_events used by idle"}},
+		{profile: "asl-compatible", want: []string{"deep_history used by main-history", "deferred_events used by idle"}},
+		{profile: "flat-only", want: []string{"composite_states used by playing", "deep_history used by main-history", "orthogonal_regions used by playing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			incompatibilities, err := CheckCompatibility(sm, tt.profile)
+			if err != nil {
+				t.Fatalf("CheckCompatibility() error = %v", err)
+			}
+			var got []string
+			for _, incompatibility := range incompatibilities {
+				got = append(got, incompatibility.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckCompatibility() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := CheckCompatibility(sm, "bpmn"); err == nil || !strings.Contains(err.Error(), "unknown compatibility profile 'bpmn'") {
+		t.Errorf("CheckCompatibility(bpmn) error = %v, want unknown profile", err)
+	}
+}
+
+func TestRegisterCompatibilityProfile(t *testing.T) {
+	profile := NewFeatureCompatibilityProfile("guards-only", UMLFeatureGuards)
+	if err := RegisterCompatibilityProfile(profile); err != nil {
+		t.Fatalf("RegisterCompatibilityProfile() error = %v", err)
+	}
+	defer unregisterCompatibilityProfile("guards-only")
+
+	if got, ok := LookupCompatibilityProfile("guards-only"); !ok || got != profile {
+		t.Errorf("LookupCompatibilityProfile() = %v, %v", got, ok)
+	}
+	if got, want := CompatibilityProfiles(), []string{"asl-compatible", "flat-only", "guards-only", "scxml-compatible"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CompatibilityProfiles() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(profile.Supported(), []UMLFeature{UMLFeatureGuards}) {
+		t.Errorf("Supported() = %v", profile.Supported())
+	}
+
+	for _, bad := range []CompatibilityProfile{nil, NewFeatureCompatibilityProfile(""), NewFeatureCompatibilityProfile("flat-only")} {
+		if err := RegisterCompatibilityProfile(bad); err == nil {
+			t.Errorf("RegisterCompatibilityProfile(%v) should fail", bad)
+		}
+	}
+}
+
+func TestValidateCompatibility(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Regions[0].Transitions[0].Guard = &Constraint{ID: "ready", Specification: "ready"}
+	errors := &ValidationErrors{}
+	profile := &ValidationProfile{Name: "flat", Compatibility: NewFeatureCompatibilityProfile("no-guards")}
+	sm.ValidateWithErrors(NewValidationContext().WithProfile(profile), errors)
+
+	var found []*ValidationError
+	for _, err := range errors.Errors {
+		if err.Field == "Compatibility" {
+			found = append(found, err)
+		}
+	}
+	if len(found) == 0 {
+		t.Fatalf("expected compatibility errors, got %v", errors)
+	}
+	for _, err := range found {
+		if err.Context["profile"] != "no-guards" || err.Context["feature"] == "" || !strings.Contains(err.Message, "which the no-guards profile does not support") {
+			t.Errorf("unexpected compatibility error %+v", err)
+		}
+	}
+
+	clean := createValidStateMachine()
+	if err := clean.ValidateInContext(NewValidationContext().WithProfile(&ValidationProfile{Name: "any", Compatibility: SCXMLCompatibleProfile})); err != nil {
+		t.Errorf("ValidateInContext() error = %v, want none for an SCXML-compatible machine", err)
+	}
+}
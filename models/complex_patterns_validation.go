@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -65,6 +66,7 @@ func (cpv *ComplexPatternValidator) ValidateConnectionPointReferences(stateMachi
 	}
 
 	// Validate connection points are properly typed
+	transitions := IndexTransitions(stateMachine)
 	for i, cp := range stateMachine.ConnectionPoints {
 		if cp == nil {
 			cpv.addError(ValidationError{
@@ -89,7 +91,7 @@ func (cpv *ComplexPatternValidator) ValidateConnectionPointReferences(stateMachi
 		}
 
 		// Validate connection point references
-		if err := cpv.validateConnectionPointReferences(cp, stateMachine); err != nil {
+		if err := cpv.validateConnectionPointReferences(cp, transitions); err != nil {
 			cpv.addError(ValidationError{
 				Type:    ErrorTypeReference,
 				Object:  fmt.Sprintf("Pseudostate[%s]", cp.Name),
@@ -192,41 +194,21 @@ func (cpv *ComplexPatternValidator) validateRegionSeparation(regions []*Region,
 	return nil
 }
 
-func (cpv *ComplexPatternValidator) validateConnectionPointReferences(cp *Pseudostate, stateMachine *StateMachine) error {
-	// Validate that connection points are properly referenced by transitions
-	referencedByTransitions := false
+func (cpv *ComplexPatternValidator) validateConnectionPointReferences(cp *Pseudostate, transitions *TransitionIndex) error {
+	// Validate that connection points are properly referenced by transitions,
+	// looking up the transitions leaving and entering this connection point
+	outgoing, incoming := transitions.outgoing[cp.ID], transitions.incoming[cp.ID]
 
-	// Check all regions for transitions that reference this connection point
-	for _, region := range stateMachine.Regions {
-		if region == nil {
-			continue
-		}
-
-		for _, transition := range region.Transitions {
-			if transition == nil {
-				continue
-			}
-
-			// Check if transition references this connection point by comparing IDs
-			sourceMatches := transition.Source != nil && transition.Source.ID == cp.ID
-			targetMatches := transition.Target != nil && transition.Target.ID == cp.ID
-
-			if sourceMatches || targetMatches {
-				referencedByTransitions = true
-
-				// Validate transition kind for connection points
-				if cp.Kind == PseudostateKindEntryPoint && !targetMatches {
-					return fmt.Errorf("entry point '%s' can only be transition target", cp.Name)
-				}
-				if cp.Kind == PseudostateKindExitPoint && !sourceMatches {
-					return fmt.Errorf("exit point '%s' can only be transition source", cp.Name)
-				}
-			}
-		}
+	// Validate transition kind for connection points
+	if cp.Kind == PseudostateKindEntryPoint && slices.ContainsFunc(outgoing, func(t *Transition) bool { return t.Target == nil || t.Target.ID != cp.ID }) {
+		return fmt.Errorf("entry point '%s' can only be transition target", cp.Name)
+	}
+	if cp.Kind == PseudostateKindExitPoint && slices.ContainsFunc(incoming, func(t *Transition) bool { return t.Source == nil || t.Source.ID != cp.ID }) {
+		return fmt.Errorf("exit point '%s' can only be transition source", cp.Name)
 	}
 
 	// Connection points should be referenced by at least one transition
-	if !referencedByTransitions {
+	if len(outgoing) == 0 && len(incoming) == 0 {
 		return fmt.Errorf("connection point '%s' is not referenced by any transitions", cp.Name)
 	}
 
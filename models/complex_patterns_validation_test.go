@@ -467,7 +467,7 @@ func TestComplexPatternValidator_ValidateConnectionPointReferencesHelper(t *test
 			context := &ValidationContext{}
 			cpv := NewComplexPatternValidator(context)
 
-			err := cpv.validateConnectionPointReferences(tt.cp, tt.stateMachine)
+			err := cpv.validateConnectionPointReferences(tt.cp, IndexTransitions(tt.stateMachine))
 
 			if tt.wantErr {
 				if err == nil {
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// runConcurrently calls fn from the given number of goroutines at once and
+// returns the results in goroutine order
+func runConcurrently(goroutines int, fn func() string) []string {
+	results := make([]string, goroutines)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results[i] = fn()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return results
+}
+
+// concurrencyMachines returns the machines the concurrency tests share
+// between goroutines: a valid one and an invalid one
+func concurrencyMachines() map[string]*StateMachine {
+	invalid := GenerateStateMachine(7, GeneratorOptions{})
+	invalid.Name = ""
+	invalid.Regions[0].Transitions = append(invalid.Regions[0].Transitions, &Transition{ID: "dangling", Kind: TransitionKindExternal})
+	return map[string]*StateMachine{
+		"valid":   GenerateStateMachine(3, GeneratorOptions{}),
+		"invalid": invalid,
+	}
+}
+
+func TestConcurrentUse_SharedMachine(t *testing.T) {
+	const goroutines = 8
+
+	validate := func(sm *StateMachine) func() string {
+		return func() string { return fmt.Sprint(sm.Validate()) }
+	}
+	traverser := NewStateMachineTraverser()
+	traverse := func(sm *StateMachine) func() string {
+		return func() string {
+			var visited []string
+			err := traverser.TraverseStateMachine(sm, func(obj interface{}, path []string, _ int) error {
+				visited = append(visited, strings.Join(path, "."))
+				return nil
+			})
+			return fmt.Sprint(visited, err)
+		}
+	}
+	export := func(format string) func(sm *StateMachine) func() string {
+		return func(sm *StateMachine) func() string {
+			return func() string {
+				var buf bytes.Buffer
+				err := Export(sm, format, &buf, ExportOptions{})
+				return fmt.Sprint(buf.String(), err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		run  func(sm *StateMachine) func() string
+	}{
+		{name: "Validate", run: validate},
+		{name: "traversal with a shared traverser", run: traverse},
+	}
+	for _, format := range ExportFormats() {
+		tests = append(tests, struct {
+			name string
+			run  func(sm *StateMachine) func() string
+		}{name: "export " + format, run: export(format)})
+	}
+
+	for machineName, sm := range concurrencyMachines() {
+		for _, tt := range tests {
+			t.Run(machineName+"/"+tt.name, func(t *testing.T) {
+				want := tt.run(sm)()
+				for i, got := range runConcurrently(goroutines, tt.run(sm)) {
+					if got != want {
+						t.Errorf("goroutine %d result differs from the sequential result:\n%s\nwant:\n%s", i, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestConcurrentUse_MixedOperations(t *testing.T) {
+	// Validation, traversal and every export race on the same machine
+	sm := GenerateStateMachine(11, GeneratorOptions{})
+	want := fmt.Sprint(sm.Validate())
+	formats := ExportFormats()
+	traverser := NewStateMachineTraverser()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*len(formats))
+	for _, format := range formats {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if got := fmt.Sprint(sm.Validate()); got != want {
+				errs <- fmt.Errorf("Validate() = %s, want %s", got, want)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := traverser.TraverseStateMachine(sm, func(interface{}, []string, int) error { return nil }); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := Export(sm, format, &bytes.Buffer{}, ExportOptions{}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestReferenceValidator_InUse(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(rv *ReferenceValidator) error
+	}{
+		{name: "ValidateReferences", validate: func(rv *ReferenceValidator) error {
+			return rv.ValidateReferences(createValidStateMachine())
+		}},
+		{name: "ValidateReferencesInContext", validate: func(rv *ReferenceValidator) error {
+			return rv.ValidateReferencesInContext(createValidStateMachine(), nil)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rv := NewReferenceValidator()
+			rv.inUse.Store(true) // Another goroutine is validating
+			if err := tt.validate(rv); !errors.Is(err, ErrValidatorInUse) {
+				t.Fatalf("error = %v, want ErrValidatorInUse", err)
+			}
+
+			rv.inUse.Store(false)
+			if err := tt.validate(rv); err != nil {
+				t.Fatalf("error = %v once the validator is free", err)
+			}
+			if rv.inUse.Load() {
+				t.Error("validator still marked in use after validating")
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// MachineConfiguration is a snapshot of a running state machine instance,
+// for persistence layers that store instances between events and restore
+// them later. It records the active vertex of every active region, the
+// history memory of regions that were exited, and the events that are
+// queued or deferred. Snapshot takes one from an Interpreter and Restore
+// loads one into a new Interpreter; the JSON encoding is the storage format.
+type MachineConfiguration struct {
+	MachineID  string            `json:"machine_id"`
+	Version    string            `json:"version,omitempty"`    // Version of the machine the snapshot was taken from
+	Active     map[string]string `json:"active"`               // Active state or final state ID by region ID
+	History    map[string]string `json:"history,omitempty"`    // Last active state or final state ID by region ID
+	Queued     []EventOccurrence `json:"queued,omitempty"`     // Events waiting to be processed, oldest first
+	Deferred   []EventOccurrence `json:"deferred,omitempty"`   // Events deferred by active states, oldest first
+	Terminated bool              `json:"terminated,omitempty"` // A terminate pseudostate was reached
+}
+
+// Validate checks that the configuration is consistent with sm
+func (c *MachineConfiguration) Validate(sm *StateMachine) error {
+	errors := &ValidationErrors{}
+	c.ValidateWithErrors(sm, NewValidationContext(), errors)
+	return errors.ToError()
+}
+
+// ValidateWithErrors checks that the configuration is consistent with sm:
+// it was taken from a machine with the same ID, every region it names
+// exists, the active vertex of a region is a state or final state of that
+// region, every top-level region and every region of an active composite
+// state is active (unless the instance terminated), no region of an
+// inactive state is active, and history memories name states of their
+// region. Queued or deferred events that no trigger of sm handles are
+// reported as warnings, as are deferred events no active state defers.
+func (c *MachineConfiguration) ValidateWithErrors(sm *StateMachine, context *ValidationContext, errors *ValidationErrors) {
+	if sm == nil {
+		errors.AddError(ErrorTypeRequired, "MachineConfiguration", "StateMachine", "state machine cannot be nil", context.Path)
+		return
+	}
+	model := newConfigurationModel(sm)
+	if c.MachineID != sm.ID {
+		errors.AddError(ErrorTypeReference, "MachineConfiguration", "MachineID",
+			fmt.Sprintf("configuration of state machine '%s' does not belong to state machine '%s'", c.MachineID, sm.ID),
+			context.WithPath("MachineID").Path)
+	}
+
+	for _, regionID := range slices.Sorted(maps.Keys(c.Active)) {
+		vertexID := c.Active[regionID]
+		path := context.WithPath("Active").WithPath(regionID).Path
+		region := model.regions[regionID]
+		switch {
+		case region == nil:
+			errors.AddError(ErrorTypeReference, "MachineConfiguration", "Active",
+				fmt.Sprintf("active region '%s' is not a region of the state machine%s", regionID, didYouMean(regionID, model.regions)), path)
+		case !model.restsIn(region, vertexID):
+			errors.AddError(ErrorTypeReference, "MachineConfiguration", "Active",
+				fmt.Sprintf("active vertex '%s' of region '%s' is not a state or final state of that region%s", vertexID, regionID, didYouMean(vertexID, model.contents[region])), path)
+		case model.owners[region] != nil && c.Active[model.regionOf[model.owners[region].ID]] != model.owners[region].ID:
+			errors.AddError(ErrorTypeConstraint, "MachineConfiguration", "Active",
+				fmt.Sprintf("region '%s' is active but its state '%s' is not", regionID, model.owners[region].ID), path)
+		}
+	}
+
+	if !c.Terminated {
+		required := slices.Clone(nonNilRegions(sm.Regions))
+		for _, regionID := range slices.Sorted(maps.Keys(c.Active)) {
+			if state := model.states[c.Active[regionID]]; state != nil && model.regions[regionID] != nil {
+				required = append(required, nonNilRegions(state.Regions)...)
+			}
+		}
+		for _, region := range required {
+			if _, active := c.Active[region.ID]; !active {
+				owner := "the state machine"
+				if state := model.owners[region]; state != nil {
+					owner = fmt.Sprintf("active state '%s'", state.ID)
+				}
+				errors.AddError(ErrorTypeMultiplicity, "MachineConfiguration", "Active",
+					fmt.Sprintf("region '%s' of %s has no active state", region.ID, owner), context.WithPath("Active").Path)
+			}
+		}
+	}
+
+	for _, regionID := range slices.Sorted(maps.Keys(c.History)) {
+		vertexID := c.History[regionID]
+		path := context.WithPath("History").WithPath(regionID).Path
+		if region := model.regions[regionID]; region == nil {
+			errors.AddError(ErrorTypeReference, "MachineConfiguration", "History",
+				fmt.Sprintf("history of region '%s', which is not a region of the state machine%s", regionID, didYouMean(regionID, model.regions)), path)
+		} else if !model.restsIn(region, vertexID) {
+			errors.AddError(ErrorTypeReference, "MachineConfiguration", "History",
+				fmt.Sprintf("history of region '%s' names '%s', which is not a state or final state of that region%s", regionID, vertexID, didYouMean(vertexID, model.contents[region])), path)
+		}
+	}
+
+	for _, queue := range []struct {
+		field       string
+		occurrences []EventOccurrence
+	}{{"Queued", c.Queued}, {"Deferred", c.Deferred}} {
+		field := queue.field
+		for i, occurrence := range queue.occurrences {
+			path := context.WithPathIndex(field, i).Path
+			if !model.events[occurrence.EventID] {
+				errors.AddWarning(ErrorTypeReference, "MachineConfiguration", field,
+					fmt.Sprintf("event '%s' is not handled by any trigger of the state machine%s", occurrence.EventID, didYouMean(occurrence.EventID, model.events)), path)
+			} else if field == "Deferred" && !c.defers(model, occurrence.EventID) {
+				errors.AddWarning(ErrorTypeConstraint, "MachineConfiguration", field,
+					fmt.Sprintf("event '%s' is deferred but no active state defers it", occurrence.EventID), path)
+			}
+		}
+	}
+}
+
+// defers reports whether an active state lists the event among its
+// deferrable triggers
+func (c *MachineConfiguration) defers(model *configurationModel, eventID string) bool {
+	for _, id := range c.Active {
+		if state := model.states[id]; state != nil {
+			for _, trigger := range state.DeferrableTriggers {
+				if trigger != nil && trigger.EventKey() == eventID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// configurationModel indexes what checking a configuration needs to know
+// about a state machine
+type configurationModel struct {
+	regions  map[string]*Region          // By ID
+	owners   map[*Region]*State          // Owning state, nil for top-level regions
+	states   map[string]*State           // By ID
+	regionOf map[string]string           // Region ID by state or final state ID
+	contents map[*Region]map[string]bool // IDs of the states and final states declared in each region
+	events   map[string]bool             // Keys of the events triggers and deferrals handle
+
+	pseudostates map[string]*Region // Containing region by pseudostate ID
+}
+
+// newConfigurationModel indexes sm
+func newConfigurationModel(sm *StateMachine) *configurationModel {
+	model := &configurationModel{
+		regions:  make(map[string]*Region),
+		owners:   make(map[*Region]*State),
+		states:   make(map[string]*State),
+		regionOf: make(map[string]string),
+		contents: make(map[*Region]map[string]bool),
+		events:   make(map[string]bool),
+
+		pseudostates: make(map[string]*Region),
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		if _, exists := model.regions[region.ID]; !exists {
+			model.regions[region.ID] = region
+		}
+		contents := make(map[string]bool)
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			contents[state.ID] = true
+			model.states[state.ID] = state
+			model.regionOf[state.ID] = region.ID
+			for _, nested := range state.Regions {
+				model.owners[nested] = state
+			}
+			for _, trigger := range state.DeferrableTriggers {
+				model.events[trigger.EventKey()] = true
+			}
+		}
+		for _, vertex := range region.Vertices {
+			switch {
+			case vertex == nil:
+			case vertex.Type == "finalstate":
+				contents[vertex.ID] = true
+				model.regionOf[vertex.ID] = region.ID
+			case vertex.Type == "pseudostate":
+				model.pseudostates[vertex.ID] = region
+			}
+		}
+		model.contents[region] = contents
+	})
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		for _, trigger := range transition.Triggers {
+			model.events[trigger.EventKey()] = true
+		}
+	})
+	delete(model.events, "")
+	return model
+}
+
+// restsIn reports whether the vertex is a state or final state declared in
+// the region
+func (m *configurationModel) restsIn(region *Region, vertexID string) bool {
+	return m.contents[region][vertexID]
+}
+
+// Snapshot returns the current configuration of the interpreter
+func (in *Interpreter) Snapshot() *MachineConfiguration {
+	c := &MachineConfiguration{
+		MachineID:  in.sm.ID,
+		Version:    in.sm.Version,
+		Active:     in.RegionConfiguration(),
+		Queued:     slices.Concat(in.internal, in.external),
+		Deferred:   slices.Clone(in.deferred),
+		Terminated: in.terminated,
+	}
+	for region, id := range in.history {
+		if c.History == nil {
+			c.History = make(map[string]string)
+		}
+		c.History[in.regionID[region]] = id
+	}
+	return c
+}
+
+// Restore puts an interpreter that has not been started into the
+// configuration c, as an alternative to Start, without running entry
+// behaviors. Queued events are processed right away; completion events of
+// the restored states are not raised again. The configuration must be valid
+// for the interpreter's state machine.
+func (in *Interpreter) Restore(c *MachineConfiguration) error {
+	if in.started {
+		return fmt.Errorf("interpreter for state machine '%s' has already been started", in.sm.ID)
+	}
+	if c == nil {
+		return fmt.Errorf("configuration cannot be nil")
+	}
+	if err := c.Validate(in.sm); err != nil {
+		return fmt.Errorf("cannot restore state machine '%s': %w", in.sm.ID, err)
+	}
+
+	regions := make(map[string]*Region, len(in.regionID))
+	for region, id := range in.regionID {
+		regions[id] = region
+	}
+	for regionID, vertexID := range c.Active {
+		in.active[regions[regionID]] = vertexID
+	}
+	for regionID, vertexID := range c.History {
+		in.history[regions[regionID]] = vertexID
+	}
+	in.external = slices.Clone(c.Queued)
+	in.deferred = slices.Clone(c.Deferred)
+	in.terminated = c.Terminated
+	in.started = true
+	return in.run()
+}
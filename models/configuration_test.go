@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInterpreter_SnapshotRestore(t *testing.T) {
+	sm := newPlayerMachine()
+	in := startInterpreter(t, sm, InterpreterHooks{})
+	for _, event := range []string{"loaded", "play", "loaded", "stop"} {
+		if err := in.Send(event); err != nil {
+			t.Fatalf("Send(%s) error = %v", event, err)
+		}
+	}
+
+	snapshot := in.Snapshot()
+	if err := snapshot.Validate(sm); err != nil {
+		t.Fatalf("Snapshot() is invalid: %v", err)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"machine_id":"player","version":"1.0","active":{"main":"idle"},"history":{"audio":"streaming","main":"playing","video":"buffering"}}`
+	if string(data) != want {
+		t.Errorf("snapshot JSON = %s, want %s", data, want)
+	}
+
+	var stored MachineConfiguration
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	restored, err := NewInterpreter(sm, InterpreterHooks{})
+	if err != nil {
+		t.Fatalf("NewInterpreter() error = %v", err)
+	}
+	if err := restored.Restore(&stored); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got, want := restored.Configuration(), in.Configuration(); !slices.Equal(got, want) {
+		t.Errorf("restored configuration = %v, want %v", got, want)
+	}
+	if err := restored.Restore(&stored); err == nil {
+		t.Error("Restore() should refuse a started interpreter")
+	}
+}
+
+func TestInterpreter_RestoreDeferred(t *testing.T) {
+	sm := newPlayerMachine()
+	in, _ := NewInterpreter(sm, InterpreterHooks{})
+	err := in.Restore(&MachineConfiguration{
+		MachineID: "player",
+		Active:    map[string]string{"main": "idle"},
+		Deferred:  []EventOccurrence{{EventID: "loaded"}},
+		Queued:    []EventOccurrence{{EventID: "play"}},
+	})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	// The queued event enters playing, which releases the deferred event
+	if got := in.RegionConfiguration(); got["audio"] != "streaming" || got["video"] != "buffering" {
+		t.Errorf("configuration after restore = %v", got)
+	}
+	if len(in.Deferred()) != 0 {
+		t.Errorf("Deferred() = %v, want the event released", in.Deferred())
+	}
+}
+
+func TestMachineConfiguration_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration MachineConfiguration
+		wantErrors    []string
+		wantWarnings  []string
+	}{
+		{
+			name:          "orthogonal state with both regions",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{"main": "playing", "audio": "loading", "video": "rendering"}},
+		},
+		{
+			name:          "terminated instance",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{}, Terminated: true},
+		},
+		{
+			name:          "other machine",
+			configuration: MachineConfiguration{MachineID: "recorder", Active: map[string]string{"main": "idle"}},
+			wantErrors:    []string{"configuration of state machine 'recorder' does not belong to state machine 'player'"},
+		},
+		{
+			name:          "missing orthogonal region",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{"main": "playing", "audio": "loading"}},
+			wantErrors:    []string{"region 'video' of active state 'playing' has no active state"},
+		},
+		{
+			name:          "missing top-level region",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{}},
+			wantErrors:    []string{"region 'main' of the state machine has no active state"},
+		},
+		{
+			name:          "unknown states and regions",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{"main": "idel", "sound": "loading"}},
+			wantErrors: []string{
+				"active vertex 'idel' of region 'main' is not a state or final state of that region; did you mean 'idle'?",
+				"active region 'sound' is not a region of the state machine",
+			},
+		},
+		{
+			name:          "pseudostate is not a resting vertex",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{"main": "initial"}},
+			wantErrors:    []string{"active vertex 'initial' of region 'main' is not a state or final state of that region"},
+		},
+		{
+			name:          "region of inactive state",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{"main": "idle", "audio": "loading"}},
+			wantErrors:    []string{"region 'audio' is active but its state 'playing' is not"},
+		},
+		{
+			name:          "history outside its region",
+			configuration: MachineConfiguration{MachineID: "player", Active: map[string]string{"main": "idle"}, History: map[string]string{"audio": "buffering"}},
+			wantErrors:    []string{"history of region 'audio' names 'buffering', which is not a state or final state of that region"},
+		},
+		{
+			name: "unhandled and undeferred events",
+			configuration: MachineConfiguration{
+				MachineID: "player",
+				Active:    map[string]string{"main": "playing", "audio": "loading", "video": "rendering"},
+				Queued:    []EventOccurrence{{EventID: "pause"}},
+				Deferred:  []EventOccurrence{{EventID: "loaded"}},
+			},
+			wantWarnings: []string{
+				"event 'pause' is not handled by any trigger of the state machine",
+				"event 'loaded' is deferred but no active state defers it",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := &ValidationErrors{}
+			tt.configuration.ValidateWithErrors(newPlayerMachine(), NewValidationContext(), errors)
+			for _, check := range []struct {
+				kind  string
+				got   []*ValidationError
+				wants []string
+			}{{"errors", errors.Errors, tt.wantErrors}, {"warnings", errors.Warnings, tt.wantWarnings}} {
+				var messages []string
+				for _, err := range check.got {
+					messages = append(messages, err.Message)
+				}
+				if strings.Join(messages, "\n") != strings.Join(check.wants, "\n") {
+					t.Errorf("Validate() %s:\n%s\nwant:\n%s", check.kind, strings.Join(messages, "\n"), strings.Join(check.wants, "\n"))
+				}
+			}
+		})
+	}
+}
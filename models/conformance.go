@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Observation is one step observed in a running system: an event and the
+// state the system was in after handling it
+type Observation struct {
+	EventID string `json:"event_id,omitempty"` // Empty to check the state without dispatching an event, e.g. the initial state
+	State   string `json:"state"`              // ID or name of a state or final state that must be active
+}
+
+// ConformanceDivergence describes the first observation that the model does
+// not allow
+type ConformanceDivergence struct {
+	Index       int         `json:"index"` // Position of the observation in the trace
+	Observation Observation `json:"observation"`
+
+	// Before and After are the configurations of the model before and after
+	// the observed event, as returned by Interpreter.Configuration
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+
+	// Enabled holds the IDs of the transitions the event could fire in the
+	// Before configuration; empty if the model ignores the event there
+	Enabled []string `json:"enabled,omitempty"`
+
+	Reason string `json:"reason"`
+}
+
+// ConformanceReport is the result of CheckConformance
+type ConformanceReport struct {
+	Conforms   bool                   `json:"conforms"`
+	Checked    int                    `json:"checked"` // Number of observations that conformed
+	Divergence *ConformanceDivergence `json:"divergence,omitempty"`
+}
+
+// String summarizes the report in one line
+func (r *ConformanceReport) String() string {
+	if r.Conforms {
+		return fmt.Sprintf("trace conforms to the model (%d observations)", r.Checked)
+	}
+	d := r.Divergence
+	return fmt.Sprintf("trace diverges at observation %d (event '%s', state '%s'): %s", d.Index, d.Observation.EventID, d.Observation.State, d.Reason)
+}
+
+// CheckConformance replays a trace observed in a real system against sm and
+// reports whether it is a legal run of the model, stopping at the first
+// divergence. The model is started and each observed event is dispatched to
+// an Interpreter; the observed state must then be active. The executor
+// evaluates guards and may be nil, in which case every guard holds and
+// behaviors do nothing.
+//
+// An error is returned if the model cannot be executed; a trace that does not
+// conform is reported through the ConformanceReport.
+func CheckConformance(sm *StateMachine, trace []Observation, executor ActionExecutor) (*ConformanceReport, error) {
+	in, err := NewInterpreter(sm, InterpreterHooks{Executor: executor})
+	if err != nil {
+		return nil, err
+	}
+	if err := in.Start(); err != nil {
+		return nil, err
+	}
+
+	report := &ConformanceReport{Conforms: true}
+	for i, observation := range trace {
+		before := in.Configuration()
+		var enabled []string
+		if observation.EventID != "" {
+			transitions, err := in.Enabled(observation.EventID)
+			if err != nil {
+				return nil, err
+			}
+			for _, transition := range transitions {
+				enabled = append(enabled, transition.ID)
+			}
+			if err := in.Send(observation.EventID); err != nil {
+				return nil, err
+			}
+		}
+
+		if in.hasActive(observation.State) {
+			report.Checked++
+			continue
+		}
+
+		divergence := &ConformanceDivergence{
+			Index:       i,
+			Observation: observation,
+			Before:      before,
+			After:       in.Configuration(),
+			Enabled:     enabled,
+		}
+		switch {
+		case observation.EventID == "":
+			divergence.Reason = fmt.Sprintf("state '%s' is not active; the model is in %s", observation.State, describeConfiguration(divergence.After))
+		case len(enabled) == 0:
+			divergence.Reason = fmt.Sprintf("event '%s' enables no transition in %s, so the model stays there instead of reaching '%s'", observation.EventID, describeConfiguration(before), observation.State)
+		default:
+			divergence.Reason = fmt.Sprintf("event '%s' fires %s and leads to %s, not '%s'", observation.EventID, strings.Join(enabled, ", "), describeConfiguration(divergence.After), observation.State)
+		}
+		report.Conforms = false
+		report.Divergence = divergence
+		break
+	}
+	return report, nil
+}
+
+// hasActive reports whether a state or final state with the given ID or name
+// is active
+func (in *Interpreter) hasActive(idOrName string) bool {
+	if in.IsActive(idOrName) {
+		return true
+	}
+	for _, id := range in.Configuration() {
+		if state, ok := in.states[id]; ok && state.Name == idOrName {
+			return true
+		}
+		if vertex, ok := in.vertices[id]; ok && vertex.Name == idOrName {
+			return true
+		}
+	}
+	return false
+}
+
+// describeConfiguration formats a configuration for divergence reasons
+func describeConfiguration(configuration []string) string {
+	if len(configuration) == 0 {
+		return "no state"
+	}
+	return "[" + strings.Join(configuration, ", ") + "]"
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckConformance(t *testing.T) {
+	tests := []struct {
+		name        string
+		trace       []Observation
+		wantChecked int
+		wantIndex   int
+		wantEnabled []string
+		wantReason  string
+	}{
+		{
+			name: "legal run",
+			trace: []Observation{
+				{State: "idle"},
+				{EventID: "play", State: "playing"},
+				{EventID: "ready", State: "rendering"},
+				{EventID: "tick", State: "loading"},
+				{EventID: "stop", State: "idle"},
+			},
+			wantChecked: 5,
+		},
+		{
+			name: "ignored event leaves the state unchanged",
+			trace: []Observation{
+				{EventID: "stop", State: "idle"},
+				{EventID: "play", State: "buffering"},
+			},
+			wantChecked: 2,
+		},
+		{
+			name: "wrong initial state",
+			trace: []Observation{
+				{State: "playing"},
+			},
+			wantIndex:  0,
+			wantReason: "state 'playing' is not active; the model is in [idle]",
+		},
+		{
+			name: "event not allowed in the current state",
+			trace: []Observation{
+				{EventID: "play", State: "playing"},
+				{EventID: "play", State: "idle"},
+			},
+			wantChecked: 1,
+			wantIndex:   1,
+			wantReason:  "event 'play' enables no transition",
+		},
+		{
+			name: "event leads elsewhere",
+			trace: []Observation{
+				{EventID: "play", State: "playing"},
+				{EventID: "tick", State: "streaming"},
+			},
+			wantChecked: 1,
+			wantIndex:   1,
+			wantEnabled: []string{"audio-tick", "video-tick"},
+			wantReason:  "event 'tick' fires audio-tick, video-tick and leads to [playing, loading, buffering], not 'streaming'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CheckConformance(newPlayerMachine(), tt.trace, nil)
+			if err != nil {
+				t.Fatalf("CheckConformance() error = %v", err)
+			}
+			if report.Checked != tt.wantChecked {
+				t.Errorf("Checked = %d, want %d", report.Checked, tt.wantChecked)
+			}
+			if tt.wantReason == "" {
+				if !report.Conforms || report.Divergence != nil {
+					t.Fatalf("report = %s, want conforming", report)
+				}
+				return
+			}
+			if report.Conforms || report.Divergence == nil {
+				t.Fatalf("report = %s, want a divergence", report)
+			}
+			d := report.Divergence
+			if d.Index != tt.wantIndex {
+				t.Errorf("Index = %d, want %d", d.Index, tt.wantIndex)
+			}
+			if !reflect.DeepEqual(d.Enabled, tt.wantEnabled) {
+				t.Errorf("Enabled = %v, want %v", d.Enabled, tt.wantEnabled)
+			}
+			if !strings.Contains(d.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", d.Reason, tt.wantReason)
+			}
+			if !strings.Contains(report.String(), "diverges at observation") {
+				t.Errorf("String() = %q", report.String())
+			}
+		})
+	}
+}
+
+func TestCheckConformance_MatchesNames(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Regions[0].States[1].Name = "Playing"
+
+	report, err := CheckConformance(sm, []Observation{{EventID: "play", State: "Playing"}}, nil)
+	if err != nil {
+		t.Fatalf("CheckConformance() error = %v", err)
+	}
+	if !report.Conforms {
+		t.Errorf("report = %s, want the state to match by name", report)
+	}
+}
+
+func TestCheckConformance_UsesExecutor(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Regions[0].Transitions[1].Guard = &Constraint{ID: "licensed"}
+	executor := NewFuncExecutor().RegisterGuard("licensed", func(*ExecutionContext) (bool, error) { return false, nil })
+
+	report, err := CheckConformance(sm, []Observation{{EventID: "play", State: "playing"}}, executor)
+	if err != nil {
+		t.Fatalf("CheckConformance() error = %v", err)
+	}
+	if report.Conforms {
+		t.Error("report conforms, want the guard to block play")
+	}
+}
+
+func TestCheckConformance_Errors(t *testing.T) {
+	if _, err := CheckConformance(nil, nil, nil); err == nil {
+		t.Error("CheckConformance(nil) error = nil")
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+// ContextKey identifies a value of type T that callers attach to a
+// ValidationContext for custom rules, such as a tenant ID or feature flags,
+// so organization-specific rules can vary per call without global state.
+// Values are stored in the context's Metadata under the key's name and are
+// inherited by every context derived from it.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey returns the key of values of type T stored under name.
+// Declare keys once, e.g. as package-level variables, and share them
+// between the code attaching values and the rules reading them.
+func NewContextKey[T any](name string) ContextKey[T] {
+	return ContextKey[T]{name: name}
+}
+
+// Name returns the metadata key the values are stored under
+func (k ContextKey[T]) Name() string {
+	return k.name
+}
+
+// With returns a new context carrying value under the key; context itself
+// is left unchanged. A nil context starts from NewValidationContext.
+func (k ContextKey[T]) With(context *ValidationContext, value T) *ValidationContext {
+	return context.WithMetadata(k.name, value)
+}
+
+// Value returns the value stored under the key. It reports false when the
+// context is nil, holds no value under the key's name, or holds a value of
+// another type.
+func (k ContextKey[T]) Value(context *ValidationContext) (T, bool) {
+	var zero T
+	if context == nil {
+		return zero, false
+	}
+	raw, ok := context.GetMetadata(k.name)
+	if !ok {
+		return zero, false
+	}
+	value, ok := raw.(T)
+	return value, ok
+}
+
+// ValueOr returns the value stored under the key, or fallback when Value
+// would report false
+func (k ContextKey[T]) ValueOr(context *ValidationContext, fallback T) T {
+	if value, ok := k.Value(context); ok {
+		return value
+	}
+	return fallback
+}
+
+// WithContextValue attaches value under key to the contexts a validation
+// with these options runs in
+func WithContextValue[T any](key ContextKey[T], value T) ValidationOption {
+	return func(o *ValidationOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]interface{})
+		}
+		o.Metadata[key.name] = value
+	}
+}
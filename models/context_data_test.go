@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var (
+	testTenantKey = NewContextKey[string]("tenant")
+	testFlagsKey  = NewContextKey[map[string]bool]("feature_flags")
+)
+
+func TestContextKey(t *testing.T) {
+	base := NewValidationContext()
+	withTenant := testTenantKey.With(base, "acme")
+
+	tests := []struct {
+		name    string
+		context *ValidationContext
+		want    string
+		wantOK  bool
+	}{
+		{name: "attached value", context: withTenant, want: "acme", wantOK: true},
+		{name: "inherited by derived contexts", context: withTenant.WithPath("Regions[0]").WithStateMachine(&StateMachine{}).WithProfile(StrictProfile), want: "acme", wantOK: true},
+		{name: "original context unchanged", context: base},
+		{name: "nil context", context: nil},
+		{name: "value of another type", context: base.WithMetadata("tenant", 42)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := testTenantKey.Value(tt.context)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Value() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			wantOr := "default"
+			if tt.wantOK {
+				wantOr = tt.want
+			}
+			if got := testTenantKey.ValueOr(tt.context, "default"); got != wantOr {
+				t.Errorf("ValueOr() = %q, want %q", got, wantOr)
+			}
+		})
+	}
+
+	flags := testFlagsKey.With(nil, map[string]bool{"strict-guards": true})
+	if got, ok := testFlagsKey.Value(flags); !ok || !got["strict-guards"] {
+		t.Errorf("Value() = %v, %v, want the attached flags", got, ok)
+	}
+	if testFlagsKey.Name() != "feature_flags" {
+		t.Errorf("Name() = %q, want feature_flags", testFlagsKey.Name())
+	}
+}
+
+// tenantGuardPolicy requires OCL guards for tenant acme only
+var tenantGuardPolicy = NewContextPolicy("acme-guards", func(sm *StateMachine, context *ValidationContext) []PolicyViolation {
+	if testTenantKey.ValueOr(context, "") != "acme" {
+		return nil
+	}
+	return RequireGuardLanguage("OCL").Evaluate(sm)
+})
+
+func TestContextPolicy_PerTenant(t *testing.T) {
+	profile := &ValidationProfile{Name: "tenants", Policies: []ModelPolicy{tenantGuardPolicy}}
+
+	tests := []struct {
+		name      string
+		opts      []ValidationOption
+		wantError bool
+	}{
+		{name: "tenant with the policy", opts: []ValidationOption{WithProfile(profile), WithContextValue(testTenantKey, "acme")}, wantError: true},
+		{name: "other tenant", opts: []ValidationOption{WithProfile(profile), WithContextValue(testTenantKey, "globex")}},
+		{name: "no tenant", opts: []ValidationOption{WithProfile(profile)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := createValidStateMachine().ValidateWithOptions(tt.opts...)
+			hasPolicyError := err != nil && strings.Contains(err.Error(), "(policy acme-guards)")
+			if hasPolicyError != tt.wantError {
+				t.Errorf("error = %v, want policy error: %v", err, tt.wantError)
+			}
+		})
+	}
+
+	// Outside validation there is no context to read the tenant from
+	if violations := EvaluatePolicies(createValidStateMachine(), tenantGuardPolicy); len(violations) != 0 {
+		t.Errorf("EvaluatePolicies() = %v, want none without a context", violations)
+	}
+}
+
+func TestValidateAll_Metadata(t *testing.T) {
+	profile := &ValidationProfile{Name: "tenants", Policies: []ModelPolicy{tenantGuardPolicy}}
+	machines := []*StateMachine{createValidStateMachine()}
+
+	aggregator, err := ValidateAll(context.Background(), machines, BulkValidationOptions{
+		Profile:  profile,
+		Metadata: map[string]interface{}{testTenantKey.Name(): "acme"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateAll() error = %v", err)
+	}
+	results := aggregator.GetResults()["sm1"]
+	if results == nil || len(results.GetErrorsByType(ErrorTypePolicy)) != 1 {
+		t.Errorf("results = %v, want one policy error", results)
+	}
+
+	aggregator, _ = ValidateAllWithOptions(machines, WithProfile(profile), WithContextValue(testTenantKey, "globex"))
+	if results := aggregator.GetResults(); len(results) != 0 {
+		t.Errorf("results = %v, want none for another tenant", results)
+	}
+}
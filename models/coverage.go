@@ -0,0 +1,152 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Colors used by Coverage.PlantUML
+const (
+	coveredColor   = "#PaleGreen"
+	uncoveredColor = "#Salmon"
+)
+
+// Coverage accumulates the states and transitions visited by interpreter
+// runs of one state machine, e.g. across the test cases of a test suite.
+// States include final states; pseudostates are not tracked.
+type Coverage struct {
+	sm          *StateMachine
+	states      []string // Tracked vertex IDs in document order
+	transitions []string // Tracked transition IDs in document order
+	visits      map[string]int
+	fired       map[string]int
+}
+
+// NewCoverage returns an empty coverage tracker for sm
+func NewCoverage(sm *StateMachine) *Coverage {
+	c := &Coverage{sm: sm, visits: make(map[string]int), fired: make(map[string]int)}
+	if sm == nil {
+		return c
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil {
+				c.states = append(c.states, state.ID)
+			}
+		}
+		for _, vertex := range region.Vertices {
+			if vertex != nil && vertex.Type == "finalstate" {
+				c.states = append(c.states, vertex.ID)
+			}
+		}
+	})
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		c.transitions = append(c.transitions, transition.ID)
+	})
+	return c
+}
+
+// Track returns hooks that record coverage and then call the given hooks.
+// Pass them to NewInterpreter for every run that should count.
+func (c *Coverage) Track(hooks InterpreterHooks) InterpreterHooks {
+	onEntry, onTransition := hooks.OnEntry, hooks.OnTransition
+	hooks.OnEntry = func(vertexID string) {
+		c.visits[vertexID]++
+		if onEntry != nil {
+			onEntry(vertexID)
+		}
+	}
+	hooks.OnTransition = func(transition *Transition, occurrence *EventOccurrence) {
+		c.fired[transition.ID]++
+		if onTransition != nil {
+			onTransition(transition, occurrence)
+		}
+	}
+	return hooks
+}
+
+// ElementCoverage reports the coverage of one kind of element
+type ElementCoverage struct {
+	Total     int            `json:"total"`
+	Covered   int            `json:"covered"`
+	Percent   float64        `json:"percent"`
+	Uncovered []string       `json:"uncovered,omitempty"` // IDs in document order
+	Counts    map[string]int `json:"counts"`              // Visits per ID, including uncovered elements with 0
+}
+
+// CoverageReport is a snapshot of a Coverage tracker
+type CoverageReport struct {
+	StateMachine string          `json:"state_machine"`
+	States       ElementCoverage `json:"states"`
+	Transitions  ElementCoverage `json:"transitions"`
+}
+
+// String summarizes the report in one line
+func (r *CoverageReport) String() string {
+	return fmt.Sprintf("states %d/%d (%.1f%%), transitions %d/%d (%.1f%%)",
+		r.States.Covered, r.States.Total, r.States.Percent,
+		r.Transitions.Covered, r.Transitions.Total, r.Transitions.Percent)
+}
+
+// Report returns the coverage accumulated so far
+func (c *Coverage) Report() *CoverageReport {
+	report := &CoverageReport{
+		States:      elementCoverage(c.states, c.visits),
+		Transitions: elementCoverage(c.transitions, c.fired),
+	}
+	if c.sm != nil {
+		report.StateMachine = c.sm.ID
+	}
+	return report
+}
+
+// elementCoverage summarizes the counts of the given elements
+func elementCoverage(ids []string, counts map[string]int) ElementCoverage {
+	coverage := ElementCoverage{Total: len(ids), Counts: make(map[string]int, len(ids)), Percent: 100}
+	for _, id := range ids {
+		coverage.Counts[id] = counts[id]
+		if counts[id] > 0 {
+			coverage.Covered++
+		} else {
+			coverage.Uncovered = append(coverage.Uncovered, id)
+		}
+	}
+	if coverage.Total > 0 {
+		coverage.Percent = 100 * float64(coverage.Covered) / float64(coverage.Total)
+	}
+	return coverage
+}
+
+// WriteJSON writes the coverage report as indented JSON
+func (c *Coverage) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.Report())
+}
+
+// PlantUML returns a PlantUML diagram of the state machine with covered
+// states and transitions in green and uncovered ones in red
+func (c *Coverage) PlantUML() string {
+	color := func(counts map[string]int) func(string) string {
+		return func(id string) string {
+			if counts[id] > 0 {
+				return coveredColor
+			}
+			return uncoveredColor
+		}
+	}
+	tracked := make(map[string]bool, len(c.states))
+	for _, id := range c.states {
+		tracked[id] = true
+	}
+	return plantUML(c.sm, plantUMLStyle{
+		vertex: func(id string) string {
+			if !tracked[id] {
+				return ""
+			}
+			return color(c.visits)(id)
+		},
+		transition: color(c.fired),
+	})
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runCoverage runs the player machine once per event sequence, tracking
+// every run with the same coverage tracker
+func runCoverage(t *testing.T, coverage *Coverage, sm *StateMachine, runs ...[]string) {
+	t.Helper()
+	for _, events := range runs {
+		in := startInterpreter(t, sm, coverage.Track(InterpreterHooks{}))
+		for _, event := range events {
+			if err := in.Send(event); err != nil {
+				t.Fatalf("Send(%q) error = %v", event, err)
+			}
+		}
+	}
+}
+
+func TestCoverage_Report(t *testing.T) {
+	tests := []struct {
+		name                string
+		runs                [][]string
+		wantStates          int
+		wantTransitions     int
+		wantUncoveredStates []string
+	}{
+		{
+			name:                "start only",
+			runs:                [][]string{nil},
+			wantStates:          1,
+			wantTransitions:     1,
+			wantUncoveredStates: []string{"playing", "loading", "streaming", "buffering", "rendering"},
+		},
+		{
+			name:                "runs accumulate",
+			runs:                [][]string{{"play", "loaded"}, {"play", "ready", "stop"}},
+			wantStates:          6,
+			wantTransitions:     7,
+			wantUncoveredStates: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newPlayerMachine()
+			coverage := NewCoverage(sm)
+			runCoverage(t, coverage, sm, tt.runs...)
+			report := coverage.Report()
+
+			if report.States.Total != 6 || report.Transitions.Total != 9 {
+				t.Fatalf("totals = %d states, %d transitions, want 6 and 9", report.States.Total, report.Transitions.Total)
+			}
+			if report.States.Covered != tt.wantStates {
+				t.Errorf("States.Covered = %d, want %d", report.States.Covered, tt.wantStates)
+			}
+			if report.Transitions.Covered != tt.wantTransitions {
+				t.Errorf("Transitions.Covered = %d, want %d", report.Transitions.Covered, tt.wantTransitions)
+			}
+			if !reflect.DeepEqual(report.States.Uncovered, tt.wantUncoveredStates) {
+				t.Errorf("States.Uncovered = %v, want %v", report.States.Uncovered, tt.wantUncoveredStates)
+			}
+			if want := 100 * float64(tt.wantStates) / 6; report.States.Percent != want {
+				t.Errorf("States.Percent = %g, want %g", report.States.Percent, want)
+			}
+		})
+	}
+}
+
+func TestCoverage_CountsAndChaining(t *testing.T) {
+	sm := newPlayerMachine()
+	coverage := NewCoverage(sm)
+	var entered []string
+	hooks := coverage.Track(InterpreterHooks{OnEntry: func(id string) { entered = append(entered, id) }})
+	in := startInterpreter(t, sm, hooks)
+	for _, event := range []string{"play", "stop", "play"} {
+		if err := in.Send(event); err != nil {
+			t.Fatalf("Send(%q) error = %v", event, err)
+		}
+	}
+
+	report := coverage.Report()
+	if got := report.States.Counts["idle"]; got != 2 {
+		t.Errorf("Counts[idle] = %d, want 2", got)
+	}
+	if got := report.Transitions.Counts["play"]; got != 2 {
+		t.Errorf("Counts[play] = %d, want 2", got)
+	}
+	if got, ok := report.Transitions.Counts["audio-tick"]; !ok || got != 0 {
+		t.Errorf("Counts[audio-tick] = %d, %v, want an explicit 0", got, ok)
+	}
+	// idle, playing, loading and buffering are each entered twice
+	if len(entered) != 8 {
+		t.Errorf("chained OnEntry saw %v, want 8 entries", entered)
+	}
+}
+
+func TestCoverage_WriteJSON(t *testing.T) {
+	sm := newPlayerMachine()
+	coverage := NewCoverage(sm)
+	runCoverage(t, coverage, sm, []string{"play"})
+
+	var buf bytes.Buffer
+	if err := coverage.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded CoverageReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if decoded.StateMachine != "player" || decoded.States.Covered != 4 {
+		t.Errorf("decoded = %+v", decoded)
+	}
+	if !strings.Contains(coverage.Report().String(), "states 4/6") {
+		t.Errorf("String() = %q", coverage.Report().String())
+	}
+}
+
+func TestCoverage_PlantUML(t *testing.T) {
+	sm := newPlayerMachine()
+	coverage := NewCoverage(sm)
+	runCoverage(t, coverage, sm, []string{"play"})
+	diagram := coverage.PlantUML()
+
+	for _, want := range []string{
+		`state "idle" as idle #PaleGreen`,
+		`state "streaming" as streaming #Salmon`,
+		"state initial <<start>>\n",
+		"idle -[#PaleGreen]-> playing : play",
+		"playing -[#Salmon]-> idle : stop",
+	} {
+		if !strings.Contains(diagram, want) {
+			t.Errorf("PlantUML() missing %q in\n%s", want, diagram)
+		}
+	}
+}
+
+func TestCoverage_NilMachine(t *testing.T) {
+	report := NewCoverage(nil).Report()
+	if report.States.Total != 0 || report.States.Percent != 100 {
+		t.Errorf("report = %+v, want empty with 100%%", report)
+	}
+}
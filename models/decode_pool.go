@@ -0,0 +1,223 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DecodePool decodes JSON state machines into recycled memory, for
+// services that ingest many machines and would otherwise spend most of
+// their time allocating model structs and collecting them again. Machines
+// decoded by the pool are handed back with PooledStateMachine.Release, after
+// which their regions, states, transitions, events and triggers, and the
+// backing arrays of their slices and maps, are reused by later decodes.
+// A DecodePool is safe for concurrent use.
+type DecodePool struct {
+	limits   ResourceLimits
+	machines sync.Pool // *StateMachine, scrubbed
+	buffers  sync.Pool // *bytes.Buffer
+}
+
+// NewDecodePool creates a pool that enforces the given limits like
+// DecodeStateMachine
+func NewDecodePool(limits ResourceLimits) *DecodePool {
+	return &DecodePool{
+		limits:   limits,
+		machines: sync.Pool{New: func() any { return new(StateMachine) }},
+		buffers:  sync.Pool{New: func() any { return new(bytes.Buffer) }},
+	}
+}
+
+// PooledStateMachine is a state machine decoded by a DecodePool. It must
+// not be used, nor anything reachable from it, after Release.
+type PooledStateMachine struct {
+	*StateMachine
+	pool *DecodePool
+}
+
+// Release returns the state machine's memory to the pool. Release zeroes
+// the machine's elements, so do not keep references to them or add
+// elements that are used elsewhere; Clone the machine to keep a copy.
+// Calling Release more than once has no effect.
+func (m *PooledStateMachine) Release() {
+	if m.StateMachine == nil || m.pool == nil {
+		return
+	}
+	scrubStateMachine(m.StateMachine)
+	m.pool.machines.Put(m.StateMachine)
+	m.StateMachine = nil
+}
+
+// Decode reads a JSON encoded state machine from r like DecodeStateMachine,
+// reusing released memory. Empty collections decode as nil.
+func (p *DecodePool) Decode(r io.Reader) (*PooledStateMachine, error) {
+	buffer := p.buffers.Get().(*bytes.Buffer)
+	buffer.Reset()
+	defer p.buffers.Put(buffer)
+
+	if p.limits.MaxJSONBytes > 0 {
+		r = io.LimitReader(r, p.limits.MaxJSONBytes+1)
+	}
+	if _, err := buffer.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("failed to read state machine: %w", err)
+	}
+	if p.limits.MaxJSONBytes > 0 && int64(buffer.Len()) > p.limits.MaxJSONBytes {
+		return nil, &ResourceLimitError{Limit: LimitJSONBytes, Max: p.limits.MaxJSONBytes, Actual: int64(buffer.Len())}
+	}
+
+	sm := p.machines.Get().(*StateMachine)
+	if err := json.Unmarshal(buffer.Bytes(), sm); err != nil {
+		scrubStateMachine(sm)
+		p.machines.Put(sm)
+		return nil, fmt.Errorf("failed to decode state machine: %w", err)
+	}
+	dropEmptyCollections(sm)
+	if err := p.limits.Check(sm); err != nil {
+		scrubStateMachine(sm)
+		p.machines.Put(sm)
+		return nil, err
+	}
+	return &PooledStateMachine{StateMachine: sm, pool: p}, nil
+}
+
+// scrubStateMachine zeroes a decoded state machine for reuse. Slices of
+// elements keep their backing arrays and the zeroed elements in them, which
+// encoding/json decodes into again, and maps keep their buckets; every other
+// field, including pointers to behaviors, constraints, endpoints and
+// submachines, is zeroed so that no value survives into the next decode.
+func scrubStateMachine(sm *StateMachine) {
+	*sm = StateMachine{
+		Regions:          scrubSlice(sm.Regions, scrubRegion),
+		ConnectionPoints: scrubSlice(sm.ConnectionPoints, func(ps *Pseudostate) { *ps = Pseudostate{} }),
+		Events:           scrubSlice(sm.Events, scrubEvent),
+		Entities:         scrubMap(sm.Entities),
+		Metadata:         scrubMap(sm.Metadata),
+	}
+}
+
+func scrubRegion(r *Region) {
+	*r = Region{
+		States:      scrubSlice(r.States, scrubState),
+		Transitions: scrubSlice(r.Transitions, scrubTransition),
+		Vertices:    scrubSlice(r.Vertices, func(v *Vertex) { *v = Vertex{} }),
+	}
+}
+
+func scrubState(s *State) {
+	*s = State{
+		Regions:            scrubSlice(s.Regions, scrubRegion),
+		Connections:        scrubSlice(s.Connections, scrubConnectionPointReference),
+		Features:           s.Features[:0],
+		DeferrableTriggers: scrubSlice(s.DeferrableTriggers, scrubTrigger),
+	}
+}
+
+func scrubConnectionPointReference(cpr *ConnectionPointReference) {
+	*cpr = ConnectionPointReference{
+		Entry: scrubSlice(cpr.Entry, func(ps *Pseudostate) { *ps = Pseudostate{} }),
+		Exit:  scrubSlice(cpr.Exit, func(ps *Pseudostate) { *ps = Pseudostate{} }),
+	}
+}
+
+func scrubTransition(t *Transition) {
+	*t = Transition{
+		Triggers: scrubSlice(t.Triggers, scrubTrigger),
+		Features: t.Features[:0],
+	}
+}
+
+func scrubTrigger(tr *Trigger) {
+	*tr = Trigger{}
+}
+
+func scrubEvent(e *Event) {
+	*e = Event{Properties: scrubMap(e.Properties)}
+}
+
+// scrubSlice scrubs the elements of a slice and truncates it, keeping its
+// backing array
+func scrubSlice[T any](elements []*T, scrub func(*T)) []*T {
+	for _, element := range elements {
+		if element != nil {
+			scrub(element)
+		}
+	}
+	return elements[:0]
+}
+
+// scrubMap empties a map, keeping its buckets
+func scrubMap[K comparable, V any](m map[K]V) map[K]V {
+	clear(m)
+	return m
+}
+
+// dropEmptyCollections replaces the empty slices and maps a scrubbed machine
+// keeps for reuse, and that were not filled by the decode, with nil, so that
+// pooled machines look like freshly decoded ones to code checking for nil
+func dropEmptyCollections(sm *StateMachine) {
+	sm.Regions = nilIfEmpty(sm.Regions)
+	sm.ConnectionPoints = nilIfEmpty(sm.ConnectionPoints)
+	sm.Events = nilIfEmpty(sm.Events)
+	if len(sm.Entities) == 0 {
+		sm.Entities = nil
+	}
+	if len(sm.Metadata) == 0 {
+		sm.Metadata = nil
+	}
+	for _, event := range sm.Events {
+		if event != nil && len(event.Properties) == 0 {
+			event.Properties = nil
+		}
+	}
+
+	var dropRegions func(regions []*Region)
+	dropRegions = func(regions []*Region) {
+		for _, region := range regions {
+			if region == nil {
+				continue
+			}
+			region.States = nilIfEmpty(region.States)
+			region.Transitions = nilIfEmpty(region.Transitions)
+			region.Vertices = nilIfEmpty(region.Vertices)
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				state.Regions = nilIfEmpty(state.Regions)
+				state.Connections = nilIfEmpty(state.Connections)
+				state.DeferrableTriggers = nilIfEmpty(state.DeferrableTriggers)
+				if len(state.Features) == 0 {
+					state.Features = nil
+				}
+				for _, connection := range state.Connections {
+					if connection != nil {
+						connection.Entry = nilIfEmpty(connection.Entry)
+						connection.Exit = nilIfEmpty(connection.Exit)
+					}
+				}
+				dropRegions(state.Regions)
+			}
+			for _, transition := range region.Transitions {
+				if transition == nil {
+					continue
+				}
+				transition.Triggers = nilIfEmpty(transition.Triggers)
+				if len(transition.Features) == 0 {
+					transition.Features = nil
+				}
+			}
+		}
+	}
+	dropRegions(sm.Regions)
+}
+
+// nilIfEmpty returns nil for an empty slice
+func nilIfEmpty[T any](elements []*T) []*T {
+	if len(elements) == 0 {
+		return nil
+	}
+	return elements
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// richStateMachine returns a machine that sets most optional fields, so
+// that values left behind by a released machine show up in the next decode
+func richStateMachine() *StateMachine {
+	sm := GenerateStateMachine(7, GeneratorOptions{MaxStates: 6, MaxDepth: 3})
+	sm.Entities = map[string]string{"order": "orders:42"}
+	sm.Metadata = map[string]interface{}{"owner": map[string]interface{}{"team": "payments"}}
+	sm.ConnectionPoints = []*Pseudostate{{Vertex: Vertex{ID: "enter", Name: "enter", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint}}
+	sm.Events[0].Properties = map[string]string{"topic": "orders"}
+	sm.Events[0].Deprecated = true
+	state := sm.Regions[0].States[0]
+	state.Features = []string{"beta"}
+	state.DoActivity = &Behavior{ID: "poll", Specification: "poll()"}
+	state.DeferrableTriggers = []*Trigger{{ID: "defer", Name: "defer", EventID: sm.Events[1].ID}}
+	return sm
+}
+
+func TestDecodePool_Decode(t *testing.T) {
+	pool := NewDecodePool(DefaultResourceLimits)
+	inputs := []*StateMachine{
+		richStateMachine(),
+		createValidStateMachine(),
+		GenerateStateMachine(3, GeneratorOptions{}),
+		{ID: "empty", Name: "Empty", Version: "1.0"},
+		richStateMachine(),
+	}
+
+	// Each machine is decoded after the previous one was released, so later
+	// decodes reuse the memory of earlier, differently shaped machines
+	for _, input := range inputs {
+		t.Run(input.ID, func(t *testing.T) {
+			data, err := json.Marshal(input)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			fresh, err := DecodeStateMachine(bytes.NewReader(data), DefaultResourceLimits)
+			if err != nil {
+				t.Fatalf("DecodeStateMachine() error = %v", err)
+			}
+			pooled, err := pool.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if changes := Diff(fresh, pooled.StateMachine); len(changes) > 0 {
+				t.Errorf("pooled decode differs from DecodeStateMachine: %v", changes)
+			}
+			if !fresh.CreatedAt.Equal(pooled.CreatedAt) || len(fresh.Metadata) != len(pooled.Metadata) || len(fresh.Entities) != len(pooled.Entities) {
+				t.Errorf("pooled header %v differs from %v", pooled.StateMachine, fresh)
+			}
+			pooled.Release()
+			pooled.Release() // Released machines are not put back twice
+			if pooled.StateMachine != nil {
+				t.Error("Release() should detach the state machine")
+			}
+		})
+	}
+}
+
+func TestDecodePool_Errors(t *testing.T) {
+	data, err := json.Marshal(createValidStateMachine())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		limits ResourceLimits
+		input  string
+		check  func(error) bool
+	}{
+		{
+			name:   "oversized input",
+			limits: ResourceLimits{MaxJSONBytes: int64(len(data))},
+			input:  string(data) + "garbage",
+			check: func(err error) bool {
+				var limitErr *ResourceLimitError
+				return errors.As(err, &limitErr) && limitErr.Limit == LimitJSONBytes
+			},
+		},
+		{
+			name:   "element limit",
+			limits: ResourceLimits{MaxElements: 3},
+			input:  string(data),
+			check:  func(err error) bool { return errors.Is(err, ErrResourceLimit) },
+		},
+		{
+			name:  "malformed JSON",
+			input: `{"id": "broken", "regions": [{"id": "r1", "states": [`,
+			check: func(err error) bool {
+				return err != nil && strings.Contains(err.Error(), "failed to decode state machine")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewDecodePool(tt.limits)
+			pooled, err := pool.Decode(strings.NewReader(tt.input))
+			if pooled != nil || !tt.check(err) {
+				t.Fatalf("Decode() = %v, %v", pooled, err)
+			}
+
+			// A failed decode leaves nothing behind for the next one
+			next, err := pool.Decode(strings.NewReader(`{"id": "next", "name": "Next", "version": "1.0"}`))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			defer next.Release()
+			if next.ID != "next" || next.Regions != nil {
+				t.Errorf("Decode() after a failure = %v", next.StateMachine)
+			}
+		})
+	}
+}
+
+// benchmarkMachineJSON encodes a medium-sized generated state machine
+func benchmarkMachineJSON(b *testing.B) []byte {
+	data, err := json.Marshal(GenerateStateMachine(42, GeneratorOptions{MaxStates: 8, MaxDepth: 3, Events: 6}))
+	if err != nil {
+		b.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
+
+func BenchmarkDecodeStateMachine(b *testing.B) {
+	data := benchmarkMachineJSON(b)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := DecodeStateMachine(bytes.NewReader(data), DefaultResourceLimits); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodePool(b *testing.B) {
+	data := benchmarkMachineJSON(b)
+	pool := NewDecodePool(DefaultResourceLimits)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		sm, err := pool.Decode(bytes.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		sm.Release()
+	}
+}
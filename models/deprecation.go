@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deprecatedElement is a state, transition or event that may be deprecated
+type deprecatedElement struct {
+	object     string // "State", "Transition" or "Event"
+	id         string
+	deprecated bool
+	replacedBy string
+	path       []string
+}
+
+// describe names the element for messages, e.g. "state 'idle'"
+func (e deprecatedElement) describe() string {
+	return fmt.Sprintf("%s '%s'", strings.ToLower(e.object), e.id)
+}
+
+// replacementHint suggests the replacement of a deprecated element, if it
+// has one
+func (e deprecatedElement) replacementHint() string {
+	if e.replacedBy == "" {
+		return ""
+	}
+	return fmt.Sprintf("; use '%s' instead", e.replacedBy)
+}
+
+// validateDeprecations checks the ReplacedBy references of states,
+// transitions and events and reports, as infos, deprecated elements that
+// are still in use: states targeted by transitions that are not deprecated,
+// events triggering such transitions or deferred by states that are not
+// deprecated, and deprecated transitions that can still fire because their
+// source is not deprecated.
+func (sm *StateMachine) validateDeprecations(context *ValidationContext, errors *ValidationErrors) {
+	elements := make(map[string]map[string]deprecatedElement) // By object, then ID
+	var order []deprecatedElement
+	add := func(element deprecatedElement) {
+		if element.id == "" {
+			return
+		}
+		if elements[element.object] == nil {
+			elements[element.object] = make(map[string]deprecatedElement)
+		}
+		if _, exists := elements[element.object][element.id]; !exists {
+			elements[element.object][element.id] = element
+			order = append(order, element)
+		}
+	}
+	subPath := func(path string) []string {
+		return append(append([]string{}, context.Path...), strings.Split(path, ".")...)
+	}
+
+	for i, event := range sm.Events {
+		if event != nil {
+			add(deprecatedElement{"Event", event.ID, event.Deprecated, event.ReplacedBy, context.WithPathIndex("Events", i).Path})
+		}
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, state := range region.States {
+			if state != nil {
+				add(deprecatedElement{"State", state.ID, state.Deprecated, state.ReplacedBy, subPath(fmt.Sprintf("%s.States[%d]", path, i))})
+			}
+		}
+		for i, transition := range region.Transitions {
+			if transition != nil {
+				add(deprecatedElement{"Transition", transition.ID, transition.Deprecated, transition.ReplacedBy, subPath(fmt.Sprintf("%s.Transitions[%d]", path, i))})
+			}
+		}
+	})
+
+	// Replacements must be other elements of the same kind
+	for _, element := range order {
+		if element.replacedBy == "" {
+			continue
+		}
+		replacement, exists := elements[element.object][element.replacedBy]
+		switch {
+		case !element.deprecated:
+			errors.AddWarning(
+				ErrorTypeConstraint,
+				element.object,
+				"ReplacedBy",
+				fmt.Sprintf("%s names replacement '%s' but is not deprecated", element.describe(), element.replacedBy),
+				element.path,
+			)
+		case element.replacedBy == element.id:
+			errors.AddError(
+				ErrorTypeReference,
+				element.object,
+				"ReplacedBy",
+				fmt.Sprintf("%s cannot be replaced by itself", element.describe()),
+				element.path,
+			)
+		case !exists:
+			errors.AddError(
+				ErrorTypeReference,
+				element.object,
+				"ReplacedBy",
+				fmt.Sprintf("%s is replaced by '%s', which is not a %s of this state machine", element.describe(), element.replacedBy, strings.ToLower(element.object)),
+				element.path,
+			)
+		case replacement.deprecated:
+			errors.AddInfo(
+				ErrorTypeReference,
+				element.object,
+				"ReplacedBy",
+				fmt.Sprintf("%s is replaced by %s, which is deprecated as well", element.describe(), replacement.describe()),
+				element.path,
+			)
+		}
+	}
+
+	// Deprecated elements still referenced by live ones
+	isDeprecated := func(object, id string) bool {
+		return elements[object][id].deprecated
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, state := range region.States {
+			if state == nil || state.Deprecated {
+				continue
+			}
+			for _, trigger := range state.DeferrableTriggers {
+				if event, exists := elements["Event"][trigger.EventKey()]; exists && event.deprecated {
+					errors.AddInfo(
+						ErrorTypeReference,
+						"State",
+						"DeferrableTriggers",
+						fmt.Sprintf("state '%s' defers deprecated %s%s", state.ID, event.describe(), event.replacementHint()),
+						subPath(fmt.Sprintf("%s.States[%d]", path, i)),
+					)
+				}
+			}
+		}
+		for i, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			transitionPath := subPath(fmt.Sprintf("%s.Transitions[%d]", path, i))
+			if transition.Deprecated {
+				if transition.Source != nil && !isDeprecated("State", transition.Source.ID) {
+					element := elements["Transition"][transition.ID]
+					errors.AddInfo(
+						ErrorTypeReference,
+						"Transition",
+						"Deprecated",
+						fmt.Sprintf("deprecated transition '%s' can still fire from '%s'%s", transition.ID, transition.Source.ID, element.replacementHint()),
+						transitionPath,
+					)
+				}
+				continue
+			}
+			if transition.Target != nil {
+				if state, exists := elements["State"][transition.Target.ID]; exists && state.deprecated {
+					errors.AddInfo(
+						ErrorTypeReference,
+						"Transition",
+						"Target",
+						fmt.Sprintf("transition '%s' targets deprecated %s%s", transition.ID, state.describe(), state.replacementHint()),
+						transitionPath,
+					)
+				}
+			}
+			for _, trigger := range transition.Triggers {
+				if event, exists := elements["Event"][trigger.EventKey()]; exists && event.deprecated {
+					errors.AddInfo(
+						ErrorTypeReference,
+						"Transition",
+						"Triggers",
+						fmt.Sprintf("transition '%s' is triggered by deprecated %s%s", transition.ID, event.describe(), event.replacementHint()),
+						transitionPath,
+					)
+				}
+			}
+		}
+	})
+}
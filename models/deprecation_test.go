@@ -0,0 +1,196 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// newDeprecatedPlayerMachine returns the player machine with an event
+// catalog for its triggers
+func newDeprecatedPlayerMachine() *StateMachine {
+	sm := newPlayerMachine()
+	for _, id := range []string{"play", "stop", "loaded", "ready", "tick", "start"} {
+		sm.Events = append(sm.Events, &Event{ID: id, Name: id, Type: EventTypeSignal})
+	}
+	return sm
+}
+
+func TestValidateDeprecations(t *testing.T) {
+	type finding struct {
+		severity Severity
+		message  string
+	}
+	tests := []struct {
+		name   string
+		modify func(sm *StateMachine)
+		want   []finding
+	}{
+		{name: "nothing deprecated", modify: func(sm *StateMachine) {}},
+		{
+			name: "deprecated state still targeted",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[0].Deprecated = true
+				sm.Regions[0].States[0].ReplacedBy = "playing"
+			},
+			want: []finding{
+				{SeverityInfo, "transition 'start' targets deprecated state 'idle'; use 'playing' instead"},
+				{SeverityInfo, "transition 'stop' targets deprecated state 'idle'; use 'playing' instead"},
+			},
+		},
+		{
+			name: "deprecated transition from deprecated source is quiet",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[1].Deprecated = true
+				sm.Regions[0].Transitions[1].Deprecated = true // play targets playing
+				sm.Regions[0].Transitions[2].Deprecated = true // stop leaves playing
+			},
+			want: []finding{
+				{SeverityInfo, "deprecated transition 'play' can still fire from 'idle'"},
+			},
+		},
+		{
+			name: "deprecated event",
+			modify: func(sm *StateMachine) {
+				sm.Events[2].Deprecated = true // loaded
+				sm.Events[2].ReplacedBy = "ready"
+			},
+			want: []finding{
+				{SeverityInfo, "state 'idle' defers deprecated event 'loaded'; use 'ready' instead"},
+				{SeverityInfo, "transition 'audio-loaded' is triggered by deprecated event 'loaded'; use 'ready' instead"},
+			},
+		},
+		{
+			name: "replacement is deprecated too",
+			modify: func(sm *StateMachine) {
+				sm.Events[4].Deprecated = true // tick
+				sm.Events[4].ReplacedBy = "start"
+				sm.Events[5].Deprecated = true // start is not used
+			},
+			want: []finding{
+				{SeverityInfo, "event 'tick' is replaced by event 'start', which is deprecated as well"},
+				{SeverityInfo, "transition 'audio-tick' is triggered by deprecated event 'tick'; use 'start' instead"},
+				{SeverityInfo, "transition 'video-tick' is triggered by deprecated event 'tick'; use 'start' instead"},
+			},
+		},
+		{
+			name: "missing replacement",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Deprecated = true
+				sm.Regions[0].Transitions[2].ReplacedBy = "halt"
+			},
+			want: []finding{
+				{SeverityError, "transition 'stop' is replaced by 'halt', which is not a transition of this state machine"},
+				{SeverityInfo, "deprecated transition 'stop' can still fire from 'playing'; use 'halt' instead"},
+			},
+		},
+		{
+			name: "replacement of another kind",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[0].Deprecated = true
+				sm.Regions[0].States[0].ReplacedBy = "play"
+				sm.Regions[0].Transitions[0].Target = &sm.Regions[0].States[1].Vertex
+				sm.Regions[0].Transitions[2].Target = &sm.Regions[0].States[1].Vertex
+			},
+			want: []finding{
+				{SeverityError, "state 'idle' is replaced by 'play', which is not a state of this state machine"},
+			},
+		},
+		{
+			name: "replaced by itself",
+			modify: func(sm *StateMachine) {
+				sm.Events[0].Deprecated = true
+				sm.Events[0].ReplacedBy = "play"
+			},
+			want: []finding{
+				{SeverityError, "event 'play' cannot be replaced by itself"},
+				{SeverityInfo, "transition 'play' is triggered by deprecated event 'play'; use 'play' instead"},
+			},
+		},
+		{
+			name: "replacement without deprecation",
+			modify: func(sm *StateMachine) {
+				sm.Events[1].ReplacedBy = "play"
+			},
+			want: []finding{
+				{SeverityWarning, "event 'stop' names replacement 'play' but is not deprecated"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newDeprecatedPlayerMachine()
+			tt.modify(sm)
+			errors := &ValidationErrors{}
+			sm.validateDeprecations(NewValidationContext(), errors)
+
+			var got []finding
+			for _, list := range [][]*ValidationError{errors.Errors, errors.Warnings, errors.Infos} {
+				for _, err := range list {
+					severity := err.Severity
+					if severity == "" {
+						severity = SeverityError
+					}
+					got = append(got, finding{severity, err.Message})
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d findings %v, want %v", len(got), got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("finding %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDeprecations_InfosDoNotFailValidation(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Regions[0].States[0].Deprecated = true
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext(), errors)
+
+	if errors.HasErrors() {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+	if !errors.HasInfos() {
+		t.Fatal("expected an info about the deprecated state")
+	}
+	info := errors.Infos[0]
+	if !info.IsInfo() || info.IsWarning() || info.Object != "Transition" || info.Field != "Target" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if !strings.Contains(errors.GetDetailedReport(), "Info (1):") {
+		t.Errorf("detailed report does not list infos:\n%s", errors.GetDetailedReport())
+	}
+
+	merged := &ValidationErrors{}
+	merged.Merge(errors)
+	if len(merged.Infos) != 1 {
+		t.Errorf("Merge() kept %d infos, want 1", len(merged.Infos))
+	}
+	merged.Clear()
+	if merged.HasInfos() {
+		t.Error("Clear() should remove infos")
+	}
+}
+
+func TestPlantUML_Deprecations(t *testing.T) {
+	sm := newDeprecatedPlayerMachine()
+	sm.Regions[0].States[0].Deprecated = true
+	sm.Regions[0].Transitions[2].Deprecated = true
+	sm.Events[2].Deprecated = true // loaded
+	diagram := PlantUML(sm)
+
+	for _, want := range []string{
+		"state \"--idle--\" as idle\n",
+		"playing --> idle : --stop--\n",
+		"loading --> streaming : --loaded--\n",
+		"idle --> playing : play\n",
+	} {
+		if !strings.Contains(diagram, want) {
+			t.Errorf("PlantUML() missing %q in\n%s", want, diagram)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImportIssue describes a diagram element that an importer could not map
+// unambiguously, or mapped by guessing
+type ImportIssue struct {
+	Element string `json:"element"` // "node" or "edge" for diagram shapes and connectors, or the kind of model element, e.g. "transition"
+	ID      string `json:"id"`
+	Label   string `json:"label,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport lists the elements of an imported diagram that need manual
+// fix-up. Diagram importers are best effort: the model they return is
+// complete as far as the diagram allows, and the report says where it may
+// not match the author's intent.
+type ImportReport struct {
+	Format string        `json:"format"`
+	Issues []ImportIssue `json:"issues,omitempty"`
+}
+
+// String summarizes the report, one issue per line
+func (r *ImportReport) String() string {
+	if len(r.Issues) == 0 {
+		return fmt.Sprintf("%s import: no issues", r.Format)
+	}
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("%s import: %d issue(s)", r.Format, len(r.Issues)))
+	for _, issue := range r.Issues {
+		out.WriteString(fmt.Sprintf("\n- %s '%s'", issue.Element, issue.ID))
+		if issue.Label != "" {
+			out.WriteString(fmt.Sprintf(" (%q)", issue.Label))
+		}
+		out.WriteString(": " + issue.Message)
+	}
+	return out.String()
+}
+
+// add records an issue
+func (r *ImportReport) add(element, id, label, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ImportIssue{Element: element, ID: id, Label: label, Message: fmt.Sprintf(format, args...)})
+}
+
+// diagramNode is a shape read from a diagram. Parent is the ID of the shape
+// that contains it, or "" at the top level.
+type diagramNode struct {
+	ID     string
+	Label  string
+	Parent string
+	Shape  string // Lowercase shape hint such as "ellipse" or "diamond", if the format has one
+}
+
+// diagramEdge is a connector read from a diagram
+type diagramEdge struct {
+	ID     string
+	Source string
+	Target string
+	Label  string
+}
+
+// diagramLabels maps the labels diagram authors use for final states, and
+// the glyphs they use for pseudostates, to the vertex they denote. Every
+// other pseudostate label is read with ParsePseudostateKind.
+var diagramLabels = map[string]struct {
+	vertexType string
+	kind       PseudostateKind
+}{
+	"●":     {"pseudostate", PseudostateKindInitial},
+	"final": {"finalstate", ""},
+	"end":   {"finalstate", ""},
+	"◉":     {"finalstate", ""},
+}
+
+// buildDiagramMachine maps diagram shapes and connectors to a state machine
+// using naming conventions:
+//   - shapes labeled with a pseudostate kind ParsePseudostateKind accepts
+//     ("initial", "choice", "H*", "deep history", ...) or like a final state
+//     ("final", "end") become one; "[*]" is an initial
+//     pseudostate when it only has outgoing connectors and a final state
+//     when it only has incoming ones, as in PlantUML
+//   - unlabeled ellipses become initial pseudostates or final states the same
+//     way, diamonds become choice pseudostates, and synchronization bars
+//     become forks or joins depending on their connectors
+//   - every other shape becomes a state; shapes containing other shapes
+//     become composite states with one region
+//   - connector labels are read as "event1, event2 [guard] / effect"
+//
+// Everything that had to be guessed or skipped is recorded in the report.
+func buildDiagramMachine(id, name string, nodes []diagramNode, edges []diagramEdge, report *ImportReport) *StateMachine {
+	sm := &StateMachine{
+		ID:      id,
+		Name:    name,
+		Version: "1.0", // Diagrams carry no model version
+		Regions: []*Region{{ID: id + "-region", Name: "Main"}},
+	}
+
+	incoming := make(map[string]int)
+	outgoing := make(map[string]int)
+	for _, edge := range edges {
+		outgoing[edge.Source]++
+		incoming[edge.Target]++
+	}
+	children := make(map[string][]diagramNode)
+	known := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		known[node.ID] = true
+	}
+	for _, node := range nodes {
+		parent := node.Parent
+		if node.ID == "" || node.ID == parent {
+			report.add("node", node.ID, node.Label, "has no ID or is its own parent; skipped")
+			continue
+		}
+		if parent != "" && !known[parent] {
+			report.add("node", node.ID, node.Label, "parent '%s' does not exist; imported at the top level", parent)
+			parent = ""
+		}
+		children[parent] = append(children[parent], node)
+	}
+
+	vertices := make(map[string]*Vertex, len(nodes))
+	regionOf := make(map[string]*Region, len(nodes))
+	stateLabels := make(map[string]string)
+
+	// Place nodes breadth first so that parents exist before their children
+	type pendingNodes struct {
+		parent string
+		region *Region
+	}
+	queue := []pendingNodes{{parent: "", region: sm.Regions[0]}}
+	queued := map[string]bool{"": true} // Parents are placed once, even in a parent cycle
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, node := range children[next.parent] {
+			vertexType, kind := classifyDiagramNode(node, incoming[node.ID], outgoing[node.ID], report)
+			regionOf[node.ID] = next.region
+			if vertexType != "state" {
+				vertex := &Vertex{ID: node.ID, Name: string(kind), Type: vertexType}
+				if vertexType == "finalstate" {
+					vertex.Name = displayName(node.Label, "final")
+				}
+				next.region.Vertices = append(next.region.Vertices, vertex)
+				vertices[node.ID] = vertex
+				if len(children[node.ID]) > 0 && !queued[node.ID] {
+					report.add("node", node.ID, node.Label, "%s contains other shapes, which were imported at its level", vertexType)
+					queued[node.ID] = true
+					queue = append(queue, pendingNodes{parent: node.ID, region: next.region})
+				}
+				continue
+			}
+
+			label := displayName(node.Label, node.ID)
+			if other, duplicate := stateLabels[label]; duplicate {
+				report.add("node", node.ID, node.Label, "label is also used by node '%s'; the states were kept apart", other)
+			} else {
+				stateLabels[label] = node.ID
+			}
+			state := &State{Vertex: Vertex{ID: node.ID, Name: label, Type: "state"}, IsSimple: true}
+			if len(children[node.ID]) > 0 && !queued[node.ID] {
+				region := &Region{ID: node.ID + "-region", Name: label}
+				state.IsSimple, state.IsComposite = false, true
+				state.Regions = []*Region{region}
+				queued[node.ID] = true
+				queue = append(queue, pendingNodes{parent: node.ID, region: region})
+			}
+			next.region.States = append(next.region.States, state)
+			vertices[node.ID] = &state.Vertex
+		}
+	}
+
+	for _, node := range nodes {
+		if _, placed := vertices[node.ID]; !placed && node.ID != "" && node.ID != node.Parent {
+			report.add("node", node.ID, node.Label, "is nested in a cycle of parents; skipped")
+		}
+	}
+
+	events := make(map[string]bool)
+	for i, edge := range edges {
+		source, target := vertices[edge.Source], vertices[edge.Target]
+		edgeID := edge.ID
+		if edgeID == "" {
+			edgeID = fmt.Sprintf("%s-transition-%d", id, i+1)
+		}
+		if source == nil || target == nil {
+			report.add("edge", edgeID, edge.Label, "connects '%s' to '%s', which are not both shapes of the diagram; skipped", edge.Source, edge.Target)
+			continue
+		}
+
+		transition := &Transition{ID: edgeID, Source: source, Target: target, Kind: TransitionKindExternal}
+		eventNames, guard, effect := parseTransitionLabel(edge.Label)
+		for j, event := range eventNames {
+			transition.Triggers = append(transition.Triggers, &Trigger{ID: fmt.Sprintf("%s-trigger-%d", edgeID, j+1), Name: event, EventID: event})
+			if !events[event] {
+				events[event] = true
+				sm.Events = append(sm.Events, &Event{ID: event, Name: event, Type: EventTypeSignal})
+			}
+		}
+		if guard != "" {
+			transition.Guard = &Constraint{ID: edgeID + "-guard", Specification: guard}
+		}
+		if effect != "" {
+			transition.Effect = &Behavior{ID: edgeID + "-effect", Name: effect, Specification: effect}
+		}
+		if edge.Label != "" && len(eventNames) == 0 && guard == "" && effect == "" {
+			report.add("edge", edgeID, edge.Label, "label could not be read as \"events [guard] / effect\"")
+		}
+		region := regionOf[edge.Source]
+		region.Transitions = append(region.Transitions, transition)
+	}
+	return sm
+}
+
+// classifyDiagramNode decides which kind of vertex a diagram shape denotes
+func classifyDiagramNode(node diagramNode, incoming, outgoing int, report *ImportReport) (string, PseudostateKind) {
+	label := strings.ToLower(strings.TrimSpace(node.Label))
+	if known, ok := diagramLabels[label]; ok {
+		return known.vertexType, known.kind
+	}
+	if kind, err := ParsePseudostateKind(label); err == nil {
+		return "pseudostate", kind
+	}
+
+	if label == "[*]" || (label == "" && (node.Shape == "ellipse" || node.Shape == "circle")) {
+		switch {
+		case incoming == 0 && outgoing > 0:
+			return "pseudostate", PseudostateKindInitial
+		case outgoing == 0 && incoming > 0:
+			return "finalstate", ""
+		}
+		report.add("node", node.ID, node.Label, "could be an initial pseudostate or a final state; imported as a state")
+		return "state", ""
+	}
+	if label == "" && (node.Shape == "diamond" || node.Shape == "rhombus") {
+		return "pseudostate", PseudostateKindChoice
+	}
+	if label == "" && node.Shape == "bar" {
+		switch {
+		case outgoing > 1 && incoming <= 1:
+			return "pseudostate", PseudostateKindFork
+		case incoming > 1 && outgoing <= 1:
+			return "pseudostate", PseudostateKindJoin
+		}
+		report.add("node", node.ID, node.Label, "synchronization bar could be a fork or a join; imported as a junction")
+		return "pseudostate", PseudostateKindJunction
+	}
+	if label == "" {
+		report.add("node", node.ID, node.Label, "has no label; imported as a state named after its ID")
+	}
+	return "state", ""
+}
+
+// parseTransitionLabel reads a transition label of the form
+// "event1, event2 [guard] / effect"; every part is optional
+func parseTransitionLabel(label string) (events []string, guard, effect string) {
+	label = strings.TrimSpace(label)
+	if before, after, found := strings.Cut(label, "/"); found {
+		label, effect = strings.TrimSpace(before), strings.TrimSpace(after)
+	}
+	if open := strings.Index(label, "["); open >= 0 {
+		if end := strings.LastIndex(label, "]"); end > open {
+			guard = strings.TrimSpace(label[open+1 : end])
+			label = strings.TrimSpace(label[:open] + label[end+1:])
+		}
+	}
+	for _, event := range strings.Split(label, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	return events, guard, effect
+}
+
+// stripMarkup removes HTML tags and collapses whitespace, for labels that
+// drawing tools store as rich text
+func stripMarkup(s string) string {
+	var out strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+			out.WriteRune(' ')
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			out.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
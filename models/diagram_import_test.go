@@ -0,0 +1,161 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTransitionLabel(t *testing.T) {
+	tests := []struct {
+		label      string
+		wantEvents []string
+		wantGuard  string
+		wantEffect string
+	}{
+		{label: ""},
+		{label: "go", wantEvents: []string{"go"}},
+		{label: "a, b", wantEvents: []string{"a", "b"}},
+		{label: "go [x > 1]", wantEvents: []string{"go"}, wantGuard: "x > 1"},
+		{label: "[ready]", wantGuard: "ready"},
+		{label: "/ log", wantEffect: "log"},
+		{label: " tick [n < 3] / n++ ", wantEvents: []string{"tick"}, wantGuard: "n < 3", wantEffect: "n++"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			events, guard, effect := parseTransitionLabel(tt.label)
+			if !reflect.DeepEqual(events, tt.wantEvents) || guard != tt.wantGuard || effect != tt.wantEffect {
+				t.Errorf("parseTransitionLabel(%q) = %v, %q, %q, want %v, %q, %q", tt.label, events, guard, effect, tt.wantEvents, tt.wantGuard, tt.wantEffect)
+			}
+		})
+	}
+}
+
+func TestClassifyDiagramNode(t *testing.T) {
+	tests := []struct {
+		name      string
+		node      diagramNode
+		incoming  int
+		outgoing  int
+		wantType  string
+		wantKind  PseudostateKind
+		wantIssue bool
+	}{
+		{name: "state", node: diagramNode{ID: "n", Label: "Idle"}, wantType: "state"},
+		{name: "initial label", node: diagramNode{ID: "n", Label: "Initial"}, wantType: "pseudostate", wantKind: PseudostateKindInitial},
+		{name: "deep history", node: diagramNode{ID: "n", Label: "H*"}, wantType: "pseudostate", wantKind: PseudostateKindDeepHistory},
+		{name: "spelled kind", node: diagramNode{ID: "n", Label: "Deep History"}, wantType: "pseudostate", wantKind: PseudostateKindDeepHistory},
+		{name: "alias", node: diagramNode{ID: "n", Label: "decision"}, wantType: "pseudostate", wantKind: PseudostateKindChoice},
+		{name: "entry point", node: diagramNode{ID: "n", Label: "entry-point"}, wantType: "pseudostate", wantKind: PseudostateKindEntryPoint},
+		{name: "initial glyph", node: diagramNode{ID: "n", Label: "●"}, wantType: "pseudostate", wantKind: PseudostateKindInitial},
+		{name: "final label", node: diagramNode{ID: "n", Label: "End"}, wantType: "finalstate"},
+		{name: "star as source", node: diagramNode{ID: "n", Label: "[*]"}, outgoing: 1, wantType: "pseudostate", wantKind: PseudostateKindInitial},
+		{name: "star as target", node: diagramNode{ID: "n", Label: "[*]"}, incoming: 2, wantType: "finalstate"},
+		{name: "ambiguous ellipse", node: diagramNode{ID: "n", Shape: "ellipse"}, incoming: 1, outgoing: 1, wantType: "state", wantIssue: true},
+		{name: "diamond", node: diagramNode{ID: "n", Shape: "diamond"}, wantType: "pseudostate", wantKind: PseudostateKindChoice},
+		{name: "fork bar", node: diagramNode{ID: "n", Shape: "bar"}, incoming: 1, outgoing: 2, wantType: "pseudostate", wantKind: PseudostateKindFork},
+		{name: "join bar", node: diagramNode{ID: "n", Shape: "bar"}, incoming: 2, outgoing: 1, wantType: "pseudostate", wantKind: PseudostateKindJoin},
+		{name: "ambiguous bar", node: diagramNode{ID: "n", Shape: "bar"}, incoming: 2, outgoing: 2, wantType: "pseudostate", wantKind: PseudostateKindJunction, wantIssue: true},
+		{name: "unlabeled", node: diagramNode{ID: "n"}, wantType: "state", wantIssue: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &ImportReport{}
+			vertexType, kind := classifyDiagramNode(tt.node, tt.incoming, tt.outgoing, report)
+			if vertexType != tt.wantType || kind != tt.wantKind {
+				t.Errorf("classifyDiagramNode() = %q, %q, want %q, %q", vertexType, kind, tt.wantType, tt.wantKind)
+			}
+			if (len(report.Issues) > 0) != tt.wantIssue {
+				t.Errorf("issues = %v, wantIssue %v", report.Issues, tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestClassifyDiagramNode_RoseStateKinds(t *testing.T) {
+	for class, label := range mdlStateKinds {
+		if label == "" {
+			continue // Synchronization bars are classified by their shape
+		}
+		t.Run(class, func(t *testing.T) {
+			report := &ImportReport{}
+			if vertexType, _ := classifyDiagramNode(diagramNode{ID: "n", Label: label}, 0, 0, report); vertexType == "state" {
+				t.Errorf("label %q of %s is imported as a state", label, class)
+			}
+		})
+	}
+}
+
+func TestBuildDiagramMachine(t *testing.T) {
+	nodes := []diagramNode{
+		{ID: "i", Label: "initial"},
+		{ID: "a", Label: "Busy"},
+		{ID: "b", Label: "Busy"},
+		{ID: "c", Label: "Child", Parent: "missing"},
+	}
+	edges := []diagramEdge{
+		{Source: "i", Target: "a"},
+		{ID: "e2", Source: "a", Target: "b", Label: "[]"},
+	}
+	report := &ImportReport{Format: "test"}
+	sm := buildDiagramMachine("m", "M", nodes, edges, report)
+
+	if got := len(sm.Regions[0].States); got != 3 {
+		t.Errorf("states = %d, want 3", got)
+	}
+	if got := sm.Regions[0].Transitions[0].ID; got != "m-transition-1" {
+		t.Errorf("generated transition ID = %q", got)
+	}
+	for _, want := range []string{"label is also used by node 'a'", "parent 'missing' does not exist", "could not be read"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestBuildDiagramMachine_MalformedNodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodes      []diagramNode
+		wantStates int
+		wantIssue  string
+	}{
+		{name: "node without an ID", nodes: []diagramNode{{Label: "Ghost"}, {ID: "a", Label: "A"}}, wantStates: 1, wantIssue: "has no ID or is its own parent"},
+		{name: "node that is its own parent", nodes: []diagramNode{{ID: "a", Label: "A", Parent: "a"}}, wantIssue: "has no ID or is its own parent"},
+		{name: "cycle of parents", nodes: []diagramNode{{ID: "a", Label: "A", Parent: "b"}, {ID: "b", Label: "B", Parent: "a"}}, wantIssue: "is nested in a cycle of parents"},
+		{name: "repeated ID with children", nodes: []diagramNode{{ID: "a", Label: "A"}, {ID: "a", Label: "A2"}, {ID: "c", Label: "C", Parent: "a"}}, wantStates: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &ImportReport{Format: "test"}
+			sm := buildDiagramMachine("m", "M", tt.nodes, nil, report)
+			if got := len(sm.Regions[0].States); got != tt.wantStates {
+				t.Errorf("top-level states = %d, want %d", got, tt.wantStates)
+			}
+			if tt.wantIssue != "" && !strings.Contains(report.String(), tt.wantIssue) {
+				t.Errorf("report missing %q:\n%s", tt.wantIssue, report)
+			}
+		})
+	}
+}
+
+func TestImportReport_String(t *testing.T) {
+	if got := (&ImportReport{Format: "GraphML"}).String(); got != "GraphML import: no issues" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestStripMarkup(t *testing.T) {
+	tests := map[string]string{
+		"plain":                 "plain",
+		"<b>Off</b>":            "Off",
+		"two<br/>lines":         "two lines",
+		"  spaced \n  out  ":    "spaced out",
+		"<div><i>x</i> y</div>": "x y",
+	}
+	for input, want := range tests {
+		if got := stripMarkup(input); got != want {
+			t.Errorf("stripMarkup(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
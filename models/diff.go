@@ -0,0 +1,335 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeKind classifies a Change
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is one difference between two versions of a state machine.
+// Elements are matched by their ID.
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Element  string     `json:"element"`         // StateMachine, Region, State, Pseudostate, FinalState, ConnectionPoint, Transition or Event
+	ID       string     `json:"id"`              // ID of the element
+	Field    string     `json:"field,omitempty"` // Changed field of a modified element, e.g. "name" or "guard"
+	Old      string     `json:"old,omitempty"`
+	New      string     `json:"new,omitempty"`
+	Semantic bool       `json:"semantic"` // Whether the change affects behavior, unlike names, documentation, deprecations and annotations
+}
+
+// String returns a concise one-line description of the change
+func (c Change) String() string {
+	if c.Kind != ChangeModified {
+		return fmt.Sprintf("%s %s '%s'", c.Kind, c.Element, c.ID)
+	}
+	return fmt.Sprintf("modified %s '%s' %s: %q -> %q", c.Element, c.ID, c.Field, c.Old, c.New)
+}
+
+// Diff lists the differences between two versions of a state machine:
+// elements added and removed, in model order, and the fields of elements
+// present in both that changed. Metadata is compared key by key and each
+// changed key is reported as a "metadata.<key>" field. Submachines are
+// compared by reference, not by content. Either version may be nil.
+func Diff(old, new *StateMachine) []Change {
+	before, after := diffElements(old), diffElements(new)
+	afterByKey := make(map[string]*diffElement, len(after))
+	for _, element := range after {
+		afterByKey[element.key()] = element
+	}
+	beforeKeys := make(map[string]bool, len(before))
+
+	var changes []Change
+	for _, element := range before {
+		beforeKeys[element.key()] = true
+		other, exists := afterByKey[element.key()]
+		if !exists {
+			changes = append(changes, Change{Kind: ChangeRemoved, Element: element.kind, ID: element.id, Semantic: true})
+			continue
+		}
+		for i, field := range element.fields {
+			if value := other.fields[i].value; value != field.value {
+				changes = append(changes, Change{
+					Kind:     ChangeModified,
+					Element:  element.kind,
+					ID:       element.id,
+					Field:    field.name,
+					Old:      field.value,
+					New:      value,
+					Semantic: field.semantic,
+				})
+			}
+		}
+		if element.kind == "StateMachine" {
+			changes = append(changes, diffMetadata(element.id, old.Metadata, new.Metadata)...)
+		}
+	}
+	for _, element := range after {
+		if !beforeKeys[element.key()] {
+			changes = append(changes, Change{Kind: ChangeAdded, Element: element.kind, ID: element.id, Semantic: true})
+		}
+	}
+	return changes
+}
+
+// EquivalentTo reports whether two state machines have the same behavior:
+// Diff finds no semantic change between them. Names, version, metadata,
+// timestamps and analysis annotations such as costs may differ, as may the
+// order of elements.
+func (sm *StateMachine) EquivalentTo(other *StateMachine) bool {
+	for _, change := range Diff(sm, other) {
+		if change.Semantic {
+			return false
+		}
+	}
+	return true
+}
+
+// diffElement is the comparable projection of a model element. Elements of
+// the same kind always have the same fields in the same order.
+type diffElement struct {
+	kind   string
+	id     string
+	fields []diffField
+}
+
+type diffField struct {
+	name     string
+	value    string
+	semantic bool
+}
+
+func (e *diffElement) key() string {
+	return e.kind + "\x00" + e.id
+}
+
+// diffElements flattens a state machine into comparable elements in model
+// order
+func diffElements(sm *StateMachine) []*diffElement {
+	if sm == nil {
+		return nil
+	}
+	add := func(elements []*diffElement, kind, id string, fields ...diffField) []*diffElement {
+		return append(elements, &diffElement{kind: kind, id: id, fields: fields})
+	}
+	semantic := func(name, value string) diffField { return diffField{name: name, value: value, semantic: true} }
+	cosmetic := func(name, value string) diffField { return diffField{name: name, value: value} }
+
+	elements := add(nil, "StateMachine", sm.ID,
+		cosmetic("name", sm.Name),
+		cosmetic("version", sm.Version),
+		semantic("is_method", strconv.FormatBool(sm.IsMethod)),
+		semantic("allow_shared_terminate", strconv.FormatBool(sm.AllowSharedTerminate)),
+		cosmetic("created_at", diffTime(sm.CreatedAt)),
+		cosmetic("entities", diffJSON(sm.Entities)),
+		cosmetic("layout", diffJSON(sm.Layout)),
+	)
+	for _, event := range sm.Events {
+		if event != nil {
+			elements = add(elements, "Event", event.ID,
+				cosmetic("name", event.Name),
+				semantic("type", string(event.Type)),
+				semantic("properties", diffJSON(event.Properties)),
+				cosmetic("deprecated", diffDeprecation(event.Deprecated, event.ReplacedBy)),
+			)
+		}
+	}
+	for _, point := range sm.ConnectionPoints {
+		if point != nil {
+			elements = add(elements, "ConnectionPoint", point.ID,
+				cosmetic("name", point.Name),
+				semantic("kind", string(point.Kind)),
+			)
+		}
+	}
+
+	type pendingRegions struct {
+		regions []*Region
+		owner   string
+	}
+	queue := []pendingRegions{{regions: sm.Regions}}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, region := range next.regions {
+			if region == nil {
+				continue
+			}
+			elements = add(elements, "Region", region.ID,
+				cosmetic("name", region.Name),
+				semantic("owner", next.owner),
+				cosmetic("ownership", Ownership{Owner: region.Owner, Team: region.Team}.String()),
+			)
+			states := make(map[string]bool, len(region.States))
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				states[state.ID] = true
+				submachine := ""
+				if state.Submachine != nil {
+					submachine = state.Submachine.ID
+				}
+				elements = add(elements, "State", state.ID,
+					cosmetic("name", state.Name),
+					semantic("region", region.ID),
+					semantic("kind", state.kindDescription()),
+					semantic("entry", diffBehavior(state.Entry)),
+					semantic("exit", diffBehavior(state.Exit)),
+					semantic("do_activity", diffBehavior(state.DoActivity)),
+					semantic("submachine", submachine),
+					semantic("deferrable_triggers", diffTriggers(state.DeferrableTriggers)),
+					semantic("features", diffSet(state.Features)),
+					cosmetic("cost", diffJSON(state.Cost)),
+					cosmetic("deprecated", diffDeprecation(state.Deprecated, state.ReplacedBy)),
+					cosmetic("ownership", Ownership{Owner: state.Owner, Team: state.Team}.String()),
+					cosmetic("tags", diffSet(state.Tags)),
+				)
+				if len(state.Regions) > 0 {
+					queue = append(queue, pendingRegions{regions: state.Regions, owner: state.ID})
+				}
+			}
+			for _, vertex := range region.Vertices {
+				if vertex == nil || states[vertex.ID] {
+					continue
+				}
+				switch vertex.Type {
+				case "pseudostate":
+					elements = add(elements, "Pseudostate", vertex.ID,
+						cosmetic("name", vertex.Name),
+						semantic("region", region.ID),
+						semantic("kind", string(pseudostateKindOf(vertex))),
+						cosmetic("tags", diffSet(vertex.Tags)),
+					)
+				case "finalstate":
+					elements = add(elements, "FinalState", vertex.ID,
+						cosmetic("name", vertex.Name),
+						semantic("region", region.ID),
+						cosmetic("tags", diffSet(vertex.Tags)),
+					)
+				default:
+					elements = add(elements, "State", vertex.ID,
+						cosmetic("name", vertex.Name),
+						semantic("region", region.ID),
+						semantic("kind", "simple"),
+						semantic("entry", ""),
+						semantic("exit", ""),
+						semantic("do_activity", ""),
+						semantic("submachine", ""),
+						semantic("deferrable_triggers", ""),
+						semantic("features", ""),
+						cosmetic("cost", diffJSON(nil)),
+						cosmetic("deprecated", ""),
+						cosmetic("ownership", Ownership{}.String()),
+						cosmetic("tags", diffSet(vertex.Tags)),
+					)
+				}
+			}
+			for _, transition := range region.Transitions {
+				if transition == nil {
+					continue
+				}
+				guard := ""
+				if transition.Guard != nil {
+					guard = transition.Guard.Specification
+				}
+				elements = add(elements, "Transition", transition.ID,
+					cosmetic("name", transition.Name),
+					semantic("source", vertexIDOrNil(transition.Source)),
+					semantic("target", vertexIDOrNil(transition.Target)),
+					semantic("kind", string(transition.Kind)),
+					semantic("triggers", diffTriggers(transition.Triggers)),
+					semantic("guard", guard),
+					semantic("effect", diffBehavior(transition.Effect)),
+					semantic("features", diffSet(transition.Features)),
+					semantic("entities", diffSet(transition.Entities)),
+					cosmetic("probability", diffJSON(transition.Probability)),
+					cosmetic("cost", diffJSON(transition.Cost)),
+					cosmetic("deprecated", diffDeprecation(transition.Deprecated, transition.ReplacedBy)),
+					cosmetic("tags", diffSet(transition.Tags)),
+				)
+			}
+		}
+	}
+	return elements
+}
+
+// diffBehavior describes what a behavior does: its specification, qualified
+// by its language if it has one, and the entities it uses
+func diffBehavior(behavior *Behavior) string {
+	if behavior == nil {
+		return ""
+	}
+	description := behavior.Specification
+	if behavior.Language != "" {
+		description = behavior.Language + ": " + description
+	}
+	if len(behavior.Entities) > 0 {
+		description += " [entities: " + diffSet(behavior.Entities) + "]"
+	}
+	return description
+}
+
+// diffDeprecation describes whether an element is deprecated and what
+// replaces it
+func diffDeprecation(deprecated bool, replacedBy string) string {
+	switch {
+	case !deprecated:
+		return ""
+	case replacedBy != "":
+		return "replaced by " + replacedBy
+	}
+	return "deprecated"
+}
+
+// diffTriggers lists the events of triggers, sorted
+func diffTriggers(triggers []*Trigger) string {
+	var events []string
+	for _, trigger := range triggers {
+		if key := trigger.EventKey(); key != "" {
+			events = append(events, key)
+		}
+	}
+	return diffSet(events)
+}
+
+// diffSet joins values in sorted order, so that order does not matter
+func diffSet(values []string) string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// diffTime formats a timestamp, ignoring its monotonic clock reading and
+// location
+func diffTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// diffJSON encodes a value for comparison, treating nil and empty values alike
+func diffJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	switch string(data) {
+	case "null", "{}", "[]":
+		return ""
+	}
+	return string(data)
+}
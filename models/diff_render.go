@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Colors of the changes in diff diagrams, by format
+var (
+	diffPlantUMLColors = map[ChangeKind]string{ChangeAdded: "#PaleGreen", ChangeRemoved: "#Salmon", ChangeModified: "#Orange"}
+	diffDOTColors      = map[ChangeKind]string{ChangeAdded: "green3", ChangeRemoved: "red3", ChangeModified: "orange"}
+)
+
+// ExportDiff writes a diagram of new in the named format, "plantuml" or
+// "dot", with the changes since old (see Diff) highlighted: states,
+// pseudostates and transitions added in new are green, those removed from
+// old red and those modified amber. Removed elements are drawn where they
+// were in old, in the region with the same ID, so reviewers see a change
+// in place rather than reading the JSON diff. Changes to regions, events
+// and the state machine itself are not drawn. Either version may be nil.
+func ExportDiff(old, new *StateMachine, format string, w io.Writer, opts ExportOptions) error {
+	if old == nil && new == nil {
+		return fmt.Errorf("cannot export the diff of two nil state machines")
+	}
+	highlight := newDiffHighlight(Diff(old, new))
+	merged := diffUnion(old, new)
+
+	var diagram string
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "plantuml":
+		diagram = plantUML(merged, plantUMLStyle{
+			title:      opts.Title,
+			vertex:     diffColors(highlight.vertices, diffPlantUMLColors),
+			transition: diffColors(highlight.transitions, diffPlantUMLColors),
+		})
+	case "dot":
+		diagram = dot(merged, opts, dotStyle{
+			vertex:     diffColors(highlight.vertices, diffDOTColors),
+			transition: diffColors(highlight.transitions, diffDOTColors),
+		})
+	default:
+		return fmt.Errorf("%w %q for diff export; supported formats: dot, plantuml", ErrUnknownFormat, format)
+	}
+	_, err := io.WriteString(w, diagram)
+	return err
+}
+
+// DiffPlantUML returns a PlantUML diagram of new with the changes since old
+// highlighted; see ExportDiff
+func DiffPlantUML(old, new *StateMachine) string {
+	var out strings.Builder
+	if err := ExportDiff(old, new, "plantuml", &out, ExportOptions{}); err != nil {
+		return ""
+	}
+	return out.String()
+}
+
+// diffHighlight holds the change of each drawn element by ID
+type diffHighlight struct {
+	vertices    map[string]ChangeKind
+	transitions map[string]ChangeKind
+}
+
+// newDiffHighlight indexes the changes to vertices and transitions; an
+// element with modified fields is modified once
+func newDiffHighlight(changes []Change) diffHighlight {
+	h := diffHighlight{vertices: make(map[string]ChangeKind), transitions: make(map[string]ChangeKind)}
+	for _, change := range changes {
+		switch change.Element {
+		case "State", "Pseudostate", "FinalState", "ConnectionPoint":
+			h.vertices[change.ID] = change.Kind
+		case "Transition":
+			h.transitions[change.ID] = change.Kind
+		}
+	}
+	return h
+}
+
+// diffColors returns the style function coloring the changed elements
+func diffColors(changes map[string]ChangeKind, palette map[ChangeKind]string) func(id string) string {
+	return func(id string) string {
+		return palette[changes[id]]
+	}
+}
+
+// diffUnion returns a copy of new with the states, vertices, regions and
+// transitions of old that new lacks added back where they were in old:
+// into the region with the same ID, or under the state or state machine
+// that owned their region. Removed transitions are drawn between the
+// vertices with their endpoints' IDs and left out when an endpoint is
+// missing from both versions.
+func diffUnion(old, new *StateMachine) *StateMachine {
+	var merged *StateMachine
+	if new != nil {
+		merged = new.Clone()
+	} else {
+		merged = &StateMachine{ID: old.ID, Name: old.Name}
+	}
+	if old == nil {
+		return merged
+	}
+
+	regions := make(map[string]*Region)
+	walkRegionTree(merged.Regions, "", func(region *Region, _ string) {
+		if _, exists := regions[region.ID]; !exists {
+			regions[region.ID] = region
+		}
+	})
+	states := stateIDs(merged)
+	vertices := canonicalVertices(merged)
+
+	// Add the missing regions, states and vertices, outer regions first so
+	// that owners exist when their regions are reached
+	type pendingRegions struct {
+		regions []*Region
+		owner   *State // Owner in merged; nil for the state machine
+	}
+	queue := []pendingRegions{{regions: old.Regions}}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, region := range next.regions {
+			if region == nil {
+				continue
+			}
+			target := regions[region.ID]
+			if target == nil {
+				target = &Region{ID: region.ID, Name: region.Name}
+				regions[region.ID] = target
+				if next.owner == nil {
+					merged.Regions = append(merged.Regions, target)
+				} else {
+					next.owner.Regions = append(next.owner.Regions, target)
+				}
+			}
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				if _, exists := vertices[state.ID]; !exists {
+					removed := *state
+					removed.Regions = nil
+					target.States = append(target.States, &removed)
+					states[state.ID] = &removed
+					vertices[state.ID] = &removed.Vertex
+				}
+				if owner := states[state.ID]; owner != nil && len(state.Regions) > 0 {
+					queue = append(queue, pendingRegions{regions: state.Regions, owner: owner})
+				}
+			}
+			for _, vertex := range region.Vertices {
+				if vertex == nil {
+					continue
+				}
+				if _, exists := vertices[vertex.ID]; !exists {
+					target.Vertices = append(target.Vertices, vertex)
+					vertices[vertex.ID] = vertex
+				}
+			}
+		}
+	}
+
+	transitions := make(map[string]bool)
+	walkTransitions(merged.Regions, func(transition *Transition) {
+		transitions[transition.ID] = true
+	})
+	walkRegionTree(old.Regions, "", func(region *Region, _ string) {
+		if regions[region.ID] == nil {
+			return // Nested in a state new turned into another kind of vertex
+		}
+		for _, transition := range region.Transitions {
+			if transition == nil || transitions[transition.ID] || transition.Source == nil || transition.Target == nil {
+				continue
+			}
+			source, target := vertices[transition.Source.ID], vertices[transition.Target.ID]
+			if source == nil || target == nil {
+				continue
+			}
+			removed := *transition
+			removed.Source, removed.Target = source, target
+			regions[region.ID].Transitions = append(regions[region.ID].Transitions, &removed)
+			transitions[transition.ID] = true
+		}
+	})
+	return merged
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newDiffRenderFixture returns two versions of the valid fixture: the new
+// one renames state1, replaces state2 with state3 and drops t2 and t3
+func newDiffRenderFixture() (old, new *StateMachine) {
+	old = createValidStateMachine()
+	new = old.Clone()
+	region := new.Regions[0]
+	region.States[0].Name = "Renamed"
+	state3 := &State{Vertex: Vertex{ID: "state3", Name: "State3", Type: "state"}, IsSimple: true}
+	region.States = []*State{region.States[0], state3}
+	region.Transitions = []*Transition{
+		region.Transitions[0],
+		{ID: "t4", Source: &region.States[0].Vertex, Target: &state3.Vertex, Kind: TransitionKindExternal},
+	}
+	return old, new
+}
+
+func TestExportDiff(t *testing.T) {
+	old, new := newDiffRenderFixture()
+	parallel := createParallelStateMachine()
+	withoutRegionB := parallel.Clone()
+	withoutRegionB.Regions[0].States[0].Regions = withoutRegionB.Regions[0].States[0].Regions[:1]
+	withoutRegionB.Regions[0].Transitions = withoutRegionB.Regions[0].Transitions[:1]
+
+	tests := []struct {
+		name    string
+		old     *StateMachine
+		new     *StateMachine
+		format  string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "plantuml",
+			old:    old,
+			new:    new,
+			format: "plantuml",
+			want: []string{
+				`state "Renamed" as state1 #Orange`,
+				`state "State3" as state3 #PaleGreen`,
+				`state "State2" as state2 #Salmon`,
+				"initial1 --> state1 : event1",
+				"state1 -[#PaleGreen]-> state3",
+				"state1 -[#Salmon]-> state2 : [x > 0] / Transition Effect",
+				"state2 -[#Salmon]-> final1",
+			},
+			notWant: []string{"initial1 <<start>> #", "final1 <<end>> #"},
+		},
+		{
+			name:   "dot",
+			old:    old,
+			new:    new,
+			format: " DOT ",
+			want: []string{
+				`"state1" [label="Renamed", color="orange", penwidth=2];`,
+				`"state3" [label="State3", color="green3", penwidth=2];`,
+				`"state2" [label="State2", color="red3", penwidth=2];`,
+				`"initial1" -> "state1" [label="event1"];`,
+				`"state1" -> "state3" [color="green3", fontcolor="green3", penwidth=2];`,
+				`"state2" -> "final1" [color="red3", fontcolor="red3", penwidth=2];`,
+			},
+		},
+		{
+			name:   "removed region is drawn in its composite state",
+			old:    parallel,
+			new:    withoutRegionB,
+			format: "plantuml",
+			want: []string{
+				"  --\n  state \"B1\" as b1 #Salmon\n  state \"B2\" as b2 #Salmon\n}",
+				"fork -[#Salmon]-> b1",
+				"b1 -[#Salmon]-> b2",
+				"a1 --> a2",
+			},
+		},
+		{
+			name:   "new machine",
+			new:    new,
+			format: "plantuml",
+			want:   []string{`state "Renamed" as state1 #PaleGreen`, "initial1 -[#PaleGreen]-> state1"},
+		},
+		{
+			name:   "deleted machine",
+			old:    old,
+			format: "dot",
+			want:   []string{`"state2" [label="State2", color="red3", penwidth=2];`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := ExportDiff(tt.old, tt.new, tt.format, &out, ExportOptions{}); err != nil {
+				t.Fatalf("ExportDiff() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("ExportDiff() missing %q in:\n%s", want, out.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("ExportDiff() contains %q", notWant)
+				}
+			}
+		})
+	}
+
+	if len(new.Regions[0].States) != 2 || len(new.Regions[0].Transitions) != 2 {
+		t.Error("ExportDiff() modified the new version")
+	}
+	if got, want := DiffPlantUML(old, old), PlantUML(old); got != want {
+		t.Errorf("DiffPlantUML() of unchanged versions = %s, want the plain diagram %s", got, want)
+	}
+}
+
+func TestExportDiff_Errors(t *testing.T) {
+	old, new := newDiffRenderFixture()
+	var out bytes.Buffer
+	if err := ExportDiff(old, new, "scxml", &out, ExportOptions{}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ExportDiff(scxml) error = %v, want ErrUnknownFormat", err)
+	}
+	if err := ExportDiff(nil, nil, "plantuml", &out, ExportOptions{}); err == nil {
+		t.Error("ExportDiff(nil, nil) should fail")
+	}
+	if DiffPlantUML(nil, nil) != "" {
+		t.Error("DiffPlantUML(nil, nil) should be empty")
+	}
+}
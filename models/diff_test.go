@@ -0,0 +1,109 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := newPlayerMachine()
+	tests := []struct {
+		name   string
+		edit   func(sm *StateMachine)
+		want   []string
+		equivs bool
+	}{
+		{
+			name:   "unchanged",
+			edit:   func(sm *StateMachine) {},
+			equivs: true,
+		},
+		{
+			name: "renamed state and reordered transitions",
+			edit: func(sm *StateMachine) {
+				sm.Regions[0].States[0].Name = "Idle"
+				transitions := sm.Regions[0].Transitions
+				transitions[0], transitions[2] = transitions[2], transitions[0]
+			},
+			want:   []string{`modified State 'idle' name: "idle" -> "Idle"`},
+			equivs: true,
+		},
+		{
+			name: "guard and target",
+			edit: func(sm *StateMachine) {
+				stop := sm.Regions[0].Transitions[2]
+				stop.Guard = &Constraint{ID: "g", Specification: "confirmed"}
+				stop.Target = &sm.Regions[0].States[1].Vertex
+			},
+			want: []string{
+				`modified Transition 'stop' target: "idle" -> "playing"`,
+				`modified Transition 'stop' guard: "" -> "confirmed"`,
+			},
+		},
+		{
+			name: "moved state",
+			edit: func(sm *StateMachine) {
+				audio := sm.Regions[0].States[1].Regions[0]
+				streaming := audio.States[1]
+				audio.States = audio.States[:1]
+				sm.Regions[0].States = append(sm.Regions[0].States, streaming)
+			},
+			want: []string{`modified State 'streaming' region: "audio" -> "main"`},
+		},
+		{
+			name: "added and removed elements",
+			edit: func(sm *StateMachine) {
+				sm.Regions[0].States = append(sm.Regions[0].States, execState("paused"))
+				video := sm.Regions[0].States[1].Regions[1]
+				video.Transitions = video.Transitions[:2]
+			},
+			want: []string{"removed Transition 'video-tick'", "added State 'paused'"},
+		},
+		{
+			name: "retagged transition",
+			edit: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Tags = []string{"control"}
+			},
+			want:   []string{`modified Transition 'stop' tags: "" -> "control"`},
+			equivs: true,
+		},
+		{
+			name: "trigger order does not matter",
+			edit: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Triggers = append(sm.Regions[0].Transitions[1].Triggers, &Trigger{ID: "x", EventID: "resume"})
+			},
+			want: []string{`modified Transition 'play' triggers: "play" -> "play, resume"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited := old.Clone()
+			tt.edit(edited)
+			var got []string
+			for _, change := range Diff(old, edited) {
+				got = append(got, change.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Diff() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if equivalent := old.EquivalentTo(edited); equivalent != tt.equivs {
+				t.Errorf("EquivalentTo() = %v, want %v", equivalent, tt.equivs)
+			}
+		})
+	}
+}
+
+func TestDiff_Nil(t *testing.T) {
+	sm := newPlayerMachine()
+	changes := Diff(nil, sm)
+	if len(changes) == 0 || changes[0].Kind != ChangeAdded || changes[0].Element != "StateMachine" {
+		t.Errorf("Diff(nil, sm) = %v, want every element added", changes)
+	}
+	if changes := Diff(sm, nil); len(changes) == 0 || changes[0].Kind != ChangeRemoved {
+		t.Errorf("Diff(sm, nil) = %v, want every element removed", changes)
+	}
+	var none *StateMachine
+	if !none.EquivalentTo(nil) || none.EquivalentTo(sm) {
+		t.Error("nil state machines should only be equivalent to each other")
+	}
+}
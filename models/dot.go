@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotExporter exports Graphviz DOT digraphs. Composite states are drawn as
+// clusters, with one dashed sub-cluster per region when they are orthogonal;
+// transitions to and from a composite state attach to its cluster.
+// Pseudostates and final states use the usual UML shapes. The "direction"
+// property sets rankdir, e.g. "LR".
+type dotExporter struct{}
+
+func (dotExporter) Name() string { return "dot" }
+
+func (dotExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	_, err := io.WriteString(w, dot(sm, opts, dotStyle{}))
+	return err
+}
+
+// dotStyle colors individual states and transitions in a DOT digraph.
+// Either function may be nil; an empty color leaves the element as it is.
+type dotStyle struct {
+	vertex     func(id string) string
+	transition func(id string) string
+}
+
+// styleColor returns the color colorOf gives the element with the given
+// ID, or "" when colorOf is nil
+func styleColor(colorOf func(id string) string, id string) string {
+	if colorOf == nil {
+		return ""
+	}
+	return colorOf(id)
+}
+
+// dot renders the digraph with the given style
+func dot(sm *StateMachine, opts ExportOptions, style dotStyle) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("digraph %s {\n", dotQuote(displayName(sm.Name, sm.ID))))
+	out.WriteString("  compound=true;\n")
+	out.WriteString(fmt.Sprintf("  label=%s;\n  labelloc=t;\n", dotQuote(opts.title(sm))))
+	if direction := opts.Properties["direction"]; direction != "" {
+		out.WriteString(fmt.Sprintf("  rankdir=%s;\n", dotQuote(direction)))
+	}
+	out.WriteString("  node [shape=box, style=rounded];\n")
+
+	composites := make(map[string]bool)
+	writeDOTRegions(&out, sm.Regions, 1, composites, style)
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source == nil || transition.Target == nil {
+			return
+		}
+		var attributes []string
+		if label := transitionLabel(transition); label != "" {
+			attributes = append(attributes, "label="+dotQuote(label))
+		}
+		if composites[transition.Source.ID] {
+			attributes = append(attributes, "ltail="+dotQuote("cluster_"+transition.Source.ID))
+		}
+		if composites[transition.Target.ID] {
+			attributes = append(attributes, "lhead="+dotQuote("cluster_"+transition.Target.ID))
+		}
+		if color := styleColor(style.transition, transition.ID); color != "" {
+			attributes = append(attributes, "color="+dotQuote(color), "fontcolor="+dotQuote(color), "penwidth=2")
+		}
+		line := fmt.Sprintf("  %s -> %s", dotQuote(transition.Source.ID), dotQuote(transition.Target.ID))
+		if len(attributes) > 0 {
+			line += " [" + strings.Join(attributes, ", ") + "]"
+		}
+		out.WriteString(line + ";\n")
+	})
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// writeDOTRegions writes the vertices and states of sibling regions and
+// records the IDs of composite states, which are drawn as clusters
+func writeDOTRegions(out *strings.Builder, regions []*Region, level int, composites map[string]bool, style dotStyle) {
+	indent := strings.Repeat("  ", level)
+	for _, region := range regions {
+		if region == nil {
+			continue
+		}
+		for _, vertex := range region.Vertices {
+			if vertex != nil {
+				out.WriteString(fmt.Sprintf("%s%s [%s%s];\n", indent, dotQuote(vertex.ID), dotVertexAttributes(vertex), dotColor(styleColor(style.vertex, vertex.ID))))
+			}
+		}
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			label := dotQuote(displayName(state.Name, state.ID))
+			color := styleColor(style.vertex, state.ID)
+			if len(state.Regions) == 0 {
+				out.WriteString(fmt.Sprintf("%s%s [label=%s%s];\n", indent, dotQuote(state.ID), label, dotColor(color)))
+				continue
+			}
+
+			// Edges attach to an invisible anchor node inside the cluster
+			composites[state.ID] = true
+			out.WriteString(fmt.Sprintf("%ssubgraph %s {\n", indent, dotQuote("cluster_"+state.ID)))
+			out.WriteString(fmt.Sprintf("%s  label=%s;\n%s  style=rounded;\n", indent, label, indent))
+			if color != "" {
+				out.WriteString(fmt.Sprintf("%s  color=%s;\n%s  penwidth=2;\n", indent, dotQuote(color), indent))
+			}
+			out.WriteString(fmt.Sprintf("%s  %s [shape=point, style=invis];\n", indent, dotQuote(state.ID)))
+			if len(state.Regions) == 1 {
+				writeDOTRegions(out, state.Regions, level+1, composites, style)
+			} else {
+				for _, child := range state.Regions {
+					if child == nil {
+						continue
+					}
+					out.WriteString(fmt.Sprintf("%s  subgraph %s {\n", indent, dotQuote("cluster_"+child.ID)))
+					out.WriteString(fmt.Sprintf("%s    label=%s;\n%s    style=dashed;\n", indent, dotQuote(child.Name), indent))
+					writeDOTRegions(out, []*Region{child}, level+2, composites, style)
+					out.WriteString(indent + "  }\n")
+				}
+			}
+			out.WriteString(indent + "}\n")
+		}
+	}
+}
+
+// dotVertexAttributes returns the node attributes drawing a pseudostate or
+// final state in its UML shape
+func dotVertexAttributes(vertex *Vertex) string {
+	if vertex.Type == "finalstate" {
+		return `shape=doublecircle, label="", width=0.2, style=filled, fillcolor=black`
+	}
+	switch pseudostateKindOf(vertex) {
+	case PseudostateKindInitial:
+		return "shape=point, width=0.15"
+	case PseudostateKindChoice:
+		return `shape=diamond, label=""`
+	case PseudostateKindJunction:
+		return `shape=circle, label="", width=0.1, style=filled, fillcolor=black`
+	case PseudostateKindFork, PseudostateKindJoin:
+		return `shape=rect, label="", height=0.05, width=0.6, style=filled, fillcolor=black`
+	case PseudostateKindShallowHistory:
+		return `shape=circle, label="H"`
+	case PseudostateKindDeepHistory:
+		return `shape=circle, label="H*"`
+	case PseudostateKindTerminate:
+		return `shape=none, label="X"`
+	case PseudostateKindEntryPoint, PseudostateKindExitPoint:
+		return fmt.Sprintf(`shape=circle, label="", width=0.2, xlabel=%s`, dotQuote(displayName(vertex.Name, vertex.ID)))
+	}
+	return "label=" + dotQuote(displayName(vertex.Name, vertex.ID))
+}
+
+// dotColor returns the node attributes outlining a node in color, or "" for
+// no color
+func dotColor(color string) string {
+	if color == "" {
+		return ""
+	}
+	return fmt.Sprintf(", color=%s, penwidth=2", dotQuote(color))
+}
+
+// dotQuote returns s as a quoted DOT string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
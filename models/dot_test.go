@@ -0,0 +1,61 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDOTExporter(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Name = `Media "Player"`
+	sm.Regions[0].Vertices = append(sm.Regions[0].Vertices,
+		&Vertex{ID: "sync", Name: "fork", Type: "pseudostate"},
+		&Vertex{ID: "done", Name: "Done", Type: "finalstate"},
+	)
+
+	var out bytes.Buffer
+	if err := Export(sm, "dot", &out, ExportOptions{Properties: map[string]string{"direction": "LR"}}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	graph := out.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "header", want: "digraph \"Media \\\"Player\\\"\" {\n  compound=true;\n"},
+		{name: "direction", want: "  rankdir=\"LR\";\n"},
+		{name: "initial pseudostate", want: "  \"initial\" [shape=point, width=0.15];\n"},
+		{name: "fork bar", want: "  \"sync\" [shape=rect, label=\"\", height=0.05"},
+		{name: "final state", want: "  \"done\" [shape=doublecircle"},
+		{name: "composite cluster", want: "  subgraph \"cluster_playing\" {\n    label=\"playing\";\n"},
+		{name: "composite anchor", want: "    \"playing\" [shape=point, style=invis];\n"},
+		{name: "orthogonal region cluster", want: "    subgraph \"cluster_audio\" {\n"},
+		{name: "nested state", want: "      \"loading\" [label=\"loading\"];\n"},
+		{name: "edge into composite", want: "  \"idle\" -> \"playing\" [label=\"play\", lhead=\"cluster_playing\"];\n"},
+		{name: "edge out of composite", want: "  \"playing\" -> \"idle\" [label=\"stop\", ltail=\"cluster_playing\"];\n"},
+		{name: "unlabeled edge", want: "  \"initial\" -> \"idle\";\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(graph, tt.want) {
+				t.Errorf("DOT export missing %q in\n%s", tt.want, graph)
+			}
+		})
+	}
+}
+
+func TestDOTQuote(t *testing.T) {
+	tests := map[string]string{
+		"idle":       `"idle"`,
+		`say "hi"`:   `"say \"hi\""`,
+		`a\b`:        `"a\\b"`,
+		"two\nlines": `"two\nlines"`,
+	}
+	for input, want := range tests {
+		if got := dotQuote(input); got != want {
+			t.Errorf("dotQuote(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package models
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// drawioExporter exports draw.io (mxGraph) files that teams can keep editing
+// visually. It places shapes like the svg exporter, from the state machine's
+// Layout or a fresh LayeredLayout in the "direction" property's direction:
+//   - states are rounded boxes; composite states are containers with their
+//     name in a header, holding their substates, or one dashed container per
+//     region if they have several; the same holds for top-level regions
+//   - pseudostates and final states use the UML shapes of draw.io's UML
+//     palette, with their kind as a hidden label so that ImportDrawio
+//     recognizes them
+//   - transitions are edges labeled "events [guard] / effect"
+//
+// With the "embed" property set to "true", the JSON encoding of the state
+// machine is stored in the diagram, and ImportDrawio reads it back instead
+// of the shapes, without losing anything the diagram cannot show.
+type drawioExporter struct{}
+
+func (drawioExporter) Name() string { return "drawio" }
+
+// IDs of the root and default layer cells every draw.io diagram has
+const (
+	drawioRootID  = "0"
+	drawioLayerID = "1"
+)
+
+func (drawioExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	layout, err := diagramLayout(sm, opts)
+	if err != nil {
+		return err
+	}
+	writer := &drawioWriter{layout: layout}
+
+	root := fmt.Sprintf(`<mxCell id="%s"/>`, drawioRootID)
+	if opts.Properties["embed"] == "true" {
+		var source strings.Builder
+		if err := (jsonExporter{}).Export(sm, &source, opts); err != nil {
+			return err
+		}
+		root = fmt.Sprintf(`<UserObject label="" id="%s" statemachine=%s><mxCell/></UserObject>`, drawioRootID, xmlAttr(source.String()))
+	}
+
+	writer.out.WriteString(`<mxfile host="go-uml-statemachine-models" type="device">` + "\n")
+	fmt.Fprintf(&writer.out, "  <diagram id=%s name=%s>\n", xmlAttr(sm.ID), xmlAttr(opts.title(sm)))
+	fmt.Fprintf(&writer.out, `    <mxGraphModel grid="1" gridSize="10" guides="1" tooltips="1" connect="1" arrows="1" fold="1" page="0" pageWidth="%s" pageHeight="%s">`+"\n",
+		svgNumber(layout.Width), svgNumber(layout.Height))
+	writer.out.WriteString("      <root>\n")
+	writer.out.WriteString("        " + root + "\n")
+	fmt.Fprintf(&writer.out, "        <mxCell id=\"%s\" parent=\"%s\"/>\n", drawioLayerID, drawioRootID)
+	writer.writeRegions(sm.Regions, drawioLayerID, Box{})
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil {
+			writer.writeTransition(transition)
+		}
+	})
+	writer.out.WriteString("      </root>\n    </mxGraphModel>\n  </diagram>\n</mxfile>\n")
+
+	_, err = io.WriteString(w, writer.out.String())
+	return err
+}
+
+// drawioWriter accumulates the cells of a draw.io diagram
+type drawioWriter struct {
+	out    strings.Builder
+	layout *Layout
+}
+
+// writeRegions writes the contents of sibling regions into the parent cell
+// whose absolute box is origin, with a dashed container per region if there
+// are several
+func (d *drawioWriter) writeRegions(regions []*Region, parent string, origin Box) {
+	regions = nonNilRegions(regions)
+	for _, region := range regions {
+		regionParent, regionOrigin := parent, origin
+		if len(regions) > 1 {
+			box := d.layout.Regions[region.ID]
+			d.writeCell(region.ID, region.Name, "rounded=0;dashed=1;fillColor=none;container=1;collapsible=0;verticalAlign=top;align=left;spacingLeft=4;", parent, box, origin)
+			regionParent, regionOrigin = region.ID, box
+		}
+		for _, state := range region.States {
+			if state != nil {
+				d.writeState(state, regionParent, regionOrigin)
+			}
+		}
+		for _, vertex := range region.Vertices {
+			if vertex != nil {
+				d.writeVertex(vertex, regionParent, regionOrigin)
+			}
+		}
+	}
+}
+
+// writeState writes a state, and the contents of a composite state inside it
+func (d *drawioWriter) writeState(state *State, parent string, origin Box) {
+	box := d.layout.Vertices[state.ID]
+	fontStyle := 0
+	if state.Deprecated {
+		fontStyle = 8 // Strikethrough
+	}
+	name := displayName(state.Name, state.ID)
+	if len(state.Regions) == 0 {
+		d.writeCell(state.ID, name, fmt.Sprintf("rounded=1;arcSize=20;fillColor=#fefece;fontStyle=%d;", fontStyle), parent, box, origin)
+		return
+	}
+	d.writeCell(state.ID, name, fmt.Sprintf("swimlane;rounded=1;arcSize=10;fillColor=#fefece;startSize=%s;fontStyle=%d;container=1;collapsible=0;", svgNumber(layoutHeaderHeight), fontStyle|1), parent, box, origin)
+	d.writeRegions(state.Regions, state.ID, box)
+}
+
+// writeVertex writes a pseudostate or final state with its UML shape. Shapes
+// without text keep their kind as a hidden label.
+func (d *drawioWriter) writeVertex(vertex *Vertex, parent string, origin Box) {
+	box := d.layout.Vertices[vertex.ID]
+	kind := pseudostateKindOf(vertex)
+	label, style := string(kind), "noLabel=1;"
+	switch {
+	case vertex.Type == "finalstate":
+		label, style = "final", "ellipse;shape=endState;fillColor=#000000;"+style
+	case kind == PseudostateKindInitial:
+		style = "ellipse;shape=startState;fillColor=#000000;" + style
+	case kind == PseudostateKindChoice:
+		style = "rhombus;" + style
+	case kind == PseudostateKindJunction:
+		style = "ellipse;fillColor=#000000;" + style
+	case kind == PseudostateKindFork, kind == PseudostateKindJoin:
+		// A bar across the direction of the flow
+		cx, cy := box.Center()
+		if d.layout.Direction == LayoutLeftToRight {
+			box = Box{X: cx - 3, Y: box.Y, Width: 6, Height: box.Height}
+		} else {
+			box = Box{X: box.X, Y: cy - 3, Width: box.Width, Height: 6}
+		}
+		style = "fillColor=#000000;strokeColor=none;" + style
+	case kind == PseudostateKindShallowHistory:
+		label, style = "H", "ellipse;"
+	case kind == PseudostateKindDeepHistory:
+		label, style = "H*", "ellipse;"
+	case kind == PseudostateKindEntryPoint:
+		style = "ellipse;fillColor=#ffffff;" + style
+	case kind == PseudostateKindExitPoint:
+		style = "shape=sumEllipse;perimeter=ellipsePerimeter;fillColor=#ffffff;" + style
+	case kind == PseudostateKindTerminate:
+		style = "shape=umlDestroy;" + style
+	default:
+		label, style = displayName(vertex.Name, vertex.ID), "ellipse;"
+	}
+	d.writeCell(vertex.ID, label, style, parent, box, origin)
+}
+
+// writeCell writes a vertex cell; mxGraph positions children relative to
+// their parent, so the absolute box is made relative to the parent's origin
+func (d *drawioWriter) writeCell(id, value, style, parent string, box, origin Box) {
+	fmt.Fprintf(&d.out, "        <mxCell id=%s value=%s style=%s vertex=\"1\" parent=%s>\n", xmlAttr(id), xmlAttr(value), xmlAttr(style), xmlAttr(parent))
+	fmt.Fprintf(&d.out, "          <mxGeometry x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" as=\"geometry\"/>\n",
+		svgNumber(box.X-origin.X), svgNumber(box.Y-origin.Y), svgNumber(box.Width), svgNumber(box.Height))
+	d.out.WriteString("        </mxCell>\n")
+}
+
+// writeTransition writes a transition as an edge on the default layer
+func (d *drawioWriter) writeTransition(transition *Transition) {
+	style := "endArrow=open;endSize=10;rounded=1;"
+	if transition.Deprecated {
+		style += "fontStyle=8;"
+	}
+	fmt.Fprintf(&d.out, "        <mxCell id=%s value=%s style=%s edge=\"1\" parent=\"%s\" source=%s target=%s>\n",
+		xmlAttr(transition.ID), xmlAttr(transitionLabel(transition)), xmlAttr(style), drawioLayerID, xmlAttr(transition.Source.ID), xmlAttr(transition.Target.ID))
+	d.out.WriteString("          <mxGeometry relative=\"1\" as=\"geometry\"/>\n")
+	d.out.WriteString("        </mxCell>\n")
+}
+
+// drawioFile is the subset of a draw.io file read by ImportDrawio
+type drawioFile struct {
+	Diagrams []drawioPage `xml:"diagram"`
+}
+
+type drawioPage struct {
+	ID    string       `xml:"id,attr"`
+	Name  string       `xml:"name,attr"`
+	Model *drawioModel `xml:"mxGraphModel"`
+	Data  string       `xml:",chardata"` // Compressed model
+}
+
+type drawioModel struct {
+	Root struct {
+		Cells []drawioCell `xml:",any"` // mxCell, object or UserObject elements
+	} `xml:"root"`
+}
+
+// drawioCell is an mxCell, or an object wrapping one to hold custom data
+type drawioCell struct {
+	ID           string      `xml:"id,attr"`
+	Value        string      `xml:"value,attr"`
+	Label        string      `xml:"label,attr"` // Value of wrapped cells
+	Style        string      `xml:"style,attr"`
+	Parent       string      `xml:"parent,attr"`
+	Vertex       string      `xml:"vertex,attr"`
+	Edge         string      `xml:"edge,attr"`
+	Source       string      `xml:"source,attr"`
+	Target       string      `xml:"target,attr"`
+	StateMachine string      `xml:"statemachine,attr"` // Embedded JSON encoding
+	Cell         *drawioCell `xml:"mxCell"`
+}
+
+// unwrap merges the wrapped cell of an object into it
+func (c drawioCell) unwrap() drawioCell {
+	if c.Cell == nil {
+		return c
+	}
+	inner := *c.Cell
+	inner.ID, inner.Value, inner.StateMachine = c.ID, c.Label, c.StateMachine
+	return inner
+}
+
+// ImportDrawio reads a draw.io file, one state machine per diagram page. A
+// page exported with the state machine embedded is read back exactly; other
+// pages are mapped by shape and label like ImportGraphML: ellipses, start
+// and end states, rhombi and UML palette shapes become pseudostates and
+// final states, containers become composite states, dashed containers are
+// treated as regions and flattened into their state, and edges become
+// transitions labeled "events [guard] / effect". Compressed pages are
+// supported.
+func ImportDrawio(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	var file drawioFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse draw.io file: %w", err)
+	}
+	if len(file.Diagrams) == 0 {
+		return nil, nil, fmt.Errorf("draw.io file contains no diagrams")
+	}
+
+	report := &ImportReport{Format: "draw.io"}
+	var machines []*StateMachine
+	for i, diagram := range file.Diagrams {
+		model := diagram.Model
+		if model == nil {
+			var err error
+			if model, err = inflateDrawioModel(diagram.Data); err != nil {
+				return nil, nil, fmt.Errorf("failed to read diagram %d: %w", i+1, err)
+			}
+		}
+		cells := make([]drawioCell, 0, len(model.Root.Cells))
+		for _, cell := range model.Root.Cells {
+			cells = append(cells, cell.unwrap())
+		}
+
+		embedded := false
+		for _, cell := range cells {
+			if cell.StateMachine != "" {
+				sm, err := DecodeStateMachine(strings.NewReader(cell.StateMachine), ResourceLimits{})
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to decode the state machine embedded in diagram %d: %w", i+1, err)
+				}
+				normalizeKinds(sm, report)
+				machines = append(machines, sm)
+				embedded = true
+				break
+			}
+		}
+		if embedded {
+			continue
+		}
+
+		id := diagram.ID
+		if id == "" {
+			id = fmt.Sprintf("page-%d", i+1)
+		}
+		nodes, edges := drawioDiagramShapes(cells, report)
+		machines = append(machines, buildDiagramMachine(id, displayName(diagram.Name, id), nodes, edges, report))
+	}
+	return machines, report, nil
+}
+
+// inflateDrawioModel decodes a compressed diagram: the URL-encoded model XML,
+// deflated and base64-encoded
+func inflateDrawioModel(data string) (*drawioModel, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed diagram: %w", err)
+	}
+	inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed diagram: %w", err)
+	}
+	text, err := url.PathUnescape(string(inflated))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed diagram: %w", err)
+	}
+	var model drawioModel
+	if err := xml.Unmarshal([]byte(text), &model); err != nil {
+		return nil, fmt.Errorf("invalid diagram model: %w", err)
+	}
+	return &model, nil
+}
+
+// drawioDiagramShapes converts the vertex and edge cells of a page into
+// diagram nodes and edges
+func drawioDiagramShapes(cells []drawioCell, report *ImportReport) ([]diagramNode, []diagramEdge) {
+	parents := make(map[string]string)
+	regions := make(map[string]bool)
+	childCount := make(map[string]int)
+	for _, cell := range cells {
+		if cell.Vertex == "1" {
+			parents[cell.ID] = cell.Parent
+			childCount[cell.Parent]++
+		}
+	}
+	for _, cell := range cells {
+		if cell.Vertex == "1" && childCount[cell.ID] > 0 && drawioStyle(cell.Style)["dashed"] == "1" {
+			regions[cell.ID] = true
+		}
+	}
+	// parentOf skips region containers and layers
+	parentOf := func(id string) string {
+		parent := parents[id]
+		for regions[parent] {
+			parent = parents[parent]
+		}
+		if _, isVertex := parents[parent]; !isVertex {
+			return ""
+		}
+		return parent
+	}
+
+	var nodes []diagramNode
+	var edges []diagramEdge
+	regionsOf := make(map[string]int)
+	for _, cell := range cells {
+		label := drawioLabel(cell)
+		switch {
+		case cell.Vertex == "1" && regions[cell.ID]:
+			if parent := parentOf(cell.ID); parent != "" {
+				if regionsOf[parent]++; regionsOf[parent] == 2 {
+					report.add("node", parent, "", "has several regions, which were merged into one")
+				}
+			}
+		case cell.Vertex == "1":
+			label, shape := drawioShape(cell.Style, label)
+			nodes = append(nodes, diagramNode{ID: cell.ID, Label: label, Parent: parentOf(cell.ID), Shape: shape})
+		case cell.Edge == "1":
+			if cell.Source == "" || cell.Target == "" {
+				report.add("edge", cell.ID, label, "is not connected at both ends; skipped")
+				continue
+			}
+			edges = append(edges, diagramEdge{ID: cell.ID, Source: cell.Source, Target: cell.Target, Label: label})
+		}
+	}
+	return nodes, edges
+}
+
+// drawioLabel returns the plain text label of a cell
+func drawioLabel(cell drawioCell) string {
+	if drawioStyle(cell.Style)["html"] == "1" {
+		return html.UnescapeString(stripMarkup(cell.Value))
+	}
+	return strings.Join(strings.Fields(cell.Value), " ")
+}
+
+// drawioShape maps the style of a vertex cell to the shape hints of
+// buildDiagramMachine, naming unlabeled UML palette shapes by what they
+// denote
+func drawioShape(style, label string) (string, string) {
+	settings := drawioStyle(style)
+	switch settings["shape"] {
+	case "startState":
+		return displayName(label, "initial"), "ellipse"
+	case "endState":
+		return displayName(label, "final"), "ellipse"
+	case "umlDestroy":
+		return displayName(label, "terminate"), ""
+	case "line":
+		return label, "bar"
+	}
+	for _, shape := range []struct{ style, hint string }{{"ellipse", "ellipse"}, {"doubleEllipse", "ellipse"}, {"rhombus", "rhombus"}} {
+		if _, exists := settings[shape.style]; exists {
+			return label, shape.hint
+		}
+	}
+	return label, ""
+}
+
+// drawioStyle parses a cell style such as "ellipse;fillColor=#000000;" into
+// its settings; bare names map to ""
+func drawioStyle(style string) map[string]string {
+	settings := make(map[string]string)
+	for _, part := range strings.Split(style, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			key, value, _ := strings.Cut(part, "=")
+			settings[key] = value
+		}
+	}
+	return settings
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// exportDrawio exports the state machine as a draw.io file
+func exportDrawio(t *testing.T, sm *StateMachine, properties map[string]string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Export(sm, "drawio", &out, ExportOptions{Properties: properties}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	return out.String()
+}
+
+func TestDrawioExporter(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Regions[0].Vertices = append(sm.Regions[0].Vertices,
+		&Vertex{ID: "pick", Name: "choice", Type: "pseudostate"},
+		&Vertex{ID: "sync", Name: "fork", Type: "pseudostate"},
+		&Vertex{ID: "done", Name: "Done", Type: "finalstate"},
+	)
+	sm.Regions[0].States[0].Deprecated = true
+	file := exportDrawio(t, sm, nil)
+
+	decoder := xml.NewDecoder(strings.NewReader(file))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("draw.io export is not well-formed XML: %v\n%s", err, file)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "page", want: `<diagram id="player" name="Player">`},
+		{name: "layers", want: "<mxCell id=\"0\"/>\n        <mxCell id=\"1\" parent=\"0\"/>\n"},
+		{name: "state", want: `<mxCell id="idle" value="idle" style="rounded=1;arcSize=20;fillColor=#fefece;fontStyle=8;" vertex="1" parent="1">`},
+		{name: "composite container", want: `<mxCell id="playing" value="playing" style="swimlane;`},
+		{name: "region container", want: `<mxCell id="audio" value="" style="rounded=0;dashed=1;fillColor=none;container=1;`},
+		{name: "region in composite", want: `vertex="1" parent="playing">`},
+		{name: "state in region", want: `<mxCell id="loading" value="loading" style="rounded=1;arcSize=20;fillColor=#fefece;fontStyle=0;" vertex="1" parent="audio">`},
+		{name: "initial", want: `<mxCell id="initial" value="initial" style="ellipse;shape=startState;fillColor=#000000;noLabel=1;"`},
+		{name: "choice", want: `<mxCell id="pick" value="choice" style="rhombus;noLabel=1;"`},
+		{name: "fork bar", want: `<mxCell id="sync" value="fork" style="fillColor=#000000;strokeColor=none;noLabel=1;"`},
+		{name: "final", want: `<mxCell id="done" value="final" style="ellipse;shape=endState;`},
+		{name: "transition", want: `value="play" style="endArrow=open;endSize=10;rounded=1;" edge="1" parent="1" source="idle" target="playing">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(file, tt.want) {
+				t.Errorf("draw.io export missing %q in\n%s", tt.want, file)
+			}
+		})
+	}
+	if strings.Contains(file, "statemachine=") {
+		t.Error("the state machine should only be embedded on request")
+	}
+}
+
+func TestDrawioExporter_RelativeGeometry(t *testing.T) {
+	sm := newPlayerMachine()
+	if err := sm.ApplyLayout(nil); err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+	// Children are placed relative to their container
+	var geometry struct {
+		Cells []struct {
+			ID       string `xml:"id,attr"`
+			Geometry struct {
+				X float64 `xml:"x,attr"`
+				Y float64 `xml:"y,attr"`
+			} `xml:"mxGeometry"`
+		} `xml:"diagram>mxGraphModel>root>mxCell"`
+	}
+	if err := xml.Unmarshal([]byte(exportDrawio(t, sm, nil)), &geometry); err != nil {
+		t.Fatalf("xml.Unmarshal() error = %v", err)
+	}
+	positions := make(map[string][2]float64)
+	for _, cell := range geometry.Cells {
+		positions[cell.ID] = [2]float64{cell.Geometry.X, cell.Geometry.Y}
+	}
+	loading, audio := sm.Layout.Vertices["loading"], sm.Layout.Regions["audio"]
+	want := [2]float64{loading.X - audio.X, loading.Y - audio.Y}
+	if positions["loading"] != want {
+		t.Errorf("loading at %v, want %v relative to its region", positions["loading"], want)
+	}
+	if idle := sm.Layout.Vertices["idle"]; positions["idle"] != [2]float64{idle.X, idle.Y} {
+		t.Errorf("idle at %v, want %v on the top level", positions["idle"], idle)
+	}
+}
+
+func TestImportDrawio(t *testing.T) {
+	t.Run("shapes", func(t *testing.T) {
+		original := newPlayerMachine()
+		original.Regions[0].Vertices = append(original.Regions[0].Vertices,
+			&Vertex{ID: "pick", Name: "choice", Type: "pseudostate"},
+			&Vertex{ID: "done", Name: "Done", Type: "finalstate"},
+		)
+		sm, report, err := Load(strings.NewReader(exportDrawio(t, original, nil)))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if sm.ID != "player" || sm.Name != "Player" {
+			t.Errorf("machine = %s (%s), want the page", sm.ID, sm.Name)
+		}
+
+		main := sm.Regions[0]
+		kinds := make(map[string]string)
+		for _, vertex := range main.Vertices {
+			kinds[vertex.ID] = vertex.Type + ":" + string(pseudostateKindOf(vertex))
+		}
+		for id, want := range map[string]string{"initial": "pseudostate:initial", "pick": "pseudostate:choice", "done": "finalstate:"} {
+			if kinds[id] != want {
+				t.Errorf("vertex %s = %q, want %q", id, kinds[id], want)
+			}
+		}
+
+		var playing *State
+		for _, state := range main.States {
+			if state.ID == "playing" {
+				playing = state
+			}
+		}
+		if playing == nil || !playing.IsComposite || len(playing.Regions) != 1 || len(playing.Regions[0].States) != 4 {
+			t.Fatalf("playing = %+v, want a composite state holding both regions' states", playing)
+		}
+		if !strings.Contains(report.String(), "has several regions, which were merged into one") {
+			t.Errorf("report should mention the merged regions:\n%s", report)
+		}
+
+		var play *Transition
+		for _, transition := range main.Transitions {
+			if transition.ID == "play" {
+				play = transition
+			}
+		}
+		if play == nil || play.Source.ID != "idle" || play.Target.ID != "playing" || len(play.Triggers) != 1 || play.Triggers[0].EventID != "play" {
+			t.Errorf("play = %+v, want idle -> playing on play", play)
+		}
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		original := newPlayerMachine()
+		machines, report, err := ImportDrawio(strings.NewReader(exportDrawio(t, original, map[string]string{"embed": "true"})))
+		if err != nil || len(machines) != 1 {
+			t.Fatalf("ImportDrawio() = %d machines, %v", len(machines), err)
+		}
+		if changes := Diff(original, machines[0]); len(changes) > 0 {
+			t.Errorf("embedded machine differs from the original: %v", changes)
+		}
+		if len(report.Issues) > 0 {
+			t.Errorf("report = %s, want no issues", report)
+		}
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		model := `<mxGraphModel><root><mxCell id="0"/><mxCell id="1" parent="0"/>` +
+			`<mxCell id="s" value="" style="ellipse;html=1;shape=startState;" vertex="1" parent="1"/>` +
+			`<mxCell id="a" value="&lt;b&gt;Off&lt;/b&gt; &amp;amp; idle" style="rounded=1;html=1;" vertex="1" parent="1"/>` +
+			`<mxCell id="e" value="" edge="1" parent="1" source="s" target="a"/>` +
+			`</root></mxGraphModel>`
+		var compressed bytes.Buffer
+		writer, _ := flate.NewWriter(&compressed, flate.BestCompression)
+		writer.Write([]byte(url.PathEscape(model)))
+		writer.Close()
+		file := `<mxfile><diagram id="switch" name="Switch">` + base64.StdEncoding.EncodeToString(compressed.Bytes()) + `</diagram></mxfile>`
+
+		machines, _, err := ImportDrawio(strings.NewReader(file))
+		if err != nil {
+			t.Fatalf("ImportDrawio() error = %v", err)
+		}
+		main := machines[0].Regions[0]
+		if len(main.States) != 1 || main.States[0].Name != "Off & idle" {
+			t.Errorf("states = %v, want the plain text label", main.States)
+		}
+		if len(main.Vertices) != 1 || pseudostateKindOf(main.Vertices[0]) != PseudostateKindInitial {
+			t.Errorf("vertices = %v, want the start state as initial pseudostate", main.Vertices)
+		}
+	})
+}
+
+func TestImportDrawio_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "malformed", input: `<mxfile><diagram>`, want: "failed to parse draw.io file"},
+		{name: "no diagrams", input: `<mxfile/>`, want: "contains no diagrams"},
+		{name: "bad compression", input: `<mxfile><diagram>not base64!</diagram></mxfile>`, want: "invalid compressed diagram"},
+		{name: "bad embedded machine", input: `<mxfile><diagram><mxGraphModel><root><UserObject id="0" statemachine="{"><mxCell/></UserObject></root></mxGraphModel></diagram></mxfile>`, want: "failed to decode the state machine embedded in diagram 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ImportDrawio(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ImportDrawio() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
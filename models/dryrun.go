@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EditFunc is an edit operation on a state machine, such as a call to
+// RenameElement, RetargetTransition or a direct change to the model
+type EditFunc func(sm *StateMachine) error
+
+// ValidationDelta describes how an edit changes the validation result of a state machine
+type ValidationDelta struct {
+	Valid      bool               // The edited state machine has no validation errors
+	Before     int                // Number of errors before the edit
+	After      int                // Number of errors after the edit
+	Introduced []*ValidationError // Errors present only after the edit
+	Resolved   []*ValidationError // Errors present only before the edit
+}
+
+// Regresses reports whether the edit introduces validation errors
+func (d *ValidationDelta) Regresses() bool {
+	return d != nil && len(d.Introduced) > 0
+}
+
+// WouldBeValid applies edit to a clone of sm and reports the resulting
+// change in validation errors without mutating sm, so editors can warn
+// before committing a change. It returns an error only if the edit itself
+// fails.
+func WouldBeValid(sm *StateMachine, edit EditFunc) (*ValidationDelta, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	if edit == nil {
+		return nil, fmt.Errorf("edit cannot be nil")
+	}
+
+	before := collectValidationErrors(sm)
+
+	candidate := sm.Clone()
+	if err := edit(candidate); err != nil {
+		return nil, fmt.Errorf("edit failed: %w", err)
+	}
+	after := collectValidationErrors(candidate)
+
+	return &ValidationDelta{
+		Valid:      !after.HasErrors(),
+		Before:     before.Count(),
+		After:      after.Count(),
+		Introduced: subtractErrors(after.Errors, before.Errors),
+		Resolved:   subtractErrors(before.Errors, after.Errors),
+	}, nil
+}
+
+// collectValidationErrors validates sm with a state machine context and returns all errors
+func collectValidationErrors(sm *StateMachine) *ValidationErrors {
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext().WithStateMachine(sm), errors)
+	return errors
+}
+
+// subtractErrors returns the errors in a that have no matching error in b,
+// treating both as multisets keyed by type, object, field, message and path
+func subtractErrors(a, b []*ValidationError) []*ValidationError {
+	remaining := make(map[string]int)
+	for _, err := range b {
+		remaining[validationErrorKey(err)]++
+	}
+
+	var result []*ValidationError
+	for _, err := range a {
+		key := validationErrorKey(err)
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		result = append(result, err)
+	}
+	return result
+}
+
+// validationErrorKey identifies an error for comparison across validation runs
+func validationErrorKey(err *ValidationError) string {
+	return strings.Join([]string{err.Type.String(), err.Object, err.Field, err.Message, strings.Join(err.Path, ".")}, "\x00")
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWouldBeValid(t *testing.T) {
+	tests := []struct {
+		name           string
+		setup          func(sm *StateMachine)
+		edit           EditFunc
+		wantValid      bool
+		wantIntroduced int
+		wantResolved   int
+		wantErr        bool
+	}{
+		{
+			name:      "valid rename",
+			edit:      func(sm *StateMachine) error { return RenameElement(sm, "state2", "", "Shipped") },
+			wantValid: true,
+		},
+		{
+			name: "edit that breaks the model",
+			edit: func(sm *StateMachine) error {
+				sm.Regions[0].States[0].Regions = []*Region{{ID: "nested", Name: "Nested"}}
+				return nil
+			},
+			wantIntroduced: 1,
+		},
+		{
+			name:         "edit that fixes the model",
+			setup:        func(sm *StateMachine) { sm.Version = "" },
+			edit:         func(sm *StateMachine) error { sm.Version = "2.0"; return nil },
+			wantValid:    true,
+			wantResolved: 1,
+		},
+		{
+			name:    "failing edit",
+			edit:    func(sm *StateMachine) error { return errors.New("boom") },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			if tt.setup != nil {
+				tt.setup(sm)
+			}
+			original := collectValidationErrors(sm).Count()
+			stateName := sm.Regions[0].States[1].Name
+
+			delta, err := WouldBeValid(sm, tt.edit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WouldBeValid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if delta.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v (introduced: %v)", delta.Valid, tt.wantValid, delta.Introduced)
+			}
+			if tt.wantIntroduced > 0 && len(delta.Introduced) < tt.wantIntroduced {
+				t.Errorf("Introduced = %v, want at least %d", delta.Introduced, tt.wantIntroduced)
+			}
+			if tt.wantIntroduced == 0 && delta.Regresses() {
+				t.Errorf("Regresses() = true, introduced: %v", delta.Introduced)
+			}
+			if len(delta.Resolved) != tt.wantResolved {
+				t.Errorf("Resolved = %v, want %d", delta.Resolved, tt.wantResolved)
+			}
+			if delta.Before != original || delta.After != delta.Before+len(delta.Introduced)-len(delta.Resolved) {
+				t.Errorf("Before/After = %d/%d inconsistent with delta (original %d)", delta.Before, delta.After, original)
+			}
+
+			if got := collectValidationErrors(sm).Count(); got != original {
+				t.Errorf("WouldBeValid() mutated the original: %d errors, want %d", got, original)
+			}
+			if sm.Regions[0].States[1].Name != stateName || len(sm.Regions[0].States[0].Regions) != 0 {
+				t.Error("WouldBeValid() mutated the original state machine")
+			}
+		})
+	}
+
+	if _, err := WouldBeValid(nil, func(*StateMachine) error { return nil }); err == nil {
+		t.Error("WouldBeValid(nil, ...) should fail")
+	}
+	if _, err := WouldBeValid(createValidStateMachine(), nil); err == nil {
+		t.Error("WouldBeValid(sm, nil) should fail")
+	}
+}
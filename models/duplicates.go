@@ -0,0 +1,69 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// validateDuplicateTransitions warns about transitions with the same source,
+// target, kind, trigger set and guard as an earlier transition of the state
+// machine, in any region. Such a transition never changes what the machine
+// does and is usually a copy-paste error, which large imported models are
+// prone to. Each duplicate is reported at its own path, with the path of the
+// transition it duplicates in the message and the IDs of both transitions
+// under ElementIDsContextKey. Transitions differing only in their effect are
+// duplicates as well: only one of them can fire. MergeDuplicateTransitions
+// merges transitions differing only in their triggers.
+func (sm *StateMachine) validateDuplicateTransitions(context *ValidationContext, errors *ValidationErrors) {
+	type definition struct {
+		transition *Transition
+		path       []string
+	}
+	first := make(map[string]definition)
+
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, transition := range region.Transitions {
+			if transition == nil || transition.Source == nil || transition.Target == nil {
+				continue
+			}
+			transitionPath := append(append([]string{}, context.Path...), strings.Split(fmt.Sprintf("%s.Transitions[%d]", path, i), ".")...)
+			key := duplicateTransitionKey(transition)
+			original, exists := first[key]
+			if !exists {
+				first[key] = definition{transition: transition, path: transitionPath}
+				continue
+			}
+			errors.Add(&ValidationError{
+				Type:   ErrorTypeConstraint,
+				Object: "Transition",
+				Field:  "ID",
+				Message: fmt.Sprintf("transition '%s' at %s duplicates transition '%s' at %s: both lead from '%s' to '%s' with the same kind, triggers and guard",
+					transition.ID, strings.Join(transitionPath, "."), original.transition.ID, strings.Join(original.path, "."),
+					transition.Source.ID, transition.Target.ID),
+				Path:     transitionPath,
+				Context:  map[string]interface{}{ElementIDsContextKey: []string{original.transition.ID, transition.ID}},
+				Severity: SeverityWarning,
+			})
+		}
+	})
+}
+
+// duplicateTransitionKey identifies transitions with the same source,
+// target, kind, set of triggered events and guard
+func duplicateTransitionKey(t *Transition) string {
+	var events []string
+	for _, trigger := range t.Triggers {
+		if trigger != nil {
+			events = append(events, trigger.EventKey())
+		}
+	}
+	slices.Sort(events)
+	events = slices.Compact(events)
+
+	guard := ""
+	if t.Guard != nil {
+		guard = t.Guard.Language + "\x00" + strings.TrimSpace(t.Guard.Specification)
+	}
+	return strings.Join([]string{t.Source.ID, t.Target.ID, string(t.Kind), strings.Join(events, "\x01"), guard}, "\x00")
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateDuplicateTransitions(t *testing.T) {
+	// copyOf returns a copy of the valid fixture's transition t2 with another ID
+	copyOf := func(sm *StateMachine, id string) *Transition {
+		duplicate := *sm.Regions[0].Transitions[1]
+		duplicate.ID = id
+		return &duplicate
+	}
+
+	tests := []struct {
+		name         string
+		modify       func(sm *StateMachine)
+		wantWarnings []string
+		wantIDs      []string
+		wantPath     string
+	}{
+		{
+			name: "distinct transitions",
+		},
+		{
+			name: "copied transition",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, copyOf(sm, "t2-copy"))
+			},
+			wantWarnings: []string{"transition 't2-copy' at Regions[0].Transitions[3] duplicates transition 't2' at Regions[0].Transitions[1]: both lead from 'state1' to 'state2'"},
+			wantIDs:      []string{"t2", "t2-copy"},
+			wantPath:     "Regions[0].Transitions[3]",
+		},
+		{
+			name: "trigger order, repeated events and guard spacing do not matter",
+			modify: func(sm *StateMachine) {
+				t1 := sm.Regions[0].Transitions[0]
+				t1.Triggers = append(t1.Triggers, &Trigger{ID: "trigger2", EventID: "event2"})
+				duplicate := *t1
+				duplicate.ID = "t1-copy"
+				duplicate.Triggers = []*Trigger{{ID: "trigger3", EventID: "event2"}, t1.Triggers[0], t1.Triggers[0]}
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, &duplicate)
+
+				t2 := copyOf(sm, "t2-copy")
+				t2.Guard = &Constraint{ID: "guard2", Specification: " x > 0 ", Language: t2.Guard.Language}
+				t2.Effect = nil
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, t2)
+			},
+			wantWarnings: []string{"transition 't1-copy' at Regions[0].Transitions[3] duplicates transition 't1'", "transition 't2-copy' at Regions[0].Transitions[4] duplicates transition 't2'"},
+			wantIDs:      []string{"t1", "t1-copy"},
+			wantPath:     "Regions[0].Transitions[3]",
+		},
+		{
+			name: "different trigger, guard or kind",
+			modify: func(sm *StateMachine) {
+				triggered := copyOf(sm, "t2-triggered")
+				triggered.Triggers = []*Trigger{{ID: "trigger2", EventID: "event2"}}
+				guarded := copyOf(sm, "t2-guarded")
+				guarded.Guard = &Constraint{ID: "guard2", Specification: "x < 0"}
+				local := copyOf(sm, "t2-local")
+				local.Kind = TransitionKindLocal
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, triggered, guarded, local)
+			},
+		},
+		{
+			name: "every copy is reported against the first",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, copyOf(sm, "copy1"), copyOf(sm, "copy2"))
+			},
+			wantWarnings: []string{"transition 'copy1' at Regions[0].Transitions[3] duplicates transition 't2'", "transition 'copy2' at Regions[0].Transitions[4] duplicates transition 't2'"},
+			wantIDs:      []string{"t2", "copy1"},
+			wantPath:     "Regions[0].Transitions[3]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			errors := &ValidationErrors{}
+			sm.validateDuplicateTransitions(NewValidationContext(), errors)
+			if len(errors.Errors) != 0 || len(errors.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("got errors %v and warnings %v, want %d warnings", errors.Errors, errors.Warnings, len(tt.wantWarnings))
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(errors.Warnings[i].Message, want) {
+					t.Errorf("warning %d = %q, want it to contain %q", i, errors.Warnings[i].Message, want)
+				}
+			}
+			if len(tt.wantWarnings) > 0 {
+				if got := errors.Warnings[0].ElementIDs(); !slices.Equal(got, tt.wantIDs) {
+					t.Errorf("ElementIDs() = %v, want %v", got, tt.wantIDs)
+				}
+				if got := strings.Join(errors.Warnings[0].Path, "."); got != tt.wantPath {
+					t.Errorf("Path = %s, want %s", got, tt.wantPath)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateTransitions_InValidation(t *testing.T) {
+	sm := createValidStateMachine()
+	duplicate := *sm.Regions[0].Transitions[2]
+	duplicate.ID = "t3-copy"
+	sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, &duplicate)
+
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext(), errors)
+	if errors.HasErrors() {
+		t.Fatalf("duplicates should not make the model invalid: %v", errors)
+	}
+	index := slices.IndexFunc(errors.Warnings, func(w *ValidationError) bool { return w.Rule == "transition.duplicates" })
+	if index < 0 || !strings.Contains(errors.Warnings[index].Message, "transition 't3-copy' at Regions[0].Transitions[3] duplicates transition 't3'") {
+		t.Errorf("Warnings = %v, want the duplicate of t3", errors.Warnings)
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ElementIDsContextKey is the ValidationError.Context key listing the IDs
+// of the elements an error is about, so UIs can highlight the offending
+// states and transitions without parsing messages or paths
+const ElementIDsContextKey = "element_ids"
+
+// ElementIDs returns the IDs of the elements the error is about, as recorded
+// under ElementIDsContextKey, or nil
+func (ve *ValidationError) ElementIDs() []string {
+	if ve == nil {
+		return nil
+	}
+	ids, _ := ve.Context[ElementIDsContextKey].([]string)
+	return ids
+}
+
+// attachElementIDs records the element IDs of findings that do not list
+// them yet, resolving each finding's path against root. base is the path of
+// root, which prefixes the paths of its findings. The IDs are those of the
+// innermost element of a collection the path names, of the elements below
+// it and of the element in the finding's field, e.g. a transition and its
+// target; when none has an ID, the nearest enclosing element with an ID is
+// used.
+func attachElementIDs(root interface{}, base []string, findings ...[]*ValidationError) {
+	for _, errors := range findings {
+		for _, err := range errors {
+			if err == nil || err.Context[ElementIDsContextKey] != nil {
+				continue
+			}
+			path := err.Path
+			if len(path) >= len(base) && slices.Equal(path[:len(base)], base) {
+				path = path[len(base):]
+			}
+			if ids := elementIDsAt(root, path, err.Field); len(ids) > 0 {
+				if err.Context == nil {
+					err.Context = make(map[string]interface{})
+				}
+				err.Context[ElementIDsContextKey] = ids
+			}
+		}
+	}
+}
+
+// elementIDsAt returns the IDs of the elements named by a path below root
+// and by the field of the element the path ends at. Segments are field
+// names, optionally indexed ("Regions[0]", "Entities[order]"); segments that
+// name no field, such as "Transition" in the paths of transition checks, are
+// skipped. The reference validator's "Object[id]", "Parent[id]" and
+// "Child[id]" segments name an element by ID.
+func elementIDsAt(root interface{}, path []string, field string) []string {
+	chain := []string{objectID(root)} // IDs of the elements walked through
+	start := 0                        // Index in chain of the innermost collection element
+	current := reflect.ValueOf(root)
+	for _, segment := range path {
+		name, key, indexed := parsePathSegment(segment)
+		if indexed && (name == "Object" || name == "Parent" || name == "Child") {
+			chain, start, current = append(chain, key), len(chain), reflect.Value{}
+			continue
+		}
+		next, ok := pathField(current, name, key, indexed)
+		if !ok {
+			continue
+		}
+		current = next
+		if indexed {
+			start = len(chain)
+		}
+		id := ""
+		if current.CanInterface() {
+			id = objectID(current.Interface())
+		}
+		chain = append(chain, id)
+	}
+	if value, ok := pathField(current, field, "", false); ok && value.CanInterface() {
+		chain = append(chain, objectID(value.Interface()))
+	}
+
+	var ids []string
+	for _, id := range chain[start:] {
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		return ids
+	}
+	for i := start - 1; i >= 0; i-- {
+		if chain[i] != "" {
+			return []string{chain[i]}
+		}
+	}
+	return nil
+}
+
+// parsePathSegment splits "Name[key]" into its name and key
+func parsePathSegment(segment string) (name, key string, indexed bool) {
+	open := strings.IndexByte(segment, '[')
+	if open < 0 || !strings.HasSuffix(segment, "]") {
+		return segment, "", false
+	}
+	return segment[:open], segment[open+1 : len(segment)-1], true
+}
+
+// pathField returns the field of the struct value current names, indexed
+// by key when indexed
+func pathField(current reflect.Value, name, key string, indexed bool) (reflect.Value, bool) {
+	for current.IsValid() && (current.Kind() == reflect.Ptr || current.Kind() == reflect.Interface) {
+		if current.IsNil() {
+			return reflect.Value{}, false
+		}
+		current = current.Elem()
+	}
+	if !current.IsValid() || current.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	field := current.FieldByName(name)
+	if !field.IsValid() || !indexed {
+		return field, field.IsValid()
+	}
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= field.Len() {
+			return reflect.Value{}, false
+		}
+		return field.Index(index), true
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		value := field.MapIndex(reflect.ValueOf(key).Convert(field.Type().Key()))
+		return value, value.IsValid()
+	default:
+		return reflect.Value{}, false
+	}
+}
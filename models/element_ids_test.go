@@ -0,0 +1,75 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestElementIDsAt(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Entities = map[string]string{"order": "Order"}
+
+	tests := []struct {
+		name  string
+		path  []string
+		field string
+		want  []string
+	}{
+		{name: "machine", path: nil, field: "Name", want: []string{"sm1"}},
+		{name: "state", path: []string{"Regions[0]", "States[0]"}, field: "Name", want: []string{"state1"}},
+		{name: "embedded vertex", path: []string{"Regions[0]", "States[0]", "Vertex"}, field: "Name", want: []string{"state1"}},
+		{name: "transition and its target field", path: []string{"Regions[0]", "Transitions[0]"}, field: "Target", want: []string{"t1", "state1"}},
+		{name: "transition and its target path", path: []string{"Regions[0]", "Transitions[1]", "Transition", "Target"}, field: "Target", want: []string{"t2", "state2"}},
+		{name: "element without ID falls back to its owner", path: []string{"Regions[0]", "Transitions[0]", "Guard"}, field: "Language", want: []string{"t1"}},
+		{name: "reference validator object", path: []string{"Object[t2]"}, field: "BidirectionalReference", want: []string{"t2"}},
+		{name: "index out of range", path: []string{"Regions[0]", "States[7]"}, field: "Name", want: []string{"region1"}},
+		{name: "map entry", path: []string{"Entities[order]"}, want: []string{"sm1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elementIDsAt(sm, tt.path, tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("elementIDsAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_ElementIDs(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Regions[0].States[1].Name = ""
+	sm.Regions[0].Transitions[1].Target = &Vertex{ID: "ghost", Name: "Ghost", Type: "state"}
+
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext().WithPath("Machines[0]"), errors)
+	if errors.IsEmpty() {
+		t.Fatal("ValidateWithErrors() found no errors")
+	}
+
+	found := make(map[string]bool)
+	for _, err := range errors.Errors {
+		ids := err.ElementIDs()
+		if len(ids) == 0 {
+			t.Errorf("error %v has no element IDs", err)
+		}
+		for _, id := range ids {
+			found[id] = true
+		}
+	}
+	for _, id := range []string{"state2", "t2", "ghost"} {
+		if !found[id] {
+			t.Errorf("no error names element %s", id)
+		}
+	}
+
+	// IDs set by a rule are kept
+	err := &ValidationError{Path: []string{"Regions[0]"}, Context: map[string]interface{}{ElementIDsContextKey: []string{"a", "b"}}}
+	attachElementIDs(sm, nil, []*ValidationError{err})
+	if got := err.ElementIDs(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("ElementIDs() = %v, want the rule's IDs", got)
+	}
+	var nilErr *ValidationError
+	if nilErr.ElementIDs() != nil {
+		t.Error("nil ElementIDs() != nil")
+	}
+}
@@ -0,0 +1,301 @@
+package models
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SpecificationAlgorithmAES256GCM names AES-256-GCM encryption of
+// specifications under a random data key
+const SpecificationAlgorithmAES256GCM = "AES-256-GCM"
+
+// ErrUnencryptedSpecification is returned when a behavior marked Sensitive
+// is serialized before its specification was encrypted
+var ErrUnencryptedSpecification = errors.New("sensitive specification is not encrypted")
+
+// ErrStaleEncryption is returned when a behavior is serialized after its
+// specification was changed without being encrypted again
+var ErrStaleEncryption = errors.New("specification changed since it was encrypted")
+
+// KeyManager wraps and unwraps the data keys that encrypt specifications
+// under key encryption keys it holds, as cloud key management services do.
+// Only wrapped data keys are stored in models.
+type KeyManager interface {
+	// WrapKey encrypts a data key under the key encryption key keyID
+	WrapKey(keyID string, dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts a data key wrapped under keyID
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// EncryptedSpecification is an envelope-encrypted behavior specification:
+// the specification is encrypted with a random data key, which is stored
+// wrapped by the key manager's key encryption key. The ciphertext is bound
+// to the ID of its behavior, so it cannot be moved to another behavior.
+// Binary fields use the standard base64 encoding.
+type EncryptedSpecification struct {
+	Algorithm  string `json:"algorithm"`
+	KeyID      string `json:"key_id"`      // Key encryption key the data key is wrapped under
+	WrappedKey string `json:"wrapped_key"` // Data key wrapped by the key manager
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+
+	specification string // Plaintext the ciphertext holds, once known
+}
+
+// MarshalJSON encodes the behavior, leaving out the plaintext specification
+// when an encrypted one is present. Encoding a Sensitive behavior that has
+// not been encrypted fails with ErrUnencryptedSpecification, so sensitive
+// specifications never reach storage in plaintext, and encoding a behavior
+// whose specification changed since EncryptSpecifications or
+// DecryptSpecifications last ran fails with ErrStaleEncryption rather than
+// storing the previous specification.
+func (b Behavior) MarshalJSON() ([]byte, error) {
+	type plain Behavior // Without the MarshalJSON method
+	switch {
+	case b.Encrypted != nil && b.Specification != "" && b.Specification != b.Encrypted.specification:
+		return nil, fmt.Errorf("behavior '%s': %w", b.ID, ErrStaleEncryption)
+	case b.Encrypted != nil:
+		b.Specification = ""
+	case b.Sensitive && b.Specification != "":
+		return nil, fmt.Errorf("behavior '%s': %w", b.ID, ErrUnencryptedSpecification)
+	}
+	return json.Marshal(plain(b))
+}
+
+// EncryptSpecifications encrypts the specifications of the behaviors of sm
+// marked Sensitive with fresh data keys wrapped under keyID. The plaintext
+// stays in memory, so validation and execution are unaffected, but is left
+// out of the JSON encoding. Already encrypted behaviors are encrypted again,
+// picking up edits to their specification. It returns the number of
+// behaviors encrypted.
+func EncryptSpecifications(sm *StateMachine, kms KeyManager, keyID string) (int, error) {
+	if sm == nil || kms == nil {
+		return 0, fmt.Errorf("state machine and key manager are required")
+	}
+	count := 0
+	err := walkBehaviors(sm, func(behavior *Behavior, path string) error {
+		if !behavior.Sensitive || behavior.Specification == "" {
+			return nil
+		}
+		encrypted, err := encryptSpecification(kms, keyID, behavior.ID, behavior.Specification)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", path, err)
+		}
+		behavior.Encrypted = encrypted
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// DecryptSpecifications restores the plaintext specifications of the
+// encrypted behaviors of sm, for example after decoding a stored model. The
+// encrypted form is kept so the model can be stored again; a behavior whose
+// specification is edited afterwards must be encrypted again before it is
+// stored. It returns the number of behaviors decrypted.
+func DecryptSpecifications(sm *StateMachine, kms KeyManager) (int, error) {
+	if sm == nil || kms == nil {
+		return 0, fmt.Errorf("state machine and key manager are required")
+	}
+	count := 0
+	err := walkBehaviors(sm, func(behavior *Behavior, path string) error {
+		if behavior.Encrypted == nil {
+			return nil
+		}
+		specification, err := decryptSpecification(kms, behavior.ID, behavior.Encrypted)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		decrypted := *behavior.Encrypted
+		decrypted.specification = specification
+		behavior.Specification, behavior.Encrypted = specification, &decrypted
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// RotateSpecificationKeys re-wraps the data keys of the encrypted behaviors
+// of sm under the key encryption key newKeyID. Ciphertexts are unchanged and
+// no plaintext is needed, so rotation works on models that were never
+// decrypted. It returns the number of behaviors whose key was rotated.
+func RotateSpecificationKeys(sm *StateMachine, kms KeyManager, newKeyID string) (int, error) {
+	if sm == nil || kms == nil {
+		return 0, fmt.Errorf("state machine and key manager are required")
+	}
+	count := 0
+	err := walkBehaviors(sm, func(behavior *Behavior, path string) error {
+		encrypted := behavior.Encrypted
+		if encrypted == nil || encrypted.KeyID == newKeyID {
+			return nil
+		}
+		dataKey, err := unwrapDataKey(kms, encrypted)
+		if err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", path, err)
+		}
+		wrapped, err := kms.WrapKey(newKeyID, dataKey)
+		if err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", path, err)
+		}
+		rotated := *encrypted
+		rotated.KeyID, rotated.WrappedKey = newKeyID, base64.StdEncoding.EncodeToString(wrapped)
+		behavior.Encrypted = &rotated
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// encryptSpecification encrypts the specification of behavior behaviorID
+// under a fresh data key, authenticating the behavior ID with it
+func encryptSpecification(kms KeyManager, keyID, behaviorID, specification string) (*EncryptedSpecification, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	wrapped, err := kms.WrapKey(keyID, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedSpecification{
+		Algorithm:  SpecificationAlgorithmAES256GCM,
+		KeyID:      keyID,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, []byte(specification), []byte(behaviorID))),
+
+		specification: specification,
+	}, nil
+}
+
+// decryptSpecification decrypts the encrypted specification of behavior
+// behaviorID
+func decryptSpecification(kms KeyManager, behaviorID string, encrypted *EncryptedSpecification) (string, error) {
+	if encrypted.Algorithm != SpecificationAlgorithmAES256GCM {
+		return "", fmt.Errorf("unsupported algorithm '%s'", encrypted.Algorithm)
+	}
+	dataKey, err := unwrapDataKey(kms, encrypted)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	nonce, nonceErr := base64.StdEncoding.DecodeString(encrypted.Nonce)
+	ciphertext, ciphertextErr := base64.StdEncoding.DecodeString(encrypted.Ciphertext)
+	if nonceErr != nil || ciphertextErr != nil || len(nonce) != gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted specification")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(behaviorID))
+	if err != nil {
+		return "", fmt.Errorf("encrypted specification was modified, belongs to another behavior or the data key is wrong")
+	}
+	return string(plaintext), nil
+}
+
+// unwrapDataKey returns the data key of an encrypted specification
+func unwrapDataKey(kms KeyManager, encrypted *EncryptedSpecification) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(encrypted.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("malformed wrapped data key")
+	}
+	return kms.UnwrapKey(encrypted.KeyID, wrapped)
+}
+
+// newGCM returns AES-GCM for a 256-bit key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("data key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LocalKeyManager is a KeyManager holding 32-byte AES key encryption keys by
+// ID in memory, for development, tests and deployments without a key
+// management service
+type LocalKeyManager map[string][]byte
+
+// WrapKey encrypts a data key with AES-GCM under the key keyID
+func (m LocalKeyManager) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	gcm, err := m.gcm(keyID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, dataKey, []byte(keyID)), nil
+}
+
+// UnwrapKey decrypts a data key wrapped by WrapKey under keyID
+func (m LocalKeyManager) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	gcm, err := m.gcm(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed wrapped data key")
+	}
+	dataKey, err := gcm.Open(nil, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("data key was not wrapped under key '%s'", keyID)
+	}
+	return dataKey, nil
+}
+
+// gcm returns AES-GCM for the key encryption key keyID
+func (m LocalKeyManager) gcm(keyID string) (cipher.AEAD, error) {
+	key, ok := m[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key '%s'", keyID)
+	}
+	return newGCM(key)
+}
+
+// walkBehaviors calls fn with every behavior of sm and its path, stopping at
+// the first error
+func walkBehaviors(sm *StateMachine, fn func(behavior *Behavior, path string) error) error {
+	var err error
+	visit := func(behavior *Behavior, path string) {
+		if behavior != nil && err == nil {
+			err = fn(behavior, path)
+		}
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, regionPath string) {
+		for i, state := range region.States {
+			if state == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s.States[%d]", regionPath, i)
+			visit(state.Entry, path+".Entry")
+			visit(state.Exit, path+".Exit")
+			visit(state.DoActivity, path+".DoActivity")
+		}
+		for i, transition := range region.Transitions {
+			if transition != nil {
+				visit(transition.Effect, fmt.Sprintf("%s.Transitions[%d].Effect", regionPath, i))
+			}
+		}
+	})
+	return err
+}
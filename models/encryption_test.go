@@ -0,0 +1,209 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newSensitiveMachine returns the valid test machine with a sensitive entry
+// behavior holding a credential
+func newSensitiveMachine() (*StateMachine, *Behavior) {
+	sm := createValidStateMachine()
+	entry := sm.Regions[0].States[0].Entry
+	entry.Specification, entry.Sensitive = "connect('svc:hunter2')", true
+	return sm, entry
+}
+
+func newTestKeyManager() LocalKeyManager {
+	return LocalKeyManager{"k1": bytes.Repeat([]byte{1}, 32), "k2": bytes.Repeat([]byte{2}, 32)}
+}
+
+func TestBehavior_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior Behavior
+		want     string // Substring of the encoding
+		wantErr  error
+	}{
+		{name: "plain behavior", behavior: Behavior{ID: "b", Specification: "run()"}, want: `"specification":"run()"`},
+		{name: "sensitive behavior without encryption", behavior: Behavior{ID: "b", Specification: "run()", Sensitive: true}, wantErr: ErrUnencryptedSpecification},
+		{
+			name:     "encrypted behavior drops the plaintext",
+			behavior: Behavior{ID: "b", Specification: "run()", Sensitive: true, Encrypted: &EncryptedSpecification{Algorithm: SpecificationAlgorithmAES256GCM, KeyID: "k1", specification: "run()"}},
+			want:     `"specification":"","sensitive":true,"encrypted":{"algorithm":"AES-256-GCM","key_id":"k1"`,
+		},
+		{
+			name:     "decoded behavior without the plaintext",
+			behavior: Behavior{ID: "b", Sensitive: true, Encrypted: &EncryptedSpecification{Algorithm: SpecificationAlgorithmAES256GCM, KeyID: "k1"}},
+			want:     `"specification":"","sensitive":true`,
+		},
+		{
+			name:     "specification edited after encryption",
+			behavior: Behavior{ID: "b", Specification: "walk()", Sensitive: true, Encrypted: &EncryptedSpecification{Algorithm: SpecificationAlgorithmAES256GCM, KeyID: "k1", specification: "run()"}},
+			wantErr:  ErrStaleEncryption,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(&tt.behavior)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("json.Marshal() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !strings.Contains(string(data), tt.want) {
+				t.Errorf("json.Marshal() = %s, %v; want %s", data, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptSpecifications(t *testing.T) {
+	kms := newTestKeyManager()
+	sm, entry := newSensitiveMachine()
+
+	// Serialization is refused until the specification is encrypted
+	if _, err := json.Marshal(sm); !errors.Is(err, ErrUnencryptedSpecification) {
+		t.Fatalf("json.Marshal() error = %v, want ErrUnencryptedSpecification", err)
+	}
+	if count, err := EncryptSpecifications(sm, kms, "k1"); err != nil || count != 1 {
+		t.Fatalf("EncryptSpecifications() = %d, %v", count, err)
+	}
+	if entry.Specification != "connect('svc:hunter2')" {
+		t.Errorf("plaintext was not kept in memory: %q", entry.Specification)
+	}
+	if err := sm.Validate(); err != nil {
+		t.Errorf("encrypted machine does not validate: %v", err)
+	}
+
+	data, err := json.Marshal(sm)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) {
+		t.Fatalf("encoding contains the plaintext: %s", data)
+	}
+
+	// A decoded model validates before and after decryption
+	var decoded StateMachine
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("decoded machine does not validate: %v", err)
+	}
+	if count, err := DecryptSpecifications(&decoded, kms); err != nil || count != 1 {
+		t.Fatalf("DecryptSpecifications() = %d, %v", count, err)
+	}
+	if got := decoded.Regions[0].States[0].Entry.Specification; got != "connect('svc:hunter2')" {
+		t.Errorf("decrypted specification = %q", got)
+	}
+
+	// An edit is stored only once it is encrypted again
+	decoded.Regions[0].States[0].Entry.Specification = "connect('svc:correct-horse')"
+	if _, err := json.Marshal(&decoded); !errors.Is(err, ErrStaleEncryption) {
+		t.Fatalf("json.Marshal() after an edit error = %v, want ErrStaleEncryption", err)
+	}
+	if _, err := EncryptSpecifications(&decoded, kms, "k1"); err != nil {
+		t.Fatalf("EncryptSpecifications() error = %v", err)
+	}
+	if _, err := json.Marshal(&decoded); err != nil {
+		t.Errorf("json.Marshal() after encrypting the edit error = %v", err)
+	}
+}
+
+func TestRotateSpecificationKeys(t *testing.T) {
+	kms := newTestKeyManager()
+	sm, entry := newSensitiveMachine()
+	if _, err := EncryptSpecifications(sm, kms, "k1"); err != nil {
+		t.Fatalf("EncryptSpecifications() error = %v", err)
+	}
+	ciphertext := entry.Encrypted.Ciphertext
+
+	if count, err := RotateSpecificationKeys(sm, kms, "k2"); err != nil || count != 1 {
+		t.Fatalf("RotateSpecificationKeys() = %d, %v", count, err)
+	}
+	if entry.Encrypted.KeyID != "k2" || entry.Encrypted.Ciphertext != ciphertext {
+		t.Errorf("rotation changed %+v, want only the key", entry.Encrypted)
+	}
+	if count, _ := RotateSpecificationKeys(sm, kms, "k2"); count != 0 {
+		t.Errorf("rotating to the current key rotated %d behaviors", count)
+	}
+
+	// The old key can be retired
+	delete(kms, "k1")
+	entry.Specification = ""
+	if _, err := DecryptSpecifications(sm, kms); err != nil || entry.Specification != "connect('svc:hunter2')" {
+		t.Errorf("DecryptSpecifications() = %q, %v", entry.Specification, err)
+	}
+}
+
+func TestDecryptSpecifications_BoundToBehavior(t *testing.T) {
+	kms := newTestKeyManager()
+	sm, entry := newSensitiveMachine()
+	if _, err := EncryptSpecifications(sm, kms, "k1"); err != nil {
+		t.Fatalf("EncryptSpecifications() error = %v", err)
+	}
+
+	// A ciphertext copied to another behavior does not decrypt there
+	exit := &Behavior{ID: "exit-copy", Sensitive: true, Encrypted: entry.Encrypted}
+	sm.Regions[0].States[0].Exit = exit
+	_, err := DecryptSpecifications(sm, kms)
+	if err == nil || !strings.Contains(err.Error(), "belongs to another behavior") || !strings.Contains(err.Error(), "Regions[0].States[0].Exit") {
+		t.Errorf("DecryptSpecifications() error = %v, want the copy to be rejected", err)
+	}
+	if exit.Specification != "" {
+		t.Errorf("copied ciphertext decrypted to %q", exit.Specification)
+	}
+}
+
+func TestDecryptSpecifications_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(encrypted *EncryptedSpecification, kms LocalKeyManager)
+		want   string
+	}{
+		{
+			name: "modified ciphertext",
+			tamper: func(encrypted *EncryptedSpecification, _ LocalKeyManager) {
+				encrypted.Ciphertext = "AAAA" + encrypted.Ciphertext[4:]
+			},
+			want: "encrypted specification was modified",
+		},
+		{
+			name:   "retired key",
+			tamper: func(_ *EncryptedSpecification, kms LocalKeyManager) { delete(kms, "k1") },
+			want:   "unknown key 'k1'",
+		},
+		{
+			name:   "data key wrapped under another key",
+			tamper: func(encrypted *EncryptedSpecification, _ LocalKeyManager) { encrypted.KeyID = "k2" },
+			want:   "data key was not wrapped under key 'k2'",
+		},
+		{
+			name:   "unsupported algorithm",
+			tamper: func(encrypted *EncryptedSpecification, _ LocalKeyManager) { encrypted.Algorithm = "ROT13" },
+			want:   "unsupported algorithm 'ROT13'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kms := newTestKeyManager()
+			sm, entry := newSensitiveMachine()
+			if _, err := EncryptSpecifications(sm, kms, "k1"); err != nil {
+				t.Fatalf("EncryptSpecifications() error = %v", err)
+			}
+			tt.tamper(entry.Encrypted, kms)
+			_, err := DecryptSpecifications(sm, kms)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "Regions[0].States[0].Entry") {
+				t.Errorf("DecryptSpecifications() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResolveEndpoints replaces the Source and Target of every transition with
+// the canonical vertex declared in the model: the embedded Vertex of a state
+// or the entry in a region's Vertices collection. Vertices declared in the
+// transition's own region take precedence over vertices elsewhere in the
+// state machine. After resolution, endpoint identity can be checked with
+// pointer comparison and edits to a state are visible through its
+// transitions. It returns an error listing endpoints whose IDs are not
+// declared anywhere, with the closest declared ID when one looks like the
+// intended spelling; those endpoints are left unchanged. With
+// RecordAdjacency, or once a previous call recorded adjacency, the vertices
+// also record their incoming and outgoing transitions.
+func ResolveEndpoints(sm *StateMachine, opts ...ResolveOption) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	var options resolveOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	global := canonicalVertices(sm)
+	var unresolved []string
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		local := regionVertices(region)
+		resolve := func(endpoint *Vertex) *Vertex {
+			if endpoint == nil {
+				return nil
+			}
+			if canonical, ok := local[endpoint.ID]; ok {
+				return canonical
+			}
+			if canonical, ok := global[endpoint.ID]; ok {
+				return canonical
+			}
+			location := path
+			if candidate := suggestion(endpoint.ID, global); candidate != "" {
+				location += fmt.Sprintf(", did you mean '%s'?", candidate)
+			}
+			unresolved = append(unresolved, fmt.Sprintf("%s (%s)", endpoint.ID, location))
+			return endpoint
+		}
+
+		for _, transition := range region.Transitions {
+			if transition != nil {
+				transition.Source = resolve(transition.Source)
+				transition.Target = resolve(transition.Target)
+			}
+		}
+	})
+	if options.adjacency {
+		sm.recordAdjacency()
+	} else {
+		sm.refreshAdjacency()
+	}
+
+	if len(unresolved) > 0 {
+		return fmt.Errorf("unresolved transition endpoints: %s", strings.Join(unresolved, ", "))
+	}
+	return nil
+}
+
+// canonicalVertices indexes the declared vertices of the whole state machine
+// by ID; the first declaration wins
+func canonicalVertices(sm *StateMachine) map[string]*Vertex {
+	vertices := make(map[string]*Vertex)
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for id, vertex := range regionVertices(region) {
+			if _, exists := vertices[id]; !exists {
+				vertices[id] = vertex
+			}
+		}
+	})
+	for _, cp := range sm.ConnectionPoints {
+		if cp != nil {
+			if _, exists := vertices[cp.ID]; !exists {
+				vertices[cp.ID] = &cp.Vertex
+			}
+		}
+	}
+	return vertices
+}
+
+// regionVertices indexes the vertices declared directly in the region by ID,
+// preferring states over entries in Vertices
+func regionVertices(region *Region) map[string]*Vertex {
+	vertices := make(map[string]*Vertex)
+	for _, vertex := range region.Vertices {
+		if vertex != nil {
+			vertices[vertex.ID] = vertex
+		}
+	}
+	for _, state := range region.States {
+		if state != nil {
+			vertices[state.ID] = &state.Vertex
+		}
+	}
+	return vertices
+}
+
+// validateEndpointIdentity flags transition endpoints that carry the ID of a
+// declared vertex but disagree with it on name or type. Such endpoints are
+// stale or mistaken copies; ResolveEndpoints replaces copies with the
+// declared vertices. Vertices are looked up through the context's vertex
+// store when it has one.
+func (sm *StateMachine) validateEndpointIdentity(context *ValidationContext, errors *ValidationErrors) {
+	lookup := newVertexLookup(sm, context, errors)
+
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		local := lookup.local(region)
+		for i, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			for _, endpoint := range []struct {
+				field  string
+				vertex *Vertex
+			}{{"Source", transition.Source}, {"Target", transition.Target}} {
+				if endpoint.vertex == nil {
+					continue
+				}
+				canonical, ok := local[endpoint.vertex.ID]
+				if !ok {
+					canonical, ok = lookup.find(endpoint.vertex.ID)
+				}
+				if !ok || canonical == endpoint.vertex {
+					continue
+				}
+				if canonical.Name != endpoint.vertex.Name || canonical.Type != endpoint.vertex.Type {
+					errors.AddError(
+						ErrorTypeReference,
+						"Transition",
+						endpoint.field,
+						fmt.Sprintf("%s vertex '%s' of transition '%s' does not match the declared vertex (name %q vs %q, type %q vs %q) (structural integrity violation)",
+							strings.ToLower(endpoint.field), endpoint.vertex.ID, transition.ID, endpoint.vertex.Name, canonical.Name, endpoint.vertex.Type, canonical.Type),
+						append(append([]string{}, context.Path...), strings.Split(fmt.Sprintf("%s.Transitions[%d]", path, i), ".")...),
+					)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveEndpoints(t *testing.T) {
+	sm := createValidStateMachine()
+	region := sm.Regions[0]
+
+	if region.Transitions[1].Source == &region.States[0].Vertex {
+		t.Fatal("fixture should use endpoint copies")
+	}
+
+	if err := ResolveEndpoints(sm); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+
+	if region.Transitions[1].Source != &region.States[0].Vertex || region.Transitions[1].Target != &region.States[1].Vertex {
+		t.Error("state endpoints should resolve to the states' embedded vertices")
+	}
+	if region.Transitions[0].Source != region.Vertices[0] {
+		t.Error("pseudostate endpoints should resolve to the region's Vertices entries")
+	}
+	if err := sm.Validate(); err != nil {
+		t.Errorf("resolved model should validate: %v", err)
+	}
+
+	t.Run("unresolved endpoint", func(t *testing.T) {
+		sm := createValidStateMachine()
+		sm.Regions[0].Transitions[2].Target = &Vertex{ID: "ghost", Name: "Ghost", Type: "state"}
+
+		err := ResolveEndpoints(sm)
+		if err == nil || !strings.Contains(err.Error(), "ghost (Regions[0])") {
+			t.Errorf("ResolveEndpoints() error = %v", err)
+		}
+	})
+
+	t.Run("misspelled endpoint", func(t *testing.T) {
+		sm := createValidStateMachine()
+		sm.Regions[0].Transitions[1].Target = &Vertex{ID: "stat2", Name: "State2", Type: "state"}
+
+		err := ResolveEndpoints(sm)
+		if err == nil || !strings.Contains(err.Error(), "stat2 (Regions[0], did you mean 'state2'?)") {
+			t.Errorf("ResolveEndpoints() error = %v", err)
+		}
+	})
+}
+
+func TestStateMachine_ValidateEndpointIdentity(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(sm *StateMachine)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:    "matching copies are accepted",
+			modify:  func(sm *StateMachine) {},
+			wantErr: false,
+		},
+		{
+			name: "copy with stale name",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Source = &Vertex{ID: "state1", Name: "Old Name", Type: "state"}
+			},
+			wantErr:     true,
+			errContains: `source vertex 'state1' of transition 't2' does not match the declared vertex (name "Old Name" vs "State1", type "state" vs "state")`,
+		},
+		{
+			name: "copy with wrong type",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Target = &Vertex{ID: "final1", Name: "Final", Type: "state"}
+			},
+			wantErr:     true,
+			errContains: `target vertex 'final1' of transition 't3' does not match the declared vertex`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			tt.modify(sm)
+
+			errors := &ValidationErrors{}
+			sm.validateEndpointIdentity(NewValidationContext(), errors)
+			err := errors.ToError()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateEndpointIdentity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("validateEndpointIdentity() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+}
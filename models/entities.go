@@ -0,0 +1,179 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrEntityNotFound is returned by ResolveEntity for names that are not in
+// the state machine's entities
+var ErrEntityNotFound = errors.New("entity not found")
+
+// EntityResolver gives access to the store behind the paths of a state
+// machine's entities, such as a cache, a file system or an object store
+type EntityResolver interface {
+	// Exists reports whether path exists in the store
+	Exists(path string) (bool, error)
+
+	// Load returns the payload stored at path
+	Load(path string) ([]byte, error)
+}
+
+// FSEntityResolver resolves entity paths in a file system, e.g. os.DirFS
+// or an embed.FS
+type FSEntityResolver struct {
+	FS fs.FS
+}
+
+// Exists reports whether path names a file in the file system
+func (r FSEntityResolver) Exists(path string) (bool, error) {
+	info, err := fs.Stat(r.FS, path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// Load reads the file at path
+func (r FSEntityResolver) Load(path string) ([]byte, error) {
+	return fs.ReadFile(r.FS, path)
+}
+
+// AddEntity maps an entity name to the path of its payload. It returns an
+// error if the name or path is empty or the name is mapped to another path.
+func (sm *StateMachine) AddEntity(name, path string) error {
+	if name == "" {
+		return fmt.Errorf("entity name cannot be empty")
+	}
+	if path == "" {
+		return fmt.Errorf("entity '%s' must have a path", name)
+	}
+	if existing, exists := sm.Entities[name]; exists && existing != path {
+		return fmt.Errorf("entity '%s' is already mapped to '%s'", name, existing)
+	}
+	if sm.Entities == nil {
+		sm.Entities = make(map[string]string)
+	}
+	sm.Entities[name] = path
+	return nil
+}
+
+// RemoveEntity removes an entity and reports whether it existed. Uses of the
+// entity are left in place and reported by validation.
+func (sm *StateMachine) RemoveEntity(name string) bool {
+	if _, exists := sm.Entities[name]; !exists {
+		return false
+	}
+	delete(sm.Entities, name)
+	return true
+}
+
+// EntityNames returns the names of the state machine's entities in sorted
+// order
+func (sm *StateMachine) EntityNames() []string {
+	return slices.Sorted(maps.Keys(sm.Entities))
+}
+
+// ResolveEntity loads the payload of an entity through resolver. It returns
+// an error wrapping ErrEntityNotFound for unknown names.
+func (sm *StateMachine) ResolveEntity(name string, resolver EntityResolver) ([]byte, error) {
+	path, exists := sm.Entities[name]
+	if !exists {
+		return nil, fmt.Errorf("entity '%s': %w", name, ErrEntityNotFound)
+	}
+	if resolver == nil {
+		return nil, fmt.Errorf("entity '%s': no entity resolver", name)
+	}
+	payload, err := resolver.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("entity '%s' at '%s': %w", name, path, err)
+	}
+	return payload, nil
+}
+
+// validateEntities checks the entities of the state machine and their uses:
+// entities need a name and a path, the entities transitions and behaviors
+// use must exist, and, if the profile sets an entity resolver, entity paths
+// must exist in its store. Submachines resolve entities in their own map.
+func (sm *StateMachine) validateEntities(context *ValidationContext, errors *ValidationErrors) {
+	entitiesPath := context.WithPath("Entities").Path
+	names := sm.EntityNames()
+	for _, name := range names {
+		if name == "" {
+			errors.AddError(ErrorTypeRequired, "StateMachine", "Entities", "entity name cannot be empty", entitiesPath)
+		} else if sm.Entities[name] == "" {
+			errors.AddError(ErrorTypeRequired, "StateMachine", "Entities", fmt.Sprintf("entity '%s' must have a path", name), entitiesPath)
+		}
+	}
+
+	subPath := func(path string) []string {
+		return append(append([]string{}, context.Path...), strings.Split(path, ".")...)
+	}
+	checkUses := func(object, id string, entities []string, path []string) {
+		for _, name := range entities {
+			if _, exists := sm.Entities[name]; !exists {
+				errors.AddErrorWithContext(
+					ErrorTypeReference,
+					object,
+					"Entities",
+					fmt.Sprintf("%s '%s' uses entity '%s', which is not in the state machine's entities", strings.ToLower(object), id, name),
+					path,
+					map[string]interface{}{"entity": name},
+				)
+			}
+		}
+	}
+	checkBehavior := func(behavior *Behavior, path string) {
+		if behavior != nil {
+			checkUses("Behavior", behavior.ID, behavior.Entities, subPath(path))
+		}
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, state := range region.States {
+			if state == nil {
+				continue
+			}
+			statePath := fmt.Sprintf("%s.States[%d]", path, i)
+			checkBehavior(state.Entry, statePath+".Entry")
+			checkBehavior(state.Exit, statePath+".Exit")
+			checkBehavior(state.DoActivity, statePath+".DoActivity")
+		}
+		for i, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			transitionPath := fmt.Sprintf("%s.Transitions[%d]", path, i)
+			checkUses("Transition", transition.ID, transition.Entities, subPath(transitionPath))
+			checkBehavior(transition.Effect, transitionPath+".Effect")
+		}
+	})
+
+	resolver := context.ActiveProfile().Entities
+	if resolver == nil {
+		return
+	}
+	for _, name := range names {
+		path := sm.Entities[name]
+		if name == "" || path == "" {
+			continue
+		}
+		exists, err := resolver.Exists(path)
+		switch {
+		case err != nil:
+			errors.AddErrorWithContext(ErrorTypeReference, "StateMachine", "Entities",
+				fmt.Sprintf("failed to check the path '%s' of entity '%s': %v", path, name, err), entitiesPath,
+				map[string]interface{}{"entity": name, "path": path})
+		case !exists:
+			errors.AddErrorWithContext(ErrorTypeReference, "StateMachine", "Entities",
+				fmt.Sprintf("the path '%s' of entity '%s' does not exist", path, name), entitiesPath,
+				map[string]interface{}{"entity": name, "path": path})
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStateMachineEntities(t *testing.T) {
+	sm := &StateMachine{ID: "sm"}
+
+	tests := []struct {
+		name    string
+		entity  string
+		path    string
+		wantErr string
+	}{
+		{name: "add", entity: "order", path: "orders/42.json"},
+		{name: "add again with the same path", entity: "order", path: "orders/42.json"},
+		{name: "add another", entity: "customer", path: "customers/7.json"},
+		{name: "remap", entity: "order", path: "orders/43.json", wantErr: "entity 'order' is already mapped to 'orders/42.json'"},
+		{name: "empty name", entity: "", path: "x", wantErr: "entity name cannot be empty"},
+		{name: "empty path", entity: "cart", path: "", wantErr: "entity 'cart' must have a path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sm.AddEntity(tt.entity, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("AddEntity() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("AddEntity() error = %v", err)
+			}
+		})
+	}
+
+	if names := sm.EntityNames(); !reflect.DeepEqual(names, []string{"customer", "order"}) {
+		t.Errorf("EntityNames() = %v", names)
+	}
+	if !sm.RemoveEntity("customer") || sm.RemoveEntity("customer") {
+		t.Error("RemoveEntity() should report whether the entity existed")
+	}
+}
+
+func TestResolveEntity(t *testing.T) {
+	sm := &StateMachine{ID: "sm", Entities: map[string]string{
+		"order": "orders/42.json",
+		"stale": "orders/41.json",
+	}}
+	resolver := FSEntityResolver{FS: fstest.MapFS{
+		"orders/42.json": {Data: []byte(`{"id":42}`)},
+	}}
+
+	payload, err := sm.ResolveEntity("order", resolver)
+	if err != nil || string(payload) != `{"id":42}` {
+		t.Errorf("ResolveEntity(order) = %q, %v", payload, err)
+	}
+	if _, err := sm.ResolveEntity("missing", resolver); !errors.Is(err, ErrEntityNotFound) {
+		t.Errorf("ResolveEntity(missing) error = %v, want ErrEntityNotFound", err)
+	}
+	if _, err := sm.ResolveEntity("stale", resolver); err == nil || !strings.Contains(err.Error(), "orders/41.json") {
+		t.Errorf("ResolveEntity(stale) error = %v", err)
+	}
+	if _, err := sm.ResolveEntity("order", nil); err == nil {
+		t.Error("ResolveEntity() without a resolver should fail")
+	}
+
+	for path, want := range map[string]bool{"orders/42.json": true, "orders/41.json": false, "orders": false} {
+		if exists, err := resolver.Exists(path); err != nil || exists != want {
+			t.Errorf("Exists(%q) = %v, %v, want %v", path, exists, err, want)
+		}
+	}
+}
+
+// failingResolver fails every lookup
+type failingResolver struct{}
+
+func (failingResolver) Exists(string) (bool, error) { return false, errors.New("store unavailable") }
+func (failingResolver) Load(string) ([]byte, error) { return nil, errors.New("store unavailable") }
+
+func TestValidateEntities(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(sm *StateMachine)
+		resolver EntityResolver
+		want     []string
+	}{
+		{
+			name: "used entities exist",
+			setup: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[0].Entities = []string{"order"}
+				sm.Regions[0].States[0].Entry = &Behavior{ID: "load", Specification: "load()", Entities: []string{"order"}}
+			},
+		},
+		{
+			name: "unknown entities",
+			setup: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[0].Entities = []string{"order", "invoice"}
+				sm.Regions[0].Transitions[0].Effect = &Behavior{ID: "bill", Specification: "bill()", Entities: []string{"customer"}}
+				sm.Regions[0].States[0].DoActivity = &Behavior{ID: "poll", Specification: "poll()", Entities: []string{"queue"}}
+			},
+			want: []string{
+				"behavior 'poll' uses entity 'queue'",
+				"transition 't1' uses entity 'invoice'",
+				"behavior 'bill' uses entity 'customer'",
+			},
+		},
+		{
+			name: "empty path",
+			setup: func(sm *StateMachine) {
+				sm.Entities["cart"] = ""
+			},
+			want: []string{"entity 'cart' must have a path"},
+		},
+		{
+			name:     "resolver finds every path",
+			resolver: FSEntityResolver{FS: fstest.MapFS{"orders/42.json": {}}},
+		},
+		{
+			name: "resolver misses a path",
+			setup: func(sm *StateMachine) {
+				sm.Entities["stale"] = "orders/41.json"
+			},
+			resolver: FSEntityResolver{FS: fstest.MapFS{"orders/42.json": {}}},
+			want:     []string{"the path 'orders/41.json' of entity 'stale' does not exist"},
+		},
+		{
+			name:     "resolver fails",
+			resolver: failingResolver{},
+			want:     []string{"failed to check the path 'orders/42.json' of entity 'order': store unavailable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			sm.Entities = map[string]string{"order": "orders/42.json"}
+			if tt.setup != nil {
+				tt.setup(sm)
+			}
+			context := NewValidationContext()
+			if tt.resolver != nil {
+				profile := *DefaultProfile
+				profile.Entities = tt.resolver
+				context = context.WithProfile(&profile)
+			}
+
+			errs := &ValidationErrors{}
+			sm.ValidateWithErrors(context, errs)
+			var got []string
+			for _, err := range errs.Errors {
+				if err.Field == "Entities" {
+					got = append(got, err.Message)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("entity errors = %q, want %d matching %q", got, len(tt.want), tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("entity error %d = %q, want %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultEntityPlaceholder matches entity placeholders such as
+// "@entity(order)" in behavior and constraint specifications
+var DefaultEntityPlaceholder = regexp.MustCompile(`@entity\(\s*([^()\s]+)\s*\)`)
+
+// EntityReference is an entity placeholder found in the specification of a
+// behavior or constraint
+type EntityReference struct {
+	Entity    string `json:"entity"`     // Name of the referenced entity
+	OwnerType string `json:"owner_type"` // "Behavior" or "Constraint"
+	OwnerID   string `json:"owner_id"`   // ID of the behavior or constraint
+	Path      string `json:"path"`       // Path of the behavior or constraint within the state machine
+	Resolved  bool   `json:"resolved"`   // Whether the entity is in the state machine's entities
+}
+
+// String returns a concise one-line description of the EntityReference
+func (r EntityReference) String() string {
+	status := "resolved"
+	if !r.Resolved {
+		status = "unresolved"
+	}
+	return fmt.Sprintf("%s %s references entity %s at %s (%s)", r.OwnerType, r.OwnerID, r.Entity, r.Path, status)
+}
+
+// FindEntityReferences scans the specifications of the state machine's
+// behaviors and guards for entity placeholders and reports each one with
+// whether it resolves in sm.Entities. The first capture group of pattern
+// names the entity, or the whole match if the pattern has no group; a nil
+// pattern uses DefaultEntityPlaceholder. References are returned in model
+// traversal order. Submachines are not scanned, since they resolve entities
+// in their own map.
+func FindEntityReferences(sm *StateMachine, pattern *regexp.Regexp) []EntityReference {
+	if sm == nil {
+		return nil
+	}
+	if pattern == nil {
+		pattern = DefaultEntityPlaceholder
+	}
+
+	var references []EntityReference
+	scan := func(ownerType, ownerID, specification, path string) {
+		for _, match := range pattern.FindAllStringSubmatch(specification, -1) {
+			name := match[0]
+			if len(match) > 1 {
+				name = match[1]
+			}
+			_, resolved := sm.Entities[name]
+			references = append(references, EntityReference{Entity: name, OwnerType: ownerType, OwnerID: ownerID, Path: path, Resolved: resolved})
+		}
+	}
+	scanBehavior := func(behavior *Behavior, path string) {
+		if behavior != nil {
+			scan("Behavior", behavior.ID, behavior.Specification, path)
+		}
+	}
+
+	walkRegionTree(sm.Regions, "", func(region *Region, regionPath string) {
+		for i, state := range region.States {
+			if state == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s.States[%d]", regionPath, i)
+			scanBehavior(state.Entry, path+".Entry")
+			scanBehavior(state.Exit, path+".Exit")
+			scanBehavior(state.DoActivity, path+".DoActivity")
+		}
+		for i, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s.Transitions[%d]", regionPath, i)
+			if transition.Guard != nil {
+				scan("Constraint", transition.Guard.ID, transition.Guard.Specification, path+".Guard")
+			}
+			scanBehavior(transition.Effect, path+".Effect")
+		}
+	})
+	return references
+}
+
+// UnresolvedEntityReferences returns the entity placeholders of the state
+// machine that do not resolve in sm.Entities
+func UnresolvedEntityReferences(sm *StateMachine, pattern *regexp.Regexp) []EntityReference {
+	var unresolved []EntityReference
+	for _, reference := range FindEntityReferences(sm, pattern) {
+		if !reference.Resolved {
+			unresolved = append(unresolved, reference)
+		}
+	}
+	return unresolved
+}
+
+// validateEntityReferences reports entity placeholders in specifications
+// that do not resolve in the state machine's entities, if the context's
+// profile sets an entity placeholder pattern
+func (sm *StateMachine) validateEntityReferences(context *ValidationContext, errors *ValidationErrors) {
+	pattern := context.ActiveProfile().EntityPlaceholders
+	if pattern == nil {
+		return
+	}
+	for _, reference := range UnresolvedEntityReferences(sm, pattern) {
+		errors.AddErrorWithContext(
+			ErrorTypeReference,
+			reference.OwnerType,
+			"Specification",
+			fmt.Sprintf("%s '%s' references entity '%s', which is not in the state machine's entities", strings.ToLower(reference.OwnerType), reference.OwnerID, reference.Entity),
+			append(append([]string{}, context.Path...), strings.Split(reference.Path, ".")...),
+			map[string]interface{}{"entity": reference.Entity},
+		)
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFindEntityReferences(t *testing.T) {
+	newMachine := func() *StateMachine {
+		sm := createValidStateMachine()
+		sm.Entities = map[string]string{"order": "orders/42.json"}
+		sm.Regions[0].States[0].Entry = &Behavior{ID: "load", Specification: "load(@entity(order), @entity( cart ))"}
+		sm.Regions[0].Transitions[1].Guard = &Constraint{ID: "paid", Specification: "{{order}}.paid && {{invoice}}.sent"}
+		return sm
+	}
+
+	tests := []struct {
+		name    string
+		pattern *regexp.Regexp
+		want    []EntityReference
+	}{
+		{
+			name: "default pattern",
+			want: []EntityReference{
+				{Entity: "order", OwnerType: "Behavior", OwnerID: "load", Path: "Regions[0].States[0].Entry", Resolved: true},
+				{Entity: "cart", OwnerType: "Behavior", OwnerID: "load", Path: "Regions[0].States[0].Entry"},
+			},
+		},
+		{
+			name:    "custom pattern",
+			pattern: regexp.MustCompile(`\{\{(\w+)\}\}`),
+			want: []EntityReference{
+				{Entity: "order", OwnerType: "Constraint", OwnerID: "paid", Path: "Regions[0].Transitions[1].Guard", Resolved: true},
+				{Entity: "invoice", OwnerType: "Constraint", OwnerID: "paid", Path: "Regions[0].Transitions[1].Guard"},
+			},
+		},
+		{
+			name:    "pattern without a group",
+			pattern: regexp.MustCompile(`\border\b`),
+			want: []EntityReference{
+				{Entity: "order", OwnerType: "Behavior", OwnerID: "load", Path: "Regions[0].States[0].Entry", Resolved: true},
+				{Entity: "order", OwnerType: "Constraint", OwnerID: "paid", Path: "Regions[0].Transitions[1].Guard", Resolved: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindEntityReferences(newMachine(), tt.pattern); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindEntityReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	unresolved := UnresolvedEntityReferences(newMachine(), nil)
+	if len(unresolved) != 1 || unresolved[0].Entity != "cart" {
+		t.Errorf("UnresolvedEntityReferences() = %v", unresolved)
+	}
+	if FindEntityReferences(nil, nil) != nil {
+		t.Error("FindEntityReferences(nil) should return nil")
+	}
+}
+
+func TestValidateEntityReferences(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Entities = map[string]string{"order": "orders/42.json"}
+	sm.Regions[0].Transitions[1].Effect = &Behavior{ID: "ship", Specification: "ship(@entity(order), @entity(address))"}
+
+	if err := sm.Validate(); err != nil {
+		t.Fatalf("placeholders should not be checked without a pattern: %v", err)
+	}
+
+	profile := *DefaultProfile
+	profile.EntityPlaceholders = DefaultEntityPlaceholder
+	errs := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext().WithProfile(&profile), errs)
+	if len(errs.Errors) != 1 {
+		t.Fatalf("errors = %v, want one unresolved placeholder", errs.Errors)
+	}
+	err := errs.Errors[0]
+	if err.Type != ErrorTypeReference || err.Object != "Behavior" || !strings.Contains(err.Message, "'address'") {
+		t.Errorf("error = %v", err)
+	}
+	if got := strings.Join(err.Path, "."); got != "Regions[0].Transitions[1].Effect" {
+		t.Errorf("error path = %s", got)
+	}
+}
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EnumRegistry holds the set of valid values for a string-based enum type.
+// It provides membership checks, case-insensitive parsing, JSON helpers and
+// error messages that list the valid values, so every enum in the model is
+// validated the same way.
+type EnumRegistry[T ~string] struct {
+	mu      sync.RWMutex
+	name    string
+	values  []T
+	index   map[string]T // normalized value -> canonical value
+	aliases map[string]T // normalized alias -> canonical value
+}
+
+// NewEnumRegistry creates a registry for the named enum type with the given canonical values
+func NewEnumRegistry[T ~string](name string, values ...T) *EnumRegistry[T] {
+	r := &EnumRegistry[T]{
+		name:    name,
+		index:   make(map[string]T),
+		aliases: make(map[string]T),
+	}
+	for _, value := range values {
+		r.add(value)
+	}
+	return r
+}
+
+// add registers a canonical value; callers must hold the write lock or own the registry
+func (r *EnumRegistry[T]) add(value T) {
+	key := normalizeEnumKey(string(value))
+	if _, exists := r.index[key]; exists {
+		return
+	}
+	r.values = append(r.values, value)
+	r.index[key] = value
+}
+
+// Register adds a new canonical value to the registry at runtime. It fails if
+// the value is empty or collides with an existing value or alias after
+// normalization.
+func (r *EnumRegistry[T]) Register(value T) error {
+	key := normalizeEnumKey(string(value))
+	if key == "" {
+		return fmt.Errorf("cannot register empty %s", r.name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.index[key]; exists {
+		return fmt.Errorf("%s %s is already registered as %s", r.name, value, existing)
+	}
+	if existing, exists := r.aliases[key]; exists {
+		return fmt.Errorf("%s %s conflicts with an alias of %s", r.name, value, existing)
+	}
+	r.add(value)
+	return nil
+}
+
+// Name returns the name of the enum type
+func (r *EnumRegistry[T]) Name() string {
+	return r.name
+}
+
+// Values returns a copy of the canonical values in registration order
+func (r *EnumRegistry[T]) Values() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make([]T, len(r.values))
+	copy(values, r.values)
+	return values
+}
+
+// IsValid reports whether value exactly matches a canonical value
+func (r *EnumRegistry[T]) IsValid(value T) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	canonical, exists := r.index[normalizeEnumKey(string(value))]
+	return exists && canonical == value
+}
+
+// WithAliases registers alternative spellings that Parse maps to canonical
+// values, and returns the registry for chaining. Aliases never make a value
+// valid for IsValid; they only help normalize externally authored input.
+func (r *EnumRegistry[T]) WithAliases(aliases map[string]T) *EnumRegistry[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for alias, value := range aliases {
+		r.aliases[normalizeEnumKey(alias)] = value
+	}
+	return r
+}
+
+// Parse returns the canonical value matching s. Matching ignores case and
+// the separators '-', '_' and ' ', and falls back to registered aliases.
+func (r *EnumRegistry[T]) Parse(s string) (T, error) {
+	key := normalizeEnumKey(s)
+
+	r.mu.RLock()
+	canonical, exists := r.index[key]
+	if !exists {
+		canonical, exists = r.aliases[key]
+	}
+	r.mu.RUnlock()
+
+	if !exists {
+		var zero T
+		return zero, fmt.Errorf("%s", r.InvalidValueMessage(T(s)))
+	}
+	return canonical, nil
+}
+
+// ValidValuesString returns the valid values formatted for error messages
+func (r *EnumRegistry[T]) ValidValuesString() string {
+	values := r.Values()
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+	return formatStringSlice(names)
+}
+
+// InvalidValueMessage returns the standard error message for an invalid value
+func (r *EnumRegistry[T]) InvalidValueMessage(value T) string {
+	return fmt.Sprintf("invalid %s: %s (valid values: %s)", r.name, value, r.ValidValuesString())
+}
+
+// MarshalJSONValue marshals value as a JSON string, rejecting invalid values
+func (r *EnumRegistry[T]) MarshalJSONValue(value T) ([]byte, error) {
+	if !r.IsValid(value) {
+		return nil, fmt.Errorf("%s", r.InvalidValueMessage(value))
+	}
+	return json.Marshal(string(value))
+}
+
+// UnmarshalJSONValue unmarshals a JSON string into its canonical value,
+// matching case-insensitively and rejecting values that are not registered
+func (r *EnumRegistry[T]) UnmarshalJSONValue(data []byte) (T, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var zero T
+		return zero, fmt.Errorf("invalid %s JSON value: %w", r.name, err)
+	}
+	return r.Parse(s)
+}
+
+// normalizeEnumKey lower-cases s and strips separators so that spellings such
+// as "shallow-history", "Shallow_History" and "shallowHistory" compare equal
+func normalizeEnumKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(s)
+}
+
+// Registries for the built-in enum types
+var (
+	// PseudostateKinds is the registry of valid PseudostateKind values
+	PseudostateKinds = NewEnumRegistry("PseudostateKind",
+		PseudostateKindInitial,
+		PseudostateKindDeepHistory,
+		PseudostateKindShallowHistory,
+		PseudostateKindJoin,
+		PseudostateKindFork,
+		PseudostateKindJunction,
+		PseudostateKindChoice,
+		PseudostateKindEntryPoint,
+		PseudostateKindExitPoint,
+		PseudostateKindTerminate,
+	).WithAliases(map[string]PseudostateKind{
+		"H":        PseudostateKindShallowHistory,
+		"H*":       PseudostateKindDeepHistory,
+		"history":  PseudostateKindShallowHistory,
+		"init":     PseudostateKindInitial,
+		"start":    PseudostateKindInitial,
+		"decision": PseudostateKindChoice,
+		"entry":    PseudostateKindEntryPoint,
+		"exit":     PseudostateKindExitPoint,
+	})
+
+	// TransitionKinds is the registry of valid TransitionKind values
+	TransitionKinds = NewEnumRegistry("TransitionKind",
+		TransitionKindInternal,
+		TransitionKindLocal,
+		TransitionKindExternal,
+	)
+
+	// EventTypes is the registry of valid EventType values
+	EventTypes = NewEnumRegistry("EventType",
+		EventTypeCall,
+		EventTypeSignal,
+		EventTypeChange,
+		EventTypeTime,
+		EventTypeAnyReceive,
+	)
+)
+
+// ParsePseudostateKind parses a pseudostate kind from externally authored
+// input, accepting case variations and common aliases such as
+// "shallow-history", "H", "H*" and "entry"
+func ParsePseudostateKind(s string) (PseudostateKind, error) {
+	return PseudostateKinds.Parse(s)
+}
+
+// ParseTransitionKind parses a transition kind from externally authored
+// input, accepting case and separator variations such as "EXTERNAL"
+func ParseTransitionKind(s string) (TransitionKind, error) {
+	return TransitionKinds.Parse(s)
+}
+
+// ParseEventType parses an event type from externally authored input,
+// accepting case and separator variations such as "any-receive"
+func ParseEventType(s string) (EventType, error) {
+	return EventTypes.Parse(s)
+}
+
+// MarshalJSON marshals the kind as a JSON string, rejecting invalid kinds
+func (pk PseudostateKind) MarshalJSON() ([]byte, error) {
+	return PseudostateKinds.MarshalJSONValue(pk)
+}
+
+// UnmarshalJSON reads the kind as ParsePseudostateKind does. A kind that is
+// not registered is kept as written for validation to report.
+func (pk *PseudostateKind) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(PseudostateKinds, data, pk)
+}
+
+// MarshalJSON marshals the kind as a JSON string, rejecting invalid kinds
+func (tk TransitionKind) MarshalJSON() ([]byte, error) {
+	return TransitionKinds.MarshalJSONValue(tk)
+}
+
+// UnmarshalJSON reads the kind as ParseTransitionKind does. A kind that is
+// not registered is kept as written for validation to report.
+func (tk *TransitionKind) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(TransitionKinds, data, tk)
+}
+
+// MarshalJSON marshals the event type as a JSON string, rejecting invalid types
+func (et EventType) MarshalJSON() ([]byte, error) {
+	return EventTypes.MarshalJSONValue(et)
+}
+
+// UnmarshalJSON reads the event type as ParseEventType does. A type that is
+// not registered is kept as written for validation to report.
+func (et *EventType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(EventTypes, data, et)
+}
+
+// unmarshalEnumJSON stores the canonical value of the JSON string in data
+// into value, or the string itself if r does not recognize it
+func unmarshalEnumJSON[T ~string](r *EnumRegistry[T], data []byte, value *T) error {
+	canonical, err := r.UnmarshalJSONValue(data)
+	if err == nil {
+		*value = canonical
+		return nil
+	}
+	var s string
+	if json.Unmarshal(data, &s) != nil {
+		return err
+	}
+	*value = T(s)
+	return nil
+}
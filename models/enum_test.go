@@ -0,0 +1,237 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEnumRegistry(t *testing.T) {
+	t.Run("IsValid requires canonical spelling", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			value PseudostateKind
+			want  bool
+		}{
+			{"canonical", PseudostateKindDeepHistory, true},
+			{"different case", PseudostateKind("DEEPHISTORY"), false},
+			{"unknown", PseudostateKind("unknown"), false},
+			{"empty", PseudostateKind(""), false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := PseudostateKinds.IsValid(tt.value); got != tt.want {
+					t.Errorf("IsValid(%q) = %v, want %v", tt.value, got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("Parse is case-insensitive", func(t *testing.T) {
+		tests := []struct {
+			input   string
+			want    TransitionKind
+			wantErr bool
+		}{
+			{"external", TransitionKindExternal, false},
+			{"EXTERNAL", TransitionKindExternal, false},
+			{" Local ", TransitionKindLocal, false},
+			{"sideways", "", true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.input, func(t *testing.T) {
+				got, err := TransitionKinds.Parse(tt.input)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("Parse(%q) = %q, want %q", tt.input, got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("Values and messages", func(t *testing.T) {
+		values := EventTypes.Values()
+		if len(values) != 5 || values[0] != EventTypeCall {
+			t.Errorf("Values() = %v, want 5 values starting with call", values)
+		}
+		values[0] = "mutated"
+		if EventTypes.Values()[0] != EventTypeCall {
+			t.Error("Values() must return a copy")
+		}
+
+		msg := EventTypes.InvalidValueMessage("bogus")
+		want := "invalid EventType: bogus (valid values: [call, signal, change, time, anyReceive])"
+		if msg != want {
+			t.Errorf("InvalidValueMessage() = %q, want %q", msg, want)
+		}
+	})
+
+	t.Run("JSON helpers", func(t *testing.T) {
+		data, err := PseudostateKinds.MarshalJSONValue(PseudostateKindChoice)
+		if err != nil || string(data) != `"choice"` {
+			t.Errorf("MarshalJSONValue(choice) = %s, %v", data, err)
+		}
+		if _, err := PseudostateKinds.MarshalJSONValue("bogus"); err == nil {
+			t.Error("MarshalJSONValue should reject invalid values")
+		}
+
+		kind, err := PseudostateKinds.UnmarshalJSONValue([]byte(`"EntryPoint"`))
+		if err != nil || kind != PseudostateKindEntryPoint {
+			t.Errorf("UnmarshalJSONValue(EntryPoint) = %q, %v", kind, err)
+		}
+		if _, err := PseudostateKinds.UnmarshalJSONValue([]byte(`"bogus"`)); err == nil || !strings.Contains(err.Error(), "valid values") {
+			t.Errorf("UnmarshalJSONValue should reject invalid values listing valid ones, got %v", err)
+		}
+		if _, err := PseudostateKinds.UnmarshalJSONValue([]byte(`42`)); err == nil {
+			t.Error("UnmarshalJSONValue should reject non-string JSON")
+		}
+	})
+
+	t.Run("Validation errors list valid values", func(t *testing.T) {
+		ps := &Pseudostate{Vertex: Vertex{ID: "p1", Name: "Choice", Type: "pseudostate"}, Kind: "bogus"}
+		err := ps.Validate()
+		if err == nil || !strings.Contains(err.Error(), "invalid PseudostateKind: bogus (valid values: [initial, deepHistory") {
+			t.Errorf("expected error listing valid kinds, got %v", err)
+		}
+	})
+}
+
+func TestParseKinds(t *testing.T) {
+	pseudostateTests := []struct {
+		input   string
+		want    PseudostateKind
+		wantErr bool
+	}{
+		{"initial", PseudostateKindInitial, false},
+		{"INITIAL", PseudostateKindInitial, false},
+		{"shallow-history", PseudostateKindShallowHistory, false},
+		{"Deep_History", PseudostateKindDeepHistory, false},
+		{"H", PseudostateKindShallowHistory, false},
+		{"h*", PseudostateKindDeepHistory, false},
+		{"entry point", PseudostateKindEntryPoint, false},
+		{"exit", PseudostateKindExitPoint, false},
+		{"Decision", PseudostateKindChoice, false},
+		{"spiral", "", true},
+	}
+	for _, tt := range pseudostateTests {
+		t.Run("pseudostate "+tt.input, func(t *testing.T) {
+			got, err := ParsePseudostateKind(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePseudostateKind(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePseudostateKind(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	transitionTests := []struct {
+		input   string
+		want    TransitionKind
+		wantErr bool
+	}{
+		{"EXTERNAL", TransitionKindExternal, false},
+		{"Internal", TransitionKindInternal, false},
+		{"local", TransitionKindLocal, false},
+		{"compound", "", true},
+	}
+	for _, tt := range transitionTests {
+		t.Run("transition "+tt.input, func(t *testing.T) {
+			got, err := ParseTransitionKind(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTransitionKind(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTransitionKind(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	if got, err := ParseEventType("any-receive"); err != nil || got != EventTypeAnyReceive {
+		t.Errorf("ParseEventType(any-receive) = %q, %v", got, err)
+	}
+
+	if PseudostateKind("H*").IsValid() {
+		t.Error("aliases must not be accepted by IsValid")
+	}
+}
+
+func TestEnumJSON(t *testing.T) {
+	type kinds struct {
+		Pseudostate PseudostateKind `json:"pseudostate"`
+		Transition  TransitionKind  `json:"transition"`
+		Event       EventType       `json:"event"`
+	}
+
+	t.Run("marshal", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			value   kinds
+			want    string
+			wantErr bool
+		}{
+			{"canonical", kinds{PseudostateKindDeepHistory, TransitionKindLocal, EventTypeAnyReceive}, `{"pseudostate":"deepHistory","transition":"local","event":"anyReceive"}`, false},
+			{"invalid pseudostate kind", kinds{"spiral", TransitionKindLocal, EventTypeCall}, "", true},
+			{"alias transition kind", kinds{PseudostateKindFork, "EXTERNAL", EventTypeCall}, "", true},
+			{"empty event type", kinds{PseudostateKindFork, TransitionKindLocal, ""}, "", true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				data, err := json.Marshal(tt.value)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && string(data) != tt.want {
+					t.Errorf("Marshal() = %s, want %s", data, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			input   string
+			want    kinds
+			wantErr bool
+		}{
+			{"canonical", `{"pseudostate":"choice","transition":"internal","event":"signal"}`, kinds{PseudostateKindChoice, TransitionKindInternal, EventTypeSignal}, false},
+			{"case and separators", `{"pseudostate":"Shallow-History","transition":"EXTERNAL","event":"any_receive"}`, kinds{PseudostateKindShallowHistory, TransitionKindExternal, EventTypeAnyReceive}, false},
+			{"lowercase aliases", `{"pseudostate":"h*","transition":"local","event":"call"}`, kinds{PseudostateKindDeepHistory, TransitionKindLocal, EventTypeCall}, false},
+			{"unknown values are kept", `{"pseudostate":"spiral","transition":"sideways","event":""}`, kinds{"spiral", "sideways", ""}, false},
+			{"non-string value", `{"pseudostate":42}`, kinds{}, true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var got kinds
+				err := json.Unmarshal([]byte(tt.input), &got)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && got != tt.want {
+					t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+// remove unregisters a canonical value; it exists so that tests can undo Register
+func (r *EnumRegistry[T]) remove(value T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := normalizeEnumKey(string(value))
+	if _, exists := r.index[key]; !exists {
+		return
+	}
+	delete(r.index, key)
+	for i, v := range r.values {
+		if v == value {
+			r.values = append(r.values[:i:i], r.values[i+1:]...)
+			break
+		}
+	}
+}
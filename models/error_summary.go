@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// knownErrorTypes are the known error types in report order
+var knownErrorTypes = []ValidationErrorType{
+	ErrorTypeRequired,
+	ErrorTypeInvalid,
+	ErrorTypeConstraint,
+	ErrorTypeReference,
+	ErrorTypeMultiplicity,
+	ErrorTypeResourceLimit,
+	ErrorTypePolicy,
+}
+
+// ErrorSummary counts the findings of a validation by error type. Unlike
+// GetSummary it has a field per type, so it encodes as JSON with named keys
+// in a fixed order, ready for dashboards and snapshot tests; the encoding
+// also carries each type's percentage of the errors.
+type ErrorSummary struct {
+	Total         int `json:"total"` // Errors of all types, including unknown ones
+	Required      int `json:"required"`
+	Invalid       int `json:"invalid"`
+	Constraint    int `json:"constraint"`
+	Reference     int `json:"reference"`
+	Multiplicity  int `json:"multiplicity"`
+	ResourceLimit int `json:"resource_limit"`
+	Policy        int `json:"policy"`
+	Warnings      int `json:"warnings"`
+	Infos         int `json:"infos"`
+}
+
+// ErrorPercentages are the shares of the errors of each type, in percent
+type ErrorPercentages struct {
+	Required      float64 `json:"required"`
+	Invalid       float64 `json:"invalid"`
+	Constraint    float64 `json:"constraint"`
+	Reference     float64 `json:"reference"`
+	Multiplicity  float64 `json:"multiplicity"`
+	ResourceLimit float64 `json:"resource_limit"`
+	Policy        float64 `json:"policy"`
+}
+
+// Summary returns the typed summary of the errors, warnings and infos
+func (ve *ValidationErrors) Summary() ErrorSummary {
+	var summary ErrorSummary
+	if ve == nil {
+		return summary
+	}
+	for _, err := range ve.Errors {
+		summary.Total++
+		if count := summary.count(err.Type); count != nil {
+			*count++
+		}
+	}
+	summary.Warnings = len(ve.Warnings)
+	summary.Infos = len(ve.Infos)
+	return summary
+}
+
+// Count returns the number of errors of the given type
+func (s ErrorSummary) Count(errorType ValidationErrorType) int {
+	if count := s.count(errorType); count != nil {
+		return *count
+	}
+	return 0
+}
+
+// Percentage returns the share of the errors that are of the given type, in
+// percent; zero when there are no errors
+func (s ErrorSummary) Percentage(errorType ValidationErrorType) float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Count(errorType)) * 100 / float64(s.Total)
+}
+
+// Percentages returns the share of the errors of each type
+func (s ErrorSummary) Percentages() ErrorPercentages {
+	return ErrorPercentages{
+		Required:      s.Percentage(ErrorTypeRequired),
+		Invalid:       s.Percentage(ErrorTypeInvalid),
+		Constraint:    s.Percentage(ErrorTypeConstraint),
+		Reference:     s.Percentage(ErrorTypeReference),
+		Multiplicity:  s.Percentage(ErrorTypeMultiplicity),
+		ResourceLimit: s.Percentage(ErrorTypeResourceLimit),
+		Policy:        s.Percentage(ErrorTypePolicy),
+	}
+}
+
+// String returns a one-line description of the summary listing the error
+// types present in report order, e.g.
+// "3 error(s): 2 Required (66.7%), 1 Reference (33.3%); 1 warning(s), 0 info(s)"
+func (s ErrorSummary) String() string {
+	var types []string
+	for _, errorType := range knownErrorTypes {
+		if count := s.Count(errorType); count > 0 {
+			types = append(types, fmt.Sprintf("%d %s (%.1f%%)", count, errorType, s.Percentage(errorType)))
+		}
+	}
+	description := fmt.Sprintf("%d error(s)", s.Total)
+	if len(types) > 0 {
+		description += ": " + strings.Join(types, ", ")
+	}
+	return fmt.Sprintf("%s; %d warning(s), %d info(s)", description, s.Warnings, s.Infos)
+}
+
+// MarshalJSON encodes the counts followed by the percentages
+func (s ErrorSummary) MarshalJSON() ([]byte, error) {
+	type plain ErrorSummary // Without the MarshalJSON method
+	return json.Marshal(struct {
+		plain
+		Percentages ErrorPercentages `json:"percentages"`
+	}{plain(s), s.Percentages()})
+}
+
+// count returns the field counting errors of the given type, or nil for
+// unknown types
+func (s *ErrorSummary) count(errorType ValidationErrorType) *int {
+	switch errorType {
+	case ErrorTypeRequired:
+		return &s.Required
+	case ErrorTypeInvalid:
+		return &s.Invalid
+	case ErrorTypeConstraint:
+		return &s.Constraint
+	case ErrorTypeReference:
+		return &s.Reference
+	case ErrorTypeMultiplicity:
+		return &s.Multiplicity
+	case ErrorTypeResourceLimit:
+		return &s.ResourceLimit
+	case ErrorTypePolicy:
+		return &s.Policy
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// summaryErrors returns findings with two Required errors, a Reference
+// error, an error of an unknown type and a warning
+func summaryErrors() *ValidationErrors {
+	errors := &ValidationErrors{}
+	errors.AddError(ErrorTypeRequired, "State", "ID", "ID is required", nil)
+	errors.AddError(ErrorTypeRequired, "State", "Name", "Name is required", nil)
+	errors.AddError(ErrorTypeReference, "Transition", "Target", "target not found", nil)
+	errors.AddError(ValidationErrorType(99), "Custom", "Field", "unknown type", nil)
+	errors.AddWarning(ErrorTypeConstraint, "State", "Name", "duplicate name", nil)
+	return errors
+}
+
+func TestValidationErrors_Summary(t *testing.T) {
+	summary := summaryErrors().Summary()
+
+	want := ErrorSummary{Total: 4, Required: 2, Reference: 1, Warnings: 1}
+	if summary != want {
+		t.Fatalf("Summary() = %+v, want %+v", summary, want)
+	}
+
+	tests := []struct {
+		errorType   ValidationErrorType
+		wantCount   int
+		wantPercent float64
+	}{
+		{ErrorTypeRequired, 2, 50},
+		{ErrorTypeReference, 1, 25},
+		{ErrorTypeConstraint, 0, 0},
+		{ValidationErrorType(99), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.errorType.String(), func(t *testing.T) {
+			if got := summary.Count(tt.errorType); got != tt.wantCount {
+				t.Errorf("Count() = %d, want %d", got, tt.wantCount)
+			}
+			if got := summary.Percentage(tt.errorType); got != tt.wantPercent {
+				t.Errorf("Percentage() = %v, want %v", got, tt.wantPercent)
+			}
+		})
+	}
+
+	wantString := "4 error(s): 2 Required (50.0%), 1 Reference (25.0%); 1 warning(s), 0 info(s)"
+	if got := summary.String(); got != wantString {
+		t.Errorf("String() = %q, want %q", got, wantString)
+	}
+
+	var nilErrors *ValidationErrors
+	if got := nilErrors.Summary(); got != (ErrorSummary{}) {
+		t.Errorf("nil Summary() = %+v, want zero", got)
+	}
+	if got := (ErrorSummary{}).String(); got != "0 error(s); 0 warning(s), 0 info(s)" {
+		t.Errorf("empty String() = %q", got)
+	}
+	if got := (ErrorSummary{}).Percentage(ErrorTypeRequired); got != 0 {
+		t.Errorf("empty Percentage() = %v, want 0", got)
+	}
+}
+
+func TestErrorSummary_JSON(t *testing.T) {
+	data, err := json.Marshal(summaryErrors().Summary())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"total":4,"required":2,"invalid":0,"constraint":0,"reference":1,"multiplicity":0,"resource_limit":0,"policy":0,"warnings":1,"infos":0,` +
+		`"percentages":{"required":50,"invalid":0,"constraint":0,"reference":25,"multiplicity":0,"resource_limit":0,"policy":0}}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded ErrorSummary
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded != summaryErrors().Summary() {
+		t.Errorf("round trip = %+v", decoded)
+	}
+}
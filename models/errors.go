@@ -14,6 +14,8 @@ const (
 	ErrorTypeConstraint
 	ErrorTypeReference
 	ErrorTypeMultiplicity
+	ErrorTypeResourceLimit
+	ErrorTypePolicy // Violations of organizational modeling standards; see ModelPolicy
 )
 
 // String returns the string representation of ValidationErrorType
@@ -29,19 +31,47 @@ func (vet ValidationErrorType) String() string {
 		return "Reference"
 	case ErrorTypeMultiplicity:
 		return "Multiplicity"
+	case ErrorTypeResourceLimit:
+		return "ResourceLimit"
+	case ErrorTypePolicy:
+		return "Policy"
 	default:
 		return "Unknown"
 	}
 }
 
+// Severity distinguishes errors, which make a model invalid, from warnings,
+// which point out likely mistakes without failing validation, and infos,
+// which note things worth knowing such as uses of deprecated elements
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
 // ValidationError represents a validation error with enhanced context
 type ValidationError struct {
-	Type    ValidationErrorType    `json:"type"`
-	Object  string                 `json:"object"`
-	Field   string                 `json:"field"`
-	Message string                 `json:"message"`
-	Path    []string               `json:"path"`
-	Context map[string]interface{} `json:"context,omitempty"`
+	Type     ValidationErrorType    `json:"type"`
+	Object   string                 `json:"object"`
+	Field    string                 `json:"field"`
+	Message  string                 `json:"message"`
+	Path     []string               `json:"path"`
+	Context  map[string]interface{} `json:"context,omitempty"`
+	Clause   string                 `json:"clause,omitempty"`   // UML specification clause behind a constraint error, e.g. "§14.5.6.7 Constraint initial_vertex"
+	Severity Severity               `json:"severity,omitempty"` // SeverityWarning or SeverityInfo for findings that are not errors; empty or SeverityError for errors
+	Rule     string                 `json:"rule,omitempty"`     // ID of the check that reported the finding, e.g. "transition.trigger_placement"; see Suppression
+}
+
+// IsWarning reports whether the error is a warning
+func (ve *ValidationError) IsWarning() bool {
+	return ve.Severity == SeverityWarning
+}
+
+// IsInfo reports whether the error is an informational finding
+func (ve *ValidationError) IsInfo() bool {
+	return ve.Severity == SeverityInfo
 }
 
 // Error implements the error interface
@@ -53,12 +83,21 @@ func (ve *ValidationError) Error() string {
 	return fmt.Sprintf("[%s] %s.%s: %s%s", ve.Type.String(), ve.Object, ve.Field, ve.Message, pathStr)
 }
 
-// ValidationErrors represents a collection of validation errors
+// ValidationErrors represents a collection of validation errors. Warnings
+// and infos are collected separately and never make ToError return an error.
 type ValidationErrors struct {
-	Errors []*ValidationError `json:"errors"`
+	Errors   []*ValidationError `json:"errors"`
+	Warnings []*ValidationError `json:"warnings,omitempty"`
+	Infos    []*ValidationError `json:"infos,omitempty"`
+	Skipped  []SkippedRule      `json:"skipped,omitempty"` // Rules not checked because rules they depend on failed
+
+	// Suppressed lists the findings silenced by suppressions on the elements
+	// they are about; they are not in Errors, Warnings or Infos
+	Suppressed []SuppressedFinding `json:"suppressed,omitempty"`
 }
 
-// Error implements the error interface for ValidationErrors
+// Error implements the error interface for ValidationErrors, listing
+// several errors in the canonical report order
 func (ve *ValidationErrors) Error() string {
 	if len(ve.Errors) == 0 {
 		return "no validation errors"
@@ -68,17 +107,74 @@ func (ve *ValidationErrors) Error() string {
 	}
 
 	var messages []string
-	for _, err := range ve.Errors {
+	for _, err := range sortedValidationErrors(ve.Errors) {
 		messages = append(messages, err.Error())
 	}
 	return fmt.Sprintf("multiple validation errors:\n  - %s", strings.Join(messages, "\n  - "))
 }
 
-// Add adds a validation error to the collection
+// Add adds a validation error to the collection. Warnings and infos are
+// added to Warnings and Infos instead of Errors.
 func (ve *ValidationErrors) Add(err *ValidationError) {
+	if err != nil && err.IsWarning() {
+		ve.Warnings = append(ve.Warnings, err)
+		return
+	}
+	if err != nil && err.IsInfo() {
+		ve.Infos = append(ve.Infos, err)
+		return
+	}
 	ve.Errors = append(ve.Errors, err)
 }
 
+// AddWarning adds a warning that does not make the model invalid
+func (ve *ValidationErrors) AddWarning(errorType ValidationErrorType, object, field, message string, path []string) {
+	ve.Add(&ValidationError{
+		Type:     errorType,
+		Object:   object,
+		Field:    field,
+		Message:  message,
+		Path:     path,
+		Severity: SeverityWarning,
+	})
+}
+
+// AddWarningWithClause adds a warning citing the UML specification clause
+// behind it
+func (ve *ValidationErrors) AddWarningWithClause(errorType ValidationErrorType, object, field, message string, path []string, clause string) {
+	ve.Add(&ValidationError{
+		Type:     errorType,
+		Object:   object,
+		Field:    field,
+		Message:  message,
+		Path:     path,
+		Clause:   clause,
+		Severity: SeverityWarning,
+	})
+}
+
+// HasWarnings returns true if there are any warnings
+func (ve *ValidationErrors) HasWarnings() bool {
+	return len(ve.Warnings) > 0
+}
+
+// AddInfo adds an informational finding that does not make the model invalid
+func (ve *ValidationErrors) AddInfo(errorType ValidationErrorType, object, field, message string, path []string) {
+	ve.Add(&ValidationError{
+		Type:     errorType,
+		Object:   object,
+		Field:    field,
+		Message:  message,
+		Path:     path,
+		Severity: SeverityInfo,
+	})
+}
+
+// HasInfos returns true if there are any informational findings
+func (ve *ValidationErrors) HasInfos() bool {
+	return len(ve.Infos) > 0
+}
+
 // AddError adds a simple error as a validation error
 func (ve *ValidationErrors) AddError(errorType ValidationErrorType, object, field, message string, path []string) {
 	ve.Add(&ValidationError{
@@ -90,6 +186,19 @@ func (ve *ValidationErrors) AddError(errorType ValidationErrorType, object, fiel
 	})
 }
 
+// AddErrorWithClause adds an error citing the UML specification clause
+// behind it, e.g. ClauseInitialVertex
+func (ve *ValidationErrors) AddErrorWithClause(errorType ValidationErrorType, object, field, message string, path []string, clause string) {
+	ve.Add(&ValidationError{
+		Type:    errorType,
+		Object:  object,
+		Field:   field,
+		Message: message,
+		Path:    path,
+		Clause:  clause,
+	})
+}
+
 // HasErrors returns true if there are any validation errors
 func (ve *ValidationErrors) HasErrors() bool {
 	return len(ve.Errors) > 0
@@ -151,7 +260,9 @@ func (ve *ValidationErrors) GetErrorsByPath(pathPrefix string) []*ValidationErro
 	return result
 }
 
-// GetSummary returns a summary of errors by type
+// GetSummary returns a summary of errors by type. The map encodes its keys
+// as numbers and iterates in no particular order; Summary returns a typed
+// summary suited to reports and JSON.
 func (ve *ValidationErrors) GetSummary() map[ValidationErrorType]int {
 	summary := make(map[ValidationErrorType]int)
 	for _, err := range ve.Errors {
@@ -166,12 +277,25 @@ func (ve *ValidationErrors) Merge(other *ValidationErrors) {
 		for _, err := range other.Errors {
 			ve.Add(err)
 		}
+		for _, warning := range other.Warnings {
+			ve.Add(warning)
+		}
+		for _, info := range other.Infos {
+			ve.Add(info)
+		}
+		ve.Skipped = append(ve.Skipped, other.Skipped...)
+		ve.Suppressed = append(ve.Suppressed, other.Suppressed...)
 	}
 }
 
-// Clear removes all errors
+// Clear removes all errors, warnings, infos, skipped rules and suppressed
+// findings
 func (ve *ValidationErrors) Clear() {
 	ve.Errors = ve.Errors[:0]
+	ve.Warnings = ve.Warnings[:0]
+	ve.Infos = ve.Infos[:0]
+	ve.Skipped = ve.Skipped[:0]
+	ve.Suppressed = ve.Suppressed[:0]
 }
 
 // Count returns the number of errors
@@ -184,9 +308,10 @@ func (ve *ValidationErrors) IsEmpty() bool {
 	return len(ve.Errors) == 0
 }
 
-// GetDetailedReport returns a detailed report of all errors
+// GetDetailedReport returns a detailed report of all errors, warnings and
+// infos in the canonical report order
 func (ve *ValidationErrors) GetDetailedReport() string {
-	if len(ve.Errors) == 0 {
+	if len(ve.Errors) == 0 && len(ve.Warnings) == 0 && len(ve.Infos) == 0 {
 		return "No validation errors"
 	}
 
@@ -194,29 +319,48 @@ func (ve *ValidationErrors) GetDetailedReport() string {
 	report.WriteString(fmt.Sprintf("Validation Report: %d error(s) found\n", len(ve.Errors)))
 	report.WriteString(strings.Repeat("=", 50) + "\n")
 
-	// Group errors by type
-	errorsByType := make(map[ValidationErrorType][]*ValidationError)
-	for _, err := range ve.Errors {
-		errorsByType[err.Type] = append(errorsByType[err.Type], err)
-	}
-
 	// Report errors by type
-	for errorType, errors := range errorsByType {
+	errorTypes, errorsByType := groupByType(ve.Errors)
+	for _, errorType := range errorTypes {
+		errors := errorsByType[errorType]
 		report.WriteString(fmt.Sprintf("\n%s Errors (%d):\n", errorType.String(), len(errors)))
 		report.WriteString(strings.Repeat("-", 30) + "\n")
 
 		for i, err := range errors {
 			report.WriteString(fmt.Sprintf("%d. %s\n", i+1, err.Error()))
+			if err.Clause != "" {
+				report.WriteString(fmt.Sprintf("   Clause: %s\n", err.Clause))
+			}
 			if len(err.Context) > 0 {
-				report.WriteString("   Context: ")
-				for k, v := range err.Context {
-					report.WriteString(fmt.Sprintf("%s=%v ", k, v))
-				}
-				report.WriteString("\n")
+				report.WriteString("   Context: " + formatErrorContext(err.Context) + "\n")
 			}
 		}
 	}
 
+	if len(ve.Warnings) > 0 {
+		report.WriteString(fmt.Sprintf("\nWarnings (%d):\n", len(ve.Warnings)))
+		report.WriteString(strings.Repeat("-", 30) + "\n")
+		for i, warning := range sortedValidationErrors(ve.Warnings) {
+			report.WriteString(fmt.Sprintf("%d. %s\n", i+1, warning.Error()))
+		}
+	}
+
+	if len(ve.Infos) > 0 {
+		report.WriteString(fmt.Sprintf("\nInfo (%d):\n", len(ve.Infos)))
+		report.WriteString(strings.Repeat("-", 30) + "\n")
+		for i, info := range sortedValidationErrors(ve.Infos) {
+			report.WriteString(fmt.Sprintf("%d. %s\n", i+1, info.Error()))
+		}
+	}
+
+	if len(ve.Suppressed) > 0 {
+		report.WriteString(fmt.Sprintf("\nSuppressed (%d):\n", len(ve.Suppressed)))
+		report.WriteString(strings.Repeat("-", 30) + "\n")
+		for i, suppressed := range ve.Suppressed {
+			report.WriteString(fmt.Sprintf("%d. %s\n", i+1, suppressed.String()))
+		}
+	}
+
 	return report.String()
 }
 
@@ -228,6 +372,14 @@ type ValidationContext struct {
 	Path           []string               `json:"path"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 	VisitedObjects map[uintptr]bool       `json:"-"` // Track visited objects to prevent infinite recursion
+	Profile        *ValidationProfile     `json:"-"` // Policies for profile-based rules; nil means DefaultProfile
+	VertexStore    VertexStore            `json:"-"` // Serves vertex lookups; nil indexes the model in memory
+	machineChain   []*StateMachine        // State machines being validated, outermost first
+	ruleProfile    *RuleProfile           // Collects rule timings; see WithRuleProfile
+	depth          int                    // Regions entered on the way to the element, submachines included
+	stack          *validationStack       // Nested elements waiting to be validated; see descend
+
+	transitionIndexes map[*StateMachine]*TransitionIndex // Built once per validation; see transitionsOf
 }
 
 // NewValidationContext creates a new validation context
@@ -399,6 +551,10 @@ func (vc *ValidationContext) Clone() *ValidationContext {
 		StateMachine: vc.StateMachine,
 		Region:       vc.Region,
 		Parent:       vc.Parent,
+		Profile:      vc.Profile,
+		VertexStore:  vc.VertexStore,
+		machineChain: vc.machineChain,
+		ruleProfile:  vc.ruleProfile,
 		Path:         make([]string, len(vc.Path)),
 		Metadata:     make(map[string]interface{}),
 	}
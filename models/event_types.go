@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+)
+
+// EventTypeValidator validates the type-specific parts of an event, typically
+// its Properties, and records any problems in errors
+type EventTypeValidator func(event *Event, context *ValidationContext, errors *ValidationErrors)
+
+var (
+	eventTypeValidatorsMu sync.RWMutex
+	eventTypeValidators   = make(map[EventType]EventTypeValidator)
+)
+
+// RegisterEventType registers a custom event type so that domain events such
+// as "webhook" or "cron" can be modeled directly instead of being encoded as
+// signals. The optional validator is run by Event validation for events of
+// that type. Built-in event types cannot be re-registered.
+func RegisterEventType(eventType EventType, validator EventTypeValidator) error {
+	if err := EventTypes.Register(eventType); err != nil {
+		return fmt.Errorf("failed to register event type: %w", err)
+	}
+
+	if validator != nil {
+		eventTypeValidatorsMu.Lock()
+		eventTypeValidators[eventType] = validator
+		eventTypeValidatorsMu.Unlock()
+	}
+	return nil
+}
+
+// IsBuiltinEventType reports whether eventType is one of the UML event kinds
+// defined by this package rather than a registered custom type
+func IsBuiltinEventType(eventType EventType) bool {
+	switch eventType {
+	case EventTypeCall, EventTypeSignal, EventTypeChange, EventTypeTime, EventTypeAnyReceive:
+		return true
+	default:
+		return false
+	}
+}
+
+// eventTypeValidator returns the validator registered for eventType, if any
+func eventTypeValidator(eventType EventType) EventTypeValidator {
+	eventTypeValidatorsMu.RLock()
+	defer eventTypeValidatorsMu.RUnlock()
+	return eventTypeValidators[eventType]
+}
+
+// RequireEventProperties returns a validator that reports an error for each
+// of the named properties that is missing or empty on the event
+func RequireEventProperties(names ...string) EventTypeValidator {
+	return func(event *Event, context *ValidationContext, errors *ValidationErrors) {
+		for _, name := range names {
+			if event.Properties[name] == "" {
+				errors.AddError(
+					ErrorTypeRequired,
+					"Event",
+					"Properties",
+					fmt.Sprintf("%s event requires property %q", event.Type, name),
+					context.Path,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterEventType(t *testing.T) {
+	webhook := EventType("webhook")
+	if err := RegisterEventType(webhook, RequireEventProperties("url")); err != nil {
+		t.Fatalf("RegisterEventType() error = %v", err)
+	}
+	t.Cleanup(func() { unregisterEventType(webhook) })
+
+	if !webhook.IsValid() {
+		t.Error("registered event type should be valid")
+	}
+	if IsBuiltinEventType(webhook) {
+		t.Error("registered event type should not be reported as built-in")
+	}
+	if got, err := ParseEventType("WebHook"); err != nil || got != webhook {
+		t.Errorf("ParseEventType(WebHook) = %q, %v", got, err)
+	}
+
+	tests := []struct {
+		name        string
+		event       *Event
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:    "webhook with url",
+			event:   &Event{ID: "e1", Name: "OrderPaid", Type: webhook, Properties: map[string]string{"url": "https://example.com/hook"}},
+			wantErr: false,
+		},
+		{
+			name:        "webhook without url",
+			event:       &Event{ID: "e2", Name: "OrderPaid", Type: webhook},
+			wantErr:     true,
+			errContains: `webhook event requires property "url"`,
+		},
+		{
+			name:    "built-in type unaffected",
+			event:   &Event{ID: "e3", Name: "Go", Type: EventTypeSignal},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+
+	t.Run("duplicate registration", func(t *testing.T) {
+		if err := RegisterEventType(EventTypeSignal, nil); err == nil {
+			t.Error("re-registering a built-in event type should fail")
+		}
+		if err := RegisterEventType("Web-Hook", nil); err == nil {
+			t.Error("registering a value that normalizes to an existing type should fail")
+		}
+		if err := RegisterEventType("", nil); err == nil {
+			t.Error("registering an empty event type should fail")
+		}
+	})
+}
+
+// unregisterEventType removes a custom event type and its validator
+func unregisterEventType(eventType EventType) {
+	EventTypes.remove(eventType)
+
+	eventTypeValidatorsMu.Lock()
+	delete(eventTypeValidators, eventType)
+	eventTypeValidatorsMu.Unlock()
+}
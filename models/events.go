@@ -0,0 +1,115 @@
+package models
+
+import "fmt"
+
+// FindEvent returns the catalog event with the given ID, or nil if the state
+// machine has no such event
+func (sm *StateMachine) FindEvent(id string) *Event {
+	if sm == nil || id == "" {
+		return nil
+	}
+	for _, event := range sm.Events {
+		if event != nil && event.ID == id {
+			return event
+		}
+	}
+	return nil
+}
+
+// UnusedEvents returns the catalog events that no trigger in the state
+// machine refers to, in catalog order
+func UnusedEvents(sm *StateMachine) []*Event {
+	if sm == nil {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		for _, trigger := range transition.Triggers {
+			if key := trigger.EventKey(); key != "" {
+				used[key] = true
+			}
+		}
+	})
+
+	var unused []*Event
+	for _, event := range sm.Events {
+		if event != nil && !used[event.ID] {
+			unused = append(unused, event)
+		}
+	}
+	return unused
+}
+
+// validateEventCatalog validates the catalog events and checks that catalog
+// IDs are unique and that embedded events agree with catalog entries
+func (sm *StateMachine) validateEventCatalog(context *ValidationContext, errors *ValidationErrors) {
+	if len(sm.Events) == 0 {
+		return
+	}
+
+	seen := make(map[string]int)
+	for i, event := range sm.Events {
+		eventContext := context.WithPathIndex("Events", i)
+		if event == nil {
+			errors.AddError(
+				ErrorTypeRequired,
+				"StateMachine",
+				"Events",
+				fmt.Sprintf("event at index %d cannot be nil", i),
+				eventContext.Path,
+			)
+			continue
+		}
+
+		event.ValidateWithErrors(eventContext, errors)
+
+		if event.ID == "" {
+			continue
+		}
+		if prevIndex, exists := seen[event.ID]; exists {
+			errors.AddError(
+				ErrorTypeConstraint,
+				"StateMachine",
+				"Events",
+				fmt.Sprintf("duplicate event ID '%s' found at indices %d and %d (structural integrity violation)", event.ID, prevIndex, i),
+				eventContext.Path,
+			)
+		} else {
+			seen[event.ID] = i
+		}
+	}
+
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		for _, trigger := range transition.Triggers {
+			if trigger == nil || trigger.Event == nil {
+				continue
+			}
+			catalogEvent := sm.FindEvent(trigger.Event.ID)
+			if catalogEvent == nil {
+				continue
+			}
+			if catalogEvent.Name != trigger.Event.Name || catalogEvent.Type != trigger.Event.Type {
+				errors.AddError(
+					ErrorTypeConstraint,
+					"StateMachine",
+					"Events",
+					fmt.Sprintf("trigger '%s' on transition '%s' embeds event '%s' that differs from the catalog entry; reference it by event_id instead", trigger.ID, transition.ID, trigger.Event.ID),
+					context.Path,
+				)
+			}
+		}
+	})
+}
+
+// walkTransitions calls fn for every non-nil transition in the regions and,
+// recursively, in the regions of their composite states
+func walkTransitions(regions []*Region, fn func(*Transition)) {
+	walkRegionTree(regions, "", func(region *Region, _ string) {
+		for _, transition := range region.Transitions {
+			if transition != nil {
+				fn(transition)
+			}
+		}
+	})
+}
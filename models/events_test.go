@@ -0,0 +1,94 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventCatalog(t *testing.T) {
+	newMachine := func() *StateMachine {
+		sm := createValidStateMachine()
+		sm.Events = []*Event{
+			{ID: "go", Name: "Go", Type: EventTypeSignal},
+			{ID: "tick", Name: "Tick", Type: EventTypeTime},
+		}
+		sm.Regions[0].Transitions[1].Triggers = []*Trigger{{ID: "tr_go", Name: "Go", EventID: "go"}}
+		return sm
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(sm *StateMachine)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:    "trigger resolves catalog reference",
+			modify:  func(sm *StateMachine) {},
+			wantErr: false,
+		},
+		{
+			name: "unresolved event reference",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Triggers[0].EventID = "missing"
+			},
+			wantErr:     true,
+			errContains: "event 'missing' is not defined in the state machine's event catalog",
+		},
+		{
+			name: "duplicate catalog IDs",
+			modify: func(sm *StateMachine) {
+				sm.Events = append(sm.Events, &Event{ID: "go", Name: "Go Again", Type: EventTypeSignal})
+			},
+			wantErr:     true,
+			errContains: "duplicate event ID 'go'",
+		},
+		{
+			name: "embedded event diverges from catalog",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Triggers = []*Trigger{
+					{ID: "tr_go", Name: "Go", Event: &Event{ID: "go", Name: "Go", Type: EventTypeCall}},
+				}
+			},
+			wantErr:     true,
+			errContains: "differs from the catalog entry",
+		},
+		{
+			name: "trigger without event or reference",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Triggers[0].EventID = ""
+			},
+			wantErr:     true,
+			errContains: "Trigger.Event: required reference cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newMachine()
+			tt.modify(sm)
+
+			err := sm.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+
+	t.Run("resolve and report unused", func(t *testing.T) {
+		sm := newMachine()
+		trigger := sm.Regions[0].Transitions[1].Triggers[0]
+
+		if got := trigger.ResolveEvent(sm); got == nil || got.Name != "Go" {
+			t.Errorf("ResolveEvent() = %v, want catalog event 'go'", got)
+		}
+
+		unused := UnusedEvents(sm)
+		if len(unused) != 1 || unused[0].ID != "tick" {
+			t.Errorf("UnusedEvents() = %v, want only 'tick'", unused)
+		}
+	})
+}
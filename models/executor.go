@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoExecutor is returned by a strict FuncExecutor for a behavior or guard
+// that has no registered function
+var ErrNoExecutor = errors.New("no executor registered")
+
+// ExecutionContext is passed to an ActionExecutor while an Interpreter runs
+type ExecutionContext struct {
+	Interpreter *Interpreter           // The running interpreter, e.g. for raising internal events
+	Event       *EventOccurrence       // The event being processed; nil for completion transitions and the initial configuration
+	Variables   map[string]interface{} // Extended state shared by all actions of the interpreter
+}
+
+// ActionExecutor runs the behaviors and evaluates the guards of a model while
+// an Interpreter executes it, letting models drive real side effects
+type ActionExecutor interface {
+	// ExecuteBehavior runs an entry, exit or do activity behavior or a
+	// transition effect. Returning an error aborts the current step.
+	ExecuteBehavior(behavior *Behavior, ctx *ExecutionContext) error
+
+	// EvaluateGuard decides whether a transition guard holds. Guards
+	// specified as "else" are resolved by the interpreter and never passed in.
+	EvaluateGuard(guard *Constraint, ctx *ExecutionContext) (bool, error)
+}
+
+// ActionFunc implements a behavior in Go
+type ActionFunc func(ctx *ExecutionContext) error
+
+// GuardFunc implements a guard in Go
+type GuardFunc func(ctx *ExecutionContext) (bool, error)
+
+// LanguageActionFunc implements every behavior written in one language, e.g.
+// an expression evaluator for the behavior's Specification
+type LanguageActionFunc func(behavior *Behavior, ctx *ExecutionContext) error
+
+// LanguageGuardFunc evaluates every guard written in one language
+type LanguageGuardFunc func(guard *Constraint, ctx *ExecutionContext) (bool, error)
+
+// FuncExecutor is an ActionExecutor that calls Go functions registered by
+// behavior or constraint ID, falling back to functions registered for the
+// element's Language. Unregistered behaviors do nothing and unregistered
+// guards hold, unless Strict is set, in which case they fail with
+// ErrNoExecutor.
+type FuncExecutor struct {
+	Strict bool
+
+	actions         map[string]ActionFunc
+	guards          map[string]GuardFunc
+	actionLanguages map[string]LanguageActionFunc
+	guardLanguages  map[string]LanguageGuardFunc
+}
+
+// NewFuncExecutor returns an empty FuncExecutor
+func NewFuncExecutor() *FuncExecutor {
+	return &FuncExecutor{
+		actions:         make(map[string]ActionFunc),
+		guards:          make(map[string]GuardFunc),
+		actionLanguages: make(map[string]LanguageActionFunc),
+		guardLanguages:  make(map[string]LanguageGuardFunc),
+	}
+}
+
+// RegisterAction registers fn for the behavior with the given ID
+func (e *FuncExecutor) RegisterAction(behaviorID string, fn ActionFunc) *FuncExecutor {
+	e.actions[behaviorID] = fn
+	return e
+}
+
+// RegisterGuard registers fn for the guard constraint with the given ID
+func (e *FuncExecutor) RegisterGuard(constraintID string, fn GuardFunc) *FuncExecutor {
+	e.guards[constraintID] = fn
+	return e
+}
+
+// RegisterActionLanguage registers fn for behaviors in the given language
+// that have no function registered by ID
+func (e *FuncExecutor) RegisterActionLanguage(language string, fn LanguageActionFunc) *FuncExecutor {
+	e.actionLanguages[language] = fn
+	return e
+}
+
+// RegisterGuardLanguage registers fn for guards in the given language that
+// have no function registered by ID
+func (e *FuncExecutor) RegisterGuardLanguage(language string, fn LanguageGuardFunc) *FuncExecutor {
+	e.guardLanguages[language] = fn
+	return e
+}
+
+// ExecuteBehavior calls the function registered for the behavior
+func (e *FuncExecutor) ExecuteBehavior(behavior *Behavior, ctx *ExecutionContext) error {
+	if fn, ok := e.actions[behavior.ID]; ok {
+		return fn(ctx)
+	}
+	if fn, ok := e.actionLanguages[behavior.Language]; ok {
+		return fn(behavior, ctx)
+	}
+	if e.Strict {
+		return fmt.Errorf("%w for behavior '%s' in language '%s'", ErrNoExecutor, behavior.ID, behavior.Language)
+	}
+	return nil
+}
+
+// EvaluateGuard calls the function registered for the guard
+func (e *FuncExecutor) EvaluateGuard(guard *Constraint, ctx *ExecutionContext) (bool, error) {
+	if fn, ok := e.guards[guard.ID]; ok {
+		return fn(ctx)
+	}
+	if fn, ok := e.guardLanguages[guard.Language]; ok {
+		return fn(guard, ctx)
+	}
+	if e.Strict {
+		return false, fmt.Errorf("%w for guard '%s' in language '%s'", ErrNoExecutor, guard.ID, guard.Language)
+	}
+	return true, nil
+}
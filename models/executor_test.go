@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFuncExecutor_ExecuteBehavior(t *testing.T) {
+	var calls []string
+	executor := NewFuncExecutor().
+		RegisterAction("log", func(*ExecutionContext) error {
+			calls = append(calls, "log")
+			return nil
+		}).
+		RegisterActionLanguage("go", func(behavior *Behavior, _ *ExecutionContext) error {
+			calls = append(calls, "go:"+behavior.Specification)
+			return nil
+		})
+
+	tests := []struct {
+		name     string
+		strict   bool
+		behavior *Behavior
+		want     string
+		wantErr  bool
+	}{
+		{name: "registered by ID", behavior: &Behavior{ID: "log", Language: "go"}, want: "log"},
+		{name: "registered by language", behavior: &Behavior{ID: "count", Specification: "n++", Language: "go"}, want: "go:n++"},
+		{name: "unregistered", behavior: &Behavior{ID: "other", Language: "ocl"}},
+		{name: "unregistered in strict mode", strict: true, behavior: &Behavior{ID: "other", Language: "ocl"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			executor.Strict = tt.strict
+			err := executor.ExecuteBehavior(tt.behavior, &ExecutionContext{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecuteBehavior() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNoExecutor) {
+				t.Errorf("ExecuteBehavior() error = %v, want ErrNoExecutor", err)
+			}
+			if got := strings.Join(calls, ","); got != tt.want {
+				t.Errorf("calls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncExecutor_EvaluateGuard(t *testing.T) {
+	executor := NewFuncExecutor().
+		RegisterGuard("closed", func(*ExecutionContext) (bool, error) { return false, nil }).
+		RegisterGuardLanguage("go", func(guard *Constraint, ctx *ExecutionContext) (bool, error) {
+			return ctx.Variables[guard.Specification] == true, nil
+		})
+	ctx := &ExecutionContext{Variables: map[string]interface{}{"ready": true}}
+
+	tests := []struct {
+		name    string
+		strict  bool
+		guard   *Constraint
+		want    bool
+		wantErr bool
+	}{
+		{name: "registered by ID", guard: &Constraint{ID: "closed", Language: "go"}, want: false},
+		{name: "registered by language", guard: &Constraint{ID: "g1", Specification: "ready", Language: "go"}, want: true},
+		{name: "language guard fails", guard: &Constraint{ID: "g2", Specification: "missing", Language: "go"}, want: false},
+		{name: "unregistered holds", guard: &Constraint{ID: "g3", Language: "ocl"}, want: true},
+		{name: "unregistered in strict mode", strict: true, guard: &Constraint{ID: "g3", Language: "ocl"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor.Strict = tt.strict
+			got, err := executor.EvaluateGuard(tt.guard, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateGuard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNoExecutor) {
+				t.Errorf("EvaluateGuard() error = %v, want ErrNoExecutor", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateGuard() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncExecutor_DrivesInterpreter(t *testing.T) {
+	sm := newPlayerMachine()
+	main := sm.Regions[0]
+	main.States[1].Entry = &Behavior{ID: "count-plays", Language: "go"}
+	main.Transitions[2].Guard = &Constraint{ID: "may-stop", Language: "go"}
+
+	executor := NewFuncExecutor().
+		RegisterAction("count-plays", func(ctx *ExecutionContext) error {
+			plays, _ := ctx.Variables["plays"].(int)
+			ctx.Variables["plays"] = plays + 1
+			if ctx.Event == nil || ctx.Event.EventID != "play" {
+				t.Errorf("Event = %v, want the play event", ctx.Event)
+			}
+			return nil
+		}).
+		RegisterGuard("may-stop", func(ctx *ExecutionContext) (bool, error) {
+			return ctx.Event.Data["force"] == true, nil
+		})
+	in := startInterpreter(t, sm, InterpreterHooks{Executor: executor})
+
+	steps := []EventOccurrence{
+		{EventID: "play"},
+		{EventID: "stop"},
+		{EventID: "stop", Data: map[string]interface{}{"force": true}},
+		{EventID: "play"},
+	}
+	for _, occurrence := range steps {
+		if err := in.Dispatch(occurrence); err != nil {
+			t.Fatalf("Dispatch(%v) error = %v", occurrence, err)
+		}
+	}
+	if got := in.Variables()["plays"]; got != 2 {
+		t.Errorf("plays = %v, want 2", got)
+	}
+	if !in.IsActive("playing") {
+		t.Errorf("Configuration() = %v, want playing to be active", in.Configuration())
+	}
+}
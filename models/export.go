@@ -0,0 +1,141 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownFormat is returned when no exporter or importer is registered
+// for a format
+var ErrUnknownFormat = errors.New("unknown format")
+
+// ExportOptions configures an export. Exporters ignore the options they do
+// not support.
+type ExportOptions struct {
+	Title      string            // Diagram or document title; defaults to the state machine name
+	Properties map[string]string // Format-specific settings, e.g. "direction" for Mermaid and DOT
+}
+
+// title returns the configured title or the display name of the state machine
+func (o ExportOptions) title(sm *StateMachine) string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return displayName(sm.Name, sm.ID)
+}
+
+// Exporter writes a state machine in some output format. Exporters are
+// registered by name with RegisterExporter and used through Export, so that
+// tools can offer every registered format without knowing about it.
+type Exporter interface {
+	// Name returns the format name the exporter is registered under, e.g. "plantuml"
+	Name() string
+	// Export writes the state machine to w
+	Export(sm *StateMachine, w io.Writer, opts ExportOptions) error
+}
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[string]Exporter{
+		"plantuml":   plantUMLExporter{},
+		"mermaid":    mermaidExporter{},
+		"dot":        dotExporter{},
+		"scxml":      scxmlExporter{},
+		"json":       jsonExporter{},
+		"jsonschema": jsonSchemaExporter{},
+		"svg":        svgExporter{},
+		"drawio":     drawioExporter{},
+		"html":       htmlExporter{},
+	}
+)
+
+// RegisterExporter registers an exporter under its name, which is matched
+// case-insensitively. It fails if the name is empty or already registered;
+// built-in formats cannot be replaced.
+func RegisterExporter(exporter Exporter) error {
+	if exporter == nil {
+		return fmt.Errorf("cannot register nil exporter")
+	}
+	name := strings.ToLower(strings.TrimSpace(exporter.Name()))
+	if name == "" {
+		return fmt.Errorf("cannot register exporter with empty name")
+	}
+
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	if _, exists := exporters[name]; exists {
+		return fmt.Errorf("exporter %q is already registered", name)
+	}
+	exporters[name] = exporter
+	return nil
+}
+
+// LookupExporter returns the exporter registered for the format name
+func LookupExporter(name string) (Exporter, bool) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	exporter, ok := exporters[strings.ToLower(strings.TrimSpace(name))]
+	return exporter, ok
+}
+
+// ExportFormats returns the names of all registered exporters, sorted
+func ExportFormats() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unregisterExporter removes an exporter; it exists so that tests can undo
+// RegisterExporter
+func unregisterExporter(name string) {
+	exportersMu.Lock()
+	delete(exporters, strings.ToLower(strings.TrimSpace(name)))
+	exportersMu.Unlock()
+}
+
+// Export writes the state machine to w in the named format
+func Export(sm *StateMachine, format string, w io.Writer, opts ExportOptions) error {
+	if sm == nil {
+		return fmt.Errorf("cannot export nil state machine")
+	}
+	exporter, ok := LookupExporter(format)
+	if !ok {
+		return fmt.Errorf("%w %q for export; registered formats: %s", ErrUnknownFormat, format, strings.Join(ExportFormats(), ", "))
+	}
+	if err := exporter.Export(sm, w, opts); err != nil {
+		return fmt.Errorf("failed to export %s: %w", exporter.Name(), err)
+	}
+	return nil
+}
+
+// plantUMLExporter exports PlantUML state diagrams; see PlantUML
+type plantUMLExporter struct{}
+
+func (plantUMLExporter) Name() string { return "plantuml" }
+
+func (plantUMLExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	_, err := io.WriteString(w, plantUML(sm, plantUMLStyle{title: opts.Title}))
+	return err
+}
+
+// jsonExporter writes the indented JSON encoding of a state machine, which
+// Load reads back
+type jsonExporter struct{}
+
+func (jsonExporter) Name() string { return "json" }
+
+func (jsonExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sm)
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExportFormats are the formats ExportPipeline renders when none are
+// given: the diagrams and documents of a docs build, with the model as an
+// instance of its JSON Schema
+var DefaultExportFormats = []string{"plantuml", "dot", "jsonschema", "scxml"}
+
+// exportExtensions are the file extensions of the built-in formats; other
+// formats use their name
+var exportExtensions = map[string]string{
+	"plantuml":   ".puml",
+	"mermaid":    ".mmd",
+	"dot":        ".dot",
+	"scxml":      ".scxml",
+	"json":       ".json",
+	"jsonschema": ".json",
+	"svg":        ".svg",
+	"drawio":     ".drawio",
+	"html":       ".html",
+}
+
+// ExportPipeline renders a state machine to several registered formats
+// concurrently and writes one file per format into a directory, named after
+// the machine's ID with the format's extension (e.g. "order.puml"). A
+// format that fails does not stop the others: Run attempts every format and
+// reports each outcome in an ExportReport. Formats writing the same file,
+// such as "json" and "jsonschema", cannot be combined. Each file is rendered in memory
+// and moved into place only when complete, so failed formats leave no
+// partial files behind.
+type ExportPipeline struct {
+	Formats []string      // Registered format names; nil uses DefaultExportFormats
+	Options ExportOptions // Passed to every exporter
+	Workers int           // Formats rendered concurrently; zero or less renders all at once
+}
+
+// ExportResult is the outcome of exporting one format
+type ExportResult struct {
+	Format   string
+	Path     string // File written; empty when the export failed
+	Size     int64
+	Duration time.Duration
+	Err      error
+}
+
+// ExportReport lists the outcome of each format of an ExportPipeline run,
+// in the order of the pipeline's formats
+type ExportReport struct {
+	Results []ExportResult
+}
+
+// Failed returns the results of the formats that failed
+func (r *ExportReport) Failed() []ExportResult {
+	var failed []ExportResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of the formats that failed, or returns nil when all
+// succeeded
+func (r *ExportReport) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Format, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Run exports the state machine to every format of the pipeline into dir,
+// creating dir if needed. Formats are checked before any is rendered, so an
+// unknown format fails the run without writing files. When ctx is
+// cancelled, formats that have not started fail with ctx.Err(). Run returns
+// the report together with its Err.
+func (p ExportPipeline) Run(ctx context.Context, sm *StateMachine, dir string) (*ExportReport, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("cannot export nil state machine")
+	}
+	formats := p.Formats
+	if formats == nil {
+		formats = DefaultExportFormats
+	}
+	exporters := make([]Exporter, len(formats))
+	files := make(map[string]string, len(formats)) // File name to format
+	for i, format := range formats {
+		exporter, ok := LookupExporter(format)
+		if !ok {
+			return nil, fmt.Errorf("%w %q for export; registered formats: %s", ErrUnknownFormat, format, strings.Join(ExportFormats(), ", "))
+		}
+		file := exportFileName(sm, exporter.Name())
+		if other, exists := files[file]; exists {
+			if other == exporter.Name() {
+				return nil, fmt.Errorf("format %q is listed more than once", exporter.Name())
+			}
+			return nil, fmt.Errorf("formats %q and %q both write %s", other, exporter.Name(), file)
+		}
+		files[file] = exporter.Name()
+		exporters[i] = exporter
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	workers := p.Workers
+	if workers <= 0 {
+		workers = len(exporters)
+	}
+	workers = min(workers, len(exporters))
+
+	report := &ExportReport{Results: make([]ExportResult, len(exporters))}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = p.export(ctx, sm, exporters[i], dir)
+			}
+		}()
+	}
+	for i := range exporters {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return report, report.Err()
+}
+
+// export renders one format into dir
+func (p ExportPipeline) export(ctx context.Context, sm *StateMachine, exporter Exporter, dir string) ExportResult {
+	result := ExportResult{Format: exporter.Name()}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+	start := time.Now()
+	var buf bytes.Buffer
+	err := exporter.Export(sm, &buf, p.Options)
+	path := filepath.Join(dir, exportFileName(sm, exporter.Name()))
+	if err == nil {
+		err = writeFileAtomically(path, buf.Bytes())
+	}
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Path, result.Size = path, int64(buf.Len())
+	return result
+}
+
+// exportFileName returns the file name of the machine's export in the format
+func exportFileName(sm *StateMachine, format string) string {
+	extension, ok := exportExtensions[format]
+	if !ok {
+		extension = "." + format
+	}
+	name := sm.ID
+	if name == "" {
+		name = "statemachine"
+	}
+	return bundlePathEscape(name) + extension
+}
+
+// writeFileAtomically writes data to a temporary file next to path and
+// renames it into place, so readers never see a partial file
+func writeFileAtomically(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // No-op once renamed
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
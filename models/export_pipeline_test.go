@@ -0,0 +1,141 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// brokenExporter fails every export after writing part of its output
+type brokenExporter struct{}
+
+func (brokenExporter) Name() string { return "broken" }
+
+func (brokenExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	io.WriteString(w, "partial")
+	return errors.New("renderer crashed")
+}
+
+func TestExportPipeline_Run(t *testing.T) {
+	if err := RegisterExporter(brokenExporter{}); err != nil {
+		t.Fatalf("RegisterExporter() error = %v", err)
+	}
+	defer unregisterExporter("broken")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		pipeline   ExportPipeline
+		wantFiles  []string
+		wantFailed int
+		wantErr    string
+	}{
+		{
+			name:      "default formats",
+			ctx:       context.Background(),
+			wantFiles: []string{"player.dot", "player.json", "player.puml", "player.scxml"},
+		},
+		{
+			name:      "one worker",
+			ctx:       context.Background(),
+			pipeline:  ExportPipeline{Formats: []string{"mermaid", "svg"}, Workers: 1},
+			wantFiles: []string{"player.mmd", "player.svg"},
+		},
+		{
+			name:       "partial failure",
+			ctx:        context.Background(),
+			pipeline:   ExportPipeline{Formats: []string{"plantuml", "broken", "dot"}},
+			wantFiles:  []string{"player.dot", "player.puml"},
+			wantFailed: 1,
+			wantErr:    "broken: renderer crashed",
+		},
+		{
+			name:       "cancelled",
+			ctx:        cancelled,
+			pipeline:   ExportPipeline{Formats: []string{"plantuml", "dot"}},
+			wantFailed: 2,
+			wantErr:    "plantuml: context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newPlayerMachine()
+			dir := filepath.Join(t.TempDir(), "docs")
+			report, err := tt.pipeline.Run(tt.ctx, sm, dir)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			if report == nil {
+				t.Fatal("Run() returned no report")
+			}
+
+			var files []string
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			if strings.Join(files, ",") != strings.Join(tt.wantFiles, ",") {
+				t.Errorf("files = %v, want %v", files, tt.wantFiles)
+			}
+
+			for _, result := range report.Results {
+				if result.Err != nil {
+					if result.Path != "" {
+						t.Errorf("failed format %s reports path %s", result.Format, result.Path)
+					}
+					continue
+				}
+				var want bytes.Buffer
+				if err := Export(sm, result.Format, &want, tt.pipeline.Options); err != nil {
+					t.Fatalf("Export(%s) error = %v", result.Format, err)
+				}
+				got, err := os.ReadFile(result.Path)
+				if err != nil || !bytes.Equal(got, want.Bytes()) || result.Size != int64(want.Len()) {
+					t.Errorf("%s: file differs from Export output (%v)", result.Format, err)
+				}
+				if result.Duration <= 0 {
+					t.Errorf("%s: Duration = %v, want the time spent exporting", result.Format, result.Duration)
+				}
+			}
+			if len(report.Failed()) != tt.wantFailed {
+				t.Errorf("Failed() = %v", report.Failed())
+			}
+		})
+	}
+}
+
+func TestExportPipeline_RunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sm       *StateMachine
+		pipeline ExportPipeline
+		want     string
+	}{
+		{"nil machine", nil, ExportPipeline{}, "cannot export nil state machine"},
+		{"unknown format", newPlayerMachine(), ExportPipeline{Formats: []string{"dot", "pdf"}}, `unknown format "pdf"`},
+		{"repeated format", newPlayerMachine(), ExportPipeline{Formats: []string{"dot", "DOT"}}, `format "dot" is listed more than once`},
+		{"same file", newPlayerMachine(), ExportPipeline{Formats: []string{"json", "jsonschema"}}, `formats "json" and "jsonschema" both write player.json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "docs")
+			report, err := tt.pipeline.Run(context.Background(), tt.sm, dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || report != nil {
+				t.Errorf("Run() = %v, %v, want %q", report, err, tt.want)
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Error("Run() created the export directory before rejecting the formats")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// summaryExporter is a third-party style exporter writing one line per state
+type summaryExporter struct{ err error }
+
+func (summaryExporter) Name() string { return "Summary" }
+
+func (e summaryExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	if e.err != nil {
+		return e.err
+	}
+	_, err := io.WriteString(w, opts.title(sm)+" "+opts.Properties["suffix"])
+	return err
+}
+
+func TestRegisterExporter(t *testing.T) {
+	if err := RegisterExporter(summaryExporter{}); err != nil {
+		t.Fatalf("RegisterExporter() error = %v", err)
+	}
+	defer unregisterExporter("summary")
+
+	if _, ok := LookupExporter(" SUMMARY "); !ok {
+		t.Error("LookupExporter() should match names case-insensitively")
+	}
+	if got := strings.Join(ExportFormats(), ","); got != "dot,drawio,html,json,jsonschema,mermaid,plantuml,scxml,summary,svg" {
+		t.Errorf("ExportFormats() = %s", got)
+	}
+
+	var out bytes.Buffer
+	opts := ExportOptions{Title: "Media", Properties: map[string]string{"suffix": "v2"}}
+	if err := Export(newPlayerMachine(), "summary", &out, opts); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if out.String() != "Media v2" {
+		t.Errorf("Export() wrote %q, want the title and property", out.String())
+	}
+
+	tests := []struct {
+		name     string
+		exporter Exporter
+		want     string
+	}{
+		{name: "duplicate", exporter: summaryExporter{}, want: `exporter "summary" is already registered`},
+		{name: "built-in", exporter: plantUMLExporter{}, want: `exporter "plantuml" is already registered`},
+		{name: "nil", exporter: nil, want: "cannot register nil exporter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterExporter(tt.exporter)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RegisterExporter() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExport_Errors(t *testing.T) {
+	failing := errors.New("disk full")
+	if err := RegisterExporter(failingExporter{summaryExporter{err: failing}}); err != nil {
+		t.Fatalf("RegisterExporter() error = %v", err)
+	}
+	defer unregisterExporter("failing")
+
+	var out bytes.Buffer
+	err := Export(newPlayerMachine(), "png", &out, ExportOptions{})
+	if !errors.Is(err, ErrUnknownFormat) || !strings.Contains(err.Error(), "registered formats: dot, drawio, failing, html, json, jsonschema, mermaid") {
+		t.Errorf("Export(png) error = %v, want ErrUnknownFormat listing the formats", err)
+	}
+	if err := Export(nil, "dot", &out, ExportOptions{}); err == nil {
+		t.Error("Export(nil) should fail")
+	}
+	if err := Export(newPlayerMachine(), "failing", &out, ExportOptions{}); !errors.Is(err, failing) {
+		t.Errorf("Export(failing) error = %v, want the exporter's error", err)
+	}
+}
+
+// failingExporter renames an exporter so it can be registered next to it
+type failingExporter struct{ summaryExporter }
+
+func (failingExporter) Name() string { return "failing" }
+
+func TestPlantUMLExporter(t *testing.T) {
+	var out bytes.Buffer
+	if err := Export(newPlayerMachine(), "PlantUML", &out, ExportOptions{Title: "Media player"}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "@startuml\ntitle Media player\n") {
+		t.Errorf("Export() = %s, want the title option used", out.String())
+	}
+	if strings.Replace(out.String(), "Media player", "Player", 1) != PlantUML(newPlayerMachine()) {
+		t.Error("the PlantUML exporter should write the same diagram as PlantUML")
+	}
+}
+
+func TestJSONExporter(t *testing.T) {
+	var out bytes.Buffer
+	if err := Export(newPlayerMachine(), "json", &out, ExportOptions{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "{\n  \"id\": \"player\",") {
+		t.Errorf("Export() = %s, want indented JSON", out.String())
+	}
+	sm, _, err := Load(&out)
+	if err != nil || !sm.EquivalentTo(newPlayerMachine()) {
+		t.Errorf("Load() = %v, %v; want the exported machine back", sm, err)
+	}
+}
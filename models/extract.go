@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ExtractCompositeState wraps the states with the given IDs, which must all
+// belong to the same region, into a new composite state with ID compositeID.
+// The selected states and the transitions between them move into a new
+// region of the composite state. Transitions crossing the new boundary are
+// rewired:
+//   - incoming transitions to the first entered state now target the
+//     composite state, and a new initial pseudostate leads to that state
+//   - incoming transitions to any other state target a new entry point
+//     inside the composite state, which continues to the original target
+//   - outgoing transitions keep their source, triggers, guard and effect but
+//     now lead to a new exit point, which is declared with its continuation
+//     to the original target in the region of the composite state
+//
+// Incoming transitions declared in other regions, such as enclosing ones,
+// are rewired the same way.
+//
+// The result is validated and the refactoring is refused, leaving sm
+// untouched, if it would introduce validation errors. It returns the new
+// composite state.
+func ExtractCompositeState(sm *StateMachine, stateIDs []string, compositeID, compositeName string) (*State, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	if len(stateIDs) == 0 {
+		return nil, fmt.Errorf("at least one state must be selected")
+	}
+	if compositeID == "" {
+		return nil, fmt.Errorf("composite state ID cannot be empty")
+	}
+	if elementExists(sm, compositeID) {
+		return nil, fmt.Errorf("cannot extract composite state: ID '%s' is already in use", compositeID)
+	}
+
+	region := findStateRegion(sm, stateIDs[0])
+	if region == nil {
+		return nil, fmt.Errorf("state '%s' not found", stateIDs[0])
+	}
+	for _, id := range stateIDs[1:] {
+		if !slices.ContainsFunc(region.States, func(s *State) bool { return s != nil && s.ID == id }) {
+			return nil, fmt.Errorf("state '%s' is not in region '%s'; all extracted states must share a region", id, region.ID)
+		}
+	}
+
+	var composite *State
+	apply := func(target *StateMachine) {
+		composite = extractCompositeState(target, findStateRegion(target, stateIDs[0]), stateIDs, compositeID, compositeName)
+	}
+	if err := checkRefactoring(sm, apply); err != nil {
+		return nil, fmt.Errorf("cannot extract composite state '%s': %w", compositeID, err)
+	}
+	apply(sm)
+	sm.refreshAdjacency()
+	return composite, nil
+}
+
+// extractCompositeState performs the extraction on region of sm; the inputs
+// have already been checked by ExtractCompositeState
+func extractCompositeState(sm *StateMachine, region *Region, stateIDs []string, compositeID, compositeName string) *State {
+	selected := make(map[string]bool, len(stateIDs))
+	for _, id := range stateIDs {
+		selected[id] = true
+	}
+
+	composite := &State{
+		Vertex:      Vertex{ID: compositeID, Name: compositeName, Type: "state"},
+		IsComposite: true,
+	}
+	inner := &Region{ID: compositeID + "_region", Name: displayName(compositeName, compositeID) + " Region"}
+	composite.Regions = []*Region{inner}
+
+	// Move the selected states, keeping the composite at the first one's position
+	var remaining []*State
+	for _, state := range region.States {
+		if state != nil && selected[state.ID] {
+			if len(inner.States) == 0 {
+				remaining = append(remaining, composite)
+			}
+			inner.States = append(inner.States, state)
+			continue
+		}
+		remaining = append(remaining, state)
+	}
+	region.States = remaining
+
+	var initialTarget *Vertex
+	entryPoints := make(map[string]*Vertex)
+	exitPoints := make(map[string]*Vertex)
+
+	// enter rewires a transition from outside the composite state to a
+	// selected state
+	enter := func(transition *Transition) {
+		if initialTarget == nil || initialTarget.ID == transition.Target.ID {
+			initialTarget = transition.Target
+			transition.Target = &composite.Vertex
+			return
+		}
+		entry, exists := entryPoints[transition.Target.ID]
+		if !exists {
+			entry = &Vertex{ID: fmt.Sprintf("%s_entry_%s", compositeID, transition.Target.ID), Name: "entry", Type: "pseudostate"}
+			entryPoints[transition.Target.ID] = entry
+			inner.Vertices = append(inner.Vertices, entry)
+			inner.Transitions = append(inner.Transitions, &Transition{
+				ID:     entry.ID + "_t",
+				Source: entry,
+				Target: transition.Target,
+				Kind:   TransitionKindExternal,
+			})
+		}
+		transition.Target = entry
+	}
+
+	var outer []*Transition
+	for _, transition := range region.Transitions {
+		if transition == nil || transition.Source == nil || transition.Target == nil {
+			outer = append(outer, transition)
+			continue
+		}
+		sourceInside, targetInside := selected[transition.Source.ID], selected[transition.Target.ID]
+
+		switch {
+		case sourceInside && targetInside:
+			inner.Transitions = append(inner.Transitions, transition)
+
+		case targetInside:
+			outer = append(outer, transition)
+			enter(transition)
+
+		case sourceInside:
+			exit, exists := exitPoints[transition.Target.ID]
+			if !exists {
+				exit = &Vertex{ID: fmt.Sprintf("%s_exit_%s", compositeID, transition.Target.ID), Name: "exit", Type: "pseudostate"}
+				exitPoints[transition.Target.ID] = exit
+				region.Vertices = append(region.Vertices, exit)
+				outer = append(outer, &Transition{
+					ID:     exit.ID + "_t",
+					Source: exit,
+					Target: transition.Target,
+					Kind:   TransitionKindExternal,
+				})
+			}
+			transition.Target = exit
+			inner.Transitions = append(inner.Transitions, transition)
+
+		default:
+			outer = append(outer, transition)
+		}
+	}
+	region.Transitions = outer
+
+	// Transitions declared in other regions outside the composite state
+	nested := map[*Region]bool{region: true}
+	walkRegionTree(composite.Regions, "", func(r *Region, _ string) { nested[r] = true })
+	walkRegionTree(sm.Regions, "", func(r *Region, _ string) {
+		if nested[r] {
+			return
+		}
+		for _, transition := range r.Transitions {
+			if transition != nil && transition.Source != nil && transition.Target != nil &&
+				!selected[transition.Source.ID] && selected[transition.Target.ID] {
+				enter(transition)
+			}
+		}
+	})
+
+	if initialTarget == nil {
+		initialTarget = &inner.States[0].Vertex
+	}
+	initial := &Vertex{ID: compositeID + "_initial", Name: "Initial", Type: "pseudostate"}
+	inner.Vertices = append([]*Vertex{initial}, inner.Vertices...)
+	inner.Transitions = append([]*Transition{{
+		ID:     initial.ID + "_t",
+		Source: initial,
+		Target: initialTarget,
+		Kind:   TransitionKindExternal,
+	}}, inner.Transitions...)
+
+	return composite
+}
+
+// findStateRegion returns the region that directly contains the state with the given ID
+func findStateRegion(sm *StateMachine, stateID string) *Region {
+	var found *Region
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		if found == nil && slices.ContainsFunc(region.States, func(s *State) bool { return s != nil && s.ID == stateID }) {
+			found = region
+		}
+	})
+	return found
+}
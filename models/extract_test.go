@@ -0,0 +1,157 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// createLinearStateMachine creates initial -> a -> b -> c -> final with a detour a -> d -> c
+func createLinearStateMachine() *StateMachine {
+	region := &Region{
+		ID:   "main",
+		Name: "Main",
+		Vertices: []*Vertex{
+			{ID: "initial", Name: "Initial", Type: "pseudostate"},
+			{ID: "final", Name: "Final", Type: "finalstate"},
+		},
+	}
+	for _, name := range []string{"A", "B", "C", "D"} {
+		region.States = append(region.States, &State{Vertex: Vertex{ID: strings.ToLower(name), Name: name, Type: "state"}, IsSimple: true})
+	}
+
+	sm := &StateMachine{ID: "linear", Name: "Linear", Version: "1.0", Regions: []*Region{region}}
+	for _, edge := range [][3]string{
+		{"t0", "initial", "a"},
+		{"t1", "a", "b"},
+		{"t2", "b", "c"},
+		{"t3", "a", "d"},
+		{"t5", "d", "c"},
+		{"t4", "c", "final"},
+	} {
+		region.Transitions = append(region.Transitions, &Transition{
+			ID:     edge[0],
+			Source: findVertex(sm, edge[1]),
+			Target: findVertex(sm, edge[2]),
+			Kind:   TransitionKindExternal,
+		})
+	}
+	return sm
+}
+
+func TestExtractCompositeState(t *testing.T) {
+	sm := createLinearStateMachine()
+	if err := sm.Validate(); err != nil {
+		t.Fatalf("fixture should validate: %v", err)
+	}
+
+	composite, err := ExtractCompositeState(sm, []string{"b", "c"}, "work", "Work")
+	if err != nil {
+		t.Fatalf("ExtractCompositeState() error = %v", err)
+	}
+
+	region := sm.Regions[0]
+	if len(region.States) != 3 || region.States[1] != composite {
+		t.Fatalf("outer region states = %v, want composite in place of B", region.States)
+	}
+	inner := composite.Regions[0]
+	if len(inner.States) != 2 || inner.States[0].ID != "b" || inner.States[1].ID != "c" {
+		t.Errorf("inner states = %v, want [b c]", inner.States)
+	}
+
+	_, t1 := findTransition(sm, "t1")
+	if t1.Target.ID != "work" {
+		t.Errorf("first incoming transition should target the composite, got %s", t1.Target.ID)
+	}
+	_, t5 := findTransition(sm, "t5")
+	if t5.Target.ID != "work_entry_c" {
+		t.Errorf("second incoming transition should target an entry point, got %s", t5.Target.ID)
+	}
+	_, t4 := findTransition(sm, "t4")
+	if t4.Target.ID != "work_exit_final" || !containsTransition(inner, "t4") {
+		t.Errorf("outgoing transition should lead to an exit point, got %s", t4.Target.ID)
+	}
+	if containsTransition(inner, "work_exit_final_t") || !containsTransition(region, "work_exit_final_t") {
+		t.Error("exit point continuation should be declared in the region of the composite")
+	}
+	if _, initial := findTransition(sm, "work_initial_t"); initial == nil || initial.Target.ID != "b" {
+		t.Error("composite region should start at the first entered state")
+	}
+	if !containsTransition(inner, "t2") {
+		t.Error("internal transition should move into the composite region")
+	}
+
+	if err := sm.Validate(); err != nil {
+		t.Errorf("extracted model should validate: %v", err)
+	}
+}
+
+func TestExtractCompositeState_EnclosingRegion(t *testing.T) {
+	// Nest the linear machine in state P and enter C from the enclosing region
+	sm := createLinearStateMachine()
+	linear := sm.Regions[0]
+	p := &State{Vertex: Vertex{ID: "p", Name: "P", Type: "state"}, IsComposite: true, Regions: []*Region{linear}}
+	x := &State{Vertex: Vertex{ID: "x", Name: "X", Type: "state"}, IsSimple: true}
+	initial := &Vertex{ID: "top_initial", Name: "Initial", Type: "pseudostate"}
+	sm.Regions = []*Region{{
+		ID:       "top",
+		Name:     "Top",
+		States:   []*State{x, p},
+		Vertices: []*Vertex{initial},
+		Transitions: []*Transition{
+			{ID: "t6", Source: initial, Target: &x.Vertex, Kind: TransitionKindExternal},
+			{ID: "t7", Source: &x.Vertex, Target: findVertex(sm, "c"), Kind: TransitionKindExternal},
+		},
+	}}
+	if err := sm.Validate(); err != nil {
+		t.Fatalf("fixture should validate: %v", err)
+	}
+
+	if _, err := ExtractCompositeState(sm, []string{"b", "c"}, "work", "Work"); err != nil {
+		t.Fatalf("ExtractCompositeState() error = %v", err)
+	}
+
+	_, t7 := findTransition(sm, "t7")
+	if t7.Target.ID != "work_entry_c" {
+		t.Errorf("transition from the enclosing region should target an entry point, got %s", t7.Target.ID)
+	}
+	if !containsTransition(sm.Regions[0], "t7") {
+		t.Error("rewired transition should stay in the enclosing region")
+	}
+	if err := sm.Validate(); err != nil {
+		t.Errorf("extracted model should validate: %v", err)
+	}
+}
+
+func TestExtractCompositeState_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		stateIDs    []string
+		compositeID string
+		errContains string
+	}{
+		{name: "no states", stateIDs: nil, compositeID: "x", errContains: "at least one state"},
+		{name: "unknown state", stateIDs: []string{"missing"}, compositeID: "x", errContains: "state 'missing' not found"},
+		{name: "ID in use", stateIDs: []string{"b"}, compositeID: "a", errContains: "ID 'a' is already in use"},
+		{name: "state outside region", stateIDs: []string{"b", "final"}, compositeID: "x", errContains: "all extracted states must share a region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createLinearStateMachine()
+			_, err := ExtractCompositeState(sm, tt.stateIDs, tt.compositeID, "X")
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ExtractCompositeState() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+// containsTransition reports whether the region directly contains the transition
+func containsTransition(region *Region, id string) bool {
+	for _, transition := range region.Transitions {
+		if transition != nil && transition.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FeatureSet is the set of feature flags enabled for a model variant
+type FeatureSet map[string]bool
+
+// NewFeatureSet creates a feature set with the given flags enabled
+func NewFeatureSet(flags ...string) FeatureSet {
+	features := make(FeatureSet, len(flags))
+	for _, flag := range flags {
+		features[flag] = true
+	}
+	return features
+}
+
+// Enables reports whether every one of the given flags is enabled. An element
+// without flags is always enabled.
+func (fs FeatureSet) Enables(flags []string) bool {
+	for _, flag := range flags {
+		if !fs[flag] {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve produces the variant of a master model for the given feature set.
+// States and transitions whose Features are not all enabled are removed, as
+// are the contents of removed states and the transitions attached to them.
+// The master is not modified. Resolve fails if a state that is reachable in
+// the master becomes unreachable in the variant, or if the variant does not
+// validate.
+func (sm *StateMachine) Resolve(features FeatureSet) (*StateMachine, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+
+	variant := sm.Clone()
+	deleted := make(map[string]bool)
+	walkRegionTree(variant.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil && !features.Enables(state.Features) {
+				for id := range collectDeletedIDs(variant, state.ID) {
+					deleted[id] = true
+				}
+			}
+		}
+		for _, transition := range region.Transitions {
+			if transition != nil && !features.Enables(transition.Features) {
+				deleted[transition.ID] = true
+			}
+		}
+	})
+	deleteElements(variant, deleted)
+
+	// Revalidate connectivity: disabling features must not strand enabled states
+	reachableInMaster := reachableVertices(sm)
+	reachableInVariant := reachableVertices(variant)
+	var stranded []string
+	walkRegionTree(variant.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil && reachableInMaster[state.ID] && !reachableInVariant[state.ID] {
+				stranded = append(stranded, state.ID)
+			}
+		}
+	})
+	if len(stranded) > 0 {
+		slices.Sort(stranded)
+		return nil, fmt.Errorf("feature set leaves states unreachable: %s", strings.Join(stranded, ", "))
+	}
+
+	if err := variant.Validate(); err != nil {
+		return nil, fmt.Errorf("resolved variant is invalid: %w", err)
+	}
+	return variant, nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStateMachine_Resolve(t *testing.T) {
+	// Master: a -> b -> c -> final, with a premium shortcut a -> d -> c
+	newMaster := func() *StateMachine {
+		sm := createLinearStateMachine()
+		findStateByID(sm, "d").Features = []string{"premium"}
+		return sm
+	}
+
+	t.Run("feature enabled keeps elements", func(t *testing.T) {
+		variant, err := newMaster().Resolve(NewFeatureSet("premium"))
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if findStateByID(variant, "d") == nil {
+			t.Error("enabled state should be kept")
+		}
+	})
+
+	t.Run("feature disabled strips state and its transitions", func(t *testing.T) {
+		master := newMaster()
+		variant, err := master.Resolve(NewFeatureSet())
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if findStateByID(variant, "d") != nil {
+			t.Error("disabled state should be removed")
+		}
+		for _, id := range []string{"t3", "t5"} {
+			if _, transition := findTransition(variant, id); transition != nil {
+				t.Errorf("transition %s attached to a removed state should be removed", id)
+			}
+		}
+		if findStateByID(master, "d") == nil {
+			t.Error("Resolve() must not modify the master")
+		}
+	})
+
+	t.Run("stranding an enabled state is rejected", func(t *testing.T) {
+		master := newMaster()
+		_, t1 := findTransition(master, "t1")
+		t1.Features = []string{"fast-track"}
+		_, t3 := findTransition(master, "t3")
+		t3.Features = []string{"fast-track"}
+
+		_, err := master.Resolve(NewFeatureSet("premium"))
+		if err == nil || !strings.Contains(err.Error(), "feature set leaves states unreachable: b, c, d") {
+			t.Errorf("Resolve() error = %v", err)
+		}
+	})
+}
+
+func TestFeatureSet_Enables(t *testing.T) {
+	features := NewFeatureSet("a", "b")
+	tests := []struct {
+		flags []string
+		want  bool
+	}{
+		{nil, true},
+		{[]string{"a"}, true},
+		{[]string{"a", "b"}, true},
+		{[]string{"a", "c"}, false},
+	}
+	for _, tt := range tests {
+		if got := features.Enables(tt.flags); got != tt.want {
+			t.Errorf("Enables(%v) = %v, want %v", tt.flags, got, tt.want)
+		}
+	}
+}
+
+// findStateByID returns the state with the given ID anywhere in the state machine
+func findStateByID(sm *StateMachine, id string) *State {
+	region := findStateRegion(sm, id)
+	if region == nil {
+		return nil
+	}
+	for _, state := range region.States {
+		if state != nil && state.ID == id {
+			return state
+		}
+	}
+	return nil
+}
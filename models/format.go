@@ -0,0 +1,39 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format rewrites the JSON encoding of a state machine in canonical form,
+// like gofmt does for Go source, so hand-authored machine files stay
+// diff-friendly: fields in declaration order, map keys sorted, empty
+// optional fields dropped, two-space indentation and a trailing newline.
+// This is the encoding the "json" exporter writes. Element order is kept,
+// since the order of states, vertices and transitions is part of the model.
+// Numbers in metadata keep their spelling.
+//
+// Format is idempotent. It fails on invalid JSON, on trailing data and on
+// fields the model does not know, rather than silently dropping them.
+func Format(src []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+
+	var sm StateMachine
+	if err := decoder.Decode(&sm); err != nil {
+		return nil, fmt.Errorf("failed to parse state machine: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse state machine: unexpected data after the state machine")
+	}
+
+	var out bytes.Buffer
+	if err := (jsonExporter{}).Export(&sm, &out, ExportOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to format state machine: %w", err)
+	}
+	return out.Bytes(), nil
+}
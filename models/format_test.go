@@ -0,0 +1,105 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "canonical layout",
+			src: `{"version":"1.0","name":"Door","id":"door",
+				"metadata":{"z":1,"a":12345678901234567890},"created_at":"2024-01-01T00:00:00Z",
+				"regions":[{"name":"Main","id":"main","states":[],"transitions":null}],"entities":{}}`,
+			want: `{
+  "id": "door",
+  "name": "Door",
+  "version": "1.0",
+  "regions": [
+    {
+      "id": "main",
+      "name": "Main",
+      "states": [],
+      "transitions": null,
+      "vertices": null
+    }
+  ],
+  "is_method": false,
+  "entities": {},
+  "metadata": {
+    "a": 12345678901234567890,
+    "z": 1
+  },
+  "created_at": "2024-01-01T00:00:00Z"
+}
+`,
+		},
+		{name: "invalid JSON", src: `{"id":`, wantErr: "failed to parse state machine"},
+		{name: "unknown field", src: `{"id":"door","colour":"red"}`, wantErr: `unknown field "colour"`},
+		{name: "trailing data", src: `{"id":"door"} {"id":"window"}`, wantErr: "unexpected data after the state machine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(tt.src))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Format() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	machines := map[string]*StateMachine{
+		"valid":  createValidStateMachine(),
+		"player": newPlayerMachine(),
+	}
+	for name, sm := range machines {
+		t.Run(name, func(t *testing.T) {
+			sm.CreatedAt = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+			sm.Metadata = map[string]interface{}{"owner": "media", "retries": 3}
+			src, err := json.Marshal(sm)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			once, err := Format(src)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			twice, err := Format(once)
+			if err != nil {
+				t.Fatalf("Format(Format()) error = %v", err)
+			}
+			if !bytes.Equal(once, twice) {
+				t.Errorf("Format() is not idempotent:\n%s\nthen\n%s", once, twice)
+			}
+
+			var exported bytes.Buffer
+			if err := Export(sm, "json", &exported, ExportOptions{}); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if !bytes.Equal(once, exported.Bytes()) {
+				t.Error("Format() should match the json exporter")
+			}
+		})
+	}
+}
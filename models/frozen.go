@@ -0,0 +1,377 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"time"
+)
+
+// FrozenStateMachine is a read-only view of a validated state machine. It
+// keeps a private deep copy of the machine and exposes it through methods
+// only: composite elements are returned as read-only views and leaf
+// elements such as behaviors, triggers and events as fresh copies, so no
+// caller can change the model after it was validated. A FrozenStateMachine
+// is safe to share between goroutines without defensive copies.
+type FrozenStateMachine struct {
+	sm *StateMachine
+}
+
+// Freeze validates a deep copy of the state machine and returns a read-only
+// view of it. Later changes to sm do not affect the view.
+func Freeze(sm *StateMachine) (*FrozenStateMachine, error) {
+	return FreezeInContext(sm, NewValidationContext())
+}
+
+// FreezeInContext is Freeze with validation in the provided context, for
+// example to apply a validation profile
+func FreezeInContext(sm *StateMachine, context *ValidationContext) (*FrozenStateMachine, error) {
+	frozen := cloneWithMetadata(sm)
+	if err := frozen.ValidateInContext(context); err != nil {
+		return nil, err
+	}
+	return &FrozenStateMachine{sm: frozen}, nil
+}
+
+// cloneWithMetadata deep-copies the state machine and its submachines
+// including nested metadata values, which Clone copies shallowly
+func cloneWithMetadata(sm *StateMachine) *StateMachine {
+	cloned := sm.Clone()
+	seen := make(map[*StateMachine]bool)
+	var copyMetadata func(machine *StateMachine)
+	copyMetadata = func(machine *StateMachine) {
+		if machine == nil || seen[machine] {
+			return
+		}
+		seen[machine] = true
+		if machine.Metadata != nil {
+			machine.Metadata = copyJSONValue(machine.Metadata).(map[string]interface{})
+		}
+		walkRegionTree(machine.Regions, "", func(region *Region, _ string) {
+			for _, state := range region.States {
+				if state != nil {
+					copyMetadata(state.Submachine)
+				}
+			}
+		})
+	}
+	copyMetadata(cloned)
+	return cloned
+}
+
+// String returns a concise one-line description of the state machine
+func (f *FrozenStateMachine) String() string {
+	return f.sm.String()
+}
+
+// ID returns the ID of the state machine
+func (f *FrozenStateMachine) ID() string { return f.sm.ID }
+
+// Name returns the name of the state machine
+func (f *FrozenStateMachine) Name() string { return f.sm.Name }
+
+// Version returns the version of the state machine
+func (f *FrozenStateMachine) Version() string { return f.sm.Version }
+
+// IsMethod reports whether the state machine is used as a method
+func (f *FrozenStateMachine) IsMethod() bool { return f.sm.IsMethod }
+
+// AllowSharedTerminate reports whether the state machine accepts terminate
+// pseudostates while it is shared as a submachine
+func (f *FrozenStateMachine) AllowSharedTerminate() bool { return f.sm.AllowSharedTerminate }
+
+// CreatedAt returns the creation time of the state machine
+func (f *FrozenStateMachine) CreatedAt() time.Time { return f.sm.CreatedAt }
+
+// Layout returns a copy of the diagram layout, or nil
+func (f *FrozenStateMachine) Layout() *Layout {
+	return f.sm.Layout.Clone()
+}
+
+// Entities returns a copy of the entity ID to cache key mapping
+func (f *FrozenStateMachine) Entities() map[string]string {
+	return maps.Clone(f.sm.Entities)
+}
+
+// Metadata returns a deep copy of a metadata value. Nested maps and slices
+// decoded from JSON are copied as well.
+func (f *FrozenStateMachine) Metadata(key string) (interface{}, bool) {
+	value, exists := f.sm.Metadata[key]
+	return copyJSONValue(value), exists
+}
+
+// MetadataKeys returns the metadata keys in sorted order
+func (f *FrozenStateMachine) MetadataKeys() []string {
+	return slices.Sorted(maps.Keys(f.sm.Metadata))
+}
+
+// Regions returns views of the top-level regions
+func (f *FrozenStateMachine) Regions() []FrozenRegion {
+	return frozenRegions(f.sm.Regions)
+}
+
+// ConnectionPoints returns copies of the entry and exit points
+func (f *FrozenStateMachine) ConnectionPoints() []*Pseudostate {
+	points := make([]*Pseudostate, 0, len(f.sm.ConnectionPoints))
+	for _, point := range f.sm.ConnectionPoints {
+		points = append(points, newModelCloner().pseudostate(point))
+	}
+	return points
+}
+
+// Events returns copies of the event catalog
+func (f *FrozenStateMachine) Events() []*Event {
+	events := make([]*Event, 0, len(f.sm.Events))
+	for _, event := range f.sm.Events {
+		events = append(events, newModelCloner().event(event))
+	}
+	return events
+}
+
+// Thaw returns a mutable deep copy of the state machine, for example to
+// edit it or to pass it to functions that take a *StateMachine
+func (f *FrozenStateMachine) Thaw() *StateMachine {
+	return cloneWithMetadata(f.sm)
+}
+
+// Export writes the state machine in the named registered format; see
+// Export
+func (f *FrozenStateMachine) Export(format string, w io.Writer, opts ExportOptions) error {
+	return Export(f.sm, format, w, opts)
+}
+
+// MarshalJSON encodes the state machine like a *StateMachine
+func (f *FrozenStateMachine) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.sm)
+}
+
+// FrozenRegion is a read-only view of a region of a FrozenStateMachine
+type FrozenRegion struct {
+	r *Region
+}
+
+// frozenRegions wraps regions in views, skipping nil regions
+func frozenRegions(regions []*Region) []FrozenRegion {
+	views := make([]FrozenRegion, 0, len(regions))
+	for _, region := range regions {
+		if region != nil {
+			views = append(views, FrozenRegion{r: region})
+		}
+	}
+	return views
+}
+
+// ID returns the ID of the region
+func (r FrozenRegion) ID() string { return r.r.ID }
+
+// Name returns the name of the region
+func (r FrozenRegion) Name() string { return r.r.Name }
+
+// Ownership returns the owner and team annotated on the region
+func (r FrozenRegion) Ownership() Ownership { return Ownership{Owner: r.r.Owner, Team: r.r.Team} }
+
+// States returns views of the states of the region
+func (r FrozenRegion) States() []FrozenState {
+	views := make([]FrozenState, 0, len(r.r.States))
+	for _, state := range r.r.States {
+		if state != nil {
+			views = append(views, FrozenState{s: state})
+		}
+	}
+	return views
+}
+
+// Vertices returns copies of the pseudostates and final states of the
+// region. The copies record no transitions: Outgoing and Incoming return
+// nil, so no live transition of the frozen machine is handed out.
+func (r FrozenRegion) Vertices() []*Vertex {
+	vertices := make([]*Vertex, 0, len(r.r.Vertices))
+	for _, vertex := range r.r.Vertices {
+		if vertex != nil {
+			vertices = append(vertices, newModelCloner().vertex(vertex))
+		}
+	}
+	return vertices
+}
+
+// Transitions returns views of the transitions of the region
+func (r FrozenRegion) Transitions() []FrozenTransition {
+	views := make([]FrozenTransition, 0, len(r.r.Transitions))
+	for _, transition := range r.r.Transitions {
+		if transition != nil {
+			views = append(views, FrozenTransition{t: transition})
+		}
+	}
+	return views
+}
+
+// FrozenState is a read-only view of a state of a FrozenStateMachine
+type FrozenState struct {
+	s *State
+}
+
+// String returns a concise one-line description of the state
+func (s FrozenState) String() string { return s.s.String() }
+
+// ID returns the ID of the state
+func (s FrozenState) ID() string { return s.s.ID }
+
+// Name returns the name of the state
+func (s FrozenState) Name() string { return s.s.Name }
+
+// IsSimple reports whether the state is a simple state
+func (s FrozenState) IsSimple() bool { return s.s.IsSimple }
+
+// IsComposite reports whether the state is a composite state
+func (s FrozenState) IsComposite() bool { return s.s.IsComposite }
+
+// IsOrthogonal reports whether the state is an orthogonal state
+func (s FrozenState) IsOrthogonal() bool { return s.s.IsOrthogonal }
+
+// IsSubmachineState reports whether the state is a submachine state
+func (s FrozenState) IsSubmachineState() bool { return s.s.IsSubmachineState }
+
+// Deprecated reports whether the state is deprecated
+func (s FrozenState) Deprecated() bool { return s.s.Deprecated }
+
+// ReplacedBy returns the ID of the state replacing a deprecated state
+func (s FrozenState) ReplacedBy() string { return s.s.ReplacedBy }
+
+// Ownership returns the owner and team annotated on the state
+func (s FrozenState) Ownership() Ownership { return Ownership{Owner: s.s.Owner, Team: s.s.Team} }
+
+// Regions returns views of the regions of a composite state
+func (s FrozenState) Regions() []FrozenRegion { return frozenRegions(s.s.Regions) }
+
+// Entry returns a copy of the entry behavior, or nil
+func (s FrozenState) Entry() *Behavior { return newModelCloner().behavior(s.s.Entry) }
+
+// Exit returns a copy of the exit behavior, or nil
+func (s FrozenState) Exit() *Behavior { return newModelCloner().behavior(s.s.Exit) }
+
+// DoActivity returns a copy of the do activity behavior, or nil
+func (s FrozenState) DoActivity() *Behavior { return newModelCloner().behavior(s.s.DoActivity) }
+
+// Submachine returns a view of the submachine of a submachine state, or nil.
+// The submachine was validated together with the state machine containing
+// the state.
+func (s FrozenState) Submachine() *FrozenStateMachine {
+	if s.s.Submachine == nil {
+		return nil
+	}
+	return &FrozenStateMachine{sm: s.s.Submachine}
+}
+
+// Connections returns copies of the connection point references
+func (s FrozenState) Connections() []*ConnectionPointReference {
+	connections := make([]*ConnectionPointReference, 0, len(s.s.Connections))
+	for _, connection := range s.s.Connections {
+		connections = append(connections, newModelCloner().connectionPointReference(connection))
+	}
+	return connections
+}
+
+// DeferrableTriggers returns copies of the triggers the state defers
+func (s FrozenState) DeferrableTriggers() []*Trigger { return copyTriggers(s.s.DeferrableTriggers) }
+
+// Features returns a copy of the feature flags of the state
+func (s FrozenState) Features() []string { return slices.Clone(s.s.Features) }
+
+// Cost returns a copy of the cost annotation, or nil
+func (s FrozenState) Cost() *Cost { return clonePointer(s.s.Cost) }
+
+// Tags returns a copy of the tags of the state
+func (s FrozenState) Tags() []string { return slices.Clone(s.s.Tags) }
+
+// FrozenTransition is a read-only view of a transition of a
+// FrozenStateMachine
+type FrozenTransition struct {
+	t *Transition
+}
+
+// String returns a concise one-line description of the transition
+func (t FrozenTransition) String() string { return t.t.String() }
+
+// ID returns the ID of the transition
+func (t FrozenTransition) ID() string { return t.t.ID }
+
+// Name returns the name of the transition
+func (t FrozenTransition) Name() string { return t.t.Name }
+
+// Kind returns the kind of the transition
+func (t FrozenTransition) Kind() TransitionKind { return t.t.Kind }
+
+// Source returns a copy of the source vertex
+func (t FrozenTransition) Source() *Vertex { return newModelCloner().vertex(t.t.Source) }
+
+// Target returns a copy of the target vertex
+func (t FrozenTransition) Target() *Vertex { return newModelCloner().vertex(t.t.Target) }
+
+// Triggers returns copies of the triggers of the transition
+func (t FrozenTransition) Triggers() []*Trigger { return copyTriggers(t.t.Triggers) }
+
+// Guard returns a copy of the guard, or nil
+func (t FrozenTransition) Guard() *Constraint { return newModelCloner().constraint(t.t.Guard) }
+
+// Effect returns a copy of the effect, or nil
+func (t FrozenTransition) Effect() *Behavior { return newModelCloner().behavior(t.t.Effect) }
+
+// Features returns a copy of the feature flags of the transition
+func (t FrozenTransition) Features() []string { return slices.Clone(t.t.Features) }
+
+// Entities returns a copy of the names of the entities the transition uses
+func (t FrozenTransition) Entities() []string { return slices.Clone(t.t.Entities) }
+
+// Probability returns the probability annotation, if any
+func (t FrozenTransition) Probability() (float64, bool) {
+	if t.t.Probability == nil {
+		return 0, false
+	}
+	return *t.t.Probability, true
+}
+
+// Cost returns a copy of the cost annotation, or nil
+func (t FrozenTransition) Cost() *Cost { return clonePointer(t.t.Cost) }
+
+// Tags returns a copy of the tags of the transition
+func (t FrozenTransition) Tags() []string { return slices.Clone(t.t.Tags) }
+
+// Deprecated reports whether the transition is deprecated
+func (t FrozenTransition) Deprecated() bool { return t.t.Deprecated }
+
+// ReplacedBy returns the ID of the transition replacing a deprecated
+// transition
+func (t FrozenTransition) ReplacedBy() string { return t.t.ReplacedBy }
+
+// copyTriggers deep-copies triggers, keeping nil entries
+func copyTriggers(triggers []*Trigger) []*Trigger {
+	copied := make([]*Trigger, 0, len(triggers))
+	for _, trigger := range triggers {
+		copied = append(copied, newModelCloner().trigger(trigger))
+	}
+	return copied
+}
+
+// copyJSONValue deep-copies the maps and slices of a value decoded from JSON
+func copyJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyJSONValue(item)
+		}
+		return copied
+	case map[string]string:
+		return maps.Clone(v)
+	case []string:
+		return slices.Clone(v)
+	}
+	return value
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Metadata = map[string]interface{}{"owner": map[string]interface{}{"team": "payments"}}
+	frozen, err := Freeze(sm)
+	if err != nil {
+		t.Fatalf("Freeze() error = %v", err)
+	}
+
+	// Changes to the original do not reach the frozen copy
+	sm.Name = "Changed"
+	sm.Regions[0].States[0].Name = "changed"
+	sm.Metadata["owner"].(map[string]interface{})["team"] = "changed"
+
+	if frozen.Name() == "Changed" {
+		t.Errorf("Name() = %q, changed with the original", frozen.Name())
+	}
+	state := frozen.Regions()[0].States()[0]
+	if state.Name() == "changed" {
+		t.Error("state name changed with the original")
+	}
+	owner, _ := frozen.Metadata("owner")
+	if owner.(map[string]interface{})["team"] != "payments" {
+		t.Errorf("Metadata(owner) = %v, changed with the original", owner)
+	}
+
+	// Values handed out are copies
+	owner.(map[string]interface{})["team"] = "mutated"
+	if entry := state.Entry(); entry != nil {
+		entry.Specification = "mutated()"
+	}
+	for _, trigger := range frozen.Regions()[0].Transitions()[0].Triggers() {
+		trigger.Name = "mutated"
+		if trigger.Event != nil {
+			trigger.Event.Name = "mutated"
+		}
+	}
+	for _, event := range frozen.Events() {
+		event.Name = "mutated"
+	}
+	thawed := frozen.Thaw()
+	thawed.Regions[0].States[0].ID = "mutated"
+
+	again, _ := frozen.Metadata("owner")
+	if again.(map[string]interface{})["team"] != "payments" {
+		t.Error("Metadata() returned a shared value")
+	}
+	if entry := frozen.Regions()[0].States()[0].Entry(); entry != nil && entry.Specification == "mutated()" {
+		t.Error("Entry() returned a shared behavior")
+	}
+	for _, trigger := range frozen.Regions()[0].Transitions()[0].Triggers() {
+		if trigger.Name == "mutated" || (trigger.Event != nil && trigger.Event.Name == "mutated") {
+			t.Error("Triggers() returned shared triggers")
+		}
+	}
+	for _, event := range frozen.Events() {
+		if event.Name == "mutated" {
+			t.Error("Events() returned shared events")
+		}
+	}
+	if frozen.Regions()[0].States()[0].ID() == "mutated" {
+		t.Error("Thaw() returned the frozen machine itself")
+	}
+	if err := frozen.Thaw().Validate(); err != nil {
+		t.Errorf("Thaw().Validate() error = %v", err)
+	}
+}
+
+func TestFreeze_Invalid(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Version = ""
+	if frozen, err := Freeze(sm); err == nil || frozen != nil {
+		t.Fatalf("Freeze() = %v, %v; want a validation error", frozen, err)
+	}
+
+	strict := createValidStateMachine()
+	strict.Version = "1.0"
+	if _, err := FreezeInContext(strict, NewValidationContext().WithProfile(&ValidationProfile{Name: "semver", Versions: StrictVersionPolicy})); err == nil || !strings.Contains(err.Error(), "MAJOR.MINOR.PATCH") {
+		t.Errorf("FreezeInContext() error = %v, want a version error from the profile", err)
+	}
+}
+
+func TestFrozenStateMachine_Views(t *testing.T) {
+	sm := createValidStateMachine()
+	frozen, err := Freeze(sm)
+	if err != nil {
+		t.Fatalf("Freeze() error = %v", err)
+	}
+
+	if frozen.ID() != sm.ID || frozen.Version() != sm.Version || frozen.IsMethod() != sm.IsMethod || !frozen.CreatedAt().Equal(sm.CreatedAt) {
+		t.Errorf("frozen header %s does not match %s", frozen, sm)
+	}
+	region := frozen.Regions()[0]
+	if region.ID() != sm.Regions[0].ID || len(region.States()) != len(sm.Regions[0].States) ||
+		len(region.Vertices()) != len(sm.Regions[0].Vertices) || len(region.Transitions()) != len(sm.Regions[0].Transitions) {
+		t.Errorf("region view does not match the region")
+	}
+	transition := region.Transitions()[0]
+	original := sm.Regions[0].Transitions[0]
+	if transition.ID() != original.ID || transition.Kind() != original.Kind ||
+		transition.Source().ID != original.Source.ID || transition.Target().ID != original.Target.ID {
+		t.Errorf("transition view %s does not match %s", transition, original)
+	}
+	if _, ok := transition.Probability(); ok != (original.Probability != nil) {
+		t.Error("Probability() disagrees with the transition")
+	}
+
+	data, err := json.Marshal(frozen)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	expected, _ := json.Marshal(sm)
+	if string(data) != string(expected) {
+		t.Errorf("MarshalJSON() = %s, want %s", data, expected)
+	}
+}
+
+func TestFrozenRegion_VerticesAreIsolated(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Regions[0].Vertices[0].Tags = []string{"entry"}
+	sm.Regions[0].Vertices[0].Suppressions = []Suppression{{Rule: "pseudostate.initial.name"}}
+	if err := ResolveEndpoints(sm, RecordAdjacency()); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+	frozen, err := Freeze(sm)
+	if err != nil {
+		t.Fatalf("Freeze() error = %v", err)
+	}
+
+	for _, vertex := range frozen.Regions()[0].Vertices() {
+		if len(vertex.Outgoing()) > 0 || len(vertex.Incoming()) > 0 {
+			t.Errorf("vertex copy %s hands out transitions of the frozen machine", vertex.ID)
+		}
+		for i := range vertex.Tags {
+			vertex.Tags[i] = "changed"
+		}
+		for i := range vertex.Suppressions {
+			vertex.Suppressions[i].Rule = "changed"
+		}
+	}
+
+	initial := frozen.Regions()[0].Vertices()[0]
+	if initial.Tags[0] != "entry" || initial.Suppressions[0].Rule != "pseudostate.initial.name" {
+		t.Errorf("editing a vertex copy changed the frozen machine: %v, %v", initial.Tags, initial.Suppressions)
+	}
+	if got := frozen.Regions()[0].Transitions()[0].ID(); got != "t1" {
+		t.Errorf("frozen transition ID = %s, want t1", got)
+	}
+}
+
+func TestFrozenStateMachine_ConcurrentReads(t *testing.T) {
+	frozen, err := Freeze(createValidStateMachine())
+	if err != nil {
+		t.Fatalf("Freeze() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, region := range frozen.Regions() {
+				for _, state := range region.States() {
+					if entry := state.Entry(); entry != nil {
+						entry.Specification += "!"
+					}
+				}
+				for _, transition := range region.Transitions() {
+					_ = transition.Triggers()
+				}
+			}
+			_ = frozen.Thaw()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// GeneratorOptions bounds the state machines made by GenerateStateMachine.
+// Zero fields use the defaults in parentheses.
+type GeneratorOptions struct {
+	MaxStates  int // States per region (4)
+	MaxDepth   int // Nesting depth of composite states (2)
+	MaxRegions int // Orthogonal regions per composite state (2)
+	Events     int // Signal events in the event catalog (3)
+}
+
+// withDefaults fills in zero fields
+func (o GeneratorOptions) withDefaults() GeneratorOptions {
+	if o.MaxStates <= 0 {
+		o.MaxStates = 4
+	}
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = 2
+	}
+	if o.MaxRegions <= 0 {
+		o.MaxRegions = 2
+	}
+	if o.Events <= 0 {
+		o.Events = 3
+	}
+	return o
+}
+
+// GenerateStateMachine creates a random, valid state machine for property
+// and round-trip tests. The same seed and options always give the same
+// machine. Every region has an initial pseudostate and some states, which
+// may be composite or orthogonal, have entry and exit behaviors, and are
+// connected by triggered transitions with optional guards and effects;
+// regions may also have a final state.
+func GenerateStateMachine(seed uint64, opts GeneratorOptions) *StateMachine {
+	opts = opts.withDefaults()
+	g := &stateMachineGenerator{rng: rand.New(rand.NewPCG(seed, seed)), opts: opts}
+
+	id := fmt.Sprintf("generated-%d", seed)
+	sm := &StateMachine{ID: id, Name: fmt.Sprintf("Generated %d", seed), Version: "1.0"}
+	for i := 1; i <= opts.Events; i++ {
+		g.events = append(g.events, fmt.Sprintf("e%d", i))
+		sm.Events = append(sm.Events, &Event{ID: g.events[i-1], Name: fmt.Sprintf("E%d", i), Type: EventTypeSignal})
+	}
+	sm.Regions = []*Region{g.region(id+"-main", "Main", 1)}
+	return sm
+}
+
+// stateMachineGenerator holds the state of GenerateStateMachine
+type stateMachineGenerator struct {
+	rng    *rand.Rand
+	opts   GeneratorOptions
+	events []string
+}
+
+// region generates a region and, up to the maximum depth, nested regions
+func (g *stateMachineGenerator) region(id, name string, depth int) *Region {
+	region := &Region{ID: id, Name: name}
+	initial := &Vertex{ID: id + "-initial", Name: "initial", Type: "pseudostate"}
+	region.Vertices = append(region.Vertices, initial)
+
+	count := 1 + g.rng.IntN(g.opts.MaxStates)
+	for i := 1; i <= count; i++ {
+		stateID := fmt.Sprintf("%s-s%d", id, i)
+		state := &State{Vertex: Vertex{ID: stateID, Name: fmt.Sprintf("%s S%d", name, i), Type: "state"}, IsSimple: true}
+		if g.rng.IntN(3) == 0 {
+			state.Entry = &Behavior{ID: stateID + "-entry", Name: "enter", Specification: fmt.Sprintf("enter_%s()", diagramAlias(stateID))}
+		}
+		if g.rng.IntN(3) == 0 {
+			state.Exit = &Behavior{ID: stateID + "-exit", Name: "exit", Specification: fmt.Sprintf("exit_%s()", diagramAlias(stateID))}
+		}
+		if depth < g.opts.MaxDepth && g.rng.IntN(3) == 0 {
+			regions := 1 + g.rng.IntN(g.opts.MaxRegions)
+			state.IsSimple, state.IsComposite, state.IsOrthogonal = false, true, regions > 1
+			for j := 1; j <= regions; j++ {
+				state.Regions = append(state.Regions, g.region(fmt.Sprintf("%s-r%d", stateID, j), fmt.Sprintf("%s R%d", state.Name, j), depth+1))
+			}
+		}
+		region.States = append(region.States, state)
+	}
+
+	region.Transitions = append(region.Transitions, &Transition{
+		ID:     id + "-t0",
+		Source: initial,
+		Target: &region.States[0].Vertex,
+		Kind:   TransitionKindExternal,
+	})
+	var final *Vertex
+	if g.rng.IntN(2) == 0 {
+		final = &Vertex{ID: id + "-final", Name: "final", Type: "finalstate"}
+		region.Vertices = append(region.Vertices, final)
+	}
+
+	for _, state := range region.States {
+		for range g.rng.IntN(3) {
+			target := &region.States[g.rng.IntN(len(region.States))].Vertex
+			if final != nil && g.rng.IntN(4) == 0 {
+				target = final
+			}
+			region.Transitions = append(region.Transitions, g.transition(fmt.Sprintf("%s-t%d", id, len(region.Transitions)), &state.Vertex, target))
+		}
+	}
+	return region
+}
+
+// transition generates a triggered transition with an optional guard and
+// effect; self transitions are internal
+func (g *stateMachineGenerator) transition(id string, source, target *Vertex) *Transition {
+	event := g.events[g.rng.IntN(len(g.events))]
+	transition := &Transition{
+		ID:       id,
+		Source:   source,
+		Target:   target,
+		Kind:     TransitionKindExternal,
+		Triggers: []*Trigger{{ID: id + "-trigger", Name: event, EventID: event}},
+	}
+	if source == target {
+		transition.Kind = TransitionKindInternal
+	}
+	if g.rng.IntN(3) == 0 {
+		transition.Guard = &Constraint{ID: id + "-guard", Specification: fmt.Sprintf("x > %d", g.rng.IntN(10))}
+	}
+	if g.rng.IntN(3) == 0 {
+		transition.Effect = &Behavior{ID: id + "-effect", Name: "effect", Specification: fmt.Sprintf("count_%s++", diagramAlias(id))}
+	}
+	return transition
+}
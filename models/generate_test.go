@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestGenerateStateMachine(t *testing.T) {
+	for seed := uint64(0); seed < 100; seed++ {
+		sm := GenerateStateMachine(seed, GeneratorOptions{})
+		if err := sm.Validate(); err != nil {
+			t.Fatalf("seed %d: generated state machine is invalid: %v", seed, err)
+		}
+		if changes := Diff(sm, GenerateStateMachine(seed, GeneratorOptions{})); len(changes) > 0 {
+			t.Fatalf("seed %d: generation is not deterministic: %v", seed, changes)
+		}
+	}
+}
+
+func TestGenerateStateMachine_Options(t *testing.T) {
+	opts := GeneratorOptions{MaxStates: 2, MaxDepth: 1, Events: 1}
+	for seed := uint64(0); seed < 50; seed++ {
+		sm := GenerateStateMachine(seed, opts)
+		if len(sm.Events) != 1 {
+			t.Fatalf("seed %d: events = %d, want 1", seed, len(sm.Events))
+		}
+		main := sm.Regions[0]
+		if len(main.States) > 2 {
+			t.Errorf("seed %d: states = %d, want at most 2", seed, len(main.States))
+		}
+		for _, state := range main.States {
+			if len(state.Regions) > 0 {
+				t.Errorf("seed %d: state %s is composite beyond the maximum depth", seed, state.ID)
+			}
+		}
+	}
+}
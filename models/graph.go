@@ -0,0 +1,92 @@
+package models
+
+import "slices"
+
+// reachableVertices returns the IDs of the vertices that can be reached from
+// the initial pseudostates of the top-level regions and the state machine's
+// connection points. Entering a composite state also enters the initial
+// pseudostates of its regions.
+func reachableVertices(sm *StateMachine) map[string]bool {
+	reached := make(map[string]bool)
+	if sm == nil {
+		return reached
+	}
+
+	outgoing := make(map[string][]string)
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil {
+			outgoing[transition.Source.ID] = append(outgoing[transition.Source.ID], transition.Target.ID)
+		}
+	})
+
+	nestedInitials := make(map[string][]string)
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			for _, sub := range state.Regions {
+				if sub != nil {
+					nestedInitials[state.ID] = append(nestedInitials[state.ID], regionInitialIDs(contentOf(sub))...)
+				}
+			}
+		}
+	})
+
+	var queue []string
+	for _, region := range sm.Regions {
+		if region != nil {
+			queue = append(queue, regionInitialIDs(contentOf(region))...)
+		}
+	}
+	for _, cp := range sm.ConnectionPoints {
+		if cp != nil && cp.Kind == PseudostateKindEntryPoint {
+			queue = append(queue, cp.ID)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if reached[id] {
+			continue
+		}
+		reached[id] = true
+		queue = append(queue, outgoing[id]...)
+		queue = append(queue, nestedInitials[id]...)
+	}
+	return reached
+}
+
+// regionInitialIDs returns the IDs of the initial pseudostates in the region
+func regionInitialIDs(region *Region) []string {
+	var ids []string
+	for _, vertex := range region.Vertices {
+		if vertex != nil && region.isInitialPseudostate(vertex) {
+			ids = append(ids, vertex.ID)
+		}
+	}
+	return ids
+}
+
+// regionReaches returns the IDs of the vertices reachable from the given
+// vertices over the transitions declared in the region
+func regionReaches(region *Region, from []string) map[string]bool {
+	outgoing := make(map[string][]string)
+	for _, transition := range region.Transitions {
+		if transition != nil && transition.Source != nil && transition.Target != nil {
+			outgoing[transition.Source.ID] = append(outgoing[transition.Source.ID], transition.Target.ID)
+		}
+	}
+	reached := make(map[string]bool)
+	queue := slices.Clone(from)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if !reached[id] {
+			reached[id] = true
+			queue = append(queue, outgoing[id]...)
+		}
+	}
+	return reached
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// graphMLDocument is the subset of GraphML read by ImportGraphML
+type graphMLDocument struct {
+	Keys  []graphMLKey  `xml:"key"`
+	Graph *graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID         string `xml:"id,attr"`
+	For        string `xml:"for,attr"`
+	Name       string `xml:"attr.name,attr"`
+	YFilesType string `xml:"yfiles.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphMLData `xml:"data"`
+	Nodes []graphMLNode `xml:"node"`
+	Edges []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphMLData `xml:"data"`
+	Graph *graphMLGraph `xml:"graph"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Inner []byte `xml:",innerxml"`
+}
+
+// ImportGraphML reads a state diagram drawn in yEd or draw.io and saved as
+// GraphML. Node labels are taken from yEd node graphics or from data keys
+// named "label" or "name", rich text labels are reduced to plain text, and
+// nested graphs become composite states. Labels are mapped to states,
+// pseudostates and transitions by naming convention; see the report for the
+// elements that could not be mapped unambiguously.
+func ImportGraphML(r io.Reader) (*StateMachine, *ImportReport, error) {
+	var doc graphMLDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse GraphML: %w", err)
+	}
+	if doc.Graph == nil {
+		return nil, nil, fmt.Errorf("GraphML document contains no graph")
+	}
+
+	labelKeys := make(map[string]bool)
+	for _, key := range doc.Keys {
+		switch {
+		case key.YFilesType == "nodegraphics" || key.YFilesType == "edgegraphics":
+			labelKeys[key.ID] = true
+		case strings.EqualFold(key.Name, "label") || strings.EqualFold(key.Name, "name"):
+			labelKeys[key.ID] = true
+		}
+	}
+
+	var nodes []diagramNode
+	var edges []diagramEdge
+	type pendingGraph struct {
+		graph  *graphMLGraph
+		parent string
+	}
+	stack := []pendingGraph{{graph: doc.Graph}}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, node := range next.graph.Nodes {
+			label, shape := graphMLLabel(node.Data, labelKeys)
+			nodes = append(nodes, diagramNode{ID: node.ID, Label: label, Parent: next.parent, Shape: shape})
+			if node.Graph != nil {
+				stack = append(stack, pendingGraph{graph: node.Graph, parent: node.ID})
+			}
+		}
+		for _, edge := range next.graph.Edges {
+			label, _ := graphMLLabel(edge.Data, labelKeys)
+			edges = append(edges, diagramEdge{ID: edge.ID, Source: edge.Source, Target: edge.Target, Label: label})
+		}
+	}
+
+	id := doc.Graph.ID
+	if id == "" {
+		id = "graphml"
+	}
+	name, _ := graphMLLabel(doc.Graph.Data, labelKeys)
+	report := &ImportReport{Format: "GraphML"}
+	return buildDiagramMachine(id, displayName(name, id), nodes, edges, report), report, nil
+}
+
+// graphMLLabel extracts the label and shape of a node or edge from its data
+// elements. yEd stores them in NodeLabel, EdgeLabel and Shape elements;
+// other tools store the label as the text of a data element.
+func graphMLLabel(data []graphMLData, labelKeys map[string]bool) (label, shape string) {
+	var plain []string
+	for _, d := range data {
+		if !labelKeys[d.Key] {
+			continue
+		}
+		var labels []string
+		var text strings.Builder
+		inLabel, nested := false, false
+		decoder := xml.NewDecoder(bytes.NewReader(d.Inner))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				nested = true
+				switch t.Name.Local {
+				case "NodeLabel", "EdgeLabel":
+					inLabel = true
+					text.Reset()
+				case "Shape":
+					for _, attr := range t.Attr {
+						if attr.Name.Local == "type" {
+							shape = strings.ToLower(attr.Value)
+						}
+					}
+				}
+			case xml.EndElement:
+				if inLabel && (t.Name.Local == "NodeLabel" || t.Name.Local == "EdgeLabel") {
+					inLabel = false
+					if value := stripMarkup(text.String()); value != "" {
+						labels = append(labels, value)
+					}
+				}
+			case xml.CharData:
+				if inLabel {
+					text.Write(t)
+				}
+			}
+		}
+		if len(labels) > 0 {
+			return strings.Join(labels, " "), shape
+		}
+		if !nested {
+			if value := stripMarkup(xmlText(d.Inner)); value != "" {
+				plain = append(plain, value)
+			}
+		}
+	}
+	return strings.Join(plain, " "), shape
+}
+
+// xmlText returns the unescaped character data of an XML fragment
+func xmlText(fragment []byte) string {
+	var text strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(fragment))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return text.String()
+		}
+		if data, ok := token.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// yedDiagram is a trimmed yEd export: an unlabeled ellipse as the initial
+// pseudostate, a group node with a nested graph and labeled edges
+const yedDiagram = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+  <key for="node" id="d6" yfiles.type="nodegraphics"/>
+  <key for="edge" id="d10" yfiles.type="edgegraphics"/>
+  <graph id="door" edgedefault="directed">
+    <node id="n0">
+      <data key="d6"><y:ShapeNode><y:Shape type="ellipse"/><y:NodeLabel/></y:ShapeNode></data>
+    </node>
+    <node id="n1">
+      <data key="d6"><y:ShapeNode><y:NodeLabel>Closed</y:NodeLabel></y:ShapeNode></data>
+    </node>
+    <node id="n2">
+      <data key="d6"><y:ProxyAutoBoundsNode><y:Realizers><y:GroupNode><y:NodeLabel>Open</y:NodeLabel></y:GroupNode></y:Realizers></y:ProxyAutoBoundsNode></data>
+      <graph id="n2:">
+        <node id="n2::n0"><data key="d6"><y:ShapeNode><y:NodeLabel>start</y:NodeLabel></y:ShapeNode></data></node>
+        <node id="n2::n1"><data key="d6"><y:ShapeNode><y:NodeLabel>Ajar</y:NodeLabel></y:ShapeNode></data></node>
+      </graph>
+    </node>
+    <node id="n3">
+      <data key="d6"><y:ShapeNode><y:NodeLabel>final</y:NodeLabel></y:ShapeNode></data>
+    </node>
+    <edge id="e0" source="n0" target="n1"/>
+    <edge id="e1" source="n1" target="n2">
+      <data key="d10"><y:PolyLineEdge><y:EdgeLabel>open [unlocked] / chime</y:EdgeLabel></y:PolyLineEdge></data>
+    </edge>
+    <edge id="e2" source="n2" target="n3">
+      <data key="d10"><y:PolyLineEdge><y:EdgeLabel>remove</y:EdgeLabel></y:PolyLineEdge></data>
+    </edge>
+    <edge id="e3" source="n2::n0" target="n2::n1"/>
+  </graph>
+</graphml>`
+
+// drawioDiagram uses plain data keys with HTML labels, as draw.io's
+// GraphML export does
+const drawioDiagram = `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="all" attr.name="label" attr.type="string"/>
+  <graph id="light">
+    <data key="label">Light switch</data>
+    <node id="a"><data key="label">[*]</data></node>
+    <node id="b"><data key="label">&lt;b&gt;Off&lt;/b&gt;</data></node>
+    <node id="c"><data key="label">On</data></node>
+    <node id="d"><data key="label"></data></node>
+    <edge id="e1" source="a" target="b"/>
+    <edge id="e2" source="b" target="c"><data key="label">toggle, power&lt;br&gt;on</data></edge>
+    <edge id="e3" source="c" target="zz"><data key="label">toggle</data></edge>
+  </graph>
+</graphml>`
+
+func TestImportGraphML_yEd(t *testing.T) {
+	sm, report, err := ImportGraphML(strings.NewReader(yedDiagram))
+	if err != nil {
+		t.Fatalf("ImportGraphML() error = %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("report = %s, want no issues", report)
+	}
+	if sm.ID != "door" || len(sm.Regions) != 1 {
+		t.Fatalf("sm = %s", sm)
+	}
+
+	main := sm.Regions[0]
+	if len(main.States) != 2 || main.States[0].Name != "Closed" || main.States[1].Name != "Open" {
+		t.Fatalf("states = %v", main.States)
+	}
+	open := main.States[1]
+	if !open.IsComposite || len(open.Regions) != 1 || len(open.Regions[0].States) != 1 || open.Regions[0].States[0].Name != "Ajar" {
+		t.Errorf("Open = %+v, want a composite state containing Ajar", open)
+	}
+	if got := vertexPseudostateKind(open.Regions[0].Vertices[0]); got != PseudostateKindInitial {
+		t.Errorf("nested start kind = %q, want initial", got)
+	}
+	if got := vertexPseudostateKind(main.Vertices[0]); got != PseudostateKindInitial {
+		t.Errorf("unlabeled ellipse kind = %q, want initial", got)
+	}
+	if main.Vertices[1].Type != "finalstate" {
+		t.Errorf("final node type = %q, want finalstate", main.Vertices[1].Type)
+	}
+
+	openDoor := main.Transitions[1]
+	if openDoor.Triggers[0].EventID != "open" || openDoor.Guard.Specification != "unlocked" || openDoor.Effect.Name != "chime" {
+		t.Errorf("transition e1 = %+v", openDoor)
+	}
+	if len(open.Regions[0].Transitions) != 1 {
+		t.Errorf("nested transitions = %d, want 1", len(open.Regions[0].Transitions))
+	}
+	if len(sm.Events) != 2 {
+		t.Errorf("events = %d, want open and remove", len(sm.Events))
+	}
+
+	in := startInterpreter(t, sm, InterpreterHooks{})
+	if err := in.Send("open"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !in.IsActive("n2::n1") {
+		t.Errorf("Configuration() = %v, want the imported model to execute", in.Configuration())
+	}
+}
+
+func TestImportGraphML_DrawIO(t *testing.T) {
+	sm, report, err := ImportGraphML(strings.NewReader(drawioDiagram))
+	if err != nil {
+		t.Fatalf("ImportGraphML() error = %v", err)
+	}
+	if sm.Name != "Light switch" {
+		t.Errorf("Name = %q, want the graph label", sm.Name)
+	}
+	main := sm.Regions[0]
+	if main.States[0].Name != "Off" {
+		t.Errorf("state name = %q, want markup removed", main.States[0].Name)
+	}
+	if got := vertexPseudostateKind(main.Vertices[0]); got != PseudostateKindInitial {
+		t.Errorf("[*] kind = %q, want initial", got)
+	}
+	if triggers := main.Transitions[1].Triggers; len(triggers) != 2 || triggers[1].EventID != "power on" {
+		t.Errorf("triggers = %v, want toggle and power on", triggers)
+	}
+
+	wantIssues := []string{"node 'd'", "has no label", "edge 'e3'", "skipped"}
+	for _, want := range wantIssues {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestImportGraphML_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "malformed XML", input: "<graphml><graph>", want: "failed to parse GraphML"},
+		{name: "no graph", input: `<graphml xmlns="http://graphml.graphdrawing.org/xmlns"/>`, want: "contains no graph"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ImportGraphML(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ImportGraphML() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportGraphML_NodeWithoutID(t *testing.T) {
+	done := make(chan struct{})
+	var report *ImportReport
+	var err error
+	go func() {
+		defer close(done)
+		_, report, err = ImportGraphML(strings.NewReader(`<graphml><graph id="g"><node/></graph></graphml>`))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ImportGraphML() did not return for a node without an ID")
+	}
+	if err != nil {
+		t.Fatalf("ImportGraphML() error = %v", err)
+	}
+	if !strings.Contains(report.String(), "has no ID") {
+		t.Errorf("report does not mention the node without an ID:\n%s", report)
+	}
+}
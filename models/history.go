@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// HistoryTarget is where a transition to a history pseudostate leads in a
+// configuration
+type HistoryTarget struct {
+	History string          `json:"history"` // ID of the history pseudostate
+	Kind    PseudostateKind `json:"kind"`    // PseudostateKindShallowHistory or PseudostateKindDeepHistory
+
+	// States lists the vertices to enter, outermost first. With a memory,
+	// shallow history re-enters the region's last active state, whose own
+	// regions are entered by default, and deep history also re-enters the
+	// last active states of the regions nested in it, depth first; nested
+	// regions without a memory are entered by default.
+	States []string `json:"states"`
+
+	// Default reports that the region has no memory: States are then the
+	// targets of the history pseudostate's default transitions, or of the
+	// region's initial pseudostate if it has none, entered like any target
+	Default bool `json:"default,omitempty"`
+}
+
+// RecordExit updates the configuration for the exit of an active state or
+// final state, as the Interpreter does: the state and its active substates
+// become inactive and each region they were active in remembers them as its
+// history. Regions remember their last active vertex whether or not they
+// have a history pseudostate; PruneHistory drops memories no history
+// pseudostate reads.
+func (c *MachineConfiguration) RecordExit(sm *StateMachine, stateID string) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	model := newConfigurationModel(sm)
+	regionID, known := model.regionOf[stateID]
+	if !known {
+		return fmt.Errorf("'%s' is not a state or final state of state machine '%s'%s", stateID, sm.ID, didYouMean(stateID, model.regionOf))
+	}
+	if c.Active[regionID] != stateID {
+		return fmt.Errorf("state '%s' is not active", stateID)
+	}
+	if c.History == nil {
+		c.History = make(map[string]string)
+	}
+
+	var exit func(id string)
+	exit = func(id string) {
+		if state := model.states[id]; state != nil {
+			for _, region := range nonNilRegions(state.Regions) {
+				if active, ok := c.Active[region.ID]; ok {
+					exit(active)
+				}
+			}
+		}
+		region := model.regionOf[id]
+		delete(c.Active, region)
+		c.History[region] = id
+	}
+	exit(stateID)
+	return nil
+}
+
+// ResolveHistoryTarget returns what entering the shallow or deep history
+// pseudostate with the given ID leads to in this configuration, following
+// the same rules as the Interpreter
+func (c *MachineConfiguration) ResolveHistoryTarget(sm *StateMachine, historyID string) (*HistoryTarget, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	model := newConfigurationModel(sm)
+	region := model.pseudostates[historyID]
+	var kind PseudostateKind
+	if region != nil {
+		for _, vertex := range region.Vertices {
+			if vertex != nil && vertex.ID == historyID {
+				kind = pseudostateKindOf(vertex)
+			}
+		}
+	}
+	if kind != PseudostateKindShallowHistory && kind != PseudostateKindDeepHistory {
+		return nil, fmt.Errorf("'%s' is not a history pseudostate of state machine '%s'%s", historyID, sm.ID, didYouMean(historyID, model.pseudostates))
+	}
+
+	target := &HistoryTarget{History: historyID, Kind: kind}
+	last := c.History[region.ID]
+	if last == "" || !model.restsIn(region, last) {
+		target.Default = true
+		index := IndexTransitions(sm)
+		target.States = transitionTargets(index, historyID)
+		if len(target.States) == 0 {
+			for _, initial := range regionInitialIDs(region) {
+				target.States = append(target.States, transitionTargets(index, initial)...)
+			}
+		}
+		return target, nil
+	}
+
+	var restore func(id string)
+	restore = func(id string) {
+		target.States = append(target.States, id)
+		state := model.states[id]
+		if kind != PseudostateKindDeepHistory || state == nil {
+			return
+		}
+		for _, nested := range nonNilRegions(state.Regions) {
+			if remembered := c.History[nested.ID]; model.restsIn(nested, remembered) {
+				restore(remembered)
+			}
+		}
+	}
+	restore(last)
+	return target, nil
+}
+
+// PruneHistory drops the history memories of regions that no history
+// pseudostate of sm reads: a region's memory is kept if the region has a
+// history pseudostate or is nested in a region with a deep history
+// pseudostate. It returns the IDs of the regions whose memory was dropped.
+func (c *MachineConfiguration) PruneHistory(sm *StateMachine) []string {
+	if sm == nil {
+		return nil
+	}
+	read := make(map[string]bool)
+	deep := make(map[*Region]bool) // Regions nested in a region with deep history
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, vertex := range region.Vertices {
+			switch pseudostateKindOf(vertex) {
+			case PseudostateKindShallowHistory:
+				read[region.ID] = true
+			case PseudostateKindDeepHistory:
+				read[region.ID] = true
+				deep[region] = true
+			}
+		}
+		if deep[region] {
+			read[region.ID] = true
+			for _, state := range region.States {
+				if state != nil {
+					for _, nested := range nonNilRegions(state.Regions) {
+						deep[nested] = true
+					}
+				}
+			}
+		}
+	})
+
+	var dropped []string
+	for _, regionID := range slices.Sorted(maps.Keys(c.History)) {
+		if !read[regionID] {
+			delete(c.History, regionID)
+			dropped = append(dropped, regionID)
+		}
+	}
+	return dropped
+}
+
+// transitionTargets returns the IDs of the targets of the transitions
+// leaving a vertex, in model order
+func transitionTargets(index *TransitionIndex, sourceID string) []string {
+	var targets []string
+	for _, transition := range index.Outgoing(sourceID) {
+		if transition.Target != nil {
+			targets = append(targets, transition.Target.ID)
+		}
+	}
+	return targets
+}
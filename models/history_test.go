@@ -0,0 +1,169 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newHistoryMachine wraps the player machine in a "session" state that
+// leaves for "paused" on "pause". Paused returns through a deep history
+// pseudostate of the player's main region on "resume" and through a shallow
+// one on "back".
+func newHistoryMachine() *StateMachine {
+	sm := newPlayerMachine()
+	main := sm.Regions[0]
+	deep, shallow := execPseudostate("deep", "deepHistory"), execPseudostate("shallow", "shallowHistory")
+	main.Vertices = append(main.Vertices, deep, shallow)
+
+	initial := execPseudostate("session-initial", "initial")
+	session := execState("session", main)
+	paused := execState("paused")
+	sm.Regions = []*Region{{
+		ID:       "outer",
+		States:   []*State{session, paused},
+		Vertices: []*Vertex{initial},
+		Transitions: []*Transition{
+			execTransition("session-start", initial, &session.Vertex),
+			execTransition("pause", &session.Vertex, &paused.Vertex, "pause"),
+			execTransition("resume", &paused.Vertex, deep, "resume"),
+			execTransition("back", &paused.Vertex, shallow, "back"),
+		},
+	}}
+	return sm
+}
+
+func TestMachineConfiguration_ResolveHistoryTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []string // Sent before taking the snapshot
+		history string
+		enter   string // Event entering the history pseudostate in the interpreter
+		want    []string
+		deflt   bool
+	}{
+		{name: "deep history restores nested states", events: []string{"play", "loaded", "pause"}, history: "deep", enter: "resume", want: []string{"playing", "streaming", "buffering"}},
+		{name: "shallow history restores the region's state", events: []string{"play", "loaded", "pause"}, history: "shallow", enter: "back", want: []string{"playing"}},
+		{name: "shallow history of a simple state", events: []string{"pause"}, history: "shallow", enter: "back", want: []string{"idle"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newHistoryMachine()
+			in := startInterpreter(t, sm, InterpreterHooks{})
+			for _, event := range tt.events {
+				if err := in.Send(event); err != nil {
+					t.Fatalf("Send(%s) error = %v", event, err)
+				}
+			}
+
+			target, err := in.Snapshot().ResolveHistoryTarget(sm, tt.history)
+			if err != nil {
+				t.Fatalf("ResolveHistoryTarget() error = %v", err)
+			}
+			if !slices.Equal(target.States, tt.want) || target.Default != tt.deflt {
+				t.Errorf("ResolveHistoryTarget() = %+v, want states %v, default %v", target, tt.want, tt.deflt)
+			}
+
+			// The interpreter enters the same states
+			if err := in.Send(tt.enter); err != nil {
+				t.Fatalf("Send(%s) error = %v", tt.enter, err)
+			}
+			for _, id := range tt.want {
+				if !in.IsActive(id) {
+					t.Errorf("interpreter did not enter %s: %v", id, in.Configuration())
+				}
+			}
+		})
+	}
+
+	t.Run("no memory follows the initial pseudostate", func(t *testing.T) {
+		c := &MachineConfiguration{MachineID: "player", Active: map[string]string{"outer": "paused"}}
+		target, err := c.ResolveHistoryTarget(newHistoryMachine(), "deep")
+		if err != nil || !target.Default || !slices.Equal(target.States, []string{"idle"}) {
+			t.Errorf("ResolveHistoryTarget() = %+v, %v", target, err)
+		}
+	})
+
+	t.Run("no memory follows the default transition", func(t *testing.T) {
+		sm := newHistoryMachine()
+		main := sm.Regions[0].States[0].Regions[0]
+		main.Transitions = append(main.Transitions, execTransition("deep-default", main.Vertices[1], &main.States[1].Vertex))
+		target, err := (&MachineConfiguration{MachineID: "player"}).ResolveHistoryTarget(sm, "deep")
+		if err != nil || !target.Default || !slices.Equal(target.States, []string{"playing"}) {
+			t.Errorf("ResolveHistoryTarget() = %+v, %v", target, err)
+		}
+	})
+
+	t.Run("not a history pseudostate", func(t *testing.T) {
+		c := &MachineConfiguration{MachineID: "player"}
+		if _, err := c.ResolveHistoryTarget(newHistoryMachine(), "initial"); err == nil || !strings.Contains(err.Error(), "'initial' is not a history pseudostate") {
+			t.Errorf("ResolveHistoryTarget() error = %v", err)
+		}
+	})
+}
+
+func TestMachineConfiguration_RecordExit(t *testing.T) {
+	sm := newHistoryMachine()
+	in := startInterpreter(t, sm, InterpreterHooks{})
+	for _, event := range []string{"play", "loaded", "ready"} {
+		in.Send(event)
+	}
+	c := in.Snapshot()
+	if err := c.RecordExit(sm, "session"); err != nil {
+		t.Fatalf("RecordExit() error = %v", err)
+	}
+
+	// Exiting session as the interpreter does on "pause", minus entering paused
+	in.Send("pause")
+	want := in.Snapshot()
+	delete(want.Active, "outer")
+	if !maps.Equal(c.Active, want.Active) || !maps.Equal(c.History, want.History) {
+		t.Errorf("RecordExit() = active %v, history %v; want %v, %v", c.Active, c.History, want.Active, want.History)
+	}
+
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{state: "session", want: "state 'session' is not active"},
+		{state: "stoped", want: "'stoped' is not a state or final state of state machine 'player'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			if err := c.RecordExit(sm, tt.state); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RecordExit() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachineConfiguration_PruneHistory(t *testing.T) {
+	history := map[string]string{"main": "playing", "audio": "streaming", "video": "rendering"}
+	tests := []struct {
+		name        string
+		keep        string // ID of the history pseudostate to keep
+		wantDropped []string
+	}{
+		{name: "deep history reads nested regions", keep: "deep"},
+		{name: "shallow history reads its region only", keep: "shallow", wantDropped: []string{"audio", "video"}},
+		{name: "no history pseudostate", wantDropped: []string{"audio", "main", "video"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newHistoryMachine()
+			main := sm.Regions[0].States[0].Regions[0]
+			main.Vertices = slices.DeleteFunc(main.Vertices, func(vertex *Vertex) bool {
+				return (vertex.ID == "deep" || vertex.ID == "shallow") && vertex.ID != tt.keep
+			})
+			c := &MachineConfiguration{MachineID: "player", History: maps.Clone(history)}
+			if dropped := c.PruneHistory(sm); !slices.Equal(dropped, tt.wantDropped) {
+				t.Errorf("PruneHistory() = %v, want %v", dropped, tt.wantDropped)
+			}
+			if len(c.History) != len(history)-len(tt.wantDropped) {
+				t.Errorf("History = %v after pruning", c.History)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// htmlExporter exports a standalone HTML page for design reviews. The page
+// shows the svg exporter's drawing in a viewer that needs no network access:
+//   - the wheel zooms, dragging pans and the toolbar resets the view
+//   - double-clicking a composite state collapses or expands its contents
+//   - clicking an element shows its details from the embedded JSON model
+//     and the validation findings attached to it; elements with findings
+//     are highlighted by their worst severity
+//
+// Findings come from validating with the profile named by the "profile"
+// property ("default", "strict" or "lenient"; default if empty). The
+// "direction" property applies as for the svg exporter.
+type htmlExporter struct{}
+
+func (htmlExporter) Name() string { return "html" }
+
+// htmlFinding is a validation finding attached to the element it concerns,
+// the state machine itself if no drawn element contains it
+type htmlFinding struct {
+	Element  string   `json:"element"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Clause   string   `json:"clause,omitempty"`
+}
+
+func (htmlExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	profile, ok := profileNamed(opts.Properties["profile"])
+	if !ok {
+		return fmt.Errorf("unknown validation profile '%s'", opts.Properties["profile"])
+	}
+	var image strings.Builder
+	if err := (svgExporter{}).Export(sm, &image, opts); err != nil {
+		return err
+	}
+	// json.Marshal escapes <, > and &, so the JSON cannot end the script
+	// elements holding it
+	model, err := json.Marshal(sm)
+	if err != nil {
+		return fmt.Errorf("failed to encode state machine: %w", err)
+	}
+	findings, err := json.Marshal(htmlFindings(sm, profile))
+	if err != nil {
+		return fmt.Errorf("failed to encode findings: %w", err)
+	}
+
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&out, "<title>%s</title>\n", xmlEscape(opts.title(sm)))
+	out.WriteString("<style>\n" + htmlViewerStyle + "</style>\n</head>\n<body>\n")
+	fmt.Fprintf(&out, "<header><h1>%s</h1><span id=\"summary\"></span>", xmlEscape(opts.title(sm)))
+	out.WriteString(`<nav><button id="zoom-in" title="Zoom in">+</button><button id="zoom-out" title="Zoom out">&#8722;</button><button id="fit" title="Fit to window">Fit</button><button id="expand" title="Expand all composite states">Expand all</button></nav></header>` + "\n")
+	out.WriteString("<main><div id=\"canvas\">\n" + image.String() + "</div>\n")
+	out.WriteString("<aside id=\"details\"><p class=\"hint\">Click an element to see its details and findings; double-click a composite state to collapse it.</p></aside></main>\n")
+	fmt.Fprintf(&out, "<script type=\"application/json\" id=\"model\">%s</script>\n", model)
+	fmt.Fprintf(&out, "<script type=\"application/json\" id=\"findings\">%s</script>\n", findings)
+	out.WriteString("<script>\n" + htmlViewerScript + "</script>\n</body>\n</html>\n")
+
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// htmlFindings validates the state machine and attaches every finding to
+// the innermost drawn element (region, state, vertex or transition) on its
+// path
+func htmlFindings(sm *StateMachine, profile *ValidationProfile) []htmlFinding {
+	elements := make(map[string]string)
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		elements[path] = region.ID
+		for i, state := range region.States {
+			if state != nil {
+				elements[fmt.Sprintf("%s.States[%d]", path, i)] = state.ID
+			}
+		}
+		for i, vertex := range region.Vertices {
+			if vertex != nil {
+				elements[fmt.Sprintf("%s.Vertices[%d]", path, i)] = vertex.ID
+			}
+		}
+		for i, transition := range region.Transitions {
+			if transition != nil {
+				elements[fmt.Sprintf("%s.Transitions[%d]", path, i)] = transition.ID
+			}
+		}
+	})
+
+	errs := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext().WithProfile(profile), errs)
+	errs.Sort()
+	findings := []htmlFinding{}
+	add := func(list []*ValidationError, severity Severity) {
+		for _, finding := range list {
+			element := sm.ID
+			for n := len(finding.Path); n > 0; n-- {
+				if id, exists := elements[strings.Join(finding.Path[:n], ".")]; exists {
+					element = id
+					break
+				}
+			}
+			findings = append(findings, htmlFinding{
+				Element:  element,
+				Severity: severity,
+				Message:  finding.Message,
+				Path:     strings.Join(finding.Path, "."),
+				Clause:   finding.Clause,
+			})
+		}
+	}
+	add(errs.Errors, SeverityError)
+	add(errs.Warnings, SeverityWarning)
+	add(errs.Infos, SeverityInfo)
+	return findings
+}
+
+// profileNamed returns the built-in validation profile with the name,
+// DefaultProfile for ""
+func profileNamed(name string) (*ValidationProfile, bool) {
+	if name == "" {
+		return DefaultProfile, true
+	}
+	for _, profile := range []*ValidationProfile{DefaultProfile, StrictProfile, LenientProfile} {
+		if strings.EqualFold(profile.Name, name) {
+			return profile, true
+		}
+	}
+	return nil, false
+}
+
+// htmlViewerStyle styles the viewer page
+const htmlViewerStyle = `html, body { margin: 0; height: 100%; font-family: sans-serif; }
+body { display: flex; flex-direction: column; }
+header { display: flex; align-items: center; gap: 1em; padding: 0.5em 1em; border-bottom: 1px solid #ccc; }
+header h1 { font-size: 1.2em; margin: 0; }
+#summary { flex: 1; color: #555; }
+#summary .error { color: #c00; }
+#summary .warning { color: #b60; }
+nav button { margin-left: 0.25em; }
+main { flex: 1; display: flex; min-height: 0; }
+#canvas { flex: 1; overflow: hidden; cursor: grab; }
+#canvas.panning { cursor: grabbing; }
+#canvas svg { width: 100%; height: 100%; user-select: none; }
+#details { width: 22em; overflow: auto; padding: 0 1em; border-left: 1px solid #ccc; }
+#details .hint { color: #777; }
+#details li.error { color: #c00; }
+#details li.warning { color: #b60; }
+#details li.info { color: #06c; }
+[data-id] { cursor: pointer; }
+.selected rect, .selected circle, .selected polygon, .selected line, .selected path { stroke: #06c; stroke-width: 3; }
+.finding-error > rect, .finding-error > circle, .finding-error > polygon, .finding-error > line, .finding-error > path { stroke: #c00; stroke-width: 2.5; }
+.finding-warning > rect, .finding-warning > circle, .finding-warning > polygon, .finding-warning > line, .finding-warning > path { stroke: #e80; stroke-width: 2.5; }
+.collapsed > rect { stroke-dasharray: 4 3; fill: #eee; }
+.hidden { display: none; }
+`
+
+// htmlViewerScript implements the viewer; it reads the model and findings
+// from the page's JSON script elements
+const htmlViewerScript = `(function () {
+  "use strict";
+  const model = JSON.parse(document.getElementById("model").textContent);
+  const findings = JSON.parse(document.getElementById("findings").textContent);
+  const canvas = document.getElementById("canvas");
+  const svg = canvas.querySelector("svg");
+  const details = document.getElementById("details");
+  svg.removeAttribute("width");
+  svg.removeAttribute("height");
+
+  // Index the model elements by ID with their kind and container
+  const elements = {};
+  const index = (regions, parent) => (regions || []).forEach((region) => {
+    if (!region) return;
+    elements[region.id] = { kind: "Region", data: region, parent };
+    (region.states || []).forEach((state) => {
+      if (!state) return;
+      elements[state.id] = { kind: (state.regions || []).length ? "Composite state" : "State", data: state, parent: region.id };
+      index(state.regions, state.id);
+    });
+    (region.vertices || []).forEach((vertex) => {
+      if (vertex) elements[vertex.id] = { kind: vertex.type === "finalstate" ? "Final state" : "Pseudostate", data: vertex, parent: region.id };
+    });
+    (region.transitions || []).forEach((transition) => {
+      if (transition) elements[transition.id] = { kind: "Transition", data: transition, parent: region.id };
+    });
+  });
+  index(model.regions, "");
+  const inside = (id, ancestor) => {
+    for (let element = elements[id]; element; element = elements[element.parent]) {
+      if (element.parent === ancestor) return true;
+    }
+    return false;
+  };
+  const shapes = (id) => svg.querySelectorAll('[data-id="' + CSS.escape(id) + '"]');
+
+  // Findings, highlighted by their worst severity
+  const rank = { error: 3, warning: 2, info: 1 };
+  const byElement = {};
+  findings.forEach((finding) => (byElement[finding.element] = byElement[finding.element] || []).push(finding));
+  Object.keys(byElement).forEach((id) => {
+    const worst = byElement[id].reduce((a, b) => (rank[b.severity] > rank[a.severity] ? b : a)).severity;
+    shapes(id).forEach((shape) => shape.classList.add("finding-" + worst));
+  });
+  const summary = document.getElementById("summary");
+  const count = (severity) => findings.filter((finding) => finding.severity === severity).length;
+  const errors = count("error"), warnings = count("warning");
+  summary.innerHTML = '<a href="#" class="error"></a>, <a href="#" class="warning"></a>';
+  summary.children[0].textContent = errors + (errors === 1 ? " error" : " errors");
+  summary.children[1].textContent = warnings + (warnings === 1 ? " warning" : " warnings");
+  Array.from(summary.children).forEach((link) => link.addEventListener("click", (event) => {
+    event.preventDefault();
+    show(model.id, true);
+  }));
+
+  // Details of the selected element
+  const add = (parent, tag, text, className) => {
+    const node = parent.appendChild(document.createElement(tag));
+    if (text !== undefined) node.textContent = text;
+    if (className) node.className = className;
+    return node;
+  };
+  const list = (parent, items) => {
+    if (!items.length) return add(parent, "p", "No findings.", "hint");
+    const ul = add(parent, "ul");
+    items.forEach((finding) => {
+      const li = add(ul, "li", finding.message, finding.severity);
+      if (finding.clause) add(li, "div", finding.clause, "hint");
+    });
+  };
+  const show = (id, all) => {
+    svg.querySelectorAll(".selected").forEach((shape) => shape.classList.remove("selected"));
+    details.textContent = "";
+    if (all) {
+      add(details, "h2", "All findings");
+      list(details, findings);
+      return;
+    }
+    const element = elements[id];
+    if (!element) return;
+    shapes(id).forEach((shape) => shape.classList.add("selected"));
+    const data = element.data;
+    add(details, "h2", data.name || data.id);
+    const facts = add(details, "dl");
+    const fact = (term, value) => {
+      if (!value) return;
+      add(facts, "dt", term);
+      add(facts, "dd", value);
+    };
+    fact("Kind", element.kind);
+    fact("ID", data.id);
+    if (element.kind === "Transition") {
+      fact("Source", data.source && data.source.id);
+      fact("Target", data.target && data.target.id);
+      fact("Triggers", (data.triggers || []).filter(Boolean).map((trigger) => trigger.event_id || (trigger.event && (trigger.event.name || trigger.event.id)) || trigger.name || trigger.id).join(", "));
+      fact("Guard", data.guard && data.guard.specification);
+      fact("Effect", data.effect && (data.effect.specification || data.effect.name));
+    } else if (element.kind !== "Region") {
+      ["entry", "exit", "do_activity"].forEach((key) => fact(key.replace("_", " "), data[key] && (data[key].specification || data[key].name)));
+    }
+    add(details, "h3", "Findings");
+    list(details, byElement[id] || []);
+  };
+
+  // Collapsing composite states hides their contents and the transitions
+  // leading into or out of them
+  const collapsed = new Set();
+  const refresh = () => {
+    svg.querySelectorAll("[data-id]").forEach((shape) => {
+      const id = shape.getAttribute("data-id");
+      const element = elements[id];
+      let hidden = false;
+      collapsed.forEach((state) => {
+        hidden = hidden || inside(id, state);
+        if (element && element.kind === "Transition") {
+          hidden = hidden || [element.data.source, element.data.target].some((end) => end && inside(end.id, state));
+        }
+      });
+      shape.classList.toggle("hidden", hidden);
+      shape.classList.toggle("collapsed", collapsed.has(id));
+    });
+  };
+  svg.addEventListener("dblclick", (event) => {
+    const shape = event.target.closest("[data-id]");
+    const id = shape && shape.getAttribute("data-id");
+    if (!id || !elements[id] || elements[id].kind !== "Composite state") return;
+    collapsed.has(id) ? collapsed.delete(id) : collapsed.add(id);
+    refresh();
+  });
+  document.getElementById("expand").addEventListener("click", () => {
+    collapsed.clear();
+    refresh();
+  });
+
+  // Zooming and panning move the view box
+  const view = svg.viewBox.baseVal;
+  const initial = { x: view.x, y: view.y, width: view.width, height: view.height };
+  const toModel = (clientX, clientY) => {
+    const point = svg.createSVGPoint();
+    point.x = clientX;
+    point.y = clientY;
+    return point.matrixTransform(svg.getScreenCTM().inverse());
+  };
+  const zoom = (factor, center) => {
+    view.x = center.x - (center.x - view.x) * factor;
+    view.y = center.y - (center.y - view.y) * factor;
+    view.width *= factor;
+    view.height *= factor;
+  };
+  const middle = () => ({ x: view.x + view.width / 2, y: view.y + view.height / 2 });
+  svg.addEventListener("wheel", (event) => {
+    event.preventDefault();
+    zoom(event.deltaY > 0 ? 1.1 : 1 / 1.1, toModel(event.clientX, event.clientY));
+  }, { passive: false });
+  document.getElementById("zoom-in").addEventListener("click", () => zoom(1 / 1.25, middle()));
+  document.getElementById("zoom-out").addEventListener("click", () => zoom(1.25, middle()));
+  document.getElementById("fit").addEventListener("click", () => Object.assign(view, initial));
+
+  // Pointer capture starts once the pointer moves, so that clicks keep
+  // their target
+  let drag = null;
+  svg.addEventListener("pointerdown", (event) => {
+    drag = { x: event.clientX, y: event.clientY, moved: false };
+  });
+  svg.addEventListener("pointermove", (event) => {
+    if (!drag) return;
+    const dx = event.clientX - drag.x, dy = event.clientY - drag.y;
+    if (!drag.moved && Math.abs(dx) + Math.abs(dy) > 3) {
+      drag.moved = true;
+      svg.setPointerCapture(event.pointerId);
+      canvas.classList.add("panning");
+    }
+    if (drag.moved) {
+      const scale = svg.getScreenCTM().a;
+      view.x -= dx / scale;
+      view.y -= dy / scale;
+      drag.x = event.clientX;
+      drag.y = event.clientY;
+    }
+  });
+  svg.addEventListener("pointerup", (event) => {
+    const moved = drag && drag.moved;
+    drag = null;
+    canvas.classList.remove("panning");
+    if (moved) return;
+    const shape = event.target.closest("[data-id]");
+    if (shape) show(shape.getAttribute("data-id"));
+  });
+})();
+`
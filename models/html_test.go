@@ -0,0 +1,123 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHTMLExporter(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Name = "Player </script>"
+	sm.Regions[0].States[0].Regions = nil
+	sm.Regions[0].States[0].Entry = &Behavior{ID: "greet", Name: "greet", Specification: "say('<hi>')"}
+
+	var out bytes.Buffer
+	if err := Export(sm, "html", &out, ExportOptions{Properties: map[string]string{"profile": "strict"}}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	page := out.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "doctype", want: "<!DOCTYPE html>\n"},
+		{name: "escaped title", want: "<title>Player &lt;/script&gt;</title>"},
+		{name: "drawing", want: `<div id="canvas">` + "\n" + `<svg xmlns="http://www.w3.org/2000/svg"`},
+		{name: "model", want: `<script type="application/json" id="model">{"id":"player"`},
+		{name: "escaped model", want: `say('\u003chi\u003e')`},
+		{name: "findings", want: `<script type="application/json" id="findings">[`},
+		{name: "viewer", want: `svg.addEventListener("wheel"`},
+		{name: "toolbar", want: `<button id="fit"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(page, tt.want) {
+				t.Errorf("HTML export missing %q", tt.want)
+			}
+		})
+	}
+	if got := strings.Count(page, "</script>"); got != 3 {
+		t.Errorf("page has %d script end tags, want 3 with the data escaped", got)
+	}
+
+	// The embedded findings use the strict profile
+	match := regexp.MustCompile(`id="findings">(.*)</script>`).FindStringSubmatch(page)
+	var findings []htmlFinding
+	if match == nil || json.Unmarshal([]byte(match[1]), &findings) != nil {
+		t.Fatalf("findings are not embedded as JSON")
+	}
+	found := false
+	for _, finding := range findings {
+		found = found || strings.Contains(finding.Message, "CreatedAt")
+	}
+	if !found {
+		t.Errorf("findings = %v, want the strict profile's timestamp check", findings)
+	}
+
+	if err := Export(sm, "html", &out, ExportOptions{Properties: map[string]string{"profile": "pedantic"}}); err == nil || !strings.Contains(err.Error(), "unknown validation profile 'pedantic'") {
+		t.Errorf("Export() error = %v, want an unknown profile error", err)
+	}
+}
+
+func TestHTMLFindings(t *testing.T) {
+	sm := newPlayerMachine()
+	loading := sm.Regions[0].States[1].Regions[0].States[0]
+	loading.ID = "loading state"
+	play := sm.Regions[0].Transitions[1]
+	play.Triggers[0].ID = "play trigger"
+
+	findings := htmlFindings(sm, StrictProfile)
+	// Elements the findings about each ID are attached to; the state's ID
+	// is also checked where transitions refer to it
+	elements := make(map[string][]string)
+	for _, finding := range findings {
+		for _, id := range []string{"loading state", "play trigger"} {
+			if strings.Contains(finding.Message, "ID '"+id+"'") {
+				elements[id] = append(elements[id], finding.Element)
+			}
+		}
+		if finding.Severity == "" || finding.Path == "" && finding.Element != sm.ID {
+			t.Errorf("finding %+v lacks a severity or path", finding)
+		}
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{name: "nested state", id: "loading state", want: "loading state"},
+		{name: "trigger attached to its transition", id: "play trigger", want: "play"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elements[tt.id]; !slices.Contains(got, tt.want) {
+				t.Errorf("findings on %s attached to %v, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileNamed(t *testing.T) {
+	tests := []struct {
+		name string
+		want *ValidationProfile
+	}{
+		{name: "", want: DefaultProfile},
+		{name: "Strict", want: StrictProfile},
+		{name: "lenient", want: LenientProfile},
+		{name: "pedantic", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := profileNamed(tt.name); got != tt.want || ok != (tt.want != nil) {
+				t.Errorf("profileNamed(%q) = %v, %v", tt.name, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package models
+
+import "reflect"
+
+// Identifiable is implemented by model elements that carry an ID. Every
+// element type of this package implements it, so code walking a model can
+// read IDs without reflection. Methods are safe on nil receivers, which have
+// an empty ID.
+type Identifiable interface {
+	GetID() string
+}
+
+// GetID returns the ID of the state machine
+func (sm *StateMachine) GetID() string {
+	if sm == nil {
+		return ""
+	}
+	return sm.ID
+}
+
+// GetID returns the ID of the region
+func (r *Region) GetID() string {
+	if r == nil {
+		return ""
+	}
+	return r.ID
+}
+
+// GetID returns the ID of the vertex
+func (v *Vertex) GetID() string {
+	if v == nil {
+		return ""
+	}
+	return v.ID
+}
+
+// GetID returns the ID of the state
+func (s *State) GetID() string {
+	if s == nil {
+		return ""
+	}
+	return s.ID
+}
+
+// GetID returns the ID of the pseudostate
+func (ps *Pseudostate) GetID() string {
+	if ps == nil {
+		return ""
+	}
+	return ps.ID
+}
+
+// GetID returns the ID of the final state
+func (fs *FinalState) GetID() string {
+	if fs == nil {
+		return ""
+	}
+	return fs.ID
+}
+
+// GetID returns the ID of the connection point reference
+func (cpr *ConnectionPointReference) GetID() string {
+	if cpr == nil {
+		return ""
+	}
+	return cpr.ID
+}
+
+// GetID returns the ID of the transition
+func (t *Transition) GetID() string {
+	if t == nil {
+		return ""
+	}
+	return t.ID
+}
+
+// GetID returns the ID of the trigger
+func (t *Trigger) GetID() string {
+	if t == nil {
+		return ""
+	}
+	return t.ID
+}
+
+// GetID returns the ID of the event
+func (e *Event) GetID() string {
+	if e == nil {
+		return ""
+	}
+	return e.ID
+}
+
+// GetID returns the ID of the behavior
+func (b *Behavior) GetID() string {
+	if b == nil {
+		return ""
+	}
+	return b.ID
+}
+
+// GetID returns the ID of the constraint
+func (c *Constraint) GetID() string {
+	if c == nil {
+		return ""
+	}
+	return c.ID
+}
+
+// objectID returns the ID of an object: through Identifiable when it
+// implements it, otherwise from a string field named ID found by reflection.
+// Objects without an ID, nil included, have an empty ID.
+func objectID(obj interface{}) string {
+	if obj == nil {
+		return ""
+	}
+	if identifiable, ok := obj.(Identifiable); ok {
+		return identifiable.GetID()
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	idField := v.FieldByName("ID")
+	if !idField.IsValid() || idField.Kind() != reflect.String {
+		return ""
+	}
+	return idField.String()
+}
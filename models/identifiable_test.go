@@ -0,0 +1,133 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObjectID(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  interface{}
+		want string
+	}{
+		{name: "state machine", obj: &StateMachine{ID: "sm"}, want: "sm"},
+		{name: "region", obj: &Region{ID: "r"}, want: "r"},
+		{name: "vertex", obj: &Vertex{ID: "v"}, want: "v"},
+		{name: "state", obj: &State{Vertex: Vertex{ID: "s"}}, want: "s"},
+		{name: "pseudostate", obj: &Pseudostate{Vertex: Vertex{ID: "ps"}}, want: "ps"},
+		{name: "final state", obj: &FinalState{Vertex: Vertex{ID: "fs"}}, want: "fs"},
+		{name: "connection point reference", obj: &ConnectionPointReference{Vertex: Vertex{ID: "cpr"}}, want: "cpr"},
+		{name: "transition", obj: &Transition{ID: "t"}, want: "t"},
+		{name: "trigger", obj: &Trigger{ID: "tr"}, want: "tr"},
+		{name: "event", obj: &Event{ID: "e"}, want: "e"},
+		{name: "behavior", obj: &Behavior{ID: "b"}, want: "b"},
+		{name: "constraint", obj: &Constraint{ID: "c"}, want: "c"},
+		{name: "typed nil state", obj: (*State)(nil), want: ""},
+		{name: "typed nil transition", obj: (*Transition)(nil), want: ""},
+		{name: "nil", obj: nil, want: ""},
+		{name: "struct value falls back to reflection", obj: Transition{ID: "value"}, want: "value"},
+		{name: "unknown type with ID field", obj: &struct{ ID string }{ID: "custom"}, want: "custom"},
+		{name: "unknown type with non-string ID", obj: &struct{ ID int }{ID: 1}, want: ""},
+		{name: "typed nil of unknown type", obj: (*struct{ ID string })(nil), want: ""},
+		{name: "not a struct", obj: "id", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := objectID(tt.obj); got != tt.want {
+				t.Errorf("objectID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentifiable_ModelTypes(t *testing.T) {
+	// Every model type walked by the traverser and the reference validator
+	// avoids the reflection fallback
+	types := []interface{}{
+		&StateMachine{}, &Region{}, &Vertex{}, &State{}, &Pseudostate{}, &FinalState{},
+		&ConnectionPointReference{}, &Transition{}, &Trigger{}, &Event{}, &Behavior{}, &Constraint{},
+	}
+	for _, obj := range types {
+		if _, ok := obj.(Identifiable); !ok {
+			t.Errorf("%s does not implement Identifiable", reflect.TypeOf(obj))
+		}
+	}
+}
+
+// reflectObjectID is the reflection-only ID extraction objectID falls back
+// to, kept for comparison in benchmarks
+func reflectObjectID(obj interface{}) string {
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if idField := v.FieldByName("ID"); idField.IsValid() && idField.Kind() == reflect.String {
+		return idField.String()
+	}
+	return ""
+}
+
+// benchmarkObjects returns the objects of a large generated machine
+func benchmarkObjects(b *testing.B) []interface{} {
+	var objects []interface{}
+	err := NewStateMachineTraverser().TraverseStateMachine(benchmarkMachine(), func(obj interface{}, _ []string, _ int) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return objects
+}
+
+// benchmarkMachine returns a large generated machine
+func benchmarkMachine() *StateMachine {
+	return GenerateStateMachine(42, GeneratorOptions{MaxStates: 8, MaxDepth: 3, Events: 6})
+}
+
+func BenchmarkObjectID(b *testing.B) {
+	objects := benchmarkObjects(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, obj := range objects {
+			objectID(obj)
+		}
+	}
+}
+
+func BenchmarkObjectID_Reflection(b *testing.B) {
+	objects := benchmarkObjects(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, obj := range objects {
+			reflectObjectID(obj)
+		}
+	}
+}
+
+func BenchmarkTraverseStateMachine(b *testing.B) {
+	sm := benchmarkMachine()
+	traverser := NewStateMachineTraverser()
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := traverser.TraverseStateMachine(sm, func(interface{}, []string, int) error { return nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateReferences(b *testing.B) {
+	sm := benchmarkMachine()
+	b.ReportAllocs()
+	for b.Loop() {
+		NewReferenceValidator().ValidateReferences(sm)
+	}
+}
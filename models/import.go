@@ -0,0 +1,433 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Importer reads state machines from some input format. Importers are
+// registered by name with RegisterImporter and used through Import, or
+// through Load, which detects the format from the content.
+type Importer interface {
+	// Name returns the format name the importer is registered under, e.g. "graphml"
+	Name() string
+	// Import reads the state machines in r, with a report of what could not
+	// be mapped exactly
+	Import(r io.Reader) ([]*StateMachine, *ImportReport, error)
+}
+
+// FormatDetector is implemented by importers that recognize their own
+// content. Load asks registered detectors before it falls back to the
+// built-in content sniffing of DetectFormat.
+type FormatDetector interface {
+	// Detect reports whether content starting with head is in the importer's format
+	Detect(head []byte) bool
+}
+
+// sniffLength is the number of leading bytes DetectFormat looks at
+const sniffLength = 4096
+
+var (
+	importersMu sync.RWMutex
+	importers   = map[string]Importer{
+		"json":    jsonImporter{},
+		"graphml": graphMLImporter{},
+		"vsdx":    vsdxImporter{},
+		"mdl":     mdlImporter{},
+		"drawio":  drawioImporter{},
+	}
+)
+
+// RegisterImporter registers an importer under its name, which is matched
+// case-insensitively. It fails if the name is empty or already registered;
+// built-in formats cannot be replaced.
+func RegisterImporter(importer Importer) error {
+	if importer == nil {
+		return fmt.Errorf("cannot register nil importer")
+	}
+	name := strings.ToLower(strings.TrimSpace(importer.Name()))
+	if name == "" {
+		return fmt.Errorf("cannot register importer with empty name")
+	}
+
+	importersMu.Lock()
+	defer importersMu.Unlock()
+	if _, exists := importers[name]; exists {
+		return fmt.Errorf("importer %q is already registered", name)
+	}
+	importers[name] = importer
+	return nil
+}
+
+// LookupImporter returns the importer registered for the format name
+func LookupImporter(name string) (Importer, bool) {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+	importer, ok := importers[strings.ToLower(strings.TrimSpace(name))]
+	return importer, ok
+}
+
+// ImportFormats returns the names of all registered importers, sorted
+func ImportFormats() []string {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unregisterImporter removes an importer; it exists so that tests can undo
+// RegisterImporter
+func unregisterImporter(name string) {
+	importersMu.Lock()
+	delete(importers, strings.ToLower(strings.TrimSpace(name)))
+	importersMu.Unlock()
+}
+
+// Import reads the state machines in r using the importer of the named format
+func Import(format string, r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	return importWithLimits(format, r, ResourceLimits{})
+}
+
+// limitedImporter is implemented by importers that enforce ResourceLimits
+// while they read, rather than only on the state machines they return
+type limitedImporter interface {
+	importWithLimits(r io.Reader, limits ResourceLimits) ([]*StateMachine, *ImportReport, error)
+}
+
+// importWithLimits is Import checking every state machine read against the
+// element and depth limits
+func importWithLimits(format string, r io.Reader, limits ResourceLimits) ([]*StateMachine, *ImportReport, error) {
+	importer, ok := LookupImporter(format)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %q for import; registered formats: %s", ErrUnknownFormat, format, strings.Join(ImportFormats(), ", "))
+	}
+	var machines []*StateMachine
+	var report *ImportReport
+	var err error
+	if limited, ok := importer.(limitedImporter); ok {
+		machines, report, err = limited.importWithLimits(r, limits)
+	} else {
+		machines, report, err = importer.Import(r)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to import %s: %w", importer.Name(), err)
+	}
+	for i, sm := range machines {
+		if err := limits.Check(sm); err != nil {
+			return nil, nil, fmt.Errorf("failed to import %s: machines[%d]: %w", importer.Name(), i, err)
+		}
+	}
+	if report == nil {
+		report = &ImportReport{Format: importer.Name()}
+	}
+	return machines, report, nil
+}
+
+// Load reads a single state machine in any registered format, detected from
+// the content with DetectFormat. The state machine is normalized with
+// Sanitize, and the fixes applied are added to the report. It fails if the
+// input contains more or fewer than one state machine; use LoadAll for
+// formats such as VSDX that hold several. The input is read with
+// DefaultResourceLimits; see LoadWithLimits.
+func Load(r io.Reader) (*StateMachine, *ImportReport, error) {
+	return LoadWithLimits(r, DefaultResourceLimits)
+}
+
+// LoadWithLimits is Load enforcing the given limits, like LoadAllWithLimits
+func LoadWithLimits(r io.Reader, limits ResourceLimits) (*StateMachine, *ImportReport, error) {
+	machines, report, err := LoadAllWithLimits(r, limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(machines) != 1 {
+		return nil, nil, fmt.Errorf("input contains %d state machines; use LoadAll", len(machines))
+	}
+	return machines[0], report, nil
+}
+
+// LoadAll reads every state machine in the input, like Load
+func LoadAll(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	return LoadAllWithLimits(r, DefaultResourceLimits)
+}
+
+// LoadAllWithLimits is LoadAll enforcing the given limits. Input of any
+// format longer than MaxJSONBytes is rejected without reading past the
+// limit, and every state machine imported is checked with
+// ResourceLimits.Check. Zero limits are not enforced, so use them only for
+// trusted input.
+func LoadAllWithLimits(r io.Reader, limits ResourceLimits) ([]*StateMachine, *ImportReport, error) {
+	if limits.MaxJSONBytes > 0 {
+		r = io.LimitReader(r, limits.MaxJSONBytes+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if limits.MaxJSONBytes > 0 && int64(len(data)) > limits.MaxJSONBytes {
+		return nil, nil, &ResourceLimitError{Limit: LimitJSONBytes, Max: limits.MaxJSONBytes, Actual: int64(len(data))}
+	}
+	// An input in a known format without an importer is reported by name
+	format := detectFormat(data)
+	if format == "" {
+		return nil, nil, fmt.Errorf("%w: the input is not in a recognized format", ErrUnknownFormat)
+	}
+	machines, report, err := importWithLimits(format, bytes.NewReader(data), limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, sm := range machines {
+		for _, fix := range Sanitize(sm) {
+			report.add("fix", fmt.Sprintf("machines[%d].%s", i, fix.Path), "", "%s (%s)", fix.Message, fix.Rule)
+		}
+	}
+	return machines, report, nil
+}
+
+// DetectFormat names the format of content from its leading bytes, if an
+// importer is registered for it. Importers registered with a FormatDetector
+// are asked first, in name order; otherwise the content is sniffed for the
+// formats of the built-in importers:
+//   - "vsdx" for zip packages
+//   - "json" for JSON objects and arrays
+//   - "graphml" and "drawio" for GraphML and draw.io XML documents
+//   - "mdl" for Rational Rose petal files
+//
+// Content sniffed as SCXML, XMI, PlantUML, Mermaid or YAML is only reported
+// once an importer is registered under "scxml", "xmi", "plantuml",
+// "mermaid" or "yaml". It returns "" if no registered importer can read
+// the content.
+func DetectFormat(content []byte) string {
+	format := detectFormat(content)
+	if _, ok := LookupImporter(format); !ok {
+		return ""
+	}
+	return format
+}
+
+// detectFormat names the format of content like DetectFormat, whether or
+// not an importer is registered for it
+func detectFormat(content []byte) string {
+	head := content
+	if len(head) > sniffLength {
+		head = head[:sniffLength]
+	}
+
+	importersMu.RLock()
+	var detectors []string
+	for name, importer := range importers {
+		if _, ok := importer.(FormatDetector); ok {
+			detectors = append(detectors, name)
+		}
+	}
+	sort.Strings(detectors)
+	for _, name := range detectors {
+		if importers[name].(FormatDetector).Detect(head) {
+			importersMu.RUnlock()
+			return name
+		}
+	}
+	importersMu.RUnlock()
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return "vsdx"
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(string(head), "\ufeff"))
+	switch {
+	case text == "":
+		return ""
+	case text[0] == '{' || text[0] == '[':
+		return "json"
+	case text[0] == '<':
+		return sniffXMLFormat([]byte(text))
+	case strings.HasPrefix(text, "(object "):
+		return "mdl"
+	case strings.HasPrefix(text, "@startuml"):
+		return "plantuml"
+	}
+
+	// Mermaid diagrams may start with a front matter block, which also
+	// looks like YAML
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "stateDiagram") {
+			return "mermaid"
+		}
+	}
+	if strings.HasPrefix(text, "---") || strings.HasPrefix(text, "%YAML") {
+		return "yaml"
+	}
+	if key, _, found := strings.Cut(strings.SplitN(text, "\n", 2)[0], ":"); found && key != "" && !strings.ContainsAny(key, " \t{}[]\"") {
+		return "yaml"
+	}
+	return ""
+}
+
+// sniffXMLFormat names an XML format by its root element
+func sniffXMLFormat(head []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(head))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case start.Name.Local == "scxml":
+			return "scxml"
+		case start.Name.Local == "graphml":
+			return "graphml"
+		case start.Name.Local == "mxfile":
+			return "drawio"
+		case start.Name.Local == "XMI" || strings.Contains(start.Name.Space, "omg.org/spec/XMI"):
+			return "xmi"
+		}
+		for _, attr := range start.Attr {
+			if strings.Contains(attr.Value, "omg.org/spec/XMI") {
+				return "xmi"
+			}
+		}
+		return ""
+	}
+}
+
+// jsonImporter reads the JSON encoding of a state machine
+type jsonImporter struct{}
+
+func (jsonImporter) Name() string { return "json" }
+
+func (i jsonImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	return i.importWithLimits(r, ResourceLimits{})
+}
+
+func (jsonImporter) importWithLimits(r io.Reader, limits ResourceLimits) ([]*StateMachine, *ImportReport, error) {
+	sm, err := DecodeStateMachine(r, limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Kinds and event types are normalized as they are decoded
+	return []*StateMachine{sm}, &ImportReport{Format: "JSON"}, nil
+}
+
+// normalizeKinds replaces the pseudostate kinds, transition kinds and event
+// types of sm and its submachines that are spelled differently from their
+// canonical value, such as "EXTERNAL", "shallow-history" or "H*", with that
+// value, as ParsePseudostateKind, ParseTransitionKind and ParseEventType
+// read them. Each replacement is recorded in the report; values that do not
+// parse are kept for validation to report.
+func normalizeKinds(sm *StateMachine, report *ImportReport) {
+	pseudostates := func(pseudostates []*Pseudostate) {
+		for _, ps := range pseudostates {
+			if ps == nil {
+				continue
+			}
+			if kind, err := ParsePseudostateKind(string(ps.Kind)); err == nil && kind != ps.Kind {
+				report.add("pseudostate", ps.ID, string(ps.Kind), "kind read as %q", kind)
+				ps.Kind = kind
+			}
+		}
+	}
+	event := func(event *Event) {
+		if event == nil {
+			return
+		}
+		if eventType, err := ParseEventType(string(event.Type)); err == nil && eventType != event.Type {
+			report.add("event", event.ID, string(event.Type), "type read as %q", eventType)
+			event.Type = eventType
+		}
+	}
+
+	seen := map[*StateMachine]bool{sm: true}
+	for machines := []*StateMachine{sm}; len(machines) > 0; machines = machines[1:] {
+		machine := machines[0]
+		pseudostates(machine.ConnectionPoints)
+		for _, e := range machine.Events {
+			event(e)
+		}
+		walkRegionTree(machine.Regions, "Regions", func(region *Region, _ string) {
+			for _, transition := range region.Transitions {
+				if transition == nil {
+					continue
+				}
+				if kind, err := ParseTransitionKind(string(transition.Kind)); err == nil && kind != transition.Kind {
+					report.add("transition", transition.ID, string(transition.Kind), "kind read as %q", kind)
+					transition.Kind = kind
+				}
+				for _, trigger := range transition.Triggers {
+					if trigger != nil {
+						event(trigger.Event)
+					}
+				}
+			}
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				for _, connection := range state.Connections {
+					if connection != nil {
+						pseudostates(connection.Entry)
+						pseudostates(connection.Exit)
+					}
+				}
+				if state.Submachine != nil && !seen[state.Submachine] {
+					seen[state.Submachine] = true
+					machines = append(machines, state.Submachine)
+				}
+			}
+		})
+	}
+}
+
+// graphMLImporter reads GraphML diagrams; see ImportGraphML
+type graphMLImporter struct{}
+
+func (graphMLImporter) Name() string { return "graphml" }
+
+func (graphMLImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	sm, report, err := ImportGraphML(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []*StateMachine{sm}, report, nil
+}
+
+// vsdxImporter reads Visio diagrams; see ImportVSDX
+type vsdxImporter struct{}
+
+func (vsdxImporter) Name() string { return "vsdx" }
+
+func (vsdxImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read VSDX package: %w", err)
+	}
+	return ImportVSDX(bytes.NewReader(data), int64(len(data)))
+}
+
+// drawioImporter reads draw.io diagrams; see ImportDrawio
+type drawioImporter struct{}
+
+func (drawioImporter) Name() string { return "drawio" }
+
+func (drawioImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	return ImportDrawio(r)
+}
+
+// mdlImporter reads Rational Rose models; see ImportMDL
+type mdlImporter struct{}
+
+func (mdlImporter) Name() string { return "mdl" }
+
+func (mdlImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	return ImportMDL(r)
+}
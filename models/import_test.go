@@ -0,0 +1,316 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		sniffed string // Format without an importer, which DetectFormat does not report
+	}{
+		{name: "zip package", content: "PK\x03\x04rest", want: "vsdx"},
+		{name: "json object", content: "\ufeff  {\"id\": \"sm\"}", want: "json"},
+		{name: "json array", content: "[{}]", want: "json"},
+		{name: "scxml", content: `<?xml version="1.0"?><!-- exported --><scxml xmlns="http://www.w3.org/2005/07/scxml"/>`, sniffed: "scxml"},
+		{name: "graphml", content: yedDiagram, want: "graphml"},
+		{name: "drawio", content: `<mxfile host="app.diagrams.net"><diagram id="a" name="Page-1"/></mxfile>`, want: "drawio"},
+		{name: "xmi root", content: `<xmi:XMI xmlns:xmi="http://www.omg.org/spec/XMI/20131001"/>`, sniffed: "xmi"},
+		{name: "xmi namespace on uml model", content: `<uml:Model xmi:version="2.1" xmlns:xmi="http://www.omg.org/spec/XMI/2.1"/>`, sniffed: "xmi"},
+		{name: "other xml", content: `<html></html>`, want: ""},
+		{name: "rose petal", content: "\n(object Petal\n    version 45)", want: "mdl"},
+		{name: "plantuml", content: "@startuml\n[*] --> idle\n@enduml", sniffed: "plantuml"},
+		{name: "mermaid with front matter", content: "---\ntitle: Player\n---\nstateDiagram-v2\n", sniffed: "mermaid"},
+		{name: "yaml document", content: "---\nid: sm\n", sniffed: "yaml"},
+		{name: "yaml mapping", content: "id: sm\nname: Player\n", sniffed: "yaml"},
+		{name: "plain text", content: "state idle to playing", want: ""},
+		{name: "empty", content: "   ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(tt.content)); got != tt.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.want)
+			}
+			sniffed := tt.sniffed
+			if sniffed == "" {
+				sniffed = tt.want
+			}
+			if got := detectFormat([]byte(tt.content)); got != sniffed {
+				t.Errorf("detectFormat() = %q, want %q", got, sniffed)
+			}
+		})
+	}
+
+	t.Run("importer registered for a sniffed format", func(t *testing.T) {
+		if err := RegisterImporter(namedImporter{"yaml"}); err != nil {
+			t.Fatalf("RegisterImporter() error = %v", err)
+		}
+		defer unregisterImporter("yaml")
+		if got := DetectFormat([]byte("id: sm\n")); got != "yaml" {
+			t.Errorf("DetectFormat() = %q, want yaml", got)
+		}
+	})
+}
+
+// namedImporter is a registered importer without content of its own
+type namedImporter struct{ name string }
+
+func (i namedImporter) Name() string { return i.name }
+
+func (namedImporter) Import(io.Reader) ([]*StateMachine, *ImportReport, error) {
+	return nil, nil, nil
+}
+
+// lineImporter reads "source -> target" lines, as a third-party DSL would
+type lineImporter struct{}
+
+func (lineImporter) Name() string { return "Lines" }
+
+func (lineImporter) Detect(head []byte) bool { return bytes.Contains(head, []byte(" -> ")) }
+
+func (lineImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	region := &Region{ID: "main"}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		source, target, _ := strings.Cut(line, " -> ")
+		region.States = append(region.States, execState(source))
+		region.Transitions = append(region.Transitions, execTransition(line, &region.States[len(region.States)-1].Vertex, &Vertex{ID: target, Type: "state"}))
+	}
+	return []*StateMachine{{ID: "lines", Name: "Lines", Version: "1.0", Regions: []*Region{region}}}, nil, nil
+}
+
+func TestRegisterImporter(t *testing.T) {
+	if err := RegisterImporter(lineImporter{}); err != nil {
+		t.Fatalf("RegisterImporter() error = %v", err)
+	}
+	defer unregisterImporter("lines")
+
+	if got := strings.Join(ImportFormats(), ","); got != "drawio,graphml,json,lines,mdl,vsdx" {
+		t.Errorf("ImportFormats() = %s", got)
+	}
+	if got := DetectFormat([]byte("a -> b")); got != "lines" {
+		t.Errorf("DetectFormat() = %q, want the registered detector to win", got)
+	}
+
+	sm, report, err := Load(strings.NewReader("a -> b"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if sm.ID != "lines" || report.Format != "Lines" {
+		t.Errorf("Load() = %s, %s; want the lines importer with a default report", sm, report)
+	}
+
+	tests := []struct {
+		name     string
+		importer Importer
+		want     string
+	}{
+		{name: "duplicate", importer: lineImporter{}, want: `importer "lines" is already registered`},
+		{name: "built-in", importer: jsonImporter{}, want: `importer "json" is already registered`},
+		{name: "nil", importer: nil, want: "cannot register nil importer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterImporter(tt.importer)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RegisterImporter() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("json normalized", func(t *testing.T) {
+		sm := newPlayerMachine()
+		idle := sm.Regions[0].States[0]
+		sm.Regions[0].Vertices = append(sm.Regions[0].Vertices, &Vertex{ID: idle.ID, Name: idle.Name, Type: "state"})
+		data, err := json.Marshal(sm)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		loaded, report, err := Load(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if loaded.ID != sm.ID || len(loaded.Regions[0].Vertices) != 1 {
+			t.Errorf("Load() = %s with %d vertices, want the duplicate vertex removed", loaded, len(loaded.Regions[0].Vertices))
+		}
+		if !strings.Contains(report.String(), "fix 'machines[0].Regions[0].Vertices[1]'") || !strings.Contains(report.String(), "(vertex-containment)") {
+			t.Errorf("report = %s, want the sanitize fix", report)
+		}
+	})
+
+	t.Run("json kinds spelled differently", func(t *testing.T) {
+		// Only registered kinds marshal, so respell them in the encoded form
+		sm := newPlayerMachine()
+		sm.Events = []*Event{{ID: "any", Name: "Any", Type: EventTypeAnyReceive}}
+		sm.ConnectionPoints = []*Pseudostate{{Vertex: Vertex{ID: "resume", Name: "Resume", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint}}
+		encoded, err := json.Marshal(sm)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		var document map[string]any
+		if err := json.Unmarshal(encoded, &document); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		transitions := document["regions"].([]any)[0].(map[string]any)["transitions"].([]any)
+		transitions[0].(map[string]any)["kind"] = "EXTERNAL"
+		transitions[1].(map[string]any)["kind"] = "sideways"
+		document["events"].([]any)[0].(map[string]any)["type"] = "Any_Receive"
+		document["connection_points"].([]any)[0].(map[string]any)["kind"] = "entry"
+		data, err := json.Marshal(document)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		loaded, _, err := Load(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got := loaded.Regions[0].Transitions[0].Kind; got != TransitionKindExternal {
+			t.Errorf("transition kind = %q, want %q", got, TransitionKindExternal)
+		}
+		if got := loaded.Events[0].Type; got != EventTypeAnyReceive {
+			t.Errorf("event type = %q, want %q", got, EventTypeAnyReceive)
+		}
+		if got := loaded.ConnectionPoints[0].Kind; got != PseudostateKindEntryPoint {
+			t.Errorf("connection point kind = %q, want %q", got, PseudostateKindEntryPoint)
+		}
+		if got := loaded.Regions[0].Transitions[1].Kind; got != "sideways" {
+			t.Errorf("unknown transition kind = %q, want it kept for validation", got)
+		}
+	})
+
+	t.Run("graphml", func(t *testing.T) {
+		sm, report, err := Load(strings.NewReader(yedDiagram))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(sm.Regions[0].States) == 0 || report.Format != "GraphML" {
+			t.Errorf("Load() = %s, %s", sm, report)
+		}
+	})
+
+	t.Run("several machines", func(t *testing.T) {
+		page := `<PageContents><Shapes><Shape ID="1"><Text>Alone</Text></Shape></Shapes></PageContents>`
+		archive := buildVSDX(t, map[string]string{"visio/pages/page1.xml": page, "visio/pages/page2.xml": page})
+		if _, _, err := Load(archive); err == nil || !strings.Contains(err.Error(), "contains 2 state machines") {
+			t.Errorf("Load() error = %v, want a hint to use LoadAll", err)
+		}
+		archive.Seek(0, io.SeekStart)
+		machines, _, err := LoadAll(archive)
+		if err != nil || len(machines) != 2 {
+			t.Errorf("LoadAll() = %d machines, %v; want 2", len(machines), err)
+		}
+	})
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		unknown bool
+		want    string
+	}{
+		{name: "unrecognized", input: "hello", unknown: true, want: "not in a recognized format"},
+		{name: "no importer", input: `<xmi:XMI xmlns:xmi="http://www.omg.org/spec/XMI/20131001"/>`, unknown: true, want: `"xmi" for import; registered formats: drawio, graphml, json, mdl, vsdx`},
+		{name: "malformed", input: `{"id": `, want: "failed to import json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Load(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.want)
+			}
+			if errors.Is(err, ErrUnknownFormat) != tt.unknown {
+				t.Errorf("errors.Is(err, ErrUnknownFormat) = %v, want %v", !tt.unknown, tt.unknown)
+			}
+		})
+	}
+}
+
+// spaces is an endless stream of spaces
+type spaces struct{}
+
+func (spaces) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	return len(p), nil
+}
+
+func TestLoadWithLimits(t *testing.T) {
+	encoded, err := json.Marshal(createValidStateMachine())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		input  func() io.Reader
+		limits ResourceLimits
+		want   string
+	}{
+		{
+			name:   "endless input",
+			input:  func() io.Reader { return io.MultiReader(bytes.NewReader(encoded), spaces{}) },
+			limits: ResourceLimits{MaxJSONBytes: 1 << 20},
+			want:   "json_bytes exceeds the limit of 1048576 (reached 1048577)",
+		},
+		{
+			name:   "diagram larger than the input limit",
+			input:  func() io.Reader { return strings.NewReader(yedDiagram) },
+			limits: ResourceLimits{MaxJSONBytes: 64},
+			want:   "json_bytes exceeds the limit of 64",
+		},
+		{
+			name:   "json with too many elements",
+			input:  func() io.Reader { return bytes.NewReader(encoded) },
+			limits: ResourceLimits{MaxElements: 3},
+			want:   "failed to import json: resource limit exceeded: elements exceeds the limit of 3",
+		},
+		{
+			name:   "diagram with too many elements",
+			input:  func() io.Reader { return strings.NewReader(yedDiagram) },
+			limits: ResourceLimits{MaxElements: 2},
+			want:   "failed to import graphml: machines[0]: resource limit exceeded: elements exceeds the limit of 2",
+		},
+		{
+			name:   "within the limits",
+			input:  func() io.Reader { return bytes.NewReader(encoded) },
+			limits: DefaultResourceLimits,
+		},
+		{
+			name:  "no limits",
+			input: func() io.Reader { return strings.NewReader(yedDiagram) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadWithLimits(tt.input(), tt.limits)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("LoadWithLimits() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadWithLimits() error = %v, want %q", err, tt.want)
+			}
+			if !errors.Is(err, ErrResourceLimit) {
+				t.Errorf("errors.Is(err, ErrResourceLimit) = false for %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InlineCompositeState dissolves the composite state with the given ID,
+// hoisting the contents of its single region into the parent region. It is
+// the inverse of ExtractCompositeState. Boundary transitions are rewired:
+//   - transitions targeting the composite state follow its initial
+//     transition to the default substate
+//   - transitions targeting an entry point follow the entry point's outgoing
+//     transition, and transitions targeting an exit point follow the exit
+//     point's outgoing transition
+//   - transitions leaving the composite state are copied to every hoisted
+//     state, since they applied to all of its substates
+//   - transitions into the final states of the composite state follow its
+//     completion transition, the outgoing transition without triggers
+//
+// Inlining is refused if hoisted states would clash by ID or name with
+// states of the parent region, if the state has entry, exit or do behaviors
+// that would be lost, if it has more than one completion transition, or
+// final states but no completion transition, or if the result would
+// introduce validation errors; in all of these cases sm is left untouched.
+func InlineCompositeState(sm *StateMachine, stateID string) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+
+	parent := findStateRegion(sm, stateID)
+	if parent == nil {
+		return fmt.Errorf("state '%s' not found", stateID)
+	}
+	composite := parent.States[slices.IndexFunc(parent.States, func(s *State) bool { return s != nil && s.ID == stateID })]
+
+	switch {
+	case composite.Submachine != nil:
+		return fmt.Errorf("cannot inline submachine state '%s'", stateID)
+	case len(composite.Regions) == 0:
+		return fmt.Errorf("state '%s' is not a composite state", stateID)
+	case len(composite.Regions) > 1:
+		return fmt.Errorf("cannot inline orthogonal state '%s' with %d regions", stateID, len(composite.Regions))
+	case composite.Entry != nil || composite.Exit != nil || composite.DoActivity != nil:
+		return fmt.Errorf("cannot inline state '%s': its entry, exit or do behaviors would be lost", stateID)
+	}
+	if err := checkInlineConflicts(parent, composite); err != nil {
+		return err
+	}
+	if findInitialTransition(composite.Regions[0]) == nil {
+		return fmt.Errorf("cannot inline state '%s': its region has no initial transition", stateID)
+	}
+	completions := completionTransitions(sm, stateID)
+	switch {
+	case len(completions) > 1:
+		ids := make([]string, len(completions))
+		for i, transition := range completions {
+			ids[i] = "'" + transition.ID + "'"
+		}
+		return fmt.Errorf("cannot inline state '%s': it has %d completion transitions (%s), so its final states have no single continuation", stateID, len(completions), strings.Join(ids, ", "))
+	case len(completions) == 0 && slices.ContainsFunc(composite.Regions[0].Vertices, func(v *Vertex) bool { return v != nil && v.Type == "finalstate" }):
+		return fmt.Errorf("cannot inline state '%s': it has final states but no completion transition to continue with", stateID)
+	}
+
+	apply := func(target *StateMachine) {
+		inlineCompositeState(target, findStateRegion(target, stateID), stateID)
+	}
+	if err := checkRefactoring(sm, apply); err != nil {
+		return fmt.Errorf("cannot inline state '%s': %w", stateID, err)
+	}
+	apply(sm)
+	sm.refreshAdjacency()
+	return nil
+}
+
+// completionTransitions returns the transitions without triggers leaving
+// the state, which fire when it completes
+func completionTransitions(sm *StateMachine, stateID string) []*Transition {
+	var completions []*Transition
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Source.ID == stateID && len(transition.Triggers) == 0 {
+			completions = append(completions, transition)
+		}
+	})
+	return completions
+}
+
+// checkInlineConflicts reports hoisted states and vertices whose IDs or names
+// clash with the other contents of the parent region
+func checkInlineConflicts(parent *Region, composite *State) error {
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, state := range parent.States {
+		if state != nil && state != composite {
+			ids[state.ID] = true
+			names[state.Name] = true
+		}
+	}
+	for _, vertex := range parent.Vertices {
+		if vertex != nil {
+			ids[vertex.ID] = true
+		}
+	}
+
+	var conflicts []string
+	inner := composite.Regions[0]
+	for _, state := range inner.States {
+		if state == nil {
+			continue
+		}
+		if ids[state.ID] {
+			conflicts = append(conflicts, fmt.Sprintf("ID '%s'", state.ID))
+		}
+		if state.Name != "" && names[state.Name] {
+			conflicts = append(conflicts, fmt.Sprintf("name '%s'", state.Name))
+		}
+	}
+	for _, vertex := range inner.Vertices {
+		if vertex != nil && ids[vertex.ID] {
+			conflicts = append(conflicts, fmt.Sprintf("ID '%s'", vertex.ID))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("cannot inline state '%s': hoisted elements conflict with region '%s': %s", composite.ID, parent.ID, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// inlineCompositeState performs the inlining; the inputs have already been
+// checked by InlineCompositeState
+func inlineCompositeState(sm *StateMachine, parent *Region, stateID string) {
+	index := slices.IndexFunc(parent.States, func(s *State) bool { return s != nil && s.ID == stateID })
+	composite := parent.States[index]
+	inner := composite.Regions[0]
+
+	initial := findInitialTransition(inner)
+	removedVertices := map[string]bool{initial.Source.ID: true}
+	removedTransitions := map[*Transition]bool{initial: true}
+
+	// Boundary pseudostates are bypassed by following their single outgoing transition
+	redirect := map[string]*Vertex{stateID: initial.Target}
+	for _, vertex := range inner.Vertices {
+		if !isBoundaryPseudostate(vertex) {
+			continue
+		}
+		if continuation := singleOutgoing(inner, vertex.ID); continuation != nil {
+			redirect[vertex.ID] = continuation.Target
+			removedVertices[vertex.ID] = true
+			removedTransitions[continuation] = true
+		}
+	}
+
+	// Exit points declared in the parent region, as ExtractCompositeState
+	// declares them, are those entered from inside the composite
+	enteredFromInside := make(map[string]bool)
+	for _, transition := range inner.Transitions {
+		if transition != nil && transition.Target != nil {
+			enteredFromInside[transition.Target.ID] = true
+		}
+	}
+	for _, vertex := range parent.Vertices {
+		if !isBoundaryPseudostate(vertex) || !enteredFromInside[vertex.ID] {
+			continue
+		}
+		if continuation := singleOutgoing(parent, vertex.ID); continuation != nil {
+			redirect[vertex.ID] = continuation.Target
+			removedVertices[vertex.ID] = true
+			removedTransitions[continuation] = true
+		}
+	}
+
+	// The completion transition of the composite replaces its final states
+	var outgoing []*Transition
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Source.ID == stateID {
+			outgoing = append(outgoing, transition)
+		}
+	})
+	var completion *Transition
+	if completions := completionTransitions(sm, stateID); len(completions) == 1 {
+		completion = completions[0]
+	}
+	if completion != nil {
+		for _, vertex := range inner.Vertices {
+			if vertex != nil && vertex.Type == "finalstate" {
+				redirect[vertex.ID] = completion.Target
+				removedVertices[vertex.ID] = true
+			}
+		}
+	}
+
+	// Rewire transitions into the composite and through bypassed pseudostates
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Target == nil {
+			return
+		}
+		for hops := 0; hops < len(redirect); hops++ {
+			next, ok := redirect[transition.Target.ID]
+			if !ok {
+				break
+			}
+			transition.Target = next
+		}
+	})
+
+	// Hoist the region contents into the parent region, dropping bypassed
+	// exit points declared there
+	parent.Vertices = slices.DeleteFunc(parent.Vertices, func(v *Vertex) bool { return v != nil && removedVertices[v.ID] })
+	parent.Transitions = slices.DeleteFunc(parent.Transitions, func(t *Transition) bool { return removedTransitions[t] })
+	hoisted := slices.DeleteFunc(slices.Clone(inner.States), func(s *State) bool { return s == nil })
+	parent.States = slices.Concat(parent.States[:index], hoisted, parent.States[index+1:])
+	for _, vertex := range inner.Vertices {
+		if vertex != nil && !removedVertices[vertex.ID] {
+			parent.Vertices = append(parent.Vertices, vertex)
+		}
+	}
+	for _, transition := range inner.Transitions {
+		if transition != nil && !removedTransitions[transition] {
+			parent.Transitions = append(parent.Transitions, transition)
+		}
+	}
+
+	// Transitions leaving the composite now leave each of its former substates
+	outgoingSet := make(map[*Transition]bool)
+	for _, transition := range outgoing {
+		outgoingSet[transition] = true
+		if transition == completion {
+			continue
+		}
+		for _, state := range hoisted {
+			parent.Transitions = append(parent.Transitions, copyOutgoingTransition(transition, state))
+		}
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		region.Transitions = slices.DeleteFunc(region.Transitions, func(transition *Transition) bool {
+			return outgoingSet[transition]
+		})
+	})
+}
+
+// isBoundaryPseudostate checks if a vertex is an entry or exit point using
+// the same naming conventions as transition validation
+func isBoundaryPseudostate(vertex *Vertex) bool {
+	if vertex == nil || vertex.Type != "pseudostate" {
+		return false
+	}
+
+	boundaryPatterns := []string{
+		"entryPoint", "EntryPoint", "ENTRY_POINT",
+		"exitPoint", "ExitPoint", "EXIT_POINT",
+		"entry", "Entry", "ENTRY",
+		"exit", "Exit", "EXIT",
+	}
+
+	return slices.Contains(boundaryPatterns, vertex.Name) || slices.Contains(boundaryPatterns, vertex.ID)
+}
+
+// findInitialTransition returns the transition leaving the region's initial pseudostate
+func findInitialTransition(region *Region) *Transition {
+	for _, vertex := range region.Vertices {
+		if vertex != nil && vertex.Type == "pseudostate" && region.isInitialPseudostate(vertex) {
+			return singleOutgoing(region, vertex.ID)
+		}
+	}
+	return nil
+}
+
+// singleOutgoing returns the only transition in the region leaving the
+// vertex, or nil if there is none or more than one
+func singleOutgoing(region *Region, vertexID string) *Transition {
+	var found *Transition
+	for _, transition := range region.Transitions {
+		if transition != nil && transition.Source != nil && transition.Source.ID == vertexID {
+			if found != nil {
+				return nil
+			}
+			found = transition
+		}
+	}
+	return found
+}
+
+// copyOutgoingTransition deep-copies transition to leave state instead. The
+// copy's triggers, guard and effect get IDs derived from its own, while its
+// target and trigger events stay shared with the original.
+func copyOutgoingTransition(transition *Transition, state *State) *Transition {
+	cloner := newModelCloner()
+	cloner.seen[transition.Source] = transition.Source
+	cloner.seen[transition.Target] = transition.Target
+	for _, trigger := range transition.Triggers {
+		if trigger != nil && trigger.Event != nil {
+			cloner.seen[trigger.Event] = trigger.Event
+		}
+	}
+
+	copied := cloner.transition(transition)
+	copied.ID = fmt.Sprintf("%s_%s", transition.ID, state.ID)
+	copied.Source = &state.Vertex
+	for i, trigger := range copied.Triggers {
+		if trigger != nil {
+			trigger.ID = fmt.Sprintf("%s_trigger_%d", copied.ID, i)
+		}
+	}
+	if copied.Guard != nil {
+		copied.Guard.ID = copied.ID + "_guard"
+	}
+	if copied.Effect != nil {
+		copied.Effect.ID = copied.ID + "_effect"
+	}
+	return copied
+}
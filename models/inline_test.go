@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestInlineCompositeState(t *testing.T) {
+	t.Run("round trip with extract", func(t *testing.T) {
+		sm := createLinearStateMachine()
+		if _, err := ExtractCompositeState(sm, []string{"b", "c"}, "work", "Work"); err != nil {
+			t.Fatalf("ExtractCompositeState() error = %v", err)
+		}
+
+		if err := InlineCompositeState(sm, "work"); err != nil {
+			t.Fatalf("InlineCompositeState() error = %v", err)
+		}
+
+		region := sm.Regions[0]
+		var ids []string
+		for _, state := range region.States {
+			ids = append(ids, state.ID)
+		}
+		if got := strings.Join(ids, ","); got != "a,b,c,d" {
+			t.Errorf("states after inlining = %s, want a,b,c,d", got)
+		}
+		if len(region.Vertices) != 2 {
+			t.Errorf("boundary pseudostates should be removed, got vertices %v", region.Vertices)
+		}
+
+		want := map[string]string{"t1": "a->b", "t2": "b->c", "t5": "d->c", "t4": "c->final"}
+		for id, edge := range want {
+			_, transition := findTransition(sm, id)
+			if transition == nil {
+				t.Errorf("transition %s missing after inlining", id)
+				continue
+			}
+			if got := transition.Source.ID + "->" + transition.Target.ID; got != edge {
+				t.Errorf("transition %s = %s, want %s", id, got, edge)
+			}
+		}
+
+		if err := sm.Validate(); err != nil {
+			t.Errorf("inlined model should validate: %v", err)
+		}
+	})
+
+	t.Run("outgoing transitions are copied to substates", func(t *testing.T) {
+		sm := createLinearStateMachine()
+		if _, err := ExtractCompositeState(sm, []string{"b", "c"}, "work", "Work"); err != nil {
+			t.Fatalf("ExtractCompositeState() error = %v", err)
+		}
+		region := sm.Regions[0]
+		cancel := &Event{ID: "cancel", Name: "Cancel", Type: EventTypeSignal}
+		region.Transitions = append(region.Transitions, &Transition{
+			ID:       "cancel",
+			Source:   findVertex(sm, "work"),
+			Target:   findVertex(sm, "d"),
+			Kind:     TransitionKindExternal,
+			Triggers: []*Trigger{{ID: "tr_cancel", Name: "Cancel", Event: cancel}},
+			Guard:    &Constraint{ID: "g_cancel", Specification: "allowed"},
+			Effect:   &Behavior{ID: "e_cancel", Specification: "rollback()"},
+		})
+
+		if err := InlineCompositeState(sm, "work"); err != nil {
+			t.Fatalf("InlineCompositeState() error = %v", err)
+		}
+		var copies []*Transition
+		for _, id := range []string{"cancel_b", "cancel_c"} {
+			_, transition := findTransition(sm, id)
+			if transition == nil || transition.Target.ID != "d" {
+				t.Errorf("expected copied transition %s to d", id)
+				continue
+			}
+			copies = append(copies, transition)
+			if transition.Triggers[0].ID != id+"_trigger_0" || transition.Guard.ID != id+"_guard" || transition.Effect.ID != id+"_effect" {
+				t.Errorf("copied transition %s should have derived trigger, guard and effect IDs, got %s, %s, %s",
+					id, transition.Triggers[0].ID, transition.Guard.ID, transition.Effect.ID)
+			}
+			if transition.Triggers[0].Event != cancel {
+				t.Errorf("copied transition %s should keep the original event", id)
+			}
+		}
+		if len(copies) == 2 && (copies[0].Triggers[0] == copies[1].Triggers[0] || copies[0].Guard == copies[1].Guard || copies[0].Effect == copies[1].Effect) {
+			t.Error("copied transitions should not share triggers, guards or effects")
+		}
+		if _, transition := findTransition(sm, "cancel"); transition != nil {
+			t.Error("original transition leaving the composite should be removed")
+		}
+	})
+
+	// withCompletions gives the extracted composite a final state entered
+	// from c and completion transitions to the given targets
+	withCompletions := func(sm *StateMachine, targets ...string) {
+		inner := findState(sm, "work").Regions[0]
+		final := &Vertex{ID: "work_final", Name: "WorkFinal", Type: "finalstate"}
+		inner.Vertices = append(inner.Vertices, final)
+		inner.Transitions = append(inner.Transitions, &Transition{ID: "finish", Source: findVertex(sm, "c"), Target: final, Kind: TransitionKindExternal,
+			Triggers: []*Trigger{{ID: "tr_finish", Name: "Finish", Event: &Event{ID: "finish", Name: "Finish", Type: EventTypeSignal}}}})
+		for i, target := range targets {
+			sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, &Transition{
+				ID: fmt.Sprintf("done%d", i+1), Source: findVertex(sm, "work"), Target: findVertex(sm, target), Kind: TransitionKindExternal,
+				Guard: &Constraint{ID: fmt.Sprintf("g%d", i+1), Specification: fmt.Sprintf("x == %d", i+1)},
+			})
+		}
+	}
+
+	t.Run("final states follow the completion transition", func(t *testing.T) {
+		sm := createLinearStateMachine()
+		if _, err := ExtractCompositeState(sm, []string{"b", "c"}, "work", "Work"); err != nil {
+			t.Fatalf("ExtractCompositeState() error = %v", err)
+		}
+		withCompletions(sm, "d")
+
+		if err := InlineCompositeState(sm, "work"); err != nil {
+			t.Fatalf("InlineCompositeState() error = %v", err)
+		}
+		if _, transition := findTransition(sm, "finish"); transition == nil || transition.Target.ID != "d" {
+			t.Errorf("finish should lead to d, the completion target, got %v", transition)
+		}
+		if findVertex(sm, "work_final") != nil {
+			t.Error("the final state of the composite should be removed")
+		}
+	})
+
+	tests := []struct {
+		name        string
+		modify      func(sm *StateMachine)
+		stateID     string
+		errContains string
+	}{
+		{name: "unknown state", stateID: "missing", errContains: "state 'missing' not found"},
+		{name: "simple state", stateID: "a", errContains: "is not a composite state"},
+		{
+			name:    "name conflict",
+			stateID: "work",
+			modify: func(sm *StateMachine) {
+				findVertex(sm, "a").Name = "B"
+			},
+			errContains: "name 'B'",
+		},
+		{
+			name:    "behaviors would be lost",
+			stateID: "work",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[1].Entry = &Behavior{ID: "enter_work", Specification: "log()"}
+			},
+			errContains: "would be lost",
+		},
+		{
+			name:        "several completion transitions",
+			stateID:     "work",
+			modify:      func(sm *StateMachine) { withCompletions(sm, "d", "a", "final") },
+			errContains: "cannot inline state 'work': it has 3 completion transitions ('done1', 'done2', 'done3')",
+		},
+		{
+			name:        "final state without completion transition",
+			stateID:     "work",
+			modify:      func(sm *StateMachine) { withCompletions(sm) },
+			errContains: "has final states but no completion transition",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createLinearStateMachine()
+			if _, err := ExtractCompositeState(sm, []string{"b", "c"}, "work", "Work"); err != nil {
+				t.Fatalf("ExtractCompositeState() error = %v", err)
+			}
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			err := InlineCompositeState(sm, tt.stateID)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("InlineCompositeState() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,882 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// maxInterpreterSteps bounds the number of run-to-completion steps and
+// compound transition segments processed per call, so that a model with a
+// completion loop or a junction cycle cannot hang the interpreter
+const maxInterpreterSteps = 10000
+
+// EventOccurrence is an event dispatched to an Interpreter. It triggers the
+// transitions whose triggers have an EventKey equal to EventID.
+type EventOccurrence struct {
+	EventID string                 `json:"event_id"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// InterpreterHooks let callers run actions and observe an Interpreter. Every
+// hook is optional.
+type InterpreterHooks struct {
+	// Executor runs entry, exit and do activity behaviors and transition
+	// effects and evaluates guards. Without one, behaviors do nothing and
+	// every guard holds. Either way, a guard specified as "else" only holds
+	// when no other transition leaving the same pseudostate is enabled.
+	Executor ActionExecutor
+
+	OnEntry      func(vertexID string)                                     // A state or final state was entered
+	OnExit       func(vertexID string)                                     // A state or final state was exited
+	OnTransition func(transition *Transition, occurrence *EventOccurrence) // A transition segment fired
+	OnDefer      func(occurrence EventOccurrence)                          // An event was deferred by an active state
+	OnUnhandled  func(occurrence EventOccurrence)                          // An event was discarded without firing a transition
+}
+
+// Interpreter executes a state machine with UML run-to-completion semantics.
+// Each event is processed completely before the next one is taken from the
+// queues: completion events first, then events raised internally with Raise,
+// then external events sent with Send or Dispatch. Orthogonal regions are
+// active concurrently and an event may fire one transition in each of them.
+// Events that no transition consumes are deferred if an active state lists
+// them in its DeferrableTriggers, and released once no active state defers
+// them any more.
+//
+// Submachine states are treated as simple states; the interpreter does not
+// enter the referenced state machine. An Interpreter is not safe for
+// concurrent use.
+type Interpreter struct {
+	sm    *StateMachine
+	hooks InterpreterHooks
+
+	states   map[string]*State
+	vertices map[string]*Vertex
+	regionOf map[string]*Region         // Vertex ID -> containing region
+	ownerOf  map[*Region]*State         // Region -> owning state, nil for top-level regions
+	depthOf  map[string]int             // Vertex ID -> region nesting depth
+	order    map[string]int             // Vertex ID -> document order
+	outgoing map[string][]*Transition   // Vertex ID -> transitions leaving it
+	incoming map[string][]*Transition   // Vertex ID -> transitions entering it
+	regionID map[*Region]string         // Region -> ID, for RegionConfiguration
+	arrivals map[string]map[string]bool // Join ID -> IDs of the incoming transitions that reached it
+
+	active    map[*Region]string // Region -> active vertex ID
+	history   map[*Region]string // Region -> last active vertex ID
+	variables map[string]interface{}
+
+	completions []string // IDs of states whose completion event is pending
+	internal    []EventOccurrence
+	external    []EventOccurrence
+	deferred    []EventOccurrence
+
+	started    bool
+	running    bool
+	terminated bool
+	steps      int
+}
+
+// NewInterpreter returns an interpreter for sm. It does not enter the initial
+// configuration until Start is called.
+func NewInterpreter(sm *StateMachine, hooks InterpreterHooks) (*Interpreter, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+
+	in := &Interpreter{
+		sm:       sm,
+		hooks:    hooks,
+		states:   make(map[string]*State),
+		vertices: make(map[string]*Vertex),
+		regionOf: make(map[string]*Region),
+		ownerOf:  make(map[*Region]*State),
+		depthOf:  make(map[string]int),
+		order:    make(map[string]int),
+		outgoing: make(map[string][]*Transition),
+		incoming: make(map[string][]*Transition),
+		regionID: make(map[*Region]string),
+		arrivals: make(map[string]map[string]bool),
+		active:   make(map[*Region]string),
+		history:  make(map[*Region]string),
+
+		variables: make(map[string]interface{}),
+	}
+
+	type pendingRegion struct {
+		region *Region
+		owner  *State
+		depth  int
+	}
+	var stack []pendingRegion
+	push := func(regions []*Region, owner *State, depth int) {
+		for i := len(regions) - 1; i >= 0; i-- {
+			if regions[i] != nil {
+				stack = append(stack, pendingRegion{region: regions[i], owner: owner, depth: depth})
+			}
+		}
+	}
+	index := func(id string, region *Region, depth int) {
+		if _, exists := in.order[id]; !exists {
+			in.order[id] = len(in.order)
+		}
+		in.regionOf[id] = region
+		in.depthOf[id] = depth
+	}
+
+	push(sm.Regions, nil, 1)
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, seen := in.ownerOf[next.region]; seen {
+			continue
+		}
+		in.ownerOf[next.region] = next.owner
+		in.regionID[next.region] = next.region.ID
+
+		for _, vertex := range next.region.Vertices {
+			if vertex != nil {
+				in.vertices[vertex.ID] = vertex
+				index(vertex.ID, next.region, next.depth)
+			}
+		}
+		for _, transition := range next.region.Transitions {
+			if transition == nil || transition.Source == nil || transition.Target == nil {
+				continue
+			}
+			in.outgoing[transition.Source.ID] = append(in.outgoing[transition.Source.ID], transition)
+			in.incoming[transition.Target.ID] = append(in.incoming[transition.Target.ID], transition)
+		}
+		for i := len(next.region.States) - 1; i >= 0; i-- {
+			state := next.region.States[i]
+			if state == nil {
+				continue
+			}
+			in.states[state.ID] = state
+			index(state.ID, next.region, next.depth)
+			push(state.Regions, state, next.depth+1)
+		}
+	}
+
+	if len(in.topLevelRegions()) == 0 {
+		return nil, fmt.Errorf("state machine '%s' has no regions to execute", sm.ID)
+	}
+	return in, nil
+}
+
+// Start enters the initial configuration of every top-level region and runs
+// the resulting completion transitions
+func (in *Interpreter) Start() error {
+	if in.started {
+		return fmt.Errorf("interpreter for state machine '%s' has already been started", in.sm.ID)
+	}
+	in.started = true
+	for _, region := range in.topLevelRegions() {
+		if err := in.enterRegion(region, nil); err != nil {
+			return err
+		}
+	}
+	return in.run()
+}
+
+// Send dispatches an external event identified by its event key
+func (in *Interpreter) Send(eventID string) error {
+	return in.Dispatch(EventOccurrence{EventID: eventID})
+}
+
+// Dispatch queues an external event and processes the queues until they are
+// empty. Called from a hook while a step is running, it only queues the
+// event, which is processed after the current step.
+func (in *Interpreter) Dispatch(occurrence EventOccurrence) error {
+	if !in.started {
+		return fmt.Errorf("interpreter for state machine '%s' has not been started", in.sm.ID)
+	}
+	in.external = append(in.external, occurrence)
+	return in.run()
+}
+
+// Raise queues an internal event. Internal events are processed before any
+// external event, so actions can use Raise to signal other orthogonal regions
+// within the same macro step.
+func (in *Interpreter) Raise(occurrence EventOccurrence) error {
+	if !in.started {
+		return fmt.Errorf("interpreter for state machine '%s' has not been started", in.sm.ID)
+	}
+	in.internal = append(in.internal, occurrence)
+	return in.run()
+}
+
+// Variables returns the extended state shared by the executor's actions
+// through ExecutionContext.Variables
+func (in *Interpreter) Variables() map[string]interface{} {
+	return in.variables
+}
+
+// Configuration returns the IDs of the active states and final states in
+// document order, composite states before their substates
+func (in *Interpreter) Configuration() []string {
+	ids := make([]string, 0, len(in.active))
+	for _, id := range in.active {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return in.order[ids[i]] < in.order[ids[j]] })
+	return ids
+}
+
+// RegionConfiguration maps the ID of every active region to the ID of its
+// active vertex, showing the concurrent configuration of orthogonal regions
+func (in *Interpreter) RegionConfiguration() map[string]string {
+	configuration := make(map[string]string, len(in.active))
+	for region, id := range in.active {
+		configuration[in.regionID[region]] = id
+	}
+	return configuration
+}
+
+// IsActive reports whether the state or final state with the given ID is
+// part of the active configuration
+func (in *Interpreter) IsActive(id string) bool {
+	region, ok := in.regionOf[id]
+	return ok && in.active[region] == id
+}
+
+// Deferred returns the events that are currently deferred, oldest first
+func (in *Interpreter) Deferred() []EventOccurrence {
+	return slices.Clone(in.deferred)
+}
+
+// IsTerminated reports whether a terminate pseudostate has been reached. A
+// terminated interpreter ignores further events.
+func (in *Interpreter) IsTerminated() bool {
+	return in.terminated
+}
+
+// IsFinished reports whether every top-level region has reached a final
+// state, or the interpreter has terminated
+func (in *Interpreter) IsFinished() bool {
+	if in.terminated {
+		return true
+	}
+	if !in.started {
+		return false
+	}
+	for _, region := range in.topLevelRegions() {
+		if !in.isFinal(in.active[region]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Enabled returns the transitions that the event would fire in the current
+// configuration, evaluating guards without event data
+func (in *Interpreter) Enabled(eventID string) ([]*Transition, error) {
+	return in.enabled(&EventOccurrence{EventID: eventID})
+}
+
+// run processes queued completion, internal and external events until the
+// queues are empty
+func (in *Interpreter) run() error {
+	if in.running {
+		return nil
+	}
+	in.running = true
+	defer func() { in.running = false }()
+
+	in.steps = 0
+	for !in.terminated {
+		if len(in.completions) > 0 {
+			id := in.completions[0]
+			in.completions = in.completions[1:]
+			if err := in.complete(id); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var occurrence EventOccurrence
+		switch {
+		case len(in.internal) > 0:
+			occurrence, in.internal = in.internal[0], in.internal[1:]
+		case len(in.external) > 0:
+			occurrence, in.external = in.external[0], in.external[1:]
+		default:
+			return nil
+		}
+		if err := in.step(occurrence); err != nil {
+			return err
+		}
+	}
+	in.completions, in.internal, in.external = nil, nil, nil
+	return nil
+}
+
+// step processes one event to completion
+func (in *Interpreter) step(occurrence EventOccurrence) error {
+	if err := in.count(); err != nil {
+		return err
+	}
+	transitions, err := in.enabled(&occurrence)
+	if err != nil {
+		return err
+	}
+	if len(transitions) == 0 {
+		if in.defers(occurrence.EventID) {
+			in.deferred = append(in.deferred, occurrence)
+			if in.hooks.OnDefer != nil {
+				in.hooks.OnDefer(occurrence)
+			}
+		} else if in.hooks.OnUnhandled != nil {
+			in.hooks.OnUnhandled(occurrence)
+		}
+		return nil
+	}
+
+	for _, transition := range transitions {
+		// An earlier transition of this step may have exited the source
+		if in.terminated || !in.IsActive(transition.Source.ID) {
+			continue
+		}
+		if err := in.fire(transition, &occurrence); err != nil {
+			return err
+		}
+	}
+	in.releaseDeferred()
+	return nil
+}
+
+// complete processes the completion event of a state, firing the first of
+// its enabled completion transitions, or its "else" one if no other is
+// enabled
+func (in *Interpreter) complete(id string) error {
+	if !in.IsActive(id) {
+		return nil
+	}
+	if err := in.count(); err != nil {
+		return err
+	}
+	transition, err := in.selectOutgoing(in.outgoing[id], func(t *Transition) bool {
+		return len(t.Triggers) == 0 && t.Kind != TransitionKindInternal
+	}, nil)
+	if err != nil || transition == nil {
+		return err
+	}
+	if err := in.fire(transition, nil); err != nil {
+		return err
+	}
+	in.releaseDeferred()
+	return nil
+}
+
+// count records a step and fails once maxInterpreterSteps is exceeded
+func (in *Interpreter) count() error {
+	in.steps++
+	if in.steps > maxInterpreterSteps {
+		return fmt.Errorf("state machine '%s' did not come to rest within %d steps", in.sm.ID, maxInterpreterSteps)
+	}
+	return nil
+}
+
+// enabled selects the transitions an event fires. Transitions leaving deeper
+// states take priority over those leaving their ancestors, at most one
+// transition fires per active state, taking an "else" transition only if no
+// other one of the state is enabled, and transitions in different orthogonal
+// regions fire together.
+func (in *Interpreter) enabled(occurrence *EventOccurrence) ([]*Transition, error) {
+	sources := in.activeStates()
+	sort.SliceStable(sources, func(i, j int) bool { return in.depthOf[sources[i]] > in.depthOf[sources[j]] })
+
+	var selected []*Transition
+	for _, source := range sources {
+		if slices.ContainsFunc(selected, func(t *Transition) bool {
+			return in.isAncestor(source, t.Source.ID) || in.isAncestor(t.Source.ID, source)
+		}) {
+			continue
+		}
+		transition, err := in.selectOutgoing(in.outgoing[source], func(t *Transition) bool {
+			return triggeredBy(t, occurrence.EventID)
+		}, occurrence)
+		if err != nil {
+			return nil, err
+		}
+		if transition != nil {
+			selected = append(selected, transition)
+		}
+	}
+	return selected, nil
+}
+
+// selectOutgoing returns the first of the transitions accepted by accept
+// whose guard holds, taking an "else" transition only if no other one is
+// enabled, or nil if none is
+func (in *Interpreter) selectOutgoing(transitions []*Transition, accept func(*Transition) bool, occurrence *EventOccurrence) (*Transition, error) {
+	var fallback *Transition
+	for _, transition := range transitions {
+		if !accept(transition) {
+			continue
+		}
+		if isElseGuard(transition.Guard) {
+			if fallback == nil {
+				fallback = transition
+			}
+			continue
+		}
+		holds, err := in.guard(transition, occurrence)
+		if err != nil {
+			return nil, err
+		}
+		if holds {
+			return transition, nil
+		}
+	}
+	return fallback, nil
+}
+
+// triggeredBy reports whether one of the transition's triggers matches the
+// event key
+func triggeredBy(transition *Transition, eventID string) bool {
+	for _, trigger := range transition.Triggers {
+		if trigger != nil && trigger.EventKey() == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// guard evaluates the transition's guard. "else" guards are not evaluated:
+// selectOutgoing takes their transitions after all others.
+func (in *Interpreter) guard(transition *Transition, occurrence *EventOccurrence) (bool, error) {
+	if transition.Guard == nil || in.hooks.Executor == nil {
+		return true, nil
+	}
+	holds, err := in.hooks.Executor.EvaluateGuard(transition.Guard, in.executionContext(occurrence))
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate guard of transition '%s': %w", transition.ID, err)
+	}
+	return holds, nil
+}
+
+// isElseGuard reports whether the guard is the "else" guard of a choice or
+// junction
+func isElseGuard(guard *Constraint) bool {
+	return guard != nil && guard.Specification == "else"
+}
+
+// fire takes a transition segment: it exits the states the transition
+// leaves, runs the effect and enters the target
+func (in *Interpreter) fire(transition *Transition, occurrence *EventOccurrence) error {
+	if err := in.count(); err != nil {
+		return err
+	}
+	source, target := transition.Source.ID, transition.Target.ID
+	if in.hooks.OnTransition != nil {
+		in.hooks.OnTransition(transition, occurrence)
+	}
+	if transition.Kind == TransitionKindInternal {
+		return in.execute(transition.Effect, occurrence)
+	}
+
+	scope := in.scope(source, target, transition.Kind)
+	if in.kindOf(target) == PseudostateKindJoin && in.IsActive(source) {
+		// Only the source is left until every region has reached the join
+		scope = in.regionOf[source]
+	}
+	if scope != nil {
+		if id := in.active[scope]; id != "" {
+			if err := in.exitVertex(id, occurrence); err != nil {
+				return err
+			}
+		}
+	}
+	if err := in.execute(transition.Effect, occurrence); err != nil {
+		return err
+	}
+
+	if in.kindOf(target) == PseudostateKindJoin {
+		return in.arrive(target, transition, occurrence)
+	}
+	return in.enterTargets([]string{target}, occurrence)
+}
+
+// scope returns the region whose active vertex a transition exits: the
+// innermost region containing both source and target, or for a local
+// transition into its own source's substates, the region of the source
+// containing the target. It returns nil if the source is a pseudostate.
+func (in *Interpreter) scope(source, target string, kind TransitionKind) *Region {
+	if !in.IsActive(source) {
+		return nil
+	}
+	targetRegions := in.enclosingRegions(target)
+	if kind == TransitionKindLocal && source != target {
+		for _, region := range targetRegions {
+			if in.ownerOf[region] != nil && in.ownerOf[region].ID == source {
+				return region
+			}
+		}
+	}
+	for _, region := range in.enclosingRegions(source) {
+		if slices.Contains(targetRegions, region) {
+			return region
+		}
+	}
+	// Source and target share no region; exit the source's outermost ancestor
+	if regions := in.enclosingRegions(source); len(regions) > 0 {
+		return regions[len(regions)-1]
+	}
+	return nil
+}
+
+// enclosingRegions returns the regions containing the vertex, innermost first
+func (in *Interpreter) enclosingRegions(id string) []*Region {
+	var regions []*Region
+	for region := in.regionOf[id]; region != nil; {
+		regions = append(regions, region)
+		owner := in.ownerOf[region]
+		if owner == nil {
+			break
+		}
+		region = in.regionOf[owner.ID]
+	}
+	return regions
+}
+
+// isAncestor reports whether the state ancestor contains the vertex id
+func (in *Interpreter) isAncestor(ancestor, id string) bool {
+	for _, region := range in.enclosingRegions(id) {
+		if owner := in.ownerOf[region]; owner != nil && owner.ID == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// arrive records a transition reaching a join and fires the join's outgoing
+// transition once every incoming transition has arrived
+func (in *Interpreter) arrive(join string, transition *Transition, occurrence *EventOccurrence) error {
+	if in.arrivals[join] == nil {
+		in.arrivals[join] = make(map[string]bool)
+	}
+	in.arrivals[join][transition.ID] = true
+	for _, incoming := range in.incoming[join] {
+		if !in.arrivals[join][incoming.ID] {
+			return nil
+		}
+	}
+	delete(in.arrivals, join)
+
+	// Exit the state containing the joined regions before continuing
+	if id := in.active[in.regionOf[join]]; id != "" && in.isAncestor(id, transition.Source.ID) {
+		if err := in.exitVertex(id, occurrence); err != nil {
+			return err
+		}
+	}
+	return in.leavePseudostate(join, occurrence)
+}
+
+// enterTargets enters the given vertices together with any of their
+// ancestors that are not active yet. Regions of the entered ancestors that
+// none of the targets lies in are entered through their initial pseudostate,
+// so that a fork into some regions of an orthogonal state activates all of
+// them.
+func (in *Interpreter) enterTargets(targets []string, occurrence *EventOccurrence) error {
+	var path []*State
+	for _, target := range targets {
+		var ancestors []*State
+		for _, region := range in.enclosingRegions(target) {
+			owner := in.ownerOf[region]
+			if owner == nil || in.IsActive(owner.ID) {
+				break
+			}
+			ancestors = append([]*State{owner}, ancestors...)
+		}
+		for _, ancestor := range ancestors {
+			if !slices.Contains(path, ancestor) {
+				path = append(path, ancestor)
+			}
+		}
+	}
+
+	for _, ancestor := range path {
+		if err := in.activate(ancestor.ID, occurrence); err != nil {
+			return err
+		}
+	}
+	for _, target := range targets {
+		if err := in.enterVertex(target, occurrence); err != nil {
+			return err
+		}
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		for _, region := range path[i].Regions {
+			if region != nil && in.active[region] == "" {
+				if err := in.enterRegion(region, occurrence); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// enterRegion enters a region through its initial pseudostate. A region
+// without one stays inactive.
+func (in *Interpreter) enterRegion(region *Region, occurrence *EventOccurrence) error {
+	initials := regionInitialIDs(region)
+	if len(initials) == 0 {
+		return nil
+	}
+	return in.leavePseudostate(initials[0], occurrence)
+}
+
+// enterVertex enters a vertex whose ancestors are already active
+func (in *Interpreter) enterVertex(id string, occurrence *EventOccurrence) error {
+	if state, isState := in.states[id]; isState {
+		if err := in.activate(id, occurrence); err != nil {
+			return err
+		}
+		for _, region := range state.Regions {
+			if region != nil && in.active[region] == "" {
+				if err := in.enterRegion(region, occurrence); err != nil {
+					return err
+				}
+			}
+		}
+		if len(state.Regions) == 0 {
+			in.completions = append(in.completions, id)
+		}
+		return nil
+	}
+
+	if vertex := in.vertices[id]; vertex != nil && vertex.Type == "state" {
+		// A state listed among the region's vertices only; enter it as a simple state
+		in.active[in.regionOf[id]] = id
+		if in.hooks.OnEntry != nil {
+			in.hooks.OnEntry(id)
+		}
+		in.completions = append(in.completions, id)
+		return nil
+	}
+
+	if in.isFinal(id) {
+		region := in.regionOf[id]
+		in.active[region] = id
+		if in.hooks.OnEntry != nil {
+			in.hooks.OnEntry(id)
+		}
+		if owner := in.ownerOf[region]; owner != nil && in.regionsFinal(owner) {
+			in.completions = append(in.completions, owner.ID)
+		}
+		return nil
+	}
+
+	switch kind := in.kindOf(id); kind {
+	case PseudostateKindTerminate:
+		in.terminated = true
+		return nil
+	case PseudostateKindFork:
+		var targets []string
+		for _, transition := range in.outgoing[id] {
+			if in.hooks.OnTransition != nil {
+				in.hooks.OnTransition(transition, occurrence)
+			}
+			if err := in.execute(transition.Effect, occurrence); err != nil {
+				return err
+			}
+			targets = append(targets, transition.Target.ID)
+		}
+		return in.enterTargets(targets, occurrence)
+	case PseudostateKindShallowHistory, PseudostateKindDeepHistory:
+		return in.enterHistory(id, kind == PseudostateKindDeepHistory, occurrence)
+	default:
+		return in.leavePseudostate(id, occurrence)
+	}
+}
+
+// leavePseudostate continues a compound transition through an initial,
+// junction, choice, entry point or exit point pseudostate by firing its
+// first enabled outgoing transition, taking an "else" transition only if no
+// other one is enabled
+func (in *Interpreter) leavePseudostate(id string, occurrence *EventOccurrence) error {
+	transition, err := in.selectOutgoing(in.outgoing[id], func(*Transition) bool { return true }, occurrence)
+	if err != nil {
+		return err
+	}
+	if transition != nil {
+		return in.fire(transition, occurrence)
+	}
+	return fmt.Errorf("no enabled transition leaves pseudostate '%s'", id)
+}
+
+// enterHistory restores the configuration a region had when it was last
+// exited, or follows the history pseudostate's default transition if the
+// region has not been active before
+func (in *Interpreter) enterHistory(id string, deep bool, occurrence *EventOccurrence) error {
+	region := in.regionOf[id]
+	last := in.history[region]
+	if last == "" {
+		if len(in.outgoing[id]) > 0 {
+			return in.leavePseudostate(id, occurrence)
+		}
+		return in.enterRegion(region, occurrence)
+	}
+	if !deep {
+		return in.enterVertex(last, occurrence)
+	}
+	return in.restore(last, occurrence)
+}
+
+// restore re-enters a vertex and, recursively, the last active vertices of
+// its regions
+func (in *Interpreter) restore(id string, occurrence *EventOccurrence) error {
+	state, isState := in.states[id]
+	if !isState || len(state.Regions) == 0 {
+		return in.enterVertex(id, occurrence)
+	}
+	if err := in.activate(id, occurrence); err != nil {
+		return err
+	}
+	for _, region := range state.Regions {
+		if region == nil {
+			continue
+		}
+		var err error
+		if last := in.history[region]; last != "" {
+			err = in.restore(last, occurrence)
+		} else {
+			err = in.enterRegion(region, occurrence)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activate marks a state active and runs its entry behavior and do activity
+func (in *Interpreter) activate(id string, occurrence *EventOccurrence) error {
+	state := in.states[id]
+	in.active[in.regionOf[id]] = id
+	if in.hooks.OnEntry != nil {
+		in.hooks.OnEntry(id)
+	}
+	if err := in.execute(state.Entry, occurrence); err != nil {
+		return err
+	}
+	return in.execute(state.DoActivity, occurrence)
+}
+
+// exitVertex exits an active vertex after exiting its active substates,
+// innermost first, and records it for history pseudostates
+func (in *Interpreter) exitVertex(id string, occurrence *EventOccurrence) error {
+	if state, isState := in.states[id]; isState {
+		for _, region := range state.Regions {
+			if active := in.active[region]; region != nil && active != "" {
+				if err := in.exitVertex(active, occurrence); err != nil {
+					return err
+				}
+			}
+		}
+		if err := in.execute(state.Exit, occurrence); err != nil {
+			return err
+		}
+	}
+	region := in.regionOf[id]
+	delete(in.active, region)
+	in.history[region] = id
+	if in.hooks.OnExit != nil {
+		in.hooks.OnExit(id)
+	}
+	return nil
+}
+
+// execute runs a behavior through the executor
+func (in *Interpreter) execute(behavior *Behavior, occurrence *EventOccurrence) error {
+	if behavior == nil || in.hooks.Executor == nil {
+		return nil
+	}
+	if err := in.hooks.Executor.ExecuteBehavior(behavior, in.executionContext(occurrence)); err != nil {
+		return fmt.Errorf("behavior '%s' failed: %w", behavior.ID, err)
+	}
+	return nil
+}
+
+// executionContext returns the context passed to the executor
+func (in *Interpreter) executionContext(occurrence *EventOccurrence) *ExecutionContext {
+	return &ExecutionContext{Interpreter: in, Event: occurrence, Variables: in.variables}
+}
+
+// defers reports whether an active state lists the event among its
+// deferrable triggers
+func (in *Interpreter) defers(eventID string) bool {
+	for _, id := range in.activeStates() {
+		state, isState := in.states[id]
+		if !isState {
+			continue
+		}
+		for _, trigger := range state.DeferrableTriggers {
+			if trigger != nil && trigger.EventKey() == eventID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// releaseDeferred moves the deferred events no active state defers any more
+// back to the front of the internal queue, keeping their order
+func (in *Interpreter) releaseDeferred() {
+	var released, kept []EventOccurrence
+	for _, occurrence := range in.deferred {
+		if in.defers(occurrence.EventID) {
+			kept = append(kept, occurrence)
+		} else {
+			released = append(released, occurrence)
+		}
+	}
+	in.deferred = kept
+	in.internal = append(released, in.internal...)
+}
+
+// activeStates returns the IDs of the active states, excluding final
+// states, in document order
+func (in *Interpreter) activeStates() []string {
+	var ids []string
+	for _, id := range in.Configuration() {
+		if !in.isFinal(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// regionsFinal reports whether every region of the state is in a final state
+func (in *Interpreter) regionsFinal(state *State) bool {
+	for _, region := range state.Regions {
+		if region != nil && !in.isFinal(in.active[region]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isFinal reports whether the vertex is a final state
+func (in *Interpreter) isFinal(id string) bool {
+	vertex, ok := in.vertices[id]
+	return ok && vertex.Type == "finalstate"
+}
+
+// kindOf returns the pseudostate kind of a vertex, recognizing terminate,
+// entry point and exit point pseudostates by name as well
+func (in *Interpreter) kindOf(id string) PseudostateKind {
+	return pseudostateKindOf(in.vertices[id])
+}
+
+// topLevelRegions returns the non-nil top-level regions
+func (in *Interpreter) topLevelRegions() []*Region {
+	var regions []*Region
+	for _, region := range in.sm.Regions {
+		if region != nil {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
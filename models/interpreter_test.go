@@ -0,0 +1,533 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Helpers for building executable fixtures. Pseudostate kinds are taken from
+// the vertex name, as vertexPseudostateKind expects.
+
+func execState(id string, regions ...*Region) *State {
+	return &State{
+		Vertex:       Vertex{ID: id, Name: id, Type: "state"},
+		IsSimple:     len(regions) == 0,
+		IsComposite:  len(regions) > 0,
+		IsOrthogonal: len(regions) > 1,
+		Regions:      regions,
+	}
+}
+
+func execPseudostate(id, kind string) *Vertex {
+	return &Vertex{ID: id, Name: kind, Type: "pseudostate"}
+}
+
+func execFinal(id string) *Vertex {
+	return &Vertex{ID: id, Name: id, Type: "finalstate"}
+}
+
+func execTransition(id string, source, target *Vertex, events ...string) *Transition {
+	transition := &Transition{ID: id, Source: source, Target: target, Kind: TransitionKindExternal}
+	for _, event := range events {
+		transition.Triggers = append(transition.Triggers, &Trigger{ID: id + "-" + event, EventID: event})
+	}
+	return transition
+}
+
+// newPlayerMachine builds a media player: idle --play--> playing, an
+// orthogonal state whose audio region goes loading --loaded--> streaming and
+// whose video region goes buffering --ready--> rendering. Both regions leave
+// on "tick"; playing --stop--> idle. Idle defers "loaded".
+func newPlayerMachine() *StateMachine {
+	audioInitial := execPseudostate("audio-initial", "initial")
+	loading := execState("loading")
+	streaming := execState("streaming")
+	audio := &Region{
+		ID:       "audio",
+		States:   []*State{loading, streaming},
+		Vertices: []*Vertex{audioInitial},
+		Transitions: []*Transition{
+			execTransition("audio-start", audioInitial, &loading.Vertex),
+			execTransition("audio-loaded", &loading.Vertex, &streaming.Vertex, "loaded"),
+			execTransition("audio-tick", &loading.Vertex, &loading.Vertex, "tick"),
+		},
+	}
+
+	videoInitial := execPseudostate("video-initial", "initial")
+	buffering := execState("buffering")
+	rendering := execState("rendering")
+	video := &Region{
+		ID:       "video",
+		States:   []*State{buffering, rendering},
+		Vertices: []*Vertex{videoInitial},
+		Transitions: []*Transition{
+			execTransition("video-start", videoInitial, &buffering.Vertex),
+			execTransition("video-ready", &buffering.Vertex, &rendering.Vertex, "ready"),
+			execTransition("video-tick", &buffering.Vertex, &buffering.Vertex, "tick"),
+		},
+	}
+
+	initial := execPseudostate("initial", "initial")
+	idle := execState("idle")
+	idle.DeferrableTriggers = []*Trigger{{ID: "defer-loaded", EventID: "loaded"}}
+	playing := execState("playing", audio, video)
+	return &StateMachine{
+		ID:      "player",
+		Name:    "Player",
+		Version: "1.0",
+		Regions: []*Region{{
+			ID:       "main",
+			States:   []*State{idle, playing},
+			Vertices: []*Vertex{initial},
+			Transitions: []*Transition{
+				execTransition("start", initial, &idle.Vertex),
+				execTransition("play", &idle.Vertex, &playing.Vertex, "play"),
+				execTransition("stop", &playing.Vertex, &idle.Vertex, "stop"),
+			},
+		}},
+	}
+}
+
+// startInterpreter creates and starts an interpreter, failing the test on error
+func startInterpreter(t *testing.T, sm *StateMachine, hooks InterpreterHooks) *Interpreter {
+	t.Helper()
+	in, err := NewInterpreter(sm, hooks)
+	if err != nil {
+		t.Fatalf("NewInterpreter() error = %v", err)
+	}
+	if err := in.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	return in
+}
+
+func TestInterpreter_Configuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []string
+		want    []string
+		regions map[string]string
+	}{
+		{
+			name:    "initial configuration",
+			want:    []string{"idle"},
+			regions: map[string]string{"main": "idle"},
+		},
+		{
+			name:    "entering an orthogonal state enters every region",
+			events:  []string{"play"},
+			want:    []string{"playing", "loading", "buffering"},
+			regions: map[string]string{"main": "playing", "audio": "loading", "video": "buffering"},
+		},
+		{
+			name:    "regions move independently",
+			events:  []string{"play", "ready"},
+			want:    []string{"playing", "loading", "rendering"},
+			regions: map[string]string{"main": "playing", "audio": "loading", "video": "rendering"},
+		},
+		{
+			name:    "leaving an orthogonal state exits every region",
+			events:  []string{"play", "ready", "stop"},
+			want:    []string{"idle"},
+			regions: map[string]string{"main": "idle"},
+		},
+		{
+			name:    "unhandled events are discarded",
+			events:  []string{"stop", "ready"},
+			want:    []string{"idle"},
+			regions: map[string]string{"main": "idle"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := startInterpreter(t, newPlayerMachine(), InterpreterHooks{})
+			for _, event := range tt.events {
+				if err := in.Send(event); err != nil {
+					t.Fatalf("Send(%q) error = %v", event, err)
+				}
+			}
+			if got := in.Configuration(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Configuration() = %v, want %v", got, tt.want)
+			}
+			if got := in.RegionConfiguration(); !reflect.DeepEqual(got, tt.regions) {
+				t.Errorf("RegionConfiguration() = %v, want %v", got, tt.regions)
+			}
+		})
+	}
+}
+
+func TestInterpreter_Hooks(t *testing.T) {
+	var trace []string
+	hooks := InterpreterHooks{
+		OnEntry:      func(id string) { trace = append(trace, "enter "+id) },
+		OnExit:       func(id string) { trace = append(trace, "exit "+id) },
+		OnTransition: func(transition *Transition, _ *EventOccurrence) { trace = append(trace, "fire "+transition.ID) },
+		OnUnhandled:  func(occurrence EventOccurrence) { trace = append(trace, "drop "+occurrence.EventID) },
+	}
+	in := startInterpreter(t, newPlayerMachine(), hooks)
+	for _, event := range []string{"play", "tick", "stop", "bogus"} {
+		if err := in.Send(event); err != nil {
+			t.Fatalf("Send(%q) error = %v", event, err)
+		}
+	}
+
+	want := []string{
+		"fire start", "enter idle",
+		"fire play", "exit idle", "enter playing",
+		"fire audio-start", "enter loading", "fire video-start", "enter buffering",
+		// One event fires a transition in each orthogonal region
+		"fire audio-tick", "exit loading", "enter loading",
+		"fire video-tick", "exit buffering", "enter buffering",
+		// Substates are exited before their composite state
+		"fire stop", "exit loading", "exit buffering", "exit playing", "enter idle",
+		"drop bogus",
+	}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %v\nwant %v", trace, want)
+	}
+}
+
+func TestInterpreter_DeferredEvents(t *testing.T) {
+	var deferred []string
+	in := startInterpreter(t, newPlayerMachine(), InterpreterHooks{
+		OnDefer: func(occurrence EventOccurrence) { deferred = append(deferred, occurrence.EventID) },
+	})
+
+	if err := in.Send("loaded"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := in.Deferred(); len(got) != 1 || got[0].EventID != "loaded" {
+		t.Fatalf("Deferred() = %v, want the loaded event", got)
+	}
+	if !reflect.DeepEqual(deferred, []string{"loaded"}) {
+		t.Errorf("OnDefer calls = %v, want [loaded]", deferred)
+	}
+
+	// Leaving idle releases the event, which is processed in playing
+	if err := in.Send("play"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := in.Deferred(); len(got) != 0 {
+		t.Errorf("Deferred() = %v, want none", got)
+	}
+	if !in.IsActive("streaming") {
+		t.Errorf("Configuration() = %v, want streaming to be active", in.Configuration())
+	}
+}
+
+func TestInterpreter_RaiseFromAction(t *testing.T) {
+	sm := newPlayerMachine()
+	streaming := sm.Regions[0].States[1].Regions[0].States[1]
+	streaming.Entry = &Behavior{ID: "announce", Specification: "raise ready"}
+
+	executor := NewFuncExecutor().RegisterAction("announce", func(ctx *ExecutionContext) error {
+		return ctx.Interpreter.Raise(EventOccurrence{EventID: "ready"})
+	})
+	in := startInterpreter(t, sm, InterpreterHooks{Executor: executor})
+	for _, event := range []string{"play", "loaded"} {
+		if err := in.Send(event); err != nil {
+			t.Fatalf("Send(%q) error = %v", event, err)
+		}
+	}
+	if want := []string{"playing", "streaming", "rendering"}; !reflect.DeepEqual(in.Configuration(), want) {
+		t.Errorf("Configuration() = %v, want %v", in.Configuration(), want)
+	}
+}
+
+func TestInterpreter_CompletionAndFinish(t *testing.T) {
+	// work is orthogonal; each region reaches its final state on its own
+	// event, and work completes to done once both have
+	aInitial, aFinal := execPseudostate("a-initial", "initial"), execFinal("a-final")
+	bInitial, bFinal := execPseudostate("b-initial", "initial"), execFinal("b-final")
+	a, b := execState("a"), execState("b")
+	regionA := &Region{ID: "ra", States: []*State{a}, Vertices: []*Vertex{aInitial, aFinal}, Transitions: []*Transition{
+		execTransition("a-start", aInitial, &a.Vertex),
+		execTransition("a-end", &a.Vertex, aFinal, "finish-a"),
+	}}
+	regionB := &Region{ID: "rb", States: []*State{b}, Vertices: []*Vertex{bInitial, bFinal}, Transitions: []*Transition{
+		execTransition("b-start", bInitial, &b.Vertex),
+		execTransition("b-end", &b.Vertex, bFinal, "finish-b"),
+	}}
+	initial, done := execPseudostate("initial", "initial"), execFinal("done")
+	work := execState("work", regionA, regionB)
+	sm := &StateMachine{ID: "jobs", Regions: []*Region{{
+		ID:       "main",
+		States:   []*State{work},
+		Vertices: []*Vertex{initial, done},
+		Transitions: []*Transition{
+			execTransition("start", initial, &work.Vertex),
+			execTransition("complete", &work.Vertex, done),
+		},
+	}}}
+
+	in := startInterpreter(t, sm, InterpreterHooks{})
+	if err := in.Send("finish-a"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if in.IsFinished() {
+		t.Fatal("IsFinished() = true after one region finished")
+	}
+	if err := in.Send("finish-b"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !in.IsFinished() {
+		t.Errorf("IsFinished() = false, configuration %v", in.Configuration())
+	}
+	if want := []string{"done"}; !reflect.DeepEqual(in.Configuration(), want) {
+		t.Errorf("Configuration() = %v, want %v", in.Configuration(), want)
+	}
+}
+
+func TestInterpreter_Pseudostates(t *testing.T) {
+	// newMachine builds idle --go--> choice, which leads to high when the
+	// guard holds and to low otherwise; idle --split--> fork into both
+	// regions of pair, whose substates meet at a join leading to merged;
+	// idle --halt--> terminate; pair --back--> idle and idle --resume--> the
+	// shallow history of pair's left region
+	newMachine := func() *StateMachine {
+		leftInitial, rightInitial := execPseudostate("left-initial", "initial"), execPseudostate("right-initial", "initial")
+		history := execPseudostate("left-history", "shallowHistory")
+		l1, l2, r1 := execState("l1"), execState("l2"), execState("r1")
+		left := &Region{ID: "left", States: []*State{l1, l2}, Vertices: []*Vertex{leftInitial, history}, Transitions: []*Transition{
+			execTransition("left-start", leftInitial, &l1.Vertex),
+			execTransition("left-next", &l1.Vertex, &l2.Vertex, "next"),
+		}}
+		right := &Region{ID: "right", States: []*State{r1}, Vertices: []*Vertex{rightInitial}, Transitions: []*Transition{
+			execTransition("right-start", rightInitial, &r1.Vertex),
+		}}
+		pair := execState("pair", left, right)
+
+		initial := execPseudostate("initial", "initial")
+		choice := execPseudostate("choice", "choice")
+		fork := execPseudostate("fork", "fork")
+		join := execPseudostate("join", "join")
+		terminate := execPseudostate("terminate", "terminate")
+		idle, high, low, merged := execState("idle"), execState("high"), execState("low"), execState("merged")
+		toHigh := execTransition("to-high", choice, &high.Vertex)
+		toHigh.Guard = &Constraint{ID: "big", Specification: "x > 10"}
+		toLow := execTransition("to-low", choice, &low.Vertex)
+		toLow.Guard = &Constraint{ID: "otherwise", Specification: "else"}
+		return &StateMachine{ID: "pseudo", Regions: []*Region{{
+			ID:       "main",
+			States:   []*State{idle, high, low, pair, merged},
+			Vertices: []*Vertex{initial, choice, fork, join, terminate},
+			Transitions: []*Transition{
+				execTransition("start", initial, &idle.Vertex),
+				execTransition("go", &idle.Vertex, choice, "go"),
+				toLow,
+				toHigh,
+				execTransition("split", &idle.Vertex, fork, "split"),
+				execTransition("fork-left", fork, &l2.Vertex),
+				execTransition("fork-right", fork, &r1.Vertex),
+				execTransition("join-left", &l2.Vertex, join, "merge"),
+				execTransition("join-right", &r1.Vertex, join, "merge"),
+				execTransition("joined", join, &merged.Vertex),
+				execTransition("back", &pair.Vertex, &idle.Vertex, "back"),
+				execTransition("resume", &idle.Vertex, history, "resume"),
+				execTransition("halt", &idle.Vertex, terminate, "halt"),
+			},
+		}}}
+	}
+
+	tests := []struct {
+		name       string
+		guard      bool
+		events     []string
+		want       []string
+		terminated bool
+	}{
+		{name: "choice takes the enabled branch", guard: true, events: []string{"go"}, want: []string{"high"}},
+		{name: "choice falls back to else", guard: false, events: []string{"go"}, want: []string{"low"}},
+		{name: "fork enters both regions", events: []string{"split"}, want: []string{"pair", "l2", "r1"}},
+		{name: "join waits for every region", events: []string{"split", "merge"}, want: []string{"merged"}},
+		{name: "history without a previous visit enters the region", events: []string{"resume"}, want: []string{"pair", "l1", "r1"}},
+		{name: "history restores the last substate", events: []string{"split", "back", "resume"}, want: []string{"pair", "l2", "r1"}},
+		{name: "terminate stops the machine", events: []string{"halt", "go"}, want: []string{}, terminated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewFuncExecutor().RegisterGuard("big", func(*ExecutionContext) (bool, error) { return tt.guard, nil })
+			in := startInterpreter(t, newMachine(), InterpreterHooks{Executor: executor})
+			for _, event := range tt.events {
+				if err := in.Send(event); err != nil {
+					t.Fatalf("Send(%q) error = %v", event, err)
+				}
+			}
+			if got := in.Configuration(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Configuration() = %v, want %v", got, tt.want)
+			}
+			if in.IsTerminated() != tt.terminated {
+				t.Errorf("IsTerminated() = %v, want %v", in.IsTerminated(), tt.terminated)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ElseGuardsLeavingStates(t *testing.T) {
+	// newMachine builds initial --> idle, whose "else" transition to low
+	// comes before its guarded one to high; both are triggered by go, or
+	// are completion transitions when events is empty
+	newMachine := func(events ...string) *StateMachine {
+		initial := execPseudostate("initial", "initial")
+		idle, high, low := execState("idle"), execState("high"), execState("low")
+		toLow := execTransition("to-low", &idle.Vertex, &low.Vertex, events...)
+		toLow.Guard = &Constraint{ID: "otherwise", Specification: "else"}
+		toHigh := execTransition("to-high", &idle.Vertex, &high.Vertex, events...)
+		toHigh.Guard = &Constraint{ID: "big", Specification: "x > 10"}
+		return &StateMachine{ID: "else", Regions: []*Region{{
+			ID:          "main",
+			States:      []*State{idle, high, low},
+			Vertices:    []*Vertex{initial},
+			Transitions: []*Transition{execTransition("start", initial, &idle.Vertex), toLow, toHigh},
+		}}}
+	}
+
+	tests := []struct {
+		name   string
+		guard  bool
+		events []string
+		want   []string
+	}{
+		{name: "event takes the guarded transition", guard: true, events: []string{"go"}, want: []string{"high"}},
+		{name: "event falls back to else", guard: false, events: []string{"go"}, want: []string{"low"}},
+		{name: "completion takes the guarded transition", guard: true, want: []string{"high"}},
+		{name: "completion falls back to else", guard: false, want: []string{"low"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewFuncExecutor().RegisterGuard("big", func(*ExecutionContext) (bool, error) { return tt.guard, nil })
+			in := startInterpreter(t, newMachine(tt.events...), InterpreterHooks{Executor: executor})
+			for _, event := range tt.events {
+				if err := in.Send(event); err != nil {
+					t.Fatalf("Send(%q) error = %v", event, err)
+				}
+			}
+			if got := in.Configuration(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Configuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_Enabled(t *testing.T) {
+	in := startInterpreter(t, newPlayerMachine(), InterpreterHooks{})
+	if err := in.Send("play"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	tests := []struct {
+		event string
+		want  []string
+	}{
+		{event: "tick", want: []string{"audio-tick", "video-tick"}},
+		{event: "stop", want: []string{"stop"}},
+		{event: "play", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			transitions, err := in.Enabled(tt.event)
+			if err != nil {
+				t.Fatalf("Enabled() error = %v", err)
+			}
+			var got []string
+			for _, transition := range transitions {
+				got = append(got, transition.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Enabled(%q) = %v, want %v", tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_Errors(t *testing.T) {
+	failure := errors.New("boom")
+
+	t.Run("nil state machine", func(t *testing.T) {
+		if _, err := NewInterpreter(nil, InterpreterHooks{}); err == nil {
+			t.Error("NewInterpreter(nil) error = nil")
+		}
+	})
+
+	t.Run("no regions", func(t *testing.T) {
+		if _, err := NewInterpreter(&StateMachine{ID: "empty"}, InterpreterHooks{}); err == nil {
+			t.Error("NewInterpreter() error = nil for a machine without regions")
+		}
+	})
+
+	t.Run("send before start", func(t *testing.T) {
+		in, err := NewInterpreter(newPlayerMachine(), InterpreterHooks{})
+		if err != nil {
+			t.Fatalf("NewInterpreter() error = %v", err)
+		}
+		if err := in.Send("play"); err == nil || !strings.Contains(err.Error(), "not been started") {
+			t.Errorf("Send() error = %v, want not started", err)
+		}
+	})
+
+	t.Run("start twice", func(t *testing.T) {
+		in := startInterpreter(t, newPlayerMachine(), InterpreterHooks{})
+		if err := in.Start(); err == nil {
+			t.Error("second Start() error = nil")
+		}
+	})
+
+	t.Run("failing behavior", func(t *testing.T) {
+		sm := newPlayerMachine()
+		sm.Regions[0].Transitions[1].Effect = &Behavior{ID: "spin-up"}
+		executor := NewFuncExecutor().RegisterAction("spin-up", func(*ExecutionContext) error { return failure })
+		in := startInterpreter(t, sm, InterpreterHooks{Executor: executor})
+		err := in.Send("play")
+		if !errors.Is(err, failure) || !strings.Contains(err.Error(), "spin-up") {
+			t.Errorf("Send() error = %v, want the behavior failure", err)
+		}
+	})
+
+	t.Run("failing guard", func(t *testing.T) {
+		sm := newPlayerMachine()
+		sm.Regions[0].Transitions[1].Guard = &Constraint{ID: "ok", Specification: "ok"}
+		executor := NewFuncExecutor().RegisterGuard("ok", func(*ExecutionContext) (bool, error) { return false, failure })
+		in := startInterpreter(t, sm, InterpreterHooks{Executor: executor})
+		if err := in.Send("play"); !errors.Is(err, failure) {
+			t.Errorf("Send() error = %v, want the guard failure", err)
+		}
+	})
+
+	t.Run("completion loop", func(t *testing.T) {
+		initial, a, b := execPseudostate("initial", "initial"), execState("a"), execState("b")
+		sm := &StateMachine{ID: "loop", Regions: []*Region{{
+			ID:       "main",
+			States:   []*State{a, b},
+			Vertices: []*Vertex{initial},
+			Transitions: []*Transition{
+				execTransition("start", initial, &a.Vertex),
+				execTransition("ab", &a.Vertex, &b.Vertex),
+				execTransition("ba", &b.Vertex, &a.Vertex),
+			},
+		}}}
+		in, err := NewInterpreter(sm, InterpreterHooks{})
+		if err != nil {
+			t.Fatalf("NewInterpreter() error = %v", err)
+		}
+		if err := in.Start(); err == nil || !strings.Contains(err.Error(), "did not come to rest") {
+			t.Errorf("Start() error = %v, want a step limit error", err)
+		}
+	})
+
+	t.Run("pseudostate without enabled transition", func(t *testing.T) {
+		initial := execPseudostate("initial", "initial")
+		sm := &StateMachine{ID: "stuck", Regions: []*Region{{ID: "main", Vertices: []*Vertex{initial}}}}
+		in, err := NewInterpreter(sm, InterpreterHooks{})
+		if err != nil {
+			t.Fatalf("NewInterpreter() error = %v", err)
+		}
+		if err := in.Start(); err == nil || !strings.Contains(err.Error(), "initial") {
+			t.Errorf("Start() error = %v, want the stuck pseudostate", err)
+		}
+	})
+}
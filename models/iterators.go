@@ -0,0 +1,121 @@
+package models
+
+import "iter"
+
+// The iterators below range over the elements of a model lazily, for use
+// with range-over-func:
+//
+//	for state, region := range sm.AllStates() {
+//		fmt.Println(region.ID, state.ID)
+//	}
+//
+// Each element is paired with the region declaring it. Regions are visited
+// depth first, each region's elements before the regions nested in its
+// states, and elements in declaration order; nil elements are skipped.
+// Breaking out of the loop stops the traversal. Regions with a Content
+// provider contribute the elements it supplies without storing them, and
+// their elements are paired with a transient copy of the region holding
+// them. Submachines are not entered.
+
+// AllStates returns an iterator over the states of the state machine at
+// any depth, paired with the region declaring each
+func (sm *StateMachine) AllStates() iter.Seq2[*State, *Region] {
+	return func(yield func(*State, *Region) bool) {
+		if sm == nil {
+			return
+		}
+		yieldStates(sm.Regions, yield)
+	}
+}
+
+// AllTransitions returns an iterator over the transitions of the state
+// machine at any depth, paired with the region declaring each
+func (sm *StateMachine) AllTransitions() iter.Seq2[*Transition, *Region] {
+	return func(yield func(*Transition, *Region) bool) {
+		if sm == nil {
+			return
+		}
+		yieldTransitions(sm.Regions, yield)
+	}
+}
+
+// AllVertices returns an iterator over the vertices of the state machine at
+// any depth, states as their embedded Vertex, paired with the region
+// declaring each
+func (sm *StateMachine) AllVertices() iter.Seq2[*Vertex, *Region] {
+	return func(yield func(*Vertex, *Region) bool) {
+		if sm == nil {
+			return
+		}
+		yieldVertices(sm.Regions, yield)
+	}
+}
+
+// AllStates returns an iterator over the states of the region and of the
+// regions nested in it, paired with the region declaring each
+func (r *Region) AllStates() iter.Seq2[*State, *Region] {
+	return func(yield func(*State, *Region) bool) {
+		if r == nil {
+			return
+		}
+		yieldStates([]*Region{r}, yield)
+	}
+}
+
+// AllTransitions returns an iterator over the transitions of the region and
+// of the regions nested in it, paired with the region declaring each
+func (r *Region) AllTransitions() iter.Seq2[*Transition, *Region] {
+	return func(yield func(*Transition, *Region) bool) {
+		if r == nil {
+			return
+		}
+		yieldTransitions([]*Region{r}, yield)
+	}
+}
+
+// AllVertices returns an iterator over the vertices of the region and of
+// the regions nested in it, states as their embedded Vertex, paired with
+// the region declaring each. The Vertices field holds only the region's own
+// non-state vertices.
+func (r *Region) AllVertices() iter.Seq2[*Vertex, *Region] {
+	return func(yield func(*Vertex, *Region) bool) {
+		if r == nil {
+			return
+		}
+		yieldVertices([]*Region{r}, yield)
+	}
+}
+
+// yieldStates yields the states of the regions at any depth
+func yieldStates(regions []*Region, yield func(*State, *Region) bool) {
+	for region := range regionTree(regions, "") {
+		for _, state := range region.States {
+			if state != nil && !yield(state, region) {
+				return
+			}
+		}
+	}
+}
+
+// yieldTransitions yields the transitions of the regions at any depth
+func yieldTransitions(regions []*Region, yield func(*Transition, *Region) bool) {
+	for region := range regionTree(regions, "") {
+		for _, transition := range region.Transitions {
+			if transition != nil && !yield(transition, region) {
+				return
+			}
+		}
+	}
+}
+
+// yieldVertices yields the vertices of the regions at any depth, each
+// region's states before its other vertices
+func yieldVertices(regions []*Region, yield func(*Vertex, *Region) bool) {
+	for region := range regionTree(regions, "") {
+		for _, vertex := range declaredVertices(region) {
+			if !yield(vertex, region) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"iter"
+	"reflect"
+	"testing"
+)
+
+// collectIDs ranges over an iterator of model elements and returns
+// "region/element" pairs, stopping after limit elements when limit > 0
+func collectIDs[T any](seq iter.Seq2[T, *Region], id func(T) string, limit int) []string {
+	var ids []string
+	for element, region := range seq {
+		ids = append(ids, region.ID+"/"+id(element))
+		if limit > 0 && len(ids) == limit {
+			break
+		}
+	}
+	return ids
+}
+
+func TestStateMachine_Iterators(t *testing.T) {
+	sm := createParallelStateMachine()
+	sm.Regions[0].States = append(sm.Regions[0].States, nil)
+	stateID := func(s *State) string { return s.ID }
+	transitionID := func(t *Transition) string { return t.ID }
+	vertexID := func(v *Vertex) string { return v.ID }
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{
+			name: "AllStates",
+			got:  collectIDs(sm.AllStates(), stateID, 0),
+			want: []string{"main/parallel", "main/done", "rA/a1", "rA/a2", "rB/b1", "rB/b2"},
+		},
+		{
+			name: "AllTransitions",
+			got:  collectIDs(sm.AllTransitions(), transitionID, 0),
+			want: []string{"main/tf1", "main/tf2", "main/tdone", "rA/ta", "rB/tb"},
+		},
+		{
+			name: "AllVertices",
+			got:  collectIDs(sm.AllVertices(), vertexID, 0),
+			want: []string{"main/parallel", "main/done", "main/fork", "rA/a1", "rA/a2", "rB/b1", "rB/b2"},
+		},
+		{
+			name: "break stops the iteration",
+			got:  collectIDs(sm.AllStates(), stateID, 3),
+			want: []string{"main/parallel", "main/done", "rA/a1"},
+		},
+		{
+			name: "Region.AllStates",
+			got:  collectIDs(sm.Regions[0].States[0].Regions[1].AllStates(), stateID, 0),
+			want: []string{"rB/b1", "rB/b2"},
+		},
+		{
+			name: "Region.AllTransitions",
+			got:  collectIDs(sm.Regions[0].AllTransitions(), transitionID, 2),
+			want: []string{"main/tf1", "main/tf2"},
+		},
+		{
+			name: "Region.AllVertices",
+			got:  collectIDs(sm.Regions[0].States[0].Regions[0].AllVertices(), vertexID, 0),
+			want: []string{"rA/a1", "rA/a2"},
+		},
+		{
+			name: "nil state machine",
+			got:  collectIDs((*StateMachine)(nil).AllStates(), stateID, 0),
+		},
+		{
+			name: "nil region",
+			got:  collectIDs((*Region)(nil).AllVertices(), vertexID, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_AllVertices_StatesAreEmbeddedVertices(t *testing.T) {
+	sm := createValidStateMachine()
+	for vertex, region := range sm.AllVertices() {
+		for _, state := range region.States {
+			if state.ID == vertex.ID && &state.Vertex != vertex {
+				t.Errorf("AllVertices() yielded a copy of state '%s'", state.ID)
+			}
+		}
+	}
+}
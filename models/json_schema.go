@@ -0,0 +1,159 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// StateMachineSchemaID identifies the JSON Schema of the JSON encoding of a
+// state machine. Documents written by the "jsonschema" exporter refer to it
+// in their "$schema" member.
+const StateMachineSchemaID = "urn:go-uml-statemachine-models:statemachine"
+
+// jsonSchemaDialect is the JSON Schema draft JSONSchema is written in
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns the JSON Schema, draft 2020-12, of the JSON encoding of
+// a state machine: the encoding the "json" exporter writes and Load reads.
+// The schema is derived from the model types, so it always matches the
+// encoding of this version of the package. Each named type is a definition
+// under "$defs"; fields marked required in the model are required, and
+// members the decoder ignores, such as "$schema", are allowed.
+func JSONSchema() ([]byte, error) {
+	builder := jsonSchemaBuilder{defs: make(map[string]any)}
+	root := builder.schema(reflect.TypeOf(StateMachine{}))
+	schema := map[string]any{
+		"$schema": jsonSchemaDialect,
+		"$id":     StateMachineSchemaID,
+		"title":   "UML state machine",
+		"$ref":    root["$ref"],
+		"$defs":   builder.defs,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// jsonSchemaBuilder collects the definitions of the named types a schema
+// refers to
+type jsonSchemaBuilder struct {
+	defs map[string]any
+}
+
+// schema returns the schema of values of type t as encoding/json encodes
+// them
+func (b *jsonSchemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{b.schema(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": []string{"array", "null"}, "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		if _, defined := b.defs[t.Name()]; !defined {
+			b.defs[t.Name()] = nil // Placeholder for recursive references
+			b.defs[t.Name()] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{} // Interfaces hold any JSON value
+}
+
+// object returns the schema of a struct type: its exported fields under
+// their JSON names, with the fields of embedded structs inlined
+func (b *jsonSchemaBuilder) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	var addFields func(t reflect.Type, embedded bool)
+	addFields = func(t reflect.Type, embedded bool) {
+		for _, field := range reflect.VisibleFields(t) {
+			if len(field.Index) > 1 || !field.IsExported() {
+				continue // Promoted fields are added with their embedded struct
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" && options == "" {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type, true)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if _, shadowed := properties[name]; shadowed && embedded {
+				continue // Fields of the outer struct take precedence
+			}
+			properties[name] = b.schema(field.Type)
+			if strings.Contains(field.Tag.Get("validate"), "required") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t, false)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// jsonSchemaExporter writes the JSON encoding of a state machine as an
+// instance of its JSON Schema, with a "$schema" member referring to the
+// schema. The "schema" property replaces StateMachineSchemaID, for example
+// with the path of a file holding JSONSchema. Load reads the document back.
+type jsonSchemaExporter struct{}
+
+func (jsonSchemaExporter) Name() string { return "jsonschema" }
+
+func (jsonSchemaExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	schema := StateMachineSchemaID
+	if opts.Properties["schema"] != "" {
+		schema = opts.Properties["schema"]
+	}
+	encoded, err := json.Marshal(sm)
+	if err != nil {
+		return err
+	}
+	member, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	var instance bytes.Buffer
+	instance.WriteString(`{"$schema":`)
+	instance.Write(member)
+	if len(encoded) > len("{}") {
+		instance.WriteByte(',')
+	}
+	instance.Write(encoded[1:])
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, instance.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err = w.Write(indented.Bytes())
+	return err
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkSchemaInstance reports the members of value the schema does not
+// declare and the required members it lacks. It follows "$ref", "anyOf",
+// "properties" and "items", which is all JSONSchema uses.
+func checkSchemaInstance(schema, defs map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return checkSchemaInstance(defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any), defs, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if value == nil {
+			return nil
+		}
+		return checkSchemaInstance(anyOf[0].(map[string]any), defs, value, path)
+	}
+	var problems []string
+	switch value := value.(type) {
+	case map[string]any:
+		properties, declared := schema["properties"].(map[string]any)
+		for name, member := range value {
+			if !declared {
+				if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+					problems = append(problems, checkSchemaInstance(additional, defs, member, path+"."+name)...)
+				}
+				continue
+			}
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				if path != "" || name != "$schema" {
+					problems = append(problems, fmt.Sprintf("%s.%s is not declared", path, name))
+				}
+				continue
+			}
+			problems = append(problems, checkSchemaInstance(property, defs, member, path+"."+name)...)
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				problems = append(problems, checkSchemaInstance(items, defs, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("JSONSchema() is not JSON: %v", err)
+	}
+	if schema["$id"] != StateMachineSchemaID || schema["$ref"] != "#/$defs/StateMachine" {
+		t.Errorf("$id = %v, $ref = %v", schema["$id"], schema["$ref"])
+	}
+	defs := schema["$defs"].(map[string]any)
+	for _, name := range []string{"StateMachine", "Region", "State", "Pseudostate", "Vertex", "Transition", "Behavior", "Event"} {
+		if _, ok := defs[name]; !ok {
+			t.Errorf("$defs lacks %s", name)
+		}
+	}
+
+	machine := defs["StateMachine"].(map[string]any)
+	if required := fmt.Sprint(machine["required"]); required != "[id name version]" {
+		t.Errorf("StateMachine required = %s, want [id name version]", required)
+	}
+	state := defs["State"].(map[string]any)["properties"].(map[string]any)
+	for _, name := range []string{"id", "name", "type", "regions", "tags"} {
+		if _, ok := state[name]; !ok {
+			t.Errorf("State lacks property %s of its embedded Vertex or its own", name)
+		}
+	}
+	if _, ok := defs["Region"].(map[string]any)["properties"].(map[string]any)["Content"]; ok {
+		t.Error("Region declares its Content provider, which is not encoded")
+	}
+
+	tests := []struct {
+		name    string
+		machine *StateMachine
+	}{
+		{"valid", createValidStateMachine()},
+		{"player", newPlayerMachine()},
+		{"submachine", createSubmachineStateMachine()},
+		{"history", newHistoryMachine()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.machine)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var instance any
+			if err := json.Unmarshal(encoded, &instance); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if problems := checkSchemaInstance(schema, defs, instance, ""); len(problems) > 0 {
+				t.Errorf("encoding does not match the schema: %v", problems)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaExporter(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       ExportOptions
+		wantSchema string
+	}{
+		{"default schema", ExportOptions{}, StateMachineSchemaID},
+		{"schema file", ExportOptions{Properties: map[string]string{"schema": "statemachine.schema.json"}}, "statemachine.schema.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newPlayerMachine()
+			var buf bytes.Buffer
+			if err := Export(sm, "jsonschema", &buf, tt.opts); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			want := fmt.Sprintf("{\n  \"$schema\": %q,\n  \"id\": \"player\",", tt.wantSchema)
+			if !strings.HasPrefix(buf.String(), want) {
+				t.Errorf("instance starts with %q, want %q", buf.String()[:min(buf.Len(), len(want))], want)
+			}
+
+			loaded, _, err := Load(&buf)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if changes := Diff(sm, loaded); len(changes) != 0 {
+				t.Errorf("loaded instance differs: %v", changes)
+			}
+		})
+	}
+
+	if !slices.Contains(ExportFormats(), "jsonschema") {
+		t.Error("jsonschema is not a registered export format")
+	}
+}
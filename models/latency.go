@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// Cost annotates a state or transition with the expected time spent in it
+// and the expected cost of performing it, e.g. in compute or currency units
+type Cost struct {
+	Duration time.Duration `json:"duration,omitempty"`
+	Amount   float64       `json:"amount,omitempty"`
+}
+
+// validate checks that the annotated duration and amount are not negative
+// and that the amount is a number
+func (c *Cost) validate(objectName string, context *ValidationContext, errors *ValidationErrors) {
+	if c == nil {
+		return
+	}
+	if c.Duration < 0 {
+		errors.AddError(ErrorTypeInvalid, objectName, "Cost", fmt.Sprintf("duration %s cannot be negative", c.Duration), context.Path)
+	}
+	switch {
+	case math.IsNaN(c.Amount):
+		errors.AddError(ErrorTypeInvalid, objectName, "Cost", "amount must be a number, not NaN", context.Path)
+	case c.Amount < 0:
+		errors.AddError(ErrorTypeInvalid, objectName, "Cost", fmt.Sprintf("amount %g cannot be negative", c.Amount), context.Path)
+	}
+}
+
+// duration returns the annotated duration, or zero for a nil cost
+func (c *Cost) duration() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.Duration
+}
+
+// amount returns the annotated amount, or zero for a nil cost
+func (c *Cost) amount() float64 {
+	if c == nil {
+		return 0
+	}
+	return c.Amount
+}
+
+// LatencyAnalysis reports the end-to-end duration and cost of running a
+// state machine from its initial pseudostates until it comes to rest
+type LatencyAnalysis struct {
+	// Bounded is false if a cycle is reachable from the initial pseudostates,
+	// in which case the worst case is unbounded and only the expected values
+	// are reported
+	Bounded bool `json:"bounded"`
+
+	// WorstCase is the duration of the slowest path and WorstCaseCost the
+	// cost along that path
+	WorstCase     time.Duration `json:"worst_case"`
+	WorstCaseCost float64       `json:"worst_case_cost"`
+
+	// CriticalPath holds the IDs of the transitions on the slowest path in
+	// the order they are taken
+	CriticalPath []string `json:"critical_path,omitempty"`
+
+	// Expected and ExpectedCost weigh every state and transition by its
+	// expected number of visits, using the transition probabilities of
+	// AnalyzeMarkov
+	Expected     time.Duration `json:"expected"`
+	ExpectedCost float64       `json:"expected_cost"`
+}
+
+// AnalyzeLatency computes the worst-case and expected end-to-end latency and
+// cost of sm from the Cost annotations of its states and transitions, and
+// reports the critical path, e.g. for SLA reviews of workflow models.
+//
+// Like AnalyzeMarkov, it treats the machine as a flat graph over its
+// transitions: time spent inside a composite state is its own annotation.
+// Unannotated states and transitions take no time and cost nothing. Ties
+// between equally slow paths are broken by cost.
+func AnalyzeLatency(sm *StateMachine) (*LatencyAnalysis, error) {
+	markov, err := AnalyzeMarkov(sm)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make(map[string]*Cost)
+	outgoing := make(map[string][]*Transition)
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil && state.Cost != nil {
+				costs[state.ID] = state.Cost
+			}
+		}
+	})
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil {
+			outgoing[transition.Source.ID] = append(outgoing[transition.Source.ID], transition)
+		}
+	})
+
+	// Sum in vertex ID order: floating-point addition is not associative, so
+	// map order would make the totals vary from run to run
+	analysis := &LatencyAnalysis{}
+	expectedCost := 0.0
+	expected := 0.0
+	for _, id := range slices.Sorted(maps.Keys(markov.ExpectedVisits)) {
+		visits := markov.ExpectedVisits[id]
+		expected += visits * float64(costs[id].duration())
+		expectedCost += visits * costs[id].amount()
+		transitions := outgoing[id]
+		for i, p := range transitionProbabilities(transitions) {
+			expected += visits * p * float64(transitions[i].Cost.duration())
+			expectedCost += visits * p * transitions[i].Cost.amount()
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(markov.Absorption)) {
+		p := markov.Absorption[id]
+		expected += p * float64(costs[id].duration())
+		expectedCost += p * costs[id].amount()
+	}
+	analysis.Expected = time.Duration(math.Round(expected))
+	analysis.ExpectedCost = expectedCost
+
+	var start []string
+	for _, region := range sm.Regions {
+		if region != nil {
+			start = append(start, regionInitialIDs(region)...)
+		}
+	}
+	order, acyclic := topologicalOrder(start, outgoing)
+	if !acyclic {
+		return analysis, nil
+	}
+	analysis.Bounded = true
+
+	// Longest path over the reachable vertices in topological order
+	type pathEnd struct {
+		duration time.Duration
+		amount   float64
+		via      *Transition
+	}
+	best := make(map[string]pathEnd, len(order))
+	for _, id := range start {
+		best[id] = pathEnd{duration: costs[id].duration(), amount: costs[id].amount()}
+	}
+	slower := func(a, b pathEnd) bool {
+		return a.duration > b.duration || (a.duration == b.duration && a.amount > b.amount)
+	}
+	var last string
+	for _, id := range order {
+		current := best[id]
+		if len(outgoing[id]) == 0 && (last == "" || slower(current, best[last])) {
+			last = id
+		}
+		for _, transition := range outgoing[id] {
+			target := transition.Target.ID
+			candidate := pathEnd{
+				duration: current.duration + transition.Cost.duration() + costs[target].duration(),
+				amount:   current.amount + transition.Cost.amount() + costs[target].amount(),
+				via:      transition,
+			}
+			if existing, seen := best[target]; !seen || slower(candidate, existing) {
+				best[target] = candidate
+			}
+		}
+	}
+
+	if last != "" {
+		analysis.WorstCase = best[last].duration
+		analysis.WorstCaseCost = best[last].amount
+		for end := best[last]; end.via != nil; end = best[end.via.Source.ID] {
+			analysis.CriticalPath = append([]string{end.via.ID}, analysis.CriticalPath...)
+		}
+	}
+	return analysis, nil
+}
+
+// topologicalOrder returns the vertices reachable from start in topological
+// order, and false if they contain a cycle
+func topologicalOrder(start []string, outgoing map[string][]*Transition) ([]string, bool) {
+	reached := make(map[string]bool)
+	queue := append([]string{}, start...)
+	var vertices []string
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if reached[id] {
+			continue
+		}
+		reached[id] = true
+		vertices = append(vertices, id)
+		for _, transition := range outgoing[id] {
+			queue = append(queue, transition.Target.ID)
+		}
+	}
+
+	incoming := make(map[string]int)
+	for _, id := range vertices {
+		for _, transition := range outgoing[id] {
+			incoming[transition.Target.ID]++
+		}
+	}
+	var order, ready []string
+	for _, id := range vertices {
+		if incoming[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, transition := range outgoing[id] {
+			incoming[transition.Target.ID]--
+			if incoming[transition.Target.ID] == 0 {
+				ready = append(ready, transition.Target.ID)
+			}
+		}
+	}
+	return order, len(order) == len(vertices)
+}
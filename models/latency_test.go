@@ -0,0 +1,152 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newBranchingMachine builds initial -> review; review -> fast or slow; both
+// lead to done. Review takes a minute, the slow path an hour and the fast
+// path ten minutes.
+func newBranchingMachine() *StateMachine {
+	initial := &Vertex{ID: "initial", Name: "Initial", Type: "pseudostate"}
+	review := &Vertex{ID: "review", Name: "Review", Type: "state"}
+	fast := &Vertex{ID: "fast", Name: "Fast", Type: "state"}
+	slow := &Vertex{ID: "slow", Name: "Slow", Type: "state"}
+	done := &Vertex{ID: "done", Name: "Done", Type: "finalstate"}
+	return &StateMachine{
+		ID:      "branching",
+		Name:    "Branching",
+		Version: "1.0",
+		Regions: []*Region{{
+			ID:   "main",
+			Name: "Main",
+			States: []*State{
+				{Vertex: *review, IsSimple: true, Cost: &Cost{Duration: time.Minute, Amount: 1}},
+				{Vertex: *fast, IsSimple: true, Cost: &Cost{Duration: 10 * time.Minute, Amount: 5}},
+				{Vertex: *slow, IsSimple: true, Cost: &Cost{Duration: time.Hour, Amount: 2}},
+			},
+			Vertices: []*Vertex{initial, done},
+			Transitions: []*Transition{
+				{ID: "start", Source: initial, Target: review, Kind: TransitionKindExternal},
+				{ID: "approve", Source: review, Target: fast, Kind: TransitionKindExternal, Probability: probability(0.75)},
+				{ID: "escalate", Source: review, Target: slow, Kind: TransitionKindExternal, Probability: probability(0.25), Cost: &Cost{Duration: 2 * time.Minute}},
+				{ID: "ship", Source: fast, Target: done, Kind: TransitionKindExternal},
+				{ID: "archive", Source: slow, Target: done, Kind: TransitionKindExternal},
+			},
+		}},
+	}
+}
+
+func TestAnalyzeLatency(t *testing.T) {
+	t.Run("acyclic machine", func(t *testing.T) {
+		analysis, err := AnalyzeLatency(newBranchingMachine())
+		if err != nil {
+			t.Fatalf("AnalyzeLatency() error = %v", err)
+		}
+		if !analysis.Bounded {
+			t.Fatal("AnalyzeLatency() Bounded = false, want true")
+		}
+		if want := 63 * time.Minute; analysis.WorstCase != want {
+			t.Errorf("WorstCase = %s, want %s", analysis.WorstCase, want)
+		}
+		if analysis.WorstCaseCost != 3 {
+			t.Errorf("WorstCaseCost = %g, want 3", analysis.WorstCaseCost)
+		}
+		if want := []string{"start", "escalate", "archive"}; !reflect.DeepEqual(analysis.CriticalPath, want) {
+			t.Errorf("CriticalPath = %v, want %v", analysis.CriticalPath, want)
+		}
+
+		// 1m + 0.75 * 10m + 0.25 * (2m + 60m)
+		if want := 24 * time.Minute; analysis.Expected != want {
+			t.Errorf("Expected = %s, want %s", analysis.Expected, want)
+		}
+		if want := 1 + 0.75*5 + 0.25*2; math.Abs(analysis.ExpectedCost-want) > 1e-9 {
+			t.Errorf("ExpectedCost = %g, want %g", analysis.ExpectedCost, want)
+		}
+	})
+
+	t.Run("cycles leave the worst case unbounded", func(t *testing.T) {
+		sm := newRetryMachine(probability(0.6), probability(0.2))
+		sm.Regions[0].States[0].Cost = &Cost{Duration: time.Second}
+
+		analysis, err := AnalyzeLatency(sm)
+		if err != nil {
+			t.Fatalf("AnalyzeLatency() error = %v", err)
+		}
+		if analysis.Bounded || analysis.CriticalPath != nil {
+			t.Errorf("AnalyzeLatency() = %+v, want an unbounded worst case", analysis)
+		}
+		if want := 1250 * time.Millisecond; analysis.Expected != want {
+			t.Errorf("Expected = %s, want %s", analysis.Expected, want)
+		}
+	})
+
+	t.Run("expected values do not depend on map order", func(t *testing.T) {
+		sm := newBranchingMachine()
+		for i, amount := range []float64{0.5, 1e16, 2} {
+			sm.Regions[0].States[i].Cost.Amount = amount
+		}
+		first, err := AnalyzeLatency(sm)
+		if err != nil {
+			t.Fatalf("AnalyzeLatency() error = %v", err)
+		}
+		for range 50 {
+			if analysis, _ := AnalyzeLatency(sm); analysis.ExpectedCost != first.ExpectedCost {
+				t.Fatalf("ExpectedCost = %v, then %v", first.ExpectedCost, analysis.ExpectedCost)
+			}
+		}
+	})
+
+	t.Run("machines without an initial pseudostate", func(t *testing.T) {
+		if _, err := AnalyzeLatency(&StateMachine{ID: "empty"}); err == nil {
+			t.Error("AnalyzeLatency() error = nil, want an error")
+		}
+	})
+}
+
+func TestCost_Validate(t *testing.T) {
+	sm := newBranchingMachine()
+	if err := sm.Validate(); err != nil {
+		for _, e := range err.(*ValidationErrors).Errors {
+			if e.Field == "Cost" {
+				t.Errorf("Validate() reported %v for valid costs", e)
+			}
+		}
+	}
+
+	sm.Regions[0].States[0].Cost = &Cost{Duration: -time.Second}
+	sm.Regions[0].Transitions[1].Cost = &Cost{Amount: -1}
+	err := sm.Validate()
+	var costErrors []*ValidationError
+	if err != nil {
+		for _, e := range err.(*ValidationErrors).Errors {
+			if e.Field == "Cost" {
+				costErrors = append(costErrors, e)
+			}
+		}
+	}
+	if len(costErrors) != 2 {
+		t.Errorf("Validate() cost errors = %v, want 2", costErrors)
+	}
+
+	tests := []struct {
+		name   string
+		amount float64
+		want   string
+	}{
+		{name: "negative", amount: -1, want: "amount -1 cannot be negative"},
+		{name: "NaN", amount: math.NaN(), want: "amount must be a number, not NaN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := &ValidationErrors{}
+			(&Cost{Amount: tt.amount}).validate("State", NewValidationContext(), errors)
+			if len(errors.Errors) != 1 || errors.Errors[0].Message != tt.want {
+				t.Errorf("validate() errors = %v, want %q", errors.Errors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,486 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Layout holds diagram coordinates for a state machine, so that diagram
+// exporters can draw it without an external layout tool. Coordinates are
+// absolute, with the origin at the top left corner of the diagram and y
+// growing downwards.
+type Layout struct {
+	Direction LayoutDirection `json:"direction"`
+	Width     float64         `json:"width"`
+	Height    float64         `json:"height"`
+	Vertices  map[string]Box  `json:"vertices"` // Boxes of states, pseudostates and final states by ID
+	Regions   map[string]Box  `json:"regions"`  // Boxes of regions by ID
+}
+
+// Box is a rectangle of a layout
+type Box struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Center returns the center of the box
+func (b Box) Center() (x, y float64) {
+	return b.X + b.Width/2, b.Y + b.Height/2
+}
+
+// translate returns the box moved by dx and dy
+func (b Box) translate(dx, dy float64) Box {
+	return Box{X: b.X + dx, Y: b.Y + dy, Width: b.Width, Height: b.Height}
+}
+
+// transpose returns the box mirrored at the diagonal
+func (b Box) transpose() Box {
+	return Box{X: b.Y, Y: b.X, Width: b.Height, Height: b.Width}
+}
+
+// Clone returns a copy of the layout
+func (l *Layout) Clone() *Layout {
+	if l == nil {
+		return nil
+	}
+	out := *l
+	out.Vertices = maps.Clone(l.Vertices)
+	out.Regions = maps.Clone(l.Regions)
+	return &out
+}
+
+// LayoutDirection is the direction transitions flow in
+type LayoutDirection string
+
+const (
+	LayoutTopToBottom LayoutDirection = "TB"
+	LayoutLeftToRight LayoutDirection = "LR"
+)
+
+// LayoutEngine computes the layout of a state machine. LayeredLayout is the
+// built-in engine; other engines can wrap external layout tools.
+type LayoutEngine interface {
+	Layout(sm *StateMachine) (*Layout, error)
+}
+
+// ApplyLayout computes the layout of the state machine with engine and
+// stores it in sm.Layout. A nil engine uses LayeredLayout with its defaults.
+func (sm *StateMachine) ApplyLayout(engine LayoutEngine) error {
+	if engine == nil {
+		engine = LayeredLayout{}
+	}
+	layout, err := engine.Layout(sm)
+	if err != nil {
+		return fmt.Errorf("failed to lay out state machine '%s': %w", sm.ID, err)
+	}
+	sm.Layout = layout
+	return nil
+}
+
+// Default sizes and spacing of LayeredLayout
+const (
+	layoutStateWidth   = 120.0
+	layoutStateHeight  = 50.0
+	layoutCharWidth    = 7.0 // Approximate width of a character of a state name
+	layoutVertexSize   = 24.0
+	layoutHeaderHeight = 30.0 // Room for the name of a composite state
+)
+
+// LayeredLayout is a layered (Sugiyama-style) layout engine. Each region is
+// laid out separately: cycles are broken by reversing back edges, vertices
+// are assigned to layers by their longest path from the region's sources,
+// crossings are reduced with the barycenter heuristic, and layers are
+// centered on each other. Composite states are sized to hold their regions,
+// which are placed side by side, as are the top-level regions. Only
+// transitions between vertices of the same region influence the layout;
+// submachines are not laid out.
+type LayeredLayout struct {
+	Direction    LayoutDirection // LayoutTopToBottom if empty
+	NodeSpacing  float64         // Space between vertices of a layer; 30 if zero
+	LayerSpacing float64         // Space between layers; 50 if zero
+	Padding      float64         // Space around the content of regions; 20 if zero
+}
+
+// withDefaults returns the engine with zero settings replaced by defaults
+func (e LayeredLayout) withDefaults() LayeredLayout {
+	if e.Direction == "" {
+		e.Direction = LayoutTopToBottom
+	}
+	if e.NodeSpacing == 0 {
+		e.NodeSpacing = 30
+	}
+	if e.LayerSpacing == 0 {
+		e.LayerSpacing = 50
+	}
+	if e.Padding == 0 {
+		e.Padding = 20
+	}
+	return e
+}
+
+// Layout computes the layout of the state machine
+func (e LayeredLayout) Layout(sm *StateMachine) (*Layout, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	e = e.withDefaults()
+	if e.Direction != LayoutTopToBottom && e.Direction != LayoutLeftToRight {
+		return nil, fmt.Errorf("unknown layout direction '%s'", e.Direction)
+	}
+
+	// Everything is laid out top to bottom; left to right layouts swap the
+	// sizes of vertices first and transpose the result
+	plan := e.placeSideBySide(sm.Regions)
+	layout := &Layout{
+		Direction: e.Direction,
+		Width:     plan.width,
+		Height:    plan.height,
+		Vertices:  plan.vertices,
+		Regions:   plan.regions,
+	}
+	if e.Direction == LayoutLeftToRight {
+		layout.Width, layout.Height = layout.Height, layout.Width
+		for id, box := range layout.Vertices {
+			layout.Vertices[id] = box.transpose()
+		}
+		for id, box := range layout.Regions {
+			layout.Regions[id] = box.transpose()
+		}
+	}
+	return layout, nil
+}
+
+// layoutPlan is the layout of regions relative to their own origin
+type layoutPlan struct {
+	width, height float64
+	vertices      map[string]Box
+	regions       map[string]Box
+}
+
+// merge adds another plan moved by dx and dy
+func (p *layoutPlan) merge(other *layoutPlan, dx, dy float64) {
+	for id, box := range other.vertices {
+		p.vertices[id] = box.translate(dx, dy)
+	}
+	for id, box := range other.regions {
+		p.regions[id] = box.translate(dx, dy)
+	}
+}
+
+func newLayoutPlan() *layoutPlan {
+	return &layoutPlan{vertices: make(map[string]Box), regions: make(map[string]Box)}
+}
+
+// placeSideBySide lays out orthogonal regions next to each other
+func (e LayeredLayout) placeSideBySide(regions []*Region) *layoutPlan {
+	plan := newLayoutPlan()
+	x := 0.0
+	for _, region := range regions {
+		if region == nil {
+			continue
+		}
+		if x > 0 {
+			x += e.NodeSpacing
+		}
+		regionPlan := e.layoutRegion(region)
+		plan.merge(regionPlan, x, 0)
+		plan.regions[region.ID] = Box{X: x, Width: regionPlan.width, Height: regionPlan.height}
+		x += regionPlan.width
+		plan.height = max(plan.height, regionPlan.height)
+	}
+	plan.width = x
+	// Orthogonal regions share the height of the tallest one
+	for _, region := range regions {
+		if region != nil {
+			box := plan.regions[region.ID]
+			box.Height = plan.height
+			plan.regions[region.ID] = box
+		}
+	}
+	return plan
+}
+
+// layoutNode is a vertex of a region, or a dummy vertex on an edge spanning
+// several layers
+type layoutNode struct {
+	id            string
+	width, height float64
+	nested        *layoutPlan // Regions of a composite state
+	layer         int
+	position      float64 // Sort key while ordering a layer
+	successors    []*layoutNode
+	predecessors  []*layoutNode
+}
+
+// layoutRegion lays out the vertices of a region
+func (e LayeredLayout) layoutRegion(region *Region) *layoutPlan {
+	// Nodes in model order: states, then the other vertices
+	var nodes []*layoutNode
+	byID := make(map[string]*layoutNode)
+	addNode := func(node *layoutNode) {
+		if _, exists := byID[node.id]; !exists {
+			byID[node.id] = node
+			nodes = append(nodes, node)
+		}
+	}
+	for _, state := range region.States {
+		if state == nil {
+			continue
+		}
+		node := &layoutNode{id: state.ID}
+		if len(state.Regions) > 0 {
+			node.nested = e.placeSideBySide(state.Regions)
+			node.width = node.nested.width + 2*e.Padding
+			node.height = node.nested.height + layoutHeaderHeight + e.Padding
+		} else {
+			node.width = max(layoutStateWidth, float64(utf8.RuneCountInString(displayName(state.Name, state.ID)))*layoutCharWidth+2*e.Padding)
+			node.height = layoutStateHeight
+			if e.Direction == LayoutLeftToRight {
+				node.width, node.height = node.height, node.width
+			}
+		}
+		addNode(node)
+	}
+	for _, vertex := range region.Vertices {
+		if vertex != nil {
+			addNode(&layoutNode{id: vertex.ID, width: layoutVertexSize, height: layoutVertexSize})
+		}
+	}
+
+	// Edges between the region's vertices, without self loops and duplicates
+	type edge struct{ from, to *layoutNode }
+	var edges []edge
+	seen := make(map[edge]bool)
+	for _, transition := range region.Transitions {
+		if transition == nil || transition.Source == nil || transition.Target == nil {
+			continue
+		}
+		from, to := byID[transition.Source.ID], byID[transition.Target.ID]
+		if from == nil || to == nil || from == to || seen[edge{from, to}] {
+			continue
+		}
+		seen[edge{from, to}] = true
+		edges = append(edges, edge{from, to})
+	}
+
+	// Break cycles: reverse the edges that lead back to a vertex on the
+	// current path of a depth-first search started at the initial vertices
+	outgoing := make(map[*layoutNode][]*layoutNode)
+	for _, edge := range edges {
+		outgoing[edge.from] = append(outgoing[edge.from], edge.to)
+	}
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[*layoutNode]int)
+	reversed := make(map[edge]bool)
+	var visit func(node *layoutNode)
+	visit = func(node *layoutNode) {
+		state[node] = onPath
+		for _, next := range outgoing[node] {
+			switch state[next] {
+			case onPath:
+				reversed[edge{node, next}] = true
+			case unvisited:
+				visit(next)
+			}
+		}
+		state[node] = done
+	}
+	var starts []*layoutNode
+	for _, id := range regionInitialIDs(region) {
+		if node := byID[id]; node != nil {
+			starts = append(starts, node)
+		}
+	}
+	for _, node := range append(starts, nodes...) {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+	for _, edge := range edges {
+		from, to := edge.from, edge.to
+		if reversed[edge] {
+			from, to = to, from
+		}
+		from.successors = append(from.successors, to)
+		to.predecessors = append(to.predecessors, from)
+	}
+
+	// Assign layers by longest path from the sources, in topological order
+	indegree := make(map[*layoutNode]int)
+	for _, node := range nodes {
+		indegree[node] = len(node.predecessors)
+	}
+	var queue []*layoutNode
+	for _, node := range nodes {
+		if indegree[node] == 0 {
+			queue = append(queue, node)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range node.successors {
+			next.layer = max(next.layer, node.layer+1)
+			if indegree[next]--; indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Split edges spanning several layers with dummy vertices, so that
+	// crossing reduction sees them
+	layerCount := 0
+	for _, node := range nodes {
+		layerCount = max(layerCount, node.layer+1)
+	}
+	layers := make([][]*layoutNode, layerCount)
+	for _, node := range nodes {
+		layers[node.layer] = append(layers[node.layer], node)
+	}
+	for _, node := range nodes {
+		successors := node.successors
+		node.successors = nil
+		for _, next := range successors {
+			from := node
+			for layer := node.layer + 1; layer < next.layer; layer++ {
+				dummy := &layoutNode{layer: layer, predecessors: []*layoutNode{from}}
+				from.successors = append(from.successors, dummy)
+				layers[layer] = append(layers[layer], dummy)
+				from = dummy
+			}
+			from.successors = append(from.successors, next)
+			if from != node {
+				next.predecessors = slices.DeleteFunc(next.predecessors, func(p *layoutNode) bool { return p == node })
+				next.predecessors = append(next.predecessors, from)
+			}
+		}
+	}
+
+	// Reduce crossings by sorting layers by the barycenter of their
+	// neighbors in the previous layer, sweeping down and up a few times
+	index := func(layer []*layoutNode) {
+		for i, node := range layer {
+			node.position = float64(i)
+		}
+	}
+	for _, layer := range layers {
+		index(layer)
+	}
+	sortLayer := func(layer []*layoutNode, neighbors func(*layoutNode) []*layoutNode) {
+		keys := make(map[*layoutNode]float64, len(layer))
+		for _, node := range layer {
+			keys[node] = node.position
+			if adjacent := neighbors(node); len(adjacent) > 0 {
+				sum := 0.0
+				for _, neighbor := range adjacent {
+					sum += neighbor.position
+				}
+				keys[node] = sum / float64(len(adjacent))
+			}
+		}
+		slices.SortStableFunc(layer, func(a, b *layoutNode) int {
+			switch {
+			case keys[a] < keys[b]:
+				return -1
+			case keys[a] > keys[b]:
+				return 1
+			}
+			return 0
+		})
+		index(layer)
+	}
+	for sweep := 0; sweep < 4; sweep++ {
+		for i := 1; i < len(layers); i++ {
+			sortLayer(layers[i], func(node *layoutNode) []*layoutNode { return node.predecessors })
+		}
+		for i := len(layers) - 2; i >= 0; i-- {
+			sortLayer(layers[i], func(node *layoutNode) []*layoutNode { return node.successors })
+		}
+	}
+
+	// Place the layers below each other, centered on the widest one
+	plan := newLayoutPlan()
+	layerWidths := make([]float64, len(layers))
+	for i, layer := range layers {
+		for j, node := range layer {
+			if j > 0 {
+				layerWidths[i] += e.NodeSpacing
+			}
+			layerWidths[i] += node.width
+		}
+	}
+	contentWidth := 0.0
+	for _, width := range layerWidths {
+		contentWidth = max(contentWidth, width)
+	}
+	y := e.Padding
+	for i, layer := range layers {
+		layerHeight := 0.0
+		for _, node := range layer {
+			layerHeight = max(layerHeight, node.height)
+		}
+		x := e.Padding + (contentWidth-layerWidths[i])/2
+		for _, node := range layer {
+			if node.id != "" {
+				box := Box{X: x, Y: y + (layerHeight-node.height)/2, Width: node.width, Height: node.height}
+				plan.vertices[node.id] = box
+				if node.nested != nil {
+					plan.merge(node.nested, box.X+e.Padding, box.Y+layoutHeaderHeight)
+				}
+			}
+			x += node.width + e.NodeSpacing
+		}
+		y += layerHeight
+		if i < len(layers)-1 {
+			y += e.LayerSpacing
+		}
+	}
+	plan.width = contentWidth + 2*e.Padding
+	plan.height = y + e.Padding
+	return plan
+}
+
+// covers reports whether the layout has a box for every region and vertex
+// of the state machine
+func (l *Layout) covers(sm *StateMachine) bool {
+	if l == nil {
+		return false
+	}
+	covered := true
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		_, exists := l.Regions[region.ID]
+		covered = covered && exists
+		for _, state := range region.States {
+			if state != nil {
+				_, exists := l.Vertices[state.ID]
+				covered = covered && exists
+			}
+		}
+		for _, vertex := range region.Vertices {
+			if vertex != nil {
+				_, exists := l.Vertices[vertex.ID]
+				covered = covered && exists
+			}
+		}
+	})
+	return covered
+}
+
+// diagramLayout returns the layout diagram exporters draw: the state
+// machine's own layout if it covers the whole machine, otherwise a fresh
+// LayeredLayout in the direction of the "direction" export property
+func diagramLayout(sm *StateMachine, opts ExportOptions) (*Layout, error) {
+	if sm.Layout.covers(sm) {
+		return sm.Layout, nil
+	}
+	return LayeredLayout{Direction: LayoutDirection(strings.ToUpper(opts.Properties["direction"]))}.Layout(sm)
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+// within reports whether the box lies within outer
+func (b Box) within(outer Box) bool {
+	return b.X >= outer.X && b.Y >= outer.Y && b.X+b.Width <= outer.X+outer.Width && b.Y+b.Height <= outer.Y+outer.Height
+}
+
+// overlaps reports whether two boxes share an area
+func (b Box) overlaps(other Box) bool {
+	return b.X < other.X+other.Width && other.X < b.X+b.Width && b.Y < other.Y+other.Height && other.Y < b.Y+b.Height
+}
+
+func TestLayeredLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine LayeredLayout
+		// before reports whether a is laid out before b along the direction
+		before func(a, b Box) bool
+	}{
+		{name: "top to bottom", engine: LayeredLayout{}, before: func(a, b Box) bool { return a.Y+a.Height <= b.Y }},
+		{name: "left to right", engine: LayeredLayout{Direction: LayoutLeftToRight}, before: func(a, b Box) bool { return a.X+a.Width <= b.X }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newPlayerMachine()
+			layout, err := tt.engine.Layout(sm)
+			if err != nil {
+				t.Fatalf("Layout() error = %v", err)
+			}
+
+			ids := []string{"initial", "idle", "playing", "audio-initial", "loading", "streaming", "video-initial", "buffering", "rendering"}
+			if len(layout.Vertices) != len(ids) || len(layout.Regions) != 3 {
+				t.Fatalf("layout has %d vertices and %d regions", len(layout.Vertices), len(layout.Regions))
+			}
+			diagram := Box{Width: layout.Width, Height: layout.Height}
+			for _, id := range ids {
+				if box, exists := layout.Vertices[id]; !exists || !box.within(diagram) || box.Width <= 0 {
+					t.Errorf("vertex %s box = %v, diagram %v", id, box, diagram)
+				}
+			}
+
+			// Transitions flow along the direction; the back edge stop is the
+			// exception
+			for _, pair := range [][2]string{{"initial", "idle"}, {"idle", "playing"}, {"audio-initial", "loading"}, {"loading", "streaming"}, {"buffering", "rendering"}} {
+				if !tt.before(layout.Vertices[pair[0]], layout.Vertices[pair[1]]) {
+					t.Errorf("%s should come before %s: %v, %v", pair[0], pair[1], layout.Vertices[pair[0]], layout.Vertices[pair[1]])
+				}
+			}
+
+			// Nested regions lie within their composite state, side by side
+			playing := layout.Vertices["playing"]
+			audio, video := layout.Regions["audio"], layout.Regions["video"]
+			if !audio.within(playing) || !video.within(playing) || audio.overlaps(video) {
+				t.Errorf("regions audio %v and video %v in playing %v", audio, video, playing)
+			}
+			for _, id := range []string{"loading", "streaming"} {
+				if !layout.Vertices[id].within(audio) {
+					t.Errorf("%s %v outside audio %v", id, layout.Vertices[id], audio)
+				}
+			}
+
+			// Vertices of a region do not overlap
+			for _, region := range [][]string{{"initial", "idle", "playing"}, {"audio-initial", "loading", "streaming"}} {
+				for i, a := range region {
+					for _, b := range region[i+1:] {
+						if layout.Vertices[a].overlaps(layout.Vertices[b]) {
+							t.Errorf("%s %v overlaps %s %v", a, layout.Vertices[a], b, layout.Vertices[b])
+						}
+					}
+				}
+			}
+
+			again, _ := tt.engine.Layout(newPlayerMachine())
+			if !reflect.DeepEqual(layout, again) {
+				t.Error("Layout() should be deterministic")
+			}
+		})
+	}
+}
+
+func TestLayeredLayoutCrossings(t *testing.T) {
+	// The targets of a and b are declared in the opposite order; ordering the
+	// second layer by barycenter avoids crossing edges
+	a, b := execState("a"), execState("b")
+	toB, toA := execState("to-b"), execState("to-a")
+	sm := &StateMachine{ID: "x", Regions: []*Region{{
+		ID:     "main",
+		States: []*State{a, b, toB, toA},
+		Transitions: []*Transition{
+			execTransition("1", &a.Vertex, &toA.Vertex),
+			execTransition("2", &b.Vertex, &toB.Vertex),
+		},
+	}}}
+	layout, err := LayeredLayout{}.Layout(sm)
+	if err != nil {
+		t.Fatalf("Layout() error = %v", err)
+	}
+	if (layout.Vertices["a"].X < layout.Vertices["b"].X) != (layout.Vertices["to-a"].X < layout.Vertices["to-b"].X) {
+		t.Errorf("edges cross: %v", layout.Vertices)
+	}
+}
+
+func TestApplyLayout(t *testing.T) {
+	sm := newPlayerMachine()
+	if err := sm.ApplyLayout(nil); err != nil {
+		t.Fatalf("ApplyLayout() error = %v", err)
+	}
+	if sm.Layout == nil || sm.Layout.Direction != LayoutTopToBottom {
+		t.Fatalf("Layout = %v", sm.Layout)
+	}
+
+	clone := sm.Clone()
+	clone.Layout.Vertices["idle"] = Box{}
+	if sm.Layout.Vertices["idle"] == (Box{}) {
+		t.Error("Clone() should copy the layout")
+	}
+	changes := Diff(sm, clone)
+	if len(changes) != 1 || changes[0].Field != "layout" || changes[0].Semantic {
+		t.Errorf("Diff() = %v, want a cosmetic layout change", changes)
+	}
+
+	if err := sm.ApplyLayout(LayeredLayout{Direction: "diagonal"}); err == nil {
+		t.Error("ApplyLayout() should reject an unknown direction")
+	}
+	if _, err := (LayeredLayout{}).Layout(nil); err == nil {
+		t.Error("Layout(nil) should fail")
+	}
+}
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ResourceLimits bounds the size of models accepted from untrusted sources.
+// A zero field disables that limit.
+type ResourceLimits struct {
+	MaxElements  int   // Regions, states, vertices, transitions, triggers, connection points, connection point references and events, submachines included
+	MaxDepth     int   // Region nesting depth; top-level regions are at depth 1 and a submachine's regions sit one level below its submachine state
+	MaxJSONBytes int64 // Size of an encoded model accepted by DecodeStateMachine, or of any input accepted by LoadWithLimits
+}
+
+// DefaultResourceLimits are generous limits suitable for services that
+// accept models from untrusted clients
+var DefaultResourceLimits = ResourceLimits{
+	MaxElements:  100000,
+	MaxDepth:     64,
+	MaxJSONBytes: 16 << 20,
+}
+
+// DefaultMaxDepth is the nesting depth at which traversals and validation
+// stop when no other limit is configured
+const DefaultMaxDepth = 1000
+
+// Resource limit names used by ResourceLimitError
+const (
+	LimitElements  = "elements"
+	LimitDepth     = "depth"
+	LimitJSONBytes = "json_bytes"
+)
+
+// ErrResourceLimit is matched by every ResourceLimitError with errors.Is
+var ErrResourceLimit = errors.New("resource limit exceeded")
+
+// ResourceLimitError reports a model that exceeds one of its ResourceLimits.
+// Checks stop as soon as a limit is exceeded, so Actual is the value reached
+// at that point rather than the model's full size.
+type ResourceLimitError struct {
+	Limit  string `json:"limit"`
+	Max    int64  `json:"max"`
+	Actual int64  `json:"actual"`
+	Path   string `json:"path,omitempty"` // Location in the model, e.g. "Regions[0].States[2].Regions"
+}
+
+// Error returns the error message
+func (e *ResourceLimitError) Error() string {
+	message := fmt.Sprintf("%s: %s exceeds the limit of %d (reached %d)", ErrResourceLimit, e.Limit, e.Max, e.Actual)
+	if e.Path != "" {
+		message += " at " + e.Path
+	}
+	return message
+}
+
+// Is reports whether target is ErrResourceLimit
+func (e *ResourceLimitError) Is(target error) bool {
+	return target == ErrResourceLimit
+}
+
+// Check returns a ResourceLimitError if sm exceeds the element count or
+// nesting depth limits. Submachines are counted once each, and the model is
+// walked iteratively so that deeply nested input cannot exhaust the stack.
+func (l ResourceLimits) Check(sm *StateMachine) error {
+	if sm == nil || (l.MaxElements <= 0 && l.MaxDepth <= 0) {
+		return nil
+	}
+
+	type pending struct {
+		regions []*Region
+		depth   int
+		path    string
+	}
+
+	var elements int64
+	count := func(n int, path string) error {
+		elements += int64(n)
+		if l.MaxElements > 0 && elements > int64(l.MaxElements) {
+			return &ResourceLimitError{Limit: LimitElements, Max: int64(l.MaxElements), Actual: elements, Path: path}
+		}
+		return nil
+	}
+
+	seen := map[*StateMachine]bool{sm: true}
+	if err := count(len(sm.ConnectionPoints)+len(sm.Events), ""); err != nil {
+		return err
+	}
+	stack := []pending{{regions: sm.Regions, depth: 1, path: "Regions"}}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if len(next.regions) == 0 {
+			continue
+		}
+		if l.MaxDepth > 0 && next.depth > l.MaxDepth {
+			return &ResourceLimitError{Limit: LimitDepth, Max: int64(l.MaxDepth), Actual: int64(next.depth), Path: next.path}
+		}
+
+		for i, region := range next.regions {
+			if region == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s[%d]", next.path, i)
+			if err := count(1+len(region.States)+len(region.Vertices)+len(region.Transitions), path); err != nil {
+				return err
+			}
+			for _, transition := range region.Transitions {
+				if transition != nil {
+					if err := count(len(transition.Triggers), path); err != nil {
+						return err
+					}
+				}
+			}
+			for j, state := range region.States {
+				if state == nil {
+					continue
+				}
+				statePath := fmt.Sprintf("%s.States[%d]", path, j)
+				if err := count(len(state.Connections), statePath); err != nil {
+					return err
+				}
+				stack = append(stack, pending{regions: state.Regions, depth: next.depth + 1, path: statePath + ".Regions"})
+				if submachine := state.Submachine; submachine != nil && !seen[submachine] {
+					seen[submachine] = true
+					if err := count(len(submachine.ConnectionPoints)+len(submachine.Events), statePath+".Submachine"); err != nil {
+						return err
+					}
+					stack = append(stack, pending{regions: submachine.Regions, depth: next.depth + 1, path: statePath + ".Submachine.Regions"})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeStateMachine reads a JSON encoded state machine from r, enforcing the
+// given limits. Input longer than MaxJSONBytes is rejected before it is
+// parsed, and the decoded model is checked with ResourceLimits.Check.
+// Use DefaultResourceLimits for input from untrusted sources.
+func DecodeStateMachine(r io.Reader, limits ResourceLimits) (*StateMachine, error) {
+	if limits.MaxJSONBytes > 0 {
+		r = io.LimitReader(r, limits.MaxJSONBytes+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state machine: %w", err)
+	}
+	if limits.MaxJSONBytes > 0 && int64(len(data)) > limits.MaxJSONBytes {
+		return nil, &ResourceLimitError{Limit: LimitJSONBytes, Max: limits.MaxJSONBytes, Actual: int64(len(data))}
+	}
+
+	var sm StateMachine
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&sm); err != nil {
+		return nil, fmt.Errorf("failed to decode state machine: %w", err)
+	}
+	if err := limits.Check(&sm); err != nil {
+		return nil, err
+	}
+	return &sm, nil
+}
+
+// validateResourceLimits checks the limits of the context's profile before
+// the rest of the validation runs. It reports false if a limit is exceeded,
+// in which case validation stops.
+func (sm *StateMachine) validateResourceLimits(context *ValidationContext, errors *ValidationErrors) bool {
+	limits := context.ActiveProfile().Limits
+	if limits == nil || len(context.machineChain) > 1 {
+		return true
+	}
+
+	limitErr, exceeded := limits.Check(sm).(*ResourceLimitError)
+	if !exceeded {
+		return true
+	}
+	path := context.Path
+	if limitErr.Path != "" {
+		path = append(append([]string{}, context.Path...), strings.Split(limitErr.Path, ".")...)
+	}
+	errors.AddErrorWithContext(
+		ErrorTypeResourceLimit,
+		"StateMachine",
+		limitErr.Limit,
+		fmt.Sprintf("model exceeds the %s limit of %d; validation stopped (resource limit)", limitErr.Limit, limitErr.Max),
+		path,
+		map[string]interface{}{"limit": limitErr.Limit, "max": limitErr.Max, "actual": limitErr.Actual},
+	)
+	return false
+}
+
+// maxDepth returns the region nesting depth validated under the context's
+// profile: its MaxDepth limit if set, and DefaultMaxDepth otherwise
+func (vc *ValidationContext) maxDepth() int {
+	if limits := vc.ActiveProfile().Limits; limits != nil && limits.MaxDepth > 0 {
+		return limits.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+// enteringRegion returns a copy of the context one region deeper
+func (vc *ValidationContext) enteringRegion() *ValidationContext {
+	newCtx := *vc
+	newCtx.depth++
+	return &newCtx
+}
+
+// validateDepthLimit reports a region nested deeper than the context allows.
+// It reports false to stop validation from descending any further.
+func (r *Region) validateDepthLimit(context *ValidationContext, errors *ValidationErrors) bool {
+	depth, maxDepth := context.depth, context.maxDepth()
+	if depth <= maxDepth {
+		return true
+	}
+	errors.AddErrorWithContext(
+		ErrorTypeResourceLimit,
+		"Region",
+		"Depth",
+		fmt.Sprintf("region nesting exceeds the maximum depth of %d; nested elements were not validated (resource limit)", maxDepth),
+		context.Path,
+		map[string]interface{}{"limit": LimitDepth, "max": int64(maxDepth), "actual": int64(depth)},
+	)
+	return false
+}
+
+// validationStack holds the nested elements of a validation still to be
+// validated into errors, as closures over their contexts
+type validationStack struct {
+	errors  *ValidationErrors
+	pending []func()
+}
+
+// descend validates elements nested below the current one, such as the
+// regions or the submachine of a state. The first call of a validation runs
+// validate and then every nested element it reaches from an explicit stack;
+// calls made while the stack is drained only push onto it. The Go stack thus
+// stays shallow however deeply the model nests, and nested elements are
+// validated depth first, in model order, after the element containing them.
+// A validation into another ValidationErrors gets a stack of its own, so
+// that its findings are complete when it returns.
+func (vc *ValidationContext) descend(errors *ValidationErrors, validate func(*ValidationContext)) {
+	if vc.stack != nil && vc.stack.errors == errors {
+		vc.stack.pending = append(vc.stack.pending, func() { validate(vc) })
+		return
+	}
+
+	stack := &validationStack{errors: errors}
+	context := *vc
+	context.stack = stack
+	validate(&context)
+	for len(stack.pending) > 0 {
+		next := stack.pending[len(stack.pending)-1]
+		stack.pending = stack.pending[:len(stack.pending)-1]
+		pushed := len(stack.pending)
+		next()
+		// Elements pushed by next run before the rest, in the order pushed
+		slices.Reverse(stack.pending[pushed:])
+	}
+}
@@ -0,0 +1,294 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// nestStates wraps the first state of sm's first region in depth levels of
+// composite states
+func nestStates(sm *StateMachine, depth int) {
+	region := sm.Regions[0]
+	for i := 0; i < depth; i++ {
+		inner := &Region{ID: "nested-" + string(rune('a'+i)), Name: "Nested"}
+		state := &State{Vertex: Vertex{ID: "composite-" + string(rune('a'+i)), Name: "Composite", Type: "state"}, IsComposite: true, Regions: []*Region{inner}}
+		region.States = append(region.States, state)
+		region = inner
+	}
+}
+
+func TestResourceLimits_Check(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    ResourceLimits
+		build     func() *StateMachine
+		wantLimit string
+		wantPath  string
+	}{
+		{
+			name:   "within limits",
+			limits: DefaultResourceLimits,
+			build:  createValidStateMachine,
+		},
+		{
+			name:      "too many elements",
+			limits:    ResourceLimits{MaxElements: 5},
+			build:     createValidStateMachine,
+			wantLimit: LimitElements,
+			wantPath:  "Regions[0]",
+		},
+		{
+			name:   "nesting depth at the limit",
+			limits: ResourceLimits{MaxDepth: 3},
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				nestStates(sm, 2)
+				return sm
+			},
+		},
+		{
+			name:   "nesting depth over the limit",
+			limits: ResourceLimits{MaxDepth: 3},
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				nestStates(sm, 3)
+				return sm
+			},
+			wantLimit: LimitDepth,
+			wantPath:  "Regions[0].States[2].Regions[0].States[0].Regions[0].States[0].Regions",
+		},
+		{
+			name:   "submachine regions count towards depth",
+			limits: ResourceLimits{MaxDepth: 1},
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				sm.Regions[0].States[1].Submachine = createValidStateMachine()
+				return sm
+			},
+			wantLimit: LimitDepth,
+			wantPath:  "Regions[0].States[1].Submachine.Regions",
+		},
+		{
+			name:   "recursive submachines are counted once",
+			limits: ResourceLimits{MaxElements: 20},
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				sm.Regions[0].States[1].Submachine = sm
+				return sm
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Check(tt.build())
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Fatalf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var limitErr *ResourceLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Check() error = %v, want *ResourceLimitError", err)
+			}
+			if limitErr.Limit != tt.wantLimit || limitErr.Path != tt.wantPath {
+				t.Errorf("Check() = %s at %q, want %s at %q", limitErr.Limit, limitErr.Path, tt.wantLimit, tt.wantPath)
+			}
+			if !errors.Is(err, ErrResourceLimit) {
+				t.Error("errors.Is(err, ErrResourceLimit) = false")
+			}
+		})
+	}
+}
+
+func TestDecodeStateMachine(t *testing.T) {
+	data, err := json.Marshal(createValidStateMachine())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	t.Run("decodes within limits", func(t *testing.T) {
+		sm, err := DecodeStateMachine(strings.NewReader(string(data)), DefaultResourceLimits)
+		if err != nil {
+			t.Fatalf("DecodeStateMachine() error = %v", err)
+		}
+		if sm.ID != "sm1" || len(sm.Regions[0].Transitions) != 3 {
+			t.Errorf("DecodeStateMachine() = %v", sm)
+		}
+	})
+
+	t.Run("rejects oversized input before parsing", func(t *testing.T) {
+		_, err := DecodeStateMachine(strings.NewReader(string(data)+"garbage"), ResourceLimits{MaxJSONBytes: int64(len(data))})
+		var limitErr *ResourceLimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != LimitJSONBytes {
+			t.Errorf("DecodeStateMachine() error = %v, want a %s limit error", err, LimitJSONBytes)
+		}
+	})
+
+	t.Run("rejects decoded models over the element limit", func(t *testing.T) {
+		_, err := DecodeStateMachine(strings.NewReader(string(data)), ResourceLimits{MaxElements: 3})
+		if !errors.Is(err, ErrResourceLimit) {
+			t.Errorf("DecodeStateMachine() error = %v, want ErrResourceLimit", err)
+		}
+	})
+
+	t.Run("reports malformed JSON", func(t *testing.T) {
+		_, err := DecodeStateMachine(strings.NewReader("{"), DefaultResourceLimits)
+		if err == nil || errors.Is(err, ErrResourceLimit) {
+			t.Errorf("DecodeStateMachine() error = %v, want a decoding error", err)
+		}
+	})
+}
+
+func TestStateMachineValidate_ResourceLimits(t *testing.T) {
+	sm := createValidStateMachine()
+	nestStates(sm, 2)
+
+	context := NewValidationContext().WithProfile(&ValidationProfile{Name: "guarded", Limits: &ResourceLimits{MaxDepth: 2}})
+	err := sm.ValidateInContext(context)
+	validationErrors, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("ValidateInContext() error = %v, want *ValidationErrors", err)
+	}
+	if len(validationErrors.Errors) != 1 || validationErrors.Errors[0].Type != ErrorTypeResourceLimit {
+		t.Fatalf("ValidateInContext() errors = %v, want a single resource limit error", validationErrors.Errors)
+	}
+	if got := validationErrors.Errors[0].Context["actual"]; got != int64(3) {
+		t.Errorf("resource limit error context actual = %v, want 3", got)
+	}
+	if ErrorTypeResourceLimit.String() != "ResourceLimit" {
+		t.Errorf("ErrorTypeResourceLimit.String() = %q", ErrorTypeResourceLimit.String())
+	}
+
+	if err := sm.ValidateInContext(context.WithProfile(&ValidationProfile{Name: "unguarded"})); err != nil && strings.Contains(err.Error(), "resource limit") {
+		t.Errorf("ValidateInContext() without limits reported %v", err)
+	}
+}
+
+// deepRegion returns a region with levels of composite states nested below it
+func deepRegion(levels int) *Region {
+	top := &Region{ID: "r0", Name: "Level"}
+	region := top
+	for i := 1; i <= levels; i++ {
+		inner := &Region{ID: fmt.Sprintf("r%d", i), Name: "Level"}
+		region.States = []*State{{Vertex: Vertex{ID: fmt.Sprintf("s%d", i), Name: "Level", Type: "state"}, IsComposite: true, Regions: []*Region{inner}}}
+		region = inner
+	}
+	return top
+}
+
+func TestRegionValidate_DepthLimit(t *testing.T) {
+	region := deepRegion(DefaultMaxDepth + 5)
+
+	err := region.Validate()
+	validationErrors, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() error = %v, want *ValidationErrors", err)
+	}
+	limitErrors := validationErrors.GetErrorsByType(ErrorTypeResourceLimit)
+	if len(limitErrors) != 1 {
+		t.Fatalf("Validate() reported %d resource limit errors, want 1", len(limitErrors))
+	}
+	if got := limitErrors[0].Context["actual"]; got != int64(DefaultMaxDepth+1) {
+		t.Errorf("resource limit error context actual = %v, want %d", got, DefaultMaxDepth+1)
+	}
+
+	// A profile limit lowers the depth at which validation stops
+	context := NewValidationContext().WithProfile(&ValidationProfile{Name: "shallow", Limits: &ResourceLimits{MaxDepth: 3}})
+	err = deepRegion(5).ValidateInContext(context)
+	if err == nil || !strings.Contains(err.Error(), "exceeds the maximum depth of 3") {
+		t.Errorf("ValidateInContext() error = %v, want a depth limit error", err)
+	}
+}
+
+// stackProbe is region content that records the depth of the Go stack it is
+// loaded at
+type stackProbe struct{ frames *[]int }
+
+func (p stackProbe) States() ([]*State, error) {
+	*p.frames = append(*p.frames, runtime.Callers(0, make([]uintptr, 4096)))
+	return nil, nil
+}
+
+func (stackProbe) Vertices() ([]*Vertex, error)        { return nil, nil }
+func (stackProbe) Transitions() ([]*Transition, error) { return nil, nil }
+
+// submachineChain returns a state machine whose only state refers to a
+// submachine, levels deep
+func submachineChain(levels int) *StateMachine {
+	var inner *StateMachine
+	for i := levels; i >= 0; i-- {
+		state := &State{Vertex: Vertex{ID: fmt.Sprintf("s%d", i), Name: "Level", Type: "state"}}
+		if inner != nil {
+			state.IsSubmachineState, state.Submachine = true, inner
+		}
+		inner = &StateMachine{ID: fmt.Sprintf("sm%d", i), Name: "Level", Version: "1.0", Regions: []*Region{{ID: fmt.Sprintf("r%d", i), Name: "Level", States: []*State{state}}}}
+	}
+	return inner
+}
+
+func TestValidate_NestingDoesNotGrowTheStack(t *testing.T) {
+	tests := []struct {
+		name     string
+		levels   int
+		validate func(levels int, probe func(*Region)) error
+	}{
+		{
+			name:   "composite states",
+			levels: 500,
+			validate: func(levels int, probe func(*Region)) error {
+				region := deepRegion(levels)
+				for r := region; r != nil; {
+					probe(r)
+					if len(r.States) == 0 {
+						break
+					}
+					r = r.States[0].Regions[0]
+				}
+				return region.Validate()
+			},
+		},
+		{
+			name:   "submachines",
+			levels: 100,
+			validate: func(levels int, probe func(*Region)) error {
+				sm := submachineChain(levels)
+				for m := sm; m != nil; m = m.Regions[0].States[0].Submachine {
+					probe(m.Regions[0])
+				}
+				return sm.Validate()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var frames []int
+			tt.validate(tt.levels, func(region *Region) { region.Content = stackProbe{&frames} })
+			if len(frames) < tt.levels {
+				t.Fatalf("content of %d regions loaded, want at least %d", len(frames), tt.levels)
+			}
+			shallowest, deepest := frames[0], frames[0]
+			for _, n := range frames {
+				shallowest, deepest = min(shallowest, n), max(deepest, n)
+			}
+			if deepest-shallowest > 50 {
+				t.Errorf("stack grew from %d to %d frames over %d levels of nesting", shallowest, deepest, tt.levels)
+			}
+		})
+	}
+
+	t.Run("submachines count toward the depth limit", func(t *testing.T) {
+		context := NewValidationContext().WithProfile(&ValidationProfile{Name: "shallow", Limits: &ResourceLimits{MaxDepth: 3}})
+		err := submachineChain(3).Regions[0].ValidateInContext(context)
+		if err == nil || !strings.Contains(err.Error(), "exceeds the maximum depth of 3") {
+			t.Errorf("ValidateInContext() error = %v, want a depth limit error", err)
+		}
+	})
+}
@@ -0,0 +1,569 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// petalNode is a value of Rational Rose's petal format: an object, a list,
+// or a scalar (string, number, identifier or tuple)
+type petalNode struct {
+	Kind  string // "object", "list" or "value"
+	Class string // Object class or list name, e.g. "State" or "transition_list"
+	Names []string
+	Attrs []petalAttr
+	Items []*petalNode
+	Value string
+}
+
+type petalAttr struct {
+	Key   string
+	Value *petalNode
+}
+
+// attr returns the first attribute with the given key
+func (n *petalNode) attr(key string) *petalNode {
+	for _, a := range n.Attrs {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+// text returns the scalar value of the attribute with the given key
+func (n *petalNode) text(key string) string {
+	if value := n.attr(key); value != nil {
+		return value.Value
+	}
+	return ""
+}
+
+// name returns the object's first name
+func (n *petalNode) name() string {
+	if len(n.Names) > 0 {
+		return n.Names[0]
+	}
+	return ""
+}
+
+// petalParser reads petal files, as written by Rational Rose
+type petalParser struct {
+	reader *bufio.Reader
+	peeked *petalToken
+	line   int
+}
+
+type petalToken struct {
+	kind  byte // '(', ')', '"' for strings, '|' for text blocks, 'a' for atoms, 0 at EOF
+	value string
+}
+
+// parsePetal parses every top-level value of a petal file
+func parsePetal(r io.Reader) ([]*petalNode, error) {
+	p := &petalParser{reader: bufio.NewReader(r), line: 1}
+	var nodes []*petalNode
+	for {
+		token, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if token.kind == 0 {
+			return nodes, nil
+		}
+		node, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+}
+
+// value parses one value
+func (p *petalParser) value() (*petalNode, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch token.kind {
+	case '"', '|', 'a':
+		return &petalNode{Kind: "value", Value: token.value}, nil
+	case '(':
+	default:
+		return nil, p.errorf("unexpected %q", string(token.kind))
+	}
+
+	head, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case head.kind == 'a' && head.value == "object":
+		return p.object()
+	case head.kind == 'a' && head.value == "list":
+		return p.list()
+	case head.kind == 'a' && head.value == "value":
+		// (value Text "...") holds a typed scalar; keep its last part
+		node := &petalNode{Kind: "value"}
+		for {
+			part, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if part.kind == ')' {
+				return node, nil
+			}
+			if part.kind == 0 || part.kind == '(' {
+				return nil, p.errorf("unterminated value")
+			}
+			node.Value = part.value
+		}
+	}
+
+	// A tuple such as (12, 34)
+	parts := []string{head.value}
+	for {
+		part, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if part.kind == ')' {
+			return &petalNode{Kind: "value", Value: strings.Join(parts, " ")}, nil
+		}
+		if part.kind == 0 || part.kind == '(' {
+			return nil, p.errorf("unterminated tuple")
+		}
+		parts = append(parts, part.value)
+	}
+}
+
+// object parses the rest of (object Class "name" key value ...)
+func (p *petalParser) object() (*petalNode, error) {
+	class, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if class.kind != 'a' {
+		return nil, p.errorf("object without a class")
+	}
+	node := &petalNode{Kind: "object", Class: class.value}
+	for {
+		token, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if token.kind != '"' {
+			break
+		}
+		p.peeked = nil
+		node.Names = append(node.Names, token.value)
+	}
+	for {
+		token, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch token.kind {
+		case ')':
+			return node, nil
+		case 'a':
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			node.Attrs = append(node.Attrs, petalAttr{Key: token.value, Value: value})
+		default:
+			return nil, p.errorf("expected an attribute of %s", node.Class)
+		}
+	}
+}
+
+// list parses the rest of (list Name item ...)
+func (p *petalParser) list() (*petalNode, error) {
+	node := &petalNode{Kind: "list"}
+	if token, err := p.peek(); err != nil {
+		return nil, err
+	} else if token.kind == 'a' {
+		p.peeked = nil
+		node.Class = token.value
+	}
+	for {
+		token, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if token.kind == ')' {
+			p.peeked = nil
+			return node, nil
+		}
+		if token.kind == 0 {
+			return nil, p.errorf("unterminated list")
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, item)
+	}
+}
+
+// peek returns the next token without consuming it
+func (p *petalParser) peek() (*petalToken, error) {
+	if p.peeked == nil {
+		token, err := p.scan()
+		if err != nil {
+			return nil, err
+		}
+		p.peeked = token
+	}
+	return p.peeked, nil
+}
+
+// next consumes the next token
+func (p *petalParser) next() (*petalToken, error) {
+	token, err := p.peek()
+	p.peeked = nil
+	return token, err
+}
+
+// scan reads a token from the input
+func (p *petalParser) scan() (*petalToken, error) {
+	for {
+		r, _, err := p.reader.ReadRune()
+		if err == io.EOF {
+			return &petalToken{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case r == '\n':
+			p.line++
+		case r == ' ' || r == '\t' || r == '\r' || r == ',':
+		case r == '(' || r == ')':
+			return &petalToken{kind: byte(r)}, nil
+		case r == '"':
+			return p.scanString()
+		case r == '|':
+			return p.scanText()
+		default:
+			var atom strings.Builder
+			atom.WriteRune(r)
+			for {
+				r, _, err := p.reader.ReadRune()
+				if err != nil {
+					break
+				}
+				if r == ' ' || r == '\t' || r == '\r' || r == '\n' || r == ',' || r == '(' || r == ')' || r == '"' {
+					_ = p.reader.UnreadRune()
+					break
+				}
+				atom.WriteRune(r)
+			}
+			return &petalToken{kind: 'a', value: atom.String()}, nil
+		}
+	}
+}
+
+// scanString reads a quoted string; backslash escapes the next character
+func (p *petalParser) scanString() (*petalToken, error) {
+	var value strings.Builder
+	for {
+		r, _, err := p.reader.ReadRune()
+		if err != nil {
+			return nil, p.errorf("unterminated string")
+		}
+		switch r {
+		case '"':
+			return &petalToken{kind: '"', value: value.String()}, nil
+		case '\\':
+			escaped, _, err := p.reader.ReadRune()
+			if err != nil {
+				return nil, p.errorf("unterminated string")
+			}
+			value.WriteRune(escaped)
+		case '\n':
+			p.line++
+			value.WriteRune(r)
+		default:
+			value.WriteRune(r)
+		}
+	}
+}
+
+// scanText reads a text block: consecutive lines that start with "|"
+func (p *petalParser) scanText() (*petalToken, error) {
+	var lines []string
+	for {
+		line, err := p.reader.ReadString('\n')
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			break
+		}
+		p.line++
+		// Continue while the next line, after indentation, starts with "|"
+		for {
+			r, _, err := p.reader.ReadRune()
+			if err != nil {
+				return &petalToken{kind: '|', value: strings.Join(lines, "\n")}, nil
+			}
+			if r == ' ' || r == '\t' {
+				continue
+			}
+			if r == '|' {
+				break
+			}
+			_ = p.reader.UnreadRune()
+			return &petalToken{kind: '|', value: strings.Join(lines, "\n")}, nil
+		}
+	}
+	return &petalToken{kind: '|', value: strings.Join(lines, "\n")}, nil
+}
+
+// errorf reports a syntax error at the current line
+func (p *petalParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("petal syntax error at line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// mdlStateKinds maps Rose state types and activity model classes to the
+// labels the diagram importer understands
+var mdlStateKinds = map[string]string{
+	"StartState":           "initial",
+	"EndState":             "final",
+	"History":              "history",
+	"DeepHistory":          "h*",
+	"Decision":             "choice",
+	"SynchronizationState": "",
+}
+
+// ImportMDL reads the state machines of a Rational Rose model (.mdl),
+// returning one state machine per State_Machine object that is not nested
+// in a state, named after the class it belongs to. States, start, end and
+// history states, decisions, synchronization bars and transitions with their
+// event, condition and action are converted, as are entry, exit and do
+// actions. Everything else, such as diagram layout, event parameters, send
+// events and documentation, is listed in the report as lost.
+func ImportMDL(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	roots, err := parsePetal(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse MDL: %w", err)
+	}
+
+	report := &ImportReport{Format: "MDL"}
+	var machines []*StateMachine
+	type pendingObject struct {
+		node  *petalNode
+		owner string // Name of the enclosing Class
+	}
+	var stack []pendingObject
+	for i := len(roots) - 1; i >= 0; i-- {
+		stack = append(stack, pendingObject{node: roots[i]})
+	}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node, owner := next.node, next.owner
+		if node.Kind == "object" && node.Class == "State_Machine" {
+			machines = append(machines, mdlStateMachine(node, owner, len(machines)+1, report))
+			continue
+		}
+		if node.Kind == "object" && node.Class == "Class" {
+			owner = node.name()
+		}
+		children := node.Items
+		for _, attr := range node.Attrs {
+			children = append(children, attr.Value)
+		}
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, pendingObject{node: children[i], owner: owner})
+		}
+	}
+	if len(machines) == 0 {
+		return nil, nil, fmt.Errorf("MDL model contains no state machines")
+	}
+	return machines, report, nil
+}
+
+// mdlStateMachine converts one State_Machine object
+func mdlStateMachine(machine *petalNode, owner string, index int, report *ImportReport) *StateMachine {
+	id := machine.text("quid")
+	if id == "" {
+		id = fmt.Sprintf("statemachine-%d", index)
+	}
+	name := displayName(owner, machine.name())
+
+	var nodes []diagramNode
+	var edges []diagramEdge
+	actions := make(map[string]*petalNode)
+	type pendingState struct {
+		node   *petalNode
+		parent string
+	}
+	var stack []pendingState
+	pushStates := func(container *petalNode, parent string) {
+		states := mdlStates(container)
+		for i := len(states) - 1; i >= 0; i-- {
+			stack = append(stack, pendingState{node: states[i], parent: parent})
+		}
+	}
+	pushStates(machine, "")
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		state := next.node
+		stateID := state.text("quid")
+		if stateID == "" {
+			stateID = fmt.Sprintf("%s-state-%d", id, len(nodes)+1)
+		}
+
+		node := diagramNode{ID: stateID, Label: state.name(), Parent: next.parent}
+		kind, known := mdlStateKinds[state.Class]
+		if state.Class == "State" {
+			kind, known = mdlStateKinds[state.text("type")]
+			if !known && state.text("type") != "" && state.text("type") != "Normal" {
+				report.add("state", stateID, state.name(), "state type '%s' is not supported; imported as a state", state.text("type"))
+			}
+		}
+		if known {
+			node.Label = kind
+			if kind == "" {
+				node.Shape = "bar"
+			}
+		}
+		nodes = append(nodes, node)
+		actions[stateID] = state
+		if state.text("documentation") != "" {
+			report.add("state", stateID, state.name(), "documentation was not imported")
+		}
+
+		if transitions := state.attr("transitions"); transitions != nil {
+			for _, transition := range transitions.Items {
+				if edge, ok := mdlTransition(transition, stateID, report); ok {
+					edges = append(edges, edge)
+				}
+			}
+		}
+		pushStates(state, stateID)
+		if nested := state.attr("statemachine"); nested != nil {
+			pushStates(nested, stateID)
+		}
+	}
+
+	if machine.attr("statediagrams") != nil {
+		report.add("statemachine", id, name, "diagram layout was not imported")
+	}
+	sm := buildDiagramMachine(id, name, nodes, edges, report)
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if source := actions[state.ID]; source != nil {
+				mdlStateActions(state, source, report)
+			}
+		}
+	})
+	return sm
+}
+
+// mdlStates returns the state objects listed in a state machine's or state's
+// "states" list
+func mdlStates(container *petalNode) []*petalNode {
+	list := container.attr("states")
+	if list == nil {
+		return nil
+	}
+	var states []*petalNode
+	for _, item := range list.Items {
+		if item.Kind == "object" {
+			states = append(states, item)
+		}
+	}
+	return states
+}
+
+// mdlTransition converts a State_Transition object leaving the given state
+func mdlTransition(transition *petalNode, sourceID string, report *ImportReport) (diagramEdge, bool) {
+	id := transition.text("quid")
+	edge := diagramEdge{ID: id, Source: sourceID}
+	previous := ""
+	for _, attr := range transition.Attrs {
+		// quidu follows the supplier (target) and client (source) references
+		if attr.Key == "quidu" {
+			switch previous {
+			case "supplier":
+				edge.Target = attr.Value.Value
+			case "client":
+				edge.Source = attr.Value.Value
+			}
+		}
+		previous = attr.Key
+	}
+	if edge.Target == "" {
+		report.add("transition", id, transition.text("label"), "target '%s' has no quid reference; skipped", transition.text("supplier"))
+		return edge, false
+	}
+
+	var label strings.Builder
+	if event := transition.attr("event"); event != nil {
+		label.WriteString(event.name())
+		if parameters := event.text("parameters"); parameters != "" {
+			report.add("transition", id, event.name(), "event parameters '%s' were not imported", parameters)
+		}
+	}
+	if condition := transition.text("condition"); condition != "" {
+		label.WriteString(" [" + condition + "]")
+	}
+	if action := transition.attr("action"); action != nil && action.name() != "" {
+		label.WriteString(" / " + action.name())
+	}
+	if send := transition.attr("sendEvent"); send != nil {
+		report.add("transition", id, send.name(), "send event '%s' was not imported", send.name())
+	}
+	edge.Label = strings.TrimSpace(label.String())
+	return edge, true
+}
+
+// mdlStateActions sets the entry, exit and do activity behaviors of a state
+// from its Rose actions
+func mdlStateActions(state *State, source *petalNode, report *ImportReport) {
+	list := source.attr("actions")
+	if list == nil {
+		return
+	}
+	for i, action := range list.Items {
+		if action.Kind != "object" {
+			continue
+		}
+		when := mdlActionTime(action)
+		behavior := &Behavior{ID: fmt.Sprintf("%s-action-%d", state.ID, i+1), Name: action.name(), Specification: action.name()}
+		var slot **Behavior
+		switch when {
+		case "Entry":
+			slot = &state.Entry
+		case "Exit":
+			slot = &state.Exit
+		case "Do":
+			slot = &state.DoActivity
+		default:
+			report.add("action", behavior.ID, action.name(), "action triggered '%s' was not imported", when)
+			continue
+		}
+		if *slot != nil {
+			report.add("action", behavior.ID, action.name(), "state already has an %s action; only the first was imported", strings.ToLower(when))
+			continue
+		}
+		*slot = behavior
+	}
+}
+
+// mdlActionTime returns when an action runs, from its own "when" attribute
+// or a nested ActionTime object
+func mdlActionTime(action *petalNode) string {
+	if when := action.text("when"); when != "" {
+		return when
+	}
+	if timing := action.attr("ActionTime"); timing != nil {
+		return timing.text("when")
+	}
+	return ""
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// roseModel is a trimmed Rose 2000 model with a Door class whose state
+// machine has a start state, a composite Open state and an end state
+const roseModel = `
+(object Petal
+    version    	45
+    _written   	"Rose 8.0.0303"
+    charSet    	0)
+
+(object Design "Logical View"
+    is_unit    	TRUE
+    defaults   	(object defaults
+	rightMargin 	0.250000
+	clientArea 	(2000, 3000))
+    root_category 	(object Class_Category "Logical View"
+	quid       	"3A0000000001"
+	logical_models 	(list unit_reference_list
+	    (object Class "Door"
+		quid       	"3A0000000002"
+		documentation
+|A door that can be
+|opened and closed.
+
+		statemachine 	(object State_Machine "State/Activity Model"
+		    quid       	"3A0000000003"
+		    states     	(list States
+			(object State "$UNNAMED$0"
+			    quid       	"S0"
+			    type       	"StartState"
+			    transitions 	(list transition_list
+				(object State_Transition
+				    quid       	"T0"
+				    supplier   	"Logical View::Door::Closed"
+				    quidu      	"S1"
+				    client     	"Logical View::Door::$UNNAMED$0"
+				    quidu      	"S0")))
+			(object State "Closed"
+			    quid       	"S1"
+			    documentation 	"Closed and latched"
+			    actions    	(list action_list
+				(object action "lock"
+				    ActionTime 	(object ActionTime
+					when       	"Entry"))
+				(object action "beep"
+				    ActionTime 	(object ActionTime
+					when       	"Entry"))
+				(object action "log"
+				    ActionTime 	(object ActionTime
+					when       	"On Event"
+					event      	"knock")))
+			    transitions 	(list transition_list
+				(object State_Transition
+				    quid       	"T1"
+				    supplier   	"Logical View::Door::Open"
+				    quidu      	"S2"
+				    event      	(object Event "open"
+					parameters 	"force")
+				    condition  	"unlocked"
+				    action     	(object action "chime")
+				    sendEvent  	(object sendEvent "opened"))))
+			(object State "Open"
+			    quid       	"S2"
+			    actions    	(list action_list
+				(object action "unlatch"
+				    when       	"Exit"))
+			    statemachine 	(object State_Machine "Nested"
+				quid       	"3A0000000004"
+				states     	(list States
+				    (object State "Ajar"
+					quid       	"S3")))
+			    transitions 	(list transition_list
+				(object State_Transition
+				    quid       	"T2"
+				    supplier   	"Logical View::Door::$UNNAMED$1"
+				    quidu      	"S4"
+				    event      	(object Event "remove"))
+				(object State_Transition
+				    quid       	"T3"
+				    supplier   	"Logical View::Door::Nowhere")))
+			(object State "$UNNAMED$1"
+			    quid       	"S4"
+			    type       	"EndState"))
+		    statediagrams 	(list StateDiagramList
+			(object State_Diagram "Door"
+			    quid       	"3A0000000005"
+			    items      	(list diagram_item_list))))))))
+`
+
+func TestImportMDL(t *testing.T) {
+	machines, report, err := ImportMDL(strings.NewReader(roseModel))
+	if err != nil {
+		t.Fatalf("ImportMDL() error = %v", err)
+	}
+	if len(machines) != 1 {
+		t.Fatalf("machines = %d, want 1 (nested state machines belong to their state)", len(machines))
+	}
+	sm := machines[0]
+	if sm.ID != "3A0000000003" || sm.Name != "Door" {
+		t.Errorf("sm = %s, want the quid and the owning class name", sm)
+	}
+
+	main := sm.Regions[0]
+	if len(main.States) != 2 {
+		t.Fatalf("states = %v, want Closed and Open", main.States)
+	}
+	closed, open := main.States[0], main.States[1]
+	if closed.Entry == nil || closed.Entry.Name != "lock" {
+		t.Errorf("Closed entry = %v, want lock", closed.Entry)
+	}
+	if open.Exit == nil || open.Exit.Name != "unlatch" {
+		t.Errorf("Open exit = %v, want unlatch", open.Exit)
+	}
+	if !open.IsComposite || open.Regions[0].States[0].Name != "Ajar" {
+		t.Errorf("Open = %+v, want a composite state containing Ajar", open)
+	}
+	if got := vertexPseudostateKind(main.Vertices[0]); got != PseudostateKindInitial {
+		t.Errorf("start state kind = %q, want initial", got)
+	}
+	if main.Vertices[1].Type != "finalstate" {
+		t.Errorf("end state type = %q, want finalstate", main.Vertices[1].Type)
+	}
+
+	if len(main.Transitions) != 3 {
+		t.Fatalf("transitions = %d, want 3", len(main.Transitions))
+	}
+	openDoor := main.Transitions[1]
+	if openDoor.Source.ID != "S1" || openDoor.Target.ID != "S2" {
+		t.Errorf("T1 = %s -> %s, want S1 -> S2", openDoor.Source.ID, openDoor.Target.ID)
+	}
+	if openDoor.Triggers[0].EventID != "open" || openDoor.Guard.Specification != "unlocked" || openDoor.Effect.Name != "chime" {
+		t.Errorf("T1 = %+v", openDoor)
+	}
+
+	for _, want := range []string{
+		"event parameters 'force' were not imported",
+		"send event 'opened' was not imported",
+		"state already has an entry action",
+		"action triggered 'On Event' was not imported",
+		"documentation was not imported",
+		"transition 'T3'",
+		"diagram layout was not imported",
+	} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestParsePetal(t *testing.T) {
+	nodes, err := parsePetal(strings.NewReader(`(object Point "p" "alias" at (10, 20) label (value Text "hi") note
+|one
+|two
+ flag TRUE)`))
+	if err != nil {
+		t.Fatalf("parsePetal() error = %v", err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("nodes = %d, want 1", len(nodes))
+	}
+	point := nodes[0]
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "at", want: "10 20"},
+		{key: "label", want: "hi"},
+		{key: "note", want: "one\ntwo"},
+		{key: "flag", want: "TRUE"},
+	}
+	for _, tt := range tests {
+		if got := point.text(tt.key); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	if point.Class != "Point" || len(point.Names) != 2 || point.name() != "p" {
+		t.Errorf("point = %+v", point)
+	}
+}
+
+func TestImportMDL_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "unterminated object", input: `(object State "a"`, want: "petal syntax error"},
+		{name: "unterminated string", input: `(object State "a`, want: "unterminated string"},
+		{name: "unexpected token", input: `)`, want: "unexpected"},
+		{name: "no state machine", input: `(object Class "Door")`, want: "contains no state machines"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ImportMDL(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ImportMDL() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
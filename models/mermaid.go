@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mermaidExporter exports Mermaid state diagrams (stateDiagram-v2).
+// Composite states are nested and orthogonal regions are separated by "--".
+// Initial pseudostates and final states are drawn as Mermaid's "[*]", so
+// transitions are written in the region that contains them. Choice, fork
+// and join pseudostates use Mermaid's stereotypes; other pseudostates are
+// drawn as states. The "direction" property sets the layout direction, e.g.
+// "LR".
+type mermaidExporter struct{}
+
+func (mermaidExporter) Name() string { return "mermaid" }
+
+func (mermaidExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("---\ntitle: %s\n---\nstateDiagram-v2\n", mermaidText(opts.title(sm))))
+	if direction := opts.Properties["direction"]; direction != "" {
+		out.WriteString("  direction " + direction + "\n")
+	}
+	writeMermaidRegions(&out, sm.Regions, 1)
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// writeMermaidRegions writes the vertices, states and transitions of sibling
+// regions, separating orthogonal regions with "--"
+func writeMermaidRegions(out *strings.Builder, regions []*Region, level int) {
+	indent := strings.Repeat("  ", level)
+	written := 0
+	for _, region := range regions {
+		if region == nil {
+			continue
+		}
+		if written > 0 {
+			out.WriteString(indent + "--\n")
+		}
+		written++
+
+		for _, vertex := range region.Vertices {
+			if vertex == nil || mermaidEndpoint(vertex) == "[*]" {
+				continue
+			}
+			alias := diagramAlias(vertex.ID)
+			switch pseudostateKindOf(vertex) {
+			case PseudostateKindChoice, PseudostateKindJunction:
+				out.WriteString(fmt.Sprintf("%sstate %s <<choice>>\n", indent, alias))
+			case PseudostateKindFork:
+				out.WriteString(fmt.Sprintf("%sstate %s <<fork>>\n", indent, alias))
+			case PseudostateKindJoin:
+				out.WriteString(fmt.Sprintf("%sstate %s <<join>>\n", indent, alias))
+			case PseudostateKindShallowHistory:
+				out.WriteString(fmt.Sprintf("%sstate \"H\" as %s\n", indent, alias))
+			case PseudostateKindDeepHistory:
+				out.WriteString(fmt.Sprintf("%sstate \"H*\" as %s\n", indent, alias))
+			default:
+				out.WriteString(fmt.Sprintf("%sstate \"%s\" as %s\n", indent, mermaidText(displayName(vertex.Name, vertex.ID)), alias))
+			}
+		}
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			alias := diagramAlias(state.ID)
+			out.WriteString(fmt.Sprintf("%sstate \"%s\" as %s\n", indent, mermaidText(displayName(state.Name, state.ID)), alias))
+			if len(state.Regions) > 0 {
+				out.WriteString(fmt.Sprintf("%sstate %s {\n", indent, alias))
+				writeMermaidRegions(out, state.Regions, level+1)
+				out.WriteString(indent + "}\n")
+			}
+		}
+		for _, transition := range region.Transitions {
+			if transition == nil || transition.Source == nil || transition.Target == nil {
+				continue
+			}
+			line := fmt.Sprintf("%s%s --> %s", indent, mermaidEndpoint(transition.Source), mermaidEndpoint(transition.Target))
+			if label := transitionLabel(transition); label != "" {
+				line += " : " + mermaidText(label)
+			}
+			out.WriteString(line + "\n")
+		}
+	}
+}
+
+// mermaidEndpoint returns how a transition end is written: "[*]" for initial
+// pseudostates and final states, and the vertex alias otherwise
+func mermaidEndpoint(vertex *Vertex) string {
+	if vertex.Type == "finalstate" || vertexPseudostateKind(vertex) == PseudostateKindInitial {
+		return "[*]"
+	}
+	return diagramAlias(vertex.ID)
+}
+
+// mermaidText makes a name or label safe to use in a Mermaid diagram, which
+// has no escape for double quotes and ends lines at newlines
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "'", "\n", " ", "\r", "").Replace(s)
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMermaidExporter(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Regions[0].Transitions[2].Guard = &Constraint{ID: "may-stop", Specification: `"confirmed"`}
+	check := &Vertex{ID: "check", Name: "choice", Type: "pseudostate"}
+	done := &Vertex{ID: "done", Name: "Done", Type: "finalstate"}
+	sm.Regions[0].Vertices = append(sm.Regions[0].Vertices, check, done, &Vertex{ID: "resume", Name: "H*", Type: "pseudostate"})
+	sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, execTransition("finish", check, done))
+
+	var out bytes.Buffer
+	if err := Export(sm, "mermaid", &out, ExportOptions{Properties: map[string]string{"direction": "LR"}}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	diagram := out.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "header", want: "---\ntitle: Player\n---\nstateDiagram-v2\n  direction LR\n"},
+		{name: "simple state", want: "  state \"idle\" as idle\n"},
+		{name: "composite state", want: "  state \"playing\" as playing\n  state playing {\n"},
+		{name: "initial in its region", want: "    [*] --> loading\n"},
+		{name: "orthogonal separator", want: "    --\n    state \"buffering\" as buffering\n"},
+		{name: "choice stereotype", want: "  state check <<choice>>\n"},
+		{name: "history", want: "  state \"H*\" as resume\n"},
+		{name: "final state", want: "  check --> [*]\n"},
+		{name: "quotes in labels", want: "  playing --> idle : stop ['confirmed']\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(diagram, tt.want) {
+				t.Errorf("Mermaid export missing %q in\n%s", tt.want, diagram)
+			}
+		})
+	}
+	if strings.Contains(diagram, "Done") {
+		t.Errorf("final states should only be drawn as [*]:\n%s", diagram)
+	}
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MetadataMergeStrategy decides the merged value of a metadata key that both
+// sides of a merge set to different values
+type MetadataMergeStrategy string
+
+const (
+	MetadataMergeOurs   MetadataMergeStrategy = "ours"   // Keep our value
+	MetadataMergeTheirs MetadataMergeStrategy = "theirs" // Take their value
+	MetadataMergeConcat MetadataMergeStrategy = "concat" // Our values followed by theirs, as a list; a value that is not a list counts as a list of one
+	MetadataMergeError  MetadataMergeStrategy = "error"  // Report a conflict
+)
+
+// MetadataMergePolicy selects the merge strategy per metadata key. A key of
+// Keys ending in "*" matches every key with that prefix, such as "deploy.*"
+// for "deploy.region"; exact keys take precedence over patterns and longer
+// patterns over shorter ones. Keys without a strategy use Default, and an
+// empty Default reports conflicts.
+type MetadataMergePolicy struct {
+	Default MetadataMergeStrategy
+	Keys    map[string]MetadataMergeStrategy
+}
+
+// strategy returns the strategy for a key
+func (p MetadataMergePolicy) strategy(key string) MetadataMergeStrategy {
+	if strategy, exists := p.Keys[key]; exists {
+		return strategy
+	}
+	var best string
+	strategy, matched := MetadataMergeStrategy(""), false
+	for pattern, candidate := range p.Keys {
+		prefix, isPattern := strings.CutSuffix(pattern, "*")
+		if isPattern && strings.HasPrefix(key, prefix) && (!matched || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best)) {
+			best, strategy, matched = pattern, candidate, true
+		}
+	}
+	if matched {
+		return strategy
+	}
+	if p.Default == "" {
+		return MetadataMergeError
+	}
+	return p.Default
+}
+
+// MetadataConflictError reports the metadata keys a merge could not combine
+type MetadataConflictError struct {
+	Keys []string // Conflicting keys in sorted order
+}
+
+// Error implements the error interface
+func (e *MetadataConflictError) Error() string {
+	return fmt.Sprintf("conflicting metadata for key(s) %s", strings.Join(e.Keys, ", "))
+}
+
+// MergeMetadata merges two metadata maps into a new map. Keys set on one
+// side only, or to equal values on both, are taken as they are; keys set to
+// different values are merged with the policy's strategy for the key.
+// MergeMetadata returns a *MetadataConflictError listing every key whose
+// strategy is MetadataMergeError or unknown, in which case the merged map
+// holds our values for those keys. Values are deep-copied, so the result
+// shares nothing with its inputs.
+func MergeMetadata(ours, theirs map[string]interface{}, policy MetadataMergePolicy) (map[string]interface{}, error) {
+	if ours == nil && theirs == nil {
+		return nil, nil
+	}
+
+	merged := make(map[string]interface{}, len(ours)+len(theirs))
+	for key, value := range ours {
+		merged[key] = copyJSONValue(value)
+	}
+
+	var conflicts []string
+	for _, key := range slices.Sorted(maps.Keys(theirs)) {
+		theirValue := theirs[key]
+		ourValue, exists := ours[key]
+		if !exists {
+			merged[key] = copyJSONValue(theirValue)
+			continue
+		}
+		if metadataJSON(ourValue) == metadataJSON(theirValue) {
+			continue
+		}
+
+		switch policy.strategy(key) {
+		case MetadataMergeOurs:
+		case MetadataMergeTheirs:
+			merged[key] = copyJSONValue(theirValue)
+		case MetadataMergeConcat:
+			merged[key] = append(metadataList(ourValue), metadataList(theirValue)...)
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return merged, &MetadataConflictError{Keys: conflicts}
+	}
+	return merged, nil
+}
+
+// metadataList returns a copy of a metadata value as a list
+func metadataList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return copyJSONValue(v).([]interface{})
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list
+	}
+	return []interface{}{copyJSONValue(value)}
+}
+
+// MergeMetadataFrom merges the metadata of other into the state machine's
+// metadata with MergeMetadata. On a conflict the state machine is left
+// unchanged.
+func (sm *StateMachine) MergeMetadataFrom(other *StateMachine, policy MetadataMergePolicy) error {
+	if other == nil {
+		return nil
+	}
+	merged, err := MergeMetadata(sm.Metadata, other.Metadata, policy)
+	if err != nil {
+		return err
+	}
+	sm.Metadata = merged
+	return nil
+}
+
+// diffMetadata compares two metadata maps key by key and reports each added,
+// removed or changed key as a modified "metadata.<key>" field of the state
+// machine, in key order
+func diffMetadata(id string, old, new map[string]interface{}) []Change {
+	keys := slices.Sorted(maps.Keys(old))
+	for key := range new {
+		if _, exists := old[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		before, after := diffMetadataValue(old, key), diffMetadataValue(new, key)
+		if before != after {
+			changes = append(changes, Change{
+				Kind:    ChangeModified,
+				Element: "StateMachine",
+				ID:      id,
+				Field:   "metadata." + key,
+				Old:     before,
+				New:     after,
+			})
+		}
+	}
+	return changes
+}
+
+// diffMetadataValue encodes a metadata value for comparison. Unlike diffJSON
+// it distinguishes a key set to an empty value from a missing key.
+func diffMetadataValue(metadata map[string]interface{}, key string) string {
+	value, exists := metadata[key]
+	if !exists {
+		return ""
+	}
+	return metadataJSON(value)
+}
+
+// metadataJSON encodes a metadata value for comparison
+func metadataJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	ours := map[string]interface{}{
+		"owner":          "payments",
+		"tags":           []interface{}{"core"},
+		"deploy.region":  "eu-west-1",
+		"deploy.replica": float64(2),
+		"same":           map[string]interface{}{"a": float64(1)},
+	}
+	theirs := map[string]interface{}{
+		"owner":          "platform",
+		"tags":           []interface{}{"critical"},
+		"deploy.region":  "us-east-1",
+		"deploy.replica": float64(3),
+		"same":           map[string]interface{}{"a": float64(1)},
+		"new":            true,
+	}
+
+	tests := []struct {
+		name          string
+		policy        MetadataMergePolicy
+		want          map[string]interface{}
+		wantConflicts []string
+	}{
+		{
+			name:          "conflicts by default",
+			policy:        MetadataMergePolicy{},
+			want:          map[string]interface{}{"owner": "payments", "tags": []interface{}{"core"}, "deploy.region": "eu-west-1", "deploy.replica": float64(2), "same": map[string]interface{}{"a": float64(1)}, "new": true},
+			wantConflicts: []string{"deploy.region", "deploy.replica", "owner", "tags"},
+		},
+		{
+			name: "per-key strategies",
+			policy: MetadataMergePolicy{
+				Default: MetadataMergeOurs,
+				Keys: map[string]MetadataMergeStrategy{
+					"tags":     MetadataMergeConcat,
+					"deploy.*": MetadataMergeTheirs,
+					"owner":    MetadataMergeConcat,
+				},
+			},
+			want: map[string]interface{}{
+				"owner":          []interface{}{"payments", "platform"},
+				"tags":           []interface{}{"core", "critical"},
+				"deploy.region":  "us-east-1",
+				"deploy.replica": float64(3),
+				"same":           map[string]interface{}{"a": float64(1)},
+				"new":            true,
+			},
+		},
+		{
+			name: "exact keys and longer patterns win",
+			policy: MetadataMergePolicy{
+				Default: MetadataMergeTheirs,
+				Keys: map[string]MetadataMergeStrategy{
+					"*":              MetadataMergeError,
+					"deploy.*":       MetadataMergeOurs,
+					"deploy.rep*":    MetadataMergeError,
+					"deploy.replica": MetadataMergeTheirs,
+				},
+			},
+			want:          map[string]interface{}{"owner": "payments", "tags": []interface{}{"core"}, "deploy.region": "eu-west-1", "deploy.replica": float64(3), "same": map[string]interface{}{"a": float64(1)}, "new": true},
+			wantConflicts: []string{"owner", "tags"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeMetadata(ours, theirs, tt.policy)
+			var conflict *MetadataConflictError
+			if tt.wantConflicts == nil {
+				if err != nil {
+					t.Fatalf("MergeMetadata() error = %v", err)
+				}
+			} else if !errors.As(err, &conflict) || !reflect.DeepEqual(conflict.Keys, tt.wantConflicts) {
+				t.Fatalf("MergeMetadata() error = %v, want conflicts %v", err, tt.wantConflicts)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("result shares nothing with inputs", func(t *testing.T) {
+		merged, err := MergeMetadata(ours, theirs, MetadataMergePolicy{Default: MetadataMergeOurs})
+		if err != nil {
+			t.Fatalf("MergeMetadata() error = %v", err)
+		}
+		merged["same"].(map[string]interface{})["a"] = float64(9)
+		merged["tags"].([]interface{})[0] = "changed"
+		if ours["same"].(map[string]interface{})["a"] != float64(1) || ours["tags"].([]interface{})[0] != "core" {
+			t.Errorf("inputs modified: %v", ours)
+		}
+	})
+
+	t.Run("nil maps", func(t *testing.T) {
+		if merged, err := MergeMetadata(nil, nil, MetadataMergePolicy{}); merged != nil || err != nil {
+			t.Errorf("MergeMetadata(nil, nil) = %v, %v", merged, err)
+		}
+		merged, err := MergeMetadata(nil, theirs, MetadataMergePolicy{})
+		if err != nil || len(merged) != len(theirs) {
+			t.Errorf("MergeMetadata(nil, theirs) = %v, %v", merged, err)
+		}
+	})
+}
+
+func TestStateMachineMergeMetadataFrom(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Metadata = map[string]interface{}{"owner": "payments"}
+	other := createValidStateMachine()
+	other.Metadata = map[string]interface{}{"owner": "platform", "tier": "gold"}
+
+	if err := sm.MergeMetadataFrom(other, MetadataMergePolicy{}); err == nil {
+		t.Fatal("MergeMetadataFrom() should report the owner conflict")
+	}
+	if len(sm.Metadata) != 1 {
+		t.Errorf("conflicting merge changed metadata: %v", sm.Metadata)
+	}
+
+	if err := sm.MergeMetadataFrom(other, MetadataMergePolicy{Keys: map[string]MetadataMergeStrategy{"owner": MetadataMergeTheirs}}); err != nil {
+		t.Fatalf("MergeMetadataFrom() error = %v", err)
+	}
+	if sm.Metadata["owner"] != "platform" || sm.Metadata["tier"] != "gold" {
+		t.Errorf("merged metadata = %v", sm.Metadata)
+	}
+	if err := sm.MergeMetadataFrom(nil, MetadataMergePolicy{}); err != nil {
+		t.Errorf("MergeMetadataFrom(nil) error = %v", err)
+	}
+}
+
+func TestDiffMetadata(t *testing.T) {
+	old := createValidStateMachine()
+	old.Metadata = map[string]interface{}{"owner": "payments", "replicas": 2, "retired": "yes", "empty": ""}
+	new := old.Clone()
+	new.Metadata = map[string]interface{}{"owner": "payments", "replicas": 3, "added": []string{"a"}, "empty": nil}
+
+	var got []Change
+	for _, change := range Diff(old, new) {
+		if change.Element == "StateMachine" {
+			got = append(got, change)
+		}
+	}
+	want := []Change{
+		{Kind: ChangeModified, Element: "StateMachine", ID: "sm1", Field: "metadata.added", Old: "", New: `["a"]`},
+		{Kind: ChangeModified, Element: "StateMachine", ID: "sm1", Field: "metadata.empty", Old: `""`, New: "null"},
+		{Kind: ChangeModified, Element: "StateMachine", ID: "sm1", Field: "metadata.replicas", Old: "2", New: "3"},
+		{Kind: ChangeModified, Element: "StateMachine", ID: "sm1", Field: "metadata.retired", Old: `"yes"`, New: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		data, _ := json.MarshalIndent(got, "", "  ")
+		t.Errorf("Diff() metadata changes = %s", data)
+	}
+	if !old.EquivalentTo(new) {
+		t.Error("metadata changes should not be semantic")
+	}
+
+	new.Metadata, old.Metadata = nil, map[string]interface{}{}
+	for _, change := range Diff(old, new) {
+		t.Errorf("unexpected change between empty and nil metadata: %v", change)
+	}
+}
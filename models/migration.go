@@ -0,0 +1,201 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MigrationMapping specifies where instances of an old version of a machine
+// go in the new version. States kept under the same ID and deprecated states
+// whose replacement exists in the new version need no entry.
+type MigrationMapping struct {
+	States     map[string]string `json:"states,omitempty"`     // New state ID by old state ID
+	Quarantine []string          `json:"quarantine,omitempty"` // Old state IDs whose instances are set aside for manual handling
+
+	// QuarantineUnmapped quarantines instances in old states that are neither
+	// mapped nor kept instead of rejecting the mapping as incomplete
+	QuarantineUnmapped bool `json:"quarantine_unmapped,omitempty"`
+}
+
+// MigrationAction is what happens to the instances in an old state
+type MigrationAction string
+
+const (
+	MigrationKeep       MigrationAction = "keep"       // The state exists in the new version under the same ID
+	MigrationMap        MigrationAction = "map"        // Instances move to the state named by the mapping
+	MigrationReplace    MigrationAction = "replace"    // Instances move to the state that replaces the deprecated old state
+	MigrationQuarantine MigrationAction = "quarantine" // Instances are set aside for manual handling
+)
+
+// MigrationStep is the plan for the instances in one old state
+type MigrationStep struct {
+	OldState string          `json:"old_state"`
+	Action   MigrationAction `json:"action"`
+	NewState string          `json:"new_state,omitempty"` // Empty when quarantined
+	Note     string          `json:"note,omitempty"`      // What else happens on arrival, e.g. entering default substates
+}
+
+// String returns a concise one-line description of the step
+func (s MigrationStep) String() string {
+	line := fmt.Sprintf("%s: %s", s.OldState, s.Action)
+	if s.NewState != "" {
+		line += " -> " + s.NewState
+	}
+	if s.Note != "" {
+		line += " (" + s.Note + ")"
+	}
+	return line
+}
+
+// MigrationPlan describes, for each state of the old version of a machine,
+// where its running instances go in the new version
+type MigrationPlan struct {
+	From  string          `json:"from"` // Version of the old machine
+	To    string          `json:"to"`   // Version of the new machine
+	Steps []MigrationStep `json:"steps"`
+}
+
+// Step returns the step for an old state
+func (p *MigrationPlan) Step(oldState string) (MigrationStep, bool) {
+	for _, step := range p.Steps {
+		if step.OldState == oldState {
+			return step, true
+		}
+	}
+	return MigrationStep{}, false
+}
+
+// Quarantined returns the old states whose instances are quarantined
+func (p *MigrationPlan) Quarantined() []string {
+	var states []string
+	for _, step := range p.Steps {
+		if step.Action == MigrationQuarantine {
+			states = append(states, step.OldState)
+		}
+	}
+	return states
+}
+
+// String returns one line per step
+func (p *MigrationPlan) String() string {
+	lines := []string{fmt.Sprintf("migration %s -> %s", displayName(p.From, "?"), displayName(p.To, "?"))}
+	for _, step := range p.Steps {
+		lines = append(lines, "  "+step.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PlanMigration plans the migration of running instances from the old to
+// the new version of a machine. Every state and final state of the old
+// version gets a step, in model order: it is kept when the new version has
+// a state with the same ID, mapped as mapping.States says, replaced when it
+// is deprecated and its ReplacedBy exists in the new version, or quarantined.
+// Mapping entries take precedence over keeping and replacing. The plan is
+// returned even when the mapping is incomplete or inconsistent; the error,
+// a *ValidationErrors, then lists old states without a destination, mapping
+// entries naming states that do not exist, and states both mapped and
+// quarantined.
+func PlanMigration(old, new *StateMachine, mapping *MigrationMapping) (*MigrationPlan, error) {
+	if old == nil || new == nil {
+		return nil, fmt.Errorf("both state machines are required")
+	}
+	if mapping == nil {
+		mapping = &MigrationMapping{}
+	}
+	before, after := migrationStates(old), migrationStates(new)
+	errors := &ValidationErrors{}
+	report := func(field, id, format string, args ...any) {
+		errors.AddError(ErrorTypeReference, "MigrationMapping", field, fmt.Sprintf(format, args...), []string{field, id})
+	}
+
+	quarantined := make(map[string]bool)
+	for _, id := range mapping.Quarantine {
+		quarantined[id] = true
+		if before.index[id] == nil {
+			report("Quarantine", id, "quarantined state '%s' is not a state of the old version%s", id, didYouMean(id, before.index))
+		}
+		if _, mapped := mapping.States[id]; mapped {
+			report("Quarantine", id, "state '%s' is both mapped and quarantined", id)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(mapping.States)) {
+		target := mapping.States[id]
+		if before.index[id] == nil {
+			report("States", id, "mapped state '%s' is not a state of the old version%s", id, didYouMean(id, before.index))
+		}
+		if after.index[target] == nil {
+			report("States", id, "state '%s' is mapped to '%s', which is not a state of the new version%s", id, target, didYouMean(target, after.index))
+		}
+	}
+
+	plan := &MigrationPlan{From: old.Version, To: new.Version}
+	for _, state := range before.order {
+		step := MigrationStep{OldState: state.ID}
+		replacement := after.index[state.ReplacedBy]
+		switch target, mapped := mapping.States[state.ID]; {
+		case quarantined[state.ID]:
+			step.Action = MigrationQuarantine
+		case mapped:
+			step.Action, step.NewState = MigrationMap, target
+		case after.index[state.ID] != nil:
+			step.Action, step.NewState = MigrationKeep, state.ID
+		case state.Deprecated && replacement != nil:
+			step.Action, step.NewState = MigrationReplace, replacement.ID
+		case mapping.QuarantineUnmapped:
+			step.Action, step.Note = MigrationQuarantine, "not mapped"
+		default:
+			step.Action = MigrationQuarantine
+			report("States", state.ID, "state '%s' does not exist in the new version and is neither mapped nor quarantined%s", state.ID, didYouMean(state.ID, after.index))
+		}
+		if target := after.index[step.NewState]; target != nil {
+			step.Note = migrationNote(target)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, errors.ToError()
+}
+
+// migrationNote describes what instances do on arriving in a new state
+func migrationNote(target *State) string {
+	switch {
+	case target.Deprecated:
+		return "the state is deprecated"
+	case target.Submachine != nil:
+		return "enters submachine " + target.Submachine.ID
+	case len(nonNilRegions(target.Regions)) > 0:
+		return "enters the default substates of " + regionList(nonNilRegions(target.Regions))
+	}
+	return ""
+}
+
+// migrationModel lists the states instances of a machine can be in
+type migrationModel struct {
+	order []*State          // In model order; final states as states of type "finalstate"
+	index map[string]*State // By ID
+}
+
+// migrationStates lists the states and final states of a machine
+func migrationStates(sm *StateMachine) *migrationModel {
+	model := &migrationModel{index: make(map[string]*State)}
+	add := func(state *State) {
+		if _, exists := model.index[state.ID]; !exists {
+			model.index[state.ID] = state
+			model.order = append(model.order, state)
+		}
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil {
+				add(state)
+			}
+		}
+		for _, vertex := range region.Vertices {
+			if vertex != nil && vertex.Type == "finalstate" {
+				add(&State{Vertex: *vertex})
+			}
+		}
+	})
+	return model
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newMigrationVersions returns version 1.0 of the player machine and a
+// version 2.0 in which idle is renamed to stopped, streaming is removed and
+// loading becomes a composite state
+func newMigrationVersions() (*StateMachine, *StateMachine) {
+	old := newPlayerMachine()
+	new := old.Clone()
+	new.Version = "2.0"
+	main := new.Regions[0]
+	main.States[0].ID = "stopped"
+	audio := main.States[1].Regions[0]
+	audio.States = audio.States[:1]
+	audio.Transitions = audio.Transitions[:1]
+	loading := audio.States[0]
+	loading.Regions = []*Region{{ID: "fetch"}}
+	loading.IsSimple, loading.IsComposite = false, true
+	return old, new
+}
+
+func TestPlanMigration(t *testing.T) {
+	old, new := newMigrationVersions()
+	mapping := &MigrationMapping{States: map[string]string{"idle": "stopped"}, Quarantine: []string{"streaming"}}
+
+	plan, err := PlanMigration(old, new, mapping)
+	if err != nil {
+		t.Fatalf("PlanMigration() error = %v", err)
+	}
+	want := strings.Join([]string{
+		"migration 1.0 -> 2.0",
+		"  idle: map -> stopped",
+		"  playing: keep -> playing (enters the default substates of audio and video)",
+		"  loading: keep -> loading (enters the default substates of fetch)",
+		"  streaming: quarantine",
+		"  buffering: keep -> buffering",
+		"  rendering: keep -> rendering",
+	}, "\n")
+	if got := plan.String(); got != want {
+		t.Errorf("PlanMigration() plan:\n%s\nwant:\n%s", got, want)
+	}
+	if got := plan.Quarantined(); len(got) != 1 || got[0] != "streaming" {
+		t.Errorf("Quarantined() = %v", got)
+	}
+	if step, ok := plan.Step("idle"); !ok || step.NewState != "stopped" {
+		t.Errorf("Step(idle) = %v, %v", step, ok)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil || !strings.Contains(string(data), `{"old_state":"idle","action":"map","new_state":"stopped"}`) {
+		t.Errorf("json.Marshal() = %s, %v", data, err)
+	}
+}
+
+func TestPlanMigration_Replacement(t *testing.T) {
+	old, new := newMigrationVersions()
+	old.Regions[0].States[0].Deprecated = true
+	old.Regions[0].States[0].ReplacedBy = "stopped"
+
+	plan, err := PlanMigration(old, new, &MigrationMapping{QuarantineUnmapped: true})
+	if err != nil {
+		t.Fatalf("PlanMigration() error = %v", err)
+	}
+	tests := []struct {
+		oldState string
+		want     string
+	}{
+		{oldState: "idle", want: "idle: replace -> stopped"},
+		{oldState: "streaming", want: "streaming: quarantine (not mapped)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.oldState, func(t *testing.T) {
+			if step, _ := plan.Step(tt.oldState); step.String() != tt.want {
+				t.Errorf("Step(%s) = %q, want %q", tt.oldState, step, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanMigration_Incomplete(t *testing.T) {
+	old, new := newMigrationVersions()
+	mapping := &MigrationMapping{
+		States:     map[string]string{"idle": "stoped", "paused": "stopped", "playing": "playing"},
+		Quarantine: []string{"playing"},
+	}
+
+	plan, err := PlanMigration(old, new, mapping)
+	var errs *ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("PlanMigration() error = %v, want validation errors", err)
+	}
+	if plan == nil || len(plan.Steps) != 6 {
+		t.Fatalf("PlanMigration() should return the plan with the errors")
+	}
+	for _, want := range []string{
+		"state 'playing' is both mapped and quarantined",
+		"state 'idle' is mapped to 'stoped', which is not a state of the new version; did you mean 'stopped'?",
+		"mapped state 'paused' is not a state of the old version",
+		"state 'streaming' does not exist in the new version and is neither mapped nor quarantined",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("PlanMigration() error = %v, want %q", err, want)
+		}
+	}
+	if len(errs.Errors) != 4 {
+		t.Errorf("PlanMigration() reported %d errors, want 4: %v", len(errs.Errors), err)
+	}
+
+	if _, err := PlanMigration(nil, new, nil); err == nil {
+		t.Error("PlanMigration() should require both machines")
+	}
+}
@@ -0,0 +1,334 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameStyle identifies a capitalization and word-separation convention for element names
+type NameStyle string
+
+// Supported name styles
+const (
+	NameStyleAny        NameStyle = ""           // Any style is accepted
+	NameStyleCamelCase  NameStyle = "camelCase"  // e.g. "awaitingPayment"
+	NameStylePascalCase NameStyle = "PascalCase" // e.g. "AwaitingPayment"
+	NameStyleTitleCase  NameStyle = "Title Case" // e.g. "Awaiting Payment"
+	NameStyleSnakeCase  NameStyle = "snake_case" // e.g. "awaiting_payment"
+	NameStyleKebabCase  NameStyle = "kebab-case" // e.g. "awaiting-payment"
+)
+
+// NamePolicy describes the naming rules applied to element names. Zero values
+// disable the corresponding check, so the zero NamePolicy accepts every name.
+type NamePolicy struct {
+	Style         NameStyle // Required style; NameStyleAny accepts any style
+	MinLength     int       // Minimum length in characters; 0 means no minimum
+	MaxLength     int       // Maximum length in characters; 0 means no maximum
+	ReservedWords []string  // Names that may not be used, compared case-insensitively
+}
+
+// Check returns the reasons name violates the policy, or nil if it conforms
+func (p *NamePolicy) Check(name string) []string {
+	if p == nil {
+		return nil
+	}
+
+	var problems []string
+	length := utf8.RuneCountInString(name)
+	if p.MinLength > 0 && length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("is shorter than %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("is longer than %d characters", p.MaxLength))
+	}
+	if p.Style != NameStyleAny && !p.Style.Matches(name) {
+		problems = append(problems, fmt.Sprintf("is not in %s style", p.Style))
+	}
+	for _, word := range p.ReservedWords {
+		if strings.EqualFold(name, word) {
+			problems = append(problems, fmt.Sprintf("is a reserved word (%s)", word))
+			break
+		}
+	}
+	return problems
+}
+
+// Matches reports whether name follows the style. Letters from scripts
+// without case, such as Japanese, are accepted wherever a lower-case letter
+// is expected, and also satisfy the upper-case requirement at the start of
+// PascalCase names and Title Case words.
+func (s NameStyle) Matches(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	switch s {
+	case NameStyleAny:
+		return true
+	case NameStyleCamelCase:
+		first, _ := utf8.DecodeRuneInString(name)
+		return isLowerOrCaseless(first) && isAlphanumeric(name)
+	case NameStylePascalCase:
+		first, _ := utf8.DecodeRuneInString(name)
+		return isUpperOrCaseless(first) && isAlphanumeric(name)
+	case NameStyleTitleCase:
+		for _, word := range strings.Split(name, " ") {
+			first, _ := utf8.DecodeRuneInString(word)
+			if word == "" || !(isUpperOrCaseless(first) || unicode.IsDigit(first)) || !isAlphanumeric(word) {
+				return false
+			}
+		}
+		return true
+	case NameStyleSnakeCase:
+		return isLowerWords(name, "_")
+	case NameStyleKebabCase:
+		return isLowerWords(name, "-")
+	default:
+		return false
+	}
+}
+
+// isLowerWords reports whether name consists of non-empty lower-case words joined by sep
+func isLowerWords(name, sep string) bool {
+	for _, word := range strings.Split(name, sep) {
+		if word == "" || !isAlphanumeric(word) {
+			return false
+		}
+		for _, r := range word {
+			if unicode.IsUpper(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isAlphanumeric reports whether s contains only letters and digits
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLowerOrCaseless reports whether r is a lower-case letter or a letter without case
+func isLowerOrCaseless(r rune) bool {
+	return unicode.IsLetter(r) && !unicode.IsUpper(r) && !unicode.IsTitle(r)
+}
+
+// isUpperOrCaseless reports whether r is an upper-case letter or a letter without case
+func isUpperOrCaseless(r rune) bool {
+	return unicode.IsLetter(r) && !unicode.IsLower(r)
+}
+
+// namePolicyFor returns the policy applied to names of the given object type
+func (p *ValidationProfile) namePolicyFor(objectName string) *NamePolicy {
+	if p == nil {
+		return nil
+	}
+	if policy, exists := p.NamePolicies[objectName]; exists {
+		return policy
+	}
+	return p.NamePolicy
+}
+
+// ValidateName checks an element name against the name policy of the
+// context's profile. Empty names are left to ValidateRequired.
+func (vh *ValidationHelper) ValidateName(name, objectName string, context *ValidationContext, errors *ValidationErrors) {
+	policy := context.ActiveProfile().namePolicyFor(objectName)
+	if name == "" || policy == nil {
+		return
+	}
+	for _, problem := range policy.Check(name) {
+		errors.AddError(
+			ErrorTypeConstraint,
+			objectName,
+			"Name",
+			fmt.Sprintf("%s name '%s' %s (name policy)", objectName, name, problem),
+			context.Path,
+		)
+	}
+}
+
+// NameKeywords maps a vertex kind to words that names of that kind are
+// expected to contain. Keys are PseudostateKind values, or "finalstate" for
+// final states. Keyword checks are opt-in: they only run when the active
+// profile sets NameKeywords, so models authored in any language validate
+// structurally by default.
+type NameKeywords map[string][]string
+
+// FinalStateKeywordKey is the NameKeywords key used for final states
+const FinalStateKeywordKey = "finalstate"
+
+// Built-in keyword sets. They can be combined with MergeNameKeywords to
+// accept names in several languages.
+var (
+	// EnglishNameKeywords holds English keywords for final states and pseudostates
+	EnglishNameKeywords = NameKeywords{
+		FinalStateKeywordKey:                  {"final", "end", "complete", "done", "finish"},
+		string(PseudostateKindInitial):        {"initial", "init", "start", "begin"},
+		string(PseudostateKindShallowHistory): {"history", "H"},
+		string(PseudostateKindDeepHistory):    {"history", "H*"},
+		string(PseudostateKindJoin):           {"join", "merge", "sync"},
+		string(PseudostateKindFork):           {"fork", "split", "parallel"},
+		string(PseudostateKindJunction):       {"junction", "branch"},
+		string(PseudostateKindChoice):         {"choice", "decide", "decision", "select"},
+		string(PseudostateKindEntryPoint):     {"entry", "enter"},
+		string(PseudostateKindExitPoint):      {"exit", "leave"},
+		string(PseudostateKindTerminate):      {"terminate", "stop", "kill", "abort"},
+	}
+
+	// GermanNameKeywords holds German keywords for final states and pseudostates
+	GermanNameKeywords = NameKeywords{
+		FinalStateKeywordKey:                  {"ende", "end", "fertig", "abgeschlossen"},
+		string(PseudostateKindInitial):        {"anfang", "start", "beginn", "initial"},
+		string(PseudostateKindShallowHistory): {"historie", "verlauf", "H"},
+		string(PseudostateKindDeepHistory):    {"historie", "verlauf", "H*"},
+		string(PseudostateKindJoin):           {"vereinigung", "zusammenführung", "join"},
+		string(PseudostateKindFork):           {"gabelung", "aufteilung", "fork"},
+		string(PseudostateKindJunction):       {"kreuzung", "verzweigung"},
+		string(PseudostateKindChoice):         {"auswahl", "entscheidung"},
+		string(PseudostateKindEntryPoint):     {"eingang", "eintritt"},
+		string(PseudostateKindExitPoint):      {"ausgang", "austritt"},
+		string(PseudostateKindTerminate):      {"abbruch", "beenden"},
+	}
+
+	// JapaneseNameKeywords holds Japanese keywords for final states and pseudostates
+	JapaneseNameKeywords = NameKeywords{
+		FinalStateKeywordKey:                  {"終了", "完了", "終端"},
+		string(PseudostateKindInitial):        {"開始", "初期"},
+		string(PseudostateKindShallowHistory): {"履歴", "H"},
+		string(PseudostateKindDeepHistory):    {"履歴", "H*"},
+		string(PseudostateKindJoin):           {"合流", "結合"},
+		string(PseudostateKindFork):           {"分岐", "フォーク"},
+		string(PseudostateKindJunction):       {"接合", "ジャンクション"},
+		string(PseudostateKindChoice):         {"選択", "判断"},
+		string(PseudostateKindEntryPoint):     {"入口", "入場"},
+		string(PseudostateKindExitPoint):      {"出口", "退場"},
+		string(PseudostateKindTerminate):      {"停止", "中止"},
+	}
+)
+
+// MergeNameKeywords combines keyword sets so that a name matching a keyword
+// from any of them is accepted
+func MergeNameKeywords(sets ...NameKeywords) NameKeywords {
+	merged := make(NameKeywords)
+	for _, set := range sets {
+		for kind, keywords := range set {
+			for _, keyword := range keywords {
+				if !slices.Contains(merged[kind], keyword) {
+					merged[kind] = append(merged[kind], keyword)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// Matches reports whether name contains one of the keywords configured for
+// kind. Matching is case-insensitive using Unicode case folding. Glyph
+// keywords with a single letter, such as "H" and "H*" for history, only match
+// as whole words. Kinds without keywords always match.
+func (k NameKeywords) Matches(kind, name string) bool {
+	keywords := k[kind]
+	if len(keywords) == 0 {
+		return true
+	}
+	folded := foldName(name)
+	for _, keyword := range keywords {
+		keyword = foldName(keyword)
+		if isGlyphKeyword(keyword) {
+			if containsWord(folded, keyword) {
+				return true
+			}
+		} else if strings.Contains(folded, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGlyphKeyword reports whether keyword has at most one letter
+func isGlyphKeyword(keyword string) bool {
+	letters := 0
+	for _, r := range keyword {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters <= 1
+}
+
+// containsWord reports whether word occurs in s without a letter or digit
+// directly before or after it
+func containsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for offset := 0; offset <= len(s)-len(word); {
+		index := strings.Index(s[offset:], word)
+		if index < 0 {
+			return false
+		}
+		start, end := offset+index, offset+index+len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(s) || !isWordRune(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		offset = start + size
+	}
+	return false
+}
+
+// foldName maps s to a case-folded form suitable for substring matching
+func foldName(s string) string {
+	return strings.Map(func(r rune) rune {
+		// SimpleFold cycles through equivalent runes; the smallest one is the canonical form
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		return folded
+	}, s)
+}
+
+// ValidateNameKeywords checks that a final state or pseudostate name
+// contains one of the keywords the context's profile configures for kind.
+// Empty names are left to the required-name rules.
+func (vh *ValidationHelper) ValidateNameKeywords(name, kind, objectName string, context *ValidationContext, errors *ValidationErrors) {
+	keywords := context.ActiveProfile().NameKeywords
+	if name == "" || keywords == nil || keywords.Matches(kind, name) {
+		return
+	}
+	errors.AddError(
+		ErrorTypeConstraint,
+		objectName,
+		"Name",
+		fmt.Sprintf("%s name '%s' should contain one of the keywords configured for %s: %s (naming keywords)", objectName, name, kind, strings.Join(keywords[kind], ", ")),
+		context.Path,
+	)
+}
+
+// vertexObjectName returns the object name used for a vertex of the given type
+func vertexObjectName(vertexType string) string {
+	switch vertexType {
+	case "state":
+		return "State"
+	case "pseudostate":
+		return "Pseudostate"
+	case "finalstate":
+		return "FinalState"
+	default:
+		return "Vertex"
+	}
+}
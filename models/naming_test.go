@@ -0,0 +1,264 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNameStyle_Matches(t *testing.T) {
+	tests := []struct {
+		style NameStyle
+		name  string
+		want  bool
+	}{
+		{NameStyleAny, "anything goes", true},
+		{NameStyleCamelCase, "awaitingPayment", true},
+		{NameStyleCamelCase, "AwaitingPayment", false},
+		{NameStyleCamelCase, "awaiting_payment", false},
+		{NameStylePascalCase, "AwaitingPayment", true},
+		{NameStylePascalCase, "awaitingPayment", false},
+		{NameStylePascalCase, "Zahlung", true},
+		{NameStyleTitleCase, "Awaiting Payment", true},
+		{NameStyleTitleCase, "Step 2", true},
+		{NameStyleTitleCase, "Awaiting payment", false},
+		{NameStyleTitleCase, "Awaiting  Payment", false},
+		{NameStyleSnakeCase, "awaiting_payment", true},
+		{NameStyleSnakeCase, "awaiting__payment", false},
+		{NameStyleSnakeCase, "Awaiting_payment", false},
+		{NameStyleKebabCase, "awaiting-payment", true},
+		{NameStyleKebabCase, "awaiting_payment", false},
+		{NameStyleCamelCase, "支払い待ち", true},
+		{NameStylePascalCase, "支払い待ち", true},
+		{NameStyle("unknown"), "name", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.style)+"/"+tt.name, func(t *testing.T) {
+			if got := tt.style.Matches(tt.name); got != tt.want {
+				t.Errorf("%q.Matches(%q) = %v, want %v", tt.style, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamePolicy_Check(t *testing.T) {
+	policy := &NamePolicy{
+		Style:         NameStyleTitleCase,
+		MinLength:     3,
+		MaxLength:     12,
+		ReservedWords: []string{"Default"},
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Shipped", nil},
+		{"Ab", []string{"is shorter than 3 characters"}},
+		{"Awaiting Customer Payment", []string{"is longer than 12 characters"}},
+		{"shipped", []string{"is not in Title Case style"}},
+		{"DEFAULT", []string{"is a reserved word (Default)"}},
+		{"Übermittelt", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Check(tt.name)
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("Check(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	var nilPolicy *NamePolicy
+	if got := nilPolicy.Check("x"); got != nil {
+		t.Errorf("nil policy Check() = %v, want nil", got)
+	}
+}
+
+func TestValidationProfile_NamePolicy(t *testing.T) {
+	newMachine := func() *StateMachine {
+		sm := createValidStateMachine()
+		sm.Regions[0].States[1].Name = "awaiting_shipment"
+		sm.Regions[0].Vertices[1].Name = "Shipped"
+		if err := ResolveEndpoints(sm); err != nil {
+			t.Fatalf("ResolveEndpoints() error = %v", err)
+		}
+		return sm
+	}
+
+	tests := []struct {
+		name         string
+		profile      *ValidationProfile
+		wantContains []string
+		wantAbsent   []string
+	}{
+		{
+			name:       "default profile applies no name policy or keyword heuristics",
+			profile:    nil,
+			wantAbsent: []string{"name policy", "should suggest"},
+		},
+		{
+			name:    "profile name policy applies to every element",
+			profile: &ValidationProfile{Name: "titles", NamePolicy: &NamePolicy{Style: NameStyleTitleCase}},
+			wantContains: []string{
+				"State name 'awaiting_shipment' is not in Title Case style (name policy)",
+				"Transition name 'State1 to State2' is not in Title Case style (name policy)",
+			},
+			wantAbsent: []string{"Shipped", "Main Region"},
+		},
+		{
+			name: "per-element override replaces the profile policy",
+			profile: &ValidationProfile{
+				Name:         "states",
+				NamePolicy:   &NamePolicy{Style: NameStyleTitleCase},
+				NamePolicies: map[string]*NamePolicy{"State": {MaxLength: 20}},
+			},
+			wantContains: []string{"Transition name 'State2 to Final'"},
+			wantAbsent:   []string{"State name"},
+		},
+		{
+			name: "reserved words and length limits",
+			profile: &ValidationProfile{Name: "reserved", NamePolicies: map[string]*NamePolicy{
+				"FinalState": {ReservedWords: []string{"shipped"}},
+				"State":      {MaxLength: 10},
+			}},
+			wantContains: []string{
+				"FinalState name 'Shipped' is a reserved word (shipped) (name policy)",
+				"State name 'awaiting_shipment' is longer than 10 characters (name policy)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := NewValidationContext()
+			if tt.profile != nil {
+				context = context.WithProfile(tt.profile)
+			}
+
+			err := newMachine().ValidateInContext(context)
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(message, want) {
+					t.Errorf("ValidateInContext() error = %v, want it to contain %q", message, want)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(message, absent) {
+					t.Errorf("ValidateInContext() error = %v, should not contain %q", message, absent)
+				}
+			}
+		})
+	}
+}
+
+func TestNameKeywords_Matches(t *testing.T) {
+	combined := MergeNameKeywords(EnglishNameKeywords, GermanNameKeywords, JapaneseNameKeywords)
+
+	tests := []struct {
+		name     string
+		keywords NameKeywords
+		kind     string
+		vertex   string
+		want     bool
+	}{
+		{"english final", EnglishNameKeywords, FinalStateKeywordKey, "Order Done", true},
+		{"english rejects german", EnglishNameKeywords, FinalStateKeywordKey, "Bestellung Abgeschlossen", false},
+		{"german final", GermanNameKeywords, FinalStateKeywordKey, "Bestellung Abgeschlossen", true},
+		{"german folds umlauts", GermanNameKeywords, string(PseudostateKindJoin), "ZUSAMMENFÜHRUNG", true},
+		{"japanese initial", JapaneseNameKeywords, string(PseudostateKindInitial), "注文開始", true},
+		{"merged accepts any language", combined, FinalStateKeywordKey, "注文完了", true},
+		{"merged still rejects unrelated", combined, string(PseudostateKindInitial), "Shipped", false},
+		{"history glyph as whole name", EnglishNameKeywords, string(PseudostateKindShallowHistory), "H", true},
+		{"deep history glyph as word", EnglishNameKeywords, string(PseudostateKindDeepHistory), "Resume H*", true},
+		{"history glyph inside a word", EnglishNameKeywords, string(PseudostateKindShallowHistory), "Checkout", false},
+		{"deep history glyph inside a word", EnglishNameKeywords, string(PseudostateKindDeepHistory), "Hash*", false},
+		{"kind without keywords matches", NameKeywords{"custom": {"x"}}, FinalStateKeywordKey, "Shipped", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keywords.Matches(tt.kind, tt.vertex); got != tt.want {
+				t.Errorf("Matches(%q, %q) = %v, want %v", tt.kind, tt.vertex, got, tt.want)
+			}
+		})
+	}
+
+	if got := len(combined[FinalStateKeywordKey]); got <= len(EnglishNameKeywords[FinalStateKeywordKey]) {
+		t.Errorf("MergeNameKeywords() final state keywords = %d, want more than the English set", got)
+	}
+}
+
+func TestValidationProfile_NameKeywords(t *testing.T) {
+	newMachine := func() *StateMachine {
+		sm := createValidStateMachine()
+		sm.Regions[0].Vertices[1].Name = "Abgeschlossen"
+		if err := ResolveEndpoints(sm); err != nil {
+			t.Fatalf("ResolveEndpoints() error = %v", err)
+		}
+		sm.ConnectionPoints = []*Pseudostate{
+			{Vertex: Vertex{ID: "cp1", Name: "Eingang", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint},
+		}
+		return sm
+	}
+
+	tests := []struct {
+		name         string
+		profile      *ValidationProfile
+		wantContains []string
+		wantAbsent   []string
+	}{
+		{
+			name:       "keyword checks are off by default",
+			profile:    nil,
+			wantAbsent: []string{"naming keywords"},
+		},
+		{
+			name:    "english keywords flag german names",
+			profile: &ValidationProfile{Name: "en", NameKeywords: EnglishNameKeywords},
+			wantContains: []string{
+				"FinalState name 'Abgeschlossen' should contain one of the keywords configured for finalstate",
+				"Pseudostate name 'Eingang' should contain one of the keywords configured for entryPoint",
+			},
+		},
+		{
+			name:       "german keywords accept german names",
+			profile:    &ValidationProfile{Name: "de", NameKeywords: GermanNameKeywords},
+			wantAbsent: []string{"naming keywords"},
+		},
+		{
+			name:       "custom keyword set",
+			profile:    &ValidationProfile{Name: "custom", NameKeywords: NameKeywords{FinalStateKeywordKey: {"geschlossen"}}},
+			wantAbsent: []string{"naming keywords"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := NewValidationContext()
+			if tt.profile != nil {
+				context = context.WithProfile(tt.profile)
+			}
+
+			err := newMachine().ValidateInContext(context)
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(message, want) {
+					t.Errorf("ValidateInContext() error = %v, want it to contain %q", message, want)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(message, absent) {
+					t.Errorf("ValidateInContext() error = %v, should not contain %q", message, absent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"context"
+	"slices"
+)
+
+// ValidationOptions gathers the settings of a validation run. The zero value
+// validates like Validate: with DefaultProfile, reporting every finding.
+// Options are usually built from ValidationOption functions passed to
+// ValidateWithOptions or ValidateAllWithOptions.
+type ValidationOptions struct {
+	Context     context.Context    // Cancels validations that have not started yet; nil means context.Background()
+	Profile     *ValidationProfile // Profile validated with; nil uses DefaultProfile
+	MaxErrors   int                // Errors reported at most, the first in report order; zero or less reports all
+	MinSeverity Severity           // Least severe findings kept: SeverityError drops warnings and infos, SeverityWarning drops infos; empty keeps all
+	MaxDepth    int                // Region nesting depth validated, overriding the profile's Limits.MaxDepth; zero or less keeps the profile's
+	Workers     int                // Machines validated concurrently by ValidateAllWithOptions; zero or less uses runtime.GOMAXPROCS(0)
+	FailFast    bool               // Stop ValidateAllWithOptions after the first invalid machine
+
+	// Metadata is attached to the validation context for custom rules;
+	// see ContextKey and WithContextValue
+	Metadata map[string]interface{}
+
+	// RuleProfile records the time spent per rule and element; nil disables
+	// profiling
+	RuleProfile *RuleProfile
+
+	// Baseline drops accepted findings, reporting only new ones; nil reports
+	// all findings
+	Baseline *Baseline
+
+	// VertexStore serves the vertex lookups of the rules, for machines too
+	// large to index in memory; nil indexes each machine in memory
+	VertexStore VertexStore
+}
+
+// ValidationOption sets one field of ValidationOptions
+type ValidationOption func(*ValidationOptions)
+
+// NewValidationOptions returns the options set by opts, applied in order
+func NewValidationOptions(opts ...ValidationOption) ValidationOptions {
+	var options ValidationOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// WithContext sets the context whose cancellation stops validations that
+// have not started yet
+func WithContext(ctx context.Context) ValidationOption {
+	return func(o *ValidationOptions) { o.Context = ctx }
+}
+
+// WithProfile sets the profile validated with
+func WithProfile(profile *ValidationProfile) ValidationOption {
+	return func(o *ValidationOptions) { o.Profile = profile }
+}
+
+// WithMaxErrors reports at most n errors per machine
+func WithMaxErrors(n int) ValidationOption {
+	return func(o *ValidationOptions) { o.MaxErrors = n }
+}
+
+// WithMinSeverity drops findings less severe than severity
+func WithMinSeverity(severity Severity) ValidationOption {
+	return func(o *ValidationOptions) { o.MinSeverity = severity }
+}
+
+// WithMaxDepth limits the region nesting depth validated
+func WithMaxDepth(depth int) ValidationOption {
+	return func(o *ValidationOptions) { o.MaxDepth = depth }
+}
+
+// WithWorkers sets the number of machines validated concurrently
+func WithWorkers(n int) ValidationOption {
+	return func(o *ValidationOptions) { o.Workers = n }
+}
+
+// WithFailFast stops bulk validation after the first invalid machine
+func WithFailFast() ValidationOption {
+	return func(o *ValidationOptions) { o.FailFast = true }
+}
+
+// ValidateWithOptions validates the state machine with the given options.
+// It returns the context's error without validating if the context is
+// already done. Validate is ValidateWithOptions without options.
+func (sm *StateMachine) ValidateWithOptions(opts ...ValidationOption) error {
+	options := NewValidationOptions(opts...)
+	if err := options.context().Err(); err != nil {
+		return err
+	}
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(options.validationContext().WithStateMachine(sm), errors)
+	options.Baseline.Filter(sm.ID, errors)
+	options.filter(errors)
+	return errors.ToError()
+}
+
+// ValidateAllWithOptions is ValidateAll configured with options; MaxErrors
+// and MinSeverity apply to each machine's errors
+func ValidateAllWithOptions(machines []*StateMachine, opts ...ValidationOption) (*ValidationResultAggregator, error) {
+	options := NewValidationOptions(opts...)
+	aggregator, err := ValidateAll(options.context(), machines, BulkValidationOptions{
+		Workers:     options.Workers,
+		FailFast:    options.FailFast,
+		Profile:     options.profile(),
+		Metadata:    options.Metadata,
+		RuleProfile: options.RuleProfile,
+		Baseline:    options.Baseline,
+		VertexStore: options.VertexStore,
+	})
+	for _, errors := range aggregator.GetResults() {
+		options.filter(errors)
+	}
+	return aggregator, err
+}
+
+// context returns the options' context, or context.Background()
+func (o ValidationOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// validationContext returns a new validation context with the options'
+// profile and metadata
+func (o ValidationOptions) validationContext() *ValidationContext {
+	context := NewValidationContext().WithProfile(o.profile()).WithRuleProfile(o.RuleProfile).WithVertexStore(o.VertexStore)
+	for key, value := range o.Metadata {
+		context.SetMetadata(key, value)
+	}
+	return context
+}
+
+// profile returns the profile validated with: the options' profile, or
+// DefaultProfile, with MaxDepth applied to a copy
+func (o ValidationOptions) profile() *ValidationProfile {
+	profile := o.Profile
+	if o.MaxDepth <= 0 {
+		return profile
+	}
+	if profile == nil {
+		profile = DefaultProfile
+	}
+	copied := *profile
+	limits := ResourceLimits{}
+	if profile.Limits != nil {
+		limits = *profile.Limits
+	}
+	limits.MaxDepth = o.MaxDepth
+	copied.Limits = &limits
+	return &copied
+}
+
+// filter drops the findings the options leave out of reports
+func (o ValidationOptions) filter(errors *ValidationErrors) {
+	if errors == nil {
+		return
+	}
+	switch o.MinSeverity {
+	case SeverityError:
+		errors.Warnings, errors.Infos = nil, nil
+	case SeverityWarning:
+		errors.Infos = nil
+	}
+	if o.MaxErrors > 0 && len(errors.Errors) > o.MaxErrors {
+		errors.Errors = slices.Clone(errors.Errors)
+		slices.SortStableFunc(errors.Errors, CompareValidationErrors)
+		errors.Errors = errors.Errors[:o.MaxErrors]
+	}
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// optionsMachine returns a machine with several errors, a warning and
+// composite states nested three regions deep
+func optionsMachine() *StateMachine {
+	sm := GenerateStateMachine(5, GeneratorOptions{MaxDepth: 3})
+	sm.Name = ""
+	sm.Version = ""
+	return sm
+}
+
+func TestValidateWithOptions(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		sm    func() *StateMachine
+		opts  []ValidationOption
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "no options validates like Validate",
+			sm:   optionsMachine,
+			check: func(t *testing.T, err error) {
+				if want := optionsMachine().Validate(); fmt.Sprint(err) != fmt.Sprint(want) {
+					t.Errorf("error = %v, want %v", err, want)
+				}
+			},
+		},
+		{
+			name: "profile",
+			sm:   optionsMachine,
+			opts: []ValidationOption{WithProfile(StrictProfile)},
+			check: func(t *testing.T, err error) {
+				want := optionsMachine().ValidateInContext(NewValidationContext().WithProfile(StrictProfile))
+				if fmt.Sprint(err) != fmt.Sprint(want) || fmt.Sprint(err) == fmt.Sprint(optionsMachine().Validate()) {
+					t.Errorf("error = %v, want %v", err, want)
+				}
+			},
+		},
+		{
+			name: "max errors keeps the first errors in report order",
+			sm:   optionsMachine,
+			opts: []ValidationOption{WithMaxErrors(1)},
+			check: func(t *testing.T, err error) {
+				var validationErrors *ValidationErrors
+				if !errors.As(err, &validationErrors) || validationErrors.Count() != 1 {
+					t.Fatalf("error = %v, want exactly one error", err)
+				}
+				all := &ValidationErrors{}
+				optionsMachine().ValidateWithErrors(NewValidationContext(), all)
+				all.Sort()
+				if got, want := validationErrors.Errors[0].Error(), all.Errors[0].Error(); got != want {
+					t.Errorf("kept %q, want %q", got, want)
+				}
+			},
+		},
+		{
+			name: "max depth",
+			sm:   optionsMachine,
+			opts: []ValidationOption{WithMaxDepth(1)},
+			check: func(t *testing.T, err error) {
+				var validationErrors *ValidationErrors
+				if !errors.As(err, &validationErrors) || len(validationErrors.GetErrorsByType(ErrorTypeResourceLimit)) != 1 {
+					t.Errorf("error = %v, want a resource limit error", err)
+				}
+			},
+		},
+		{
+			name: "max depth within the limit",
+			sm:   createValidStateMachine,
+			opts: []ValidationOption{WithMaxDepth(5)},
+			check: func(t *testing.T, err error) {
+				if err != nil {
+					t.Errorf("error = %v, want nil", err)
+				}
+			},
+		},
+		{
+			name: "cancelled context",
+			sm:   optionsMachine,
+			opts: []ValidationOption{WithContext(cancelled)},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("error = %v, want context.Canceled", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, tt.sm().ValidateWithOptions(tt.opts...))
+		})
+	}
+
+	if DefaultProfile.Limits != nil {
+		t.Errorf("WithMaxDepth modified DefaultProfile: %+v", DefaultProfile.Limits)
+	}
+}
+
+func TestValidationOptions_Filter(t *testing.T) {
+	findings := func() *ValidationErrors {
+		errs := &ValidationErrors{}
+		errs.AddError(ErrorTypeRequired, "State", "Name", "b", []string{"b"})
+		errs.AddError(ErrorTypeRequired, "State", "Name", "a", []string{"a"})
+		errs.AddWarning(ErrorTypeConstraint, "State", "Name", "w", nil)
+		errs.AddInfo(ErrorTypeConstraint, "State", "Name", "i", nil)
+		return errs
+	}
+
+	tests := []struct {
+		name                    string
+		opts                    []ValidationOption
+		errors, warnings, infos int
+		firstError              string
+	}{
+		{name: "defaults keep everything", errors: 2, warnings: 1, infos: 1, firstError: "b"},
+		{name: "min severity warning", opts: []ValidationOption{WithMinSeverity(SeverityWarning)}, errors: 2, warnings: 1, firstError: "b"},
+		{name: "min severity error", opts: []ValidationOption{WithMinSeverity(SeverityError)}, errors: 2, firstError: "b"},
+		{name: "max errors", opts: []ValidationOption{WithMaxErrors(1)}, errors: 1, warnings: 1, infos: 1, firstError: "a"},
+		{name: "max errors above the count", opts: []ValidationOption{WithMaxErrors(5)}, errors: 2, warnings: 1, infos: 1, firstError: "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := findings()
+			NewValidationOptions(tt.opts...).filter(errs)
+			if len(errs.Errors) != tt.errors || len(errs.Warnings) != tt.warnings || len(errs.Infos) != tt.infos {
+				t.Fatalf("errors/warnings/infos = %d/%d/%d, want %d/%d/%d",
+					len(errs.Errors), len(errs.Warnings), len(errs.Infos), tt.errors, tt.warnings, tt.infos)
+			}
+			if errs.Errors[0].Message != tt.firstError {
+				t.Errorf("first error = %q, want %q", errs.Errors[0].Message, tt.firstError)
+			}
+		})
+	}
+}
+
+func TestValidateAllWithOptions(t *testing.T) {
+	machines := []*StateMachine{optionsMachine(), createValidStateMachine(), nil}
+
+	aggregator, err := ValidateAllWithOptions(machines, WithWorkers(2), WithMaxErrors(1), WithMinSeverity(SeverityError))
+	if err != nil {
+		t.Fatalf("ValidateAllWithOptions() error = %v", err)
+	}
+	results := aggregator.GetResults()
+	if len(results) != 2 {
+		t.Fatalf("results = %v, want the generated machine and the nil machine", results)
+	}
+	for key, errs := range results {
+		if len(errs.Errors) != 1 || len(errs.Warnings) != 0 {
+			t.Errorf("%s errors/warnings = %d/%d, want 1/0", key, len(errs.Errors), len(errs.Warnings))
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ValidateAllWithOptions(machines, WithContext(cancelled)); !errors.Is(err, context.Canceled) {
+		t.Errorf("ValidateAllWithOptions(cancelled) error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Reports and JSON encodings of validation results list errors in a
+// canonical order that does not depend on the order in which rules ran or
+// on map iteration, so that their output can be compared across runs:
+//
+//  1. by error type, in the order of the ValidationErrorType constants
+//  2. by path, segment by segment, with numbers inside segments compared
+//     numerically so that Regions[2] sorts before Regions[10], and a path
+//     before the paths below it
+//  3. by code, the Object and Field of the error
+//  4. by message
+//
+// Errors equal in all of these keep the order in which they were added.
+// Context entries are listed by key, and results of several objects by
+// object ID.
+
+// CompareValidationErrors compares two validation errors in the canonical
+// report order, returning a negative number when a sorts before b, a
+// positive number when it sorts after b and zero otherwise. Nil errors sort
+// last.
+func CompareValidationErrors(a, b *ValidationError) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	if a.Type != b.Type {
+		return int(a.Type) - int(b.Type)
+	}
+	if c := comparePaths(a.Path, b.Path); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Object, b.Object); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Field, b.Field); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Message, b.Message)
+}
+
+// Sort puts the errors, warnings and infos into the canonical report order
+func (ve *ValidationErrors) Sort() {
+	slices.SortStableFunc(ve.Errors, CompareValidationErrors)
+	slices.SortStableFunc(ve.Warnings, CompareValidationErrors)
+	slices.SortStableFunc(ve.Infos, CompareValidationErrors)
+}
+
+// MarshalJSON encodes the errors, warnings and infos in the canonical report
+// order without reordering the collection itself
+func (ve ValidationErrors) MarshalJSON() ([]byte, error) {
+	type plain ValidationErrors
+	return json.Marshal(plain{
+		Errors:     sortedValidationErrors(ve.Errors),
+		Warnings:   sortedValidationErrors(ve.Warnings),
+		Infos:      sortedValidationErrors(ve.Infos),
+		Skipped:    ve.Skipped,
+		Suppressed: ve.Suppressed,
+	})
+}
+
+// sortedValidationErrors returns a copy of errors in the canonical report
+// order
+func sortedValidationErrors(errors []*ValidationError) []*ValidationError {
+	if errors == nil {
+		return nil
+	}
+	sorted := slices.Clone(errors)
+	slices.SortStableFunc(sorted, CompareValidationErrors)
+	return sorted
+}
+
+// groupByType groups errors in the canonical report order by error type and
+// returns the types present in ascending order
+func groupByType(errors []*ValidationError) ([]ValidationErrorType, map[ValidationErrorType][]*ValidationError) {
+	groups := make(map[ValidationErrorType][]*ValidationError)
+	for _, err := range sortedValidationErrors(errors) {
+		if err != nil {
+			groups[err.Type] = append(groups[err.Type], err)
+		}
+	}
+	return slices.Sorted(maps.Keys(groups)), groups
+}
+
+// formatErrorContext formats context entries as "key=value " pairs in key
+// order
+func formatErrorContext(context map[string]interface{}) string {
+	var formatted strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(context)) {
+		formatted.WriteString(fmt.Sprintf("%s=%v ", key, context[key]))
+	}
+	return formatted.String()
+}
+
+// comparePaths compares paths segment by segment; a path sorts before the
+// paths it is a prefix of
+func comparePaths(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareNatural(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// compareNatural compares strings with runs of digits compared by numeric
+// value, so that "States[9]" sorts before "States[10]"
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		digitsA, digitsB := leadingDigits(a), leadingDigits(b)
+		if digitsA > 0 && digitsB > 0 {
+			numberA := strings.TrimLeft(a[:digitsA], "0")
+			numberB := strings.TrimLeft(b[:digitsB], "0")
+			if len(numberA) != len(numberB) {
+				return len(numberA) - len(numberB)
+			}
+			if c := strings.Compare(numberA, numberB); c != 0 {
+				return c
+			}
+			a, b = a[digitsA:], b[digitsB:]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+// leadingDigits returns the number of ASCII digits at the start of s
+func leadingDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
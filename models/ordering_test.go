@@ -0,0 +1,238 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCompareValidationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *ValidationError
+		want int // sign of the comparison
+	}{
+		{
+			name: "type before path",
+			a:    &ValidationError{Type: ErrorTypeRequired, Path: []string{"z"}},
+			b:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"a"}},
+			want: -1,
+		},
+		{
+			name: "numeric path indices",
+			a:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"Regions[2]"}},
+			b:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"Regions[10]"}},
+			want: -1,
+		},
+		{
+			name: "parent path before child path",
+			a:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"Regions[0]", "States[1]"}},
+			b:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"Regions[0]"}},
+			want: 1,
+		},
+		{
+			name: "object and field after path",
+			a:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"x"}, Object: "State", Field: "Name"},
+			b:    &ValidationError{Type: ErrorTypeInvalid, Path: []string{"x"}, Object: "State", Field: "ID"},
+			want: 1,
+		},
+		{
+			name: "message last",
+			a:    &ValidationError{Type: ErrorTypeInvalid, Object: "State", Message: "a"},
+			b:    &ValidationError{Type: ErrorTypeInvalid, Object: "State", Message: "b"},
+			want: -1,
+		},
+		{
+			name: "equal",
+			a:    &ValidationError{Type: ErrorTypeInvalid, Object: "State", Message: "a"},
+			b:    &ValidationError{Type: ErrorTypeInvalid, Object: "State", Message: "a"},
+			want: 0,
+		},
+		{
+			name: "nil last",
+			a:    nil,
+			b:    &ValidationError{Type: ErrorTypeResourceLimit},
+			want: 1,
+		},
+	}
+
+	sign := func(n int) int {
+		switch {
+		case n < 0:
+			return -1
+		case n > 0:
+			return 1
+		}
+		return 0
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sign(CompareValidationErrors(tt.a, tt.b)); got != tt.want {
+				t.Errorf("CompareValidationErrors() = %d, want %d", got, tt.want)
+			}
+			if got := sign(CompareValidationErrors(tt.b, tt.a)); got != -tt.want {
+				t.Errorf("CompareValidationErrors() reversed = %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
+
+// shuffledValidationErrors returns the same errors, warnings and infos added
+// in an order that depends on seed
+func shuffledValidationErrors(seed int64) *ValidationErrors {
+	var findings []*ValidationError
+	for i := 0; i < 12; i++ {
+		findings = append(findings, &ValidationError{
+			Type:    ValidationErrorType(i % 4),
+			Object:  "State",
+			Field:   "Name",
+			Message: fmt.Sprintf("problem %d", i),
+			Path:    []string{"Regions[0]", fmt.Sprintf("States[%d]", i)},
+			Context: map[string]interface{}{"b": i, "a": "x", "c": true},
+		})
+	}
+	findings = append(findings,
+		&ValidationError{Type: ErrorTypeInvalid, Object: "Transition", Field: "Kind", Message: "warning", Severity: SeverityWarning},
+		&ValidationError{Type: ErrorTypeConstraint, Object: "State", Field: "Deprecated", Message: "info", Severity: SeverityInfo},
+		&ValidationError{Type: ErrorTypeRequired, Object: "Region", Field: "ID", Message: "warning", Severity: SeverityWarning},
+	)
+	rand.New(rand.NewSource(seed)).Shuffle(len(findings), func(i, j int) {
+		findings[i], findings[j] = findings[j], findings[i]
+	})
+
+	errors := &ValidationErrors{}
+	for _, finding := range findings {
+		errors.Add(finding)
+	}
+	return errors
+}
+
+func TestValidationErrorsDeterministicReports(t *testing.T) {
+	reports := []struct {
+		name   string
+		render func(errors *ValidationErrors) string
+	}{
+		{"Error", func(errors *ValidationErrors) string { return errors.Error() }},
+		{"GetDetailedReport", func(errors *ValidationErrors) string { return errors.GetDetailedReport() }},
+		{"GetClauseReport", func(errors *ValidationErrors) string { return errors.GetClauseReport() }},
+		{"MarshalJSON", func(errors *ValidationErrors) string {
+			data, err := json.Marshal(errors)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			return string(data)
+		}},
+		{"aggregator GetSummaryReport", func(errors *ValidationErrors) string {
+			aggregator := NewValidationResultAggregator()
+			aggregator.AddResult("sm1", errors)
+			return aggregator.GetSummaryReport()
+		}},
+		{"aggregator GetDetailedReport", func(errors *ValidationErrors) string {
+			aggregator := NewValidationResultAggregator()
+			aggregator.AddResult("sm2", shuffledValidationErrors(7))
+			aggregator.AddResult("sm1", errors)
+			report := aggregator.GetDetailedReport()
+			// Drop the generation time
+			lines := strings.Split(report, "\n")
+			return strings.Join(append(lines[:1:1], lines[2:]...), "\n")
+		}},
+	}
+
+	for _, tt := range reports {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.render(shuffledValidationErrors(1))
+			for seed := int64(2); seed < 20; seed++ {
+				if got := tt.render(shuffledValidationErrors(seed)); got != want {
+					t.Fatalf("report differs for seed %d:\n%s\nwant:\n%s", seed, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorsReportOrder(t *testing.T) {
+	errors := shuffledValidationErrors(3)
+	report := errors.GetDetailedReport()
+
+	// Types in constant order, paths in numeric order
+	for _, pair := range [][2]string{
+		{"Required Errors", "Invalid Errors"},
+		{"Invalid Errors", "Constraint Errors"},
+		{"Constraint Errors", "Reference Errors"},
+		{"States[0]", "States[4]"},
+		{"States[4]", "States[8]"},
+		{"States[1]", "States[5]"},
+		{"States[5]", "States[9]"},
+		{"Region.ID: warning", "Transition.Kind: warning"},
+	} {
+		first, second := strings.Index(report, pair[0]), strings.Index(report, pair[1])
+		if first < 0 || second < 0 || first > second {
+			t.Errorf("expected %q before %q in report:\n%s", pair[0], pair[1], report)
+		}
+	}
+	if !strings.Contains(report, "Context: a=x b=0 c=true") {
+		t.Errorf("expected context entries in key order, got:\n%s", report)
+	}
+
+	// Encoding does not reorder the collection
+	before := errors.Errors[0]
+	if _, err := json.Marshal(errors); err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if errors.Errors[0] != before {
+		t.Error("MarshalJSON should not reorder the errors")
+	}
+
+	errors.Sort()
+	for i := 1; i < len(errors.Errors); i++ {
+		if CompareValidationErrors(errors.Errors[i-1], errors.Errors[i]) > 0 {
+			t.Fatalf("Sort() left %v before %v", errors.Errors[i-1], errors.Errors[i])
+		}
+	}
+	if errors.Warnings[0].Object != "Region" {
+		t.Errorf("Sort() should order warnings, first warning = %v", errors.Warnings[0])
+	}
+}
+
+func TestValidationErrorsJSONRoundTrip(t *testing.T) {
+	errors := shuffledValidationErrors(5)
+	data, err := json.Marshal(errors)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded ValidationErrors
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(decoded.Errors) != len(errors.Errors) || len(decoded.Warnings) != 2 || len(decoded.Infos) != 1 {
+		t.Fatalf("decoded %d errors, %d warnings, %d infos", len(decoded.Errors), len(decoded.Warnings), len(decoded.Infos))
+	}
+	if decoded.Errors[0].Type != ErrorTypeRequired || decoded.Errors[0].Path[1] != "States[0]" {
+		t.Errorf("first decoded error = %v, want the first in report order", decoded.Errors[0])
+	}
+}
+
+func TestValidationDebugReportSummaryOrder(t *testing.T) {
+	report := &ValidationDebugReport{
+		StateMachineID: "sm1",
+		TotalErrors:    2,
+		Objects: map[string]*ObjectDebugInfo{
+			"a": {Type: "State"}, "b": {Type: "Region"}, "c": {Type: "Transition"},
+		},
+		ValidationResults: map[string]*ValidationErrors{
+			"zeta":  {Errors: []*ValidationError{{}}},
+			"alpha": {Errors: []*ValidationError{{}}},
+		},
+	}
+	want := report.GetSummary()
+	for i := 0; i < 20; i++ {
+		if got := report.GetSummary(); got != want {
+			t.Fatalf("summary differs between calls:\n%s\nwant:\n%s", got, want)
+		}
+	}
+	if strings.Index(want, "  Region:") > strings.Index(want, "  State:") || strings.Index(want, "alpha") > strings.Index(want, "zeta") {
+		t.Errorf("expected sorted summary, got:\n%s", want)
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// regionStep is one level of a vertex's containment chain: a region and the
+// state that owns it, or nil for the state machine's top-level regions
+type regionStep struct {
+	owner  *State
+	region *Region
+}
+
+// vertexRegionChains maps each declared vertex ID to the chain of regions
+// that contain it, outermost first
+func vertexRegionChains(sm *StateMachine) map[string][]regionStep {
+	chains := make(map[string][]regionStep)
+
+	var walk func(regions []*Region, owner *State, chain []regionStep)
+	walk = func(regions []*Region, owner *State, chain []regionStep) {
+		for _, region := range regions {
+			if region == nil {
+				continue
+			}
+			regionChain := append(chain[:len(chain):len(chain)], regionStep{owner: owner, region: region})
+			for _, vertex := range region.Vertices {
+				if vertex != nil {
+					chains[vertex.ID] = regionChain
+				}
+			}
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				chains[state.ID] = regionChain
+				walk(state.Regions, state, regionChain)
+			}
+		}
+	}
+	walk(sm.Regions, nil, nil)
+
+	return chains
+}
+
+// validateOrthogonalIsolation flags transitions whose source and target lie
+// in different regions of the same orthogonal state, or in different
+// top-level regions of the state machine. Such transitions must instead go
+// through a fork, a join or the boundary of the composite state.
+func (sm *StateMachine) validateOrthogonalIsolation(context *ValidationContext, errors *ValidationErrors) {
+	chains := vertexRegionChains(sm)
+
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, transition := range region.Transitions {
+			if transition == nil || transition.Source == nil || transition.Target == nil {
+				continue
+			}
+			sourceChain, sourceOK := chains[transition.Source.ID]
+			targetChain, targetOK := chains[transition.Target.ID]
+			if !sourceOK || !targetOK {
+				continue
+			}
+
+			for level := 0; level < len(sourceChain) && level < len(targetChain); level++ {
+				source, target := sourceChain[level], targetChain[level]
+				if source.region == target.region {
+					continue
+				}
+				if source.owner == target.owner {
+					owner := fmt.Sprintf("state machine '%s'", sm.ID)
+					if source.owner != nil {
+						owner = fmt.Sprintf("state '%s'", source.owner.ID)
+					}
+					errors.AddErrorWithClause(
+						ErrorTypeConstraint,
+						"Transition",
+						"Target",
+						fmt.Sprintf("transition '%s' connects vertices in sibling orthogonal regions '%s' and '%s' of %s; use a fork, a join or the composite state boundary instead (UML constraint)",
+							transition.ID, source.region.ID, target.region.ID, owner),
+						append(append([]string{}, context.Path...), strings.Split(fmt.Sprintf("%s.Transitions[%d]", path, i), ".")...),
+						ClauseTransition,
+					)
+				}
+				break
+			}
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func createParallelStateMachine() *StateMachine {
+	state := func(id string) *State {
+		return &State{Vertex: Vertex{ID: id, Name: strings.ToUpper(id[:1]) + id[1:], Type: "state"}, IsSimple: true}
+	}
+	a1, a2, b1, b2, done := state("a1"), state("a2"), state("b1"), state("b2"), state("done")
+	fork := &Vertex{ID: "fork", Name: "Fork", Type: "pseudostate"}
+
+	regionA := &Region{ID: "rA", Name: "Region A", States: []*State{a1, a2}, Transitions: []*Transition{
+		{ID: "ta", Source: &a1.Vertex, Target: &a2.Vertex, Kind: TransitionKindExternal},
+	}}
+	regionB := &Region{ID: "rB", Name: "Region B", States: []*State{b1, b2}, Transitions: []*Transition{
+		{ID: "tb", Source: &b1.Vertex, Target: &b2.Vertex, Kind: TransitionKindExternal},
+	}}
+	parallel := &State{
+		Vertex:       Vertex{ID: "parallel", Name: "Parallel", Type: "state"},
+		IsComposite:  true,
+		IsOrthogonal: true,
+		Regions:      []*Region{regionA, regionB},
+	}
+
+	return &StateMachine{
+		ID:      "sm",
+		Name:    "Orthogonal",
+		Version: "1.0",
+		Regions: []*Region{{
+			ID:       "main",
+			Name:     "Main",
+			States:   []*State{parallel, done},
+			Vertices: []*Vertex{fork},
+			Transitions: []*Transition{
+				{ID: "tf1", Source: fork, Target: &a1.Vertex, Kind: TransitionKindExternal},
+				{ID: "tf2", Source: fork, Target: &b1.Vertex, Kind: TransitionKindExternal},
+				{ID: "tdone", Source: &parallel.Vertex, Target: &done.Vertex, Kind: TransitionKindExternal},
+			},
+		}},
+	}
+}
+
+func TestValidateOrthogonalIsolation(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(sm *StateMachine)
+		wantErrs []string
+	}{
+		{
+			name: "fork into both regions and exits through the boundary are allowed",
+			modify: func(sm *StateMachine) {
+				regionA := sm.Regions[0].States[0].Regions[0]
+				regionA.Transitions = append(regionA.Transitions, &Transition{
+					ID: "texit", Source: &regionA.States[1].Vertex, Target: &sm.Regions[0].States[1].Vertex, Kind: TransitionKindExternal,
+				})
+			},
+		},
+		{
+			name: "transition into a sibling orthogonal region",
+			modify: func(sm *StateMachine) {
+				regionA := sm.Regions[0].States[0].Regions[0]
+				regionB := sm.Regions[0].States[0].Regions[1]
+				regionA.Transitions = append(regionA.Transitions, &Transition{
+					ID: "tcross", Source: &regionA.States[1].Vertex, Target: &regionB.States[1].Vertex, Kind: TransitionKindExternal,
+				})
+			},
+			wantErrs: []string{"transition 'tcross' connects vertices in sibling orthogonal regions 'rA' and 'rB' of state 'parallel'"},
+		},
+		{
+			name: "transition between top-level regions of the state machine",
+			modify: func(sm *StateMachine) {
+				other := &State{Vertex: Vertex{ID: "other", Name: "Other", Type: "state"}, IsSimple: true}
+				sm.Regions = append(sm.Regions, &Region{ID: "second", Name: "Second", States: []*State{other}})
+				sm.Regions[0].Transitions = append(sm.Regions[0].Transitions, &Transition{
+					ID: "ttop", Source: &sm.Regions[0].States[1].Vertex, Target: &other.Vertex, Kind: TransitionKindExternal,
+				})
+			},
+			wantErrs: []string{"transition 'ttop' connects vertices in sibling orthogonal regions 'main' and 'second' of state machine 'sm'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createParallelStateMachine()
+			tt.modify(sm)
+
+			errors := &ValidationErrors{}
+			sm.validateOrthogonalIsolation(NewValidationContext(), errors)
+
+			if len(errors.Errors) != len(tt.wantErrs) {
+				t.Fatalf("validateOrthogonalIsolation() errors = %v, want %d", errors.Errors, len(tt.wantErrs))
+			}
+			for i, want := range tt.wantErrs {
+				if !strings.Contains(errors.Errors[i].Message, want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errors.Errors[i].Message, want)
+				}
+				if errors.Errors[i].Clause != ClauseTransition {
+					t.Errorf("error %d clause = %q, want %q", i, errors.Errors[i].Clause, ClauseTransition)
+				}
+			}
+		})
+	}
+}
+
+func TestStateMachineValidate_OrthogonalIsolation(t *testing.T) {
+	sm := createParallelStateMachine()
+	regionA := sm.Regions[0].States[0].Regions[0]
+	regionB := sm.Regions[0].States[0].Regions[1]
+	regionA.Transitions = append(regionA.Transitions, &Transition{
+		ID: "tcross", Source: &regionA.States[0].Vertex, Target: &regionB.States[0].Vertex, Kind: TransitionKindExternal,
+	})
+
+	err := sm.Validate()
+	if err == nil || !strings.Contains(err.Error(), "sibling orthogonal regions") {
+		t.Errorf("Validate() error = %v, want a sibling orthogonal region violation", err)
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Outline returns a hierarchical Markdown outline of the state machine.
+// The outline lists regions, states (with composite nesting), pseudostates,
+// final states and transition counts, and is intended for quick reviews
+// where a full diagram export would be too heavy.
+func Outline(sm *StateMachine) string {
+	if sm == nil {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("# %s (id: %s, version: %s)\n", displayName(sm.Name, sm.ID), sm.ID, sm.Version))
+
+	if len(sm.ConnectionPoints) > 0 {
+		out.WriteString("\n")
+		for _, cp := range sm.ConnectionPoints {
+			if cp == nil {
+				continue
+			}
+			out.WriteString(fmt.Sprintf("- Connection point: %s (%s) [%s]\n", displayName(cp.Name, cp.ID), cp.ID, cp.Kind))
+		}
+	}
+
+	out.WriteString("\n")
+	for _, region := range sm.Regions {
+		writeRegionOutline(&out, region, 0)
+	}
+
+	return out.String()
+}
+
+// writeRegionOutline writes a region and its contents at the given indentation level
+func writeRegionOutline(out *strings.Builder, region *Region, level int) {
+	if region == nil {
+		return
+	}
+
+	indent := strings.Repeat("  ", level)
+	out.WriteString(fmt.Sprintf("%s- Region: %s (%s) — %d transition(s)\n", indent, displayName(region.Name, region.ID), region.ID, len(region.Transitions)))
+
+	outgoing := make(map[string]int)
+	incoming := make(map[string]int)
+	for _, transition := range region.Transitions {
+		if transition == nil {
+			continue
+		}
+		if transition.Source != nil {
+			outgoing[transition.Source.ID]++
+		}
+		if transition.Target != nil {
+			incoming[transition.Target.ID]++
+		}
+	}
+
+	childIndent := strings.Repeat("  ", level+1)
+	listed := make(map[string]bool)
+
+	for _, state := range region.States {
+		if state == nil {
+			continue
+		}
+		listed[state.ID] = true
+		out.WriteString(fmt.Sprintf("%s- State: %s (%s) [%s] — in: %d, out: %d\n",
+			childIndent, displayName(state.Name, state.ID), state.ID, state.kindDescription(), incoming[state.ID], outgoing[state.ID]))
+
+		if state.Submachine != nil {
+			out.WriteString(fmt.Sprintf("%s  - Submachine: %s (%s)\n", childIndent, displayName(state.Submachine.Name, state.Submachine.ID), state.Submachine.ID))
+		}
+		for _, subRegion := range state.Regions {
+			writeRegionOutline(out, subRegion, level+2)
+		}
+	}
+
+	for _, vertex := range region.Vertices {
+		if vertex == nil || listed[vertex.ID] {
+			continue
+		}
+		listed[vertex.ID] = true
+		out.WriteString(fmt.Sprintf("%s- %s: %s (%s) — in: %d, out: %d\n",
+			childIndent, outlineVertexLabel(vertex.Type), displayName(vertex.Name, vertex.ID), vertex.ID, incoming[vertex.ID], outgoing[vertex.ID]))
+	}
+}
+
+// outlineVertexLabel returns the outline label used for a vertex type
+func outlineVertexLabel(vertexType string) string {
+	switch vertexType {
+	case "pseudostate":
+		return "Pseudostate"
+	case "finalstate":
+		return "Final state"
+	case "state":
+		return "State"
+	default:
+		return "Vertex"
+	}
+}
+
+// displayName returns the name if present, otherwise the ID
+func displayName(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutline(t *testing.T) {
+	sm := &StateMachine{
+		ID:      "order",
+		Name:    "Order Lifecycle",
+		Version: "1.0",
+		ConnectionPoints: []*Pseudostate{
+			{Vertex: Vertex{ID: "entry1", Name: "EntryPoint", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint},
+		},
+		Regions: []*Region{
+			{
+				ID:   "main",
+				Name: "Main",
+				States: []*State{
+					{Vertex: Vertex{ID: "new", Name: "New", Type: "state"}, IsSimple: true},
+					{
+						Vertex:      Vertex{ID: "active", Name: "Active", Type: "state"},
+						IsComposite: true,
+						Regions: []*Region{
+							{
+								ID:     "active_region",
+								Name:   "Active Region",
+								States: []*State{{Vertex: Vertex{ID: "paying", Name: "Paying", Type: "state"}}},
+							},
+						},
+					},
+				},
+				Vertices: []*Vertex{
+					{ID: "initial", Name: "Initial", Type: "pseudostate"},
+					{ID: "new", Name: "New", Type: "state"},
+					{ID: "done", Name: "Done", Type: "finalstate"},
+				},
+				Transitions: []*Transition{
+					{ID: "t1", Source: &Vertex{ID: "initial"}, Target: &Vertex{ID: "new"}, Kind: TransitionKindExternal},
+					{ID: "t2", Source: &Vertex{ID: "new"}, Target: &Vertex{ID: "active"}, Kind: TransitionKindExternal},
+					{ID: "t3", Source: &Vertex{ID: "active"}, Target: &Vertex{ID: "done"}, Kind: TransitionKindExternal},
+				},
+			},
+		},
+	}
+
+	outline := Outline(sm)
+
+	expectedLines := []string{
+		"# Order Lifecycle (id: order, version: 1.0)",
+		"- Connection point: EntryPoint (entry1) [entryPoint]",
+		"- Region: Main (main) — 3 transition(s)",
+		"  - State: New (new) [simple] — in: 1, out: 1",
+		"  - State: Active (active) [composite] — in: 1, out: 1",
+		"    - Region: Active Region (active_region) — 0 transition(s)",
+		"      - State: Paying (paying) [simple] — in: 0, out: 0",
+		"  - Pseudostate: Initial (initial) — in: 0, out: 1",
+		"  - Final state: Done (done) — in: 1, out: 0",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(outline, line+"\n") {
+			t.Errorf("Outline() missing line %q\ngot:\n%s", line, outline)
+		}
+	}
+
+	if strings.Count(outline, "(new)") != 1 {
+		t.Errorf("Outline() should list a vertex present in both States and Vertices once\ngot:\n%s", outline)
+	}
+
+	if got := Outline(nil); got != "" {
+		t.Errorf("Outline(nil) = %q, want empty string", got)
+	}
+}
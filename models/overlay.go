@@ -0,0 +1,315 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Overlay layers proposed changes over a base state machine without copying
+// it, for what-if analysis in planning tools. The overlay's view shares
+// every element with the base until an edit touches it; editing an element
+// copies it and the regions and composite states containing it, and
+// repoints the transitions that reference a copied vertex. The base is never
+// modified before Commit.
+//
+// The view can be queried and validated like any state machine. Edits go
+// through the Edit methods; the view returned by StateMachine must not be
+// modified directly, and the base must not be modified while the overlay is
+// in use. Elements of submachines are shared and cannot be edited through
+// the overlay. An edit function that returns an error may already have
+// changed the view; Discard drops all changes.
+type Overlay struct {
+	base  *StateMachine
+	view  *StateMachine
+	owned map[any]bool // Elements copied into the view, safe to modify
+
+	machineEdited bool // The view's machine-level slices and maps are copies
+}
+
+// NewOverlay creates an overlay without changes over base
+func NewOverlay(base *StateMachine) *Overlay {
+	o := &Overlay{base: base}
+	o.Discard()
+	return o
+}
+
+// Base returns the state machine under the overlay
+func (o *Overlay) Base() *StateMachine {
+	return o.base
+}
+
+// StateMachine returns the view of the base with the overlay's changes
+// applied, for queries and validation. The view shares unchanged elements
+// with the base and must not be modified.
+func (o *Overlay) StateMachine() *StateMachine {
+	return o.view
+}
+
+// Changed reports whether any element has been edited through the overlay
+func (o *Overlay) Changed() bool {
+	return o.machineEdited || len(o.owned) > 1
+}
+
+// Changes returns the differences between the base and the view
+func (o *Overlay) Changes() []Change {
+	return Diff(o.base, o.view)
+}
+
+// Validate validates the view
+func (o *Overlay) Validate() error {
+	return o.view.Validate()
+}
+
+// ValidateInContext validates the view with the provided context
+func (o *Overlay) ValidateInContext(context *ValidationContext) error {
+	return o.view.ValidateInContext(context)
+}
+
+// Commit applies the overlay's changes to the base and starts a new overlay
+// without changes over it. Pointers to elements of the base that were
+// edited keep the old, unchanged elements. Transitions recorded on the
+// vertices (see RecordAdjacency) are recorded again; edited copies in the
+// view record none before Commit.
+func (o *Overlay) Commit() {
+	if o.base != nil && o.Changed() {
+		*o.base = *o.view
+		o.base.refreshAdjacency()
+	}
+	o.Discard()
+}
+
+// Discard drops the overlay's changes
+func (o *Overlay) Discard() {
+	o.owned = make(map[any]bool)
+	o.machineEdited = false
+	if o.base == nil {
+		o.view = &StateMachine{}
+	} else {
+		view := *o.base
+		view.Regions = slices.Clone(o.base.Regions)
+		o.view = &view
+	}
+	o.owned[o.view] = true
+}
+
+// EditMachine changes the machine-level fields of the view, such as its
+// name, version, connection points, events, entities, metadata or layout. The
+// slices and maps of the view may be modified; their elements are shared
+// with the base and must be replaced rather than modified.
+func (o *Overlay) EditMachine(edit func(sm *StateMachine) error) error {
+	if !o.machineEdited {
+		o.view.ConnectionPoints = slices.Clone(o.view.ConnectionPoints)
+		o.view.Events = slices.Clone(o.view.Events)
+		o.view.Entities = maps.Clone(o.view.Entities)
+		o.view.Metadata = maps.Clone(o.view.Metadata)
+		o.view.Layout = o.view.Layout.Clone()
+		o.machineEdited = true
+	}
+	return edit(o.view)
+}
+
+// EditRegion changes the region with the given ID. The edit may add, remove
+// or reorder the region's states, vertices and transitions; to change one of
+// them, use EditState, EditVertex or EditTransition.
+func (o *Overlay) EditRegion(id string, edit func(region *Region) error) error {
+	path, _, found := o.locate(func(region *Region) int {
+		if region.ID == id {
+			return 0
+		}
+		return -1
+	})
+	if !found {
+		return fmt.Errorf("region '%s' not found", id)
+	}
+	return edit(o.ownRegionPath(path))
+}
+
+// EditState changes the state with the given ID. Its behaviors, triggers
+// and other nested elements are copies and may be modified; its regions are
+// shared until edited with EditRegion.
+func (o *Overlay) EditState(id string, edit func(state *State) error) error {
+	path, index, found := o.locate(func(region *Region) int {
+		return slices.IndexFunc(region.States, func(state *State) bool { return state != nil && state.ID == id })
+	})
+	if !found {
+		return fmt.Errorf("state '%s' not found", id)
+	}
+	return edit(o.ownState(o.ownRegionPath(path), index))
+}
+
+// EditVertex changes the pseudostate or final state with the given ID
+func (o *Overlay) EditVertex(id string, edit func(vertex *Vertex) error) error {
+	path, index, found := o.locate(func(region *Region) int {
+		return slices.IndexFunc(region.Vertices, func(vertex *Vertex) bool { return vertex != nil && vertex.ID == id })
+	})
+	if !found {
+		return fmt.Errorf("vertex '%s' not found", id)
+	}
+	return edit(o.ownVertex(o.ownRegionPath(path), index))
+}
+
+// EditTransition changes the transition with the given ID. Its triggers,
+// guard and effect are copies and may be modified.
+func (o *Overlay) EditTransition(id string, edit func(transition *Transition) error) error {
+	path, index, found := o.locate(func(region *Region) int {
+		return slices.IndexFunc(region.Transitions, func(transition *Transition) bool { return transition != nil && transition.ID == id })
+	})
+	if !found {
+		return fmt.Errorf("transition '%s' not found", id)
+	}
+	return edit(o.ownTransition(o.ownRegionPath(path), index))
+}
+
+// locate finds the first region of the view, in walk order, for which find
+// returns an index other than -1, and returns the region's path and that
+// index
+func (o *Overlay) locate(find func(region *Region) int) (string, int, bool) {
+	var path string
+	index := -1
+	walkRegionTree(o.view.Regions, "", func(region *Region, regionPath string) {
+		if index < 0 {
+			if i := find(region); i >= 0 {
+				path, index = regionPath, i
+			}
+		}
+	})
+	return path, index, index >= 0
+}
+
+// ownRegionPath returns the owned copy of the region at a walkRegionTree
+// path such as "Regions[0].States[1].Regions[0]", copying the regions and
+// states on the way
+func (o *Overlay) ownRegionPath(path string) *Region {
+	regions := &o.view.Regions
+	var region *Region
+	for _, segment := range strings.Split(path, ".") {
+		name, index := splitIndexedSegment(segment)
+		switch name {
+		case "Regions":
+			region = o.ownRegion(regions, index)
+		case "States":
+			regions = &o.ownState(region, index).Regions
+		}
+	}
+	return region
+}
+
+// splitIndexedSegment splits a path segment such as "States[3]" into its
+// name and index
+func splitIndexedSegment(segment string) (string, int) {
+	name, rest, _ := strings.Cut(segment, "[")
+	index, _ := strconv.Atoi(strings.TrimSuffix(rest, "]"))
+	return name, index
+}
+
+// ownRegion replaces the i-th region of an owned regions slice with an owned
+// copy whose element slices may be modified
+func (o *Overlay) ownRegion(regions *[]*Region, i int) *Region {
+	region := (*regions)[i]
+	if o.owned[region] {
+		return region
+	}
+	copied := *region
+	copied.States = slices.Clone(region.States)
+	copied.Vertices = slices.Clone(region.Vertices)
+	copied.Transitions = slices.Clone(region.Transitions)
+	copied.Suppressions = slices.Clone(region.Suppressions)
+	o.owned[&copied] = true
+	(*regions)[i] = &copied
+	return &copied
+}
+
+// ownState replaces the i-th state of an owned region with an owned copy
+// and repoints the transitions targeting or leaving the state
+func (o *Overlay) ownState(region *Region, i int) *State {
+	state := region.States[i]
+	if o.owned[state] {
+		return state
+	}
+	cloner := newModelCloner()
+	copied := *state
+	copied.clearAdjacency()
+	copied.Suppressions = slices.Clone(state.Suppressions)
+	copied.Tags = slices.Clone(state.Tags)
+	copied.Regions = slices.Clone(state.Regions)
+	copied.Entry = cloner.behavior(state.Entry)
+	copied.Exit = cloner.behavior(state.Exit)
+	copied.DoActivity = cloner.behavior(state.DoActivity)
+	copied.Connections = cloneSlice(state.Connections, cloner.connectionPointReference)
+	copied.Features = slices.Clone(state.Features)
+	copied.Cost = clonePointer(state.Cost)
+	copied.DeferrableTriggers = cloneSlice(state.DeferrableTriggers, cloner.trigger)
+	o.owned[&copied] = true
+	region.States[i] = &copied
+	o.repoint(&state.Vertex, &copied.Vertex)
+	return &copied
+}
+
+// ownVertex replaces the i-th vertex of an owned region with an owned copy
+// and repoints the transitions referencing it
+func (o *Overlay) ownVertex(region *Region, i int) *Vertex {
+	vertex := region.Vertices[i]
+	if o.owned[vertex] {
+		return vertex
+	}
+	copied := *vertex
+	copied.clearAdjacency()
+	copied.Suppressions = slices.Clone(vertex.Suppressions)
+	copied.Tags = slices.Clone(vertex.Tags)
+	o.owned[&copied] = true
+	region.Vertices[i] = &copied
+	o.repoint(vertex, &copied)
+	return &copied
+}
+
+// ownTransition replaces the i-th transition of an owned region with an
+// owned copy
+func (o *Overlay) ownTransition(region *Region, i int) *Transition {
+	transition := region.Transitions[i]
+	if o.owned[transition] {
+		return transition
+	}
+	cloner := newModelCloner()
+	copied := *transition
+	copied.Triggers = cloneSlice(transition.Triggers, cloner.trigger)
+	copied.Guard = cloner.constraint(transition.Guard)
+	copied.Effect = cloner.behavior(transition.Effect)
+	copied.Features = slices.Clone(transition.Features)
+	copied.Entities = slices.Clone(transition.Entities)
+	copied.Probability = clonePointer(transition.Probability)
+	copied.Cost = clonePointer(transition.Cost)
+	copied.Suppressions = slices.Clone(transition.Suppressions)
+	copied.Tags = slices.Clone(transition.Tags)
+	o.owned[&copied] = true
+	region.Transitions[i] = &copied
+	return &copied
+}
+
+// repoint makes the transitions of the view that reference a copied vertex
+// reference its copy instead
+func (o *Overlay) repoint(old, copied *Vertex) {
+	type reference struct {
+		path  string
+		index int
+	}
+	var references []reference
+	walkRegionTree(o.view.Regions, "", func(region *Region, path string) {
+		for i, transition := range region.Transitions {
+			if transition != nil && (transition.Source == old || transition.Target == old) {
+				references = append(references, reference{path: path, index: i})
+			}
+		}
+	})
+	for _, ref := range references {
+		transition := o.ownTransition(o.ownRegionPath(ref.path), ref.index)
+		if transition.Source == old {
+			transition.Source = copied
+		}
+		if transition.Target == old {
+			transition.Target = copied
+		}
+	}
+}
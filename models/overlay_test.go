@@ -0,0 +1,286 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOverlayEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(o *Overlay) error
+		check   func(t *testing.T, base, view *StateMachine)
+		wantErr string
+	}{
+		{
+			name: "edit nested state",
+			edit: func(o *Overlay) error {
+				return o.EditState("loading", func(state *State) error {
+					state.Name = "Loading"
+					state.Entry = &Behavior{ID: "load", Specification: "load()"}
+					return nil
+				})
+			},
+			check: func(t *testing.T, base, view *StateMachine) {
+				audio := view.Regions[0].States[1].Regions[0]
+				loading := audio.States[0]
+				if loading.Name != "Loading" || loading.Entry == nil {
+					t.Errorf("view state = %v", loading)
+				}
+				// Transitions referencing the state follow the copy
+				for _, transition := range audio.Transitions {
+					if transition.Source != nil && transition.Source.ID == "loading" && transition.Source != &loading.Vertex {
+						t.Errorf("transition '%s' still references the base state", transition.ID)
+					}
+					if transition.Target != nil && transition.Target.ID == "loading" && transition.Target != &loading.Vertex {
+						t.Errorf("transition '%s' still targets the base state", transition.ID)
+					}
+				}
+				// Untouched elements are shared
+				if view.Regions[0].States[1].Regions[1] != base.Regions[0].States[1].Regions[1] {
+					t.Error("video region should be shared with the base")
+				}
+				if view.Regions[0].States[0] != base.Regions[0].States[0] {
+					t.Error("idle should be shared with the base")
+				}
+			},
+		},
+		{
+			name: "edit transition",
+			edit: func(o *Overlay) error {
+				return o.EditTransition("play", func(transition *Transition) error {
+					transition.Triggers[0].EventID = "resume"
+					transition.Guard = &Constraint{ID: "ready", Specification: "ready"}
+					return nil
+				})
+			},
+			check: func(t *testing.T, base, view *StateMachine) {
+				play := view.Regions[0].Transitions[1]
+				if play.Triggers[0].EventID != "resume" || play.Guard == nil {
+					t.Errorf("view transition = %v", play)
+				}
+				if view.Regions[0].States[1].Regions[0] != base.Regions[0].States[1].Regions[0] {
+					t.Error("audio region should be shared with the base")
+				}
+			},
+		},
+		{
+			name: "edit vertex",
+			edit: func(o *Overlay) error {
+				return o.EditVertex("video-initial", func(vertex *Vertex) error {
+					vertex.Name = "start"
+					return nil
+				})
+			},
+			check: func(t *testing.T, base, view *StateMachine) {
+				video := view.Regions[0].States[1].Regions[1]
+				if video.Vertices[0].Name != "start" || video.Transitions[0].Source != video.Vertices[0] {
+					t.Errorf("view vertex = %v, transition source = %v", video.Vertices[0], video.Transitions[0].Source)
+				}
+			},
+		},
+		{
+			name: "edit region",
+			edit: func(o *Overlay) error {
+				return o.EditRegion("main", func(region *Region) error {
+					region.States = append(region.States, execState("paused"))
+					return nil
+				})
+			},
+			check: func(t *testing.T, base, view *StateMachine) {
+				if len(view.Regions[0].States) != 3 {
+					t.Errorf("view states = %d, want 3", len(view.Regions[0].States))
+				}
+			},
+		},
+		{
+			name: "edit machine",
+			edit: func(o *Overlay) error {
+				return o.EditMachine(func(sm *StateMachine) error {
+					sm.Version = "2.0"
+					sm.Metadata["owner"] = "planning"
+					return nil
+				})
+			},
+			check: func(t *testing.T, base, view *StateMachine) {
+				if view.Version != "2.0" || view.Metadata["owner"] != "planning" {
+					t.Errorf("view = %s, metadata %v", view, view.Metadata)
+				}
+			},
+		},
+		{
+			name: "unknown state",
+			edit: func(o *Overlay) error {
+				return o.EditState("missing", func(*State) error { return nil })
+			},
+			wantErr: "state 'missing' not found",
+		},
+		{
+			name: "edit error",
+			edit: func(o *Overlay) error {
+				return o.EditTransition("stop", func(*Transition) error { return errors.New("rejected") })
+			},
+			wantErr: "rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newPlayerMachine()
+			base.Metadata = map[string]interface{}{"owner": "playback"}
+			original := base.Clone()
+			overlay := NewOverlay(base)
+
+			err := tt.edit(overlay)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("edit error = %v, want %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("edit error = %v", err)
+				}
+				tt.check(t, base, overlay.StateMachine())
+				if !overlay.Changed() || len(overlay.Changes()) == 0 {
+					t.Error("overlay should report changes")
+				}
+			}
+
+			if changes := Diff(original, base); len(changes) != 0 {
+				t.Errorf("base was modified: %v", changes)
+			}
+			if original.Metadata["owner"] != base.Metadata["owner"] {
+				t.Errorf("base metadata was modified: %v", base.Metadata)
+			}
+		})
+	}
+}
+
+func TestOverlayValidation(t *testing.T) {
+	base := createValidStateMachine()
+	overlay := NewOverlay(base)
+	if overlay.Changed() {
+		t.Error("new overlay should have no changes")
+	}
+	if err := overlay.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	stateID := base.Regions[0].States[0].ID
+	if err := overlay.EditState(stateID, func(state *State) error {
+		state.Name = ""
+		return nil
+	}); err != nil {
+		t.Fatalf("EditState() error = %v", err)
+	}
+	if err := overlay.Validate(); err == nil || !strings.Contains(err.Error(), "Name") {
+		t.Errorf("Validate() error = %v, want a name error", err)
+	}
+	if err := overlay.ValidateInContext(NewValidationContext()); err == nil {
+		t.Error("ValidateInContext() should fail too")
+	}
+	if err := base.Validate(); err != nil {
+		t.Errorf("base should stay valid, got %v", err)
+	}
+}
+
+func TestOverlayCommitAndDiscard(t *testing.T) {
+	base := newPlayerMachine()
+	overlay := NewOverlay(base)
+	rename := func(name string) {
+		t.Helper()
+		if err := overlay.EditState("streaming", func(state *State) error {
+			state.Name = name
+			return nil
+		}); err != nil {
+			t.Fatalf("EditState() error = %v", err)
+		}
+	}
+
+	rename("discarded")
+	overlay.Discard()
+	if overlay.Changed() || len(overlay.Changes()) != 0 {
+		t.Errorf("Discard() left changes: %v", overlay.Changes())
+	}
+	if overlay.StateMachine().Regions[0].States[1].Regions[0].States[1].Name != "streaming" {
+		t.Error("Discard() should restore the base view")
+	}
+
+	rename("Streaming")
+	overlay.Commit()
+	streaming := base.Regions[0].States[1].Regions[0].States[1]
+	if streaming.Name != "Streaming" {
+		t.Errorf("Commit() base state name = %q", streaming.Name)
+	}
+	if base.Regions[0].States[1].Regions[0].Transitions[1].Target != &streaming.Vertex {
+		t.Error("Commit() base transition should target the edited state")
+	}
+	if overlay.Changed() {
+		t.Error("overlay should have no changes after Commit()")
+	}
+
+	// Edits after a commit copy again instead of modifying the base
+	rename("again")
+	if streaming.Name != "Streaming" {
+		t.Errorf("edit after Commit() modified the base: %q", streaming.Name)
+	}
+}
+
+func TestOverlayEditsAreIsolated(t *testing.T) {
+	base := newPlayerMachine()
+	if err := ResolveEndpoints(base, RecordAdjacency()); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+	idle := findState(base, "idle")
+	idle.Tags = []string{"resting"}
+	idle.Suppressions = []Suppression{{Rule: "state.behaviors", Justification: "legacy"}}
+	initial := base.Regions[0].Vertices[0]
+	initial.Tags = []string{"entry"}
+	stop := base.Regions[0].Transitions[2]
+	stop.Tags = []string{"control"}
+	stop.Suppressions = []Suppression{{Rule: "transition.triggers", Justification: "legacy"}}
+
+	overlay := NewOverlay(base)
+	edits := []error{
+		overlay.EditState("idle", func(state *State) error {
+			state.Tags[0] = "changed"
+			state.Suppressions[0].Justification = "changed"
+			return nil
+		}),
+		overlay.EditVertex(initial.ID, func(vertex *Vertex) error {
+			vertex.Tags[0] = "changed"
+			return nil
+		}),
+		overlay.EditTransition("stop", func(transition *Transition) error {
+			transition.Tags[0] = "changed"
+			transition.Suppressions[0].Justification = "changed"
+			return nil
+		}),
+	}
+	for _, err := range edits {
+		if err != nil {
+			t.Fatalf("edit error = %v", err)
+		}
+	}
+
+	if idle.Tags[0] != "resting" || idle.Suppressions[0].Justification != "legacy" || initial.Tags[0] != "entry" ||
+		stop.Tags[0] != "control" || stop.Suppressions[0].Justification != "legacy" {
+		t.Errorf("edits before Commit() changed the base: %v %v %v %v %v", idle.Tags, idle.Suppressions, initial.Tags, stop.Tags, stop.Suppressions)
+	}
+	if len(idle.Outgoing()) != 1 || idle.Outgoing()[0] != base.Regions[0].Transitions[1] {
+		t.Errorf("base idle Outgoing() = %v, want the base play transition", idle.Outgoing())
+	}
+	if copied := findState(overlay.StateMachine(), "idle"); len(copied.Outgoing()) != 0 {
+		t.Errorf("edited copy shares the base adjacency: %v", copied.Outgoing())
+	}
+
+	overlay.Commit()
+	committed := findState(base, "idle")
+	if committed.Tags[0] != "changed" || base.Regions[0].Transitions[2].Tags[0] != "changed" {
+		t.Errorf("Commit() did not apply the tag edits: %v, %v", committed.Tags, base.Regions[0].Transitions[2].Tags)
+	}
+	if outgoing := committed.Outgoing(); len(outgoing) != 1 || outgoing[0].ID != "play" {
+		t.Errorf("Commit() idle Outgoing() = %v, want the play transition", outgoing)
+	}
+}
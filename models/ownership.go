@@ -0,0 +1,272 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Ownership annotates who is responsible for an element of a large shared
+// model. Regions and states carry it directly; every other element, and
+// regions and states without annotations of their own, inherit it from the
+// nearest enclosing region or state that has one.
+type Ownership struct {
+	Owner string `json:"owner,omitempty"` // Person or role responsible for the element
+	Team  string `json:"team,omitempty"`  // Team allowed to edit the element
+}
+
+// String formats the ownership as "team audio, owner alice", leaving out
+// parts that are not set
+func (o Ownership) String() string {
+	var parts []string
+	if o.Team != "" {
+		parts = append(parts, "team "+o.Team)
+	}
+	if o.Owner != "" {
+		parts = append(parts, "owner "+o.Owner)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// OwnedElement is an element of a state machine with its effective ownership
+type OwnedElement struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"` // StateMachine, Region, State, Pseudostate, FinalState, Transition, Event or ConnectionPoint
+	Ownership
+
+	// Source is the ID of the region or state the team was annotated on,
+	// which is ID itself unless the team is inherited; empty when no team
+	// owns the element
+	Source string `json:"source,omitempty"`
+}
+
+// ElementOwnership returns the element with the given ID and its effective
+// ownership. The state machine, its events and its connection points are
+// never owned.
+func ElementOwnership(sm *StateMachine, id string) (OwnedElement, bool) {
+	element, ok := ownershipIndex(sm)[id]
+	return element, ok
+}
+
+// ownershipIndex returns every element of sm by ID with its effective
+// ownership. Submachines are separate models and are not descended into.
+func ownershipIndex(sm *StateMachine) map[string]OwnedElement {
+	index := make(map[string]OwnedElement)
+	if sm == nil {
+		return index
+	}
+	index[sm.ID] = OwnedElement{ID: sm.ID, Kind: "StateMachine"}
+	for _, event := range sm.Events {
+		if event != nil {
+			index[event.ID] = OwnedElement{ID: event.ID, Kind: "Event"}
+		}
+	}
+	for _, cp := range sm.ConnectionPoints {
+		if cp != nil {
+			index[cp.ID] = OwnedElement{ID: cp.ID, Kind: "ConnectionPoint"}
+		}
+	}
+
+	// annotate returns the ownership of an element annotated with owner and
+	// team inside an element with the given ownership
+	annotate := func(id, kind, owner, team string, outer OwnedElement) OwnedElement {
+		element := OwnedElement{ID: id, Kind: kind, Ownership: outer.Ownership, Source: outer.Source}
+		if owner != "" {
+			element.Owner = owner
+		}
+		if team != "" {
+			element.Team, element.Source = team, id
+		}
+		return element
+	}
+
+	var walk func(regions []*Region, outer OwnedElement)
+	walk = func(regions []*Region, outer OwnedElement) {
+		for _, region := range regions {
+			if region == nil {
+				continue
+			}
+			owned := annotate(region.ID, "Region", region.Owner, region.Team, outer)
+			index[region.ID] = owned
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				ownedState := annotate(state.ID, "State", state.Owner, state.Team, owned)
+				index[state.ID] = ownedState
+				walk(state.Regions, ownedState)
+			}
+			for _, vertex := range region.Vertices {
+				if vertex == nil {
+					continue
+				}
+				kind := "Pseudostate"
+				if vertex.Type == "finalstate" {
+					kind = "FinalState"
+				}
+				index[vertex.ID] = annotate(vertex.ID, kind, "", "", owned)
+			}
+			for _, transition := range region.Transitions {
+				if transition != nil {
+					index[transition.ID] = annotate(transition.ID, "Transition", "", "", owned)
+				}
+			}
+		}
+	}
+	walk(sm.Regions, OwnedElement{})
+	return index
+}
+
+// EditPolicy decides whether a team may modify an element
+type EditPolicy interface {
+	// CanEdit returns an error describing why team may not modify element,
+	// or nil
+	CanEdit(team string, element OwnedElement) error
+}
+
+// EditPolicyFunc adapts a function to the EditPolicy interface
+type EditPolicyFunc func(team string, element OwnedElement) error
+
+// CanEdit calls f(team, element)
+func (f EditPolicyFunc) CanEdit(team string, element OwnedElement) error {
+	return f(team, element)
+}
+
+// TeamEditPolicy lets a team edit the elements it owns and elements no team
+// owns. It is the policy of editors created without one.
+var TeamEditPolicy EditPolicy = EditPolicyFunc(func(team string, element OwnedElement) error {
+	if element.Team == "" || element.Team == team {
+		return nil
+	}
+	if element.Source != element.ID {
+		return fmt.Errorf("owned by team '%s' through '%s'", element.Team, element.Source)
+	}
+	return fmt.Errorf("owned by team '%s'", element.Team)
+})
+
+// PermissionError is returned by Editor operations that would modify an
+// element the editor's team may not edit
+type PermissionError struct {
+	Team      string
+	ElementID string
+	Reason    error // Why the policy refused the edit
+}
+
+// Error implements the error interface
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("team '%s' may not modify '%s': %v", e.Team, e.ElementID, e.Reason)
+}
+
+// Unwrap returns the policy's reason
+func (e *PermissionError) Unwrap() error {
+	return e.Reason
+}
+
+// Editor applies the refactoring operations of this package on behalf of a
+// team, refusing with a *PermissionError to modify elements the team may not
+// edit under its policy. The state machine is left untouched when an
+// operation is refused.
+type Editor struct {
+	sm     *StateMachine
+	team   string
+	policy EditPolicy
+}
+
+// NewEditor creates an editor of sm for team; a nil policy means
+// TeamEditPolicy
+func NewEditor(sm *StateMachine, team string, policy EditPolicy) *Editor {
+	if policy == nil {
+		policy = TeamEditPolicy
+	}
+	return &Editor{sm: sm, team: team, policy: policy}
+}
+
+// Check returns a *PermissionError for the first element, in sorted order,
+// that the editor's team may not modify. Unknown IDs are skipped, since the
+// operations report them.
+func (e *Editor) Check(ids ...string) error {
+	index := ownershipIndex(e.sm)
+	for _, id := range slices.Sorted(slices.Values(ids)) {
+		element, ok := index[id]
+		if !ok {
+			continue
+		}
+		if err := e.policy.CanEdit(e.team, element); err != nil {
+			return &PermissionError{Team: e.team, ElementID: id, Reason: err}
+		}
+	}
+	return nil
+}
+
+// Rename is RenameElement for an element the team may edit
+func (e *Editor) Rename(id, newID, newName string) error {
+	if err := e.Check(id); err != nil {
+		return err
+	}
+	return RenameElement(e.sm, id, newID, newName)
+}
+
+// Delete is DeleteElement for an element the team may edit. Everything the
+// element contains must be editable too, and with cascade so must the
+// elements whose references to it are removed.
+func (e *Editor) Delete(id string, cascade bool) error {
+	if e.sm == nil || !elementExists(e.sm, id) {
+		return DeleteElement(e.sm, id, cascade)
+	}
+	affected := collectDeletedIDs(e.sm, id)
+	if cascade {
+		for _, deletedID := range slices.Collect(maps.Keys(affected)) {
+			for _, usage := range Usages(e.sm, deletedID) {
+				affected[usage.OwnerID] = true
+			}
+		}
+	}
+	if err := e.Check(slices.Collect(maps.Keys(affected))...); err != nil {
+		return err
+	}
+	return DeleteElement(e.sm, id, cascade)
+}
+
+// RetargetTransition is RetargetTransition for a transition the team may
+// edit; the new target may belong to anyone
+func (e *Editor) RetargetTransition(transitionID, targetID string) error {
+	if err := e.Check(transitionID); err != nil {
+		return err
+	}
+	return RetargetTransition(e.sm, transitionID, targetID)
+}
+
+// SplitTransition is SplitTransition for a transition the team may edit
+func (e *Editor) SplitTransition(transitionID, choiceID string) (*Transition, error) {
+	if err := e.Check(transitionID); err != nil {
+		return nil, err
+	}
+	return SplitTransition(e.sm, transitionID, choiceID)
+}
+
+// SetOwnership replaces the owner and team annotated on a region or state
+// the team may edit, for example to hand it over to another team
+func (e *Editor) SetOwnership(id string, ownership Ownership) error {
+	if e.sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	if err := e.Check(id); err != nil {
+		return err
+	}
+	found := false
+	walkRegionTree(e.sm.Regions, "", func(region *Region, _ string) {
+		if region.ID == id {
+			region.Owner, region.Team, found = ownership.Owner, ownership.Team, true
+		}
+		for _, state := range region.States {
+			if state != nil && state.ID == id {
+				state.Owner, state.Team, found = ownership.Owner, ownership.Team, true
+			}
+		}
+	})
+	if !found {
+		return fmt.Errorf("region or state '%s' not found", id)
+	}
+	return nil
+}
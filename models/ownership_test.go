@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newOwnedPlayerMachine returns the player machine with its main region
+// owned by team core and its audio region owned by alice of team audio
+func newOwnedPlayerMachine() *StateMachine {
+	sm := newPlayerMachine()
+	main := sm.Regions[0]
+	main.Team = "core"
+	audio := main.States[1].Regions[0]
+	audio.Owner, audio.Team = "alice", "audio"
+	return sm
+}
+
+func TestElementOwnership(t *testing.T) {
+	tests := []struct {
+		id   string
+		want OwnedElement
+	}{
+		{id: "player", want: OwnedElement{ID: "player", Kind: "StateMachine"}},
+		{id: "main", want: OwnedElement{ID: "main", Kind: "Region", Ownership: Ownership{Team: "core"}, Source: "main"}},
+		{id: "playing", want: OwnedElement{ID: "playing", Kind: "State", Ownership: Ownership{Team: "core"}, Source: "main"}},
+		{id: "audio", want: OwnedElement{ID: "audio", Kind: "Region", Ownership: Ownership{Owner: "alice", Team: "audio"}, Source: "audio"}},
+		{id: "streaming", want: OwnedElement{ID: "streaming", Kind: "State", Ownership: Ownership{Owner: "alice", Team: "audio"}, Source: "audio"}},
+		{id: "audio-initial", want: OwnedElement{ID: "audio-initial", Kind: "Pseudostate", Ownership: Ownership{Owner: "alice", Team: "audio"}, Source: "audio"}},
+		{id: "video-ready", want: OwnedElement{ID: "video-ready", Kind: "Transition", Ownership: Ownership{Team: "core"}, Source: "main"}},
+	}
+
+	sm := newOwnedPlayerMachine()
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, ok := ElementOwnership(sm, tt.id)
+			if !ok || got != tt.want {
+				t.Errorf("ElementOwnership() = %+v, %v; want %+v", got, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := ElementOwnership(sm, "missing"); ok {
+		t.Error("ElementOwnership(missing) found an element")
+	}
+}
+
+func TestOwnership_String(t *testing.T) {
+	tests := []struct {
+		ownership Ownership
+		want      string
+	}{
+		{ownership: Ownership{}, want: ""},
+		{ownership: Ownership{Team: "audio"}, want: "team audio"},
+		{ownership: Ownership{Owner: "alice", Team: "audio"}, want: "team audio, owner alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.ownership.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor(t *testing.T) {
+	// adminPolicy lets team admin edit everything
+	adminPolicy := EditPolicyFunc(func(team string, element OwnedElement) error {
+		if team == "admin" {
+			return nil
+		}
+		return TeamEditPolicy.CanEdit(team, element)
+	})
+
+	tests := []struct {
+		name    string
+		team    string
+		policy  EditPolicy
+		setup   func(sm *StateMachine)
+		edit    func(e *Editor) error
+		wantErr string // Empty when the edit is allowed
+	}{
+		{
+			name: "rename an owned state",
+			team: "audio",
+			edit: func(e *Editor) error { return e.Rename("streaming", "", "Streaming audio") },
+		},
+		{
+			name:    "rename a state of another team",
+			team:    "audio",
+			edit:    func(e *Editor) error { return e.Rename("idle", "", "Stopped") },
+			wantErr: "team 'audio' may not modify 'idle': owned by team 'core' through 'main'",
+		},
+		{
+			name:    "rename a region of another team",
+			team:    "core",
+			edit:    func(e *Editor) error { return e.Rename("audio", "", "Sound") },
+			wantErr: "team 'core' may not modify 'audio': owned by team 'audio'",
+		},
+		{
+			name:    "delete a state containing another team's region",
+			team:    "core",
+			edit:    func(e *Editor) error { return e.Delete("playing", true) },
+			wantErr: "team 'core' may not modify 'audio'",
+		},
+		{
+			name: "cascade into another team's transitions",
+			team: "audio",
+			setup: func(sm *StateMachine) {
+				main := sm.Regions[0]
+				streaming := &main.States[1].Regions[0].States[1].Vertex
+				main.Transitions = append(main.Transitions, execTransition("resume", &main.States[0].Vertex, streaming, "resume"))
+			},
+			edit:    func(e *Editor) error { return e.Delete("streaming", true) },
+			wantErr: "team 'audio' may not modify 'resume': owned by team 'core' through 'main'",
+		},
+		{
+			name: "retarget an owned transition at another team's state",
+			team: "core",
+			edit: func(e *Editor) error { return e.RetargetTransition("stop", "idle") },
+		},
+		{
+			name:    "split another team's transition",
+			team:    "audio",
+			edit:    func(e *Editor) error { _, err := e.SplitTransition("play", "play-choice"); return err },
+			wantErr: "team 'audio' may not modify 'play'",
+		},
+		{
+			name:   "custom policy",
+			team:   "admin",
+			policy: adminPolicy,
+			edit:   func(e *Editor) error { return e.Rename("audio", "", "Sound") },
+		},
+		{
+			name: "hand a state over to another team",
+			team: "core",
+			edit: func(e *Editor) error {
+				if err := e.SetOwnership("playing", Ownership{Team: "audio"}); err != nil {
+					return err
+				}
+				return NewEditor(e.sm, "audio", nil).Rename("buffering", "", "Buffering video")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOwnedPlayerMachine()
+			if tt.setup != nil {
+				tt.setup(sm)
+			}
+			before := fmt.Sprint(Diff(newOwnedPlayerMachine(), sm))
+			err := tt.edit(NewEditor(sm, tt.team, tt.policy))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("edit error = %v", err)
+				}
+				return
+			}
+
+			var permission *PermissionError
+			if !errors.As(err, &permission) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("edit error = %v, want a *PermissionError containing %q", err, tt.wantErr)
+			}
+			if after := fmt.Sprint(Diff(newOwnedPlayerMachine(), sm)); after != before {
+				t.Errorf("refused edit changed the state machine: %s", after)
+			}
+		})
+	}
+}
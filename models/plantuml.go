@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// plantUMLStyle colors individual states and transitions in a PlantUML
+// diagram. Either function may be nil; an empty color leaves the element as
+// it is.
+type plantUMLStyle struct {
+	title      string // Overrides the state machine name as the diagram title
+	vertex     func(id string) string
+	transition func(id string) string
+}
+
+// PlantUML returns a PlantUML state diagram of the state machine. Composite
+// states are nested, orthogonal regions are separated by "--", and
+// pseudostates and final states use PlantUML's stereotypes. Transitions are
+// labeled with their triggers, guard and effect. Deprecated states,
+// transitions and events are struck through.
+func PlantUML(sm *StateMachine) string {
+	return plantUML(sm, plantUMLStyle{})
+}
+
+// plantUML renders the diagram with the given style
+func plantUML(sm *StateMachine, style plantUMLStyle) string {
+	if sm == nil {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("@startuml\n")
+	title := style.title
+	if title == "" {
+		title = displayName(sm.Name, sm.ID)
+	}
+	out.WriteString(fmt.Sprintf("title %s\n", title))
+	writePlantUMLRegions(&out, sm.Regions, 0, style)
+	deprecatedEvents := make(map[string]bool)
+	for _, event := range sm.Events {
+		if event != nil && event.Deprecated {
+			deprecatedEvents[event.ID] = true
+		}
+	}
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source == nil || transition.Target == nil {
+			return
+		}
+		arrow := "-->"
+		if style.transition != nil {
+			if color := style.transition(transition.ID); color != "" {
+				arrow = fmt.Sprintf("-[%s]->", color)
+			}
+		}
+		line := fmt.Sprintf("%s %s %s", diagramAlias(transition.Source.ID), arrow, diagramAlias(transition.Target.ID))
+		if label := plantUMLTransitionLabel(transition, deprecatedEvents); label != "" {
+			line += " : " + label
+		}
+		out.WriteString(line + "\n")
+	})
+	out.WriteString("@enduml\n")
+	return out.String()
+}
+
+// writePlantUMLRegions writes the contents of sibling regions, separating
+// orthogonal regions with "--"
+func writePlantUMLRegions(out *strings.Builder, regions []*Region, level int, style plantUMLStyle) {
+	indent := strings.Repeat("  ", level)
+	written := 0
+	for _, region := range regions {
+		if region == nil {
+			continue
+		}
+		if written > 0 {
+			out.WriteString(indent + "--\n")
+		}
+		written++
+
+		for _, vertex := range region.Vertices {
+			if vertex == nil {
+				continue
+			}
+			line := fmt.Sprintf("%sstate %q as %s", indent, displayName(vertex.Name, vertex.ID), diagramAlias(vertex.ID))
+			if stereotype := plantUMLStereotype(vertex); stereotype != "" {
+				line = fmt.Sprintf("%sstate %s <<%s>>", indent, diagramAlias(vertex.ID), stereotype)
+			}
+			out.WriteString(line + plantUMLColor(vertex.ID, style) + "\n")
+		}
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			name := displayName(state.Name, state.ID)
+			if state.Deprecated {
+				name = plantUMLStrike(name)
+			}
+			line := fmt.Sprintf("%sstate %q as %s%s", indent, name, diagramAlias(state.ID), plantUMLColor(state.ID, style))
+			if len(state.Regions) == 0 {
+				out.WriteString(line + "\n")
+				continue
+			}
+			out.WriteString(line + " {\n")
+			writePlantUMLRegions(out, state.Regions, level+1, style)
+			out.WriteString(indent + "}\n")
+		}
+	}
+}
+
+// plantUMLStereotype returns the PlantUML stereotype of a pseudostate or
+// final state, or "" for states
+func plantUMLStereotype(vertex *Vertex) string {
+	switch vertex.Type {
+	case "finalstate":
+		return "end"
+	case "pseudostate":
+	default:
+		return ""
+	}
+	switch vertexPseudostateKind(vertex) {
+	case PseudostateKindInitial:
+		return "start"
+	case PseudostateKindChoice, PseudostateKindJunction:
+		return "choice"
+	case PseudostateKindFork:
+		return "fork"
+	case PseudostateKindJoin:
+		return "join"
+	case PseudostateKindShallowHistory:
+		return "history"
+	case PseudostateKindDeepHistory:
+		return "history*"
+	}
+	switch PseudostateKind(vertex.Name) {
+	case PseudostateKindEntryPoint:
+		return "entryPoint"
+	case PseudostateKindExitPoint:
+		return "exitPoint"
+	case PseudostateKindTerminate:
+		return "end"
+	}
+	return "choice"
+}
+
+// plantUMLColor returns the color suffix of a state declaration
+func plantUMLColor(id string, style plantUMLStyle) string {
+	if style.vertex == nil {
+		return ""
+	}
+	if color := style.vertex(id); color != "" {
+		return " " + color
+	}
+	return ""
+}
+
+// plantUMLTransitionLabel labels a transition like transitionLabel, striking
+// through deprecated events, or the whole label of a deprecated transition
+func plantUMLTransitionLabel(transition *Transition, deprecatedEvents map[string]bool) string {
+	if transition.Deprecated {
+		if label := transitionLabel(transition); label != "" {
+			return plantUMLStrike(label)
+		}
+		return ""
+	}
+	return formatTransitionLabel(transition, func(event string) string {
+		if deprecatedEvents[event] {
+			return plantUMLStrike(event)
+		}
+		return event
+	})
+}
+
+// plantUMLStrike strikes text through with PlantUML's creole markup
+func plantUMLStrike(text string) string {
+	return "--" + text + "--"
+}
+
+// transitionLabel describes a transition as "triggers [guard] / effect", as
+// diagrams label it
+func transitionLabel(transition *Transition) string {
+	return formatTransitionLabel(transition, func(event string) string { return event })
+}
+
+// formatTransitionLabel builds a transition label, formatting each trigger's
+// event with eventText
+func formatTransitionLabel(transition *Transition, eventText func(event string) string) string {
+	var events []string
+	for _, trigger := range transition.Triggers {
+		if key := trigger.EventKey(); key != "" {
+			events = append(events, eventText(key))
+		}
+	}
+	label := strings.Join(events, ", ")
+	if transition.Guard != nil {
+		label += fmt.Sprintf(" [%s]", displayName(transition.Guard.Specification, transition.Guard.ID))
+	}
+	if transition.Effect != nil {
+		label += " / " + displayName(transition.Effect.Name, transition.Effect.ID)
+	}
+	return strings.TrimSpace(label)
+}
+
+// diagramAlias turns an ID into an identifier usable in PlantUML, Mermaid and
+// DOT diagrams
+func diagramAlias(id string) string {
+	alias := []rune(id)
+	for i, r := range alias {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			alias[i] = '_'
+		}
+	}
+	if len(alias) == 0 || alias[0] >= '0' && alias[0] <= '9' {
+		return "s_" + string(alias)
+	}
+	return string(alias)
+}
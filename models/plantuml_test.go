@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlantUML(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Regions[0].Transitions[2].Guard = &Constraint{ID: "may-stop", Specification: "user confirmed"}
+	sm.Regions[0].Transitions[2].Effect = &Behavior{ID: "save", Name: "savePosition"}
+	diagram := PlantUML(sm)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "header", want: "@startuml\ntitle Player\n"},
+		{name: "initial pseudostate", want: "state initial <<start>>\n"},
+		{name: "simple state", want: "state \"idle\" as idle\n"},
+		{name: "composite state", want: "state \"playing\" as playing {\n"},
+		{name: "nested state", want: "  state \"loading\" as loading\n"},
+		{name: "orthogonal separator", want: "  --\n  state video_initial <<start>>\n"},
+		{name: "aliases replace invalid characters", want: "audio_initial --> loading\n"},
+		{name: "trigger label", want: "idle --> playing : play\n"},
+		{name: "guard and effect", want: "playing --> idle : stop [user confirmed] / savePosition\n"},
+		{name: "footer", want: "@enduml\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(diagram, tt.want) {
+				t.Errorf("PlantUML() missing %q in\n%s", tt.want, diagram)
+			}
+		})
+	}
+
+	if got := PlantUML(nil); got != "" {
+		t.Errorf("PlantUML(nil) = %q, want empty", got)
+	}
+}
+
+func TestPlantUMLStereotype(t *testing.T) {
+	tests := []struct {
+		vertex *Vertex
+		want   string
+	}{
+		{vertex: &Vertex{ID: "s", Type: "state"}, want: ""},
+		{vertex: &Vertex{ID: "f", Type: "finalstate"}, want: "end"},
+		{vertex: &Vertex{ID: "c", Name: "choice", Type: "pseudostate"}, want: "choice"},
+		{vertex: &Vertex{ID: "j", Name: "join", Type: "pseudostate"}, want: "join"},
+		{vertex: &Vertex{ID: "h", Name: "deepHistory", Type: "pseudostate"}, want: "history*"},
+		{vertex: &Vertex{ID: "e", Name: "entryPoint", Type: "pseudostate"}, want: "entryPoint"},
+		{vertex: &Vertex{ID: "x", Name: "terminate", Type: "pseudostate"}, want: "end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vertex.ID, func(t *testing.T) {
+			if got := plantUMLStereotype(tt.vertex); got != tt.want {
+				t.Errorf("plantUMLStereotype() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagramAlias(t *testing.T) {
+	tests := map[string]string{
+		"idle":       "idle",
+		"audio-init": "audio_init",
+		"1st":        "s_1st",
+		"":           "s_",
+	}
+	for id, want := range tests {
+		if got := diagramAlias(id); got != want {
+			t.Errorf("diagramAlias(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelPolicy is an organizational modeling standard, such as "every state
+// has an owner" or "guards are written in OCL". Policies are declared in a
+// ValidationProfile and evaluated alongside validation; their violations are
+// reported as ErrorTypePolicy so they can be told apart from UML errors.
+type ModelPolicy interface {
+	// Name identifies the policy in reports, e.g. "states-have-owners"
+	Name() string
+
+	// Evaluate returns the violations of the policy in sm
+	Evaluate(sm *StateMachine) []PolicyViolation
+}
+
+// PolicyViolation is an element that does not meet a policy
+type PolicyViolation struct {
+	Policy    string   `json:"policy"`             // Name of the violated policy; filled in by EvaluatePolicies
+	Object    string   `json:"object"`             // Type of the element, e.g. "State"
+	ElementID string   `json:"element_id"`         // Empty for violations of the machine as a whole
+	Message   string   `json:"message"`            // What is wrong
+	Path      string   `json:"path,omitempty"`     // Location in the model, e.g. "Regions[0].States[2]"
+	Severity  Severity `json:"severity,omitempty"` // SeverityWarning or SeverityInfo for advisory policies; empty or SeverityError otherwise
+}
+
+// String returns a concise one-line description of the violation
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("[%s] %s", v.Policy, v.Message)
+}
+
+// policyFunc is a ModelPolicy defined by a function
+type policyFunc struct {
+	name     string
+	evaluate func(sm *StateMachine) []PolicyViolation
+}
+
+func (p policyFunc) Name() string                                { return p.name }
+func (p policyFunc) Evaluate(sm *StateMachine) []PolicyViolation { return p.evaluate(sm) }
+
+// NewPolicy declares a policy from a function returning its violations
+func NewPolicy(name string, evaluate func(sm *StateMachine) []PolicyViolation) ModelPolicy {
+	return policyFunc{name: name, evaluate: evaluate}
+}
+
+// ContextPolicy is a policy that also reads the validation context, for
+// example a tenant ID attached with a ContextKey. Validation evaluates it
+// with EvaluateInContext; Evaluate evaluates it with a nil context.
+type ContextPolicy interface {
+	ModelPolicy
+
+	// EvaluateInContext returns the violations of the policy in sm when
+	// validated in context
+	EvaluateInContext(sm *StateMachine, context *ValidationContext) []PolicyViolation
+}
+
+// contextPolicyFunc is a ContextPolicy defined by a function
+type contextPolicyFunc struct {
+	name     string
+	evaluate func(sm *StateMachine, context *ValidationContext) []PolicyViolation
+}
+
+func (p contextPolicyFunc) Name() string                                { return p.name }
+func (p contextPolicyFunc) Evaluate(sm *StateMachine) []PolicyViolation { return p.evaluate(sm, nil) }
+func (p contextPolicyFunc) EvaluateInContext(sm *StateMachine, context *ValidationContext) []PolicyViolation {
+	return p.evaluate(sm, context)
+}
+
+// NewContextPolicy declares a policy from a function of the machine and the
+// validation context, which is nil outside validation
+func NewContextPolicy(name string, evaluate func(sm *StateMachine, context *ValidationContext) []PolicyViolation) ContextPolicy {
+	return contextPolicyFunc{name: name, evaluate: evaluate}
+}
+
+// StatePolicy declares a policy that checks every state on its own: check
+// returns why the state violates the policy, or "" if it does not
+func StatePolicy(name string, check func(sm *StateMachine, state *State) string) ModelPolicy {
+	return NewPolicy(name, func(sm *StateMachine) []PolicyViolation {
+		var violations []PolicyViolation
+		walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+			for i, state := range region.States {
+				if state == nil {
+					continue
+				}
+				if message := check(sm, state); message != "" {
+					violations = append(violations, PolicyViolation{
+						Object: "State", ElementID: state.ID, Message: message, Path: fmt.Sprintf("%s.States[%d]", path, i),
+					})
+				}
+			}
+		})
+		return violations
+	})
+}
+
+// RequireStateOwners requires every state to have an owner, annotated on the
+// state or inherited from an enclosing region or state (see Ownership)
+func RequireStateOwners() ModelPolicy {
+	return StatePolicy("states-have-owners", func(sm *StateMachine, state *State) string {
+		if owned, _ := ElementOwnership(sm, state.ID); owned.Owner == "" {
+			return fmt.Sprintf("state '%s' has no owner", state.ID)
+		}
+		return ""
+	})
+}
+
+// MaxStates limits the number of states of a machine, nested states
+// included and submachines excluded
+func MaxStates(limit int) ModelPolicy {
+	return NewPolicy("max-states", func(sm *StateMachine) []PolicyViolation {
+		count := 0
+		walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+			for _, state := range region.States {
+				if state != nil {
+					count++
+				}
+			}
+		})
+		if count <= limit {
+			return nil
+		}
+		return []PolicyViolation{{
+			Object:  "StateMachine",
+			Message: fmt.Sprintf("state machine '%s' has %d states, more than the %d allowed", sm.ID, count, limit),
+		}}
+	})
+}
+
+// RequireGuardLanguage requires every guard to be written in the given
+// language, compared case-insensitively, e.g. RequireGuardLanguage("OCL")
+func RequireGuardLanguage(language string) ModelPolicy {
+	return NewPolicy("guard-language", func(sm *StateMachine) []PolicyViolation {
+		var violations []PolicyViolation
+		walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+			for i, transition := range region.Transitions {
+				if transition == nil || transition.Guard == nil || strings.EqualFold(transition.Guard.Language, language) {
+					continue
+				}
+				violations = append(violations, PolicyViolation{
+					Object:    "Constraint",
+					ElementID: transition.Guard.ID,
+					Message:   fmt.Sprintf("guard '%s' of transition '%s' is written in %s, not %s", transition.Guard.ID, transition.ID, displayName(transition.Guard.Language, "an unspecified language"), language),
+					Path:      fmt.Sprintf("%s.Transitions[%d].Guard", path, i),
+				})
+			}
+		})
+		return violations
+	})
+}
+
+// EvaluatePolicies evaluates the policies against sm and returns their
+// violations, policy by policy, with Policy set to the policy's name
+func EvaluatePolicies(sm *StateMachine, policies ...ModelPolicy) []PolicyViolation {
+	return evaluatePolicies(sm, nil, policies)
+}
+
+// evaluatePolicies is EvaluatePolicies evaluating context policies in
+// context
+func evaluatePolicies(sm *StateMachine, context *ValidationContext, policies []ModelPolicy) []PolicyViolation {
+	if sm == nil {
+		return nil
+	}
+	var violations []PolicyViolation
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		var found []PolicyViolation
+		if contextPolicy, ok := policy.(ContextPolicy); ok && context != nil {
+			found = contextPolicy.EvaluateInContext(sm, context)
+		} else {
+			found = policy.Evaluate(sm)
+		}
+		for _, violation := range found {
+			violation.Policy = policy.Name()
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// validatePolicies reports the violations of the policies of the context's
+// profile. Only the machine at the root of the validation is evaluated;
+// submachines are evaluated when they are validated on their own.
+func (sm *StateMachine) validatePolicies(context *ValidationContext, errors *ValidationErrors) {
+	policies := context.ActiveProfile().Policies
+	if len(policies) == 0 || len(context.machineChain) > 1 {
+		return
+	}
+	for _, violation := range evaluatePolicies(sm, context, policies) {
+		path := context.Path
+		if violation.Path != "" {
+			path = append(append([]string{}, context.Path...), strings.Split(violation.Path, ".")...)
+		}
+		errors.Add(&ValidationError{
+			Type:     ErrorTypePolicy,
+			Object:   violation.Object,
+			Field:    "Policy",
+			Message:  fmt.Sprintf("%s (policy %s)", violation.Message, violation.Policy),
+			Path:     path,
+			Context:  map[string]interface{}{"policy": violation.Policy, "element": violation.ElementID},
+			Severity: violation.Severity,
+		})
+	}
+}
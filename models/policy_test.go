@@ -0,0 +1,110 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func() *StateMachine
+		policies []ModelPolicy
+		want     []PolicyViolation
+	}{
+		{
+			name:     "states without owners",
+			build:    createValidStateMachine,
+			policies: []ModelPolicy{RequireStateOwners()},
+			want: []PolicyViolation{
+				{Policy: "states-have-owners", Object: "State", ElementID: "state1", Message: "state 'state1' has no owner", Path: "Regions[0].States[0]"},
+				{Policy: "states-have-owners", Object: "State", ElementID: "state2", Message: "state 'state2' has no owner", Path: "Regions[0].States[1]"},
+			},
+		},
+		{
+			name: "owners inherited from the region",
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				sm.Regions[0].Owner = "alice"
+				return sm
+			},
+			policies: []ModelPolicy{RequireStateOwners()},
+		},
+		{
+			name:     "too many states",
+			build:    newPlayerMachine,
+			policies: []ModelPolicy{MaxStates(6), MaxStates(5)},
+			want: []PolicyViolation{
+				{Policy: "max-states", Object: "StateMachine", Message: "state machine 'player' has 6 states, more than the 5 allowed"},
+			},
+		},
+		{
+			name:     "guards in another language",
+			build:    createValidStateMachine,
+			policies: []ModelPolicy{RequireGuardLanguage("OCL")},
+			want: []PolicyViolation{
+				{Policy: "guard-language", Object: "Constraint", ElementID: "guard1", Message: "guard 'guard1' of transition 't2' is written in Java, not OCL", Path: "Regions[0].Transitions[1].Guard"},
+			},
+		},
+		{
+			name:     "guards in the required language",
+			build:    createValidStateMachine,
+			policies: []ModelPolicy{RequireGuardLanguage("java")},
+		},
+		{
+			name:  "custom policy",
+			build: createValidStateMachine,
+			policies: []ModelPolicy{StatePolicy("no-entry-actions", func(_ *StateMachine, state *State) string {
+				if state.Entry != nil {
+					return "state '" + state.ID + "' has an entry action"
+				}
+				return ""
+			})},
+			want: []PolicyViolation{
+				{Policy: "no-entry-actions", Object: "State", ElementID: "state1", Message: "state 'state1' has an entry action", Path: "Regions[0].States[0]"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluatePolicies(tt.build(), tt.policies...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluatePolicies() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ValidatePolicies(t *testing.T) {
+	advisory := NewPolicy("advisory", func(sm *StateMachine) []PolicyViolation {
+		return []PolicyViolation{{Object: "StateMachine", Message: "consider splitting '" + sm.ID + "'", Severity: SeverityWarning}}
+	})
+	profile := &ValidationProfile{Name: "org", Policies: []ModelPolicy{RequireGuardLanguage("OCL"), advisory}}
+
+	errors := &ValidationErrors{}
+	createValidStateMachine().ValidateWithErrors(NewValidationContext().WithProfile(profile), errors)
+
+	policyErrors := errors.GetErrorsByType(ErrorTypePolicy)
+	if len(policyErrors) != 1 {
+		t.Fatalf("policy errors = %v, want 1", policyErrors)
+	}
+	got := policyErrors[0]
+	if got.Message != "guard 'guard1' of transition 't2' is written in Java, not OCL (policy guard-language)" ||
+		strings.Join(got.Path, ".") != "Regions[0].Transitions[1].Guard" || got.Context["policy"] != "guard-language" {
+		t.Errorf("policy error = %+v", got)
+	}
+	if got.Type.String() != "Policy" {
+		t.Errorf("Type.String() = %q, want Policy", got.Type.String())
+	}
+	var advisories []*ValidationError
+	for _, warning := range errors.Warnings {
+		if warning.Type == ErrorTypePolicy {
+			advisories = append(advisories, warning)
+		}
+	}
+	if len(advisories) != 1 || advisories[0].Message != "consider splitting 'sm1' (policy advisory)" {
+		t.Errorf("policy warnings = %v", advisories)
+	}
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// probabilityTolerance absorbs rounding errors when probabilities are summed
+const probabilityTolerance = 1e-9
+
+// probabilityGroup returns the key of the group of alternative transitions t
+// belongs to: transitions leaving the same vertex on the same triggers. The
+// probabilities within a group must not sum to more than 1.
+func (t *Transition) probabilityGroup() string {
+	events := make([]string, 0, len(t.Triggers))
+	for _, trigger := range t.Triggers {
+		if trigger != nil {
+			events = append(events, trigger.EventKey())
+		}
+	}
+	sort.Strings(events)
+	source := ""
+	if t.Source != nil {
+		source = t.Source.ID
+	}
+	return source + "|" + strings.Join(events, ",")
+}
+
+// validateProbability checks that an annotated probability lies in [0, 1]
+func (t *Transition) validateProbability(context *ValidationContext, errors *ValidationErrors) {
+	if t.Probability == nil {
+		return
+	}
+	if p := *t.Probability; math.IsNaN(p) || p < 0 || p > 1 {
+		errors.AddError(
+			ErrorTypeInvalid,
+			"Transition",
+			"Probability",
+			fmt.Sprintf("probability %g must be between 0 and 1", p),
+			context.Path,
+		)
+	}
+}
+
+// validateProbabilityGroups checks that the probabilities of alternative
+// transitions, those leaving the same vertex on the same triggers, do not sum
+// to more than 1
+func (sm *StateMachine) validateProbabilityGroups(context *ValidationContext, errors *ValidationErrors) {
+	sums := make(map[string]float64)
+	members := make(map[string][]string)
+	var order []string
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Probability == nil {
+			return
+		}
+		group := transition.probabilityGroup()
+		if _, exists := sums[group]; !exists {
+			order = append(order, group)
+		}
+		sums[group] += *transition.Probability
+		members[group] = append(members[group], transition.ID)
+	})
+
+	for _, group := range order {
+		if sums[group] <= 1+probabilityTolerance {
+			continue
+		}
+		source, triggers, _ := strings.Cut(group, "|")
+		on := "without triggers"
+		if triggers != "" {
+			on = fmt.Sprintf("on '%s'", triggers)
+		}
+		errors.AddError(
+			ErrorTypeConstraint,
+			"StateMachine",
+			"Probability",
+			fmt.Sprintf("probabilities of transitions %s leaving '%s' %s sum to %g, which exceeds 1", strings.Join(members[group], ", "), source, on, sums[group]),
+			context.Path,
+		)
+	}
+}
+
+// MarkovAnalysis is the result of treating a state machine as an absorbing
+// Markov chain over its vertices
+type MarkovAnalysis struct {
+	// ExpectedVisits maps each transient vertex to the expected number of
+	// times it is entered before the machine comes to rest
+	ExpectedVisits map[string]float64 `json:"expected_visits"`
+
+	// Absorption maps each absorbing vertex (final states, terminate
+	// pseudostates and other vertices without outgoing transitions) to the
+	// probability that the machine ends there
+	Absorption map[string]float64 `json:"absorption"`
+}
+
+// AnalyzeMarkov treats sm as an absorbing Markov chain and computes the
+// expected visit count of every transient vertex and the probability of
+// ending in every absorbing vertex, e.g. for reliability analysis.
+//
+// The chain is flat: it starts in the initial pseudostates of the top-level
+// regions with equal probability and moves along transitions. A vertex's
+// outgoing transitions form a single distribution: annotated probabilities
+// are used as given, unannotated transitions share the remaining probability
+// equally, and the result is normalized to sum to 1. AnalyzeMarkov returns an
+// error if there is no initial pseudostate or if some vertices can never
+// come to rest.
+func AnalyzeMarkov(sm *StateMachine) (*MarkovAnalysis, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+
+	// Outgoing distribution of every vertex
+	outgoing := make(map[string][]*Transition)
+	var vertices []string
+	added := make(map[string]bool)
+	addVertex := func(id string) {
+		if !added[id] {
+			added[id] = true
+			vertices = append(vertices, id)
+		}
+	}
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source == nil || transition.Target == nil {
+			return
+		}
+		addVertex(transition.Source.ID)
+		addVertex(transition.Target.ID)
+		outgoing[transition.Source.ID] = append(outgoing[transition.Source.ID], transition)
+	})
+
+	var start []string
+	for _, region := range sm.Regions {
+		if region != nil {
+			start = append(start, regionInitialIDs(region)...)
+		}
+	}
+	if len(start) == 0 {
+		return nil, fmt.Errorf("state machine '%s' has no top-level initial pseudostate to start from", sm.ID)
+	}
+	isStart := make(map[string]bool, len(start))
+	for _, id := range start {
+		addVertex(id)
+		isStart[id] = true
+	}
+
+	var transient, absorbing []string
+	for _, id := range vertices {
+		if len(outgoing[id]) > 0 {
+			transient = append(transient, id)
+		} else {
+			absorbing = append(absorbing, id)
+		}
+	}
+	index := make(map[string]int, len(transient))
+	for i, id := range transient {
+		index[id] = i
+	}
+
+	// Solve x (I - Q) = s for the expected visits x, where Q holds the
+	// transition probabilities between transient vertices and s is the
+	// start distribution. The system is solved in its transposed form.
+	n := len(transient)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n+1)
+		matrix[i][i] = 1
+	}
+	for _, id := range start {
+		if i, ok := index[id]; ok {
+			matrix[i][n] += 1 / float64(len(start))
+		}
+	}
+	distributions := make(map[string]map[string]float64, n)
+	for _, id := range transient {
+		distributions[id] = transitionDistribution(outgoing[id])
+		for target, p := range distributions[id] {
+			if j, ok := index[target]; ok {
+				matrix[j][index[id]] -= p
+			}
+		}
+	}
+	visits, err := solveLinearSystem(matrix)
+	if err != nil {
+		return nil, fmt.Errorf("state machine '%s' has vertices that never come to rest: %w", sm.ID, err)
+	}
+
+	analysis := &MarkovAnalysis{
+		ExpectedVisits: make(map[string]float64, n),
+		Absorption:     make(map[string]float64, len(absorbing)),
+	}
+	for i, id := range transient {
+		analysis.ExpectedVisits[id] = visits[i]
+	}
+	for _, id := range absorbing {
+		analysis.Absorption[id] = 0
+		if isStart[id] {
+			analysis.Absorption[id] += 1 / float64(len(start))
+		}
+	}
+	for _, id := range transient {
+		for target, p := range distributions[id] {
+			if _, isAbsorbing := analysis.Absorption[target]; isAbsorbing {
+				analysis.Absorption[target] += analysis.ExpectedVisits[id] * p
+			}
+		}
+	}
+	return analysis, nil
+}
+
+// transitionDistribution returns the probability of moving to each target
+// along the given transitions leaving one vertex
+func transitionDistribution(transitions []*Transition) map[string]float64 {
+	weights := make(map[string]float64)
+	for i, p := range transitionProbabilities(transitions) {
+		weights[transitions[i].Target.ID] += p
+	}
+	return weights
+}
+
+// transitionProbabilities returns the probability of taking each of the
+// given transitions leaving one vertex. Annotated probabilities are used as
+// given, unannotated transitions share the remaining probability equally,
+// and the result is normalized to sum to 1.
+func transitionProbabilities(transitions []*Transition) []float64 {
+	annotated, unannotated := 0.0, 0
+	for _, transition := range transitions {
+		if transition.Probability != nil {
+			annotated += *transition.Probability
+		} else {
+			unannotated++
+		}
+	}
+	share := 0.0
+	if unannotated > 0 && annotated < 1 {
+		share = (1 - annotated) / float64(unannotated)
+	}
+
+	probabilities := make([]float64, len(transitions))
+	total := 0.0
+	for i, transition := range transitions {
+		probabilities[i] = share
+		if transition.Probability != nil {
+			probabilities[i] = *transition.Probability
+		}
+		total += probabilities[i]
+	}
+	for i := range probabilities {
+		if total <= 0 {
+			// Every alternative is annotated with zero; treat them as equally likely
+			probabilities[i] = 1 / float64(len(transitions))
+		} else {
+			probabilities[i] /= total
+		}
+	}
+	return probabilities
+}
+
+// solveLinearSystem solves the n×(n+1) augmented matrix by Gaussian
+// elimination with partial pivoting
+func solveLinearSystem(matrix [][]float64) ([]float64, error) {
+	n := len(matrix)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(matrix[row][col]) > math.Abs(matrix[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(matrix[pivot][col]) < probabilityTolerance {
+			return nil, fmt.Errorf("the chain contains a closed loop without an exit")
+		}
+		matrix[col], matrix[pivot] = matrix[pivot], matrix[col]
+		for row := 0; row < n; row++ {
+			if row == col || matrix[row][col] == 0 {
+				continue
+			}
+			factor := matrix[row][col] / matrix[col][col]
+			for k := col; k <= n; k++ {
+				matrix[row][k] -= factor * matrix[col][k]
+			}
+		}
+	}
+
+	solution := make([]float64, n)
+	for i := range solution {
+		solution[i] = matrix[i][n] / matrix[i][i]
+	}
+	return solution, nil
+}
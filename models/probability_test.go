@@ -0,0 +1,147 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// newRetryMachine builds a machine that processes a job and either finishes,
+// fails or retries: initial -> work; work -> done (p), work -> failed (q),
+// work -> work (the remainder)
+func newRetryMachine(done, failed *float64) *StateMachine {
+	initial := &Vertex{ID: "initial", Name: "Initial", Type: "pseudostate"}
+	work := &Vertex{ID: "work", Name: "Work", Type: "state"}
+	doneVertex := &Vertex{ID: "done", Name: "Done", Type: "finalstate"}
+	failedVertex := &Vertex{ID: "failed", Name: "Failed", Type: "finalstate"}
+	return &StateMachine{
+		ID:      "retry",
+		Name:    "Retry",
+		Version: "1.0",
+		Regions: []*Region{{
+			ID:       "main",
+			Name:     "Main",
+			States:   []*State{{Vertex: *work, IsSimple: true}},
+			Vertices: []*Vertex{initial, doneVertex, failedVertex},
+			Transitions: []*Transition{
+				{ID: "start", Source: initial, Target: work, Kind: TransitionKindExternal},
+				{ID: "finish", Source: work, Target: doneVertex, Kind: TransitionKindExternal, Probability: done},
+				{ID: "fail", Source: work, Target: failedVertex, Kind: TransitionKindExternal, Probability: failed},
+				{ID: "retry", Source: work, Target: work, Kind: TransitionKindExternal},
+			},
+		}},
+	}
+}
+
+func probability(p float64) *float64 {
+	return &p
+}
+
+func TestTransition_ValidateProbability(t *testing.T) {
+	tests := []struct {
+		name        string
+		probability *float64
+		wantErr     bool
+	}{
+		{"unannotated", nil, false},
+		{"zero", probability(0), false},
+		{"one", probability(1), false},
+		{"negative", probability(-0.1), true},
+		{"above one", probability(1.5), true},
+		{"not a number", probability(math.NaN()), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition := &Transition{ID: "t", Probability: tt.probability}
+			errors := &ValidationErrors{}
+			transition.validateProbability(NewValidationContext(), errors)
+			if errors.HasErrors() != tt.wantErr {
+				t.Errorf("validateProbability() errors = %v, wantErr %v", errors.Errors, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ValidateProbabilityGroups(t *testing.T) {
+	t.Run("alternatives within one", func(t *testing.T) {
+		errors := &ValidationErrors{}
+		newRetryMachine(probability(0.5), probability(0.5)).validateProbabilityGroups(NewValidationContext(), errors)
+		if errors.HasErrors() {
+			t.Errorf("validateProbabilityGroups() errors = %v", errors.Errors)
+		}
+	})
+
+	t.Run("alternatives over one", func(t *testing.T) {
+		errors := &ValidationErrors{}
+		newRetryMachine(probability(0.7), probability(0.4)).validateProbabilityGroups(NewValidationContext(), errors)
+		want := "probabilities of transitions finish, fail leaving 'work' without triggers sum to 1.1, which exceeds 1"
+		if len(errors.Errors) != 1 || errors.Errors[0].Message != want {
+			t.Errorf("validateProbabilityGroups() errors = %v, want %q", errors.Errors, want)
+		}
+	})
+
+	t.Run("different triggers form separate groups", func(t *testing.T) {
+		sm := newRetryMachine(probability(0.7), probability(0.4))
+		sm.Regions[0].Transitions[2].Triggers = []*Trigger{{ID: "trig", Name: "Abort", Event: &Event{ID: "abort", Name: "Abort", Type: EventTypeSignal}}}
+		errors := &ValidationErrors{}
+		sm.validateProbabilityGroups(NewValidationContext(), errors)
+		if errors.HasErrors() {
+			t.Errorf("validateProbabilityGroups() errors = %v", errors.Errors)
+		}
+	})
+}
+
+func TestAnalyzeMarkov(t *testing.T) {
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+
+	t.Run("retry loop", func(t *testing.T) {
+		// work finishes with 0.6, fails with 0.2 and retries with the remaining 0.2
+		analysis, err := AnalyzeMarkov(newRetryMachine(probability(0.6), probability(0.2)))
+		if err != nil {
+			t.Fatalf("AnalyzeMarkov() error = %v", err)
+		}
+		if got := analysis.ExpectedVisits["work"]; !near(got, 1.25) {
+			t.Errorf("ExpectedVisits[work] = %g, want 1.25", got)
+		}
+		if got := analysis.ExpectedVisits["initial"]; !near(got, 1) {
+			t.Errorf("ExpectedVisits[initial] = %g, want 1", got)
+		}
+		if got := analysis.Absorption["done"]; !near(got, 0.75) {
+			t.Errorf("Absorption[done] = %g, want 0.75", got)
+		}
+		if got := analysis.Absorption["failed"]; !near(got, 0.25) {
+			t.Errorf("Absorption[failed] = %g, want 0.25", got)
+		}
+	})
+
+	t.Run("unannotated transitions are equally likely", func(t *testing.T) {
+		analysis, err := AnalyzeMarkov(newRetryMachine(nil, nil))
+		if err != nil {
+			t.Fatalf("AnalyzeMarkov() error = %v", err)
+		}
+		if got := analysis.Absorption["done"]; !near(got, 0.5) {
+			t.Errorf("Absorption[done] = %g, want 0.5", got)
+		}
+		if got := analysis.ExpectedVisits["work"]; !near(got, 1.5) {
+			t.Errorf("ExpectedVisits[work] = %g, want 1.5", got)
+		}
+	})
+
+	t.Run("closed loop never comes to rest", func(t *testing.T) {
+		// Finishing and failing are annotated as impossible, so work retries forever
+		sm := newRetryMachine(probability(0), probability(0))
+		if _, err := AnalyzeMarkov(sm); err == nil || !strings.Contains(err.Error(), "never come to rest") {
+			t.Errorf("AnalyzeMarkov() error = %v, want a closed loop error", err)
+		}
+	})
+
+	t.Run("no initial pseudostate", func(t *testing.T) {
+		sm := newRetryMachine(nil, nil)
+		sm.Regions[0].Vertices = sm.Regions[0].Vertices[1:]
+		sm.Regions[0].Transitions = sm.Regions[0].Transitions[1:]
+		if _, err := AnalyzeMarkov(sm); err == nil {
+			t.Error("AnalyzeMarkov() error = nil, want an error")
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationProfile selects the policies applied by policy-based validation
+// rules. Profiles are attached to a ValidationContext with WithProfile; a
+// context without a profile uses DefaultProfile.
+type ValidationProfile struct {
+	Name               string
+	IDPolicy           IDPolicy               // Policy applied to the IDs of all element types; nil disables ID format checks
+	NamePolicy         *NamePolicy            // Policy applied to element names; nil disables name checks
+	NamePolicies       map[string]*NamePolicy // Per-element overrides of NamePolicy keyed by object name, e.g. "State" or "Event"
+	NameKeywords       NameKeywords           // Words expected in the names of final states and pseudostates; nil disables keyword checks
+	Limits             *ResourceLimits        // Size limits checked before a state machine is validated; nil disables them
+	Timestamps         *TimestampPolicy       // Sanity checks of the state machine's CreatedAt; nil disables them
+	Versions           *VersionPolicy         // Accepted spellings of the state machine's semantic version; nil disables version format checks
+	Compatibility      CompatibilityProfile   // Target platform whose supported UML features the state machine must stay within; nil disables the check
+	Entities           EntityResolver         // Store the paths of the state machine's entities must exist in; nil disables the check
+	EntityPlaceholders *regexp.Regexp         // Pattern of entity placeholders in behavior and constraint specifications that must resolve in the state machine's entities, e.g. DefaultEntityPlaceholder; nil disables the check
+	Policies           []ModelPolicy          // Organizational modeling standards evaluated after the built-in rules and reported as ErrorTypePolicy
+}
+
+// Built-in validation profiles
+var (
+	// DefaultProfile flags IDs containing characters that commonly break
+	// paths, file names and generated code
+	DefaultProfile = &ValidationProfile{
+		Name:     "default",
+		IDPolicy: SafeCharactersIDPolicy,
+	}
+
+	// StrictProfile requires slug-formatted IDs, a plausible CreatedAt and
+	// a MAJOR.MINOR.PATCH version
+	StrictProfile = &ValidationProfile{
+		Name:       "strict",
+		IDPolicy:   SlugIDPolicy,
+		Timestamps: DefaultTimestampPolicy,
+		Versions:   StrictVersionPolicy,
+	}
+
+	// LenientProfile performs no ID format checks
+	LenientProfile = &ValidationProfile{
+		Name: "lenient",
+	}
+)
+
+// IDPolicy checks the format of element IDs
+type IDPolicy interface {
+	// Name returns a short name for the policy used in error messages
+	Name() string
+
+	// Check returns an error describing why id violates the policy, or nil
+	Check(id string) error
+}
+
+// RegexIDPolicy accepts IDs that fully match a regular expression
+type RegexIDPolicy struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// NewRegexIDPolicy creates an ID policy that requires IDs to match pattern
+// in full. The pattern is anchored automatically.
+func NewRegexIDPolicy(name, pattern string) (*RegexIDPolicy, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID policy pattern: %w", err)
+	}
+	return &RegexIDPolicy{name: name, pattern: re}, nil
+}
+
+// MustRegexIDPolicy is like NewRegexIDPolicy but panics on an invalid pattern
+func MustRegexIDPolicy(name, pattern string) *RegexIDPolicy {
+	policy, err := NewRegexIDPolicy(name, pattern)
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+// Name returns the policy name
+func (p *RegexIDPolicy) Name() string {
+	return p.name
+}
+
+// Check returns an error if id does not match the policy's pattern
+func (p *RegexIDPolicy) Check(id string) error {
+	if !p.pattern.MatchString(id) {
+		return fmt.Errorf("does not match the required format %s", strings.TrimSuffix(strings.TrimPrefix(p.pattern.String(), "^(?:"), ")$"))
+	}
+	return nil
+}
+
+// Built-in ID policies
+var (
+	// UUIDIDPolicy requires canonical, hyphenated UUIDs
+	UUIDIDPolicy = MustRegexIDPolicy("uuid", `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+	// SlugIDPolicy requires lower-case letters and digits separated by single '-' or '_'
+	SlugIDPolicy = MustRegexIDPolicy("slug", `[a-z0-9]+(?:[-_][a-z0-9]+)*`)
+
+	// SafeCharactersIDPolicy rejects whitespace, path separators, quotes and
+	// other punctuation that may cause issues
+	SafeCharactersIDPolicy IDPolicy = safeCharactersIDPolicy{}
+)
+
+// safeCharactersIDPolicy is the permissive policy used by DefaultProfile
+type safeCharactersIDPolicy struct{}
+
+func (safeCharactersIDPolicy) Name() string {
+	return "safe-characters"
+}
+
+func (safeCharactersIDPolicy) Check(id string) error {
+	problematicChars := []string{" ", "\t", "\n", "\r", ".", "/", "\\", ":", ";", ",", "\"", "'"}
+	for _, char := range problematicChars {
+		if strings.Contains(id, char) {
+			return fmt.Errorf("contains potentially problematic character %q which may cause issues", char)
+		}
+	}
+	return nil
+}
+
+// WithProfile returns a new context that validates with the given profile
+func (vc *ValidationContext) WithProfile(profile *ValidationProfile) *ValidationContext {
+	if vc == nil {
+		vc = NewValidationContext()
+	}
+	newCtx := *vc
+	newCtx.Profile = profile
+	return &newCtx
+}
+
+// ActiveProfile returns the context's profile, or DefaultProfile if none is set
+func (vc *ValidationContext) ActiveProfile() *ValidationProfile {
+	if vc == nil || vc.Profile == nil {
+		return DefaultProfile
+	}
+	return vc.Profile
+}
+
+// ValidateID checks an element ID against the ID policy of the context's
+// profile. Empty IDs are left to ValidateRequired.
+func (vh *ValidationHelper) ValidateID(id, objectName string, context *ValidationContext, errors *ValidationErrors) {
+	policy := context.ActiveProfile().IDPolicy
+	if id == "" || policy == nil {
+		return
+	}
+	if err := policy.Check(id); err != nil {
+		errors.AddError(
+			ErrorTypeConstraint,
+			objectName,
+			"ID",
+			fmt.Sprintf("%s ID '%s' %s (ID policy: %s)", objectName, id, err, policy.Name()),
+			context.Path,
+		)
+	}
+}
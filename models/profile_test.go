@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIDPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  IDPolicy
+		id      string
+		wantErr bool
+	}{
+		{"uuid accepts uuid", UUIDIDPolicy, "123e4567-e89b-12d3-a456-426614174000", false},
+		{"uuid rejects slug", UUIDIDPolicy, "order-created", true},
+		{"slug accepts slug", SlugIDPolicy, "order_created-2", false},
+		{"slug rejects upper case", SlugIDPolicy, "OrderCreated", true},
+		{"slug rejects double separator", SlugIDPolicy, "order--created", true},
+		{"safe characters accepts camel case", SafeCharactersIDPolicy, "orderCreated", false},
+		{"safe characters rejects space", SafeCharactersIDPolicy, "order created", true},
+		{"regex policy", MustRegexIDPolicy("prefixed", `sm_[0-9]+`), "sm_12", false},
+		{"regex policy is anchored", MustRegexIDPolicy("prefixed", `sm_[0-9]+`), "xsm_12x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s.Check(%q) error = %v, wantErr %v", tt.policy.Name(), tt.id, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := NewRegexIDPolicy("broken", "("); err == nil {
+		t.Error("NewRegexIDPolicy() with an invalid pattern should fail")
+	}
+}
+
+func TestValidationProfile_IDPolicy(t *testing.T) {
+	newMachine := func() *StateMachine {
+		sm := createValidStateMachine()
+		sm.Regions[0].Transitions[1].ID = "State1ToState2"
+		sm.Regions[0].States[0].Entry.ID = "entry action"
+		return sm
+	}
+
+	tests := []struct {
+		name         string
+		profile      *ValidationProfile
+		wantContains []string
+		wantAbsent   []string
+	}{
+		{
+			name:         "default profile flags problematic characters on any element",
+			profile:      nil,
+			wantContains: []string{`Behavior ID 'entry action' contains potentially problematic character " " which may cause issues (ID policy: safe-characters)`},
+			wantAbsent:   []string{"State1ToState2"},
+		},
+		{
+			name:    "strict profile requires slugs",
+			profile: StrictProfile,
+			wantContains: []string{
+				"Transition ID 'State1ToState2' does not match the required format",
+				"Behavior ID 'entry action' does not match the required format",
+			},
+		},
+		{
+			name:       "lenient profile skips ID checks",
+			profile:    LenientProfile,
+			wantAbsent: []string{"ID policy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newMachine()
+			context := NewValidationContext()
+			if tt.profile != nil {
+				context = context.WithProfile(tt.profile)
+			}
+
+			err := sm.ValidateInContext(context)
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(message, want) {
+					t.Errorf("ValidateInContext() error = %v, want it to contain %q", message, want)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(message, absent) {
+					t.Errorf("ValidateInContext() error = %v, should not contain %q", message, absent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsNamespace prefixes the names of the metrics PrometheusMetrics
+// writes when no namespace is given
+const DefaultMetricsNamespace = "statemachine"
+
+// prometheusContentType is the content type of the Prometheus text
+// exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusMetrics exposes the validation summaries of many state machines
+// in the Prometheus text exposition format, for services that store and
+// validate machines and want fleet-level dashboards. Observe records each
+// summary returned by ValidateWithSummary; the latest summary of each
+// machine and profile gives the element counts, findings by severity and
+// duration gauges, and all summaries add to the validation counters.
+// PrometheusMetrics is safe for concurrent use and serves the metrics over
+// HTTP as an http.Handler.
+type PrometheusMetrics struct {
+	mu        sync.Mutex
+	namespace string
+	machines  map[metricsKey]*machineMetrics
+}
+
+// metricsKey identifies the series of a machine validated with a profile
+type metricsKey struct {
+	machine, profile string
+}
+
+// machineMetrics are the metrics of a machine validated with a profile
+type machineMetrics struct {
+	latest      ValidationSummary
+	validations int
+	duration    time.Duration // Total of all validations
+}
+
+// NewPrometheusMetrics creates an empty metrics set whose metric names start
+// with namespace, or DefaultMetricsNamespace when it is empty
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = DefaultMetricsNamespace
+	}
+	return &PrometheusMetrics{namespace: namespace, machines: make(map[metricsKey]*machineMetrics)}
+}
+
+// Observe records a validation summary; nil summaries are ignored
+func (m *PrometheusMetrics) Observe(summary *ValidationSummary) {
+	if summary == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := metricsKey{summary.StateMachineID, summary.Profile}
+	metrics := m.machines[key]
+	if metrics == nil {
+		metrics = &machineMetrics{}
+		m.machines[key] = metrics
+	}
+	metrics.latest = *summary
+	metrics.validations++
+	metrics.duration += summary.Duration
+}
+
+// Forget drops the series of the state machine with the given ID, e.g. when
+// it is deleted from the store
+func (m *PrometheusMetrics) Forget(stateMachineID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.machines {
+		if key.machine == stateMachineID {
+			delete(m.machines, key)
+		}
+	}
+}
+
+// Reset drops all series
+func (m *PrometheusMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.machines)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format, with
+// series ordered by state machine ID and profile
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	keys := make([]metricsKey, 0, len(m.machines))
+	snapshot := make(map[metricsKey]machineMetrics, len(m.machines))
+	for key, metrics := range m.machines {
+		keys = append(keys, key)
+		snapshot[key] = *metrics
+	}
+	m.mu.Unlock()
+	slices.SortFunc(keys, func(a, b metricsKey) int {
+		return cmp.Or(cmp.Compare(a.machine, b.machine), cmp.Compare(a.profile, b.profile))
+	})
+
+	counter := &countingWriter{w: w}
+	out := bufio.NewWriter(counter)
+	family := func(name, kind, help string, value func(key metricsKey, metrics machineMetrics) []prometheusSample) {
+		fmt.Fprintf(out, "# HELP %s_%s %s\n", m.namespace, name, help)
+		fmt.Fprintf(out, "# TYPE %s_%s %s\n", m.namespace, name, kind)
+		for _, key := range keys {
+			for _, sample := range value(key, snapshot[key]) {
+				labels := []string{"state_machine", key.machine, "profile", key.profile}
+				if sample.label != "" {
+					labels = append(labels, sample.label, sample.labelValue)
+				}
+				fmt.Fprintf(out, "%s_%s%s %s\n", m.namespace, name, prometheusLabels(labels), strconv.FormatFloat(sample.value, 'g', -1, 64))
+			}
+		}
+	}
+
+	family("elements", "gauge", "Elements of the state machine and its submachines by type, as of the latest validation.",
+		func(_ metricsKey, metrics machineMetrics) []prometheusSample {
+			c := metrics.latest.Elements
+			return []prometheusSample{
+				{"type", "state_machines", float64(c.StateMachines)},
+				{"type", "regions", float64(c.Regions)},
+				{"type", "states", float64(c.States)},
+				{"type", "pseudostates", float64(c.Pseudostates)},
+				{"type", "final_states", float64(c.FinalStates)},
+				{"type", "transitions", float64(c.Transitions)},
+				{"type", "triggers", float64(c.Triggers)},
+				{"type", "events", float64(c.Events)},
+				{"type", "behaviors", float64(c.Behaviors)},
+				{"type", "constraints", float64(c.Constraints)},
+			}
+		})
+	family("validation_findings", "gauge", "Findings of the latest validation by severity.",
+		func(_ metricsKey, metrics machineMetrics) []prometheusSample {
+			return []prometheusSample{
+				{"severity", string(SeverityError), float64(metrics.latest.Errors)},
+				{"severity", string(SeverityWarning), float64(metrics.latest.Warnings)},
+				{"severity", string(SeverityInfo), float64(metrics.latest.Infos)},
+			}
+		})
+	family("validation_skipped_rules", "gauge", "Rules the latest validation skipped because rules they depend on failed.",
+		func(_ metricsKey, metrics machineMetrics) []prometheusSample {
+			return []prometheusSample{{value: float64(len(metrics.latest.Skipped))}}
+		})
+	family("validation_duration_seconds", "gauge", "Duration of the latest validation.",
+		func(_ metricsKey, metrics machineMetrics) []prometheusSample {
+			return []prometheusSample{{value: metrics.latest.Duration.Seconds()}}
+		})
+	family("validations_total", "counter", "Validations observed.",
+		func(_ metricsKey, metrics machineMetrics) []prometheusSample {
+			return []prometheusSample{{value: float64(metrics.validations)}}
+		})
+	family("validation_seconds_total", "counter", "Total duration of the validations observed.",
+		func(_ metricsKey, metrics machineMetrics) []prometheusSample {
+			return []prometheusSample{{value: metrics.duration.Seconds()}}
+		})
+
+	err := out.Flush()
+	return counter.n, err
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format, so
+// the metrics set can be mounted as a scrape endpoint such as /metrics
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	_, _ = m.WriteTo(w)
+}
+
+// WritePrometheus writes the given validation summaries in the Prometheus
+// text exposition format with DefaultMetricsNamespace; see PrometheusMetrics
+func WritePrometheus(w io.Writer, summaries ...*ValidationSummary) error {
+	metrics := NewPrometheusMetrics("")
+	for _, summary := range summaries {
+		metrics.Observe(summary)
+	}
+	_, err := metrics.WriteTo(w)
+	return err
+}
+
+// prometheusSample is one value of a metric family, with an optional extra
+// label
+type prometheusSample struct {
+	label, labelValue string
+	value             float64
+}
+
+// prometheusLabels formats name/value pairs as a Prometheus label set
+func prometheusLabels(pairs []string) string {
+	labels := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, fmt.Sprintf("%s=\"%s\"", pairs[i], prometheusLabelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// prometheusLabelEscaper escapes label values as the exposition format
+// requires
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
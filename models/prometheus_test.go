@@ -0,0 +1,128 @@
+package models
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	summary := func(id string, errors int, duration time.Duration) *ValidationSummary {
+		return &ValidationSummary{
+			StateMachineID: id,
+			Profile:        "default",
+			Elements:       ElementCounts{StateMachines: 1, Regions: 1, States: 2, Transitions: 3},
+			Duration:       duration,
+			Errors:         errors,
+			Warnings:       1,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		observe   []*ValidationSummary
+		forget    string
+		want      []string
+		wantNotIn []string
+	}{
+		{
+			name:    "latest summary sets the gauges",
+			observe: []*ValidationSummary{summary("sm1", 4, time.Second), summary("sm1", 2, 500*time.Millisecond)},
+			want: []string{
+				"# TYPE statemachine_elements gauge",
+				`statemachine_elements{state_machine="sm1",profile="default",type="states"} 2`,
+				`statemachine_elements{state_machine="sm1",profile="default",type="transitions"} 3`,
+				`statemachine_validation_findings{state_machine="sm1",profile="default",severity="error"} 2`,
+				`statemachine_validation_findings{state_machine="sm1",profile="default",severity="warning"} 1`,
+				`statemachine_validation_findings{state_machine="sm1",profile="default",severity="info"} 0`,
+				`statemachine_validation_duration_seconds{state_machine="sm1",profile="default"} 0.5`,
+				"# TYPE statemachine_validations_total counter",
+				`statemachine_validations_total{state_machine="sm1",profile="default"} 2`,
+				`statemachine_validation_seconds_total{state_machine="sm1",profile="default"} 1.5`,
+			},
+		},
+		{
+			name:    "series are ordered by state machine",
+			observe: []*ValidationSummary{summary("b", 0, 0), summary("a", 0, 0), nil},
+			want:    []string{`statemachine_validations_total{state_machine="a",profile="default"} 1` + "\n" + `statemachine_validations_total{state_machine="b",profile="default"} 1`},
+		},
+		{
+			name:      "forgotten machines are dropped",
+			observe:   []*ValidationSummary{summary("a", 0, 0), summary("b", 0, 0)},
+			forget:    "a",
+			want:      []string{`state_machine="b"`},
+			wantNotIn: []string{`state_machine="a"`},
+		},
+		{
+			name:    "label values are escaped",
+			observe: []*ValidationSummary{summary("say \"hi\"\\\n", 0, 0)},
+			want:    []string{`state_machine="say \"hi\"\\\n"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewPrometheusMetrics("")
+			for _, s := range tt.observe {
+				metrics.Observe(s)
+			}
+			if tt.forget != "" {
+				metrics.Forget(tt.forget)
+			}
+			var buf bytes.Buffer
+			n, err := metrics.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo() = %d bytes, wrote %d", n, buf.Len())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+			for _, unwanted := range tt.wantNotIn {
+				if strings.Contains(buf.String(), unwanted) {
+					t.Errorf("output contains %q:\n%s", unwanted, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestPrometheusMetrics_ServeHTTP(t *testing.T) {
+	metrics := NewPrometheusMetrics("fleet")
+	summary, _ := createValidStateMachine().ValidateWithSummary(nil)
+	metrics.Observe(summary)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, `fleet_elements{state_machine="sm1",profile="default",type="states"} 2`) {
+		t.Errorf("body missing the state count:\n%s", body)
+	}
+
+	metrics.Reset()
+	recorder = httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(recorder.Body.String(), "sm1") {
+		t.Errorf("Reset() kept series:\n%s", recorder.Body.String())
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, &ValidationSummary{StateMachineID: "sm", Profile: "strict", Errors: 3}); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	want := `statemachine_validation_findings{state_machine="sm",profile="strict",severity="error"} 3`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,359 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// UsageError is returned by refactoring operations that refuse to change an
+// element because it is still referenced elsewhere in the state machine
+type UsageError struct {
+	ElementID string
+	Usages    []Usage
+}
+
+// Error implements the error interface
+func (e *UsageError) Error() string {
+	lines := make([]string, len(e.Usages))
+	for i, usage := range e.Usages {
+		lines[i] = usage.String()
+	}
+	return fmt.Sprintf("element '%s' is still referenced %d time(s):\n  - %s", e.ElementID, len(e.Usages), strings.Join(lines, "\n  - "))
+}
+
+// RenameElement changes the ID and/or name of the element with the given ID
+// and updates every reference to it, including transition endpoints and
+// trigger event references. An empty newID or newName leaves that attribute
+// unchanged. The rename is first applied to a copy and refused if it would
+// introduce validation errors, in which case sm is left untouched.
+func RenameElement(sm *StateMachine, id, newID, newName string) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	if id == "" {
+		return fmt.Errorf("element ID cannot be empty")
+	}
+	if newID == "" && newName == "" {
+		return fmt.Errorf("rename of '%s' requires a new ID or a new name", id)
+	}
+	if !elementExists(sm, id) {
+		return fmt.Errorf("element '%s' not found", id)
+	}
+	if newID != "" && newID != id && elementExists(sm, newID) {
+		return fmt.Errorf("cannot rename '%s': ID '%s' is already in use", id, newID)
+	}
+
+	apply := func(target *StateMachine) {
+		walkElements(target, func(_ string, elementID, elementName *string) {
+			if *elementID != id {
+				return
+			}
+			if newName != "" && elementName != nil {
+				*elementName = newName
+			}
+			if newID != "" {
+				*elementID = newID
+			}
+		})
+		if newID != "" {
+			walkTransitions(target.Regions, func(transition *Transition) {
+				for _, trigger := range transition.Triggers {
+					if trigger != nil && trigger.EventID == id {
+						trigger.EventID = newID
+					}
+				}
+			})
+		}
+	}
+
+	if err := checkRefactoring(sm, apply); err != nil {
+		return fmt.Errorf("cannot rename '%s': %w", id, err)
+	}
+	apply(sm)
+	sm.refreshAdjacency()
+	return nil
+}
+
+// DeleteElement removes the element with the given ID from the state
+// machine. Deleting a state or region also deletes everything it contains.
+// When the element (or anything it contains) is still referenced from
+// outside, DeleteElement returns a *UsageError unless cascade is true, in
+// which case the referencing transitions, triggers, behaviors and guards are
+// removed as well. The deletion is refused if it would introduce validation
+// errors, in which case sm is left untouched.
+func DeleteElement(sm *StateMachine, id string, cascade bool) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	if id == "" {
+		return fmt.Errorf("element ID cannot be empty")
+	}
+	if id == sm.ID {
+		return fmt.Errorf("cannot delete the state machine itself")
+	}
+	if !elementExists(sm, id) {
+		return fmt.Errorf("element '%s' not found", id)
+	}
+
+	deleted := collectDeletedIDs(sm, id)
+
+	var external []Usage
+	for _, deletedID := range slices.Sorted(maps.Keys(deleted)) {
+		for _, usage := range Usages(sm, deletedID) {
+			if !deleted[usage.OwnerID] {
+				external = append(external, usage)
+			}
+		}
+	}
+	if len(external) > 0 && !cascade {
+		return &UsageError{ElementID: id, Usages: external}
+	}
+
+	apply := func(target *StateMachine) {
+		deleteElements(target, deleted)
+	}
+	if err := checkRefactoring(sm, apply); err != nil {
+		return fmt.Errorf("cannot delete '%s': %w", id, err)
+	}
+	apply(sm)
+	sm.refreshAdjacency()
+	return nil
+}
+
+// checkRefactoring applies the operation to a copy of sm and reports an error
+// if the copy has validation errors the original does not, compared by
+// type, object, field, message and path. Errors the operation resolves do
+// not make up for errors it introduces.
+func checkRefactoring(sm *StateMachine, apply func(*StateMachine)) error {
+	before := collectValidationErrors(sm)
+
+	candidate := sm.Clone()
+	apply(candidate)
+
+	after := collectValidationErrors(candidate)
+	if introduced := subtractErrors(after.Errors, before.Errors); len(introduced) > 0 {
+		return fmt.Errorf("result would not validate: %w", &ValidationErrors{Errors: introduced})
+	}
+	return nil
+}
+
+// elementExists reports whether any element in sm has the given ID
+func elementExists(sm *StateMachine, id string) bool {
+	found := false
+	walkElements(sm, func(_ string, elementID, _ *string) {
+		if *elementID == id {
+			found = true
+		}
+	})
+	return found
+}
+
+// walkElements calls fn with the ID and name fields of every identified
+// element owned by sm, including the copies held as transition endpoints.
+// name is nil for elements without a name. Submachines are separate models
+// and are not descended into.
+func walkElements(sm *StateMachine, fn func(kind string, id, name *string)) {
+	if sm == nil {
+		return
+	}
+
+	fn("StateMachine", &sm.ID, &sm.Name)
+	for _, cp := range sm.ConnectionPoints {
+		if cp != nil {
+			fn("Pseudostate", &cp.ID, &cp.Name)
+		}
+	}
+	for _, event := range sm.Events {
+		if event != nil {
+			fn("Event", &event.ID, &event.Name)
+		}
+	}
+
+	behavior := func(b *Behavior) {
+		if b != nil {
+			fn("Behavior", &b.ID, &b.Name)
+		}
+	}
+
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		fn("Region", &region.ID, &region.Name)
+
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			fn("State", &state.ID, &state.Name)
+			behavior(state.Entry)
+			behavior(state.Exit)
+			behavior(state.DoActivity)
+			for _, connection := range state.Connections {
+				if connection == nil {
+					continue
+				}
+				fn("ConnectionPointReference", &connection.ID, &connection.Name)
+				for _, ps := range slices.Concat(connection.Entry, connection.Exit) {
+					if ps != nil {
+						fn("Pseudostate", &ps.ID, &ps.Name)
+					}
+				}
+			}
+		}
+
+		for _, vertex := range region.Vertices {
+			if vertex != nil {
+				fn("Vertex", &vertex.ID, &vertex.Name)
+			}
+		}
+
+		for _, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			fn("Transition", &transition.ID, &transition.Name)
+			if transition.Source != nil {
+				fn("Vertex", &transition.Source.ID, &transition.Source.Name)
+			}
+			if transition.Target != nil {
+				fn("Vertex", &transition.Target.ID, &transition.Target.Name)
+			}
+			if transition.Guard != nil {
+				fn("Constraint", &transition.Guard.ID, &transition.Guard.Name)
+			}
+			behavior(transition.Effect)
+			for _, trigger := range transition.Triggers {
+				if trigger == nil {
+					continue
+				}
+				fn("Trigger", &trigger.ID, &trigger.Name)
+				if trigger.Event != nil {
+					fn("Event", &trigger.Event.ID, &trigger.Event.Name)
+				}
+			}
+		}
+	})
+}
+
+// collectDeletedIDs returns the ID of the element together with the IDs of
+// everything contained in it when it is a state or region
+func collectDeletedIDs(sm *StateMachine, id string) map[string]bool {
+	deleted := map[string]bool{id: true}
+
+	var collectRegions func(regions []*Region)
+	collectRegions = func(regions []*Region) {
+		walkRegionTree(regions, "", func(region *Region, _ string) {
+			deleted[region.ID] = true
+			for _, state := range region.States {
+				if state != nil {
+					deleted[state.ID] = true
+				}
+			}
+			for _, vertex := range region.Vertices {
+				if vertex != nil {
+					deleted[vertex.ID] = true
+				}
+			}
+			for _, transition := range region.Transitions {
+				if transition != nil {
+					deleted[transition.ID] = true
+				}
+			}
+		})
+	}
+
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		if region.ID == id {
+			collectRegions([]*Region{region})
+		}
+		for _, state := range region.States {
+			if state != nil && state.ID == id {
+				collectRegions(state.Regions)
+			}
+		}
+	})
+	return deleted
+}
+
+// deleteElements removes every element whose ID is in deleted, together with
+// all references to those IDs
+func deleteElements(sm *StateMachine, deleted map[string]bool) {
+	isDeleted := func(id string) bool { return deleted[id] }
+
+	sm.Regions = removeRegions(sm.Regions, deleted)
+	sm.ConnectionPoints = slices.DeleteFunc(sm.ConnectionPoints, func(cp *Pseudostate) bool {
+		return cp != nil && isDeleted(cp.ID)
+	})
+	sm.Events = slices.DeleteFunc(sm.Events, func(event *Event) bool {
+		return event != nil && isDeleted(event.ID)
+	})
+
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		region.States = slices.DeleteFunc(region.States, func(state *State) bool {
+			return state != nil && isDeleted(state.ID)
+		})
+		region.Vertices = slices.DeleteFunc(region.Vertices, func(vertex *Vertex) bool {
+			return vertex != nil && isDeleted(vertex.ID)
+		})
+		region.Transitions = slices.DeleteFunc(region.Transitions, func(transition *Transition) bool {
+			if transition == nil {
+				return false
+			}
+			return isDeleted(transition.ID) ||
+				(transition.Source != nil && isDeleted(transition.Source.ID)) ||
+				(transition.Target != nil && isDeleted(transition.Target.ID))
+		})
+
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			state.Regions = removeRegions(state.Regions, deleted)
+			if state.Entry != nil && isDeleted(state.Entry.ID) {
+				state.Entry = nil
+			}
+			if state.Exit != nil && isDeleted(state.Exit.ID) {
+				state.Exit = nil
+			}
+			if state.DoActivity != nil && isDeleted(state.DoActivity.ID) {
+				state.DoActivity = nil
+			}
+			if state.Submachine != nil && isDeleted(state.Submachine.ID) {
+				state.Submachine = nil
+			}
+			state.Connections = slices.DeleteFunc(state.Connections, func(connection *ConnectionPointReference) bool {
+				return connection != nil && isDeleted(connection.ID)
+			})
+			for _, connection := range state.Connections {
+				if connection == nil {
+					continue
+				}
+				removePseudostate := func(ps *Pseudostate) bool { return ps != nil && isDeleted(ps.ID) }
+				connection.Entry = slices.DeleteFunc(connection.Entry, removePseudostate)
+				connection.Exit = slices.DeleteFunc(connection.Exit, removePseudostate)
+			}
+		}
+
+		for _, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			if transition.Guard != nil && isDeleted(transition.Guard.ID) {
+				transition.Guard = nil
+			}
+			if transition.Effect != nil && isDeleted(transition.Effect.ID) {
+				transition.Effect = nil
+			}
+			transition.Triggers = slices.DeleteFunc(transition.Triggers, func(trigger *Trigger) bool {
+				return trigger != nil && (isDeleted(trigger.ID) || isDeleted(trigger.EventKey()))
+			})
+		}
+	})
+}
+
+// removeRegions drops the regions whose IDs are in deleted
+func removeRegions(regions []*Region, deleted map[string]bool) []*Region {
+	return slices.DeleteFunc(regions, func(region *Region) bool {
+		return region != nil && deleted[region.ID]
+	})
+}
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRenameElement(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		newID       string
+		newName     string
+		wantErr     bool
+		errContains string
+		check       func(t *testing.T, sm *StateMachine)
+	}{
+		{
+			name:    "rename state ID and name",
+			id:      "state1",
+			newID:   "idle",
+			newName: "Idle",
+			check: func(t *testing.T, sm *StateMachine) {
+				region := sm.Regions[0]
+				if region.States[0].ID != "idle" || region.States[0].Name != "Idle" {
+					t.Errorf("state not renamed: %v", region.States[0])
+				}
+				if region.Transitions[0].Target.ID != "idle" || region.Transitions[1].Source.ID != "idle" {
+					t.Error("transition endpoints not updated")
+				}
+				if len(Usages(sm, "state1")) != 0 {
+					t.Error("old ID should no longer be referenced")
+				}
+			},
+		},
+		{
+			name:    "rename catalog event updates trigger references",
+			id:      "go",
+			newID:   "start",
+			newName: "",
+			check: func(t *testing.T, sm *StateMachine) {
+				if sm.Events[0].ID != "start" || sm.Regions[0].Transitions[1].Triggers[0].EventID != "start" {
+					t.Error("event reference not updated")
+				}
+			},
+		},
+		{
+			name:        "new ID already in use",
+			id:          "state1",
+			newID:       "state2",
+			wantErr:     true,
+			errContains: "ID 'state2' is already in use",
+		},
+		{
+			name:        "unknown element",
+			id:          "missing",
+			newName:     "Missing",
+			wantErr:     true,
+			errContains: "element 'missing' not found",
+		},
+		{
+			name:        "nothing to rename",
+			id:          "state1",
+			wantErr:     true,
+			errContains: "requires a new ID or a new name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			sm.Events = []*Event{{ID: "go", Name: "Go", Type: EventTypeSignal}}
+			sm.Regions[0].Transitions[1].Triggers = []*Trigger{{ID: "tr_go", Name: "Go", EventID: "go"}}
+
+			err := RenameElement(sm, tt.id, tt.newID, tt.newName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenameElement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("RenameElement() error = %v, want it to contain %q", err, tt.errContains)
+			}
+			if tt.check != nil {
+				tt.check(t, sm)
+			}
+		})
+	}
+}
+
+func TestDeleteElement(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		cascade    bool
+		wantUsages int
+		wantErr    bool
+		check      func(t *testing.T, sm *StateMachine)
+	}{
+		{
+			name:       "referenced state without cascade",
+			id:         "state2",
+			cascade:    false,
+			wantUsages: 2,
+			wantErr:    true,
+			check: func(t *testing.T, sm *StateMachine) {
+				if len(sm.Regions[0].States) != 2 {
+					t.Error("refused delete should leave the model unchanged")
+				}
+			},
+		},
+		{
+			name:    "unreferenced transition",
+			id:      "t2",
+			cascade: false,
+			check: func(t *testing.T, sm *StateMachine) {
+				if len(sm.Regions[0].Transitions) != 2 {
+					t.Errorf("expected 2 transitions, got %d", len(sm.Regions[0].Transitions))
+				}
+			},
+		},
+		{
+			name:       "behavior without cascade",
+			id:         "entry1",
+			cascade:    false,
+			wantUsages: 1,
+			wantErr:    true,
+		},
+		{
+			name:    "behavior with cascade",
+			id:      "entry1",
+			cascade: true,
+			check: func(t *testing.T, sm *StateMachine) {
+				if sm.Regions[0].States[0].Entry != nil {
+					t.Error("cascade should clear the entry behavior")
+				}
+			},
+		},
+		{
+			name:    "delete that breaks validation is refused",
+			id:      "region1",
+			cascade: true,
+			wantErr: true,
+			check: func(t *testing.T, sm *StateMachine) {
+				if len(sm.Regions) != 1 {
+					t.Error("refused delete should leave the model unchanged")
+				}
+			},
+		},
+		{
+			name:    "catalog event with cascade removes triggers",
+			id:      "go",
+			cascade: true,
+			check: func(t *testing.T, sm *StateMachine) {
+				if len(sm.Events) != 0 || len(sm.Regions[0].Transitions[1].Triggers) != 0 {
+					t.Error("cascade should remove the event and its triggers")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			sm.Events = []*Event{{ID: "go", Name: "Go", Type: EventTypeSignal}}
+			sm.Regions[0].Transitions[1].Triggers = []*Trigger{{ID: "tr_go", Name: "Go", EventID: "go"}}
+
+			err := DeleteElement(sm, tt.id, tt.cascade)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteElement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantUsages > 0 {
+				var usageErr *UsageError
+				if !errors.As(err, &usageErr) {
+					t.Fatalf("DeleteElement() error = %v, want *UsageError", err)
+				}
+				if len(usageErr.Usages) != tt.wantUsages {
+					t.Errorf("UsageError has %d usages, want %d", len(usageErr.Usages), tt.wantUsages)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, sm)
+			}
+		})
+	}
+
+	t.Run("composite state with cascade", func(t *testing.T) {
+		sm := createValidStateMachine()
+		inner := &State{Vertex: Vertex{ID: "inner", Name: "Inner", Type: "state"}, IsSimple: true}
+		composite := sm.Regions[0].States[1]
+		composite.IsSimple = false
+		composite.IsComposite = true
+		composite.Regions = []*Region{{ID: "nested", Name: "Nested", States: []*State{inner}}}
+
+		var usageErr *UsageError
+		if err := DeleteElement(sm, "state2", false); !errors.As(err, &usageErr) {
+			t.Fatalf("DeleteElement() error = %v, want *UsageError", err)
+		}
+
+		if err := DeleteElement(sm, "state2", true); err != nil {
+			t.Fatalf("DeleteElement() with cascade error = %v", err)
+		}
+		if elementExists(sm, "inner") || elementExists(sm, "nested") || elementExists(sm, "t2") {
+			t.Error("cascade delete should remove nested content and referencing transitions")
+		}
+	})
+}
+
+func TestCheckRefactoring(t *testing.T) {
+	// newMachine returns the valid test machine whose first state has an
+	// entry behavior without a specification
+	newMachine := func() *StateMachine {
+		sm := createValidStateMachine()
+		sm.Regions[0].States[0].Entry.Specification = ""
+		return sm
+	}
+
+	tests := []struct {
+		name    string
+		apply   func(sm *StateMachine)
+		wantErr string
+	}{
+		{
+			name:  "existing errors are kept",
+			apply: func(sm *StateMachine) { sm.Version = "2.0" },
+		},
+		{
+			name:  "existing errors are resolved",
+			apply: func(sm *StateMachine) { sm.Regions[0].States[0].Entry.Specification = "start()" },
+		},
+		{
+			name: "resolved errors do not make up for introduced ones",
+			apply: func(sm *StateMachine) {
+				state := sm.Regions[0].States[0]
+				state.Entry, state.Exit = nil, state.Entry
+			},
+			wantErr: "exit behavior must have a valid specification",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newMachine()
+			err := checkRefactoring(sm, tt.apply)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkRefactoring() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || strings.Contains(err.Error(), "entry behavior") {
+				t.Errorf("checkRefactoring() error = %v, want only the introduced errors", err)
+			}
+
+			candidate := newMachine()
+			tt.apply(candidate)
+			if before, after := collectValidationErrors(sm).Count(), collectValidationErrors(candidate).Count(); before != after {
+				t.Errorf("the edit changes the error count from %d to %d; the case needs equal counts", before, after)
+			}
+		})
+	}
+}
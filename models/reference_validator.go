@@ -1,12 +1,23 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 )
 
-// ReferenceValidator provides structural integrity validation for UML state machine models
+// ErrValidatorInUse is returned by a ReferenceValidator that is called while
+// another goroutine is validating with it
+var ErrValidatorInUse = errors.New("reference validator is already in use")
+
+// ReferenceValidator provides structural integrity validation for UML state
+// machine models. It accumulates its reference maps and errors while
+// validating, so each goroutine needs its own instance; calls that overlap
+// another validation fail with ErrValidatorInUse. StateMachine.Validate
+// creates a fresh instance per call and is safe for concurrent use.
 type ReferenceValidator struct {
+	inUse             atomic.Bool
 	visited           map[string]bool
 	errors            *ValidationErrors
 	context           *ValidationContext
@@ -14,6 +25,19 @@ type ReferenceValidator struct {
 	bidirectionalRefs map[string][]string    // Maps object IDs to their bidirectional references
 	containmentTree   map[string][]string    // Maps parent IDs to child IDs
 	inheritanceTree   map[string]string      // Maps child IDs to parent IDs
+	pending           []referenceTask        // Children queued by the object being visited
+	loaded            map[*Region]*Region    // Transient copies of lazy regions holding their content
+
+	// MaxDepth is the deepest object nesting visited by the reference
+	// passes; deeper objects are reported and skipped. Zero means DefaultMaxDepth.
+	MaxDepth int
+}
+
+// referenceTask is an object waiting to be visited by a reference pass
+type referenceTask struct {
+	obj     interface{}
+	context *ValidationContext
+	depth   int
 }
 
 // NewReferenceValidator creates a new reference validator
@@ -25,11 +49,17 @@ func NewReferenceValidator() *ReferenceValidator {
 		bidirectionalRefs: make(map[string][]string),
 		containmentTree:   make(map[string][]string),
 		inheritanceTree:   make(map[string]string),
+		loaded:            make(map[*Region]*Region),
 	}
 }
 
 // ValidateReferences performs comprehensive reference validation on a state machine
 func (rv *ReferenceValidator) ValidateReferences(obj interface{}) error {
+	if !rv.inUse.CompareAndSwap(false, true) {
+		return ErrValidatorInUse
+	}
+	defer rv.inUse.Store(false)
+
 	rv.context = NewValidationContext()
 
 	// First pass: build reference maps
@@ -46,6 +76,11 @@ func (rv *ReferenceValidator) ValidateReferences(obj interface{}) error {
 
 // ValidateReferencesInContext performs reference validation with provided context
 func (rv *ReferenceValidator) ValidateReferencesInContext(obj interface{}, context *ValidationContext) error {
+	if !rv.inUse.CompareAndSwap(false, true) {
+		return ErrValidatorInUse
+	}
+	defer rv.inUse.Store(false)
+
 	rv.context = context
 	if rv.context == nil {
 		rv.context = NewValidationContext()
@@ -65,6 +100,12 @@ func (rv *ReferenceValidator) ValidateReferencesInContext(obj interface{}, conte
 
 // buildReferenceMaps builds internal maps of object references for validation
 func (rv *ReferenceValidator) buildReferenceMaps(obj interface{}, context *ValidationContext) {
+	rv.walk(obj, context, rv.buildObjectReferences)
+}
+
+// buildObjectReferences records a single object in the reference maps and
+// queues its children
+func (rv *ReferenceValidator) buildObjectReferences(obj interface{}, context *ValidationContext) {
 	if obj == nil {
 		return
 	}
@@ -117,6 +158,65 @@ func (rv *ReferenceValidator) buildReferenceMaps(obj interface{}, context *Valid
 	}
 }
 
+// walk visits obj and everything it contains depth-first in pre-order. Each
+// visit queues the object's children with queue; they are kept on an explicit
+// stack rather than visited recursively, and objects nested deeper than
+// MaxDepth are reported instead of visited.
+func (rv *ReferenceValidator) walk(obj interface{}, context *ValidationContext, visit func(obj interface{}, context *ValidationContext)) {
+	maxDepth := rv.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	stack := []referenceTask{{obj: obj, context: context}}
+	for len(stack) > 0 {
+		task := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if task.depth > maxDepth {
+			rv.errors.AddErrorWithContext(
+				ErrorTypeResourceLimit,
+				rv.getObjectTypeName(task.obj),
+				"Depth",
+				fmt.Sprintf("object nesting exceeds the maximum depth of %d; nested references were not checked (resource limit)", maxDepth),
+				task.context.Path,
+				map[string]interface{}{"limit": LimitDepth, "max": int64(maxDepth), "actual": int64(task.depth)},
+			)
+			continue
+		}
+
+		if region, ok := task.obj.(*Region); ok {
+			task.obj = rv.content(region)
+		}
+		rv.pending = nil
+		visit(task.obj, task.context)
+		for i := len(rv.pending) - 1; i >= 0; i-- {
+			child := rv.pending[i]
+			child.depth = task.depth + 1
+			stack = append(stack, child)
+		}
+		rv.pending = nil
+	}
+}
+
+// content returns the region with the content its provider supplies, loading
+// it once for both passes without storing it on the region
+func (rv *ReferenceValidator) content(region *Region) *Region {
+	if region == nil || region.Content == nil {
+		return region
+	}
+	if loaded, ok := rv.loaded[region]; ok {
+		return loaded
+	}
+	loaded := contentOf(region)
+	rv.loaded[region] = loaded
+	return loaded
+}
+
+// queue schedules a child of the object being visited by walk
+func (rv *ReferenceValidator) queue(obj interface{}, context *ValidationContext) {
+	rv.pending = append(rv.pending, referenceTask{obj: obj, context: context})
+}
+
 // buildStateMachineReferences builds reference maps for StateMachine
 func (rv *ReferenceValidator) buildStateMachineReferences(sm *StateMachine, context *ValidationContext) {
 	smContext := context.WithStateMachine(sm)
@@ -125,7 +225,7 @@ func (rv *ReferenceValidator) buildStateMachineReferences(sm *StateMachine, cont
 	for i, region := range sm.Regions {
 		if region != nil {
 			rv.containmentTree[sm.ID] = append(rv.containmentTree[sm.ID], region.ID)
-			rv.buildReferenceMaps(region, smContext.WithPathIndex("Regions", i))
+			rv.queue(region, smContext.WithPathIndex("Regions", i))
 		}
 	}
 
@@ -133,7 +233,7 @@ func (rv *ReferenceValidator) buildStateMachineReferences(sm *StateMachine, cont
 	for i, cp := range sm.ConnectionPoints {
 		if cp != nil {
 			rv.containmentTree[sm.ID] = append(rv.containmentTree[sm.ID], cp.ID)
-			rv.buildReferenceMaps(cp, smContext.WithPathIndex("ConnectionPoints", i))
+			rv.queue(cp, smContext.WithPathIndex("ConnectionPoints", i))
 		}
 	}
 }
@@ -146,7 +246,7 @@ func (rv *ReferenceValidator) buildRegionReferences(region *Region, context *Val
 	for i, state := range region.States {
 		if state != nil {
 			rv.containmentTree[region.ID] = append(rv.containmentTree[region.ID], state.ID)
-			rv.buildReferenceMaps(state, regionContext.WithPathIndex("States", i))
+			rv.queue(state, regionContext.WithPathIndex("States", i))
 		}
 	}
 
@@ -154,7 +254,7 @@ func (rv *ReferenceValidator) buildRegionReferences(region *Region, context *Val
 	for i, vertex := range region.Vertices {
 		if vertex != nil {
 			rv.containmentTree[region.ID] = append(rv.containmentTree[region.ID], vertex.ID)
-			rv.buildReferenceMaps(vertex, regionContext.WithPathIndex("Vertices", i))
+			rv.queue(vertex, regionContext.WithPathIndex("Vertices", i))
 		}
 	}
 
@@ -162,7 +262,7 @@ func (rv *ReferenceValidator) buildRegionReferences(region *Region, context *Val
 	for i, transition := range region.Transitions {
 		if transition != nil {
 			rv.containmentTree[region.ID] = append(rv.containmentTree[region.ID], transition.ID)
-			rv.buildReferenceMaps(transition, regionContext.WithPathIndex("Transitions", i))
+			rv.queue(transition, regionContext.WithPathIndex("Transitions", i))
 		}
 	}
 }
@@ -175,33 +275,33 @@ func (rv *ReferenceValidator) buildStateReferences(state *State, context *Valida
 	for i, region := range state.Regions {
 		if region != nil {
 			rv.containmentTree[state.ID] = append(rv.containmentTree[state.ID], region.ID)
-			rv.buildReferenceMaps(region, stateContext.WithPathIndex("Regions", i))
+			rv.queue(region, stateContext.WithPathIndex("Regions", i))
 		}
 	}
 
 	// Build references for submachine (inheritance-like relationship)
 	if state.Submachine != nil {
 		rv.inheritanceTree[state.ID] = state.Submachine.ID
-		rv.buildReferenceMaps(state.Submachine, stateContext.WithPath("Submachine"))
+		rv.queue(state.Submachine, stateContext.WithPath("Submachine"))
 	}
 
 	// Build references for connection point references
 	for i, conn := range state.Connections {
 		if conn != nil {
 			rv.containmentTree[state.ID] = append(rv.containmentTree[state.ID], conn.ID)
-			rv.buildReferenceMaps(conn, stateContext.WithPathIndex("Connections", i))
+			rv.queue(conn, stateContext.WithPathIndex("Connections", i))
 		}
 	}
 
 	// Build references for behaviors
 	if state.Entry != nil {
-		rv.buildReferenceMaps(state.Entry, stateContext.WithPath("Entry"))
+		rv.queue(state.Entry, stateContext.WithPath("Entry"))
 	}
 	if state.Exit != nil {
-		rv.buildReferenceMaps(state.Exit, stateContext.WithPath("Exit"))
+		rv.queue(state.Exit, stateContext.WithPath("Exit"))
 	}
 	if state.DoActivity != nil {
-		rv.buildReferenceMaps(state.DoActivity, stateContext.WithPath("DoActivity"))
+		rv.queue(state.DoActivity, stateContext.WithPath("DoActivity"))
 	}
 }
 
@@ -233,16 +333,16 @@ func (rv *ReferenceValidator) buildTransitionReferences(transition *Transition,
 	// Build references for triggers
 	for i, trigger := range transition.Triggers {
 		if trigger != nil {
-			rv.buildReferenceMaps(trigger, transitionContext.WithPathIndex("Triggers", i))
+			rv.queue(trigger, transitionContext.WithPathIndex("Triggers", i))
 		}
 	}
 
 	// Build references for guard and effect
 	if transition.Guard != nil {
-		rv.buildReferenceMaps(transition.Guard, transitionContext.WithPath("Guard"))
+		rv.queue(transition.Guard, transitionContext.WithPath("Guard"))
 	}
 	if transition.Effect != nil {
-		rv.buildReferenceMaps(transition.Effect, transitionContext.WithPath("Effect"))
+		rv.queue(transition.Effect, transitionContext.WithPath("Effect"))
 	}
 }
 
@@ -255,7 +355,7 @@ func (rv *ReferenceValidator) buildConnectionPointReferences(cpr *ConnectionPoin
 		if entry != nil {
 			rv.bidirectionalRefs[cpr.ID] = append(rv.bidirectionalRefs[cpr.ID], entry.ID)
 			rv.bidirectionalRefs[entry.ID] = append(rv.bidirectionalRefs[entry.ID], cpr.ID)
-			rv.buildReferenceMaps(entry, cprContext.WithPathIndex("Entry", i))
+			rv.queue(entry, cprContext.WithPathIndex("Entry", i))
 		}
 	}
 
@@ -263,13 +363,19 @@ func (rv *ReferenceValidator) buildConnectionPointReferences(cpr *ConnectionPoin
 		if exit != nil {
 			rv.bidirectionalRefs[cpr.ID] = append(rv.bidirectionalRefs[cpr.ID], exit.ID)
 			rv.bidirectionalRefs[exit.ID] = append(rv.bidirectionalRefs[exit.ID], cpr.ID)
-			rv.buildReferenceMaps(exit, cprContext.WithPathIndex("Exit", i))
+			rv.queue(exit, cprContext.WithPathIndex("Exit", i))
 		}
 	}
 }
 
-// validateObjectReferences validates individual object references
+// validateObjectReferences validates the references of obj and the objects it contains
 func (rv *ReferenceValidator) validateObjectReferences(obj interface{}, context *ValidationContext) {
+	rv.walk(obj, context, rv.validateSingleObjectReferences)
+}
+
+// validateSingleObjectReferences validates individual object references and
+// queues the object's children
+func (rv *ReferenceValidator) validateSingleObjectReferences(obj interface{}, context *ValidationContext) {
 	if obj == nil {
 		return
 	}
@@ -322,7 +428,7 @@ func (rv *ReferenceValidator) validateStateMachineReferences(sm *StateMachine, c
 			)
 		}
 
-		rv.validateObjectReferences(region, smContext.WithPathIndex("Regions", i))
+		rv.queue(region, smContext.WithPathIndex("Regions", i))
 	}
 
 	// Validate connection point references
@@ -349,7 +455,7 @@ func (rv *ReferenceValidator) validateStateMachineReferences(sm *StateMachine, c
 			)
 		}
 
-		rv.validateObjectReferences(cp, smContext.WithPathIndex("ConnectionPoints", i))
+		rv.queue(cp, smContext.WithPathIndex("ConnectionPoints", i))
 	}
 }
 
@@ -370,7 +476,7 @@ func (rv *ReferenceValidator) validateRegionReferences(region *Region, context *
 			continue
 		}
 
-		rv.validateObjectReferences(state, regionContext.WithPathIndex("States", i))
+		rv.queue(state, regionContext.WithPathIndex("States", i))
 	}
 
 	// Validate vertex references
@@ -386,7 +492,7 @@ func (rv *ReferenceValidator) validateRegionReferences(region *Region, context *
 			continue
 		}
 
-		rv.validateObjectReferences(vertex, regionContext.WithPathIndex("Vertices", i))
+		rv.queue(vertex, regionContext.WithPathIndex("Vertices", i))
 	}
 
 	// Validate transition references
@@ -402,7 +508,7 @@ func (rv *ReferenceValidator) validateRegionReferences(region *Region, context *
 			continue
 		}
 
-		rv.validateObjectReferences(transition, regionContext.WithPathIndex("Transitions", i))
+		rv.queue(transition, regionContext.WithPathIndex("Transitions", i))
 	}
 }
 
@@ -422,7 +528,11 @@ func (rv *ReferenceValidator) validateStateReferences(state *State, context *Val
 			)
 		}
 
-		rv.validateObjectReferences(state.Submachine, stateContext.WithPath("Submachine"))
+		// Submachines already on the validation chain are not entered again;
+		// StateMachine.validateSubmachineRecursion reports the cycle
+		if !stateContext.validatingMachine(state.Submachine) {
+			rv.queue(state.Submachine, stateContext.WithPath("Submachine").withMachine(state.Submachine))
+		}
 	}
 
 	// Validate region references in composite states
@@ -438,7 +548,7 @@ func (rv *ReferenceValidator) validateStateReferences(state *State, context *Val
 			continue
 		}
 
-		rv.validateObjectReferences(region, stateContext.WithPathIndex("Regions", i))
+		rv.queue(region, stateContext.WithPathIndex("Regions", i))
 	}
 
 	// Validate connection point references
@@ -454,7 +564,7 @@ func (rv *ReferenceValidator) validateStateReferences(state *State, context *Val
 			continue
 		}
 
-		rv.validateObjectReferences(conn, stateContext.WithPathIndex("Connections", i))
+		rv.queue(conn, stateContext.WithPathIndex("Connections", i))
 	}
 }
 
@@ -477,7 +587,7 @@ func (rv *ReferenceValidator) validateTransitionReferences(transition *Transitio
 				ErrorTypeReference,
 				"Transition",
 				"Source",
-				fmt.Sprintf("source vertex (ID: %s) not found in reference map", transition.Source.ID),
+				fmt.Sprintf("source vertex (ID: %s) not found in reference map%s", transition.Source.ID, didYouMean(transition.Source.ID, rv.vertexIDs())),
 				transitionContext.WithPath("Source").Path,
 			)
 		}
@@ -498,7 +608,7 @@ func (rv *ReferenceValidator) validateTransitionReferences(transition *Transitio
 				ErrorTypeReference,
 				"Transition",
 				"Target",
-				fmt.Sprintf("target vertex (ID: %s) not found in reference map", transition.Target.ID),
+				fmt.Sprintf("target vertex (ID: %s) not found in reference map%s", transition.Target.ID, didYouMean(transition.Target.ID, rv.vertexIDs())),
 				transitionContext.WithPath("Target").Path,
 			)
 		}
@@ -527,7 +637,7 @@ func (rv *ReferenceValidator) validateConnectionPointReferenceReferences(cpr *Co
 				ErrorTypeReference,
 				"ConnectionPointReference",
 				"Entry",
-				fmt.Sprintf("entry pseudostate at index %d (ID: %s) not found in reference map", i, entry.ID),
+				fmt.Sprintf("entry pseudostate at index %d (ID: %s) not found in reference map%s", i, entry.ID, didYouMean(entry.ID, rv.vertexIDs())),
 				cprContext.WithPathIndex("Entry", i).Path,
 			)
 		}
@@ -551,7 +661,7 @@ func (rv *ReferenceValidator) validateConnectionPointReferenceReferences(cpr *Co
 				ErrorTypeReference,
 				"ConnectionPointReference",
 				"Exit",
-				fmt.Sprintf("exit pseudostate at index %d (ID: %s) not found in reference map", i, exit.ID),
+				fmt.Sprintf("exit pseudostate at index %d (ID: %s) not found in reference map%s", i, exit.ID, didYouMean(exit.ID, rv.vertexIDs())),
 				cprContext.WithPathIndex("Exit", i).Path,
 			)
 		}
@@ -909,31 +1019,22 @@ func (rv *ReferenceValidator) hasInheritanceCycleWithDepthLimit(currentID, targe
 	return false
 }
 
-// getObjectID extracts the ID from an object
+// getObjectID extracts the ID from an object; see objectID
 func (rv *ReferenceValidator) getObjectID(obj interface{}) string {
-	if obj == nil {
-		return ""
-	}
+	return objectID(obj)
+}
 
-	// Use reflection to get the ID field
-	v := reflect.ValueOf(obj)
-	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return ""
+// vertexIDs returns the IDs of the vertices in the reference map, the
+// candidates suggested for unresolved vertex references
+func (rv *ReferenceValidator) vertexIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for id, obj := range rv.referenceMap {
+		switch obj.(type) {
+		case *Vertex, *State, *Pseudostate, *FinalState:
+			ids[id] = true
 		}
-		v = v.Elem()
 	}
-
-	if v.Kind() != reflect.Struct {
-		return ""
-	}
-
-	idField := v.FieldByName("ID")
-	if !idField.IsValid() || idField.Kind() != reflect.String {
-		return ""
-	}
-
-	return idField.String()
+	return ids
 }
 
 // getObjectTypeName returns the type name of an object
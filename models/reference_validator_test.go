@@ -113,6 +113,34 @@ func TestReferenceValidator_ValidateReferences(t *testing.T) {
 			wantErr: true,
 			errMsgs: []string{"target vertex is required and cannot be nil"},
 		},
+		{
+			name: "misspelled target suggests the declared vertex",
+			obj: &StateMachine{
+				ID:      "sm1",
+				Name:    "TestStateMachine",
+				Version: "1.0",
+				Regions: []*Region{
+					{
+						ID:   "r1",
+						Name: "TestRegion",
+						States: []*State{
+							{Vertex: Vertex{ID: "state_12", Name: "Twelve", Type: "state"}},
+							{Vertex: Vertex{ID: "state_13", Name: "Thirteen", Type: "state"}},
+						},
+						Transitions: []*Transition{
+							{
+								ID:     "t1",
+								Kind:   TransitionKindExternal,
+								Source: &Vertex{ID: "state_13", Name: "Thirteen", Type: "state"},
+								Target: &Vertex{ID: "state_21", Name: "Twelve", Type: "state"},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			errMsgs: []string{"target vertex (ID: state_21) not found in reference map; did you mean 'state_12'?"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -883,3 +911,33 @@ func TestReferenceValidator_GetObjectTypeName(t *testing.T) {
 		})
 	}
 }
+
+func TestReferenceValidator_DepthLimit(t *testing.T) {
+	sm := &StateMachine{ID: "sm", Name: "Deep", Version: "1.0", Regions: []*Region{deepRegion(10)}}
+
+	rv := NewReferenceValidator()
+	rv.MaxDepth = 6
+	err := rv.ValidateReferences(sm)
+	validationErrors, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("ValidateReferences() error = %v, want *ValidationErrors", err)
+	}
+	limitErrors := validationErrors.GetErrorsByType(ErrorTypeResourceLimit)
+	if len(limitErrors) == 0 || !strings.Contains(limitErrors[0].Message, "maximum depth of 6") {
+		t.Fatalf("ValidateReferences() errors = %v, want a depth limit error", validationErrors.Errors)
+	}
+
+	// Objects within the limit are still recorded
+	if _, exists := rv.referenceMap["s3"]; !exists {
+		t.Error("ValidateReferences() should record objects within the depth limit")
+	}
+	if _, exists := rv.referenceMap["s4"]; exists {
+		t.Error("ValidateReferences() should not record objects beyond the depth limit")
+	}
+
+	if err := NewReferenceValidator().ValidateReferences(&StateMachine{ID: "sm", Name: "Deep", Version: "1.0", Regions: []*Region{deepRegion(400)}}); err != nil {
+		if strings.Contains(err.Error(), "resource limit") {
+			t.Errorf("ValidateReferences() with the default limit reported %v", err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// RegionContent supplies the states, vertices and transitions of a region
+// that are kept outside the model, for example in a database, so that only
+// the regions a validation or traversal reaches are loaded. Vertices and
+// transitions may reference the states and vertices of other regions,
+// which the provider must return as the same instances each region uses.
+//
+// Validation, traversals and the iterators of this package read a region's
+// content through a transient copy of the region that is dropped once the
+// region has been visited, so they never modify the model and only hold the
+// content of the regions on the path to the element being visited. Each
+// reads the content again; providers that load slowly should cache. A
+// provider used by concurrent validations must be safe for concurrent use.
+// Edit operations only see elements stored on the region: call Materialize
+// or MaterializeRegions before editing a machine with lazy regions.
+type RegionContent interface {
+	States() ([]*State, error)
+	Vertices() ([]*Vertex, error)
+	Transitions() ([]*Transition, error)
+}
+
+// Materialize loads the elements supplied by the region's Content provider
+// into States, Vertices and Transitions, after any elements already there,
+// and detaches the provider. It does nothing for regions without a
+// provider. If loading fails the region is left unchanged, so Materialize
+// can be retried.
+func (r *Region) Materialize() error {
+	loaded, err := r.withContent()
+	if err != nil || loaded == r {
+		return err
+	}
+	r.States, r.Vertices, r.Transitions = loaded.States, loaded.Vertices, loaded.Transitions
+	r.Content = nil
+	return nil
+}
+
+// withContent returns a copy of the region with the elements its Content
+// provider supplies added after its own, without storing them on the
+// region, or the region itself if it has no provider
+func (r *Region) withContent() (*Region, error) {
+	if r == nil || r.Content == nil {
+		return r, nil
+	}
+	states, err := r.Content.States()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load states of region '%s': %w", r.ID, err)
+	}
+	vertices, err := r.Content.Vertices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vertices of region '%s': %w", r.ID, err)
+	}
+	transitions, err := r.Content.Transitions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transitions of region '%s': %w", r.ID, err)
+	}
+	loaded := *r
+	loaded.States = slices.Concat(r.States, states)
+	loaded.Vertices = slices.Concat(r.Vertices, vertices)
+	loaded.Transitions = slices.Concat(r.Transitions, transitions)
+	loaded.Content = nil
+	return &loaded, nil
+}
+
+// contentOf returns the region as validation and traversals read it: with
+// the content its provider supplies, or with its own elements only when the
+// content fails to load, which Region.ValidateWithErrors reports
+func contentOf(region *Region) *Region {
+	if loaded, err := region.withContent(); err == nil {
+		return loaded
+	}
+	return region
+}
+
+// MaterializeRegions materializes every region of sm and of its composite
+// states and submachines, loading each region before the regions nested in
+// its states are reached. Regions that fail to load are reported by path.
+func MaterializeRegions(sm *StateMachine) error {
+	if sm == nil {
+		return nil
+	}
+
+	type pendingMachine struct {
+		machine *StateMachine
+		prefix  string
+	}
+	var errs []error
+	seen := map[*StateMachine]bool{sm: true}
+	machines := []pendingMachine{{machine: sm}}
+	for len(machines) > 0 {
+		next := machines[0]
+		machines = machines[1:]
+		materializeRegionTree(next.machine.Regions, next.prefix, func(region *Region, path string, err error) {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				return
+			}
+			for j, state := range region.States {
+				if state != nil && state.Submachine != nil && !seen[state.Submachine] {
+					seen[state.Submachine] = true
+					machines = append(machines, pendingMachine{machine: state.Submachine, prefix: fmt.Sprintf("%s.States[%d].Submachine", path, j)})
+				}
+			}
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// materializeRegionTree materializes regions and the regions nested in their
+// states depth first, calling visit for each region with the load error, if
+// any
+func materializeRegionTree(regions []*Region, prefix string, visit func(region *Region, path string, err error)) {
+	for range loadedRegionTree(regions, prefix, func(region *Region, path string) *Region {
+		visit(region, path, region.Materialize())
+		return region
+	}) {
+	}
+}
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lazyContent is a RegionContent serving fixed elements and counting loads
+type lazyContent struct {
+	states      []*State
+	vertices    []*Vertex
+	transitions []*Transition
+	err         error
+	loads       int
+}
+
+func (c *lazyContent) States() ([]*State, error) {
+	c.loads++
+	return c.states, nil
+}
+
+func (c *lazyContent) Vertices() ([]*Vertex, error) {
+	return c.vertices, nil
+}
+
+func (c *lazyContent) Transitions() ([]*Transition, error) {
+	return c.transitions, c.err
+}
+
+// makeAllLazy moves the elements of every region of the state machine, at
+// any depth, into lazyContent providers
+func makeAllLazy(sm *StateMachine) {
+	var regions []*Region
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		regions = append(regions, region)
+	})
+	for _, region := range regions {
+		makeLazy(region)
+	}
+}
+
+// makeLazy moves the elements of a region into a lazyContent provider
+func makeLazy(region *Region) *lazyContent {
+	content := &lazyContent{states: region.States, vertices: region.Vertices, transitions: region.Transitions}
+	region.States, region.Vertices, region.Transitions = nil, nil, nil
+	region.Content = content
+	return content
+}
+
+func TestRegionMaterialize(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    func() (*Region, *lazyContent)
+		wantErr   string
+		wantCount int // States after Materialize
+	}{
+		{
+			name:      "without provider",
+			region:    func() (*Region, *lazyContent) { return newPlayerMachine().Regions[0], nil },
+			wantCount: 2,
+		},
+		{
+			name: "with provider",
+			region: func() (*Region, *lazyContent) {
+				region := newPlayerMachine().Regions[0]
+				return region, makeLazy(region)
+			},
+			wantCount: 2,
+		},
+		{
+			name: "appends to loaded elements",
+			region: func() (*Region, *lazyContent) {
+				region := newPlayerMachine().Regions[0]
+				content := makeLazy(region)
+				region.States = []*State{execState("extra")}
+				return region, content
+			},
+			wantCount: 3,
+		},
+		{
+			name: "provider error leaves region unchanged",
+			region: func() (*Region, *lazyContent) {
+				region := newPlayerMachine().Regions[0]
+				content := makeLazy(region)
+				content.err = errors.New("connection refused")
+				return region, content
+			},
+			wantErr:   "failed to load transitions of region 'main': connection refused",
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, content := tt.region()
+			err := region.Materialize()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Materialize() error = %v, want %q", err, tt.wantErr)
+				}
+				if region.Content == nil {
+					t.Error("Materialize() should keep the provider after an error")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Materialize() error = %v", err)
+				}
+				if region.Content != nil {
+					t.Error("Materialize() should detach the provider")
+				}
+				if err := region.Materialize(); err != nil || (content != nil && content.loads != 1) {
+					t.Errorf("second Materialize() = %v, want no further loads", err)
+				}
+			}
+			if len(region.States) != tt.wantCount {
+				t.Errorf("States = %d, want %d", len(region.States), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestMaterializeRegions(t *testing.T) {
+	sm := newPlayerMachine()
+	playing := sm.Regions[0].States[1]
+	sub := newPlayerMachine()
+	sub.ID = "sub"
+	subState := &State{Vertex: Vertex{ID: "nested", Type: "state"}, IsSubmachineState: true, Submachine: sub}
+	playing.Regions[1].States = append(playing.Regions[1].States, subState)
+
+	// Lazy regions inside lazy regions and inside the submachine
+	audio := makeLazy(playing.Regions[0])
+	video := makeLazy(playing.Regions[1])
+	main := makeLazy(sm.Regions[0])
+	subMain := makeLazy(sub.Regions[0])
+
+	if err := MaterializeRegions(sm); err != nil {
+		t.Fatalf("MaterializeRegions() error = %v", err)
+	}
+	for name, content := range map[string]*lazyContent{"main": main, "audio": audio, "video": video, "sub": subMain} {
+		if content.loads != 1 {
+			t.Errorf("%s loaded %d times, want 1", name, content.loads)
+		}
+	}
+	if len(sub.Regions[0].States) != 2 || len(playing.Regions[1].States) != 3 {
+		t.Errorf("regions not materialized: sub %d states, video %d states", len(sub.Regions[0].States), len(playing.Regions[1].States))
+	}
+
+	failing := newPlayerMachine()
+	content := makeLazy(failing.Regions[0].States[1].Regions[0])
+	content.err = errors.New("timeout")
+	err := MaterializeRegions(failing)
+	if err == nil || err.Error() != "Regions[0].States[1].Regions[0]: failed to load transitions of region 'audio': timeout" {
+		t.Errorf("MaterializeRegions() error = %v", err)
+	}
+	if MaterializeRegions(nil) != nil {
+		t.Error("MaterializeRegions(nil) should succeed")
+	}
+}
+
+func TestValidateLazyRegions(t *testing.T) {
+	t.Run("validates content without materializing", func(t *testing.T) {
+		lazy := createValidStateMachine()
+		content := makeLazy(lazy.Regions[0])
+
+		if err := lazy.Validate(); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		if content.loads == 0 {
+			t.Error("Validate() should load the region content")
+		}
+		if region := lazy.Regions[0]; region.Content != content || region.States != nil || region.Vertices != nil || region.Transitions != nil {
+			t.Errorf("Validate() materialized the region: %d states, %d vertices, %d transitions", len(region.States), len(region.Vertices), len(region.Transitions))
+		}
+	})
+
+	t.Run("reports load failures", func(t *testing.T) {
+		sm := createValidStateMachine()
+		makeLazy(sm.Regions[0]).err = errors.New("store unavailable")
+
+		errs := &ValidationErrors{}
+		sm.ValidateWithErrors(NewValidationContext(), errs)
+		var found *ValidationError
+		for _, err := range errs.Errors {
+			if err.Object == "Region" && err.Field == "Content" {
+				found = err
+			}
+		}
+		if found == nil {
+			t.Fatalf("expected a Region.Content error, got %v", errs)
+		}
+		if found.Type != ErrorTypeReference || strings.Join(found.Path, ".") != "Regions[0]" || !strings.Contains(found.Message, "store unavailable") {
+			t.Errorf("unexpected error %v", found)
+		}
+	})
+
+	t.Run("standalone region", func(t *testing.T) {
+		region := createValidStateMachine().Regions[0]
+		makeLazy(region).err = errors.New("store unavailable")
+		err := region.Validate()
+		if err == nil || !strings.Contains(err.Error(), "Region.Content: failed to load transitions") {
+			t.Errorf("Validate() error = %v", err)
+		}
+	})
+}
+
+func TestValidateLazyRegions_MatchesEager(t *testing.T) {
+	tests := []struct {
+		name    string
+		machine func() *StateMachine
+	}{
+		{"valid", createValidStateMachine},
+		{"invalid", createInvalidStateMachine},
+		{"complex", createComplexStateMachine},
+		{"orthogonal", createOrthogonalStateMachine},
+		{"submachine", createSubmachineStateMachine},
+		{"player", newPlayerMachine},
+		{"history", newHistoryMachine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eager, lazy := tt.machine(), tt.machine()
+			lazy.CreatedAt = eager.CreatedAt
+			makeAllLazy(lazy)
+
+			if want, got := fmt.Sprint(eager.Validate()), fmt.Sprint(lazy.Validate()); got != want {
+				t.Errorf("Validate() = %s, want %s", got, want)
+			}
+			for i := range eager.Regions {
+				if want, got := fmt.Sprint(eager.Regions[i].Validate()), fmt.Sprint(lazy.Regions[i].Validate()); got != want {
+					t.Errorf("Regions[%d].Validate() = %s, want %s", i, got, want)
+				}
+			}
+			for region := range regionTree(lazy.Regions, "") {
+				if region.Content != nil {
+					t.Fatal("regionTree() should yield regions with their content loaded")
+				}
+			}
+			if lazy.Regions[0].Content == nil || lazy.Regions[0].States != nil {
+				t.Error("Validate() materialized the regions")
+			}
+		})
+	}
+}
+
+func TestTraverseLazyRegions(t *testing.T) {
+	sm := newPlayerMachine()
+	playing := sm.Regions[0].States[1]
+	main := makeLazy(sm.Regions[0])
+	audio := makeLazy(playing.Regions[0])
+
+	// Stopping at idle leaves the nested region of playing unloaded
+	stop := errors.New("stop")
+	err := NewStateMachineTraverser().TraverseStateMachine(sm, func(obj interface{}, path []string, depth int) error {
+		if state, ok := obj.(*State); ok && state.ID == "idle" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("TraverseStateMachine() error = %v, want stop", err)
+	}
+	if main.loads != 1 || audio.loads != 0 {
+		t.Errorf("loads main=%d audio=%d, want 1 and 0", main.loads, audio.loads)
+	}
+
+	var visited []string
+	err = NewStateMachineTraverser().TraverseStateMachine(sm, func(obj interface{}, path []string, depth int) error {
+		if state, ok := obj.(*State); ok {
+			visited = append(visited, state.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TraverseStateMachine() error = %v", err)
+	}
+	if strings.Join(visited, ",") != "idle,playing,loading,streaming,buffering,rendering" || audio.loads != 1 {
+		t.Errorf("visited %v with %d audio loads", visited, audio.loads)
+	}
+
+	failing := newPlayerMachine()
+	makeLazy(failing.Regions[0]).err = errors.New("timeout")
+	err = NewStateMachineTraverser().TraverseStateMachine(failing, func(interface{}, []string, int) error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), "StateMachine.Regions[0]: failed to load") {
+		t.Errorf("TraverseStateMachine() error = %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -170,11 +171,6 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 							Name: "Initial",
 							Type: "pseudostate",
 						},
-						{
-							ID:   "s1",
-							Name: "State1",
-							Type: "state",
-						},
 					},
 				},
 				wantErr: false,
@@ -209,7 +205,7 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 			errMsg  string
 		}{
 			{
-				name: "valid - all states in vertices collection",
+				name: "valid - states and pseudostates declared once",
 				region: &Region{
 					ID:   "r1",
 					Name: "TestRegion",
@@ -231,14 +227,9 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 					},
 					Vertices: []*Vertex{
 						{
-							ID:   "s1",
-							Name: "State1",
-							Type: "state",
-						},
-						{
-							ID:   "s2",
-							Name: "State2",
-							Type: "state",
+							ID:   "initial",
+							Name: "Initial",
+							Type: "pseudostate",
 						},
 					},
 				},
@@ -280,7 +271,7 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 				wantErr: false,
 			},
 			{
-				name: "invalid - state not in vertices collection",
+				name: "invalid - state declared in both collections",
 				region: &Region{
 					ID:   "r1",
 					Name: "TestRegion",
@@ -302,15 +293,14 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 					},
 					Vertices: []*Vertex{
 						{
-							ID:   "s1",
-							Name: "State1",
+							ID:   "s2",
+							Name: "State2",
 							Type: "state",
 						},
-						// s2 is missing from vertices
 					},
 				},
 				wantErr: true,
-				errMsg:  "state at index 1 (ID: s2) is not contained in the region's vertices collection (UML constraint)",
+				errMsg:  "vertex at index 0 (ID: s2) is also declared in the region's states collection; each vertex must appear exactly once across States and Vertices (UML constraint)",
 			},
 			{
 				name: "invalid - vertex with empty ID",
@@ -669,11 +659,6 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 							Name: "Initial",
 							Type: "pseudostate",
 						},
-						{
-							ID:   "s1",
-							Name: "State1",
-							Type: "state",
-						},
 						{
 							ID:   "fs1",
 							Name: "FinalState1",
@@ -728,7 +713,7 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 						},
 						{
 							Vertex: Vertex{
-								ID:   "s3", // Not in vertices - containment violation
+								ID:   "s3",
 								Name: "State3",
 								Type: "state",
 							},
@@ -746,7 +731,7 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 							Type: "pseudostate",
 						},
 						{
-							ID:   "s1",
+							ID:   "s1", // Also declared in States - containment violation
 							Name: "State1",
 							Type: "state",
 						},
@@ -795,7 +780,7 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 				wantErr: true,
 				errMsgs: []string{
 					"Region can have at most one initial pseudostate, found 2 at indices: [0 1] (UML constraint)",
-					"state at index 1 (ID: s3) is not contained in the region's vertices collection (UML constraint)",
+					"vertex at index 2 (ID: s1) is also declared in the region's states collection; each vertex must appear exactly once across States and Vertices (UML constraint)",
 					"vertex at index 4 must have a valid ID for proper containment (UML constraint)",
 					"transition at index 0 has a final state as source, which is not allowed (UML constraint)",
 					"transition at index 1 has target vertex (ID: s2) that is not contained in this region, but transition kind is internal (UML constraint)",
@@ -825,3 +810,68 @@ func TestRegion_UMLConstraintValidation(t *testing.T) {
 		}
 	})
 }
+
+// TestMultiplicity_Consolidated tests that a multiplicity violation is
+// reported once for its region, listing the offending elements
+func TestMultiplicity_Consolidated(t *testing.T) {
+	region := &Region{
+		ID:   "r1",
+		Name: "TestRegion",
+		Vertices: []*Vertex{
+			{ID: "initial1", Name: "Initial", Type: "pseudostate"},
+			{ID: "s1", Name: "State1", Type: "state"},
+			{ID: "initial2", Name: "init", Type: "pseudostate"},
+			{ID: "initial3", Name: "start", Type: "pseudostate"},
+		},
+	}
+	wantContext := map[string]interface{}{
+		"count":         3,
+		"element_ids":   []string{"initial1", "initial2", "initial3"},
+		"element_paths": []string{"Regions[0].Vertices[0]", "Regions[0].Vertices[2]", "Regions[0].Vertices[3]"},
+	}
+
+	t.Run("region", func(t *testing.T) {
+		errors := &ValidationErrors{}
+		region.validateInitialStates(NewValidationContext().WithPathIndex("Regions", 0), errors)
+		multiplicity := errors.GetErrorsByType(ErrorTypeMultiplicity)
+		if len(multiplicity) != 1 {
+			t.Fatalf("errors = %v, want one multiplicity error", errors.Errors)
+		}
+		if !reflect.DeepEqual(multiplicity[0].Context, wantContext) {
+			t.Errorf("Context = %v, want %v", multiplicity[0].Context, wantContext)
+		}
+	})
+
+	t.Run("pseudostates", func(t *testing.T) {
+		errors := &ValidationErrors{}
+		context := NewValidationContext().WithRegion(region).WithPathIndex("Regions", 0)
+		for i, vertex := range region.Vertices {
+			if vertex.Type != "pseudostate" {
+				continue
+			}
+			ps := &Pseudostate{Vertex: *vertex, Kind: PseudostateKindInitial}
+			ps.validateMultiplicity(context.WithPathIndex("Vertices", i), errors)
+		}
+		multiplicity := errors.GetErrorsByType(ErrorTypeMultiplicity)
+		if len(multiplicity) != 1 {
+			t.Fatalf("errors = %v, want one multiplicity error for the region", errors.Errors)
+		}
+		err := multiplicity[0]
+		if err.Object != "Region" || !reflect.DeepEqual(err.Path, []string{"Regions[0]"}) {
+			t.Errorf("error = %s %v, want a Region error at Regions[0]", err.Object, err.Path)
+		}
+		wantContext["region_id"] = "r1"
+		if !reflect.DeepEqual(err.Context, wantContext) {
+			t.Errorf("Context = %v, want %v", err.Context, wantContext)
+		}
+	})
+
+	t.Run("pseudostate outside the region", func(t *testing.T) {
+		errors := &ValidationErrors{}
+		ps := &Pseudostate{Vertex: Vertex{ID: "other", Name: "Initial", Type: "pseudostate"}, Kind: PseudostateKindInitial}
+		ps.validateMultiplicity(NewValidationContext().WithRegion(region), errors)
+		if len(errors.GetErrorsByType(ErrorTypeMultiplicity)) != 1 {
+			t.Errorf("errors = %v, want the region's multiplicity error", errors.Errors)
+		}
+	})
+}
@@ -0,0 +1,453 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RepairPolicy names a class of problems that Repair fixes
+type RepairPolicy string
+
+const (
+	// RepairAssignIDs gives elements without an ID one derived from their
+	// container, e.g. "main-transition-2"
+	RepairAssignIDs RepairPolicy = "assign-ids"
+	// RepairVertexContainment applies Sanitize's vertex-containment fix
+	RepairVertexContainment RepairPolicy = "vertex-containment"
+	// RepairDanglingReferences removes nil collection entries, transitions
+	// whose source or target is not declared, connection point references to
+	// entry and exit points the submachine does not have, and ReplacedBy
+	// references to missing elements
+	RepairDanglingReferences RepairPolicy = "remove-dangling-references"
+	// RepairDeriveFlags sets IsComposite, IsOrthogonal, IsSimple and
+	// IsSubmachineState from the regions and submachine of each state
+	RepairDeriveFlags RepairPolicy = "derive-flags"
+	// RepairInitialPseudostates adds an initial pseudostate to regions with
+	// states but none, with a transition to the region's first state
+	RepairInitialPseudostates RepairPolicy = "create-initial-pseudostates"
+)
+
+// RepairPolicies returns every repair policy in the order Repair applies them
+func RepairPolicies() []RepairPolicy {
+	return []RepairPolicy{RepairAssignIDs, RepairVertexContainment, RepairDanglingReferences, RepairDeriveFlags, RepairInitialPseudostates}
+}
+
+// RepairReport lists the changes made by Repair, grouped by policy in the
+// order the policies were applied and in model order within a policy
+type RepairReport struct {
+	Changes []SanitizeFix `json:"changes"`
+}
+
+// String returns one line per change
+func (r *RepairReport) String() string {
+	if r == nil || len(r.Changes) == 0 {
+		return "no changes"
+	}
+	lines := make([]string, len(r.Changes))
+	for i, change := range r.Changes {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Repair fixes the classes of problems selected by policies in sm, in place,
+// and reports each change; with no policies it applies them all. Policies
+// run in the order of RepairPolicies whatever order they are given in, so
+// later ones see elements fixed by earlier ones, e.g. initial pseudostates
+// are named after the IDs assigned to their regions. Lazy regions are
+// materialized first. Problems outside the selected policies are left for
+// validation to report; MakeValid combines the two.
+func Repair(sm *StateMachine, policies ...RepairPolicy) (*RepairReport, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	selected := make(map[RepairPolicy]bool)
+	for _, policy := range policies {
+		if !slices.Contains(RepairPolicies(), policy) {
+			return nil, fmt.Errorf("unknown repair policy '%s'", policy)
+		}
+		selected[policy] = true
+	}
+	if err := MaterializeRegions(sm); err != nil {
+		return nil, fmt.Errorf("cannot repair state machine: %w", err)
+	}
+
+	report := &RepairReport{}
+	for _, policy := range RepairPolicies() {
+		if len(selected) > 0 && !selected[policy] {
+			continue
+		}
+		var changes []SanitizeFix
+		switch policy {
+		case RepairAssignIDs:
+			changes = repairIDs(sm)
+		case RepairVertexContainment:
+			changes = Sanitize(sm)
+		case RepairDanglingReferences:
+			changes = repairDanglingReferences(sm)
+		case RepairDeriveFlags:
+			changes = repairFlags(sm)
+		case RepairInitialPseudostates:
+			changes = repairInitials(sm)
+		}
+		report.Changes = append(report.Changes, changes...)
+	}
+	sm.refreshAdjacency()
+	return report, nil
+}
+
+// MakeValid repairs sm with the given policies and validates the result,
+// returning the repair report together with the validation error for the
+// problems that remain
+func MakeValid(sm *StateMachine, policies ...RepairPolicy) (*RepairReport, error) {
+	report, err := Repair(sm, policies...)
+	if err != nil {
+		return nil, err
+	}
+	return report, sm.Validate()
+}
+
+// repairIDs assigns IDs to unidentified elements. IDs are derived from the
+// container's ID and made unique with a numeric suffix.
+func repairIDs(sm *StateMachine) []SanitizeFix {
+	taken := make(map[string]bool)
+	walkElements(sm, func(_ string, id, _ *string) {
+		taken[*id] = true
+	})
+	var fixes []SanitizeFix
+	assign := func(id *string, base, object, path string) {
+		if *id != "" {
+			return
+		}
+		candidate := base
+		for n := 2; taken[candidate]; n++ {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[candidate] = true
+		*id = candidate
+		fixes = append(fixes, SanitizeFix{
+			Rule:    string(RepairAssignIDs),
+			Path:    path,
+			Message: fmt.Sprintf("assigned ID '%s' to the %s", candidate, object),
+		})
+	}
+	behavior := func(b *Behavior, owner, kind, path string) {
+		if b != nil {
+			assign(&b.ID, owner+"-"+kind, kind+" behavior", path)
+		}
+	}
+
+	assign(&sm.ID, "statemachine", "state machine", "StateMachine")
+	for i, event := range sm.Events {
+		if event != nil {
+			assign(&event.ID, displayName(event.Name, fmt.Sprintf("event-%d", i+1)), "event", fmt.Sprintf("Events[%d]", i))
+		}
+	}
+	for i, cp := range sm.ConnectionPoints {
+		if cp != nil {
+			assign(&cp.ID, fmt.Sprintf("%s-connection-point-%d", sm.ID, i+1), "connection point", fmt.Sprintf("ConnectionPoints[%d]", i))
+		}
+	}
+
+	var regions func(regions []*Region, owner, prefix string)
+	regions = func(list []*Region, owner, prefix string) {
+		for i, region := range list {
+			if region == nil {
+				continue
+			}
+			path := joinPath(prefix, fmt.Sprintf("Regions[%d]", i))
+			assign(&region.ID, fmt.Sprintf("%s-region-%d", owner, i+1), "region", path)
+
+			for j, state := range region.States {
+				if state == nil {
+					continue
+				}
+				statePath := fmt.Sprintf("%s.States[%d]", path, j)
+				assign(&state.ID, fmt.Sprintf("%s-state-%d", region.ID, j+1), "state", statePath)
+				behavior(state.Entry, state.ID, "entry", statePath+".Entry")
+				behavior(state.Exit, state.ID, "exit", statePath+".Exit")
+				behavior(state.DoActivity, state.ID, "do", statePath+".DoActivity")
+				for k, trigger := range state.DeferrableTriggers {
+					if trigger != nil {
+						assign(&trigger.ID, fmt.Sprintf("%s-deferred-%d", state.ID, k+1), "deferrable trigger", fmt.Sprintf("%s.DeferrableTriggers[%d]", statePath, k))
+					}
+				}
+				for k, connection := range state.Connections {
+					if connection != nil {
+						assign(&connection.ID, fmt.Sprintf("%s-connection-%d", state.ID, k+1), "connection point reference", fmt.Sprintf("%s.Connections[%d]", statePath, k))
+					}
+				}
+			}
+			for j, vertex := range region.Vertices {
+				if vertex == nil {
+					continue
+				}
+				base := fmt.Sprintf("%s-vertex-%d", region.ID, j+1)
+				if kind := pseudostateKindOf(vertex); kind != "" {
+					base = region.ID + "-" + string(kind)
+				} else if vertex.Type == "finalstate" {
+					base = region.ID + "-final"
+				}
+				assign(&vertex.ID, base, "vertex", fmt.Sprintf("%s.Vertices[%d]", path, j))
+			}
+			for j, transition := range region.Transitions {
+				if transition == nil {
+					continue
+				}
+				transitionPath := fmt.Sprintf("%s.Transitions[%d]", path, j)
+				assign(&transition.ID, fmt.Sprintf("%s-transition-%d", region.ID, j+1), "transition", transitionPath)
+				for k, trigger := range transition.Triggers {
+					if trigger != nil {
+						assign(&trigger.ID, fmt.Sprintf("%s-trigger-%d", transition.ID, k+1), "trigger", fmt.Sprintf("%s.Triggers[%d]", transitionPath, k))
+					}
+				}
+				if transition.Guard != nil {
+					assign(&transition.Guard.ID, transition.ID+"-guard", "guard", transitionPath+".Guard")
+				}
+				behavior(transition.Effect, transition.ID, "effect", transitionPath+".Effect")
+			}
+
+			for j, state := range region.States {
+				if state != nil {
+					regions(state.Regions, state.ID, fmt.Sprintf("%s.States[%d]", path, j))
+				}
+			}
+		}
+	}
+	regions(sm.Regions, sm.ID, "")
+	return fixes
+}
+
+// repairDanglingReferences removes references that cannot be resolved
+func repairDanglingReferences(sm *StateMachine) []SanitizeFix {
+	var fixes []SanitizeFix
+	fix := func(path, format string, args ...any) {
+		fixes = append(fixes, SanitizeFix{Rule: string(RepairDanglingReferences), Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	// dropNil removes the nil entries of a collection, reporting each
+	dropNil := func(path, field string, n int, isNil func(int) bool) []int {
+		var kept []int
+		for i := range n {
+			if isNil(i) {
+				fix(joinPath(path, fmt.Sprintf("%s[%d]", field, i)), "removed nil entry from %s", field)
+				continue
+			}
+			kept = append(kept, i)
+		}
+		return kept
+	}
+
+	sm.Regions = keepIndices(sm.Regions, dropNil("", "Regions", len(sm.Regions), func(i int) bool { return sm.Regions[i] == nil }))
+	sm.Events = keepIndices(sm.Events, dropNil("", "Events", len(sm.Events), func(i int) bool { return sm.Events[i] == nil }))
+	sm.ConnectionPoints = keepIndices(sm.ConnectionPoints, dropNil("", "ConnectionPoints", len(sm.ConnectionPoints), func(i int) bool { return sm.ConnectionPoints[i] == nil }))
+
+	// Nil entries first, so that declared vertices are indexed over clean
+	// collections; walkRegionTree reaches nested regions after their owner
+	// region was cleaned
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		region.States = keepIndices(region.States, dropNil(path, "States", len(region.States), func(i int) bool { return region.States[i] == nil }))
+		region.Vertices = keepIndices(region.Vertices, dropNil(path, "Vertices", len(region.Vertices), func(i int) bool { return region.Vertices[i] == nil }))
+		region.Transitions = keepIndices(region.Transitions, dropNil(path, "Transitions", len(region.Transitions), func(i int) bool { return region.Transitions[i] == nil }))
+		for i, state := range region.States {
+			statePath := fmt.Sprintf("%s.States[%d]", path, i)
+			state.Regions = keepIndices(state.Regions, dropNil(statePath, "Regions", len(state.Regions), func(j int) bool { return state.Regions[j] == nil }))
+			state.DeferrableTriggers = keepIndices(state.DeferrableTriggers, dropNil(statePath, "DeferrableTriggers", len(state.DeferrableTriggers), func(j int) bool { return state.DeferrableTriggers[j] == nil }))
+			state.Connections = keepIndices(state.Connections, dropNil(statePath, "Connections", len(state.Connections), func(j int) bool { return state.Connections[j] == nil }))
+		}
+		for i, transition := range region.Transitions {
+			transition.Triggers = keepIndices(transition.Triggers, dropNil(fmt.Sprintf("%s.Transitions[%d]", path, i), "Triggers", len(transition.Triggers), func(j int) bool { return transition.Triggers[j] == nil }))
+		}
+	})
+
+	declared := canonicalVertices(sm)
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		var kept []int
+		for i, transition := range region.Transitions {
+			transitionPath := fmt.Sprintf("%s.Transitions[%d]", path, i)
+			switch {
+			case transition.Source == nil || transition.Target == nil:
+				fix(transitionPath, "removed transition '%s' because it lacks a source or target", transition.ID)
+			case declared[transition.Source.ID] == nil:
+				fix(transitionPath, "removed transition '%s' because its source '%s' is not declared", transition.ID, transition.Source.ID)
+			case declared[transition.Target.ID] == nil:
+				fix(transitionPath, "removed transition '%s' because its target '%s' is not declared", transition.ID, transition.Target.ID)
+			default:
+				kept = append(kept, i)
+			}
+		}
+		region.Transitions = keepIndices(region.Transitions, kept)
+
+		for i, state := range region.States {
+			if state.Submachine == nil {
+				continue
+			}
+			points := make(map[string]bool)
+			for _, cp := range state.Submachine.ConnectionPoints {
+				if cp != nil {
+					points[cp.ID] = true
+				}
+			}
+			for j, connection := range state.Connections {
+				connectionPath := fmt.Sprintf("%s.States[%d].Connections[%d]", path, i, j)
+				prune := func(field string, pseudostates []*Pseudostate) []*Pseudostate {
+					var kept []int
+					for k, ps := range pseudostates {
+						if ps != nil && points[ps.ID] {
+							kept = append(kept, k)
+							continue
+						}
+						id := "<nil>"
+						if ps != nil {
+							id = ps.ID
+						}
+						fix(fmt.Sprintf("%s.%s[%d]", connectionPath, field, k), "removed reference to %s point '%s', which submachine '%s' does not have", strings.ToLower(field), id, state.Submachine.ID)
+					}
+					return keepIndices(pseudostates, kept)
+				}
+				connection.Entry = prune("Entry", connection.Entry)
+				connection.Exit = prune("Exit", connection.Exit)
+			}
+		}
+	})
+
+	// Replacements, after transitions were removed
+	ids := map[string]map[string]bool{"Event": {}, "State": {}, "Transition": {}}
+	for _, event := range sm.Events {
+		ids["Event"][event.ID] = true
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			ids["State"][state.ID] = true
+		}
+		for _, transition := range region.Transitions {
+			ids["Transition"][transition.ID] = true
+		}
+	})
+	clearReplacement := func(object, id string, replacedBy *string, path string) {
+		if *replacedBy != "" && !ids[object][*replacedBy] {
+			fix(path, "cleared replacement '%s' of %s '%s', which is not a %s of this state machine", *replacedBy, strings.ToLower(object), id, strings.ToLower(object))
+			*replacedBy = ""
+		}
+	}
+	for i, event := range sm.Events {
+		clearReplacement("Event", event.ID, &event.ReplacedBy, fmt.Sprintf("Events[%d]", i))
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, state := range region.States {
+			clearReplacement("State", state.ID, &state.ReplacedBy, fmt.Sprintf("%s.States[%d]", path, i))
+		}
+		for i, transition := range region.Transitions {
+			clearReplacement("Transition", transition.ID, &transition.ReplacedBy, fmt.Sprintf("%s.Transitions[%d]", path, i))
+		}
+	})
+	return fixes
+}
+
+// repairFlags derives the kind flags of every state from its regions and
+// submachine
+func repairFlags(sm *StateMachine) []SanitizeFix {
+	var fixes []SanitizeFix
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		for i, state := range region.States {
+			if state == nil {
+				continue
+			}
+			submachine := state.Submachine != nil
+			regions := len(nonNilRegions(state.Regions))
+			composite := !submachine && regions > 0
+			var changed []string
+			set := func(name string, flag *bool, value bool) {
+				if *flag != value {
+					*flag = value
+					changed = append(changed, fmt.Sprintf("%s to %t", name, value))
+				}
+			}
+			set("IsComposite", &state.IsComposite, composite)
+			set("IsOrthogonal", &state.IsOrthogonal, composite && regions > 1)
+			set("IsSimple", &state.IsSimple, !composite && !submachine)
+			set("IsSubmachineState", &state.IsSubmachineState, submachine)
+			if len(changed) == 0 {
+				continue
+			}
+			reason := plural(regions, "region")
+			switch {
+			case submachine:
+				reason = "a submachine"
+			case regions == 0:
+				reason = "no regions"
+			}
+			fixes = append(fixes, SanitizeFix{
+				Rule:    string(RepairDeriveFlags),
+				Path:    fmt.Sprintf("%s.States[%d]", path, i),
+				Message: fmt.Sprintf("set %s for state '%s', which has %s", joinWords(changed, "and"), state.ID, reason),
+			})
+		}
+	})
+	return fixes
+}
+
+// repairInitials adds an initial pseudostate to every region that has states
+// but no initial pseudostate
+func repairInitials(sm *StateMachine) []SanitizeFix {
+	taken := make(map[string]bool)
+	walkElements(sm, func(_ string, id, _ *string) {
+		taken[*id] = true
+	})
+	unique := func(base string) string {
+		candidate := base
+		for n := 2; taken[candidate]; n++ {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[candidate] = true
+		return candidate
+	}
+
+	var fixes []SanitizeFix
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		if len(regionInitialIDs(region)) > 0 {
+			return
+		}
+		first := slices.IndexFunc(region.States, func(state *State) bool { return state != nil })
+		if first < 0 {
+			return
+		}
+		target := region.States[first]
+		initial := &Vertex{ID: unique(region.ID + "-initial"), Name: "initial", Type: "pseudostate"}
+		region.Vertices = append(region.Vertices, initial)
+		region.Transitions = append(region.Transitions, &Transition{
+			ID:     unique(initial.ID + "-transition"),
+			Source: initial,
+			Target: &target.Vertex,
+			Kind:   TransitionKindExternal,
+		})
+		fixes = append(fixes, SanitizeFix{
+			Rule:    string(RepairInitialPseudostates),
+			Path:    fmt.Sprintf("%s.Vertices[%d]", path, len(region.Vertices)-1),
+			Message: fmt.Sprintf("added initial pseudostate '%s' with a transition to state '%s'", initial.ID, target.ID),
+		})
+	})
+	return fixes
+}
+
+// keepIndices returns the elements at the given ascending indices, reusing
+// the backing array
+func keepIndices[T any](elements []*T, indices []int) []*T {
+	if len(indices) == len(elements) {
+		return elements
+	}
+	kept := elements[:0]
+	for _, i := range indices {
+		kept = append(kept, elements[i])
+	}
+	clear(elements[len(kept):])
+	return kept
+}
+
+// joinPath appends a field to a dotted path
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RepairPolicy
+		modify func(sm *StateMachine)
+		want   []string
+		check  func(t *testing.T, sm *StateMachine)
+	}{
+		{
+			name:   "assign IDs",
+			policy: RepairAssignIDs,
+			modify: func(sm *StateMachine) {
+				region := sm.Regions[0]
+				region.ID = ""
+				region.Transitions[1].ID = ""
+				region.Transitions[1].Triggers = []*Trigger{{Name: "go", EventID: "go"}}
+				region.States[0].Entry = &Behavior{Specification: "start()"}
+			},
+			want: []string{
+				"[assign-ids] Regions[0]: assigned ID 'sm1-region-1' to the region",
+				"[assign-ids] Regions[0].States[0].Entry: assigned ID 'state1-entry' to the entry behavior",
+				"[assign-ids] Regions[0].Transitions[1]: assigned ID 'sm1-region-1-transition-2' to the transition",
+				"[assign-ids] Regions[0].Transitions[1].Triggers[0]: assigned ID 'sm1-region-1-transition-2-trigger-1' to the trigger",
+			},
+		},
+		{
+			name:   "assigned IDs are unique",
+			policy: RepairAssignIDs,
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].ID = "sm1-region-1"
+				sm.Regions = append(sm.Regions, &Region{Name: "Second"}, &Region{Name: "Third"})
+			},
+			want: []string{
+				"[assign-ids] Regions[1]: assigned ID 'sm1-region-2' to the region",
+				"[assign-ids] Regions[2]: assigned ID 'sm1-region-3' to the region",
+			},
+			check: func(t *testing.T, sm *StateMachine) {
+				sm.Regions[2].ID = ""
+				sm.Regions[1].ID = "sm1-region-3"
+				report, _ := Repair(sm, RepairAssignIDs)
+				if sm.Regions[2].ID != "sm1-region-3-2" {
+					t.Errorf("region ID = %q, want a suffixed ID; report:\n%s", sm.Regions[2].ID, report)
+				}
+			},
+		},
+		{
+			name:   "remove dangling references",
+			policy: RepairDanglingReferences,
+			modify: func(sm *StateMachine) {
+				region := sm.Regions[0]
+				region.States = append(region.States, nil)
+				region.Transitions = append(region.Transitions, &Transition{ID: "lost", Source: &region.States[0].Vertex, Target: &Vertex{ID: "ghost", Type: "state"}})
+				region.States[1].Deprecated = true
+				region.States[1].ReplacedBy = "gone"
+			},
+			want: []string{
+				"[remove-dangling-references] Regions[0].States[2]: removed nil entry from States",
+				"[remove-dangling-references] Regions[0].Transitions[3]: removed transition 'lost' because its target 'ghost' is not declared",
+				"[remove-dangling-references] Regions[0].States[1]: cleared replacement 'gone' of state 'state2', which is not a state of this state machine",
+			},
+			check: func(t *testing.T, sm *StateMachine) {
+				if region := sm.Regions[0]; len(region.States) != 2 || len(region.Transitions) != 3 {
+					t.Errorf("region has %d states and %d transitions, want 2 and 3", len(region.States), len(region.Transitions))
+				}
+			},
+		},
+		{
+			name:   "remove connection point references",
+			policy: RepairDanglingReferences,
+			modify: func(sm *StateMachine) {
+				entry := &Pseudostate{Vertex: Vertex{ID: "in", Name: "In", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint}
+				state := sm.Regions[0].States[1]
+				state.Submachine = &StateMachine{ID: "sub", Name: "Sub", ConnectionPoints: []*Pseudostate{entry}}
+				state.Connections = []*ConnectionPointReference{{
+					Vertex: Vertex{ID: "conn", Name: "Conn", Type: "pseudostate"},
+					Entry:  []*Pseudostate{entry, {Vertex: Vertex{ID: "inn"}, Kind: PseudostateKindEntryPoint}},
+				}}
+			},
+			want: []string{
+				"[remove-dangling-references] Regions[0].States[1].Connections[0].Entry[1]: removed reference to entry point 'inn', which submachine 'sub' does not have",
+			},
+			check: func(t *testing.T, sm *StateMachine) {
+				if entries := sm.Regions[0].States[1].Connections[0].Entry; len(entries) != 1 || entries[0].ID != "in" {
+					t.Errorf("entries = %v, want only the declared entry point", entries)
+				}
+			},
+		},
+		{
+			name:   "derive flags",
+			policy: RepairDeriveFlags,
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[0].IsSimple = false
+				sm.Regions[0].States[1].Regions = []*Region{{ID: "inner", Name: "Inner"}, {ID: "other", Name: "Other"}}
+			},
+			want: []string{
+				"[derive-flags] Regions[0].States[0]: set IsSimple to true for state 'state1', which has no regions",
+				"[derive-flags] Regions[0].States[1]: set IsComposite to true, IsOrthogonal to true and IsSimple to false for state 'state2', which has 2 regions",
+			},
+		},
+		{
+			name:   "create initial pseudostates",
+			policy: RepairInitialPseudostates,
+			modify: func(sm *StateMachine) {
+				region := sm.Regions[0]
+				region.Vertices = region.Vertices[1:]
+				region.Transitions = region.Transitions[1:]
+				region.States[1].Regions = []*Region{{ID: "empty", Name: "Empty"}}
+			},
+			want: []string{
+				"[create-initial-pseudostates] Regions[0].Vertices[1]: added initial pseudostate 'region1-initial' with a transition to state 'state1'",
+			},
+			check: func(t *testing.T, sm *StateMachine) {
+				region := sm.Regions[0]
+				if ids := regionInitialIDs(region); len(ids) != 1 || ids[0] != "region1-initial" {
+					t.Errorf("initial pseudostates = %v", ids)
+				}
+				last := region.Transitions[len(region.Transitions)-1]
+				if last.ID != "region1-initial-transition" || last.Target != &region.States[0].Vertex {
+					t.Errorf("initial transition = %v", last)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			tt.modify(sm)
+			report, err := Repair(sm, tt.policy)
+			if err != nil {
+				t.Fatalf("Repair() error = %v", err)
+			}
+			if got := report.String(); got != strings.Join(tt.want, "\n") {
+				t.Errorf("Repair() report:\n%s\nwant:\n%s", got, strings.Join(tt.want, "\n"))
+			}
+			if tt.check != nil {
+				tt.check(t, sm)
+			}
+			if report, _ := Repair(sm, tt.policy); len(report.Changes) > 0 {
+				t.Errorf("second Repair() should change nothing:\n%s", report)
+			}
+		})
+	}
+}
+
+func TestMakeValid(t *testing.T) {
+	sm := createValidStateMachine()
+	region := sm.Regions[0]
+	region.ID = ""
+	region.Vertices = region.Vertices[1:]
+	region.Transitions = append(region.Transitions[1:], &Transition{Source: &region.States[0].Vertex, Target: &Vertex{ID: "ghost", Type: "state"}})
+	region.States[0].IsSimple = false
+	region.States = append(region.States, nil)
+	if sm.Validate() == nil {
+		t.Fatal("fixture should be invalid")
+	}
+
+	report, err := MakeValid(sm)
+	if err != nil {
+		t.Fatalf("MakeValid() error = %v\nreport:\n%s", err, report)
+	}
+	rules := make(map[string]int)
+	for _, change := range report.Changes {
+		rules[change.Rule]++
+	}
+	for _, policy := range []RepairPolicy{RepairAssignIDs, RepairDanglingReferences, RepairDeriveFlags, RepairInitialPseudostates} {
+		if rules[string(policy)] == 0 {
+			t.Errorf("report has no %s changes:\n%s", policy, report)
+		}
+	}
+
+	if _, err := Repair(sm, "rename-everything"); err == nil || !strings.Contains(err.Error(), "unknown repair policy 'rename-everything'") {
+		t.Errorf("Repair() error = %v, want an unknown policy error", err)
+	}
+	if _, err := Repair(nil); err == nil {
+		t.Error("Repair(nil) should fail")
+	}
+	if got := (&RepairReport{}).String(); got != "no changes" {
+		t.Errorf("empty report = %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ModelRepository stores the published versions of state machines, keyed
+// by ID and semantic version, and resolves submachine references against
+// them. Versions are read with LenientVersionPolicy. A ModelRepository is
+// safe for concurrent use.
+type ModelRepository struct {
+	mu       sync.RWMutex
+	machines map[string][]repositoryEntry // Versions of each state machine ID, oldest first
+}
+
+// repositoryEntry is one stored version of a state machine
+type repositoryEntry struct {
+	version SemanticVersion
+	sm      *StateMachine
+}
+
+// NewModelRepository creates an empty repository
+func NewModelRepository() *ModelRepository {
+	return &ModelRepository{machines: make(map[string][]repositoryEntry)}
+}
+
+// Add stores a version of a state machine. The machine needs an ID and a
+// semantic version that is not already stored for that ID.
+func (r *ModelRepository) Add(sm *StateMachine) error {
+	if sm == nil || sm.ID == "" {
+		return fmt.Errorf("state machine must have an ID to be stored")
+	}
+	version, err := sm.SemanticVersion()
+	if err != nil {
+		return fmt.Errorf("cannot store state machine '%s': %w", sm.ID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.machines[sm.ID]
+	i, found := slices.BinarySearchFunc(entries, version, func(entry repositoryEntry, target SemanticVersion) int {
+		return entry.version.Compare(target)
+	})
+	if found {
+		return fmt.Errorf("state machine '%s' version %s is already stored", sm.ID, entries[i].version)
+	}
+	r.machines[sm.ID] = slices.Insert(entries, i, repositoryEntry{version: version, sm: sm})
+	return nil
+}
+
+// Get returns the stored state machine with the given ID and version
+func (r *ModelRepository) Get(id, version string) (*StateMachine, bool) {
+	wanted, err := LenientVersionPolicy.Parse(version)
+	if err != nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, entry := range r.machines[id] {
+		if entry.version.Compare(wanted) == 0 {
+			return entry.sm, true
+		}
+	}
+	return nil, false
+}
+
+// Versions returns the stored versions of a state machine, oldest first
+func (r *ModelRepository) Versions(id string) []SemanticVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]SemanticVersion, 0, len(r.machines[id]))
+	for _, entry := range r.machines[id] {
+		versions = append(versions, entry.version)
+	}
+	return versions
+}
+
+// Latest returns the newest stored version of a state machine. Pre-releases
+// are only returned when no release is stored.
+func (r *ModelRepository) Latest(id string) (*StateMachine, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := r.machines[id]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].version.Prerelease == "" {
+			return entries[i].sm, true
+		}
+	}
+	if len(entries) > 0 {
+		return entries[len(entries)-1].sm, true
+	}
+	return nil, false
+}
+
+// LatestCompatible returns the newest stored version of a state machine that
+// is compatible with the required version, as defined by
+// SemanticVersion.CompatibleWith
+func (r *ModelRepository) LatestCompatible(id, version string) (*StateMachine, bool) {
+	required, err := LenientVersionPolicy.Parse(version)
+	if err != nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := r.machines[id]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].version.CompatibleWith(required) {
+			return entries[i].sm, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveSubmachines replaces the submachine references of sm's states,
+// through every nested region, with the latest compatible stored version of
+// the referenced machine. A reference without a version resolves to the
+// latest version. The resolved machines are shared with the repository and
+// their own references are left as stored. References that cannot be
+// resolved are left in place and reported together in the returned error.
+func (r *ModelRepository) ResolveSubmachines(sm *StateMachine) error {
+	if sm == nil {
+		return nil
+	}
+	var errs []error
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state == nil || state.Submachine == nil || state.Submachine.ID == "" {
+				continue
+			}
+			reference := state.Submachine
+			var resolved *StateMachine
+			var found bool
+			if reference.Version == "" {
+				resolved, found = r.Latest(reference.ID)
+			} else {
+				resolved, found = r.LatestCompatible(reference.ID, reference.Version)
+			}
+			if !found {
+				errs = append(errs, fmt.Errorf("state '%s': no stored version of state machine '%s' is compatible with %q", state.ID, reference.ID, reference.Version))
+				continue
+			}
+			state.Submachine = resolved
+		}
+	})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// newRepositoryMachine creates a stored machine with the given ID and version
+func newRepositoryMachine(id, version string) *StateMachine {
+	return &StateMachine{ID: id, Name: id, Version: version}
+}
+
+func TestModelRepository_Add(t *testing.T) {
+	repo := NewModelRepository()
+	for _, version := range []string{"1.2.0", "1.0", "2.0.0-rc.1", "v1.10.0"} {
+		if err := repo.Add(newRepositoryMachine("door", version)); err != nil {
+			t.Fatalf("Add(%s) error = %v", version, err)
+		}
+	}
+
+	var versions []string
+	for _, version := range repo.Versions("door") {
+		versions = append(versions, version.String())
+	}
+	if got, want := strings.Join(versions, " "), "1.0.0 1.2.0 1.10.0 2.0.0-rc.1"; got != want {
+		t.Errorf("Versions() = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		sm      *StateMachine
+		wantErr string
+	}{
+		{name: "nil", sm: nil, wantErr: "must have an ID"},
+		{name: "no ID", sm: newRepositoryMachine("", "1.0.0"), wantErr: "must have an ID"},
+		{name: "invalid version", sm: newRepositoryMachine("door", "draft"), wantErr: `invalid version "draft"`},
+		{name: "duplicate", sm: newRepositoryMachine("door", "1.0.0"), wantErr: "version 1.0.0 is already stored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.Add(tt.sm)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Add() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModelRepository_Lookup(t *testing.T) {
+	repo := NewModelRepository()
+	for _, version := range []string{"1.0.0", "1.3.1", "2.0.0", "2.1.0-beta"} {
+		if err := repo.Add(newRepositoryMachine("door", version)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Add(newRepositoryMachine("beta-only", "0.1.0-alpha")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func() (*StateMachine, bool)
+		want   string
+	}{
+		{name: "get", lookup: func() (*StateMachine, bool) { return repo.Get("door", "1.3.1") }, want: "1.3.1"},
+		{name: "get short", lookup: func() (*StateMachine, bool) { return repo.Get("door", "2.0") }, want: "2.0.0"},
+		{name: "get missing", lookup: func() (*StateMachine, bool) { return repo.Get("door", "1.1.0") }},
+		{name: "latest skips pre-release", lookup: func() (*StateMachine, bool) { return repo.Latest("door") }, want: "2.0.0"},
+		{name: "latest pre-release only", lookup: func() (*StateMachine, bool) { return repo.Latest("beta-only") }, want: "0.1.0-alpha"},
+		{name: "latest unknown", lookup: func() (*StateMachine, bool) { return repo.Latest("window") }},
+		{name: "compatible same major", lookup: func() (*StateMachine, bool) { return repo.LatestCompatible("door", "1.1") }, want: "1.3.1"},
+		{name: "compatible next major", lookup: func() (*StateMachine, bool) { return repo.LatestCompatible("door", "2.0.0") }, want: "2.0.0"},
+		{name: "compatible too new", lookup: func() (*StateMachine, bool) { return repo.LatestCompatible("door", "1.4.0") }},
+		{name: "compatible invalid", lookup: func() (*StateMachine, bool) { return repo.LatestCompatible("door", "draft") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, found := tt.lookup()
+			if tt.want == "" {
+				if found {
+					t.Errorf("found version %s, want none", sm.Version)
+				}
+				return
+			}
+			if !found || sm.Version != tt.want {
+				t.Errorf("got %v (found %v), want version %s", sm, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelRepository_ResolveSubmachines(t *testing.T) {
+	repo := NewModelRepository()
+	for _, sm := range []*StateMachine{
+		newRepositoryMachine("door", "1.0.0"),
+		newRepositoryMachine("door", "1.2.0"),
+		newRepositoryMachine("door", "2.0.0"),
+		newRepositoryMachine("lock", "0.2.0"),
+	} {
+		if err := repo.Add(sm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nested := &State{Vertex: Vertex{ID: "nested", Type: "state"}, IsSubmachineState: true, Submachine: &StateMachine{ID: "lock"}}
+	sm := &StateMachine{
+		ID:      "house",
+		Version: "1.0.0",
+		Regions: []*Region{{
+			ID: "main",
+			States: []*State{
+				{Vertex: Vertex{ID: "front", Type: "state"}, IsSubmachineState: true, Submachine: &StateMachine{ID: "door", Version: "1.1"}},
+				{Vertex: Vertex{ID: "back", Type: "state"}, IsSubmachineState: true, Submachine: &StateMachine{ID: "door"}},
+				{Vertex: Vertex{ID: "garage", Type: "state"}, IsSubmachineState: true, Submachine: &StateMachine{ID: "door", Version: "3.0.0"}},
+				{Vertex: Vertex{ID: "hall", Type: "state"}, IsComposite: true, Regions: []*Region{{ID: "hall-region", States: []*State{nested}}}},
+			},
+		}},
+	}
+
+	err := repo.ResolveSubmachines(sm)
+	if err == nil || !strings.Contains(err.Error(), `state 'garage': no stored version of state machine 'door' is compatible with "3.0.0"`) {
+		t.Errorf("ResolveSubmachines() error = %v, want the unresolved garage reference", err)
+	}
+
+	states := sm.Regions[0].States
+	want := map[string]string{"front": "1.2.0", "back": "2.0.0", "garage": "3.0.0"}
+	for _, state := range states[:3] {
+		if got := state.Submachine.Version; got != want[state.ID] {
+			t.Errorf("state %s resolved to version %s, want %s", state.ID, got, want[state.ID])
+		}
+	}
+	if nested.Submachine.Version != "0.2.0" {
+		t.Errorf("nested state resolved to version %q, want 0.2.0", nested.Submachine.Version)
+	}
+	if repo.ResolveSubmachines(nil) != nil {
+		t.Error("ResolveSubmachines(nil) should not fail")
+	}
+}
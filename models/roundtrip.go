@@ -0,0 +1,141 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RoundTrip checks how faithfully a format preserves state machines. Each
+// fixture, and each of a number of machines from GenerateStateMachine, is
+// exported with Exporter, imported back with Importer and compared with the
+// original using Diff: semantic changes mean the pair is not faithful, other
+// changes are reported as lossy fields.
+type RoundTrip struct {
+	Exporter  Exporter
+	Importer  Importer
+	Options   ExportOptions    // Options passed to the exporter
+	Fixtures  []*StateMachine  // Hand-written machines to round-trip
+	Generated int              // Number of generated machines to round-trip
+	Seed      uint64           // Seed of the first generated machine; the others use the following seeds
+	Generator GeneratorOptions // Options for the generated machines
+}
+
+// RoundTripResult is the outcome of round-tripping one state machine
+type RoundTripResult struct {
+	StateMachine string   `json:"state_machine"`         // ID of the original state machine
+	Differences  []Change `json:"differences,omitempty"` // Semantic changes; the round trip failed
+	Lossy        []Change `json:"lossy,omitempty"`       // Changes that do not affect behavior, such as lost names
+	Error        string   `json:"error,omitempty"`       // Export or import failure
+}
+
+// Passed reports whether the state machine came back equivalent
+func (r RoundTripResult) Passed() bool {
+	return r.Error == "" && len(r.Differences) == 0
+}
+
+// RoundTripReport collects the results of a RoundTrip
+type RoundTripReport struct {
+	Format  string            `json:"format"` // "exporter -> importer"
+	Results []RoundTripResult `json:"results"`
+}
+
+// Passed reports whether every state machine came back equivalent
+func (r *RoundTripReport) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// LossyFields returns the fields the format loses, as sorted, de-duplicated
+// "Element.field" names, e.g. "StateMachine.created_at"
+func (r *RoundTripReport) LossyFields() []string {
+	seen := make(map[string]bool)
+	for _, result := range r.Results {
+		for _, change := range result.Lossy {
+			seen[change.Element+"."+change.Field] = true
+		}
+	}
+	fields := make([]string, 0, len(seen))
+	for field := range seen {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// String summarizes the report: the lossy fields and each failed state
+// machine with its differences
+func (r *RoundTripReport) String() string {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("%s round trip: %d state machine(s), %d failed", r.Format, len(r.Results), failed))
+	if lossy := r.LossyFields(); len(lossy) > 0 {
+		out.WriteString("\nlossy fields: " + strings.Join(lossy, ", "))
+	}
+	for _, result := range r.Results {
+		if result.Error != "" {
+			out.WriteString(fmt.Sprintf("\n- %s: %s", result.StateMachine, result.Error))
+		}
+		for _, change := range result.Differences {
+			out.WriteString(fmt.Sprintf("\n- %s: %s", result.StateMachine, change))
+		}
+	}
+	return out.String()
+}
+
+// Run round-trips the fixtures and generated state machines
+func (rt RoundTrip) Run() (*RoundTripReport, error) {
+	if rt.Exporter == nil || rt.Importer == nil {
+		return nil, fmt.Errorf("round trip needs an exporter and an importer")
+	}
+
+	report := &RoundTripReport{Format: rt.Exporter.Name() + " -> " + rt.Importer.Name()}
+	machines := append([]*StateMachine(nil), rt.Fixtures...)
+	for i := 0; i < rt.Generated; i++ {
+		machines = append(machines, GenerateStateMachine(rt.Seed+uint64(i), rt.Generator))
+	}
+	for _, sm := range machines {
+		if sm != nil {
+			report.Results = append(report.Results, rt.check(sm))
+		}
+	}
+	return report, nil
+}
+
+// check round-trips a single state machine
+func (rt RoundTrip) check(sm *StateMachine) RoundTripResult {
+	result := RoundTripResult{StateMachine: sm.ID}
+	var buf bytes.Buffer
+	if err := rt.Exporter.Export(sm, &buf, rt.Options); err != nil {
+		result.Error = fmt.Sprintf("export failed: %v", err)
+		return result
+	}
+	imported, _, err := rt.Importer.Import(&buf)
+	if err != nil {
+		result.Error = fmt.Sprintf("import failed: %v", err)
+		return result
+	}
+	if len(imported) != 1 {
+		result.Error = fmt.Sprintf("import returned %d state machines, want 1", len(imported))
+		return result
+	}
+
+	for _, change := range Diff(sm, imported[0]) {
+		if change.Semantic {
+			result.Differences = append(result.Differences, change)
+		} else {
+			result.Lossy = append(result.Lossy, change)
+		}
+	}
+	return result
+}
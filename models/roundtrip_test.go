@@ -0,0 +1,109 @@
+package models
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// editingExporter exports JSON after applying edit to a copy of the machine,
+// to simulate a format that loses information
+type editingExporter struct{ edit func(sm *StateMachine) }
+
+func (editingExporter) Name() string { return "edited" }
+
+func (e editingExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	copied := sm.Clone()
+	e.edit(copied)
+	return jsonExporter{}.Export(copied, w, opts)
+}
+
+func TestRoundTrip_JSON(t *testing.T) {
+	report, err := RoundTrip{
+		Exporter:  jsonExporter{},
+		Importer:  jsonImporter{},
+		Fixtures:  []*StateMachine{newPlayerMachine(), createValidStateMachine()},
+		Generated: 25,
+	}.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !report.Passed() || len(report.LossyFields()) > 0 {
+		t.Errorf("JSON should round-trip exactly:\n%s", report)
+	}
+	if len(report.Results) != 27 || report.Results[2].StateMachine != "generated-0" {
+		t.Errorf("results = %d, want the fixtures followed by the generated machines", len(report.Results))
+	}
+}
+
+func TestRoundTrip_LossyAndFailing(t *testing.T) {
+	lossy, err := RoundTrip{
+		Exporter:  editingExporter{edit: func(sm *StateMachine) { sm.Name, sm.Version = "", "" }},
+		Importer:  jsonImporter{},
+		Generated: 3,
+		Seed:      7,
+	}.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !lossy.Passed() || strings.Join(lossy.LossyFields(), ",") != "StateMachine.name,StateMachine.version" {
+		t.Errorf("losing names should pass and report lossy fields:\n%s", lossy)
+	}
+
+	dropGuards := func(sm *StateMachine) {
+		walkTransitions(sm.Regions, func(transition *Transition) { transition.Guard = nil })
+	}
+	sm := newPlayerMachine()
+	sm.Regions[0].Transitions[2].Guard = &Constraint{ID: "g", Specification: "confirmed"}
+	failing, err := RoundTrip{Exporter: editingExporter{edit: dropGuards}, Importer: jsonImporter{}, Fixtures: []*StateMachine{sm}}.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if failing.Passed() {
+		t.Fatal("dropping guards should fail the round trip")
+	}
+	want := "edited -> json round trip: 1 state machine(s), 1 failed\n- player: modified Transition 'stop' guard: \"confirmed\" -> \"\""
+	if failing.String() != want {
+		t.Errorf("String() =\n%s\nwant\n%s", failing, want)
+	}
+}
+
+// pairImporter returns every machine twice
+type pairImporter struct{}
+
+func (pairImporter) Name() string { return "pair" }
+
+func (pairImporter) Import(r io.Reader) ([]*StateMachine, *ImportReport, error) {
+	machines, report, err := jsonImporter{}.Import(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(machines, machines[0]), report, nil
+}
+
+func TestRoundTrip_Errors(t *testing.T) {
+	if _, err := (RoundTrip{Importer: jsonImporter{}}).Run(); err == nil {
+		t.Error("Run() without an exporter should fail")
+	}
+
+	tests := []struct {
+		name     string
+		exporter Exporter
+		importer Importer
+		want     string
+	}{
+		{name: "import failure", exporter: plantUMLExporter{}, importer: jsonImporter{}, want: "import failed"},
+		{name: "several machines", exporter: jsonExporter{}, importer: pairImporter{}, want: "import returned 2 state machines, want 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := RoundTrip{Exporter: tt.exporter, Importer: tt.importer, Fixtures: []*StateMachine{newPlayerMachine()}}.Run()
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if report.Passed() || !strings.Contains(report.Results[0].Error, tt.want) {
+				t.Errorf("result = %+v, want %q", report.Results[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RuleProfile collects the time validation spends in each check and on each
+// element, to find the constraint checks that dominate the validation time
+// of large machines. Profiling is opt-in: attach a profile with
+// ValidationContext.WithRuleProfile or WithRuleProfile. Checks are named
+// after the catalog rule they implement, e.g. "region.containment"; checks
+// covering several rules are named by object and topic, e.g.
+// "transition.kind" or "state.structural_integrity". A profile may be
+// shared by concurrent validations and accumulates until Reset.
+type RuleProfile struct {
+	mu       sync.Mutex
+	rules    map[string]*RuleTiming
+	elements map[elementKey]*ElementTiming
+}
+
+// RuleTiming is the time spent in one check
+type RuleTiming struct {
+	Rule  string        `json:"rule"`
+	Calls int           `json:"calls"` // Number of times the check ran, once per element checked
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"` // Longest single run
+}
+
+// Mean returns the average time of a run of the check
+func (t RuleTiming) Mean() time.Duration {
+	if t.Calls == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Calls)
+}
+
+// ElementTiming is the time spent validating one element, including the
+// elements nested in it, e.g. the regions of a composite state
+type ElementTiming struct {
+	ID       string        `json:"id"`
+	Kind     string        `json:"kind"` // StateMachine, Region, State, Pseudostate, FinalState, Vertex or Transition; states, pseudostates and final states are also timed as the Vertex they embed
+	Calls    int           `json:"calls"`
+	Duration time.Duration `json:"duration"`
+}
+
+// elementKey identifies an element in a profile
+type elementKey struct {
+	kind, id string
+}
+
+// NewRuleProfile creates an empty rule profile
+func NewRuleProfile() *RuleProfile {
+	return &RuleProfile{
+		rules:    make(map[string]*RuleTiming),
+		elements: make(map[elementKey]*ElementTiming),
+	}
+}
+
+// Rules returns the timings of the checks that ran, slowest in total first
+func (p *RuleProfile) Rules() []RuleTiming {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	timings := make([]RuleTiming, 0, len(p.rules))
+	for _, timing := range p.rules {
+		timings = append(timings, *timing)
+	}
+	slices.SortFunc(timings, func(a, b RuleTiming) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Rule, b.Rule))
+	})
+	return timings
+}
+
+// Elements returns the timings of the elements validated, slowest first
+func (p *RuleProfile) Elements() []ElementTiming {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	timings := make([]ElementTiming, 0, len(p.elements))
+	for _, timing := range p.elements {
+		timings = append(timings, *timing)
+	}
+	slices.SortFunc(timings, func(a, b ElementTiming) int {
+		return cmp.Or(cmp.Compare(b.Duration, a.Duration), cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.ID, b.ID))
+	})
+	return timings
+}
+
+// SlowestRules returns the n checks with the highest total time
+func (p *RuleProfile) SlowestRules(n int) []RuleTiming {
+	rules := p.Rules()
+	return rules[:min(max(n, 0), len(rules))]
+}
+
+// SlowestElements returns the n elements that took longest to validate
+func (p *RuleProfile) SlowestElements(n int) []ElementTiming {
+	elements := p.Elements()
+	return elements[:min(max(n, 0), len(elements))]
+}
+
+// Report returns a plain-text report of the n slowest checks and elements
+func (p *RuleProfile) Report(n int) string {
+	var b strings.Builder
+	rules := p.SlowestRules(n)
+	fmt.Fprintf(&b, "Slowest rules (%d of %d):\n", len(rules), len(p.Rules()))
+	for _, rule := range rules {
+		fmt.Fprintf(&b, "  %-40s %12s total %6d call(s) %12s mean %12s max\n", rule.Rule, rule.Total, rule.Calls, rule.Mean(), rule.Max)
+	}
+	elements := p.SlowestElements(n)
+	fmt.Fprintf(&b, "Slowest elements (%d of %d):\n", len(elements), len(p.Elements()))
+	for _, element := range elements {
+		fmt.Fprintf(&b, "  %-40s %12s\n", element.Kind+" "+element.ID, element.Duration)
+	}
+	return b.String()
+}
+
+// Reset discards the collected timings
+func (p *RuleProfile) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.rules)
+	clear(p.elements)
+}
+
+// recordRule adds a run of a check to the profile
+func (p *RuleProfile) recordRule(rule string, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	timing := p.rules[rule]
+	if timing == nil {
+		timing = &RuleTiming{Rule: rule}
+		p.rules[rule] = timing
+	}
+	timing.Calls++
+	timing.Total += elapsed
+	timing.Max = max(timing.Max, elapsed)
+}
+
+// recordElement adds the validation of an element to the profile
+func (p *RuleProfile) recordElement(kind, id string, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := elementKey{kind, id}
+	timing := p.elements[key]
+	if timing == nil {
+		timing = &ElementTiming{ID: id, Kind: kind}
+		p.elements[key] = timing
+	}
+	timing.Calls++
+	timing.Duration += elapsed
+}
+
+// WithRuleProfile returns a new context that records rule and element
+// timings in profile; nil stops profiling
+func (vc *ValidationContext) WithRuleProfile(profile *RuleProfile) *ValidationContext {
+	if vc == nil {
+		vc = NewValidationContext()
+	}
+	newCtx := *vc
+	newCtx.ruleProfile = profile
+	return &newCtx
+}
+
+// RuleProfile returns the profile timings are recorded in, or nil when the
+// context does not profile
+func (vc *ValidationContext) RuleProfile() *RuleProfile {
+	if vc == nil {
+		return nil
+	}
+	return vc.ruleProfile
+}
+
+// check runs a check of the given rule, timing it when profiling, and
+// records the rule on the findings it reports that nested checks did not
+// claim
+func (vc *ValidationContext) check(rule string, check func(*ValidationContext, *ValidationErrors), errors *ValidationErrors) {
+	errorCount, warningCount, infoCount := len(errors.Errors), len(errors.Warnings), len(errors.Infos)
+	defer func() {
+		for _, findings := range [][]*ValidationError{errors.Errors[errorCount:], errors.Warnings[warningCount:], errors.Infos[infoCount:]} {
+			for _, finding := range findings {
+				if finding != nil && finding.Rule == "" {
+					finding.Rule = rule
+				}
+			}
+		}
+	}()
+
+	profile := vc.RuleProfile()
+	if profile == nil {
+		check(vc, errors)
+		return
+	}
+	start := time.Now()
+	check(vc, errors)
+	profile.recordRule(rule, time.Since(start))
+}
+
+// timeElement starts timing the validation of an element and returns the
+// function that stops it, to be deferred
+func (vc *ValidationContext) timeElement(kind, id string) func() {
+	profile := vc.RuleProfile()
+	if profile == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { profile.recordElement(kind, id, time.Since(start)) }
+}
+
+// WithRuleProfile records the rule and element timings of the validation in
+// profile
+func WithRuleProfile(profile *RuleProfile) ValidationOption {
+	return func(o *ValidationOptions) { o.RuleProfile = profile }
+}
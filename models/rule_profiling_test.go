@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuleProfile_Validation(t *testing.T) {
+	profile := NewRuleProfile()
+	if err := createValidStateMachine().ValidateWithOptions(WithRuleProfile(profile)); err != nil {
+		t.Fatalf("ValidateWithOptions() error = %v", err)
+	}
+
+	rules := make(map[string]RuleTiming)
+	for _, timing := range profile.Rules() {
+		rules[timing.Rule] = timing
+	}
+	tests := []struct {
+		rule      string
+		wantCalls int
+	}{
+		{rule: "statemachine.version", wantCalls: 1},
+		{rule: "statemachine.references", wantCalls: 1},
+		{rule: "region.containment", wantCalls: 1},
+		{rule: "state.structural_integrity", wantCalls: 2},
+		{rule: "transition.endpoints", wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			if got := rules[tt.rule].Calls; got != tt.wantCalls {
+				t.Errorf("Calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+
+	elements := make(map[elementKey]ElementTiming)
+	for _, timing := range profile.Elements() {
+		elements[elementKey{timing.Kind, timing.ID}] = timing
+	}
+	for _, key := range []elementKey{{"StateMachine", "sm1"}, {"Region", "region1"}, {"State", "state1"}, {"Transition", "t2"}} {
+		if elements[key].Calls != 1 {
+			t.Errorf("element %s %s = %+v, want one call", key.kind, key.id, elements[key])
+		}
+	}
+	if machine, state := elements[elementKey{"StateMachine", "sm1"}], elements[elementKey{"State", "state1"}]; machine.Duration < state.Duration {
+		t.Errorf("machine duration %v < state duration %v, want inclusive timings", machine.Duration, state.Duration)
+	}
+}
+
+func TestRuleProfile_Disabled(t *testing.T) {
+	profile := NewRuleProfile()
+	context := NewValidationContext().WithRuleProfile(profile).WithRuleProfile(nil)
+	if context.RuleProfile() != nil {
+		t.Fatal("RuleProfile() != nil after WithRuleProfile(nil)")
+	}
+	createValidStateMachine().ValidateWithErrors(context, &ValidationErrors{})
+	if len(profile.Rules()) != 0 || len(profile.Elements()) != 0 {
+		t.Errorf("profile recorded %d rule(s) and %d element(s), want none", len(profile.Rules()), len(profile.Elements()))
+	}
+	var nilContext *ValidationContext
+	if nilContext.RuleProfile() != nil {
+		t.Error("nil context RuleProfile() != nil")
+	}
+}
+
+func TestRuleProfile_Reports(t *testing.T) {
+	profile := NewRuleProfile()
+	profile.recordRule("b.fast", time.Millisecond)
+	profile.recordRule("a.slow", 3*time.Millisecond)
+	profile.recordRule("a.slow", 5*time.Millisecond)
+	profile.recordRule("c.fast", time.Millisecond)
+	profile.recordElement("State", "s1", 2*time.Millisecond)
+	profile.recordElement("Region", "r1", 9*time.Millisecond)
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{name: "top one", n: 1, want: []string{"a.slow"}},
+		{name: "ties by name", n: 3, want: []string{"a.slow", "b.fast", "c.fast"}},
+		{name: "more than recorded", n: 10, want: []string{"a.slow", "b.fast", "c.fast"}},
+		{name: "negative", n: -1, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MapSlice(profile.SlowestRules(tt.n), func(timing RuleTiming) string { return timing.Rule })
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SlowestRules(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+
+	slow := profile.SlowestRules(1)[0]
+	if slow.Calls != 2 || slow.Total != 8*time.Millisecond || slow.Max != 5*time.Millisecond || slow.Mean() != 4*time.Millisecond {
+		t.Errorf("slowest rule = %+v, mean %v", slow, slow.Mean())
+	}
+	if (RuleTiming{}).Mean() != 0 {
+		t.Error("Mean() of an unrun rule != 0")
+	}
+	if elements := profile.SlowestElements(1); len(elements) != 1 || elements[0].ID != "r1" {
+		t.Errorf("SlowestElements(1) = %v, want r1", elements)
+	}
+
+	report := profile.Report(2)
+	for _, want := range []string{"Slowest rules (2 of 3):", "a.slow", "8ms total", "Slowest elements (2 of 2):", "Region r1"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report() missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "c.fast") {
+		t.Errorf("Report(2) lists a third rule:\n%s", report)
+	}
+
+	profile.Reset()
+	if len(profile.Rules()) != 0 || len(profile.Elements()) != 0 {
+		t.Error("Reset() kept timings")
+	}
+}
+
+func TestRuleProfile_Bulk(t *testing.T) {
+	profile := NewRuleProfile()
+	machines := []*StateMachine{createValidStateMachine(), newPlayerMachine()}
+	if _, err := ValidateAll(context.Background(), machines, BulkValidationOptions{RuleProfile: profile}); err != nil {
+		t.Fatalf("ValidateAll() error = %v", err)
+	}
+	for _, timing := range profile.Rules() {
+		if timing.Rule == "statemachine.version" && timing.Calls != len(machines) {
+			t.Errorf("statemachine.version Calls = %d, want %d", timing.Calls, len(machines))
+		}
+	}
+
+	profile.Reset()
+	if _, err := ValidateAllWithOptions(machines, WithRuleProfile(profile)); err != nil {
+		t.Fatalf("ValidateAllWithOptions() error = %v", err)
+	}
+	if len(profile.Elements()) == 0 {
+		t.Error("ValidateAllWithOptions() recorded no element timings")
+	}
+}
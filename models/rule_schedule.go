@@ -0,0 +1,147 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ruleDependencies declares the rules that must pass before a rule is
+// checked. Analyses of the transition graph only make sense once its
+// references resolve; a rule whose prerequisites reported errors is skipped
+// rather than reporting follow-on errors, and the skip is recorded in
+// ValidationErrors.Skipped.
+var ruleDependencies = map[string][]string{
+	"transition.orthogonal_isolation": {"statemachine.references", "statemachine.endpoints"},
+	"transition.local_boundary":       {"statemachine.references"},
+	"statemachine.shared_terminate":   {"statemachine.submachine_recursion"},
+}
+
+// RuleDependencies returns the IDs of the rules that must pass before the
+// rule with the given ID is checked, or nil if it has no prerequisites
+func RuleDependencies(id string) []string {
+	return slices.Clone(ruleDependencies[id])
+}
+
+// SkippedRule records a rule that was not checked because rules it depends
+// on failed
+type SkippedRule struct {
+	Rule    string   `json:"rule"`           // Catalog ID of the skipped rule
+	Because []string `json:"because"`        // Prerequisites that failed or were skipped themselves
+	Path    []string `json:"path,omitempty"` // Location of the element the rule was skipped for
+}
+
+// String returns a concise one-line description of the skip
+func (s SkippedRule) String() string {
+	description := fmt.Sprintf("rule %s skipped: %s did not pass", s.Rule, joinWords(s.Because, "and"))
+	if len(s.Path) > 0 {
+		description += " at " + strings.Join(s.Path, ".")
+	}
+	return description
+}
+
+// rulePass is a check run as part of a scheduled group of rules
+type rulePass struct {
+	rule  string // Catalog ID of the rule the pass checks
+	check func(sm *StateMachine, context *ValidationContext, errors *ValidationErrors)
+}
+
+// structuralRulePasses are the structural integrity checks of a state
+// machine, in declaration order; runRulePasses reorders them to satisfy
+// ruleDependencies
+var structuralRulePasses = []rulePass{
+	{"statemachine.references", (*StateMachine).validateReferences},
+	{"statemachine.endpoints", (*StateMachine).validateEndpointIdentity},
+	{"transition.orthogonal_isolation", (*StateMachine).validateOrthogonalIsolation},
+	{"transition.local_boundary", (*StateMachine).validateLocalBoundaries},
+	{"statemachine.submachine_recursion", (*StateMachine).validateSubmachineRecursion},
+	{"statemachine.shared_terminate", (*StateMachine).validateSharedTerminates},
+	{"statemachine.probabilities", (*StateMachine).validateProbabilityGroups},
+	{"transition.duplicates", (*StateMachine).validateDuplicateTransitions},
+}
+
+// scheduleRulePasses orders passes so that every pass runs after the passes
+// of the rules it depends on, keeping declaration order otherwise. It fails
+// on dependency cycles and on dependencies on rules none of the passes
+// check.
+func scheduleRulePasses(passes []rulePass, dependencies map[string][]string) ([]rulePass, error) {
+	checked := make(map[string]bool, len(passes))
+	for _, pass := range passes {
+		checked[pass.rule] = true
+	}
+	for _, pass := range passes {
+		for _, dependency := range dependencies[pass.rule] {
+			if !checked[dependency] {
+				return nil, fmt.Errorf("rule %s depends on %s, which is not scheduled", pass.rule, dependency)
+			}
+		}
+	}
+
+	ordered := make([]rulePass, 0, len(passes))
+	scheduled := make(map[string]bool, len(passes))
+	for len(ordered) < len(passes) {
+		progressed := false
+		for _, pass := range passes {
+			if scheduled[pass.rule] || !allScheduled(dependencies[pass.rule], scheduled) {
+				continue
+			}
+			ordered = append(ordered, pass)
+			scheduled[pass.rule] = true
+			progressed = true
+			break // Restart so earlier declarations go first
+		}
+		if !progressed {
+			var blocked []string
+			for _, pass := range passes {
+				if !scheduled[pass.rule] {
+					blocked = append(blocked, pass.rule)
+				}
+			}
+			return nil, fmt.Errorf("rule dependency cycle among %s", strings.Join(blocked, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// allScheduled reports whether every rule is scheduled
+func allScheduled(rules []string, scheduled map[string]bool) bool {
+	for _, rule := range rules {
+		if !scheduled[rule] {
+			return false
+		}
+	}
+	return true
+}
+
+// runRulePasses runs passes in dependency order. A pass fails when it
+// reports errors; passes depending on a failed or skipped rule are skipped
+// and recorded in errors.Skipped.
+func runRulePasses(sm *StateMachine, passes []rulePass, context *ValidationContext, errors *ValidationErrors) {
+	ordered, err := scheduleRulePasses(passes, ruleDependencies)
+	if err != nil {
+		// The built-in declarations are checked by tests; an inconsistent
+		// schedule is a programming error
+		panic(err)
+	}
+
+	passed := make(map[string]bool, len(ordered))
+	for _, pass := range ordered {
+		var failed []string
+		for _, dependency := range ruleDependencies[pass.rule] {
+			if !passed[dependency] {
+				failed = append(failed, dependency)
+			}
+		}
+		if len(failed) > 0 {
+			skipped := SkippedRule{Rule: pass.rule, Because: failed}
+			if len(context.Path) > 0 {
+				skipped.Path = slices.Clone(context.Path)
+			}
+			errors.Skipped = append(errors.Skipped, skipped)
+			continue
+		}
+		before := len(errors.Errors)
+		context.check(pass.rule, func(context *ValidationContext, errors *ValidationErrors) { pass.check(sm, context, errors) }, errors)
+		passed[pass.rule] = len(errors.Errors) == before
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// passRules returns the rule IDs of passes in order
+func passRules(passes []rulePass) []string {
+	return MapSlice(passes, func(pass rulePass) string { return pass.rule })
+}
+
+func TestScheduleRulePasses(t *testing.T) {
+	noop := func(*StateMachine, *ValidationContext, *ValidationErrors) {}
+	passes := func(rules ...string) []rulePass {
+		return MapSlice(rules, func(rule string) rulePass { return rulePass{rule, noop} })
+	}
+
+	tests := []struct {
+		name         string
+		passes       []rulePass
+		dependencies map[string][]string
+		want         []string
+		wantErr      string
+	}{
+		{
+			name:   "declaration order without dependencies",
+			passes: passes("a", "b", "c"),
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:         "prerequisites declared later run first",
+			passes:       passes("graph", "other", "resolve"),
+			dependencies: map[string][]string{"graph": {"resolve"}},
+			want:         []string{"other", "resolve", "graph"},
+		},
+		{
+			name:         "transitive dependencies",
+			passes:       passes("c", "b", "a"),
+			dependencies: map[string][]string{"c": {"b"}, "b": {"a"}},
+			want:         []string{"a", "b", "c"},
+		},
+		{
+			name:         "cycle",
+			passes:       passes("a", "b", "c"),
+			dependencies: map[string][]string{"a": {"b"}, "b": {"a"}},
+			wantErr:      "rule dependency cycle among a, b",
+		},
+		{
+			name:         "unscheduled dependency",
+			passes:       passes("a"),
+			dependencies: map[string][]string{"a": {"missing"}},
+			wantErr:      "rule a depends on missing, which is not scheduled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := scheduleRulePasses(tt.passes, tt.dependencies)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got := passRules(ordered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleDependencies_BuiltIn(t *testing.T) {
+	if _, err := scheduleRulePasses(structuralRulePasses, ruleDependencies); err != nil {
+		t.Fatalf("built-in rule schedule: %v", err)
+	}
+
+	catalog := MapSlice(Rules(), func(rule RuleInfo) string { return rule.ID })
+	for rule, dependencies := range ruleDependencies {
+		for _, id := range append([]string{rule}, dependencies...) {
+			if !slices.Contains(catalog, id) {
+				t.Errorf("rule dependency on %s, which is not in the rule catalog", id)
+			}
+		}
+	}
+
+	if got := RuleDependencies("statemachine.shared_terminate"); !reflect.DeepEqual(got, []string{"statemachine.submachine_recursion"}) {
+		t.Errorf("RuleDependencies(shared_terminate) = %v", got)
+	}
+	if got := RuleDependencies("statemachine.references"); got != nil {
+		t.Errorf("RuleDependencies(references) = %v, want nil", got)
+	}
+}
+
+func TestRunRulePasses_Skips(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() *StateMachine
+		want  []SkippedRule
+	}{
+		{
+			name:  "valid machine",
+			build: createValidStateMachine,
+		},
+		{
+			name: "unresolved transition target skips the graph analyses",
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				sm.Regions[0].Transitions[1].Target = &Vertex{ID: "ghost", Name: "Ghost", Type: "state"}
+				return sm
+			},
+			want: []SkippedRule{
+				{Rule: "transition.orthogonal_isolation", Because: []string{"statemachine.references"}},
+				{Rule: "transition.local_boundary", Because: []string{"statemachine.references"}},
+			},
+		},
+		{
+			name: "submachine recursion skips the shared terminate analysis",
+			build: func() *StateMachine {
+				a := newSubmachineFixture("A")
+				includeSubmachine(a, a)
+				return a
+			},
+			want: []SkippedRule{{Rule: "statemachine.shared_terminate", Because: []string{"statemachine.submachine_recursion"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := &ValidationErrors{}
+			tt.build().ValidateWithErrors(NewValidationContext(), errors)
+			if !reflect.DeepEqual(errors.Skipped, tt.want) {
+				t.Errorf("Skipped = %v, want %v", errors.Skipped, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWithSummary_Skipped(t *testing.T) {
+	sm := newSubmachineFixture("A")
+	includeSubmachine(sm, sm)
+
+	summary, err := sm.ValidateWithSummary(nil)
+	if err == nil {
+		t.Fatal("ValidateWithSummary() error = nil, want a recursion error")
+	}
+	if len(summary.Skipped) != 1 || summary.Skipped[0].Rule != "statemachine.shared_terminate" {
+		t.Fatalf("Skipped = %v, want the shared terminate rule", summary.Skipped)
+	}
+	if !strings.HasSuffix(summary.String(), ", 1 rule(s) skipped") {
+		t.Errorf("String() = %q, want the skipped rule count", summary.String())
+	}
+	want := "rule statemachine.shared_terminate skipped: statemachine.submachine_recursion did not pass"
+	if got := summary.Skipped[0].String(); got != want {
+		t.Errorf("SkippedRule.String() = %q, want %q", got, want)
+	}
+}
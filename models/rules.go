@@ -0,0 +1,209 @@
+package models
+
+// RuleInfo describes a validation rule for display in editors and reports
+type RuleInfo struct {
+	ID          string `json:"id"`               // Stable identifier, e.g. "pseudostate.choice.name"
+	Object      string `json:"object"`           // Object type the rule applies to, e.g. "Pseudostate"
+	Description string `json:"description"`      // What the rule requires
+	Clause      string `json:"clause,omitempty"` // UML specification clause, if the rule comes from UML
+}
+
+// ruleEntry pairs rule metadata with a predicate selecting the elements it applies to
+type ruleEntry struct {
+	info    RuleInfo
+	applies func(obj interface{}) bool
+}
+
+// Predicates used by the rule catalog
+func anyElement(interface{}) bool         { return true }
+func isStateMachine(obj interface{}) bool { _, ok := obj.(*StateMachine); return ok }
+func isRegion(obj interface{}) bool       { _, ok := obj.(*Region); return ok }
+func isTransition(obj interface{}) bool   { _, ok := obj.(*Transition); return ok }
+func isTrigger(obj interface{}) bool      { _, ok := obj.(*Trigger); return ok }
+func isEvent(obj interface{}) bool        { _, ok := obj.(*Event); return ok }
+func isBehavior(obj interface{}) bool     { _, ok := obj.(*Behavior); return ok }
+func isConstraint(obj interface{}) bool   { _, ok := obj.(*Constraint); return ok }
+func isFinalState(obj interface{}) bool   { _, ok := obj.(*FinalState); return ok }
+func isState(obj interface{}) bool        { _, ok := obj.(*State); return ok }
+
+// isVertexLike reports whether obj is any vertex type
+func isVertexLike(obj interface{}) bool {
+	switch obj.(type) {
+	case *Vertex, *State, *Pseudostate, *FinalState:
+		return true
+	}
+	return false
+}
+
+// isStateWhere returns a predicate matching states that satisfy cond
+func isStateWhere(cond func(*State) bool) func(interface{}) bool {
+	return func(obj interface{}) bool {
+		s, ok := obj.(*State)
+		return ok && cond(s)
+	}
+}
+
+// isPseudostateKind returns a predicate matching pseudostates of the given kinds
+func isPseudostateKind(kinds ...PseudostateKind) func(interface{}) bool {
+	return func(obj interface{}) bool {
+		ps, ok := obj.(*Pseudostate)
+		if !ok {
+			return false
+		}
+		if len(kinds) == 0 {
+			return true
+		}
+		for _, kind := range kinds {
+			if ps.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ruleCatalog lists the built-in validation rules in the order they run
+var ruleCatalog = []ruleEntry{
+	// Identity and naming, shared by all identified elements
+	{RuleInfo{"element.id.required", "*", "ID is required and must satisfy the profile's ID policy", ""}, anyElement},
+	{RuleInfo{"element.name.policy", "*", "Name must satisfy the profile's name policy, if one is configured", ""}, anyElement},
+
+	// State machines
+	{RuleInfo{"statemachine.required", "StateMachine", "Name and version are required", ClauseStateMachine}, isStateMachine},
+	{RuleInfo{"statemachine.version", "StateMachine", "Version is a semantic version, if the profile sets a version policy", ""}, isStateMachine},
+	{RuleInfo{"statemachine.created_at", "StateMachine", "CreatedAt is set and not in the future beyond the allowed skew, if the profile sets a timestamp policy", ""}, isStateMachine},
+	{RuleInfo{"statemachine.regions.multiplicity", "StateMachine", "At least one region is required", ClauseStateMachine}, isStateMachine},
+	{RuleInfo{"statemachine.connection_points", "StateMachine", "Connection points must be entry or exit point pseudostates", ClauseConnectionPoints}, isStateMachine},
+	{RuleInfo{"statemachine.method", "StateMachine", "A state machine used as a method cannot have connection points", ClauseMethod}, isStateMachine},
+	{RuleInfo{"statemachine.events", "StateMachine", "Catalog event IDs are unique and triggers resolve to catalog events", ClauseEvents}, isStateMachine},
+	{RuleInfo{"statemachine.entities", "StateMachine", "Entities have names and paths, transitions and behaviors only use entities of the state machine, and entity paths exist if the profile sets an entity resolver", ""}, isStateMachine},
+	{RuleInfo{"statemachine.entity_placeholders", "StateMachine", "Entity placeholders in behavior and guard specifications resolve in the state machine's entities if the profile sets a placeholder pattern", ""}, isStateMachine},
+	{RuleInfo{"statemachine.deprecations", "StateMachine", "Replacements of deprecated states, transitions and events exist; uses of deprecated elements are reported as infos", ""}, isStateMachine},
+	{RuleInfo{"statemachine.references", "StateMachine", "Transition endpoints, submachines and connection point references resolve to elements of the state machine, and region and connection point IDs are unique", ClauseRegionContainment}, isStateMachine},
+	{RuleInfo{"statemachine.endpoints", "StateMachine", "Transition endpoints match the declared vertices with the same ID", ClauseRegionContainment}, isStateMachine},
+	{RuleInfo{"statemachine.submachine_recursion", "StateMachine", "A state machine does not include itself directly or through a chain of submachines", ClauseSubmachineStates}, isStateMachine},
+	{RuleInfo{"statemachine.shared_terminate", "StateMachine", "Submachines shared by more than one machine have no terminate pseudostates unless they set AllowSharedTerminate", ClauseTerminate}, isStateMachine},
+	{RuleInfo{"statemachine.compatibility", "StateMachine", "The state machine and its submachines only use UML features the target platform supports, if the profile sets a compatibility profile", ""}, isStateMachine},
+	{RuleInfo{"statemachine.policies", "StateMachine", "The state machine satisfies the organizational modeling standards the profile declares as policies", ""}, isStateMachine},
+	{RuleInfo{"statemachine.probabilities", "StateMachine", "Probabilities of transitions leaving a vertex on the same triggers sum to at most 1", ""}, isStateMachine},
+
+	// Regions
+	{RuleInfo{"region.name.required", "Region", "Name is required", ClauseRegion}, isRegion},
+	{RuleInfo{"region.initial.multiplicity", "Region", "A region has at most one initial pseudostate", ClauseInitialVertex}, isRegion},
+	{RuleInfo{"region.containment", "Region", "Each vertex is declared exactly once, states in States and other vertices in Vertices", ClauseRegionContainment}, isRegion},
+	{RuleInfo{"region.transition.scope", "Region", "Transitions connect vertices contained in the region, except external transitions", ClauseRegionContainment}, isRegion},
+
+	// Vertices
+	{RuleInfo{"vertex.name.required", "Vertex", "Name and type are required; type is state, pseudostate or finalstate", ClauseVertex}, isVertexLike},
+
+	// States
+	{RuleInfo{"state.kind.exclusive", "State", "A state is exactly one of simple, composite or submachine", ClauseStateKindAttributes}, isState},
+	{RuleInfo{"state.behaviors", "State", "Entry, exit and do activity behaviors are valid and distinct", ClauseStateBehaviors}, isState},
+	{RuleInfo{"state.composite.regions", "State", "A composite state has at least one region", ClauseStateKindAttributes}, isStateWhere(func(s *State) bool { return s.IsComposite })},
+	{RuleInfo{"state.orthogonal.isolation", "State", "Transitions do not connect vertices in different regions of the orthogonal state", ClauseTransition}, isStateWhere(func(s *State) bool { return s.IsOrthogonal })},
+	{RuleInfo{"state.orthogonal.regions", "State", "An orthogonal state is composite and has at least two regions, each with an initial pseudostate", ClauseStateKindAttributes}, isStateWhere(func(s *State) bool { return s.IsOrthogonal })},
+	{RuleInfo{"state.simple.no_regions", "State", "A simple state has no regions", ClauseStateKindAttributes}, isStateWhere(func(s *State) bool { return !s.IsComposite && !s.IsSubmachineState })},
+	{RuleInfo{"state.submachine.reference", "State", "A submachine state references a valid state machine other than its container", ClauseSubmachineStates}, isStateWhere(func(s *State) bool { return s.IsSubmachineState })},
+	{RuleInfo{"state.submachine.no_regions", "State", "A submachine state has no regions of its own and is not composite", ClauseSubmachineOrRegions}, isStateWhere(func(s *State) bool { return s.IsSubmachineState })},
+	{RuleInfo{"state.submachine.connections", "State", "Connection point references resolve to entry and exit points of the submachine", ClauseCPREntryPseudostates}, isStateWhere(func(s *State) bool { return s.IsSubmachineState })},
+
+	// Pseudostates
+	{RuleInfo{"pseudostate.kind", "Pseudostate", "Kind is a valid pseudostate kind and type is pseudostate", ClausePseudostate}, isPseudostateKind()},
+	{RuleInfo{"pseudostate.name.keywords", "Pseudostate", "Name contains a keyword configured for the kind, if the profile sets keywords", ""}, isPseudostateKind()},
+	{RuleInfo{"pseudostate.initial.name", "Pseudostate", "An initial pseudostate has a descriptive name", ClauseInitialVertex}, isPseudostateKind(PseudostateKindInitial)},
+	{RuleInfo{"pseudostate.initial.incoming", "Pseudostate", "An initial pseudostate is not the target of transitions in its region", ClauseInitialVertex}, isPseudostateKind(PseudostateKindInitial)},
+	{RuleInfo{"pseudostate.initial.multiplicity", "Pseudostate", "A region has at most one initial pseudostate", ClauseInitialVertex}, isPseudostateKind(PseudostateKindInitial)},
+	{RuleInfo{"pseudostate.history.placement", "Pseudostate", "A history pseudostate is contained in a region of a composite state", ClauseHistoryVertices}, isPseudostateKind(PseudostateKindDeepHistory, PseudostateKindShallowHistory)},
+	{RuleInfo{"pseudostate.history.multiplicity", "Pseudostate", "A region has at most one history pseudostate of each kind", ClauseHistoryVertices}, isPseudostateKind(PseudostateKindDeepHistory, PseudostateKindShallowHistory)},
+	{RuleInfo{"pseudostate.join.name", "Pseudostate", "A join pseudostate has a descriptive name", ClauseJoinVertex}, isPseudostateKind(PseudostateKindJoin)},
+	{RuleInfo{"pseudostate.fork.name", "Pseudostate", "A fork pseudostate has a descriptive name", ClauseForkVertex}, isPseudostateKind(PseudostateKindFork)},
+	{RuleInfo{"pseudostate.junction.name", "Pseudostate", "A junction pseudostate has a descriptive name", ClauseJunctionVertex}, isPseudostateKind(PseudostateKindJunction)},
+	{RuleInfo{"pseudostate.choice.name", "Pseudostate", "A choice pseudostate has a descriptive name", ClauseChoiceVertex}, isPseudostateKind(PseudostateKindChoice)},
+	{RuleInfo{"pseudostate.connection_point.placement", "Pseudostate", "Entry and exit points are used as connection points, not as regular region vertices", ClauseEntryOrExit}, isPseudostateKind(PseudostateKindEntryPoint, PseudostateKindExitPoint)},
+	{RuleInfo{"pseudostate.terminate.name", "Pseudostate", "A terminate pseudostate has a descriptive name", ClauseTerminate}, isPseudostateKind(PseudostateKindTerminate)},
+	{RuleInfo{"pseudostate.terminate.outgoing", "Pseudostate", "A terminate pseudostate has no outgoing transitions", ClauseTerminate}, isPseudostateKind(PseudostateKindTerminate)},
+
+	// Final states
+	{RuleInfo{"finalstate.name", "FinalState", "A final state has a name; keyword checks apply if the profile sets keywords", ClauseFinalState}, isFinalState},
+	{RuleInfo{"finalstate.no_outgoing", "FinalState", "A final state has no outgoing transitions", ClauseFinalStateNoOutgoing}, isFinalState},
+	{RuleInfo{"finalstate.not_connection_point", "FinalState", "A final state is not used as a connection point", ClauseConnectionPoints}, isFinalState},
+	{RuleInfo{"finalstate.reachable", "FinalState", "A final state has incoming transitions and can be reached from the initial pseudostate of its region", ClauseFinalState}, isFinalState},
+
+	// Transitions
+	{RuleInfo{"transition.endpoints", "Transition", "Source and target are required and kind is internal, local or external", ClauseTransition}, isTransition},
+	{RuleInfo{"transition.internal", "Transition", "An internal transition has the same state as source and target", ClauseStateIsInternal}, isTransition},
+	{RuleInfo{"transition.local", "Transition", "A local transition stays within its composite state and does not use connection points", ClauseStateIsLocal}, isTransition},
+	{RuleInfo{"transition.local_boundary", "Transition", "A local transition leaving a composite state targets the state itself or a vertex nested in it", ClauseStateIsLocal}, isTransition},
+	{RuleInfo{"transition.external", "Transition", "An external transition's target exists in some region of the state machine", ClauseStateIsExternal}, isTransition},
+	{RuleInfo{"transition.duplicates", "Transition", "No two transitions have the same source, target, kind, trigger set and guard; duplicates are reported as warnings", ""}, isTransition},
+	{RuleInfo{"transition.orthogonal_isolation", "Transition", "A transition does not connect sibling orthogonal regions directly; it goes through a fork, a join or the composite boundary", ClauseTransition}, isTransition},
+	{RuleInfo{"transition.final_source", "Transition", "A final state is never the source of a transition", ClauseFinalStateNoOutgoing}, isTransition},
+	{RuleInfo{"transition.trigger_placement", "Transition", "Transitions leaving pseudostates have no triggers, except the initial transition of a top-level region; untriggered transitions leaving states are reported as completion transitions", ClauseTransition}, isTransition},
+	{RuleInfo{"transition.segment_guards", "Transition", "The initial transition and fork segments have no guards; join segments have neither guards nor triggers", ClauseJoinSegmentGuards}, isTransition},
+	{RuleInfo{"transition.probability", "Transition", "An annotated probability lies between 0 and 1", ""}, isTransition},
+	{RuleInfo{"transition.triggers", "Transition", "Triggers are valid and do not reference the same event twice", ClauseEvents}, isTransition},
+	{RuleInfo{"transition.guard_effect", "Transition", "Guard and effect are valid constraints and behaviors with distinct IDs", ClauseTransitionGuard}, isTransition},
+
+	// Triggers, events, behaviors and constraints
+	{RuleInfo{"trigger.event", "Trigger", "A trigger has a name and an embedded event or a catalog event ID", ClauseEvents}, isTrigger},
+	{RuleInfo{"event.type", "Event", "Name is required, type is a registered event type and custom type validators pass", ClauseEvents}, isEvent},
+	{RuleInfo{"behavior.specification", "Behavior", "A behavior has a specification", ClauseBehaviors}, isBehavior},
+	{RuleInfo{"constraint.specification", "Constraint", "A constraint has a specification", ClauseConstraints}, isConstraint},
+}
+
+// Rules returns the metadata of every built-in validation rule
+func Rules() []RuleInfo {
+	rules := make([]RuleInfo, len(ruleCatalog))
+	for i, entry := range ruleCatalog {
+		rules[i] = entry.info
+	}
+	return rules
+}
+
+// ApplicableRules returns the metadata of the rules that validation runs for
+// obj, taking its type and kind into account. For example, a choice
+// pseudostate gets the choice rules but not the history rules, and a
+// submachine state gets the submachine reference rules. Unknown and nil
+// objects have no applicable rules.
+func ApplicableRules(obj interface{}) []RuleInfo {
+	if !isModelElement(obj) {
+		return nil
+	}
+
+	var rules []RuleInfo
+	for _, entry := range ruleCatalog {
+		if entry.applies(obj) {
+			rules = append(rules, entry.info)
+		}
+	}
+	return rules
+}
+
+// isModelElement reports whether obj is a non-nil pointer to a model element type
+func isModelElement(obj interface{}) bool {
+	switch v := obj.(type) {
+	case *StateMachine:
+		return v != nil
+	case *Region:
+		return v != nil
+	case *Vertex:
+		return v != nil
+	case *State:
+		return v != nil
+	case *Pseudostate:
+		return v != nil
+	case *FinalState:
+		return v != nil
+	case *Transition:
+		return v != nil
+	case *Trigger:
+		return v != nil
+	case *Event:
+		return v != nil
+	case *Behavior:
+		return v != nil
+	case *Constraint:
+		return v != nil
+	}
+	return false
+}
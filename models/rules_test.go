@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestApplicableRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		obj         interface{}
+		wantRules   []string
+		absentRules []string
+	}{
+		{
+			name:        "choice pseudostate",
+			obj:         &Pseudostate{Vertex: Vertex{ID: "c", Name: "Choice", Type: "pseudostate"}, Kind: PseudostateKindChoice},
+			wantRules:   []string{"element.id.required", "vertex.name.required", "pseudostate.kind", "pseudostate.choice.name"},
+			absentRules: []string{"pseudostate.history.placement", "pseudostate.initial.name", "state.kind.exclusive"},
+		},
+		{
+			name:        "submachine state",
+			obj:         &State{Vertex: Vertex{ID: "s", Name: "Sub", Type: "state"}, IsSubmachineState: true},
+			wantRules:   []string{"state.kind.exclusive", "state.submachine.reference", "state.submachine.no_regions", "state.submachine.connections"},
+			absentRules: []string{"state.simple.no_regions", "state.composite.regions"},
+		},
+		{
+			name:        "simple state",
+			obj:         &State{Vertex: Vertex{ID: "s", Name: "Idle", Type: "state"}, IsSimple: true},
+			wantRules:   []string{"state.simple.no_regions"},
+			absentRules: []string{"state.submachine.reference"},
+		},
+		{
+			name:      "transition",
+			obj:       &Transition{ID: "t"},
+			wantRules: []string{"transition.internal", "transition.guard_effect"},
+		},
+		{
+			name:      "state machine",
+			obj:       &StateMachine{ID: "sm"},
+			wantRules: []string{"statemachine.connection_points", "statemachine.method"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]RuleInfo)
+			for _, rule := range ApplicableRules(tt.obj) {
+				got[rule.ID] = rule
+			}
+			for _, id := range tt.wantRules {
+				if _, exists := got[id]; !exists {
+					t.Errorf("ApplicableRules() missing rule %q", id)
+				}
+			}
+			for _, id := range tt.absentRules {
+				if _, exists := got[id]; exists {
+					t.Errorf("ApplicableRules() should not include rule %q", id)
+				}
+			}
+		})
+	}
+
+	if got := ApplicableRules(nil); got != nil {
+		t.Errorf("ApplicableRules(nil) = %v, want nil", got)
+	}
+	if got := ApplicableRules((*State)(nil)); got != nil {
+		t.Errorf("ApplicableRules(nil state) = %v, want nil", got)
+	}
+	if got := ApplicableRules("state"); got != nil {
+		t.Errorf("ApplicableRules(string) = %v, want nil", got)
+	}
+}
+
+func TestRules(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range Rules() {
+		if rule.ID == "" || rule.Object == "" || rule.Description == "" {
+			t.Errorf("rule %+v has empty metadata", rule)
+		}
+		if seen[rule.ID] {
+			t.Errorf("duplicate rule ID %q", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+
+	choiceRules := ApplicableRules(&Pseudostate{Kind: PseudostateKindChoice})
+	for _, r := range choiceRules {
+		if r.ID == "pseudostate.choice.name" && r.Clause != ClauseChoiceVertex {
+			t.Errorf("choice rule clause = %q, want %q", r.Clause, ClauseChoiceVertex)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import "fmt"
+
+// SanitizeFix describes a single automatic correction applied by Sanitize
+type SanitizeFix struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns a concise one-line description of the fix
+func (f SanitizeFix) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Rule, f.Path, f.Message)
+}
+
+// Sanitize applies automatic fixes for structural problems that have a single
+// safe correction, modifying sm in place, and returns the fixes applied in
+// model order. Problems without an unambiguous fix are left for validation to
+// report.
+//
+// Fixes:
+//   - vertex-containment: a state that is also listed in its region's
+//     Vertices collection is removed from Vertices, and transitions that
+//     pointed at the removed entry are pointed at the state instead
+func Sanitize(sm *StateMachine) []SanitizeFix {
+	if sm == nil {
+		return nil
+	}
+
+	var fixes []SanitizeFix
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		fixes = append(fixes, sanitizeVertexContainment(sm, region, path)...)
+	})
+	sm.refreshAdjacency()
+	return fixes
+}
+
+// sanitizeVertexContainment removes state duplicates from the region's
+// Vertices collection. Entries whose type disagrees with the state are
+// ambiguous and are kept.
+func sanitizeVertexContainment(sm *StateMachine, region *Region, path string) []SanitizeFix {
+	states := make(map[string]*State)
+	for _, state := range region.States {
+		if state != nil {
+			states[state.ID] = state
+		}
+	}
+
+	var fixes []SanitizeFix
+	replaced := make(map[*Vertex]*Vertex)
+	kept := region.Vertices[:0]
+	for i, vertex := range region.Vertices {
+		state, duplicated := (*State)(nil), false
+		if vertex != nil {
+			state, duplicated = states[vertex.ID]
+		}
+		if !duplicated || vertex.Type != state.Type {
+			kept = append(kept, vertex)
+			continue
+		}
+		replaced[vertex] = &state.Vertex
+		fixes = append(fixes, SanitizeFix{
+			Rule:    "vertex-containment",
+			Path:    fmt.Sprintf("%s.Vertices[%d]", path, i),
+			Message: fmt.Sprintf("removed vertex '%s' from Vertices because it is declared in States", vertex.ID),
+		})
+	}
+	clear(region.Vertices[len(kept):])
+	region.Vertices = kept
+
+	if len(replaced) > 0 {
+		walkTransitions(sm.Regions, func(transition *Transition) {
+			if canonical, ok := replaced[transition.Source]; ok {
+				transition.Source = canonical
+			}
+			if canonical, ok := replaced[transition.Target]; ok {
+				transition.Target = canonical
+			}
+		})
+	}
+	return fixes
+}
@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestSanitize_VertexContainment(t *testing.T) {
+	sm := createValidStateMachine()
+	region := sm.Regions[0]
+	duplicate := &Vertex{ID: "state1", Name: "State1", Type: "state"}
+	ambiguous := &Vertex{ID: "state2", Name: "State2", Type: "pseudostate"}
+	region.Vertices = append(region.Vertices, duplicate, ambiguous)
+	region.Transitions[1].Source = duplicate
+
+	fixes := Sanitize(sm)
+
+	if len(fixes) != 1 {
+		t.Fatalf("Sanitize() applied %d fixes, want 1: %v", len(fixes), fixes)
+	}
+	want := SanitizeFix{
+		Rule:    "vertex-containment",
+		Path:    "Regions[0].Vertices[2]",
+		Message: "removed vertex 'state1' from Vertices because it is declared in States",
+	}
+	if fixes[0] != want {
+		t.Errorf("Sanitize() fix = %+v, want %+v", fixes[0], want)
+	}
+
+	if len(region.Vertices) != 3 || region.Vertices[2] != ambiguous {
+		t.Errorf("Vertices after Sanitize() = %v, want ambiguous entry kept", region.Vertices)
+	}
+	if region.Transitions[1].Source != &region.States[0].Vertex {
+		t.Error("transition endpoint should point at the state after Sanitize()")
+	}
+
+	// The ambiguous duplicate is still reported by validation
+	if err := sm.Validate(); err == nil || !contains(err.Error(), "vertex at index 2 (ID: state2) is also declared") {
+		t.Errorf("Validate() error = %v, want remaining containment violation", err)
+	}
+
+	if fixes := Sanitize(nil); fixes != nil {
+		t.Errorf("Sanitize(nil) = %v, want nil", fixes)
+	}
+}
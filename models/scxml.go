@@ -0,0 +1,237 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// scxmlExporter exports W3C SCXML documents:
+//   - states become <state>, orthogonal states <parallel> with one <state>
+//     per region, and final states and terminate pseudostates <final>
+//   - initial pseudostates become <initial> (the initial attribute at the
+//     top level), history pseudostates <history> with their default transition
+//   - SCXML has no choice, junction, fork, join or connection points; they
+//     become transient states left by eventless transitions, with a fork's
+//     outgoing transitions merged into one transition with several targets
+//   - guards become cond attributes and behaviors <script> elements; do
+//     activities have no SCXML equivalent and are written as comments
+//   - internal transitions have no target and local transitions have
+//     type="internal"
+type scxmlExporter struct{}
+
+func (scxmlExporter) Name() string { return "scxml" }
+
+func (scxmlExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	writer := &scxmlWriter{outgoing: make(map[string][]*Transition)}
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil {
+			writer.outgoing[transition.Source.ID] = append(writer.outgoing[transition.Source.ID], transition)
+		}
+	})
+
+	writer.out.WriteString(xml.Header)
+	start := fmt.Sprintf(`<scxml xmlns="http://www.w3.org/2005/07/scxml" version="1.0" name=%s`, xmlAttr(opts.title(sm)))
+	regions := nonNilRegions(sm.Regions)
+	if len(regions) == 1 {
+		if initial := writer.initialTargets(regions[0]); initial != "" {
+			start += " initial=" + xmlAttr(initial)
+		}
+		writer.out.WriteString(start + ">\n")
+		writer.writeRegionContents(regions[0], 1, false)
+	} else {
+		writer.out.WriteString(start + ">\n")
+		writer.out.WriteString(fmt.Sprintf("  <parallel id=%s>\n", xmlAttr(sm.ID)))
+		for _, region := range regions {
+			writer.writeRegion(region, 2)
+		}
+		writer.out.WriteString("  </parallel>\n")
+	}
+	writer.out.WriteString("</scxml>\n")
+	_, err := io.WriteString(w, writer.out.String())
+	return err
+}
+
+// scxmlWriter accumulates an SCXML document
+type scxmlWriter struct {
+	out      strings.Builder
+	outgoing map[string][]*Transition // Transitions by source vertex ID
+}
+
+// writeRegion writes a region as a compound state
+func (x *scxmlWriter) writeRegion(region *Region, level int) {
+	indent := strings.Repeat("  ", level)
+	x.out.WriteString(fmt.Sprintf("%s<state id=%s>\n", indent, xmlAttr(region.ID)))
+	x.writeRegionContents(region, level+1, true)
+	x.out.WriteString(indent + "</state>\n")
+}
+
+// writeRegionContents writes the children of a region; withInitial writes
+// its initial pseudostate as an <initial> element
+func (x *scxmlWriter) writeRegionContents(region *Region, level int, withInitial bool) {
+	indent := strings.Repeat("  ", level)
+	for _, vertex := range region.Vertices {
+		if vertex == nil {
+			continue
+		}
+		if vertex.Type == "finalstate" {
+			x.out.WriteString(fmt.Sprintf("%s<final id=%s/>\n", indent, xmlAttr(vertex.ID)))
+			continue
+		}
+		switch kind := pseudostateKindOf(vertex); kind {
+		case PseudostateKindInitial:
+			if withInitial {
+				x.out.WriteString(indent + "<initial>\n")
+				x.writeTransitions(x.outgoing[vertex.ID], level+1, false)
+				x.out.WriteString(indent + "</initial>\n")
+			}
+		case PseudostateKindShallowHistory, PseudostateKindDeepHistory:
+			historyType := "shallow"
+			if kind == PseudostateKindDeepHistory {
+				historyType = "deep"
+			}
+			x.out.WriteString(fmt.Sprintf("%s<history id=%s type=%q>\n", indent, xmlAttr(vertex.ID), historyType))
+			x.writeTransitions(x.outgoing[vertex.ID], level+1, false)
+			x.out.WriteString(indent + "</history>\n")
+		case PseudostateKindTerminate:
+			x.out.WriteString(fmt.Sprintf("%s<final id=%s/>\n", indent, xmlAttr(vertex.ID)))
+		default:
+			if len(x.outgoing[vertex.ID]) == 0 {
+				x.out.WriteString(fmt.Sprintf("%s<state id=%s/>\n", indent, xmlAttr(vertex.ID)))
+				continue
+			}
+			x.out.WriteString(fmt.Sprintf("%s<state id=%s>\n", indent, xmlAttr(vertex.ID)))
+			x.writeTransitions(x.outgoing[vertex.ID], level+1, kind == PseudostateKindFork)
+			x.out.WriteString(indent + "</state>\n")
+		}
+	}
+	for _, state := range region.States {
+		if state != nil {
+			x.writeState(state, level)
+		}
+	}
+}
+
+// writeState writes a state with its behaviors, outgoing transitions and
+// regions
+func (x *scxmlWriter) writeState(state *State, level int) {
+	indent := strings.Repeat("  ", level)
+	regions := nonNilRegions(state.Regions)
+	element := "state"
+	if len(regions) > 1 {
+		element = "parallel"
+	}
+	if state.Entry == nil && state.Exit == nil && state.DoActivity == nil && len(x.outgoing[state.ID]) == 0 && len(regions) == 0 {
+		x.out.WriteString(fmt.Sprintf("%s<state id=%s/>\n", indent, xmlAttr(state.ID)))
+		return
+	}
+	x.out.WriteString(fmt.Sprintf("%s<%s id=%s>\n", indent, element, xmlAttr(state.ID)))
+	if state.Entry != nil {
+		x.out.WriteString(indent + "  <onentry>" + scxmlScript(state.Entry) + "</onentry>\n")
+	}
+	if state.Exit != nil {
+		x.out.WriteString(indent + "  <onexit>" + scxmlScript(state.Exit) + "</onexit>\n")
+	}
+	if state.DoActivity != nil {
+		x.out.WriteString(fmt.Sprintf("%s  <!-- do activity: %s -->\n", indent, xmlComment(displayName(state.DoActivity.Name, state.DoActivity.ID))))
+	}
+	x.writeTransitions(x.outgoing[state.ID], level+1, false)
+	switch len(regions) {
+	case 0:
+	case 1:
+		x.writeRegionContents(regions[0], level+1, true)
+	default:
+		for _, region := range regions {
+			x.writeRegion(region, level+1)
+		}
+	}
+	x.out.WriteString(fmt.Sprintf("%s</%s>\n", indent, element))
+}
+
+// writeTransitions writes transitions as <transition> elements; merge
+// combines them into one transition targeting all their targets, for forks
+func (x *scxmlWriter) writeTransitions(transitions []*Transition, level int, merge bool) {
+	indent := strings.Repeat("  ", level)
+	if merge && len(transitions) > 1 {
+		var targets []string
+		for _, transition := range transitions {
+			targets = append(targets, transition.Target.ID)
+		}
+		x.out.WriteString(fmt.Sprintf("%s<transition target=%s/>\n", indent, xmlAttr(strings.Join(targets, " "))))
+		return
+	}
+
+	for _, transition := range transitions {
+		attributes := ""
+		var events []string
+		for _, trigger := range transition.Triggers {
+			if key := trigger.EventKey(); key != "" {
+				events = append(events, key)
+			}
+		}
+		if len(events) > 0 {
+			attributes += " event=" + xmlAttr(strings.Join(events, " "))
+		}
+		if transition.Guard != nil && transition.Guard.Specification != "" {
+			attributes += " cond=" + xmlAttr(transition.Guard.Specification)
+		}
+		switch transition.Kind {
+		case TransitionKindInternal:
+		case TransitionKindLocal:
+			attributes += ` type="internal" target=` + xmlAttr(transition.Target.ID)
+		default:
+			attributes += " target=" + xmlAttr(transition.Target.ID)
+		}
+		if transition.Effect == nil {
+			x.out.WriteString(fmt.Sprintf("%s<transition%s/>\n", indent, attributes))
+			continue
+		}
+		x.out.WriteString(fmt.Sprintf("%s<transition%s>%s</transition>\n", indent, attributes, scxmlScript(transition.Effect)))
+	}
+}
+
+// initialTargets returns the space-separated targets of the initial
+// pseudostate of a region
+func (x *scxmlWriter) initialTargets(region *Region) string {
+	var targets []string
+	for _, id := range regionInitialIDs(region) {
+		for _, transition := range x.outgoing[id] {
+			targets = append(targets, transition.Target.ID)
+		}
+	}
+	return strings.Join(targets, " ")
+}
+
+// scxmlScript writes a behavior as a <script> element
+func scxmlScript(behavior *Behavior) string {
+	return "<script>" + xmlEscape(displayName(behavior.Specification, behavior.Name)) + "</script>"
+}
+
+// nonNilRegions returns the regions that are not nil
+func nonNilRegions(regions []*Region) []*Region {
+	var result []*Region
+	for _, region := range regions {
+		if region != nil {
+			result = append(result, region)
+		}
+	}
+	return result
+}
+
+// xmlEscape escapes text for use in XML content
+func xmlEscape(s string) string {
+	var out strings.Builder
+	_ = xml.EscapeText(&out, []byte(s)) // Writing to a strings.Builder cannot fail
+	return out.String()
+}
+
+// xmlAttr returns s as a quoted XML attribute value
+func xmlAttr(s string) string {
+	return `"` + xmlEscape(s) + `"`
+}
+
+// xmlComment makes s safe to use inside an XML comment
+func xmlComment(s string) string {
+	return strings.ReplaceAll(s, "--", "- -")
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestSCXMLExporter(t *testing.T) {
+	sm := newPlayerMachine()
+	main := sm.Regions[0]
+	idle := main.States[0]
+	idle.Entry = &Behavior{ID: "reset", Specification: "position = 0"}
+	idle.DoActivity = &Behavior{ID: "blink", Name: "blink--led"}
+	main.Transitions[2].Guard = &Constraint{ID: "may-stop", Specification: "x < 1 && y"}
+	main.Transitions[2].Effect = &Behavior{ID: "save", Specification: "save()"}
+	split := &Vertex{ID: "split", Name: "fork", Type: "pseudostate"}
+	resume := &Vertex{ID: "resume", Name: "H*", Type: "pseudostate"}
+	main.Vertices = append(main.Vertices, split, resume, &Vertex{ID: "done", Name: "Done", Type: "finalstate"})
+	playing := main.States[1]
+	loading, buffering := &playing.Regions[0].States[0].Vertex, &playing.Regions[1].States[0].Vertex
+	main.Transitions = append(main.Transitions,
+		execTransition("split-audio", split, loading),
+		execTransition("split-video", split, buffering),
+		execTransition("resume-default", resume, &idle.Vertex),
+		execTransition("volume", &playing.Vertex, &playing.Vertex, "volume"),
+	)
+	main.Transitions[len(main.Transitions)-1].Kind = TransitionKindInternal
+
+	var out bytes.Buffer
+	if err := Export(sm, "scxml", &out, ExportOptions{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	document := out.String()
+	if err := xml.Unmarshal(out.Bytes(), new(struct{})); err != nil {
+		t.Fatalf("SCXML export is not well-formed: %v\n%s", err, document)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "root with initial", want: `<scxml xmlns="http://www.w3.org/2005/07/scxml" version="1.0" name="Player" initial="idle">`},
+		{name: "entry behavior", want: "<onentry><script>position = 0</script></onentry>"},
+		{name: "do activity comment", want: "<!-- do activity: blink- -led -->"},
+		{name: "orthogonal state", want: "  <parallel id=\"playing\">\n"},
+		{name: "region as compound state", want: "    <state id=\"audio\">\n      <initial>\n        <transition target=\"loading\"/>\n"},
+		{name: "guard and effect", want: `<transition event="stop" cond="x &lt; 1 &amp;&amp; y" target="idle"><script>save()</script></transition>`},
+		{name: "internal transition", want: `<transition event="volume"/>`},
+		{name: "fork with merged targets", want: "  <state id=\"split\">\n    <transition target=\"loading buffering\"/>\n"},
+		{name: "history", want: "  <history id=\"resume\" type=\"deep\">\n    <transition target=\"idle\"/>\n"},
+		{name: "final state", want: `<final id="done"/>`},
+		{name: "empty state", want: `<state id="streaming"/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(document, tt.want) {
+				t.Errorf("SCXML export missing %q in\n%s", tt.want, document)
+			}
+		})
+	}
+}
+
+func TestSCXMLExporter_OrthogonalTopLevel(t *testing.T) {
+	sm := &StateMachine{ID: "sm", Name: "Both", Regions: []*Region{
+		{ID: "left", States: []*State{{Vertex: Vertex{ID: "a", Type: "state"}}}},
+		nil,
+		{ID: "right", States: []*State{{Vertex: Vertex{ID: "b", Type: "state"}}}},
+	}}
+	var out bytes.Buffer
+	if err := Export(sm, "scxml", &out, ExportOptions{Title: "Title"}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := `name="Title">
+  <parallel id="sm">
+    <state id="left">
+      <state id="a"/>
+    </state>
+    <state id="right">
+      <state id="b"/>
+    </state>
+  </parallel>
+</scxml>
+`
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Export() = %s, want it to end with\n%s", out.String(), want)
+	}
+}
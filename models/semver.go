@@ -0,0 +1,262 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemanticVersion is a parsed Semantic Versioning 2.0.0 version
+type SemanticVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string // Dot-separated identifiers after "-", e.g. "rc.1"
+	Build      string // Dot-separated build metadata after "+"; ignored by comparisons
+}
+
+// ParseVersion parses a version of the form MAJOR.MINOR.PATCH with optional
+// pre-release and build metadata, following Semantic Versioning 2.0.0
+func ParseVersion(s string) (SemanticVersion, error) {
+	v, err := parseVersion(s)
+	if err != nil {
+		return v, fmt.Errorf("invalid version %q: %w", s, err)
+	}
+	return v, nil
+}
+
+// parseVersion parses a Semantic Versioning 2.0.0 version; errors do not
+// repeat the version
+func parseVersion(s string) (SemanticVersion, error) {
+	var v SemanticVersion
+	rest := s
+	if before, build, found := strings.Cut(rest, "+"); found {
+		if err := checkVersionIdentifiers(build, false); err != nil {
+			return v, fmt.Errorf("build metadata %w", err)
+		}
+		rest, v.Build = before, build
+	}
+	if before, prerelease, found := strings.Cut(rest, "-"); found {
+		if err := checkVersionIdentifiers(prerelease, true); err != nil {
+			return v, fmt.Errorf("pre-release %w", err)
+		}
+		rest, v.Prerelease = before, prerelease
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("want MAJOR.MINOR.PATCH")
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		if !isVersionNumber(part) {
+			return v, fmt.Errorf("%q is not a number without leading zeros", part)
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, err
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// isVersionNumber reports whether s is a non-negative decimal number without
+// leading zeros
+func isVersionNumber(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkVersionIdentifiers checks dot-separated pre-release or build
+// identifiers; numeric pre-release identifiers cannot have leading zeros
+func checkVersionIdentifiers(s string, prerelease bool) error {
+	for _, identifier := range strings.Split(s, ".") {
+		if identifier == "" {
+			return fmt.Errorf("has an empty identifier")
+		}
+		numeric := true
+		for _, r := range identifier {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return fmt.Errorf("identifier %q contains %q", identifier, r)
+			}
+		}
+		if prerelease && numeric && !isVersionNumber(identifier) {
+			return fmt.Errorf("identifier %q has a leading zero", identifier)
+		}
+	}
+	return nil
+}
+
+// String formats the version
+func (v SemanticVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v has lower, equal or higher precedence than
+// other. Pre-releases precede their release and build metadata is ignored.
+func (v SemanticVersion) Compare(other SemanticVersion) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			return compareInts(pair[0], pair[1])
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	left, right := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if left[i] == right[i] {
+			continue
+		}
+		leftNumber, leftErr := strconv.Atoi(left[i])
+		rightNumber, rightErr := strconv.Atoi(right[i])
+		switch {
+		case leftErr == nil && rightErr == nil:
+			return compareInts(leftNumber, rightNumber)
+		case leftErr == nil:
+			return -1 // Numeric identifiers precede alphanumeric ones
+		case rightErr == nil:
+			return 1
+		}
+		return strings.Compare(left[i], right[i])
+	}
+	return compareInts(len(left), len(right))
+}
+
+// IsNewerThan reports whether v has higher precedence than other
+func (v SemanticVersion) IsNewerThan(other SemanticVersion) bool {
+	return v.Compare(other) > 0
+}
+
+// CompatibleWith reports whether a model at version v can be used where
+// version required is referenced: v is not older and has the same major
+// version, or for 0.x versions the same minor version. Pre-releases are only
+// compatible with a pre-release of the same version.
+func (v SemanticVersion) CompatibleWith(required SemanticVersion) bool {
+	if v.Major != required.Major || (v.Major == 0 && v.Minor != required.Minor) || v.Compare(required) < 0 {
+		return false
+	}
+	if v.Prerelease != "" {
+		return required.Prerelease != "" && v.Major == required.Major && v.Minor == required.Minor && v.Patch == required.Patch
+	}
+	return true
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// VersionPolicy configures which spellings of StateMachine.Version are
+// accepted. The zero value requires strict Semantic Versioning.
+type VersionPolicy struct {
+	AllowShort       bool // Accept "1" and "1.2", read as 1.0.0 and 1.2.0
+	AllowPrefix      bool // Accept a leading "v", as in "v1.2.3"
+	RejectPrerelease bool // Reject pre-release versions such as 1.0.0-rc.1
+}
+
+// Built-in version policies
+var (
+	// StrictVersionPolicy requires MAJOR.MINOR.PATCH versions
+	StrictVersionPolicy = &VersionPolicy{}
+
+	// LenientVersionPolicy also accepts short versions and a "v" prefix; it
+	// is used to compare the versions of state machines
+	LenientVersionPolicy = &VersionPolicy{AllowShort: true, AllowPrefix: true}
+)
+
+// Parse parses a version according to the policy
+func (p *VersionPolicy) Parse(s string) (SemanticVersion, error) {
+	version := s
+	if p.AllowPrefix {
+		version = strings.TrimPrefix(version, "v")
+	}
+	if p.AllowShort {
+		core, suffix := version, ""
+		if i := strings.IndexAny(version, "-+"); i >= 0 {
+			core, suffix = version[:i], version[i:]
+		}
+		if dots := strings.Count(core, "."); dots < 2 && core != "" {
+			version = core + strings.Repeat(".0", 2-dots) + suffix
+		}
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return v, fmt.Errorf("invalid version %q: %w", s, err)
+	}
+	if p.RejectPrerelease && v.Prerelease != "" {
+		return v, fmt.Errorf("invalid version %q: pre-release versions are not allowed", s)
+	}
+	return v, nil
+}
+
+// SemanticVersion parses the state machine's version with
+// LenientVersionPolicy
+func (sm *StateMachine) SemanticVersion() (SemanticVersion, error) {
+	return LenientVersionPolicy.Parse(sm.Version)
+}
+
+// IsNewerThan reports whether the state machine's version has higher
+// precedence than other's. Versions that cannot be parsed are never newer.
+func (sm *StateMachine) IsNewerThan(other *StateMachine) bool {
+	if sm == nil || other == nil {
+		return false
+	}
+	mine, err := sm.SemanticVersion()
+	if err != nil {
+		return false
+	}
+	theirs, err := other.SemanticVersion()
+	if err != nil {
+		return true
+	}
+	return mine.IsNewerThan(theirs)
+}
+
+// validateVersion checks the version format against the version policy of
+// the context's profile. Empty versions are left to ValidateRequired.
+func (sm *StateMachine) validateVersion(context *ValidationContext, errors *ValidationErrors) {
+	policy := context.ActiveProfile().Versions
+	if policy == nil || sm.Version == "" {
+		return
+	}
+	if _, err := policy.Parse(sm.Version); err != nil {
+		errors.AddError(
+			ErrorTypeInvalid,
+			"StateMachine",
+			"Version",
+			err.Error(),
+			context.Path,
+		)
+	}
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SemanticVersion
+		wantErr string
+	}{
+		{input: "1.2.3", want: SemanticVersion{Major: 1, Minor: 2, Patch: 3}},
+		{input: "0.0.0", want: SemanticVersion{}},
+		{input: "1.0.0-rc.1", want: SemanticVersion{Major: 1, Prerelease: "rc.1"}},
+		{input: "1.0.0-x-y.7+build.5", want: SemanticVersion{Major: 1, Prerelease: "x-y.7", Build: "build.5"}},
+		{input: "2.1.0+20240301", want: SemanticVersion{Major: 2, Minor: 1, Build: "20240301"}},
+		{input: "1.0", wantErr: "want MAJOR.MINOR.PATCH"},
+		{input: "v1.0.0", wantErr: `"v1" is not a number`},
+		{input: "01.0.0", wantErr: "leading zeros"},
+		{input: "1.0.0-rc.01", wantErr: "has a leading zero"},
+		{input: "1.0.0-", wantErr: "pre-release has an empty identifier"},
+		{input: "1.0.0+a..b", wantErr: "build metadata has an empty identifier"},
+		{input: "1.0.0-rc_1", wantErr: "contains '_'"},
+		{input: "", wantErr: "want MAJOR.MINOR.PATCH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVersion(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseVersion(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestSemanticVersion_Compare(t *testing.T) {
+	// Precedence order from the Semantic Versioning specification
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			want := compareInts(i, j)
+			if got := a.Compare(b); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+			if got := a.IsNewerThan(b); got != (want > 0) {
+				t.Errorf("%s.IsNewerThan(%s) = %v, want %v", ordered[i], ordered[j], got, want > 0)
+			}
+		}
+	}
+
+	a, _ := ParseVersion("1.0.0+one")
+	b, _ := ParseVersion("1.0.0+two")
+	if a.Compare(b) != 0 {
+		t.Error("build metadata should not affect precedence")
+	}
+}
+
+func TestSemanticVersion_CompatibleWith(t *testing.T) {
+	tests := []struct {
+		version  string
+		required string
+		want     bool
+	}{
+		{version: "1.4.2", required: "1.2.0", want: true},
+		{version: "1.2.0", required: "1.2.0", want: true},
+		{version: "1.1.9", required: "1.2.0", want: false},
+		{version: "2.0.0", required: "1.2.0", want: false},
+		{version: "0.3.5", required: "0.3.1", want: true},
+		{version: "0.4.0", required: "0.3.1", want: false},
+		{version: "1.3.0-rc.1", required: "1.2.0", want: false},
+		{version: "1.2.0-rc.2", required: "1.2.0-rc.1", want: true},
+		{version: "1.2.0", required: "1.2.0-rc.1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+" for "+tt.required, func(t *testing.T) {
+			v, _ := ParseVersion(tt.version)
+			required, _ := ParseVersion(tt.required)
+			if got := v.CompatibleWith(required); got != tt.want {
+				t.Errorf("CompatibleWith() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionPolicy_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *VersionPolicy
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "strict", policy: StrictVersionPolicy, input: "1.2.3", want: "1.2.3"},
+		{name: "strict short", policy: StrictVersionPolicy, input: "1.0", wantErr: `invalid version "1.0": want MAJOR.MINOR.PATCH`},
+		{name: "strict prefix", policy: StrictVersionPolicy, input: "v1.2.3", wantErr: `invalid version "v1.2.3"`},
+		{name: "lenient short", policy: LenientVersionPolicy, input: "1.0", want: "1.0.0"},
+		{name: "lenient major only", policy: LenientVersionPolicy, input: "3", want: "3.0.0"},
+		{name: "lenient prefix", policy: LenientVersionPolicy, input: "v2.1", want: "2.1.0"},
+		{name: "lenient short pre-release", policy: LenientVersionPolicy, input: "1.2-beta+7", want: "1.2.0-beta+7"},
+		{name: "lenient garbage", policy: LenientVersionPolicy, input: "latest", wantErr: `invalid version "latest"`},
+		{name: "reject pre-release", policy: &VersionPolicy{RejectPrerelease: true}, input: "1.0.0-rc.1", wantErr: "pre-release versions are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Parse(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_IsNewerThan(t *testing.T) {
+	tests := []struct {
+		name  string
+		mine  string
+		other string
+		want  bool
+	}{
+		{name: "newer minor", mine: "1.1", other: "1.0.5", want: true},
+		{name: "older", mine: "1.0.0", other: "v1.0.1", want: false},
+		{name: "equal", mine: "1.0", other: "1.0.0", want: false},
+		{name: "unparsable mine", mine: "draft", other: "1.0.0", want: false},
+		{name: "unparsable other", mine: "1.0.0", other: "draft", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mine := &StateMachine{ID: "m", Version: tt.mine}
+			other := &StateMachine{ID: "m", Version: tt.other}
+			if got := mine.IsNewerThan(other); got != tt.want {
+				t.Errorf("IsNewerThan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if (&StateMachine{Version: "1.0.0"}).IsNewerThan(nil) {
+		t.Error("IsNewerThan(nil) = true, want false")
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *VersionPolicy
+		version string
+		want    string
+	}{
+		{name: "no policy", policy: nil, version: "draft"},
+		{name: "strict valid", policy: StrictVersionPolicy, version: "1.0.0"},
+		{name: "strict short", policy: StrictVersionPolicy, version: "1.0", want: `invalid version "1.0": want MAJOR.MINOR.PATCH`},
+		{name: "lenient short", policy: LenientVersionPolicy, version: "1.0"},
+		{name: "lenient invalid", policy: LenientVersionPolicy, version: "draft", want: `invalid version "draft"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			sm.Version = tt.version
+			profile := &ValidationProfile{Name: "versions", Versions: tt.policy}
+			err := sm.ValidateInContext(NewValidationContext().WithProfile(profile))
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ValidateInContext() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateInContext() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("empty version is only reported as required", func(t *testing.T) {
+		sm := createValidStateMachine()
+		sm.Version = ""
+		errors := &ValidationErrors{}
+		profile := &ValidationProfile{Name: "versions", Versions: StrictVersionPolicy}
+		sm.ValidateWithErrors(NewValidationContext().WithProfile(profile), errors)
+		for _, err := range errors.Errors {
+			if err.Field == "Version" && err.Type != ErrorTypeRequired {
+				t.Errorf("unexpected version error: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+)
+
+// SignatureMetadataKey is the metadata key Sign stores the signature under
+const SignatureMetadataKey = "signature"
+
+// SignatureAlgorithmEd25519 names Ed25519 signatures
+const SignatureAlgorithmEd25519 = "ed25519"
+
+// ErrInvalidSignature is matched with errors.Is by Verify errors for
+// documents that are unsigned, signed with an unknown key or modified after
+// signing
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signer signs the canonical serialization of state machines
+type Signer interface {
+	// KeyID identifies the key so verifiers can look up the matching one
+	KeyID() string
+
+	// Algorithm names the signature algorithm, e.g. SignatureAlgorithmEd25519
+	Algorithm() string
+
+	// Sign returns the signature of payload
+	Sign(payload []byte) ([]byte, error)
+}
+
+// Keyring holds the keys signatures are verified with
+type Keyring interface {
+	// Verify returns an error unless signature is a valid signature of
+	// payload by the key with the given ID and algorithm
+	Verify(keyID, algorithm string, payload, signature []byte) error
+}
+
+// Ed25519Signer signs with an Ed25519 private key
+type Ed25519Signer struct {
+	ID  string // Key ID recorded in signatures
+	Key ed25519.PrivateKey
+}
+
+// KeyID returns the signer's key ID
+func (s Ed25519Signer) KeyID() string { return s.ID }
+
+// Algorithm returns SignatureAlgorithmEd25519
+func (s Ed25519Signer) Algorithm() string { return SignatureAlgorithmEd25519 }
+
+// Sign returns the Ed25519 signature of payload
+func (s Ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	if len(s.Key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 private key for key '%s'", s.ID)
+	}
+	return ed25519.Sign(s.Key, payload), nil
+}
+
+// Ed25519Keyring holds Ed25519 public keys by key ID
+type Ed25519Keyring map[string]ed25519.PublicKey
+
+// Verify checks an Ed25519 signature with the public key stored under keyID
+func (k Ed25519Keyring) Verify(keyID, algorithm string, payload, signature []byte) error {
+	if algorithm != SignatureAlgorithmEd25519 {
+		return fmt.Errorf("unsupported signature algorithm '%s'", algorithm)
+	}
+	key, ok := k[keyID]
+	if !ok {
+		return fmt.Errorf("unknown key '%s'", keyID)
+	}
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, payload, signature) {
+		return fmt.Errorf("signature does not match the document")
+	}
+	return nil
+}
+
+// ModelSignature is the detached signature Sign embeds in a state machine's
+// metadata under SignatureMetadataKey
+type ModelSignature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	Value     string `json:"value"` // Standard base64 encoding of the signature
+}
+
+// Sign signs the canonical serialization of sm with signer and stores the
+// signature in sm's metadata under SignatureMetadataKey, replacing any
+// previous signature. The canonical serialization is the JSON encoding of
+// the machine without its signature, with map keys sorted; any later change
+// to the model, its metadata included, invalidates the signature.
+func Sign(sm *StateMachine, signer Signer) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	if signer == nil {
+		return fmt.Errorf("signer cannot be nil")
+	}
+	payload, err := signaturePayload(sm)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return fmt.Errorf("failed to sign state machine '%s': %w", sm.ID, err)
+	}
+
+	if sm.Metadata == nil {
+		sm.Metadata = make(map[string]interface{})
+	}
+	sm.Metadata[SignatureMetadataKey] = map[string]interface{}{
+		"algorithm": signer.Algorithm(),
+		"key_id":    signer.KeyID(),
+		"value":     base64.StdEncoding.EncodeToString(signature),
+	}
+	return nil
+}
+
+// Verify decodes a signed JSON document and checks its embedded signature
+// against keyring. The state machine and its signature are returned only if
+// the signature is valid; otherwise the error matches ErrInvalidSignature, or
+// describes why the document could not be decoded.
+//
+// The signature covers the delivered bytes, not just what decoding keeps:
+// documents with fields the model does not know, data after the state
+// machine, or any other content that does not survive re-encoding (such as
+// duplicated keys) are rejected. Apart from whitespace, the document must be
+// the json.Marshal or the Format encoding of the machine it decodes to.
+func Verify(doc []byte, keyring Keyring) (*StateMachine, *ModelSignature, error) {
+	if keyring == nil {
+		return nil, nil, fmt.Errorf("keyring cannot be nil")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	var sm StateMachine
+	if err := decoder.Decode(&sm); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode state machine: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("state machine '%s': %w: unexpected data after the state machine", sm.ID, ErrInvalidSignature)
+	}
+	if err := checkCanonicalEncoding(doc, &sm); err != nil {
+		return nil, nil, fmt.Errorf("state machine '%s': %w: %v", sm.ID, ErrInvalidSignature, err)
+	}
+
+	signature, err := embeddedSignature(&sm)
+	if err != nil {
+		return nil, nil, fmt.Errorf("state machine '%s': %w: %v", sm.ID, ErrInvalidSignature, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(signature.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("state machine '%s': %w: malformed signature value", sm.ID, ErrInvalidSignature)
+	}
+	payload, err := signaturePayload(&sm)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := keyring.Verify(signature.KeyID, signature.Algorithm, payload, value); err != nil {
+		return nil, nil, fmt.Errorf("state machine '%s': %w: %v", sm.ID, ErrInvalidSignature, err)
+	}
+	return &sm, signature, nil
+}
+
+// checkCanonicalEncoding reports an error unless doc, ignoring whitespace,
+// is the json.Marshal or the Format encoding of sm, so that no content of
+// the document escapes the signature
+func checkCanonicalEncoding(doc []byte, sm *StateMachine) error {
+	var delivered bytes.Buffer
+	if err := json.Compact(&delivered, doc); err != nil {
+		return fmt.Errorf("malformed document")
+	}
+	marshaled, err := json.Marshal(sm)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode document: %v", err)
+	}
+	if bytes.Equal(delivered.Bytes(), marshaled) {
+		return nil
+	}
+	formatted, err := Format(marshaled)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode document: %v", err)
+	}
+	var canonical bytes.Buffer
+	if err := json.Compact(&canonical, formatted); err == nil && bytes.Equal(delivered.Bytes(), canonical.Bytes()) {
+		return nil
+	}
+	return fmt.Errorf("document contains content its signature does not cover")
+}
+
+// embeddedSignature reads the signature Sign stored in sm's metadata
+func embeddedSignature(sm *StateMachine) (*ModelSignature, error) {
+	raw, ok := sm.Metadata[SignatureMetadataKey]
+	if !ok {
+		return nil, fmt.Errorf("document is not signed")
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature metadata")
+	}
+	var signature ModelSignature
+	if err := json.Unmarshal(encoded, &signature); err != nil || signature.Algorithm == "" || signature.Value == "" {
+		return nil, fmt.Errorf("malformed signature metadata")
+	}
+	return &signature, nil
+}
+
+// signaturePayload returns the canonical serialization of sm that signatures
+// cover: its JSON encoding without the signature metadata entry. Metadata
+// left empty by removing the signature encodes as absent, so signing a
+// machine without metadata and verifying its signed encoding agree.
+func signaturePayload(sm *StateMachine) ([]byte, error) {
+	unsigned := *sm
+	unsigned.Metadata = maps.Clone(sm.Metadata)
+	delete(unsigned.Metadata, SignatureMetadataKey)
+	if len(unsigned.Metadata) == 0 {
+		unsigned.Metadata = nil
+	}
+	payload, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize state machine '%s': %w", sm.ID, err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestSigner returns a deterministic Ed25519 signer and the keyring that
+// verifies its signatures
+func newTestSigner(id string) (Ed25519Signer, Ed25519Keyring) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte(id[:1]), ed25519.SeedSize))
+	return Ed25519Signer{ID: id, Key: key}, Ed25519Keyring{id: key.Public().(ed25519.PublicKey)}
+}
+
+// signedDocument signs sm and returns its JSON encoding
+func signedDocument(t *testing.T, sm *StateMachine, signer Signer) []byte {
+	t.Helper()
+	if err := Sign(sm, signer); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	doc, err := json.Marshal(sm)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return doc
+}
+
+func TestSignVerify(t *testing.T) {
+	signer, keyring := newTestSigner("release")
+	_, otherKeyring := newTestSigner("other")
+
+	tests := []struct {
+		name    string
+		build   func() *StateMachine
+		tamper  func(doc []byte) []byte
+		keyring Keyring
+		wantErr string // Empty when the document verifies
+	}{
+		{
+			name:    "signed document verifies",
+			build:   createValidStateMachine,
+			keyring: keyring,
+		},
+		{
+			name: "metadata is covered",
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				sm.Metadata = map[string]interface{}{"approved_by": "alice", "ticket": 12345678901234567}
+				return sm
+			},
+			keyring: keyring,
+		},
+		{
+			name: "formatting does not affect the signature",
+			build: func() *StateMachine {
+				sm := createValidStateMachine()
+				sm.Metadata = map[string]interface{}{"weight": 0.25}
+				return sm
+			},
+			tamper: func(doc []byte) []byte {
+				formatted, _ := Format(doc)
+				return formatted
+			},
+			keyring: keyring,
+		},
+		{
+			name:    "modified model",
+			build:   createValidStateMachine,
+			tamper:  func(doc []byte) []byte { return bytes.Replace(doc, []byte(`"state2"`), []byte(`"state3"`), 1) },
+			keyring: keyring,
+			wantErr: "signature does not match the document",
+		},
+		{
+			name:  "injected field",
+			build: createValidStateMachine,
+			tamper: func(doc []byte) []byte {
+				return bytes.Replace(doc, []byte(`{"id":"sm1"`), []byte(`{"injected_field":"evil","id":"sm1"`), 1)
+			},
+			keyring: keyring,
+			wantErr: "failed to decode state machine",
+		},
+		{
+			name:    "trailing data",
+			build:   createValidStateMachine,
+			tamper:  func(doc []byte) []byte { return append(doc, []byte(`{"trailing":"junk"}`)...) },
+			keyring: keyring,
+			wantErr: "unexpected data after the state machine",
+		},
+		{
+			name:  "duplicated key",
+			build: createValidStateMachine,
+			tamper: func(doc []byte) []byte {
+				return bytes.Replace(doc, []byte(`{"id":"sm1"`), []byte(`{"id":"forged","id":"sm1"`), 1)
+			},
+			keyring: keyring,
+			wantErr: "content its signature does not cover",
+		},
+		{
+			name:    "unknown key",
+			build:   createValidStateMachine,
+			keyring: otherKeyring,
+			wantErr: "unknown key 'release'",
+		},
+		{
+			name:  "unsigned document",
+			build: createValidStateMachine,
+			tamper: func([]byte) []byte {
+				doc, _ := json.Marshal(createValidStateMachine())
+				return doc
+			},
+			keyring: keyring,
+			wantErr: "document is not signed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := signedDocument(t, tt.build(), signer)
+			if tt.tamper != nil {
+				doc = tt.tamper(doc)
+			}
+
+			sm, signature, err := Verify(doc, tt.keyring)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Verify() error = %v, want it to contain %q", err, tt.wantErr)
+				} else if !errors.Is(err, ErrInvalidSignature) && !strings.Contains(err.Error(), "failed to decode") {
+					t.Errorf("Verify() error = %v, want ErrInvalidSignature containing %q", err, tt.wantErr)
+				}
+				if sm != nil {
+					t.Error("Verify() returned the state machine of an invalid document")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if sm.ID != "sm1" || signature.KeyID != "release" || signature.Algorithm != SignatureAlgorithmEd25519 {
+				t.Errorf("Verify() = %v, %+v", sm, signature)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	signer, keyring := newTestSigner("release")
+	sm := createValidStateMachine()
+	first := signedDocument(t, sm, signer)
+
+	// Re-signing replaces the signature rather than signing it
+	second := signedDocument(t, sm, signer)
+	if !bytes.Equal(first, second) {
+		t.Errorf("re-signing changed the document:\n%s\n%s", first, second)
+	}
+
+	// The signed model is still valid and edits after signing are detected
+	if err := sm.Validate(); err != nil {
+		t.Errorf("signed state machine does not validate: %v", err)
+	}
+	sm.Name = "Renamed"
+	doc, _ := json.Marshal(sm)
+	if _, _, err := Verify(doc, keyring); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() of a renamed machine error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		sm     *StateMachine
+		signer Signer
+		want   string
+	}{
+		{name: "nil state machine", signer: signer, want: "state machine cannot be nil"},
+		{name: "nil signer", sm: createValidStateMachine(), want: "signer cannot be nil"},
+		{name: "invalid key", sm: createValidStateMachine(), signer: Ed25519Signer{ID: "broken"}, want: "invalid Ed25519 private key for key 'broken'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Sign(tt.sm, tt.signer); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Sign() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SliceCriteria selects the scenario Slice keeps: the flow from a starting
+// state either through a sequence of events or to a set of target states.
+// Exactly one of Events and Targets must be set.
+type SliceCriteria struct {
+	From    string   // ID of the vertex the scenario starts in; empty starts at the initial pseudostates of the top-level regions
+	Events  []string // Event IDs dispatched in order, starting in From
+	Targets []string // IDs of the vertices the scenario ends in
+}
+
+// Slice extracts the part of the state machine a scenario runs through, for
+// focused review and documentation of one flow. With Targets it keeps the
+// vertices and transitions on any path from From to one of the targets;
+// with Events it keeps those a run dispatching the events in order may
+// visit and fire. The analysis is static: guards are not evaluated, so
+// every branch a guard could take is kept, and events no transition of an
+// active state handles are discarded.
+//
+// The slice is a copy: it keeps the states enclosing kept vertices, drops
+// regions, states, vertices and transitions off the scenario, updates the
+// composite and orthogonal flags of states that lost regions, and keeps only
+// the events its transitions and deferrable triggers reference. Each kept
+// region keeps its initial pseudostate; when the scenario does not pass
+// through it, a transition with the ID "<initial>-slice" leads from it to the
+// region's vertex the scenario starts in, or to its first kept vertex, so the
+// slice is valid on its own wherever the original was.
+func Slice(sm *StateMachine, criteria SliceCriteria) (*StateMachine, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	if (len(criteria.Events) > 0) == (len(criteria.Targets) > 0) {
+		return nil, fmt.Errorf("slice criteria need either events or targets")
+	}
+	index := newSliceIndex(sm)
+	for _, id := range append([]string{criteria.From}, criteria.Targets...) {
+		if _, ok := index.vertices[id]; id != "" && !ok {
+			return nil, fmt.Errorf("vertex '%s' not found%s", id, didYouMean(id, index.vertices))
+		}
+	}
+
+	var start []string
+	if criteria.From != "" {
+		start = []string{criteria.From}
+	} else {
+		for _, region := range sm.Regions {
+			if region != nil {
+				start = append(start, regionInitialIDs(region)...)
+			}
+		}
+		if len(start) == 0 {
+			return nil, fmt.Errorf("state machine '%s' has no initial pseudostate to start the slice from", sm.ID)
+		}
+	}
+
+	var kept sliceSelection
+	if len(criteria.Targets) > 0 {
+		kept = index.paths(start, criteria.Targets)
+		if len(kept.vertices) == 0 {
+			return nil, fmt.Errorf("no path leads from %s to %s", sliceDescribe(start), sliceDescribe(criteria.Targets))
+		}
+	} else {
+		kept = index.run(start, criteria.Events)
+	}
+	return index.extract(kept, start), nil
+}
+
+// sliceSelection holds the IDs of the vertices and transitions of a slice
+type sliceSelection struct {
+	vertices    map[string]bool
+	transitions map[string]bool
+}
+
+// sliceIndex locates the vertices and transitions of a state machine for
+// slicing
+type sliceIndex struct {
+	sm       *StateMachine
+	vertices map[string]*Vertex
+	states   map[string]*State
+	parent   map[string]string // ID of the state owning each vertex's region; absent for top-level vertices
+	outgoing map[string][]*Transition
+}
+
+// newSliceIndex indexes the state machine
+func newSliceIndex(sm *StateMachine) *sliceIndex {
+	index := &sliceIndex{
+		sm:       sm,
+		vertices: canonicalVertices(sm),
+		states:   stateIDs(sm),
+		parent:   make(map[string]string),
+		outgoing: make(map[string][]*Transition),
+	}
+	for _, state := range index.states {
+		for _, region := range state.Regions {
+			if region == nil {
+				continue
+			}
+			for _, vertex := range declaredVertices(region) {
+				index.parent[vertex.ID] = state.ID
+			}
+		}
+	}
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil {
+			index.outgoing[transition.Source.ID] = append(index.outgoing[transition.Source.ID], transition)
+		}
+	})
+	return index
+}
+
+// initials returns the IDs of the initial pseudostates of the state's
+// regions
+func (ix *sliceIndex) initials(id string) []string {
+	var ids []string
+	if state := ix.states[id]; state != nil {
+		for _, region := range state.Regions {
+			if region != nil {
+				ids = append(ids, regionInitialIDs(region)...)
+			}
+		}
+	}
+	return ids
+}
+
+// ancestors returns the IDs of the states enclosing the vertex, innermost
+// first
+func (ix *sliceIndex) ancestors(id string) []string {
+	var ancestors []string
+	seen := map[string]bool{id: true}
+	for parent, ok := ix.parent[id]; ok && !seen[parent]; parent, ok = ix.parent[parent] {
+		seen[parent] = true
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// sliceNode is a step of a scenario: entering a vertex, or being in it and
+// able to leave it through its own transitions or those of the states
+// enclosing it
+type sliceNode struct {
+	vertex string
+	active bool
+}
+
+// sliceEdge leads from one scenario step to the next, through a transition
+// or, when transition is nil, through the structure of the model
+type sliceEdge struct {
+	to         sliceNode
+	transition *Transition
+}
+
+// edges returns the steps following a step. Entering a vertex makes it
+// active and enters the initial pseudostates of its regions; an active
+// vertex fires its transitions, and can leave through the transitions of
+// its enclosing state.
+func (ix *sliceIndex) edges(node sliceNode) []sliceEdge {
+	if !node.active {
+		edges := []sliceEdge{{to: sliceNode{node.vertex, true}}}
+		for _, initial := range ix.initials(node.vertex) {
+			edges = append(edges, sliceEdge{to: sliceNode{initial, false}})
+		}
+		return edges
+	}
+	var edges []sliceEdge
+	for _, transition := range ix.outgoing[node.vertex] {
+		edges = append(edges, sliceEdge{to: sliceNode{transition.Target.ID, false}, transition: transition})
+	}
+	if parent, ok := ix.parent[node.vertex]; ok {
+		edges = append(edges, sliceEdge{to: sliceNode{parent, true}})
+	}
+	return edges
+}
+
+// paths selects the vertices and transitions on the paths from entering a
+// start vertex to entering a target. Paths end at the first target they
+// enter and do not return to a start vertex, so cycles through the ends of
+// the scenario are left out.
+func (ix *sliceIndex) paths(start, targets []string) sliceSelection {
+	ends := make(map[sliceNode]bool)
+	for _, id := range targets {
+		ends[sliceNode{id, false}] = true
+	}
+	forward := make(map[sliceNode]bool)
+	reverse := make(map[sliceNode][]sliceEdge)
+	var queue []sliceNode
+	for _, id := range start {
+		queue = append(queue, sliceNode{id, false})
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if forward[node] {
+			continue
+		}
+		forward[node] = true
+		if ends[node] {
+			continue
+		}
+		for _, edge := range ix.edges(node) {
+			reverse[edge.to] = append(reverse[edge.to], sliceEdge{to: node, transition: edge.transition})
+			queue = append(queue, edge.to)
+		}
+	}
+
+	kept := sliceSelection{vertices: make(map[string]bool), transitions: make(map[string]bool)}
+	backward := make(map[sliceNode]bool)
+	for _, id := range targets {
+		if forward[sliceNode{id, false}] {
+			queue = append(queue, sliceNode{id, false})
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if backward[node] {
+			continue
+		}
+		backward[node] = true
+		kept.vertices[node.vertex] = true
+		if slices.Contains(start, node.vertex) && !node.active {
+			continue
+		}
+		for _, edge := range reverse[node] {
+			if edge.transition != nil {
+				kept.transitions[edge.transition.ID] = true
+			}
+			queue = append(queue, edge.to)
+		}
+	}
+	return kept
+}
+
+// run selects the vertices a run entering the start vertices and then
+// dispatching the events in order may visit, and the transitions it may
+// fire. Transitions without triggers leaving pseudostates and simple states
+// fire on their own.
+func (ix *sliceIndex) run(start, events []string) sliceSelection {
+	kept := sliceSelection{vertices: make(map[string]bool), transitions: make(map[string]bool)}
+	active := make(map[string]bool)
+
+	var enter func(id string)
+	enter = func(id string) {
+		// Enter the enclosing states not active yet, outermost first, and
+		// the other regions of the states entered
+		ancestors := ix.ancestors(id)
+		for i := len(ancestors) - 1; i >= 0; i-- {
+			if active[ancestors[i]] {
+				continue
+			}
+			active[ancestors[i]], kept.vertices[ancestors[i]] = true, true
+			next := id
+			if i > 0 {
+				next = ancestors[i-1]
+			}
+			for _, region := range ix.states[ancestors[i]].Regions {
+				if region != nil && !slices.ContainsFunc(declaredVertices(region), func(v *Vertex) bool { return v.ID == next }) {
+					for _, initial := range regionInitialIDs(region) {
+						enter(initial)
+					}
+				}
+			}
+		}
+		active[id], kept.vertices[id] = true, true
+		for _, initial := range ix.initials(id) {
+			enter(initial)
+		}
+	}
+	fire := func(transition *Transition) {
+		kept.transitions[transition.ID] = true
+		exited := map[string]bool{transition.Source.ID: true}
+		targetAncestors := ix.ancestors(transition.Target.ID)
+		for _, ancestor := range ix.ancestors(transition.Source.ID) {
+			if ancestor == transition.Target.ID || slices.Contains(targetAncestors, ancestor) {
+				break
+			}
+			exited[ancestor] = true
+		}
+		for id := range active {
+			if exited[id] || slices.ContainsFunc(ix.ancestors(id), func(ancestor string) bool { return exited[ancestor] }) {
+				delete(active, id)
+			}
+		}
+		enter(transition.Target.ID)
+	}
+	complete := func() {
+		fired := make(map[*Transition]bool)
+		for progressed := true; progressed; {
+			progressed = false
+			for _, id := range slices.Sorted(maps.Keys(active)) {
+				vertex := ix.vertices[id]
+				if !active[id] || vertex == nil || (vertex.Type != "pseudostate" && len(ix.initials(id)) > 0) {
+					continue
+				}
+				for _, transition := range ix.outgoing[id] {
+					if len(transition.Triggers) == 0 && !fired[transition] {
+						fired[transition], progressed = true, true
+						fire(transition)
+					}
+				}
+			}
+		}
+	}
+
+	for _, id := range start {
+		enter(id)
+	}
+	complete()
+	for _, event := range events {
+		var enabled []*Transition
+		for _, id := range slices.Sorted(maps.Keys(active)) {
+			for _, transition := range ix.outgoing[id] {
+				if triggeredBy(transition, event) {
+					enabled = append(enabled, transition)
+				}
+			}
+		}
+		for _, transition := range enabled {
+			fire(transition)
+		}
+		complete()
+	}
+	return kept
+}
+
+// extract copies the state machine with only the selected vertices and
+// transitions, the states enclosing them and the initial pseudostates of
+// the regions kept
+func (ix *sliceIndex) extract(kept sliceSelection, start []string) *StateMachine {
+	ix.enclose(kept)
+	entry := make(map[string]bool) // Start vertices and the states enclosing them
+	for _, id := range start {
+		entry[id] = true
+		for _, ancestor := range ix.ancestors(id) {
+			entry[ancestor] = true
+		}
+	}
+	walkRegionTree(ix.sm.Regions, "", func(region *Region, _ string) {
+		if slices.ContainsFunc(declaredVertices(region), func(v *Vertex) bool { return kept.vertices[v.ID] }) {
+			for _, initial := range regionInitialIDs(region) {
+				kept.vertices[initial] = true
+			}
+		}
+	})
+
+	slice := ix.project(kept)
+	walkRegionTree(slice.Regions, "", func(region *Region, _ string) {
+		connectInitial(region, entry)
+	})
+	slice.refreshAdjacency()
+	return slice
+}
+
+// enclose adds the states enclosing the selected vertices to the selection
+func (ix *sliceIndex) enclose(kept sliceSelection) {
+	for id := range kept.vertices {
+		for _, ancestor := range ix.ancestors(id) {
+			kept.vertices[ancestor] = true
+		}
+	}
+}
+
+// project copies the state machine with only the selected vertices, the
+// selected transitions between them, the regions declaring selected
+// vertices and the events the remaining triggers reference. States that
+// lost regions have their composite and orthogonal flags updated.
+func (ix *sliceIndex) project(kept sliceSelection) *StateMachine {
+	projected := ix.sm.Clone()
+	var prune func(regions []*Region) []*Region
+	prune = func(regions []*Region) []*Region {
+		var keptRegions []*Region
+		for _, region := range regions {
+			if region == nil || !slices.ContainsFunc(declaredVertices(region), func(v *Vertex) bool { return kept.vertices[v.ID] }) {
+				continue
+			}
+			region.States = slices.DeleteFunc(region.States, func(s *State) bool { return s == nil || !kept.vertices[s.ID] })
+			for _, state := range region.States {
+				if len(state.Regions) > 0 {
+					state.Regions = prune(state.Regions)
+					state.IsComposite = len(state.Regions) > 0
+					state.IsOrthogonal = len(state.Regions) > 1
+					state.IsSimple = len(state.Regions) == 0 && state.Submachine == nil
+				}
+			}
+			region.Vertices = slices.DeleteFunc(region.Vertices, func(v *Vertex) bool { return v == nil || !kept.vertices[v.ID] })
+			region.Transitions = slices.DeleteFunc(region.Transitions, func(t *Transition) bool {
+				return t == nil || !kept.transitions[t.ID] || t.Source == nil || t.Target == nil ||
+					!kept.vertices[t.Source.ID] || !kept.vertices[t.Target.ID]
+			})
+			keptRegions = append(keptRegions, region)
+		}
+		return keptRegions
+	}
+	projected.Regions = prune(projected.Regions)
+	projected.ConnectionPoints = slices.DeleteFunc(projected.ConnectionPoints, func(cp *Pseudostate) bool { return cp == nil || !kept.vertices[cp.ID] })
+
+	events := make(map[string]bool)
+	walkRegionTree(projected.Regions, "", func(region *Region, _ string) {
+		for _, transition := range region.Transitions {
+			for _, trigger := range transition.Triggers {
+				if trigger != nil {
+					events[trigger.EventKey()] = true
+				}
+			}
+		}
+		for _, state := range region.States {
+			for _, trigger := range state.DeferrableTriggers {
+				if trigger != nil {
+					events[trigger.EventKey()] = true
+				}
+			}
+		}
+	})
+	projected.Events = slices.DeleteFunc(projected.Events, func(event *Event) bool { return event == nil || !events[event.ID] })
+	projected.refreshAdjacency()
+	return projected
+}
+
+// connectInitial leads the first initial pseudostate of a region to the
+// region's entry vertex when no transition leaves it
+func connectInitial(region *Region, entry map[string]bool) {
+	initials := regionInitialIDs(region)
+	if len(initials) == 0 {
+		return
+	}
+	var initial *Vertex
+	for _, vertex := range region.Vertices {
+		if vertex.ID == initials[0] {
+			initial = vertex
+		}
+	}
+	for _, transition := range region.Transitions {
+		if transition.Source.ID == initial.ID {
+			return
+		}
+	}
+
+	var target *Vertex
+	for _, vertex := range declaredVertices(region) {
+		if vertex.ID == initial.ID {
+			continue
+		}
+		if entry[vertex.ID] {
+			target = vertex
+			break
+		}
+		if target == nil {
+			target = vertex
+		}
+	}
+	if target == nil {
+		return
+	}
+	region.Transitions = append([]*Transition{{
+		ID:     initial.ID + "-slice",
+		Source: initial,
+		Target: target,
+		Kind:   TransitionKindExternal,
+	}}, region.Transitions...)
+}
+
+// sliceDescribe lists vertex IDs for errors
+func sliceDescribe(ids []string) string {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = "'" + id + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
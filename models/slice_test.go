@@ -0,0 +1,164 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sliceContents returns the sorted vertex and transition IDs of a slice
+func sliceContents(sm *StateMachine) (vertices, transitions []string) {
+	for vertex := range sm.AllVertices() {
+		vertices = append(vertices, vertex.ID)
+	}
+	for transition := range sm.AllTransitions() {
+		transitions = append(transitions, transition.ID)
+	}
+	slices.Sort(vertices)
+	slices.Sort(transitions)
+	return vertices, transitions
+}
+
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		name            string
+		sm              *StateMachine
+		criteria        SliceCriteria
+		wantVertices    []string
+		wantTransitions []string
+	}{
+		{
+			name:            "events from the initial state",
+			sm:              newPlayerMachine(),
+			criteria:        SliceCriteria{Events: []string{"play", "ready"}},
+			wantVertices:    []string{"audio-initial", "buffering", "idle", "initial", "loading", "playing", "rendering", "video-initial"},
+			wantTransitions: []string{"audio-start", "play", "start", "video-ready", "video-start"},
+		},
+		{
+			name:            "events leaving the composite state",
+			sm:              newPlayerMachine(),
+			criteria:        SliceCriteria{From: "buffering", Events: []string{"stop", "play"}},
+			wantVertices:    []string{"audio-initial", "buffering", "idle", "initial", "loading", "playing", "video-initial"},
+			wantTransitions: []string{"audio-start", "initial-slice", "play", "stop", "video-start"},
+		},
+		{
+			name:            "targets",
+			sm:              newPlayerMachine(),
+			criteria:        SliceCriteria{From: "idle", Targets: []string{"rendering"}},
+			wantVertices:    []string{"buffering", "idle", "initial", "playing", "rendering", "video-initial"},
+			wantTransitions: []string{"initial-slice", "play", "video-ready", "video-start", "video-tick"},
+		},
+		{
+			name:            "targets from the initial state",
+			sm:              createValidStateMachine(),
+			criteria:        SliceCriteria{Targets: []string{"state1"}},
+			wantVertices:    []string{"initial1", "state1"},
+			wantTransitions: []string{"t1"},
+		},
+		{
+			name:            "events with completion transitions",
+			sm:              createValidStateMachine(),
+			criteria:        SliceCriteria{Events: []string{"event1"}},
+			wantVertices:    []string{"final1", "initial1", "state1", "state2"},
+			wantTransitions: []string{"t1", "t2", "t3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalVertices, originalTransitions := sliceContents(tt.sm)
+			slice, err := Slice(tt.sm, tt.criteria)
+			if err != nil {
+				t.Fatalf("Slice() error = %v", err)
+			}
+			vertices, transitions := sliceContents(slice)
+			if !slices.Equal(vertices, tt.wantVertices) {
+				t.Errorf("vertices = %v, want %v", vertices, tt.wantVertices)
+			}
+			if !slices.Equal(transitions, tt.wantTransitions) {
+				t.Errorf("transitions = %v, want %v", transitions, tt.wantTransitions)
+			}
+			if vertices, transitions := sliceContents(tt.sm); !slices.Equal(vertices, originalVertices) || !slices.Equal(transitions, originalTransitions) {
+				t.Error("Slice() modified the original state machine")
+			}
+		})
+	}
+}
+
+func TestSlice_Structure(t *testing.T) {
+	sm := newPlayerMachine()
+	if err := ResolveEndpoints(sm, RecordAdjacency()); err != nil {
+		t.Fatalf("ResolveEndpoints() error = %v", err)
+	}
+	slice, err := Slice(sm, SliceCriteria{From: "idle", Targets: []string{"rendering"}})
+	if err != nil {
+		t.Fatalf("Slice() error = %v", err)
+	}
+
+	playing := findState(slice, "playing")
+	if playing == nil || len(playing.Regions) != 1 || playing.Regions[0].ID != "video" {
+		t.Fatalf("playing should keep only the video region, got %+v", playing)
+	}
+	if !playing.IsComposite || playing.IsOrthogonal {
+		t.Errorf("playing flags = composite %v, orthogonal %v, want composite only", playing.IsComposite, playing.IsOrthogonal)
+	}
+	if len(findState(sm, "playing").Regions) != 2 {
+		t.Error("Slice() modified the original state machine")
+	}
+
+	entry := slice.Regions[0].Transitions[0]
+	if entry.ID != "initial-slice" || entry.Source.ID != "initial" || entry.Target.ID != "idle" {
+		t.Errorf("entry transition = %s: %s -> %s, want initial-slice: initial -> idle", entry.ID, entry.Source.ID, entry.Target.ID)
+	}
+	if got := findState(slice, "idle").Outgoing(); len(got) != 1 || got[0].ID != "play" {
+		t.Errorf("Outgoing(idle) = %v, want the play transition", got)
+	}
+}
+
+func TestSlice_ValidOnItsOwn(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria SliceCriteria
+	}{
+		{"events", SliceCriteria{Events: []string{"event1"}}},
+		{"targets", SliceCriteria{From: "state1", Targets: []string{"final1"}}},
+		{"target next to the start", SliceCriteria{From: "state2", Targets: []string{"final1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice, err := Slice(createValidStateMachine(), tt.criteria)
+			if err != nil {
+				t.Fatalf("Slice() error = %v", err)
+			}
+			if err := slice.Validate(); err != nil {
+				t.Errorf("slice should be valid: %v", err)
+			}
+		})
+	}
+}
+
+func TestSlice_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sm       *StateMachine
+		criteria SliceCriteria
+		want     string
+	}{
+		{"nil machine", nil, SliceCriteria{Targets: []string{"idle"}}, "state machine cannot be nil"},
+		{"no criteria", newPlayerMachine(), SliceCriteria{From: "idle"}, "need either events or targets"},
+		{"events and targets", newPlayerMachine(), SliceCriteria{Events: []string{"play"}, Targets: []string{"idle"}}, "need either events or targets"},
+		{"unknown start", newPlayerMachine(), SliceCriteria{From: "idel", Events: []string{"play"}}, "vertex 'idel' not found; did you mean 'idle'?"},
+		{"unknown target", newPlayerMachine(), SliceCriteria{Targets: []string{"renderin"}}, "vertex 'renderin' not found"},
+		{"unreachable target", createValidStateMachine(), SliceCriteria{From: "final1", Targets: []string{"state1"}}, "no path leads from 'final1' to 'state1'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice, err := Slice(tt.sm, tt.criteria)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || slice != nil {
+				t.Errorf("Slice() = %v, %v, want error %q", slice, err, tt.want)
+			}
+		})
+	}
+}
@@ -7,23 +7,44 @@ import (
 
 // StateMachine represents a UML state machine
 type StateMachine struct {
-	ID               string                 `json:"id" validate:"required"`
-	Name             string                 `json:"name" validate:"required"`
-	Version          string                 `json:"version" validate:"required"`
-	Regions          []*Region              `json:"regions"`
-	ConnectionPoints []*Pseudostate         `json:"connection_points,omitempty"` // UML connection points (entry/exit pseudostates)
-	IsMethod         bool                   `json:"is_method"`                   // True if this state machine is used as a method
-	Entities         map[string]string      `json:"entities"`                    // entityID -> cache key mapping
-	Metadata         map[string]interface{} `json:"metadata"`
-	CreatedAt        time.Time              `json:"created_at"`
+	ID               string         `json:"id" validate:"required"`
+	Name             string         `json:"name" validate:"required"`
+	Version          string         `json:"version" validate:"required"`
+	Regions          []*Region      `json:"regions"`
+	ConnectionPoints []*Pseudostate `json:"connection_points,omitempty"` // UML connection points (entry/exit pseudostates)
+	Events           []*Event       `json:"events,omitempty"`            // Event catalog referenced by Trigger.EventID
+	IsMethod         bool           `json:"is_method"`                   // True if this state machine is used as a method
+	// AllowSharedTerminate accepts terminate pseudostates in this machine
+	// while it is the submachine of states in more than one machine; reaching
+	// one ends the execution of every machine that includes it
+	AllowSharedTerminate bool                   `json:"allow_shared_terminate,omitempty"`
+	Entities             map[string]string      `json:"entities"` // entityID -> cache key mapping
+	Metadata             map[string]interface{} `json:"metadata"`
+	Layout               *Layout                `json:"layout,omitempty"` // Diagram coordinates; see ApplyLayout
+	CreatedAt            time.Time              `json:"created_at"`
+	Suppressions         []Suppression          `json:"suppressions,omitempty"` // Accepted findings anywhere in the machine; see Suppression
+
+	adjacency bool // Vertices record their transitions; see RecordAdjacency
 }
 
-// Validate validates the StateMachine data integrity
+// String returns a concise one-line description of the StateMachine
+func (sm *StateMachine) String() string {
+	if sm == nil {
+		return "StateMachine(<nil>)"
+	}
+	return fmt.Sprintf("StateMachine(id=%s, name=%q, version=%s, regions=%d)", sm.ID, sm.Name, sm.Version, len(sm.Regions))
+}
+
+// Validate validates the StateMachine data integrity. Validation only reads
+// the machine, so Validate, traversals and exports may run concurrently on the
+// same machine as long as nothing modifies it meanwhile. Regions with lazy
+// Content are read through their provider without storing what it loads, so
+// this holds for them too when the provider is safe for concurrent use. The
+// same holds for ValidateInContext and ValidateWithErrors, provided each
+// goroutine collects into its own ValidationErrors. Use ValidateWithOptions to choose a profile, a depth
+// limit or which findings are reported.
 func (sm *StateMachine) Validate() error {
-	context := NewValidationContext().WithStateMachine(sm)
-	errors := &ValidationErrors{}
-	sm.ValidateWithErrors(context, errors)
-	return errors.ToError()
+	return sm.ValidateWithOptions()
 }
 
 // ValidateInContext validates the StateMachine with the provided context
@@ -42,34 +63,64 @@ func (sm *StateMachine) ValidateWithErrors(context *ValidationContext, errors *V
 		return
 	}
 
+	// A submachine that is already being validated further up the chain is
+	// not entered again; validateSubmachineRecursion reports the cycle
+	if context.validatingMachine(sm) {
+		return
+	}
+	context = context.withMachine(sm)
+	defer context.timeElement("StateMachine", sm.ID)()
+	if len(context.machineChain) == 1 {
+		context.transitionIndexes = make(map[*StateMachine]*TransitionIndex)
+
+		// The root machine resolves the elements its findings are about and
+		// honors the suppressions on them
+		errorCount, warningCount, infoCount := len(errors.Errors), len(errors.Warnings), len(errors.Infos)
+		defer func() {
+			attachElementIDs(sm, context.Path, errors.Errors[errorCount:], errors.Warnings[warningCount:], errors.Infos[infoCount:])
+			sm.applySuppressions(context, errors, errorCount, warningCount, infoCount)
+		}()
+	}
+	if !sm.validateResourceLimits(context, errors) {
+		return
+	}
+
 	helper := NewValidationHelper()
 
 	// Validate required fields
 	helper.ValidateRequired(sm.ID, "ID", "StateMachine", context, errors)
+	helper.ValidateID(sm.ID, "StateMachine", context, errors)
 	helper.ValidateRequired(sm.Name, "Name", "StateMachine", context, errors)
+	helper.ValidateName(sm.Name, "StateMachine", context, errors)
 	helper.ValidateRequired(sm.Version, "Version", "StateMachine", context, errors)
+	context.check("statemachine.version", sm.validateVersion, errors)
+	context.check("statemachine.created_at", sm.validateTimestamps, errors)
 
 	// Validate regions collection
-	regionValidators := make([]Validator, len(sm.Regions))
-	for i, region := range sm.Regions {
-		regionValidators[i] = region
-	}
-	helper.ValidateCollection(regionValidators, "Regions", "StateMachine", context, errors)
+	ValidateSlice(sm.Regions, "Regions", "StateMachine", context, errors)
 
 	// Validate connection points collection
-	connectionPointValidators := make([]Validator, len(sm.ConnectionPoints))
-	for i, cp := range sm.ConnectionPoints {
-		connectionPointValidators[i] = cp
-	}
-	helper.ValidateCollection(connectionPointValidators, "ConnectionPoints", "StateMachine", context, errors)
+	ValidateSlice(sm.ConnectionPoints, "ConnectionPoints", "StateMachine", context, errors)
+
+	// Validate event catalog
+	context.check("statemachine.events", sm.validateEventCatalog, errors)
+	context.check("statemachine.deprecations", sm.validateDeprecations, errors)
+	context.check("statemachine.entities", sm.validateEntities, errors)
+	context.check("statemachine.entity_placeholders", sm.validateEntityReferences, errors)
 
 	// UML constraint validations
-	sm.validateConnectionPoints(context, errors)
-	sm.validateRegionMultiplicity(context, errors)
-	sm.validateMethodConstraints(context, errors)
+	context.check("statemachine.connection_points", sm.validateConnectionPoints, errors)
+	context.check("statemachine.regions.multiplicity", sm.validateRegionMultiplicity, errors)
+	context.check("statemachine.method", sm.validateMethodConstraints, errors)
 
 	// Structural integrity validation
 	sm.validateStructuralIntegrity(context, errors)
+
+	// Target platform compatibility
+	context.check("statemachine.compatibility", sm.validateCompatibility, errors)
+
+	// Organizational modeling standards
+	context.check("statemachine.policies", sm.validatePolicies, errors)
 }
 
 // Region represents a region within a state machine
@@ -79,6 +130,19 @@ type Region struct {
 	States      []*State      `json:"states"`
 	Transitions []*Transition `json:"transitions"`
 	Vertices    []*Vertex     `json:"vertices"`
+	Owner       string        `json:"owner,omitempty"` // Person or role responsible for the region; see Ownership
+	Team        string        `json:"team,omitempty"`  // Team allowed to edit the region; see Editor
+	Content     RegionContent `json:"-"`               // Provider of elements not loaded yet; see Materialize
+
+	Suppressions []Suppression `json:"suppressions,omitempty"` // Accepted findings about the region; see Suppression
+}
+
+// String returns a concise one-line description of the Region
+func (r *Region) String() string {
+	if r == nil {
+		return "Region(<nil>)"
+	}
+	return fmt.Sprintf("Region(id=%s, name=%q, states=%d, vertices=%d, transitions=%d)", r.ID, r.Name, len(r.States), len(r.Vertices), len(r.Transitions))
 }
 
 // Validate validates the Region data integrity
@@ -104,41 +168,59 @@ func (r *Region) ValidateWithErrors(context *ValidationContext, errors *Validati
 	if errors == nil {
 		return
 	}
+	defer context.timeElement("Region", r.ID)()
+	context = context.enteringRegion()
+	if !r.validateDepthLimit(context, errors) {
+		return
+	}
+	// Content is validated through a transient copy, leaving r unmodified
+	region, err := r.withContent()
+	if err != nil {
+		errors.AddError(ErrorTypeReference, "Region", "Content", err.Error(), context.Path)
+		region = r
+	}
+	if context.Region == r {
+		context = context.WithRegion(region)
+	}
 
 	helper := NewValidationHelper()
 
 	// Validate required fields
-	helper.ValidateRequired(r.ID, "ID", "Region", context, errors)
-	helper.ValidateRequired(r.Name, "Name", "Region", context, errors)
+	helper.ValidateRequired(region.ID, "ID", "Region", context, errors)
+	helper.ValidateID(region.ID, "Region", context, errors)
+	helper.ValidateRequired(region.Name, "Name", "Region", context, errors)
+	helper.ValidateName(region.Name, "Region", context, errors)
 
 	// Validate states collection
-	stateValidators := make([]Validator, len(r.States))
-	for i, state := range r.States {
-		stateValidators[i] = state
-	}
-	helper.ValidateCollection(stateValidators, "States", "Region", context, errors)
+	ValidateSlice(region.States, "States", "Region", context, errors)
 
 	// Validate transitions collection
-	transitionValidators := make([]Validator, len(r.Transitions))
-	for i, transition := range r.Transitions {
-		transitionValidators[i] = transition
-	}
-	helper.ValidateCollection(transitionValidators, "Transitions", "Region", context, errors)
+	ValidateSlice(region.Transitions, "Transitions", "Region", context, errors)
 
 	// Validate vertices collection
-	vertexValidators := make([]Validator, len(r.Vertices))
-	for i, vertex := range r.Vertices {
-		vertexValidators[i] = vertex
-	}
-	helper.ValidateCollection(vertexValidators, "Vertices", "Region", context, errors)
+	ValidateSlice(region.Vertices, "Vertices", "Region", context, errors)
 
 	// UML constraint validations
-	r.validateInitialStates(context, errors)
-	r.validateVertexContainment(context, errors)
-	r.validateTransitionScope(context, errors)
+	context.check("region.initial.multiplicity", region.validateInitialStates, errors)
+	context.check("region.containment", region.validateVertexContainment, errors)
+	context.check("region.transition.scope", region.validateTransitionScope, errors)
+	context.check("finalstate.reachable", region.validateFinalStateReachability, errors)
 
 	// Structural integrity validation
-	r.validateStructuralIntegrity(context, errors)
+	context.check("region.structural_integrity", region.validateStructuralIntegrity, errors)
+}
+
+// validateFinalStateReachability warns about the final states declared in
+// the region's Vertices that can never be reached; see
+// FinalState.validateFinalStateReachability
+func (r *Region) validateFinalStateReachability(context *ValidationContext, errors *ValidationErrors) {
+	regionContext := context.WithRegion(r)
+	for i, vertex := range r.Vertices {
+		if vertex != nil && vertex.Type == "finalstate" {
+			final := &FinalState{Vertex: *vertex}
+			final.validateFinalStateReachability(regionContext.WithPathIndex("Vertices", i), errors)
+		}
+	}
 }
 
 // validateConnectionPoints ensures connection points are entry/exit pseudostates
@@ -191,21 +273,24 @@ func (sm *StateMachine) validateRegionMultiplicity(context *ValidationContext, e
 // UML Constraint: If a StateMachine is used as a method, it cannot have connection points
 func (sm *StateMachine) validateMethodConstraints(context *ValidationContext, errors *ValidationErrors) {
 	if sm.IsMethod && len(sm.ConnectionPoints) > 0 {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"StateMachine",
 			"ConnectionPoints",
 			"StateMachine used as method cannot have connection points (UML constraint)",
 			context.Path,
+			ClauseMethod,
 		)
 	}
 }
 
 // validateInitialStates ensures at most one initial pseudostate per region
-// UML Constraint: A Region can have at most one initial pseudostate
+// UML Constraint: A Region can have at most one initial pseudostate. A
+// violation is reported once for the region, with the offending elements in
+// the error context.
 func (r *Region) validateInitialStates(context *ValidationContext, errors *ValidationErrors) {
-	initialCount := 0
 	var initialIndices []int
+	var offenders multiplicityOffenders
 
 	// Check vertices for initial pseudostates using naming conventions
 	for i, vertex := range r.Vertices {
@@ -215,8 +300,8 @@ func (r *Region) validateInitialStates(context *ValidationContext, errors *Valid
 
 		// Check if this vertex is an initial pseudostate
 		if vertex.Type == "pseudostate" && r.isInitialPseudostate(vertex) {
-			initialCount++
 			initialIndices = append(initialIndices, i)
+			offenders.add(vertex.ID, context.WithPathIndex("Vertices", i).Path)
 		}
 	}
 
@@ -227,67 +312,48 @@ func (r *Region) validateInitialStates(context *ValidationContext, errors *Valid
 		}
 
 		if state.Type == "pseudostate" && r.isInitialPseudostate(&state.Vertex) {
-			initialCount++
 			initialIndices = append(initialIndices, i)
+			offenders.add(state.ID, context.WithPathIndex("States", i).Path)
 		}
 	}
 
-	if initialCount > 1 {
-		errors.AddError(
+	if len(offenders.ids) > 1 {
+		errors.AddErrorWithContext(
 			ErrorTypeMultiplicity,
 			"Region",
 			"Vertices",
-			fmt.Sprintf("Region can have at most one initial pseudostate, found %d at indices: %v (UML constraint)", initialCount, initialIndices),
+			fmt.Sprintf("Region can have at most one initial pseudostate, found %d at indices: %v (UML constraint)", len(offenders.ids), initialIndices),
 			context.Path,
+			offenders.context(),
 		)
 	}
 }
 
 // validateVertexContainment verifies vertex collections are properly structured
-// UML Constraint: States and Vertices collections should not overlap - states go in States, pseudostates/final states go in Vertices
+// UML Constraint: every vertex is owned exactly once by its region. States are
+// declared in States; pseudostates and final states are declared in Vertices.
+// A vertex that appears in both collections is invalid (see Sanitize for an
+// automatic fix).
 func (r *Region) validateVertexContainment(context *ValidationContext, errors *ValidationErrors) {
-	// Create maps for checking overlaps
-	stateIDs := make(map[string]bool)
-	vertexIDs := make(map[string]bool)
-
 	// Collect state IDs
+	stateIDs := make(map[string]bool)
 	for _, state := range r.States {
 		if state != nil {
 			stateIDs[state.ID] = true
 		}
 	}
 
-	// Collect vertex IDs
-	for _, vertex := range r.Vertices {
-		if vertex != nil {
-			vertexIDs[vertex.ID] = true
-		}
-	}
-
-	// Check that all states are also represented in the vertices collection
-	// Only enforce this if there are vertices with state types in the vertices collection
-	hasStateVertices := false
-	for _, vertex := range r.Vertices {
-		if vertex != nil && vertex.Type == "state" {
-			hasStateVertices = true
-			break
-		}
-	}
-
-	// If vertices collection contains states, then all states should be represented there
-	if hasStateVertices {
-		for i, state := range r.States {
-			if state != nil {
-				if !vertexIDs[state.ID] {
-					errors.AddError(
-						ErrorTypeConstraint,
-						"Region",
-						"States",
-						fmt.Sprintf("state at index %d (ID: %s) is not contained in the region's vertices collection (UML constraint)", i, state.ID),
-						context.WithPathIndex("States", i).Path,
-					)
-				}
-			}
+	// Check that no vertex is declared in both collections
+	for i, vertex := range r.Vertices {
+		if vertex != nil && vertex.ID != "" && stateIDs[vertex.ID] {
+			errors.AddErrorWithClause(
+				ErrorTypeConstraint,
+				"Region",
+				"Vertices",
+				fmt.Sprintf("vertex at index %d (ID: %s) is also declared in the region's states collection; each vertex must appear exactly once across States and Vertices (UML constraint)", i, vertex.ID),
+				context.WithPathIndex("Vertices", i).Path,
+				ClauseRegionContainment,
+			)
 		}
 	}
 
@@ -300,12 +366,13 @@ func (r *Region) validateVertexContainment(context *ValidationContext, errors *V
 
 		// Ensure vertex has proper identification
 		if vertex.ID == "" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Region",
 				"Vertices",
 				fmt.Sprintf("vertex at index %d must have a valid ID for proper containment (UML constraint)", i),
 				context.WithPathIndex("Vertices", i).Path,
+				ClauseRegionContainment,
 			)
 		}
 
@@ -321,12 +388,13 @@ func (r *Region) validateVertexContainment(context *ValidationContext, errors *V
 		}
 
 		if !isValidType {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Region",
 				"Vertices",
 				fmt.Sprintf("vertex at index %d has invalid type '%s' for region containment (UML constraint)", i, vertex.Type),
 				context.WithPathIndex("Vertices", i).Path,
+				ClauseRegionContainment,
 			)
 		}
 	}
@@ -363,12 +431,13 @@ func (r *Region) validateTransitionScope(context *ValidationContext, errors *Val
 		// Validate source vertex is in this region
 		if transition.Source != nil {
 			if !vertexIDs[transition.Source.ID] {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"Region",
 					"Transitions",
 					fmt.Sprintf("transition at index %d has source vertex (ID: %s) that is not contained in this region (UML constraint)", i, transition.Source.ID),
 					transitionContext.Path,
+					ClauseRegionContainment,
 				)
 			}
 		}
@@ -379,12 +448,13 @@ func (r *Region) validateTransitionScope(context *ValidationContext, errors *Val
 				// For external transitions, the target might be in a different region
 				// but for internal and local transitions, target must be in same region
 				if transition.Kind == TransitionKindInternal || transition.Kind == TransitionKindLocal {
-					errors.AddError(
+					errors.AddErrorWithClause(
 						ErrorTypeConstraint,
 						"Region",
 						"Transitions",
 						fmt.Sprintf("transition at index %d has target vertex (ID: %s) that is not contained in this region, but transition kind is %s (UML constraint)", i, transition.Target.ID, transition.Kind),
 						transitionContext.Path,
+						ClauseRegionContainment,
 					)
 				}
 				// For external transitions, we allow targets outside the region
@@ -397,12 +467,13 @@ func (r *Region) validateTransitionScope(context *ValidationContext, errors *Val
 			// Internal transitions must have the same source and target
 			if transition.Kind == TransitionKindInternal {
 				if transition.Source.ID != transition.Target.ID {
-					errors.AddError(
+					errors.AddErrorWithClause(
 						ErrorTypeConstraint,
 						"Region",
 						"Transitions",
 						fmt.Sprintf("internal transition at index %d must have the same source and target vertex (UML constraint)", i),
 						transitionContext.Path,
+						ClauseStateIsInternal,
 					)
 				}
 			}
@@ -440,12 +511,13 @@ func (r *Region) validateTransitionVertexCompatibility(transition *Transition, i
 
 	// Validate that final states don't have outgoing transitions
 	if source.Type == "finalstate" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Region",
 			"Transitions",
 			fmt.Sprintf("transition at index %d has a final state as source, which is not allowed (UML constraint)", index),
 			context.Path,
+			ClauseFinalStateNoOutgoing,
 		)
 	}
 
@@ -481,6 +553,12 @@ func (r *Region) isInitialPseudostate(vertex *Vertex) bool {
 
 // validateStructuralIntegrity performs structural integrity validation for StateMachine
 func (sm *StateMachine) validateStructuralIntegrity(context *ValidationContext, errors *ValidationErrors) {
+	runRulePasses(sm, structuralRulePasses, context, errors)
+}
+
+// validateReferences resolves the references within the state machine and
+// checks the consistency of its regions and connection points
+func (sm *StateMachine) validateReferences(context *ValidationContext, errors *ValidationErrors) {
 	// Create a reference validator for this state machine
 	refValidator := NewReferenceValidator()
 
@@ -553,13 +631,26 @@ func (sm *StateMachine) validateRegionConsistency(context *ValidationContext, er
 
 // validateConnectionPointConsistency validates consistency of connection points
 func (sm *StateMachine) validateConnectionPointConsistency(context *ValidationContext, errors *ValidationErrors) {
-	// Check for duplicate connection point IDs
+	// Check for connection points listed more than once and for duplicate IDs
 	cpIDs := make(map[string]int)
+	cpInstances := make(map[*Pseudostate]int)
 	for i, cp := range sm.ConnectionPoints {
 		if cp == nil {
 			continue
 		}
 
+		if prevIndex, exists := cpInstances[cp]; exists {
+			errors.AddError(
+				ErrorTypeConstraint,
+				"StateMachine",
+				"ConnectionPoints",
+				fmt.Sprintf("connection point '%s' is listed at indices %d and %d; each connection point must appear once (structural integrity violation)", cp.ID, prevIndex, i),
+				context.WithPathIndex("ConnectionPoints", i).Path,
+			)
+			continue
+		}
+		cpInstances[cp] = i
+
 		if prevIndex, exists := cpIDs[cp.ID]; exists {
 			errors.AddError(
 				ErrorTypeConstraint,
@@ -573,14 +664,17 @@ func (sm *StateMachine) validateConnectionPointConsistency(context *ValidationCo
 		}
 	}
 
-	// Validate connection point names are unique within their kind
+	// Validate connection point names are unique within their kind and
+	// across entry and exit points
 	entryNames := make(map[string]int)
 	exitNames := make(map[string]int)
+	listed := make(map[*Pseudostate]bool)
 
 	for i, cp := range sm.ConnectionPoints {
-		if cp == nil || cp.Name == "" {
+		if cp == nil || cp.Name == "" || listed[cp] {
 			continue
 		}
+		listed[cp] = true
 
 		switch cp.Kind {
 		case PseudostateKindEntryPoint:
@@ -608,6 +702,37 @@ func (sm *StateMachine) validateConnectionPointConsistency(context *ValidationCo
 				exitNames[cp.Name] = i
 			}
 		}
+
+		// Entry and exit points sharing a name are easily confused
+		otherNames := exitNames
+		if cp.Kind == PseudostateKindExitPoint {
+			otherNames = entryNames
+		}
+		if prevIndex, exists := otherNames[cp.Name]; exists && (cp.Kind == PseudostateKindEntryPoint || cp.Kind == PseudostateKindExitPoint) {
+			errors.AddError(
+				ErrorTypeConstraint,
+				"StateMachine",
+				"ConnectionPoints",
+				fmt.Sprintf("entry point and exit point share the name '%s' at indices %d and %d (may cause confusion)", cp.Name, prevIndex, i),
+				context.WithPathIndex("ConnectionPoints", i).Path,
+			)
+		}
+	}
+
+	// Connection points that nothing references are likely leftovers
+	for i, cp := range sm.ConnectionPoints {
+		if cp == nil || cp.ID == "" || cpInstances[cp] != i {
+			continue
+		}
+		if len(Usages(sm, cp.ID)) == 0 {
+			errors.AddWarning(
+				ErrorTypeReference,
+				"StateMachine",
+				"ConnectionPoints",
+				fmt.Sprintf("%s '%s' is not referenced by any transition or connection point reference (unused connection point)", cp.Kind, cp.ID),
+				context.WithPathIndex("ConnectionPoints", i).Path,
+			)
+		}
 	}
 }
 
@@ -625,8 +750,8 @@ func (r *Region) validateStructuralIntegrity(context *ValidationContext, errors
 
 // validateVertexIDConsistency validates that vertex IDs are consistent across collections
 func (r *Region) validateVertexIDConsistency(context *ValidationContext, errors *ValidationErrors) {
-	// Check for duplicate vertex IDs within each collection separately
-	// Note: States can appear in both States and Vertices collections as per UML design
+	// Check for duplicate vertex IDs within each collection separately;
+	// overlap between the collections is reported by validateVertexContainment
 
 	// Check for duplicates within vertices collection
 	vertexIDs := make(map[string]int)
@@ -747,7 +872,7 @@ func (r *Region) validateContainmentRelationships(context *ValidationContext, er
 			subRegionContext := stateContext.WithPathIndex("Regions", j)
 
 			// Validate that sub-region vertices don't have ID conflicts with parent region
-			for k, subVertex := range subRegion.Vertices {
+			for k, subVertex := range contentOf(subRegion).Vertices {
 				if subVertex == nil {
 					continue
 				}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -622,3 +623,126 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestStateMachineAndRegion_String(t *testing.T) {
+	region := &Region{
+		ID:          "r1",
+		Name:        "Main",
+		States:      []*State{{Vertex: Vertex{ID: "s1", Name: "Idle", Type: "state"}}},
+		Vertices:    []*Vertex{{ID: "initial", Name: "Initial", Type: "pseudostate"}},
+		Transitions: []*Transition{},
+	}
+	sm := &StateMachine{ID: "sm1", Name: "Machine", Version: "1.0", Regions: []*Region{region}}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"state machine", sm.String(), `StateMachine(id=sm1, name="Machine", version=1.0, regions=1)`},
+		{"region", region.String(), `Region(id=r1, name="Main", states=1, vertices=1, transitions=0)`},
+		{"nil state machine", (*StateMachine)(nil).String(), "StateMachine(<nil>)"},
+		{"nil region", (*Region)(nil).String(), "Region(<nil>)"},
+		{"fmt verb", fmt.Sprintf("%v", sm), `StateMachine(id=sm1, name="Machine", version=1.0, regions=1)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("String() = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ConnectionPointConsistency(t *testing.T) {
+	newPoint := func(id, name string, kind PseudostateKind) *Pseudostate {
+		return &Pseudostate{Vertex: Vertex{ID: id, Name: name, Type: "pseudostate"}, Kind: kind}
+	}
+
+	tests := []struct {
+		name         string
+		points       func() []*Pseudostate
+		useIDs       []string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "distinct and used connection points",
+			points: func() []*Pseudostate {
+				return []*Pseudostate{newPoint("in", "In", PseudostateKindEntryPoint), newPoint("out", "Out", PseudostateKindExitPoint)}
+			},
+			useIDs: []string{"in", "out"},
+		},
+		{
+			name: "same connection point listed twice",
+			points: func() []*Pseudostate {
+				in := newPoint("in", "In", PseudostateKindEntryPoint)
+				return []*Pseudostate{in, in}
+			},
+			useIDs:     []string{"in"},
+			wantErrors: []string{"connection point 'in' is listed at indices 0 and 1"},
+		},
+		{
+			name: "duplicate IDs",
+			points: func() []*Pseudostate {
+				return []*Pseudostate{newPoint("cp", "In", PseudostateKindEntryPoint), newPoint("cp", "Out", PseudostateKindExitPoint)}
+			},
+			useIDs:     []string{"cp"},
+			wantErrors: []string{"duplicate connection point ID 'cp' found at indices 0 and 1"},
+		},
+		{
+			name: "entry and exit points sharing a name",
+			points: func() []*Pseudostate {
+				return []*Pseudostate{newPoint("in", "Port", PseudostateKindEntryPoint), newPoint("out", "Port", PseudostateKindExitPoint)}
+			},
+			useIDs:     []string{"in", "out"},
+			wantErrors: []string{"entry point and exit point share the name 'Port' at indices 0 and 1"},
+		},
+		{
+			name: "unused connection point is a warning",
+			points: func() []*Pseudostate {
+				return []*Pseudostate{newPoint("in", "In", PseudostateKindEntryPoint), newPoint("out", "Out", PseudostateKindExitPoint)}
+			},
+			useIDs:       []string{"in"},
+			wantWarnings: []string{"exitPoint 'out' is not referenced by any transition or connection point reference"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			sm.ConnectionPoints = tt.points()
+			region := sm.Regions[0]
+			for _, id := range tt.useIDs {
+				region.Transitions = append(region.Transitions, &Transition{
+					ID: "t_" + id, Source: &Vertex{ID: id}, Target: &Vertex{ID: "state1"}, Kind: TransitionKindExternal,
+				})
+			}
+
+			errors := &ValidationErrors{}
+			sm.validateConnectionPointConsistency(NewValidationContext(), errors)
+
+			check := func(kind string, got []*ValidationError, want []string) {
+				if len(got) != len(want) {
+					t.Fatalf("%s = %v, want %d", kind, got, len(want))
+				}
+				for i, message := range want {
+					if !strings.Contains(got[i].Message, message) {
+						t.Errorf("%s[%d] = %q, want it to contain %q", kind, i, got[i].Message, message)
+					}
+				}
+			}
+			check("errors", errors.Errors, tt.wantErrors)
+			check("warnings", errors.Warnings, tt.wantWarnings)
+			for _, warning := range errors.Warnings {
+				if !warning.IsWarning() {
+					t.Errorf("warning %v should have warning severity", warning)
+				}
+			}
+			if len(tt.wantErrors) == 0 && errors.ToError() != nil {
+				t.Errorf("ToError() = %v, warnings must not fail validation", errors.ToError())
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"fmt"
+)
+
+// VertexStore serves the vertex lookups of validation from outside the
+// model, such as an on-disk index of machines too large to index in memory.
+// Without a store, the rules that resolve vertex IDs across regions (the
+// statemachine.endpoints rule and the suggestions for unknown transition
+// targets) index the whole state machine in memory on every validation;
+// with one, they ask the store for each ID and for the vertices of one
+// region at a time, and unknown targets are reported without suggestions.
+// Stores are keyed by state machine ID so one store can serve a machine and
+// its submachines, or a fleet in bulk validation, and must be safe for
+// concurrent use when shared by concurrent validations.
+type VertexStore interface {
+	// FindVertex returns the first vertex with the given ID declared in the
+	// state machine, at any depth and including its connection points;
+	// found is false when none is
+	FindVertex(stateMachineID, vertexID string) (vertex *Vertex, found bool, err error)
+
+	// RegionVertices calls yield with each vertex declared directly in the
+	// region with the given ID, states before the other vertices, and stops
+	// when yield returns false
+	RegionVertices(stateMachineID, regionID string, yield func(*Vertex) bool) error
+}
+
+// MemoryVertexStore is a VertexStore holding the vertex indexes of state
+// machines in memory. It serves as a reference implementation and to check
+// external stores against; for models with unique region IDs, validating
+// with it reports the same findings as validating without a store.
+type MemoryVertexStore struct {
+	machines map[string]memoryVertexIndex
+}
+
+// memoryVertexIndex holds the vertices of a state machine by ID, and the
+// vertices of its regions by region ID
+type memoryVertexIndex struct {
+	vertices map[string]*Vertex
+	regions  map[string][]*Vertex
+}
+
+// NewMemoryVertexStore indexes the vertices of the given state machines;
+// the first machine with an ID wins
+func NewMemoryVertexStore(machines ...*StateMachine) *MemoryVertexStore {
+	store := &MemoryVertexStore{machines: make(map[string]memoryVertexIndex, len(machines))}
+	for _, sm := range machines {
+		if sm == nil {
+			continue
+		}
+		if _, exists := store.machines[sm.ID]; exists {
+			continue
+		}
+		index := memoryVertexIndex{vertices: canonicalVertices(sm), regions: make(map[string][]*Vertex)}
+		walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+			if _, exists := index.regions[region.ID]; !exists {
+				index.regions[region.ID] = declaredVertices(region)
+			}
+		})
+		store.machines[sm.ID] = index
+	}
+	return store
+}
+
+// FindVertex implements VertexStore
+func (s *MemoryVertexStore) FindVertex(stateMachineID, vertexID string) (*Vertex, bool, error) {
+	index, ok := s.machines[stateMachineID]
+	if !ok {
+		return nil, false, fmt.Errorf("state machine '%s' is not indexed", stateMachineID)
+	}
+	vertex, found := index.vertices[vertexID]
+	return vertex, found, nil
+}
+
+// RegionVertices implements VertexStore
+func (s *MemoryVertexStore) RegionVertices(stateMachineID, regionID string, yield func(*Vertex) bool) error {
+	index, ok := s.machines[stateMachineID]
+	if !ok {
+		return fmt.Errorf("state machine '%s' is not indexed", stateMachineID)
+	}
+	for _, vertex := range index.regions[regionID] {
+		if !yield(vertex) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// WithVertexStore returns a new context whose rules look vertices up in
+// store; nil indexes the model in memory
+func (vc *ValidationContext) WithVertexStore(store VertexStore) *ValidationContext {
+	if vc == nil {
+		vc = NewValidationContext()
+	}
+	newCtx := *vc
+	newCtx.VertexStore = store
+	return &newCtx
+}
+
+// WithVertexStore looks vertices up in store while validating; see
+// VertexStore
+func WithVertexStore(store VertexStore) ValidationOption {
+	return func(o *ValidationOptions) { o.VertexStore = store }
+}
+
+// declaredVertices returns the vertices declared directly in the region,
+// states first, in model order
+func declaredVertices(region *Region) []*Vertex {
+	vertices := make([]*Vertex, 0, len(region.States)+len(region.Vertices))
+	for _, state := range region.States {
+		if state != nil {
+			vertices = append(vertices, &state.Vertex)
+		}
+	}
+	for _, vertex := range region.Vertices {
+		if vertex != nil {
+			vertices = append(vertices, vertex)
+		}
+	}
+	return vertices
+}
+
+// vertexLookup resolves the vertex IDs of a state machine for a rule,
+// through the context's vertex store or an in-memory index of the machine
+type vertexLookup struct {
+	sm      *StateMachine
+	store   VertexStore
+	global  map[string]*Vertex // In-memory index, when there is no store
+	context *ValidationContext
+	errors  *ValidationErrors
+	failed  bool // The store failed; the failure was reported and lookups find nothing
+}
+
+// newVertexLookup returns the vertex lookup of the state machine
+func newVertexLookup(sm *StateMachine, context *ValidationContext, errors *ValidationErrors) *vertexLookup {
+	lookup := &vertexLookup{sm: sm, store: context.VertexStore, context: context, errors: errors}
+	if lookup.store == nil {
+		lookup.global = canonicalVertices(sm)
+	}
+	return lookup
+}
+
+// find returns the first vertex with the given ID declared in the state
+// machine
+func (l *vertexLookup) find(id string) (*Vertex, bool) {
+	if l.store == nil {
+		vertex, ok := l.global[id]
+		return vertex, ok
+	}
+	if l.failed {
+		return nil, false
+	}
+	vertex, found, err := l.store.FindVertex(l.sm.ID, id)
+	if err != nil {
+		l.fail(err)
+		return nil, false
+	}
+	return vertex, found && vertex != nil
+}
+
+// local indexes the vertices declared directly in the region by ID,
+// preferring states over entries in Vertices
+func (l *vertexLookup) local(region *Region) map[string]*Vertex {
+	if l.store == nil {
+		return regionVertices(region)
+	}
+	vertices := make(map[string]*Vertex)
+	if l.failed {
+		return vertices
+	}
+	err := l.store.RegionVertices(l.sm.ID, region.ID, func(vertex *Vertex) bool {
+		if vertex == nil {
+			return true
+		}
+		if _, exists := vertices[vertex.ID]; !exists {
+			vertices[vertex.ID] = vertex
+		}
+		return true
+	})
+	if err != nil {
+		l.fail(err)
+	}
+	return vertices
+}
+
+// fail reports the first failure of the store
+func (l *vertexLookup) fail(err error) {
+	if l.failed {
+		return
+	}
+	l.failed = true
+	l.errors.AddError(
+		ErrorTypeReference,
+		"StateMachine",
+		"VertexStore",
+		fmt.Sprintf("vertex store lookup failed for state machine '%s': %v", l.sm.ID, err),
+		l.context.Path,
+	)
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoryVertexStore(t *testing.T) {
+	sm := createParallelStateMachine()
+	store := NewMemoryVertexStore(sm, nil)
+
+	t.Run("FindVertex", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			machineID string
+			vertexID  string
+			wantFound bool
+			wantErr   bool
+		}{
+			{"top-level state", "sm", "parallel", true, false},
+			{"pseudostate", "sm", "fork", true, false},
+			{"nested state", "sm", "a1", true, false},
+			{"unknown vertex", "sm", "missing", false, false},
+			{"unknown machine", "other", "a1", false, true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				vertex, found, err := store.FindVertex(tt.machineID, tt.vertexID)
+				if (err != nil) != tt.wantErr || found != tt.wantFound {
+					t.Fatalf("FindVertex() = %v, %v, %v", vertex, found, err)
+				}
+				if found && vertex.ID != tt.vertexID {
+					t.Errorf("FindVertex() returned vertex '%s'", vertex.ID)
+				}
+			})
+		}
+	})
+
+	t.Run("RegionVertices", func(t *testing.T) {
+		var ids []string
+		if err := store.RegionVertices("sm", "rA", func(vertex *Vertex) bool {
+			ids = append(ids, vertex.ID)
+			return true
+		}); err != nil {
+			t.Fatalf("RegionVertices() error = %v", err)
+		}
+		if want := []string{"a1", "a2"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("RegionVertices() = %v, want %v", ids, want)
+		}
+
+		calls := 0
+		store.RegionVertices("sm", "rA", func(*Vertex) bool {
+			calls++
+			return false
+		})
+		if calls != 1 {
+			t.Errorf("RegionVertices() called yield %d times after it returned false", calls)
+		}
+		if err := store.RegionVertices("other", "rA", func(*Vertex) bool { return true }); err == nil {
+			t.Error("RegionVertices() of an unknown machine should fail")
+		}
+	})
+}
+
+// countingVertexStore counts the lookups served by a store
+type countingVertexStore struct {
+	VertexStore
+	lookups atomic.Int64
+}
+
+func (s *countingVertexStore) FindVertex(stateMachineID, vertexID string) (*Vertex, bool, error) {
+	s.lookups.Add(1)
+	return s.VertexStore.FindVertex(stateMachineID, vertexID)
+}
+
+func (s *countingVertexStore) RegionVertices(stateMachineID, regionID string, yield func(*Vertex) bool) error {
+	s.lookups.Add(1)
+	return s.VertexStore.RegionVertices(stateMachineID, regionID, yield)
+}
+
+// failingVertexStore fails every lookup
+type failingVertexStore struct{}
+
+func (failingVertexStore) FindVertex(string, string) (*Vertex, bool, error) {
+	return nil, false, errors.New("index unavailable")
+}
+
+func (failingVertexStore) RegionVertices(string, string, func(*Vertex) bool) error {
+	return errors.New("index unavailable")
+}
+
+func TestValidateWithVertexStore(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(sm *StateMachine)
+		errContains string
+	}{
+		{
+			name:   "valid model",
+			modify: func(sm *StateMachine) {},
+		},
+		{
+			name: "stale copy of a vertex in the transition's region",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Source = &Vertex{ID: "parallel", Name: "Old Name", Type: "state"}
+			},
+			errContains: `source vertex 'parallel' of transition 'tdone' does not match the declared vertex`,
+		},
+		{
+			name: "stale copy of a nested vertex",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[0].Target = &Vertex{ID: "a1", Name: "Old Name", Type: "state"}
+			},
+			errContains: `target vertex 'a1' of transition 'tf1' does not match the declared vertex`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createParallelStateMachine()
+			tt.modify(sm)
+			store := &countingVertexStore{VertexStore: NewMemoryVertexStore(sm)}
+
+			want := errorString(sm.Validate())
+			got := errorString(sm.ValidateWithOptions(WithVertexStore(store)))
+			if got != want {
+				t.Errorf("ValidateWithOptions(WithVertexStore()) = %s, want the findings without a store: %s", got, want)
+			}
+			if tt.errContains != "" && !strings.Contains(got, tt.errContains) {
+				t.Errorf("ValidateWithOptions() = %s, want %q", got, tt.errContains)
+			}
+			if store.lookups.Load() == 0 {
+				t.Error("validation did not consult the vertex store")
+			}
+		})
+	}
+}
+
+func TestValidateWithVertexStore_Failure(t *testing.T) {
+	sm := createParallelStateMachine()
+	err := sm.ValidateWithOptions(WithVertexStore(failingVertexStore{}))
+	if err == nil || strings.Count(err.Error(), "vertex store lookup failed for state machine 'sm': index unavailable") != 1 {
+		t.Errorf("ValidateWithOptions() = %v, want one store failure", err)
+	}
+}
+
+func TestValidateAllWithVertexStore(t *testing.T) {
+	a, b := createParallelStateMachine(), createValidStateMachine()
+	store := &countingVertexStore{VertexStore: NewMemoryVertexStore(a, b)}
+
+	got, err := ValidateAllWithOptions([]*StateMachine{a, b}, WithVertexStore(store))
+	if err != nil {
+		t.Fatalf("ValidateAllWithOptions() error = %v", err)
+	}
+	want, _ := ValidateAllWithOptions([]*StateMachine{a, b})
+	if !reflect.DeepEqual(got.GetResults(), want.GetResults()) {
+		t.Errorf("ValidateAllWithOptions(WithVertexStore()) = %v, want the results without a store: %v", got.GetResults(), want.GetResults())
+	}
+	if store.lookups.Load() == 0 {
+		t.Error("bulk validation did not consult the vertex store")
+	}
+}
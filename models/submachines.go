@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SubmachineCycle is a chain of submachine references that leads back to the
+// state machine it starts from. Machines holds the state machine IDs along
+// the chain with the first ID repeated at the end; States holds the IDs of
+// the submachine states that link each machine to the next one.
+type SubmachineCycle struct {
+	Machines []string `json:"machines"`
+	States   []string `json:"states"`
+}
+
+// String formats the cycle as "A (state 's1') -> B (state 's2') -> A"
+func (c SubmachineCycle) String() string {
+	var b strings.Builder
+	for i, machineID := range c.Machines {
+		if i > 0 {
+			b.WriteString(" -> ")
+		}
+		b.WriteString(machineID)
+		if i < len(c.States) {
+			fmt.Fprintf(&b, " (state '%s')", c.States[i])
+		}
+	}
+	return b.String()
+}
+
+// submachineLink is a submachine state of one machine referencing another
+type submachineLink struct {
+	stateID   string
+	machineID string
+}
+
+// SubmachineCycles returns the submachine recursion cycles reachable from the
+// given state machines, following State.Submachine references through every
+// nested region and submachine. Machines are identified by ID, so a
+// submachine reference that only carries an ID is resolved against the other
+// machines that are reachable or passed in. Each cycle is reported once.
+func SubmachineCycles(machines ...*StateMachine) []SubmachineCycle {
+	_, order, graph := submachineGraph(machines...)
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	status := make(map[string]int)
+	reported := make(map[string]bool)
+	var cycles []SubmachineCycle
+	var stack []string
+	var via []string
+
+	var visit func(machineID string)
+	visit = func(machineID string) {
+		status[machineID] = onStack
+		stack = append(stack, machineID)
+		for _, link := range graph[machineID] {
+			switch status[link.machineID] {
+			case onStack:
+				start := len(stack) - 1
+				for stack[start] != link.machineID {
+					start--
+				}
+				cycle := SubmachineCycle{
+					Machines: append(append([]string{}, stack[start:]...), link.machineID),
+					States:   append(append([]string{}, via[start:]...), link.stateID),
+				}
+				if key := cycleKey(cycle); !reported[key] {
+					reported[key] = true
+					cycles = append(cycles, cycle)
+				}
+			case unvisited:
+				via = append(via, link.stateID)
+				visit(link.machineID)
+				via = via[:len(via)-1]
+			}
+		}
+		stack = stack[:len(stack)-1]
+		status[machineID] = done
+	}
+	for _, machineID := range order {
+		if status[machineID] == unvisited {
+			visit(machineID)
+		}
+	}
+	return cycles
+}
+
+// submachineGraph resolves every machine reachable from the given ones by
+// ID, preferring a full definition over a reference that only carries the
+// ID, and returns them by ID, their IDs in discovery order and the
+// submachine states of each machine
+func submachineGraph(machines ...*StateMachine) (map[string]*StateMachine, []string, map[string][]submachineLink) {
+	byID := make(map[string]*StateMachine)
+	var order []string
+	seen := make(map[*StateMachine]bool)
+	queue := append([]*StateMachine{}, machines...)
+	for len(queue) > 0 {
+		sm := queue[0]
+		queue = queue[1:]
+		if sm == nil || sm.ID == "" || seen[sm] {
+			continue
+		}
+		seen[sm] = true
+		if existing, exists := byID[sm.ID]; !exists {
+			order = append(order, sm.ID)
+			byID[sm.ID] = sm
+		} else if len(existing.Regions) == 0 {
+			byID[sm.ID] = sm
+		}
+		walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+			for _, state := range region.States {
+				if state != nil && state.Submachine != nil {
+					queue = append(queue, state.Submachine)
+				}
+			}
+		})
+	}
+
+	graph := make(map[string][]submachineLink)
+	for _, machineID := range order {
+		walkRegionTree(byID[machineID].Regions, "", func(region *Region, _ string) {
+			for _, state := range region.States {
+				if state != nil && state.Submachine != nil && state.Submachine.ID != "" {
+					graph[machineID] = append(graph[machineID], submachineLink{stateID: state.ID, machineID: state.Submachine.ID})
+				}
+			}
+		})
+	}
+	return byID, order, graph
+}
+
+// cycleKey identifies a cycle independently of the machine it starts from
+func cycleKey(cycle SubmachineCycle) string {
+	n := len(cycle.States)
+	start := 0
+	for i := 1; i < n; i++ {
+		if cycle.Machines[i] < cycle.Machines[start] {
+			start = i
+		}
+	}
+	parts := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		j := (start + i) % n
+		parts = append(parts, cycle.Machines[j]+"/"+cycle.States[j])
+	}
+	return strings.Join(parts, "|")
+}
+
+// validateSubmachineRecursion reports state machines that include themselves
+// directly or through a chain of submachine states. Only the machine at the
+// root of the validation reports cycles, since nested machines are reached
+// through it and would report the same cycles again.
+func (sm *StateMachine) validateSubmachineRecursion(context *ValidationContext, errors *ValidationErrors) {
+	if len(context.machineChain) > 1 {
+		return
+	}
+
+	for _, cycle := range SubmachineCycles(sm) {
+		message := fmt.Sprintf("submachine recursion cycle %s; a state machine cannot include itself directly or indirectly (circular reference)", cycle)
+		if len(cycle.States) == 1 {
+			message = fmt.Sprintf("state machine '%s' includes itself through submachine state '%s' (circular reference)", cycle.Machines[0], cycle.States[0])
+		}
+		errors.AddError(
+			ErrorTypeConstraint,
+			"StateMachine",
+			"Submachine",
+			message,
+			context.Path,
+		)
+	}
+}
+
+// validatingMachine reports whether sm is already being validated further up
+// the submachine chain
+func (vc *ValidationContext) validatingMachine(sm *StateMachine) bool {
+	for _, machine := range vc.machineChain {
+		if machine == sm {
+			return true
+		}
+	}
+	return false
+}
+
+// withMachine returns a new context with sm appended to the submachine chain
+func (vc *ValidationContext) withMachine(sm *StateMachine) *ValidationContext {
+	newCtx := *vc
+	newCtx.machineChain = append(append([]*StateMachine{}, vc.machineChain...), sm)
+	return &newCtx
+}
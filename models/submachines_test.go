@@ -0,0 +1,140 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// includeSubmachine turns the second state of sm's first region into a
+// submachine state referencing submachine
+func includeSubmachine(sm, submachine *StateMachine) {
+	state := sm.Regions[0].States[1]
+	state.IsSimple = false
+	state.IsSubmachineState = true
+	state.Submachine = submachine
+}
+
+func newSubmachineFixture(id string) *StateMachine {
+	sm := createValidStateMachine()
+	sm.ID = id
+	return sm
+}
+
+func TestSubmachineCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() []*StateMachine
+		want  []SubmachineCycle
+	}{
+		{
+			name: "no recursion",
+			build: func() []*StateMachine {
+				a, b := newSubmachineFixture("A"), newSubmachineFixture("B")
+				includeSubmachine(a, b)
+				return []*StateMachine{a}
+			},
+			want: nil,
+		},
+		{
+			name: "direct self reference",
+			build: func() []*StateMachine {
+				a := newSubmachineFixture("A")
+				includeSubmachine(a, a)
+				return []*StateMachine{a}
+			},
+			want: []SubmachineCycle{{Machines: []string{"A", "A"}, States: []string{"state2"}}},
+		},
+		{
+			name: "indirect recursion through three machines",
+			build: func() []*StateMachine {
+				a, b, c := newSubmachineFixture("A"), newSubmachineFixture("B"), newSubmachineFixture("C")
+				includeSubmachine(a, b)
+				includeSubmachine(b, c)
+				includeSubmachine(c, a)
+				return []*StateMachine{a}
+			},
+			want: []SubmachineCycle{{Machines: []string{"A", "B", "C", "A"}, States: []string{"state2", "state2", "state2"}}},
+		},
+		{
+			name: "cycle below the root is reported once",
+			build: func() []*StateMachine {
+				root, a, b := newSubmachineFixture("Root"), newSubmachineFixture("A"), newSubmachineFixture("B")
+				includeSubmachine(root, a)
+				includeSubmachine(a, b)
+				includeSubmachine(b, a)
+				return []*StateMachine{root, b}
+			},
+			want: []SubmachineCycle{{Machines: []string{"A", "B", "A"}, States: []string{"state2", "state2"}}},
+		},
+		{
+			name: "ID-only references resolve against the given machines",
+			build: func() []*StateMachine {
+				a, b := newSubmachineFixture("A"), newSubmachineFixture("B")
+				includeSubmachine(a, &StateMachine{ID: "B"})
+				includeSubmachine(b, &StateMachine{ID: "A"})
+				return []*StateMachine{a, b}
+			},
+			want: []SubmachineCycle{{Machines: []string{"A", "B", "A"}, States: []string{"state2", "state2"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SubmachineCycles(tt.build()...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SubmachineCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmachineCycle_String(t *testing.T) {
+	cycle := SubmachineCycle{Machines: []string{"A", "B", "A"}, States: []string{"s1", "s2"}}
+	if got, want := cycle.String(), "A (state 's1') -> B (state 's2') -> A"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestStateMachineValidate_SubmachineRecursion(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() *StateMachine
+		want  string
+	}{
+		{
+			name: "indirect recursion",
+			build: func() *StateMachine {
+				a, b := newSubmachineFixture("A"), newSubmachineFixture("B")
+				includeSubmachine(a, b)
+				includeSubmachine(b, a)
+				return a
+			},
+			want: "submachine recursion cycle A (state 'state2') -> B (state 'state2') -> A",
+		},
+		{
+			name: "direct recursion",
+			build: func() *StateMachine {
+				a := newSubmachineFixture("A")
+				includeSubmachine(a, a)
+				return a
+			},
+			want: "state machine 'A' includes itself through submachine state 'state2' (circular reference)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.build().Validate()
+			if err == nil {
+				t.Fatal("Validate() error = nil, want a recursion error")
+			}
+			if got := strings.Count(err.Error(), "(circular reference)"); got != 1 {
+				t.Errorf("Validate() reported %d circular references, want 1:\n%v", got, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// suggestion returns the known ID closest to id by edit distance, or "" when
+// none is close enough to be a likely typo of it. A candidate qualifies when
+// at most a third of the longer ID has to change; ties go to the candidate
+// that sorts first, so messages are stable.
+func suggestion[V any](id string, known map[string]V) string {
+	if id == "" {
+		return ""
+	}
+	best, bestDistance := "", 0
+	for _, candidate := range slices.Sorted(maps.Keys(known)) {
+		if candidate == "" || candidate == id {
+			continue
+		}
+		distance := editDistance(id, candidate)
+		if distance*3 > max(len([]rune(id)), len([]rune(candidate))) {
+			continue
+		}
+		if best == "" || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// didYouMean returns "; did you mean 'x'?" naming the suggestion for an
+// unresolved ID, or "" when there is none, for appending to error messages
+func didYouMean[V any](id string, known map[string]V) string {
+	if candidate := suggestion(id, known); candidate != "" {
+		return fmt.Sprintf("; did you mean '%s'?", candidate)
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting a
+// swap of adjacent characters as a single edit since it is a common typo
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Three rows of the distance matrix: two rows back, the previous row and
+	// the current row
+	before, previous, current := make([]int, len(t)+1), make([]int, len(t)+1), make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				current[j] = min(current[j], before[j-2]+1)
+			}
+		}
+		before, previous, current = previous, current, before
+	}
+	return previous[len(t)]
+}
@@ -0,0 +1,58 @@
+package models
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "idle", b: "", want: 4},
+		{a: "idle", b: "idle", want: 0},
+		{a: "idle", b: "idel", want: 1},
+		{a: "state_12", b: "state_21", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "größe", b: "grösse", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := editDistance(tt.b, tt.a); got != tt.want {
+				t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestion(t *testing.T) {
+	known := map[string]bool{"state_12": true, "state_13": true, "idle": true, "a": true, "playing": true}
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "state_21", want: "state_12"},
+		{id: "state_1", want: "state_12"},
+		{id: "Idle", want: "idle"},
+		{id: "playng", want: "playing"},
+		{id: "b", want: ""},
+		{id: "stopped", want: ""},
+		{id: "idle", want: ""},
+		{id: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := suggestion(tt.id, known); got != tt.want {
+				t.Errorf("suggestion(%q) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+	if got := didYouMean("idel", known); got != "; did you mean 'idle'?" {
+		t.Errorf("didYouMean() = %q", got)
+	}
+	if got := didYouMean("stopped", known); got != "" {
+		t.Errorf("didYouMean() = %q, want no suggestion", got)
+	}
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ElementCounts counts the elements of a state machine by type, including
+// the elements of its submachines, each counted once
+type ElementCounts struct {
+	StateMachines int `json:"state_machines"` // The machine and its distinct submachines
+	Regions       int `json:"regions"`
+	States        int `json:"states"`
+	Pseudostates  int `json:"pseudostates"` // Pseudostate vertices and connection points
+	FinalStates   int `json:"final_states"`
+	Transitions   int `json:"transitions"`
+	Triggers      int `json:"triggers"` // Transition triggers and deferrable triggers
+	Events        int `json:"events"`   // Catalog events and events embedded in triggers
+	Behaviors     int `json:"behaviors"`
+	Constraints   int `json:"constraints"`
+}
+
+// Total returns the number of elements of all types
+func (c ElementCounts) Total() int {
+	return c.StateMachines + c.Regions + c.States + c.Pseudostates + c.FinalStates +
+		c.Transitions + c.Triggers + c.Events + c.Behaviors + c.Constraints
+}
+
+// ValidationSummary describes a validation run, so that pipelines can log
+// the size of a machine and the work validation did next to its findings
+type ValidationSummary struct {
+	StateMachineID  string        `json:"state_machine_id"`
+	Profile         string        `json:"profile"` // Name of the validation profile used
+	Elements        ElementCounts `json:"elements"`
+	Rules           []string      `json:"rules"`            // IDs of the catalog rules that applied to at least one element, sorted
+	RuleEvaluations int           `json:"rule_evaluations"` // Number of times catalog rules applied to an element
+	Duration        time.Duration `json:"duration"`
+	Errors          int           `json:"errors"`
+	Warnings        int           `json:"warnings"`
+	Infos           int           `json:"infos"`
+	Skipped         []SkippedRule `json:"skipped,omitempty"`    // Rules not checked because rules they depend on failed
+	Suppressed      int           `json:"suppressed,omitempty"` // Findings silenced by suppressions on model elements
+}
+
+// String returns a one-line description of the summary for logs
+func (s *ValidationSummary) String() string {
+	description := fmt.Sprintf("state machine '%s' (profile %s): %d element(s), %d rule(s) applied %d time(s) in %s: %d error(s), %d warning(s), %d info(s)",
+		s.StateMachineID, s.Profile, s.Elements.Total(), len(s.Rules), s.RuleEvaluations, s.Duration, s.Errors, s.Warnings, s.Infos)
+	if len(s.Skipped) > 0 {
+		description += fmt.Sprintf(", %d rule(s) skipped", len(s.Skipped))
+	}
+	return description
+}
+
+// ValidateWithSummary validates the StateMachine like ValidateInContext and
+// also returns a summary of the run. A nil context validates with a new
+// context. The summary is returned whether or not validation fails.
+func (sm *StateMachine) ValidateWithSummary(context *ValidationContext) (*ValidationSummary, error) {
+	if context == nil {
+		context = NewValidationContext()
+	}
+	errors := &ValidationErrors{}
+	start := time.Now()
+	sm.ValidateWithErrors(context.WithStateMachine(sm), errors)
+	duration := time.Since(start)
+
+	summary := summarize(sm)
+	summary.Profile = context.ActiveProfile().Name
+	summary.Duration = duration
+	summary.Errors = len(errors.Errors)
+	summary.Warnings = len(errors.Warnings)
+	summary.Infos = len(errors.Infos)
+	summary.Skipped = errors.Skipped
+	summary.Suppressed = len(errors.Suppressed)
+	return summary, errors.ToError()
+}
+
+// CountElements counts the elements of a state machine and its submachines
+// by type
+func CountElements(sm *StateMachine) ElementCounts {
+	return summarize(sm).Elements
+}
+
+// summarize counts the elements of sm and the catalog rules that apply to
+// them
+func summarize(sm *StateMachine) *ValidationSummary {
+	summary := &ValidationSummary{Rules: []string{}}
+	if sm == nil {
+		return summary
+	}
+	summary.StateMachineID = sm.ID
+
+	counts := &summary.Elements
+	rules := make(map[string]bool)
+	apply := func(obj interface{}) {
+		for _, rule := range ApplicableRules(obj) {
+			rules[rule.ID] = true
+			summary.RuleEvaluations++
+		}
+	}
+	behavior := func(b *Behavior) {
+		if b != nil {
+			counts.Behaviors++
+			apply(b)
+		}
+	}
+	trigger := func(t *Trigger) {
+		if t == nil {
+			return
+		}
+		counts.Triggers++
+		apply(t)
+		if t.Event != nil {
+			counts.Events++
+			apply(t.Event)
+		}
+	}
+	pseudostate := func(ps *Pseudostate) {
+		if ps != nil {
+			counts.Pseudostates++
+			apply(ps)
+		}
+	}
+
+	seen := map[*StateMachine]bool{sm: true}
+	machines := []*StateMachine{sm}
+	for len(machines) > 0 {
+		machine := machines[0]
+		machines = machines[1:]
+		counts.StateMachines++
+		apply(machine)
+		for _, point := range machine.ConnectionPoints {
+			pseudostate(point)
+		}
+		for _, event := range machine.Events {
+			if event != nil {
+				counts.Events++
+				apply(event)
+			}
+		}
+
+		walkRegionTree(machine.Regions, "", func(region *Region, _ string) {
+			counts.Regions++
+			apply(region)
+			for _, state := range region.States {
+				if state == nil {
+					continue
+				}
+				counts.States++
+				apply(state)
+				behavior(state.Entry)
+				behavior(state.Exit)
+				behavior(state.DoActivity)
+				for _, deferred := range state.DeferrableTriggers {
+					trigger(deferred)
+				}
+				if submachine := state.Submachine; submachine != nil && !seen[submachine] {
+					seen[submachine] = true
+					machines = append(machines, submachine)
+				}
+			}
+			for _, vertex := range region.Vertices {
+				switch {
+				case vertex == nil:
+				case vertex.Type == "pseudostate":
+					pseudostate(&Pseudostate{Vertex: *vertex, Kind: pseudostateKindOf(vertex)})
+				case vertex.Type == "finalstate":
+					counts.FinalStates++
+					apply(&FinalState{Vertex: *vertex})
+				default:
+					apply(vertex)
+				}
+			}
+			for _, transition := range region.Transitions {
+				if transition == nil {
+					continue
+				}
+				counts.Transitions++
+				apply(transition)
+				for _, t := range transition.Triggers {
+					trigger(t)
+				}
+				if transition.Guard != nil {
+					counts.Constraints++
+					apply(transition.Guard)
+				}
+				behavior(transition.Effect)
+			}
+		})
+	}
+
+	for id := range rules {
+		summary.Rules = append(summary.Rules, id)
+	}
+	slices.Sort(summary.Rules)
+	return summary
+}
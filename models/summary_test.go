@@ -0,0 +1,172 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCountElements(t *testing.T) {
+	withBehaviors := func() *StateMachine {
+		sm := newPlayerMachine()
+		main := sm.Regions[0]
+		main.States[0].Entry = &Behavior{ID: "idle-entry", Specification: "reset()"}
+		main.Transitions[1].Guard = &Constraint{ID: "ready-guard", Specification: "ready"}
+		main.Transitions[2].Effect = &Behavior{ID: "stop-effect", Specification: "halt()"}
+		main.Transitions[2].Triggers[0].Event = &Event{ID: "stop-event", Name: "stop", Type: EventTypeSignal}
+		main.Vertices = append(main.Vertices, execFinal("done"))
+		sm.Events = []*Event{{ID: "play", Name: "play", Type: EventTypeSignal}}
+		return sm
+	}
+	withSubmachine := func() *StateMachine {
+		sm := newPlayerMachine()
+		sub := newPlayerMachine()
+		sub.ID = "sub"
+		sub.ConnectionPoints = []*Pseudostate{{Vertex: Vertex{ID: "sub-entry", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint}}
+		first := &State{Vertex: Vertex{ID: "first", Type: "state"}, IsSubmachineState: true, Submachine: sub}
+		second := &State{Vertex: Vertex{ID: "second", Type: "state"}, IsSubmachineState: true, Submachine: sub}
+		sm.Regions[0].States = append(sm.Regions[0].States, first, second)
+		return sm
+	}
+
+	tests := []struct {
+		name string
+		sm   *StateMachine
+		want ElementCounts
+	}{
+		{
+			name: "nil machine",
+			sm:   nil,
+			want: ElementCounts{},
+		},
+		{
+			name: "player machine",
+			sm:   newPlayerMachine(),
+			want: ElementCounts{StateMachines: 1, Regions: 3, States: 6, Pseudostates: 3, Transitions: 9, Triggers: 7},
+		},
+		{
+			name: "behaviors, constraints, events and final states",
+			sm:   withBehaviors(),
+			want: ElementCounts{StateMachines: 1, Regions: 3, States: 6, Pseudostates: 3, FinalStates: 1, Transitions: 9, Triggers: 7, Events: 2, Behaviors: 2, Constraints: 1},
+		},
+		{
+			name: "shared submachine counted once",
+			sm:   withSubmachine(),
+			want: ElementCounts{StateMachines: 2, Regions: 6, States: 14, Pseudostates: 7, Transitions: 18, Triggers: 14},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CountElements(tt.sm)
+			if got != tt.want {
+				t.Errorf("CountElements() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWithSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		sm          func() *StateMachine
+		context     *ValidationContext
+		wantErr     bool
+		wantProfile string
+		wantRules   []string
+	}{
+		{
+			name:        "valid machine with default profile",
+			sm:          createValidStateMachine,
+			context:     nil,
+			wantProfile: "default",
+			wantRules:   []string{"statemachine.regions.multiplicity", "region.initial.multiplicity", "pseudostate.initial.name", "transition.endpoints", "behavior.specification"},
+		},
+		{
+			name:        "invalid machine with default profile",
+			sm:          newPlayerMachine,
+			context:     NewValidationContext(),
+			wantErr:     true,
+			wantProfile: "default",
+		},
+		{
+			name: "invalid machine with strict profile",
+			sm: func() *StateMachine {
+				sm := newPlayerMachine()
+				sm.Name = ""
+				return sm
+			},
+			context:     NewValidationContext().WithProfile(StrictProfile),
+			wantErr:     true,
+			wantProfile: "strict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := tt.sm()
+			summary, err := sm.ValidateWithSummary(tt.context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWithSummary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if summary == nil {
+				t.Fatal("ValidateWithSummary() returned no summary")
+			}
+			if summary.StateMachineID != sm.ID || summary.Profile != tt.wantProfile {
+				t.Errorf("summary = %s, want machine %s and profile %s", summary, sm.ID, tt.wantProfile)
+			}
+			if summary.Elements != CountElements(sm) {
+				t.Errorf("Elements = %+v, want %+v", summary.Elements, CountElements(sm))
+			}
+			if summary.Duration <= 0 {
+				t.Errorf("Duration = %s, want a positive duration", summary.Duration)
+			}
+			if !slices.IsSorted(summary.Rules) || summary.RuleEvaluations < len(summary.Rules) {
+				t.Errorf("Rules = %v with %d evaluations, want sorted rules evaluated at least once", summary.Rules, summary.RuleEvaluations)
+			}
+			for _, rule := range tt.wantRules {
+				if !slices.Contains(summary.Rules, rule) {
+					t.Errorf("Rules = %v, want %s", summary.Rules, rule)
+				}
+			}
+
+			expected := &ValidationErrors{}
+			sm.ValidateWithErrors(tt.context.WithStateMachine(sm), expected)
+			if summary.Errors != len(expected.Errors) || summary.Warnings != len(expected.Warnings) || summary.Infos != len(expected.Infos) {
+				t.Errorf("summary counts %d/%d/%d, want %d/%d/%d", summary.Errors, summary.Warnings, summary.Infos,
+					len(expected.Errors), len(expected.Warnings), len(expected.Infos))
+			}
+		})
+	}
+}
+
+func TestValidationSummaryEncoding(t *testing.T) {
+	summary, err := newPlayerMachine().ValidateWithSummary(nil)
+	if err == nil {
+		t.Fatal("ValidateWithSummary() expected the player machine to be invalid")
+	}
+
+	if line := summary.String(); !strings.Contains(line, "state machine 'player' (profile default): 29 element(s)") ||
+		summary.Errors == 0 || !strings.Contains(line, fmt.Sprintf(": %d error(s), 0 warning(s), 0 info(s)", summary.Errors)) {
+		t.Errorf("String() = %q", line)
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded ValidationSummary
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded.Elements != summary.Elements || !slices.Equal(decoded.Rules, summary.Rules) || decoded.Duration != summary.Duration {
+		t.Errorf("decoded summary = %+v, want %+v", decoded, summary)
+	}
+	for _, key := range []string{`"state_machine_id":"player"`, `"profile":"default"`, `"states":6`, `"rule_evaluations":`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("JSON %s does not contain %s", data, key)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Suppression silences the findings of a rule about one element, like a
+// //nolint comment in Go code. Suppressions are listed on the element, or on
+// the state machine to silence a rule everywhere in it. Findings are about
+// the element their path names (ValidationError.ElementIDs), and rules are
+// the IDs validation records on findings (ValidationError.Rule).
+// Suppressed findings are moved to ValidationErrors.Suppressed, so reports
+// still show them and their justification.
+type Suppression struct {
+	Rule          string `json:"rule"`          // Rule ID, e.g. "transition.trigger_placement"; "transition.*" matches the rules under a prefix and "*" every finding
+	Justification string `json:"justification"` // Why the findings are accepted; suppressions without one are reported as warnings
+}
+
+// Matches reports whether the suppression applies to findings of the rule
+func (s Suppression) Matches(rule string) bool {
+	switch {
+	case s.Rule == "*":
+		return true
+	case strings.HasSuffix(s.Rule, ".*"):
+		return strings.HasPrefix(rule, strings.TrimSuffix(s.Rule, "*"))
+	default:
+		return s.Rule != "" && s.Rule == rule
+	}
+}
+
+// SuppressedFinding is a finding silenced by a suppression
+type SuppressedFinding struct {
+	Finding     *ValidationError `json:"finding"`
+	ElementID   string           `json:"element_id"` // ID of the element carrying the suppression
+	Suppression Suppression      `json:"suppression"`
+}
+
+// String returns a concise one-line description of the suppressed finding
+func (s SuppressedFinding) String() string {
+	return fmt.Sprintf("%s (suppressed by %s on '%s': %s)", s.Finding.Error(), s.Suppression.Rule, s.ElementID, s.Suppression.Justification)
+}
+
+// suppressionSite is an element carrying suppressions
+type suppressionSite struct {
+	id, object   string
+	path         []string
+	suppressions []Suppression
+}
+
+// suppressionSites returns the elements of the state machine that carry
+// suppressions, in model order
+func suppressionSites(sm *StateMachine) []suppressionSite {
+	var sites []suppressionSite
+	add := func(id, object, path string, suppressions []Suppression) {
+		if len(suppressions) > 0 {
+			sites = append(sites, suppressionSite{id: id, object: object, path: strings.Split(path, "."), suppressions: suppressions})
+		}
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, path string) {
+		add(region.ID, "Region", path, region.Suppressions)
+		for i, state := range region.States {
+			if state != nil {
+				add(state.ID, "State", fmt.Sprintf("%s.States[%d]", path, i), state.Suppressions)
+			}
+		}
+		for i, vertex := range region.Vertices {
+			if vertex != nil {
+				add(vertex.ID, "Vertex", fmt.Sprintf("%s.Vertices[%d]", path, i), vertex.Suppressions)
+			}
+		}
+		for i, transition := range region.Transitions {
+			if transition != nil {
+				add(transition.ID, "Transition", fmt.Sprintf("%s.Transitions[%d]", path, i), transition.Suppressions)
+			}
+		}
+	})
+	return sites
+}
+
+// applySuppressions moves the findings from the given counts on that
+// suppressions on the state machine's elements silence to errors.Suppressed,
+// then warns about suppressions without a justification
+func (sm *StateMachine) applySuppressions(context *ValidationContext, errors *ValidationErrors, errorCount, warningCount, infoCount int) {
+	sites := suppressionSites(sm)
+	if len(sites) == 0 && len(sm.Suppressions) == 0 {
+		return
+	}
+	byID := make(map[string][]Suppression, len(sites))
+	for _, site := range sites {
+		byID[site.id] = append(byID[site.id], site.suppressions...)
+	}
+
+	suppress := func(finding *ValidationError) bool {
+		if finding == nil {
+			return false
+		}
+		if ids := finding.ElementIDs(); len(ids) > 0 {
+			for _, suppression := range byID[ids[0]] {
+				if suppression.Matches(finding.Rule) {
+					errors.Suppressed = append(errors.Suppressed, SuppressedFinding{Finding: finding, ElementID: ids[0], Suppression: suppression})
+					return true
+				}
+			}
+		}
+		for _, suppression := range sm.Suppressions {
+			if suppression.Matches(finding.Rule) {
+				errors.Suppressed = append(errors.Suppressed, SuppressedFinding{Finding: finding, ElementID: sm.ID, Suppression: suppression})
+				return true
+			}
+		}
+		return false
+	}
+	keep := func(findings []*ValidationError, from int) []*ValidationError {
+		kept := findings[:from]
+		for _, finding := range findings[from:] {
+			if !suppress(finding) {
+				kept = append(kept, finding)
+			}
+		}
+		return kept
+	}
+	errors.Errors = keep(errors.Errors, errorCount)
+	errors.Warnings = keep(errors.Warnings, warningCount)
+	errors.Infos = keep(errors.Infos, infoCount)
+
+	sites = append([]suppressionSite{{id: sm.ID, object: "StateMachine", suppressions: sm.Suppressions}}, sites...)
+	for _, site := range sites {
+		for i, suppression := range site.suppressions {
+			if strings.TrimSpace(suppression.Justification) != "" {
+				continue
+			}
+			errors.Add(&ValidationError{
+				Type:     ErrorTypeInvalid,
+				Object:   site.object,
+				Field:    "Suppressions",
+				Message:  fmt.Sprintf("suppression of rule '%s' on %s '%s' has no justification", suppression.Rule, site.object, site.id),
+				Path:     append(append(append([]string{}, context.Path...), site.path...), fmt.Sprintf("Suppressions[%d]", i)),
+				Context:  map[string]interface{}{ElementIDsContextKey: []string{site.id}},
+				Severity: SeverityWarning,
+			})
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuppression_Matches(t *testing.T) {
+	tests := []struct {
+		rule, finding string
+		want          bool
+	}{
+		{"transition.trigger_placement", "transition.trigger_placement", true},
+		{"transition.trigger_placement", "transition.kind", false},
+		{"transition.*", "transition.kind", true},
+		{"transition.*", "transitions.kind", false},
+		{"*", "", true},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.finding, func(t *testing.T) {
+			if got := (Suppression{Rule: tt.rule}).Matches(tt.finding); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.finding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	justified := func(rule string) []Suppression {
+		return []Suppression{{Rule: rule, Justification: "legacy model"}}
+	}
+
+	tests := []struct {
+		name           string
+		modify         func(sm *StateMachine)
+		wantSuppressed []string // Messages of the suppressed findings
+		wantWarnings   []string // Messages of the warnings left
+		wantErrors     int
+	}{
+		{
+			name: "no suppressions",
+			wantWarnings: []string{
+				"transition 't2' leaving state 'state1' has no triggers",
+				"transition 't3' leaving state 'state2' has no triggers",
+			},
+		},
+		{
+			name: "rule suppressed on one transition",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Suppressions = justified("transition.trigger_placement")
+			},
+			wantSuppressed: []string{"transition 't2' leaving state 'state1' has no triggers"},
+			wantWarnings:   []string{"transition 't3' leaving state 'state2' has no triggers"},
+		},
+		{
+			name: "other rules are still reported",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Suppressions = justified("transition.kind")
+			},
+			wantWarnings: []string{
+				"transition 't2' leaving state 'state1' has no triggers",
+				"transition 't3' leaving state 'state2' has no triggers",
+			},
+		},
+		{
+			name: "rule prefix suppressed on the state machine",
+			modify: func(sm *StateMachine) {
+				sm.Suppressions = justified("transition.*")
+			},
+			wantSuppressed: []string{
+				"transition 't2' leaving state 'state1' has no triggers",
+				"transition 't3' leaving state 'state2' has no triggers",
+			},
+		},
+		{
+			name: "all findings about a state",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[0].Name = ""
+				sm.Regions[0].States[0].Suppressions = justified("*")
+			},
+			wantSuppressed: []string{
+				"field is required and cannot be empty",
+				"state vertices should have meaningful names (UML best practice)",
+			},
+			wantWarnings: []string{
+				"transition 't2' leaving state 'state1' has no triggers",
+				"transition 't3' leaving state 'state2' has no triggers",
+			},
+			wantErrors: 2, // The transitions' endpoints no longer match the renamed state
+		},
+		{
+			name: "suppressions need a justification",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Suppressions = []Suppression{{Rule: "transition.trigger_placement"}}
+			},
+			wantSuppressed: []string{"transition 't3' leaving state 'state2' has no triggers"},
+			wantWarnings: []string{
+				"transition 't2' leaving state 'state1' has no triggers",
+				"suppression of rule 'transition.trigger_placement' on Transition 't3' has no justification",
+			},
+		},
+	}
+
+	messages := func(findings []*ValidationError) []string {
+		return MapSlice(findings, func(err *ValidationError) string { return err.Message })
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			errors := &ValidationErrors{}
+			sm.ValidateWithErrors(NewValidationContext().WithStateMachine(sm), errors)
+
+			suppressed := MapSlice(errors.Suppressed, func(s SuppressedFinding) *ValidationError { return s.Finding })
+			checkMessages(t, "suppressed", messages(suppressed), tt.wantSuppressed)
+			checkMessages(t, "warnings", messages(errors.Warnings), tt.wantWarnings)
+			if len(errors.Errors) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %v", len(errors.Errors), tt.wantErrors, errors.Errors)
+			}
+		})
+	}
+}
+
+// checkMessages checks that each message starts with the wanted prefix
+func checkMessages(t *testing.T, kind string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", kind, got, want)
+		return
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("%s[%d] = %q, want prefix %q", kind, i, got[i], want[i])
+		}
+	}
+}
+
+func TestSuppressions_Reporting(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Regions[0].Name = ""
+	sm.Regions[0].Suppressions = []Suppression{{Rule: "*", Justification: "named by the importer"}}
+	err := sm.ValidateWithOptions()
+	if err != nil {
+		t.Fatalf("ValidateWithOptions() = %v, want the region's findings suppressed", err)
+	}
+
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext().WithStateMachine(sm), errors)
+	if len(errors.Suppressed) == 0 {
+		t.Fatal("no findings were suppressed")
+	}
+	first := errors.Suppressed[0]
+	if first.ElementID != "region1" || first.Suppression.Justification != "named by the importer" {
+		t.Errorf("Suppressed[0] = %+v", first)
+	}
+	if report := errors.GetDetailedReport(); !strings.Contains(report, "Suppressed (") || !strings.Contains(report, "suppressed by * on 'region1': named by the importer") {
+		t.Errorf("report lacks the suppressed section:\n%s", report)
+	}
+	data, jsonErr := json.Marshal(errors)
+	if jsonErr != nil {
+		t.Fatalf("json.Marshal() error = %v", jsonErr)
+	}
+	if !strings.Contains(string(data), `"suppressed":[{"finding":`) {
+		t.Errorf("JSON lacks the suppressed section: %s", data)
+	}
+
+	summary, _ := sm.ValidateWithSummary(nil)
+	if summary.Suppressed != len(errors.Suppressed) {
+		t.Errorf("ValidationSummary.Suppressed = %d, want %d", summary.Suppressed, len(errors.Suppressed))
+	}
+
+	merged := &ValidationErrors{}
+	merged.Merge(errors)
+	if len(merged.Suppressed) != len(errors.Suppressed) {
+		t.Errorf("Merge() kept %d suppressed findings, want %d", len(merged.Suppressed), len(errors.Suppressed))
+	}
+	merged.Clear()
+	if len(merged.Suppressed) != 0 {
+		t.Errorf("Clear() kept %d suppressed findings", len(merged.Suppressed))
+	}
+}
+
+func TestSuppressions_Clone(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Suppressions = []Suppression{{Rule: "*", Justification: "machine"}}
+	sm.Regions[0].Suppressions = []Suppression{{Rule: "*", Justification: "region"}}
+	sm.Regions[0].States[0].Suppressions = []Suppression{{Rule: "*", Justification: "state"}}
+	sm.Regions[0].Vertices[0].Suppressions = []Suppression{{Rule: "*", Justification: "vertex"}}
+	sm.Regions[0].Transitions[0].Suppressions = []Suppression{{Rule: "*", Justification: "transition"}}
+
+	clone := sm.Clone()
+	lists := func(sm *StateMachine) [][]Suppression {
+		region := sm.Regions[0]
+		return [][]Suppression{sm.Suppressions, region.Suppressions, region.States[0].Suppressions, region.Vertices[0].Suppressions, region.Transitions[0].Suppressions}
+	}
+	if !reflect.DeepEqual(lists(clone), lists(sm)) {
+		t.Fatalf("Clone() suppressions = %v, want %v", lists(clone), lists(sm))
+	}
+	for _, list := range lists(clone) {
+		list[0].Justification = "changed"
+	}
+	for i, list := range lists(sm) {
+		if list[0].Justification == "changed" {
+			t.Errorf("suppression list %d is shared with the clone", i)
+		}
+	}
+}
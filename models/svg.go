@@ -0,0 +1,227 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgExporter renders state machines as SVG images, so that web
+// applications can display models without a diagram server. It draws the
+// state machine's Layout if it covers the whole machine and computes a
+// LayeredLayout otherwise; the "direction" property ("TB" or "LR") applies
+// to computed layouts. States are rounded boxes with composite states
+// nesting their regions, orthogonal regions are dashed boxes, pseudostates
+// and final states use their UML shapes, and transitions are arrows labeled
+// with their triggers, guard and effect. Deprecated states and transitions
+// are struck through. Every element is a group with a class and a data-id
+// attribute holding its ID, for styling and scripting.
+type svgExporter struct{}
+
+func (svgExporter) Name() string { return "svg" }
+
+// Sizes of the parts of SVG diagrams
+const (
+	svgMargin      = 20.0
+	svgTitleHeight = 30.0
+	svgFontSize    = 12.0
+)
+
+func (svgExporter) Export(sm *StateMachine, w io.Writer, opts ExportOptions) error {
+	layout, err := diagramLayout(sm, opts)
+	if err != nil {
+		return err
+	}
+	// Shift the layout below the title
+	dx, dy := svgMargin, svgMargin+svgTitleHeight
+	box := func(b Box) Box { return b.translate(dx, dy) }
+
+	var out strings.Builder
+	width, height := layout.Width+2*svgMargin, layout.Height+2*svgMargin+svgTitleHeight
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="sans-serif" font-size="%s">`+"\n",
+		svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height), svgNumber(svgFontSize))
+	out.WriteString(`  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z"/></marker></defs>` + "\n")
+	fmt.Fprintf(&out, `  <text class="title" x="%s" y="%s" font-size="16" font-weight="bold">%s</text>`+"\n",
+		svgNumber(svgMargin), svgNumber(svgMargin+16), xmlEscape(opts.title(sm)))
+
+	// Orthogonal regions, composite states first so that their contents
+	// are drawn on top
+	writeSVGRegions(&out, sm.Regions, layout, box)
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil && len(state.Regions) > 0 {
+				writeSVGState(&out, state, box(layout.Vertices[state.ID]))
+				writeSVGRegions(&out, state.Regions, layout, box)
+			}
+		}
+	})
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil && len(state.Regions) == 0 {
+				writeSVGState(&out, state, box(layout.Vertices[state.ID]))
+			}
+		}
+		for _, vertex := range region.Vertices {
+			if vertex != nil {
+				writeSVGVertex(&out, vertex, box(layout.Vertices[vertex.ID]))
+			}
+		}
+	})
+	walkTransitions(sm.Regions, func(transition *Transition) {
+		if transition.Source == nil || transition.Target == nil {
+			return
+		}
+		source, sourceExists := layout.Vertices[transition.Source.ID]
+		target, targetExists := layout.Vertices[transition.Target.ID]
+		if sourceExists && targetExists {
+			writeSVGTransition(&out, transition, box(source), box(target))
+		}
+	})
+	out.WriteString("</svg>\n")
+
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// writeSVGRegions draws sibling regions as dashed boxes if there are several
+func writeSVGRegions(out *strings.Builder, regions []*Region, layout *Layout, box func(Box) Box) {
+	regions = nonNilRegions(regions)
+	if len(regions) < 2 {
+		return
+	}
+	for _, region := range regions {
+		b := box(layout.Regions[region.ID])
+		fmt.Fprintf(out, `  <g class="region" data-id="%s"><rect x="%s" y="%s" width="%s" height="%s" fill="none" stroke="#888" stroke-dasharray="6,4"/>`,
+			xmlEscape(region.ID), svgNumber(b.X), svgNumber(b.Y), svgNumber(b.Width), svgNumber(b.Height))
+		if region.Name != "" {
+			fmt.Fprintf(out, `<text x="%s" y="%s" fill="#888">%s</text>`, svgNumber(b.X+4), svgNumber(b.Y+svgFontSize+2), xmlEscape(region.Name))
+		}
+		out.WriteString("</g>\n")
+	}
+}
+
+// writeSVGState draws a state as a rounded box with its name, centered for
+// simple states and in a header for composite states
+func writeSVGState(out *strings.Builder, state *State, b Box) {
+	fmt.Fprintf(out, `  <g class="state" data-id="%s"><rect x="%s" y="%s" width="%s" height="%s" rx="10" ry="10" fill="#fefece" stroke="#333"/>`,
+		xmlEscape(state.ID), svgNumber(b.X), svgNumber(b.Y), svgNumber(b.Width), svgNumber(b.Height))
+	name := xmlEscape(displayName(state.Name, state.ID))
+	decoration := ""
+	if state.Deprecated {
+		decoration = ` text-decoration="line-through"`
+	}
+	cx, cy := b.Center()
+	if len(state.Regions) > 0 {
+		fmt.Fprintf(out, `<text x="%s" y="%s" text-anchor="middle" font-weight="bold"%s>%s</text>`, svgNumber(cx), svgNumber(b.Y+layoutHeaderHeight/2+svgFontSize/3), decoration, name)
+		fmt.Fprintf(out, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#333"/>`, svgNumber(b.X), svgNumber(b.Y+layoutHeaderHeight), svgNumber(b.X+b.Width), svgNumber(b.Y+layoutHeaderHeight))
+	} else {
+		fmt.Fprintf(out, `<text x="%s" y="%s" text-anchor="middle"%s>%s</text>`, svgNumber(cx), svgNumber(cy+svgFontSize/3), decoration, name)
+	}
+	out.WriteString("</g>\n")
+}
+
+// writeSVGVertex draws a pseudostate or final state in its UML shape
+func writeSVGVertex(out *strings.Builder, vertex *Vertex, b Box) {
+	cx, cy := b.Center()
+	r := min(b.Width, b.Height) / 2
+	circle := func(radius float64, fill string) string {
+		return fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="%s" stroke="#333"/>`, svgNumber(cx), svgNumber(cy), svgNumber(radius), fill)
+	}
+	label := func(text string) string {
+		return fmt.Sprintf(`<text x="%s" y="%s" text-anchor="middle" font-size="10">%s</text>`, svgNumber(cx), svgNumber(cy+3.5), text)
+	}
+	cross := func(size float64) string {
+		return fmt.Sprintf(`<path d="M%s,%s L%s,%s M%s,%s L%s,%s" stroke="#333" stroke-width="2"/>`,
+			svgNumber(cx-size), svgNumber(cy-size), svgNumber(cx+size), svgNumber(cy+size),
+			svgNumber(cx-size), svgNumber(cy+size), svgNumber(cx+size), svgNumber(cy-size))
+	}
+
+	kind := pseudostateKindOf(vertex)
+	class := string(kind)
+	var shape string
+	switch {
+	case vertex.Type == "finalstate":
+		class = "final"
+		shape = circle(r, "white") + circle(r*0.6, "#333")
+	case kind == PseudostateKindInitial:
+		shape = circle(r*0.6, "#333")
+	case kind == PseudostateKindChoice:
+		shape = fmt.Sprintf(`<polygon points="%s,%s %s,%s %s,%s %s,%s" fill="white" stroke="#333"/>`,
+			svgNumber(cx), svgNumber(b.Y), svgNumber(b.X+b.Width), svgNumber(cy),
+			svgNumber(cx), svgNumber(b.Y+b.Height), svgNumber(b.X), svgNumber(cy))
+	case kind == PseudostateKindJunction:
+		shape = circle(r*0.4, "#333")
+	case kind == PseudostateKindFork, kind == PseudostateKindJoin:
+		shape = fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="#333"/>`,
+			svgNumber(b.X), svgNumber(cy-3), svgNumber(b.Width), svgNumber(6))
+	case kind == PseudostateKindShallowHistory:
+		shape = circle(r, "white") + label("H")
+	case kind == PseudostateKindDeepHistory:
+		shape = circle(r, "white") + label("H*")
+	case kind == PseudostateKindEntryPoint:
+		shape = circle(r*0.6, "white")
+	case kind == PseudostateKindExitPoint:
+		shape = circle(r*0.6, "white") + cross(r*0.4)
+	case kind == PseudostateKindTerminate:
+		shape = cross(r * 0.6)
+	default:
+		class = "pseudostate"
+		shape = circle(r*0.6, "white")
+	}
+	fmt.Fprintf(out, `  <g class="vertex %s" data-id="%s">%s</g>`+"\n", class, xmlEscape(vertex.ID), shape)
+}
+
+// writeSVGTransition draws a transition as an arrow between the borders of
+// its source and target, or as a loop on the right of a state it leaves
+// and enters again, labeled at its middle
+func writeSVGTransition(out *strings.Builder, transition *Transition, source, target Box) {
+	fmt.Fprintf(out, `  <g class="transition" data-id="%s">`, xmlEscape(transition.ID))
+	var labelX, labelY float64
+	if source == target {
+		x, y := source.X+source.Width, source.Y+source.Height/2
+		fmt.Fprintf(out, `<path d="M%s,%s C%s,%s %s,%s %s,%s" fill="none" stroke="#333" marker-end="url(#arrow)"/>`,
+			svgNumber(x), svgNumber(y-10), svgNumber(x+40), svgNumber(y-30), svgNumber(x+40), svgNumber(y+30), svgNumber(x), svgNumber(y+10))
+		labelX, labelY = x+34, y+4
+	} else {
+		sx, sy := target.Center()
+		tx, ty := source.Center()
+		x1, y1 := boxBorderPoint(source, sx, sy)
+		x2, y2 := boxBorderPoint(target, tx, ty)
+		fmt.Fprintf(out, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#333" marker-end="url(#arrow)"/>`,
+			svgNumber(x1), svgNumber(y1), svgNumber(x2), svgNumber(y2))
+		labelX, labelY = (x1+x2)/2+4, (y1+y2)/2-4
+	}
+	if label := transitionLabel(transition); label != "" {
+		decoration := ""
+		if transition.Deprecated {
+			decoration = ` text-decoration="line-through"`
+		}
+		fmt.Fprintf(out, `<text x="%s" y="%s" font-size="11" fill="#333"%s>%s</text>`, svgNumber(labelX), svgNumber(labelY), decoration, xmlEscape(label))
+	}
+	out.WriteString("</g>\n")
+}
+
+// boxBorderPoint returns where the line from the center of a box towards
+// the point (x, y) leaves the box
+func boxBorderPoint(b Box, x, y float64) (float64, float64) {
+	cx, cy := b.Center()
+	dx, dy := x-cx, y-cy
+	if dx == 0 && dy == 0 {
+		return cx, cy
+	}
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Min(scale, b.Width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		scale = math.Min(scale, b.Height/2/math.Abs(dy))
+	}
+	return cx + dx*scale, cy + dy*scale
+}
+
+// svgNumber formats a coordinate with at most one decimal
+func svgNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
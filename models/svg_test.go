@@ -0,0 +1,163 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSVGExporter(t *testing.T) {
+	sm := newPlayerMachine()
+	sm.Name = `Media <Player>`
+	sm.Regions[0].Vertices = append(sm.Regions[0].Vertices,
+		&Vertex{ID: "pick", Name: "choice", Type: "pseudostate"},
+		&Vertex{ID: "resume", Name: "history", Type: "pseudostate"},
+		&Vertex{ID: "done", Name: "Done", Type: "finalstate"},
+	)
+	sm.Regions[0].States[0].Deprecated = true
+
+	var out bytes.Buffer
+	if err := Export(sm, "svg", &out, ExportOptions{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	image := out.String()
+
+	decoder := xml.NewDecoder(strings.NewReader(image))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("SVG export is not well-formed XML: %v\n%s", err, image)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "root", want: `<svg xmlns="http://www.w3.org/2000/svg"`},
+		{name: "escaped title", want: `font-weight="bold">Media &lt;Player&gt;</text>`},
+		{name: "arrow marker", want: `<marker id="arrow"`},
+		{name: "rounded state", want: `<g class="state" data-id="loading"><rect `},
+		{name: "state corners", want: `rx="10" ry="10"`},
+		{name: "composite header", want: `font-weight="bold">playing</text><line `},
+		{name: "orthogonal region", want: `<g class="region" data-id="audio"><rect `},
+		{name: "initial pseudostate", want: `<g class="vertex initial" data-id="initial"><circle `},
+		{name: "choice diamond", want: `<g class="vertex choice" data-id="pick"><polygon `},
+		{name: "history icon", want: `>H</text></g>`},
+		{name: "final state", want: `<g class="vertex final" data-id="done"><circle `},
+		{name: "transition arrow", want: `marker-end="url(#arrow)"`},
+		{name: "transition label", want: `>play</text></g>`},
+		{name: "deprecated state", want: `text-decoration="line-through">idle</text>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(image, tt.want) {
+				t.Errorf("SVG export missing %q in\n%s", tt.want, image)
+			}
+		})
+	}
+	if strings.Contains(image, `class="region" data-id="main"`) {
+		t.Error("a single region should not be drawn as a box")
+	}
+}
+
+func TestSVGExporter_Layout(t *testing.T) {
+	export := func(sm *StateMachine, opts ExportOptions) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := Export(sm, "svg", &out, opts); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		return out.String()
+	}
+
+	tests := []struct {
+		name    string
+		layout  func(sm *StateMachine)
+		opts    ExportOptions
+		want    string
+		notWant string
+	}{
+		{
+			name: "stored layout",
+			layout: func(sm *StateMachine) {
+				layout, _ := LayeredLayout{}.Layout(sm)
+				layout.Vertices["idle"] = Box{X: 500, Y: 600, Width: 100, Height: 40}
+				sm.Layout = layout
+			},
+			want: `<g class="state" data-id="idle"><rect x="520" y="650" width="100" height="40"`,
+		},
+		{
+			name: "incomplete stored layout is recomputed",
+			layout: func(sm *StateMachine) {
+				sm.Layout = &Layout{Vertices: map[string]Box{"idle": {X: 500, Y: 600, Width: 100, Height: 40}}}
+			},
+			want:    `<g class="state" data-id="idle"><rect x="`,
+			notWant: `x="520" y="650"`,
+		},
+		{
+			name: "left to right",
+			opts: ExportOptions{Properties: map[string]string{"direction": "lr"}},
+			want: `<g class="state" data-id="idle"><rect x="`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newPlayerMachine()
+			if tt.layout != nil {
+				tt.layout(sm)
+			}
+			image := export(sm, tt.opts)
+			if !strings.Contains(image, tt.want) {
+				t.Errorf("SVG export missing %q in\n%s", tt.want, image)
+			}
+			if tt.notWant != "" && strings.Contains(image, tt.notWant) {
+				t.Errorf("SVG export should not contain %q", tt.notWant)
+			}
+		})
+	}
+
+	// Left to right diagrams are wider than they are tall
+	var root struct {
+		Width  float64 `xml:"width,attr"`
+		Height float64 `xml:"height,attr"`
+	}
+	sm := newPlayerMachine()
+	sm.Regions[0].States = sm.Regions[0].States[:1]
+	for i, direction := range []string{"TB", "LR"} {
+		if err := xml.Unmarshal([]byte(export(sm, ExportOptions{Properties: map[string]string{"direction": direction}})), &root); err != nil {
+			t.Fatalf("xml.Unmarshal() error = %v", err)
+		}
+		if wide := root.Width > root.Height; wide != (i == 1) {
+			t.Errorf("%s diagram is %vx%v", direction, root.Width, root.Height)
+		}
+	}
+
+	if err := Export(newPlayerMachine(), "svg", io.Discard, ExportOptions{Properties: map[string]string{"direction": "diagonal"}}); err == nil {
+		t.Error("Export() should reject an unknown direction")
+	}
+}
+
+func TestBoxBorderPoint(t *testing.T) {
+	box := Box{X: 0, Y: 0, Width: 100, Height: 50}
+	tests := []struct {
+		name         string
+		x, y         float64
+		wantX, wantY float64
+	}{
+		{name: "right", x: 300, y: 25, wantX: 100, wantY: 25},
+		{name: "below", x: 50, y: 200, wantX: 50, wantY: 50},
+		{name: "corner", x: 150, y: 75, wantX: 100, wantY: 50},
+		{name: "center", x: 50, y: 25, wantX: 50, wantY: 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if x, y := boxBorderPoint(box, tt.x, tt.y); x != tt.wantX || y != tt.wantY {
+				t.Errorf("boxBorderPoint() = %v, %v, want %v, %v", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
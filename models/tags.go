@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// View projects the state machine onto the elements carrying any of the
+// given tags, for example only the "error handling" states and the
+// transitions among them, and returns the projection as a read-only
+// FrozenStateMachine ready for export. The view keeps the tagged vertices,
+// the tagged transitions with their source and target, the transitions
+// between kept vertices and the states enclosing kept vertices; regions
+// without kept vertices and events no kept trigger references are left
+// out. Tags match case-insensitively and ignore stereotype brackets, so
+// "<<Error Handling>>" matches "error handling".
+//
+// Unlike Freeze, View does not validate the projection: a view shows part
+// of a model and usually lacks initial pseudostates. The original state
+// machine is not modified.
+func View(sm *StateMachine, tags ...string) (*FrozenStateMachine, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	selected := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag := normalizeTag(tag); tag != "" {
+			selected[tag] = true
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("a view needs at least one tag")
+	}
+
+	index := newSliceIndex(cloneWithMetadata(sm))
+	kept := sliceSelection{vertices: make(map[string]bool), transitions: make(map[string]bool)}
+	for id, vertex := range index.vertices {
+		if hasTag(vertex.Tags, selected) {
+			kept.vertices[id] = true
+		}
+	}
+	walkTransitions(index.sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil && hasTag(transition.Tags, selected) {
+			kept.transitions[transition.ID] = true
+			kept.vertices[transition.Source.ID] = true
+			kept.vertices[transition.Target.ID] = true
+		}
+	})
+	if len(kept.vertices) == 0 {
+		return nil, fmt.Errorf("no element of state machine '%s' is tagged %s", sm.ID, sliceDescribe(tags))
+	}
+	walkTransitions(index.sm.Regions, func(transition *Transition) {
+		if transition.Source != nil && transition.Target != nil && kept.vertices[transition.Source.ID] && kept.vertices[transition.Target.ID] {
+			kept.transitions[transition.ID] = true
+		}
+	})
+
+	index.enclose(kept)
+	return &FrozenStateMachine{sm: index.project(kept)}, nil
+}
+
+// hasTag reports whether any of the tags is selected
+func hasTag(tags []string, selected map[string]bool) bool {
+	for _, tag := range tags {
+		if selected[normalizeTag(tag)] {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTag returns the form tags are compared in: lower case, without
+// surrounding spaces or stereotype brackets
+func normalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	for _, brackets := range [][2]string{{"<<", ">>"}, {"«", "»"}} {
+		if strings.HasPrefix(tag, brackets[0]) && strings.HasSuffix(tag, brackets[1]) && len(tag) >= len(brackets[0])+len(brackets[1]) {
+			tag = strings.TrimSpace(tag[len(brackets[0]) : len(tag)-len(brackets[1])])
+		}
+	}
+	return strings.ToLower(tag)
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTaggedPlayerMachine returns the player machine with its audio states
+// tagged as media and its stop transition tagged as control
+func newTaggedPlayerMachine() *StateMachine {
+	sm := newPlayerMachine()
+	for state := range sm.AllStates() {
+		if state.ID == "loading" || state.ID == "streaming" {
+			state.Tags = []string{"<<Media>>"}
+		}
+	}
+	for transition := range sm.AllTransitions() {
+		if transition.ID == "stop" {
+			transition.Tags = []string{"control"}
+		}
+	}
+	return sm
+}
+
+func TestView(t *testing.T) {
+	tests := []struct {
+		name            string
+		tags            []string
+		wantVertices    []string
+		wantTransitions []string
+	}{
+		{
+			name:            "tagged states and the transitions among them",
+			tags:            []string{"media"},
+			wantVertices:    []string{"loading", "playing", "streaming"},
+			wantTransitions: []string{"audio-loaded", "audio-tick"},
+		},
+		{
+			name:            "tagged transition keeps its endpoints",
+			tags:            []string{"Control"},
+			wantVertices:    []string{"idle", "playing"},
+			wantTransitions: []string{"play", "stop"},
+		},
+		{
+			name:            "any of several tags",
+			tags:            []string{" MEDIA ", "«control»"},
+			wantVertices:    []string{"idle", "loading", "playing", "streaming"},
+			wantTransitions: []string{"audio-loaded", "audio-tick", "play", "stop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTaggedPlayerMachine()
+			originalVertices, originalTransitions := sliceContents(sm)
+			view, err := View(sm, tt.tags...)
+			if err != nil {
+				t.Fatalf("View() error = %v", err)
+			}
+			vertices, transitions := sliceContents(view.Thaw())
+			if !slices.Equal(vertices, tt.wantVertices) {
+				t.Errorf("vertices = %v, want %v", vertices, tt.wantVertices)
+			}
+			if !slices.Equal(transitions, tt.wantTransitions) {
+				t.Errorf("transitions = %v, want %v", transitions, tt.wantTransitions)
+			}
+			if vertices, transitions := sliceContents(sm); !slices.Equal(vertices, originalVertices) || !slices.Equal(transitions, originalTransitions) {
+				t.Error("View() modified the original state machine")
+			}
+		})
+	}
+}
+
+func TestView_Projection(t *testing.T) {
+	sm := newTaggedPlayerMachine()
+	sm.Metadata = map[string]interface{}{"owner": map[string]interface{}{"team": "media"}}
+	view, err := View(sm, "media")
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	playing := view.Regions()[0].States()[0]
+	if playing.ID() != "playing" || len(playing.Regions()) != 1 || playing.IsOrthogonal() {
+		t.Errorf("playing should keep only the audio region, got %v with %d regions", playing, len(playing.Regions()))
+	}
+	if tags := playing.Regions()[0].States()[0].Tags(); !slices.Equal(tags, []string{"<<Media>>"}) {
+		t.Errorf("Tags() = %v", tags)
+	}
+
+	sm.Metadata["owner"].(map[string]interface{})["team"] = "video"
+	if owner, _ := view.Metadata("owner"); owner.(map[string]interface{})["team"] != "media" {
+		t.Error("View() shares metadata with the original state machine")
+	}
+
+	var out bytes.Buffer
+	if err := view.Export("plantuml", &out, ExportOptions{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "loading --> streaming : loaded") || strings.Contains(out.String(), "buffering") {
+		t.Errorf("Export() should draw only the view:\n%s", out.String())
+	}
+}
+
+func TestView_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		sm   *StateMachine
+		tags []string
+		want string
+	}{
+		{"nil machine", nil, []string{"media"}, "state machine cannot be nil"},
+		{"no tags", newTaggedPlayerMachine(), nil, "a view needs at least one tag"},
+		{"blank tags", newTaggedPlayerMachine(), []string{" ", "<<>>"}, "a view needs at least one tag"},
+		{"unused tag", newTaggedPlayerMachine(), []string{"network"}, "no element of state machine 'player' is tagged 'network'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view, err := View(tt.sm, tt.tags...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || view != nil {
+				t.Errorf("View() = %v, %v, want error %q", view, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// templatePlaceholder matches placeholders of the form ${name}
+var templatePlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// TemplatePlaceholders returns the sorted, de-duplicated names of the
+// placeholders used in a template state machine
+func TemplatePlaceholders(template *StateMachine) []string {
+	if template == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	walkTemplateStrings(template.Clone(), func(s string) string {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			seen[match[1]] = true
+		}
+		return s
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Instantiate creates a state machine from a template by substituting
+// placeholders such as ${entityName} with values from params. Placeholders
+// are replaced in IDs, names, the version, behavior and guard
+// specifications, event references and properties, entity mappings and
+// string metadata values. Because every copy of an ID is substituted the
+// same way, IDs built from placeholders are remapped consistently across
+// the model. Substituted values are not expanded again. The template is not
+// modified. Instantiate fails if a placeholder has no value or if the
+// resulting state machine does not validate.
+func Instantiate(template *StateMachine, params map[string]string) (*StateMachine, error) {
+	if template == nil {
+		return nil, fmt.Errorf("template cannot be nil")
+	}
+
+	var missing []string
+	instance := template.Clone()
+	walkTemplateStrings(instance, func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := placeholder[2 : len(placeholder)-1]
+			value, ok := params[name]
+			if !ok {
+				if !slices.Contains(missing, name) {
+					missing = append(missing, name)
+				}
+				return placeholder
+			}
+			return value
+		})
+	})
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("missing template parameters: %s", strings.Join(missing, ", "))
+	}
+	if err := instance.Validate(); err != nil {
+		return nil, fmt.Errorf("instantiated state machine is invalid: %w", err)
+	}
+	return instance, nil
+}
+
+// walkTemplateStrings replaces every templatable string in sm with the result
+// of fn. Each string field is visited once even when its owner is shared.
+func walkTemplateStrings(sm *StateMachine, fn func(string) string) {
+	visited := make(map[*string]bool)
+	visit := func(s *string) {
+		if s == nil || visited[s] {
+			return
+		}
+		visited[s] = true
+		*s = fn(*s)
+	}
+
+	visit(&sm.Version)
+	walkElements(sm, func(_ string, id, name *string) {
+		visit(id)
+		visit(name)
+	})
+
+	specifications := func(b *Behavior) {
+		if b != nil {
+			visit(&b.Specification)
+		}
+	}
+	visitedEvents := make(map[*Event]bool)
+	events := func(e *Event) {
+		if e == nil || visitedEvents[e] {
+			return
+		}
+		visitedEvents[e] = true
+		for key, value := range e.Properties {
+			e.Properties[key] = fn(value)
+		}
+	}
+	for _, event := range sm.Events {
+		events(event)
+	}
+
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		for _, state := range region.States {
+			if state != nil {
+				specifications(state.Entry)
+				specifications(state.Exit)
+				specifications(state.DoActivity)
+			}
+		}
+		for _, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			specifications(transition.Effect)
+			if transition.Guard != nil {
+				visit(&transition.Guard.Specification)
+			}
+			for _, trigger := range transition.Triggers {
+				if trigger != nil {
+					visit(&trigger.EventID)
+					events(trigger.Event)
+				}
+			}
+		}
+	})
+
+	if sm.Entities != nil {
+		entities := make(map[string]string, len(sm.Entities))
+		for key, value := range sm.Entities {
+			entities[fn(key)] = fn(value)
+		}
+		sm.Entities = entities
+	}
+	for key, value := range sm.Metadata {
+		sm.Metadata[key] = substituteMetadataValue(value, fn)
+	}
+}
+
+// substituteMetadataValue applies fn to strings nested in a metadata value,
+// copying maps and slices so that the template's values are not modified
+func substituteMetadataValue(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = substituteMetadataValue(item, fn)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substituteMetadataValue(item, fn)
+		}
+		return out
+	default:
+		return value
+	}
+}
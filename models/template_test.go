@@ -0,0 +1,82 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// createLifecycleTemplate creates a per-entity lifecycle blueprint
+func createLifecycleTemplate() *StateMachine {
+	created := &State{Vertex: Vertex{ID: "${entity}_created", Name: "${Entity} Created", Type: "state"}, IsSimple: true,
+		Entry: &Behavior{ID: "${entity}_on_create", Name: "Announce ${Entity}", Specification: "notify('${entity}')"}}
+	archived := &State{Vertex: Vertex{ID: "${entity}_archived", Name: "${Entity} Archived", Type: "state"}, IsSimple: true}
+	initial := &Vertex{ID: "${entity}_initial", Name: "Initial", Type: "pseudostate"}
+
+	return &StateMachine{
+		ID:      "${entity}_lifecycle",
+		Name:    "${Entity} Lifecycle",
+		Version: "1.0",
+		Events:  []*Event{{ID: "${entity}_archive", Name: "Archive ${Entity}", Type: EventTypeSignal}},
+		Regions: []*Region{{
+			ID:       "${entity}_main",
+			Name:     "Main",
+			States:   []*State{created, archived},
+			Vertices: []*Vertex{initial},
+			Transitions: []*Transition{
+				{ID: "${entity}_t0", Source: initial, Target: &created.Vertex, Kind: TransitionKindExternal},
+				{ID: "${entity}_t1", Source: &created.Vertex, Target: &archived.Vertex, Kind: TransitionKindExternal,
+					Triggers: []*Trigger{{ID: "${entity}_archive_trigger", Name: "Archive", EventID: "${entity}_archive"}}},
+			},
+		}},
+		Metadata: map[string]interface{}{"table": "${entity}s", "tags": []interface{}{"${entity}", 42}},
+	}
+}
+
+func TestInstantiate(t *testing.T) {
+	template := createLifecycleTemplate()
+
+	if got := TemplatePlaceholders(template); !slices.Equal(got, []string{"Entity", "entity"}) {
+		t.Errorf("TemplatePlaceholders() = %v, want [Entity entity]", got)
+	}
+
+	sm, err := Instantiate(template, map[string]string{"entity": "order", "Entity": "Order"})
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+
+	if sm.ID != "order_lifecycle" || sm.Name != "Order Lifecycle" {
+		t.Errorf("machine = %s", sm)
+	}
+	region := sm.Regions[0]
+	if region.States[0].ID != "order_created" || region.States[0].Entry.Specification != "notify('order')" {
+		t.Errorf("state = %s, entry = %v", region.States[0], region.States[0].Entry)
+	}
+	transition := region.Transitions[1]
+	if transition.Source.ID != "order_created" || transition.Triggers[0].EventID != "order_archive" {
+		t.Errorf("transition references were not remapped: %s", transition)
+	}
+	if sm.Metadata["table"] != "orders" || sm.Metadata["tags"].([]interface{})[0] != "order" {
+		t.Errorf("metadata = %v", sm.Metadata)
+	}
+
+	if template.ID != "${entity}_lifecycle" || template.Metadata["tags"].([]interface{})[0] != "${entity}" {
+		t.Error("Instantiate() must not modify the template")
+	}
+
+	t.Run("missing parameters", func(t *testing.T) {
+		_, err := Instantiate(template, map[string]string{"entity": "order"})
+		if err == nil || !strings.Contains(err.Error(), "missing template parameters: Entity") {
+			t.Errorf("Instantiate() error = %v", err)
+		}
+	})
+
+	t.Run("invalid result", func(t *testing.T) {
+		versioned := createLifecycleTemplate()
+		versioned.Version = "${version}"
+		_, err := Instantiate(versioned, map[string]string{"entity": "order", "Entity": "Order", "version": ""})
+		if err == nil || !strings.Contains(err.Error(), "instantiated state machine is invalid") {
+			t.Errorf("Instantiate() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// TerminateImpact describes what reaching a terminate pseudostate ends.
+// Reaching a terminate pseudostate ends the execution of the whole state
+// machine, so a terminate pseudostate in a submachine also ends every machine
+// that includes the submachine, directly or through other submachines.
+type TerminateImpact struct {
+	Machine   string   `json:"machine"`           // ID of the machine declaring the terminate pseudostate
+	Terminate string   `json:"terminate"`         // ID of the terminate pseudostate
+	Parents   []string `json:"parents,omitempty"` // IDs of the machines whose submachine states reference Machine, sorted
+	Affected  []string `json:"affected"`          // IDs of Machine and every machine including it, sorted
+	Allowed   bool     `json:"allowed,omitempty"` // Machine sets AllowSharedTerminate
+}
+
+// Shared reports whether the machine declaring the terminate pseudostate is
+// the submachine of states in more than one machine
+func (i TerminateImpact) Shared() bool {
+	return len(i.Parents) > 1
+}
+
+// String formats the impact as "terminate 'kill' in 'sub' ends a, b and sub"
+func (i TerminateImpact) String() string {
+	return fmt.Sprintf("terminate '%s' in '%s' ends %s", i.Terminate, i.Machine, joinWords(i.Affected, "and"))
+}
+
+// TerminateImpacts returns the impact of every terminate pseudostate of the
+// given state machines and the submachines reachable from them, machine by
+// machine in the order they are found and in model order within a machine.
+// Machines are identified by ID as in SubmachineCycles.
+func TerminateImpacts(machines ...*StateMachine) []TerminateImpact {
+	byID, order, graph := submachineGraph(machines...)
+	parents := make(map[string]map[string]bool) // Parent machine IDs by submachine ID
+	for _, machineID := range order {
+		for _, link := range graph[machineID] {
+			if parents[link.machineID] == nil {
+				parents[link.machineID] = make(map[string]bool)
+			}
+			parents[link.machineID][machineID] = true
+		}
+	}
+
+	var impacts []TerminateImpact
+	for _, machineID := range order {
+		sm := byID[machineID]
+		walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+			for _, vertex := range region.Vertices {
+				if pseudostateKindOf(vertex) != PseudostateKindTerminate {
+					continue
+				}
+				affected := map[string]bool{machineID: true}
+				queue := []string{machineID}
+				for len(queue) > 0 {
+					for parent := range parents[queue[0]] {
+						if !affected[parent] {
+							affected[parent] = true
+							queue = append(queue, parent)
+						}
+					}
+					queue = queue[1:]
+				}
+				impacts = append(impacts, TerminateImpact{
+					Machine:   machineID,
+					Terminate: vertex.ID,
+					Parents:   slices.Sorted(maps.Keys(parents[machineID])),
+					Affected:  slices.Sorted(maps.Keys(affected)),
+					Allowed:   sm.AllowSharedTerminate,
+				})
+			}
+		})
+	}
+	return impacts
+}
+
+// validateSharedTerminates reports terminate pseudostates in submachines
+// shared by more than one machine: reaching one from any of them ends every
+// machine including the submachine. Machines opt in with
+// AllowSharedTerminate. Like validateSubmachineRecursion, only the machine at
+// the root of the validation reports them.
+func (sm *StateMachine) validateSharedTerminates(context *ValidationContext, errors *ValidationErrors) {
+	if len(context.machineChain) > 1 {
+		return
+	}
+
+	for _, impact := range TerminateImpacts(sm) {
+		if !impact.Shared() || impact.Allowed {
+			continue
+		}
+		errors.AddError(
+			ErrorTypeConstraint,
+			"StateMachine",
+			"Submachine",
+			fmt.Sprintf("terminate pseudostate '%s' of submachine '%s' is shared by state machines %s, so reaching it ends %s; set AllowSharedTerminate on '%s' to allow it",
+				impact.Terminate, impact.Machine, joinWords(impact.Parents, "and"), joinWords(impact.Affected, "and"), impact.Machine),
+			context.Path,
+		)
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTerminateFixture returns a machine with a terminate pseudostate reached
+// from its first state
+func newTerminateFixture(id string) *StateMachine {
+	sm := newSubmachineFixture(id)
+	region := sm.Regions[0]
+	kill := &Vertex{ID: id + "-kill", Name: "terminate", Type: "pseudostate"}
+	region.Vertices = append(region.Vertices, kill)
+	region.Transitions = append(region.Transitions, &Transition{
+		ID: id + "-abort", Source: &region.States[0].Vertex, Target: kill, Kind: TransitionKindExternal,
+		Triggers: []*Trigger{{ID: id + "-abort-trigger", Name: "abort", EventID: "abort"}},
+	})
+	return sm
+}
+
+// newSharedTerminateFixture returns Root including A and B, which both
+// include Sub, which has a terminate pseudostate
+func newSharedTerminateFixture() (root, sub *StateMachine) {
+	root, a, b := newSubmachineFixture("Root"), newSubmachineFixture("A"), newSubmachineFixture("B")
+	sub = newTerminateFixture("Sub")
+	includeSubmachine(root, a)
+	first := root.Regions[0].States[0]
+	first.IsSimple, first.IsSubmachineState, first.Submachine = false, true, b
+	includeSubmachine(a, sub)
+	includeSubmachine(b, sub)
+	return root, sub
+}
+
+func TestTerminateImpacts(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() []*StateMachine
+		want  []TerminateImpact
+	}{
+		{
+			name:  "no terminate pseudostates",
+			build: func() []*StateMachine { return []*StateMachine{newSubmachineFixture("A")} },
+		},
+		{
+			name:  "terminate in a standalone machine",
+			build: func() []*StateMachine { return []*StateMachine{newTerminateFixture("A")} },
+			want:  []TerminateImpact{{Machine: "A", Terminate: "A-kill", Affected: []string{"A"}}},
+		},
+		{
+			name: "terminate in a submachine ends every including machine",
+			build: func() []*StateMachine {
+				root, a := newTerminateFixture("Root"), newSubmachineFixture("A")
+				includeSubmachine(root, a)
+				includeSubmachine(a, newTerminateFixture("Sub"))
+				return []*StateMachine{root}
+			},
+			want: []TerminateImpact{
+				{Machine: "Root", Terminate: "Root-kill", Affected: []string{"Root"}},
+				{Machine: "Sub", Terminate: "Sub-kill", Parents: []string{"A"}, Affected: []string{"A", "Root", "Sub"}},
+			},
+		},
+		{
+			name: "shared submachine",
+			build: func() []*StateMachine {
+				root, _ := newSharedTerminateFixture()
+				return []*StateMachine{root}
+			},
+			want: []TerminateImpact{{Machine: "Sub", Terminate: "Sub-kill", Parents: []string{"A", "B"}, Affected: []string{"A", "B", "Root", "Sub"}}},
+		},
+		{
+			name: "allowed shared submachine",
+			build: func() []*StateMachine {
+				root, sub := newSharedTerminateFixture()
+				sub.AllowSharedTerminate = true
+				return []*StateMachine{root}
+			},
+			want: []TerminateImpact{{Machine: "Sub", Terminate: "Sub-kill", Parents: []string{"A", "B"}, Affected: []string{"A", "B", "Root", "Sub"}, Allowed: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TerminateImpacts(tt.build()...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TerminateImpacts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminateImpact_String(t *testing.T) {
+	impact := TerminateImpact{Machine: "Sub", Terminate: "kill", Parents: []string{"A", "B"}, Affected: []string{"A", "B", "Sub"}}
+	if got, want := impact.String(), "terminate 'kill' in 'Sub' ends A, B and Sub"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !impact.Shared() {
+		t.Error("Shared() = false, want true")
+	}
+}
+
+func TestStateMachine_SharedTerminateValidation(t *testing.T) {
+	const message = "terminate pseudostate 'Sub-kill' of submachine 'Sub' is shared by state machines A and B, so reaching it ends A, B, Root and Sub"
+	tests := []struct {
+		name    string
+		allow   bool
+		wantErr bool
+	}{
+		{name: "shared terminate is rejected", wantErr: true},
+		{name: "shared terminate is allowed", allow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, sub := newSharedTerminateFixture()
+			sub.AllowSharedTerminate = tt.allow
+			err := root.Validate()
+			got := err != nil && strings.Contains(err.Error(), message)
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %v, want shared terminate error: %v", err, tt.wantErr)
+			}
+			if got && strings.Count(err.Error(), message) != 1 {
+				t.Errorf("shared terminate reported more than once: %v", err)
+			}
+		})
+	}
+}
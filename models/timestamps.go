@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimestampPolicy configures the sanity checks of a state machine's
+// CreatedAt timestamp, for pipelines that ingest machines from systems with
+// unreliable clocks
+type TimestampPolicy struct {
+	RequireCreatedAt bool             // Report a zero CreatedAt
+	MaxFutureSkew    time.Duration    // How far CreatedAt may lie in the future; zero allows none and a negative value disables the check
+	Now              func() time.Time // Clock the future check compares against; nil uses time.Now
+}
+
+// DefaultTimestampPolicy requires CreatedAt and tolerates five minutes of
+// clock skew
+var DefaultTimestampPolicy = &TimestampPolicy{
+	RequireCreatedAt: true,
+	MaxFutureSkew:    5 * time.Minute,
+}
+
+// now returns the current time of the policy's clock
+func (p *TimestampPolicy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// validateTimestamps checks CreatedAt against the timestamp policy of the
+// context's profile
+func (sm *StateMachine) validateTimestamps(context *ValidationContext, errors *ValidationErrors) {
+	policy := context.ActiveProfile().Timestamps
+	if policy == nil {
+		return
+	}
+
+	if sm.CreatedAt.IsZero() {
+		if policy.RequireCreatedAt {
+			errors.AddError(
+				ErrorTypeRequired,
+				"StateMachine",
+				"CreatedAt",
+				"CreatedAt is required and cannot be the zero time",
+				context.Path,
+			)
+		}
+		return
+	}
+	if policy.MaxFutureSkew < 0 {
+		return
+	}
+	if limit := policy.now().Add(policy.MaxFutureSkew); sm.CreatedAt.After(limit) {
+		errors.AddError(
+			ErrorTypeConstraint,
+			"StateMachine",
+			"CreatedAt",
+			fmt.Sprintf("CreatedAt %s is in the future by more than the allowed %s", sm.CreatedAt.Format(time.RFC3339), policy.MaxFutureSkew),
+			context.Path,
+		)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTimestamps(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tests := []struct {
+		name      string
+		policy    *TimestampPolicy
+		createdAt time.Time
+		want      string
+	}{
+		{name: "no policy", policy: nil, createdAt: time.Time{}},
+		{name: "zero required", policy: &TimestampPolicy{RequireCreatedAt: true, Now: clock}, want: "CreatedAt is required and cannot be the zero time"},
+		{name: "zero allowed", policy: &TimestampPolicy{Now: clock}},
+		{name: "past", policy: &TimestampPolicy{RequireCreatedAt: true, Now: clock}, createdAt: now.Add(-48 * time.Hour)},
+		{name: "within skew", policy: &TimestampPolicy{MaxFutureSkew: time.Minute, Now: clock}, createdAt: now.Add(30 * time.Second)},
+		{
+			name:      "beyond skew",
+			policy:    &TimestampPolicy{MaxFutureSkew: time.Minute, Now: clock},
+			createdAt: now.Add(2 * time.Minute),
+			want:      "CreatedAt 2024-03-01T12:02:00Z is in the future by more than the allowed 1m0s",
+		},
+		{name: "no skew allowed", policy: &TimestampPolicy{Now: clock}, createdAt: now.Add(time.Second), want: "is in the future"},
+		{name: "future check disabled", policy: &TimestampPolicy{MaxFutureSkew: -1, Now: clock}, createdAt: now.AddDate(10, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			sm.CreatedAt = tt.createdAt
+			profile := &ValidationProfile{Name: "timestamps", Timestamps: tt.policy}
+			err := sm.ValidateInContext(NewValidationContext().WithProfile(profile))
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ValidateInContext() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateInContext() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictProfile_Timestamps(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.CreatedAt = time.Now().Add(time.Hour)
+	errors := &ValidationErrors{}
+	sm.ValidateWithErrors(NewValidationContext().WithProfile(StrictProfile), errors)
+
+	found := false
+	for _, err := range errors.Errors {
+		if err.Field == "CreatedAt" && err.Type == ErrorTypeConstraint {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("strict profile should reject a CreatedAt an hour ahead: %v", errors.ToError())
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 // TransitionKind represents the kind of transition
 type TransitionKind string
@@ -13,27 +16,47 @@ const (
 
 // IsValid checks if the TransitionKind is valid
 func (tk TransitionKind) IsValid() bool {
-	validKinds := map[TransitionKind]bool{
-		TransitionKindInternal: true,
-		TransitionKindLocal:    true,
-		TransitionKindExternal: true,
-	}
-	return validKinds[tk]
+	return TransitionKinds.IsValid(tk)
 }
 
 // Transition represents a transition between vertices in a state machine
 type Transition struct {
-	ID       string         `json:"id" validate:"required"`
-	Name     string         `json:"name,omitempty"`
-	Source   *Vertex        `json:"source" validate:"required"`
-	Target   *Vertex        `json:"target" validate:"required"`
-	Kind     TransitionKind `json:"kind" validate:"required"`
-	Triggers []*Trigger     `json:"triggers,omitempty"`
-	Guard    *Constraint    `json:"guard,omitempty"`
-	Effect   *Behavior      `json:"effect,omitempty"`
+	ID          string         `json:"id" validate:"required"`
+	Name        string         `json:"name,omitempty"`
+	Source      *Vertex        `json:"source" validate:"required"`
+	Target      *Vertex        `json:"target" validate:"required"`
+	Kind        TransitionKind `json:"kind" validate:"required"`
+	Triggers    []*Trigger     `json:"triggers,omitempty"`
+	Guard       *Constraint    `json:"guard,omitempty"`
+	Effect      *Behavior      `json:"effect,omitempty"`
+	Features    []string       `json:"features,omitempty"`    // Feature flags that must all be enabled for this transition to be included
+	Probability *float64       `json:"probability,omitempty"` // Optional likelihood among the transitions leaving the same vertex on the same triggers; see AnalyzeMarkov
+	Cost        *Cost          `json:"cost,omitempty"`        // Optional expected duration and cost of taking the transition; see AnalyzeLatency
+	Deprecated  bool           `json:"deprecated,omitempty"`  // The transition is being phased out; see ReplacedBy
+	ReplacedBy  string         `json:"replaced_by,omitempty"` // ID of the transition that replaces a deprecated transition
+	Entities    []string       `json:"entities,omitempty"`    // Names of the state machine's entities the transition uses
+	Tags        []string       `json:"tags,omitempty"`        // Labels or stereotypes grouping the transition with related elements; see View
+	// Suppressions lists accepted findings about the transition; see Suppression
+	Suppressions []Suppression `json:"suppressions,omitempty"`
 	// Container *Region       `json:"-"` // Parent region (not serialized)
 }
 
+// String returns a concise one-line description of the Transition
+func (t *Transition) String() string {
+	if t == nil {
+		return "Transition(<nil>)"
+	}
+	return fmt.Sprintf("Transition(id=%s, kind=%s, %s -> %s)", t.ID, t.Kind, vertexIDOrNil(t.Source), vertexIDOrNil(t.Target))
+}
+
+// vertexIDOrNil returns the vertex ID, or "<nil>" when the vertex is missing
+func vertexIDOrNil(v *Vertex) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return v.ID
+}
+
 // Validate validates the Transition data integrity
 func (t *Transition) Validate() error {
 	context := NewValidationContext()
@@ -57,11 +80,14 @@ func (t *Transition) ValidateWithErrors(context *ValidationContext, errors *Vali
 	if errors == nil {
 		return
 	}
+	defer context.timeElement("Transition", t.ID)()
 
 	helper := NewValidationHelper()
 
 	// Validate required fields
 	helper.ValidateRequired(t.ID, "ID", "Transition", context, errors)
+	helper.ValidateID(t.ID, "Transition", context, errors)
+	helper.ValidateName(t.Name, "Transition", context, errors)
 
 	// Validate required references
 	helper.ValidateReference(t.Source, "Source", "Transition", context, errors, true)
@@ -73,29 +99,29 @@ func (t *Transition) ValidateWithErrors(context *ValidationContext, errors *Vali
 			ErrorTypeInvalid,
 			"Transition",
 			"Kind",
-			fmt.Sprintf("invalid TransitionKind: %s", t.Kind),
+			TransitionKinds.InvalidValueMessage(t.Kind),
 			context.Path,
 		)
 	}
 
 	// Validate triggers collection
-	triggerValidators := make([]Validator, len(t.Triggers))
-	for i, trigger := range t.Triggers {
-		triggerValidators[i] = trigger
-	}
-	helper.ValidateCollection(triggerValidators, "Triggers", "Transition", context, errors)
+	ValidateSlice(t.Triggers, "Triggers", "Transition", context, errors)
 
 	// Validate optional references
 	helper.ValidateReference(t.Guard, "Guard", "Transition", context, errors, false)
 	helper.ValidateReference(t.Effect, "Effect", "Transition", context, errors, false)
 
 	// UML constraint validations
-	t.validateSourceTarget(context, errors)
-	t.validateKindConstraints(context, errors)
-	t.validateContainment(context, errors)
+	context.check("transition.endpoints", t.validateSourceTarget, errors)
+	context.check("transition.kind", t.validateKindConstraints, errors)
+	context.check("transition.containment", t.validateContainment, errors)
+	context.check("transition.trigger_placement", t.validateTriggerPlacement, errors)
+	context.check("transition.segment_guards", t.validateSegmentGuards, errors)
+	context.check("transition.probability", t.validateProbability, errors)
+	t.Cost.validate("Transition", context, errors)
 
 	// Structural integrity validation
-	t.validateStructuralIntegrity(context, errors)
+	context.check("transition.structural_integrity", t.validateStructuralIntegrity, errors)
 }
 
 // validateSourceTarget ensures source/target compatibility
@@ -166,12 +192,13 @@ func (t *Transition) validateContainment(context *ValidationContext, errors *Val
 func (t *Transition) validateSourceConstraints(source *Vertex, context *ValidationContext, errors *ValidationErrors) {
 	// Final states cannot have outgoing transitions
 	if source.Type == "finalstate" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Source",
 			"final state cannot be the source of a transition (UML constraint)",
 			context.Path,
+			ClauseFinalStateNoOutgoing,
 		)
 	}
 
@@ -190,12 +217,13 @@ func (t *Transition) validateTargetConstraints(target *Vertex, context *Validati
 
 	// Initial pseudostates cannot be targets of transitions (except from outside the region)
 	if target.Type == "pseudostate" && t.isInitialPseudostate(target) {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Target",
 			"initial pseudostate cannot be the target of a transition within the same region (UML constraint)",
 			context.Path,
+			ClauseInitialVertex,
 		)
 	}
 }
@@ -208,12 +236,13 @@ func (t *Transition) validateSourceTargetCompatibility(source, target *Vertex, c
 		if t.Kind == TransitionKindExternal {
 			// This is allowed but might indicate a design issue
 			// We'll issue a warning-level constraint error
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"Kind",
 				"self-transition with external kind may cause exit/entry actions to be executed (UML design consideration)",
 				context.Path,
+				ClauseTransition,
 			)
 		}
 	}
@@ -226,24 +255,26 @@ func (t *Transition) validateSourceTargetCompatibility(source, target *Vertex, c
 func (t *Transition) validateInternalTransitionConstraints(context *ValidationContext, errors *ValidationErrors) {
 	// Internal transitions must have the same source and target
 	if t.Source.ID != t.Target.ID {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Kind",
 			"internal transition must have the same source and target vertex (UML constraint)",
 			context.Path,
+			ClauseStateIsInternal,
 		)
 	}
 
 	// Internal transitions should not cause state exit/entry
 	// This is more of a semantic constraint that affects behavior
 	if t.Source.Type != "state" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Source",
 			"internal transition source should be a state (UML constraint)",
 			context.Path,
+			ClauseStateIsInternal,
 		)
 	}
 }
@@ -255,22 +286,24 @@ func (t *Transition) validateLocalTransitionConstraints(context *ValidationConte
 
 	// For now, we validate that both source and target are proper vertices
 	if t.Source.Type == "pseudostate" && t.isConnectionPoint(t.Source) {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Source",
 			"local transition should not originate from connection points (UML constraint)",
 			context.Path,
+			ClauseStateIsLocal,
 		)
 	}
 
 	if t.Target.Type == "pseudostate" && t.isConnectionPoint(t.Target) {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Target",
 			"local transition should not target connection points (UML constraint)",
 			context.Path,
+			ClauseStateIsLocal,
 		)
 	}
 }
@@ -284,12 +317,13 @@ func (t *Transition) validateExternalTransitionConstraints(context *ValidationCo
 	if t.Source.ID == t.Target.ID && t.Source.Type == "state" {
 		// This is allowed but might indicate a design issue
 		// We'll issue a warning-level constraint error
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Kind",
 			"self-transition with external kind may cause exit/entry actions to be executed (UML design consideration)",
 			context.Path,
+			ClauseTransition,
 		)
 	}
 
@@ -303,12 +337,13 @@ func (t *Transition) validatePseudostateSourceConstraints(source *Vertex, contex
 	// We use naming conventions to identify pseudostate kinds
 
 	if t.isTerminatePseudostate(source) {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"Source",
 			"terminate pseudostate cannot have outgoing transitions (UML constraint)",
 			context.Path,
+			ClauseTerminate,
 		)
 	}
 
@@ -356,12 +391,13 @@ func (t *Transition) validateVertexContainment(vertex *Vertex, vertexRole string
 	}
 
 	if !found {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			vertexRole,
 			fmt.Sprintf("%s vertex (ID: %s) is not contained in the transition's region (UML constraint)", vertexRole, vertex.ID),
 			context.Path,
+			ClauseRegionContainment,
 		)
 	}
 }
@@ -442,12 +478,17 @@ func (t *Transition) validateExternalTargetAccessibility(target *Vertex, region
 
 		// If still not found, it's an error
 		if !found {
-			errors.AddError(
+			suggestion := "" // Suggestions need an index of the whole state machine
+			if context.VertexStore == nil {
+				suggestion = didYouMean(target.ID, canonicalVertices(context.StateMachine))
+			}
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"Target",
-				fmt.Sprintf("external transition target (ID: %s) not found in any region of the state machine (UML constraint)", target.ID),
+				fmt.Sprintf("external transition target (ID: %s) not found in any region of the state machine (UML constraint)%s", target.ID, suggestion),
 				context.Path,
+				ClauseStateIsExternal,
 			)
 		}
 	}
@@ -471,6 +512,165 @@ func (t *Transition) validateCrossRegionTransition(source, target *Vertex, conte
 	}
 }
 
+// validateTriggerPlacement validates where triggers may appear
+// UML Constraint: Transitions leaving pseudostates are not triggered; only
+// the initial transition of a state machine's top-level region may carry a
+// (creation) trigger. Transitions leaving states without triggers are
+// completion transitions and are reported as warnings.
+func (t *Transition) validateTriggerPlacement(context *ValidationContext, errors *ValidationErrors) {
+	if t.Source == nil {
+		return
+	}
+
+	switch t.Source.Type {
+	case "pseudostate":
+		kind := vertexPseudostateKind(t.Source)
+		if kind == "" || len(t.Triggers) == 0 {
+			return
+		}
+		if kind == PseudostateKindInitial && !context.inNestedRegion() {
+			return
+		}
+		errors.AddErrorWithClause(
+			ErrorTypeConstraint,
+			"Transition",
+			"Triggers",
+			fmt.Sprintf("transition leaving %s pseudostate '%s' cannot have triggers (UML constraint)", kind, t.Source.ID),
+			context.Path,
+			ClauseOutgoingPseudostates,
+		)
+	case "state":
+		// Join segments are never triggered; validateSegmentGuards reports them
+		if len(t.Triggers) == 0 && vertexPseudostateKind(t.Target) != PseudostateKindJoin {
+			errors.AddWarningWithClause(
+				ErrorTypeConstraint,
+				"Transition",
+				"Triggers",
+				fmt.Sprintf("transition '%s' leaving state '%s' has no triggers and is a completion transition (UML semantics)", t.ID, t.Source.ID),
+				context.Path,
+				ClauseTransition,
+			)
+		}
+	}
+}
+
+// validateSegmentGuards validates guards and triggers on transition segments
+// that UML requires to be unconditional
+// UML Constraint: The transition leaving an initial pseudostate has no guard,
+// fork segments have no guards (fork_segment_guards) and join segments have
+// neither guards nor triggers (join_segment_guards)
+func (t *Transition) validateSegmentGuards(context *ValidationContext, errors *ValidationErrors) {
+	if t.Source == nil || t.Target == nil {
+		return
+	}
+
+	if t.Guard != nil {
+		switch vertexPseudostateKind(t.Source) {
+		case PseudostateKindInitial:
+			errors.AddErrorWithClause(
+				ErrorTypeConstraint,
+				"Transition",
+				"Guard",
+				fmt.Sprintf("transition leaving initial pseudostate '%s' cannot have a guard (UML constraint)", t.Source.ID),
+				context.Path,
+				ClauseInitialVertex,
+			)
+		case PseudostateKindFork:
+			errors.AddErrorWithClause(
+				ErrorTypeConstraint,
+				"Transition",
+				"Guard",
+				fmt.Sprintf("fork segment leaving '%s' cannot have a guard (UML constraint)", t.Source.ID),
+				context.Path,
+				ClauseForkSegmentGuards,
+			)
+		}
+	}
+
+	if vertexPseudostateKind(t.Target) == PseudostateKindJoin {
+		if t.Guard != nil {
+			errors.AddErrorWithClause(
+				ErrorTypeConstraint,
+				"Transition",
+				"Guard",
+				fmt.Sprintf("join segment entering '%s' cannot have a guard (UML constraint)", t.Target.ID),
+				context.Path,
+				ClauseJoinSegmentGuards,
+			)
+		}
+		if len(t.Triggers) > 0 {
+			errors.AddErrorWithClause(
+				ErrorTypeConstraint,
+				"Transition",
+				"Triggers",
+				fmt.Sprintf("join segment entering '%s' cannot have triggers (UML constraint)", t.Target.ID),
+				context.Path,
+				ClauseJoinSegmentGuards,
+			)
+		}
+	}
+}
+
+// inNestedRegion reports whether the context's region is known to be nested
+// inside a composite state rather than a top-level region of the state
+// machine. It uses the context's state machine and region when both are set,
+// and otherwise the number of regions entered.
+func (vc *ValidationContext) inNestedRegion() bool {
+	if vc == nil {
+		return false
+	}
+	if vc.StateMachine != nil && vc.Region != nil {
+		return !slices.Contains(vc.StateMachine.Regions, vc.Region)
+	}
+	return vc.depth > 1
+}
+
+// vertexPseudostateKind infers the kind of a pseudostate vertex from its
+// name or ID using the same naming conventions as the other pseudostate
+// helpers, and returns "" if the kind cannot be inferred
+func vertexPseudostateKind(vertex *Vertex) PseudostateKind {
+	if vertex == nil || vertex.Type != "pseudostate" {
+		return ""
+	}
+
+	patterns := []struct {
+		kind     PseudostateKind
+		patterns []string
+	}{
+		{PseudostateKindInitial, []string{"initial", "Initial", "INITIAL", "init", "Init", "INIT", "start", "Start", "START"}},
+		{PseudostateKindDeepHistory, []string{"deepHistory", "DeepHistory", "DEEP_HISTORY", "H*"}},
+		{PseudostateKindShallowHistory, []string{"history", "History", "HISTORY", "shallowHistory", "ShallowHistory", "SHALLOW_HISTORY", "H"}},
+		{PseudostateKindJunction, []string{"junction", "Junction", "JUNCTION"}},
+		{PseudostateKindChoice, []string{"choice", "Choice", "CHOICE", "decision", "Decision", "DECISION"}},
+		{PseudostateKindFork, []string{"fork", "Fork", "FORK"}},
+		{PseudostateKindJoin, []string{"join", "Join", "JOIN"}},
+	}
+
+	for _, candidate := range patterns {
+		for _, pattern := range candidate.patterns {
+			if vertex.Name == pattern || vertex.ID == pattern {
+				return candidate.kind
+			}
+		}
+	}
+	return ""
+}
+
+// pseudostateKindOf extends vertexPseudostateKind with the kinds that are
+// only recognized by their exact name: terminate, entryPoint and exitPoint
+func pseudostateKindOf(vertex *Vertex) PseudostateKind {
+	if kind := vertexPseudostateKind(vertex); kind != "" {
+		return kind
+	}
+	if vertex != nil && vertex.Type == "pseudostate" {
+		switch PseudostateKind(vertex.Name) {
+		case PseudostateKindTerminate, PseudostateKindEntryPoint, PseudostateKindExitPoint:
+			return PseudostateKind(vertex.Name)
+		}
+	}
+	return ""
+}
+
 // Helper methods for identifying pseudostate types
 
 // isInitialPseudostate checks if a vertex is an initial pseudostate
@@ -674,35 +874,38 @@ func (t *Transition) validateTransitionGraphConsistency(context *ValidationConte
 	if t.isInitialPseudostate(t.Target) {
 		// Initial pseudostates should not be targets (except for external transitions from other regions)
 		if t.Kind != TransitionKindExternal {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"GraphConsistency",
 				"initial pseudostate should not be the target of internal or local transitions (UML graph constraint)",
 				context.Path,
+				ClauseInitialVertex,
 			)
 		}
 	}
 
 	// Final states should not have outgoing transitions
 	if t.Source.Type == "finalstate" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"GraphConsistency",
 			"final state cannot have outgoing transitions (UML graph constraint)",
 			context.Path,
+			ClauseFinalStateNoOutgoing,
 		)
 	}
 
 	// Terminate pseudostates should not have outgoing transitions
 	if t.isTerminatePseudostate(t.Source) {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"GraphConsistency",
 			"terminate pseudostate cannot have outgoing transitions (UML graph constraint)",
 			context.Path,
+			ClauseTerminate,
 		)
 	}
 
@@ -720,23 +923,25 @@ func (t *Transition) validateKindVertexConsistency(context *ValidationContext, e
 	if t.Kind == TransitionKindInternal {
 		// Internal transitions must have the same source and target
 		if t.Source.ID != t.Target.ID {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"KindConsistency",
 				"internal transition must have the same source and target vertex (UML constraint)",
 				context.Path,
+				ClauseStateIsInternal,
 			)
 		}
 
 		// Internal transitions should typically be on states, not pseudostates
 		if t.Source.Type != "state" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"KindConsistency",
 				"internal transition should typically have a state as source, not a pseudostate (UML best practice)",
 				context.Path,
+				ClauseStateIsInternal,
 			)
 		}
 	}
@@ -746,22 +951,24 @@ func (t *Transition) validateKindVertexConsistency(context *ValidationContext, e
 		// This requires more context about the state hierarchy to validate properly
 		// For now, we validate that neither source nor target are connection points
 		if t.isConnectionPoint(t.Source) {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"KindConsistency",
 				"local transition should not originate from connection points (UML constraint)",
 				context.Path,
+				ClauseStateIsLocal,
 			)
 		}
 
 		if t.isConnectionPoint(t.Target) {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"KindConsistency",
 				"local transition should not target connection points (UML constraint)",
 				context.Path,
+				ClauseStateIsLocal,
 			)
 		}
 	}
@@ -808,17 +1015,17 @@ func (t *Transition) validateTriggerConsistency(context *ValidationContext, erro
 		}
 
 		// Check for duplicate event references
-		if trigger.Event != nil && trigger.Event.ID != "" {
-			if prevIndex, exists := triggerEvents[trigger.Event.ID]; exists {
+		if eventID := trigger.EventKey(); eventID != "" {
+			if prevIndex, exists := triggerEvents[eventID]; exists {
 				errors.AddError(
 					ErrorTypeConstraint,
 					"Transition",
 					"Triggers",
-					fmt.Sprintf("duplicate event reference '%s' found at indices %d and %d (may cause ambiguity)", trigger.Event.ID, prevIndex, i),
+					fmt.Sprintf("duplicate event reference '%s' found at indices %d and %d (may cause ambiguity)", eventID, prevIndex, i),
 					triggerContext.Path,
 				)
 			} else {
-				triggerEvents[trigger.Event.ID] = i
+				triggerEvents[eventID] = i
 			}
 		}
 	}
@@ -832,23 +1039,25 @@ func (t *Transition) validateGuardEffectConsistency(context *ValidationContext,
 
 		// Guard should have meaningful specification
 		if t.Guard.Specification == "" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"Guard",
 				"guard constraint should have a meaningful specification (UML best practice)",
 				guardContext.Path,
+				ClauseTransitionGuard,
 			)
 		}
 
 		// Guard language should be consistent with effect language if both are specified
 		if t.Effect != nil && t.Guard.Language != "" && t.Effect.Language != "" && t.Guard.Language != t.Effect.Language {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"GuardEffectConsistency",
 				fmt.Sprintf("guard uses language '%s' while effect uses '%s', consider consistency (UML best practice)", t.Guard.Language, t.Effect.Language),
 				context.Path,
+				ClauseTransitionGuard,
 			)
 		}
 	}
@@ -858,25 +1067,27 @@ func (t *Transition) validateGuardEffectConsistency(context *ValidationContext,
 		effectContext := context.WithPath("Effect")
 
 		// Effect should have meaningful specification
-		if t.Effect.Specification == "" {
-			errors.AddError(
+		if !t.Effect.hasSpecification() {
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Transition",
 				"Effect",
 				"effect behavior should have a meaningful specification (UML best practice)",
 				effectContext.Path,
+				ClauseTransitionEffect,
 			)
 		}
 	}
 
 	// Validate that guard and effect don't have conflicting IDs
 	if t.Guard != nil && t.Effect != nil && t.Guard.ID == t.Effect.ID {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Transition",
 			"GuardEffectConsistency",
 			"guard and effect have the same ID, which may cause confusion (UML best practice)",
 			context.Path,
+			ClauseTransition,
 		)
 	}
 }
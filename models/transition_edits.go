@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RetargetTransition points the transition with the given ID at the vertex
+// with ID targetID. The change is refused, leaving sm untouched, if it would
+// introduce validation errors.
+func RetargetTransition(sm *StateMachine, transitionID, targetID string) error {
+	if sm == nil {
+		return fmt.Errorf("state machine cannot be nil")
+	}
+	if _, transition := findTransition(sm, transitionID); transition == nil {
+		return fmt.Errorf("transition '%s' not found", transitionID)
+	}
+	if findVertex(sm, targetID) == nil {
+		return fmt.Errorf("vertex '%s' not found", targetID)
+	}
+
+	apply := func(target *StateMachine) {
+		_, transition := findTransition(target, transitionID)
+		transition.Target = findVertex(target, targetID)
+	}
+	if err := checkRefactoring(sm, apply); err != nil {
+		return fmt.Errorf("cannot retarget transition '%s': %w", transitionID, err)
+	}
+	apply(sm)
+	sm.refreshAdjacency()
+	return nil
+}
+
+// SplitTransition reroutes the transition with the given ID through a new
+// choice pseudostate with ID choiceID, inserted into the transition's region.
+// The original transition keeps its source and triggers and now ends at the
+// choice; a new transition with ID "<transitionID>_<choiceID>" carries the
+// guard and effect from the choice to the original target, so further
+// guarded branches can be added to the choice. It returns the new transition.
+func SplitTransition(sm *StateMachine, transitionID, choiceID string) (*Transition, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+	if _, transition := findTransition(sm, transitionID); transition == nil {
+		return nil, fmt.Errorf("transition '%s' not found", transitionID)
+	}
+	if choiceID == "" {
+		return nil, fmt.Errorf("choice ID cannot be empty")
+	}
+	branchID := transitionID + "_" + choiceID
+	for _, id := range []string{choiceID, branchID} {
+		if elementExists(sm, id) {
+			return nil, fmt.Errorf("cannot split transition '%s': ID '%s' is already in use", transitionID, id)
+		}
+	}
+
+	var branch *Transition
+	apply := func(target *StateMachine) {
+		region, transition := findTransition(target, transitionID)
+		choice := &Vertex{ID: choiceID, Name: "Choice", Type: "pseudostate"}
+		region.Vertices = append(region.Vertices, choice)
+
+		branch = &Transition{
+			ID:     branchID,
+			Name:   transition.Name,
+			Source: choice,
+			Target: transition.Target,
+			Kind:   TransitionKindExternal,
+			Guard:  transition.Guard,
+			Effect: transition.Effect,
+		}
+		region.Transitions = append(region.Transitions, branch)
+
+		transition.Target = choice
+		transition.Guard = nil
+		transition.Effect = nil
+	}
+	if err := checkRefactoring(sm, apply); err != nil {
+		return nil, fmt.Errorf("cannot split transition '%s': %w", transitionID, err)
+	}
+	apply(sm)
+	sm.refreshAdjacency()
+	return branch, nil
+}
+
+// MergeDuplicateTransitions merges parallel triggered transitions, those in
+// the same region with the same source, target, kind, guard and effect, into
+// the first of them. Triggers of the merged transitions are added to the
+// surviving transition unless it already has a trigger for the same event.
+// Transitions without triggers are completion transitions and are never
+// merged. The merge is refused, leaving sm untouched, if it would introduce
+// validation errors. It returns the IDs of the removed transitions in model
+// order.
+func MergeDuplicateTransitions(sm *StateMachine) ([]string, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("state machine cannot be nil")
+	}
+
+	apply := func(target *StateMachine) { mergeDuplicateTransitions(target) }
+	if err := checkRefactoring(sm, apply); err != nil {
+		return nil, fmt.Errorf("cannot merge duplicate transitions: %w", err)
+	}
+	removed := mergeDuplicateTransitions(sm)
+	sm.refreshAdjacency()
+	return removed, nil
+}
+
+// mergeDuplicateTransitions merges the parallel triggered transitions of sm
+// and returns the IDs of the removed ones
+func mergeDuplicateTransitions(sm *StateMachine) []string {
+	var removed []string
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		survivors := make(map[string]*Transition)
+		region.Transitions = slices.DeleteFunc(region.Transitions, func(transition *Transition) bool {
+			if transition == nil || transition.Source == nil || transition.Target == nil || len(transition.Triggers) == 0 {
+				return false
+			}
+			key := parallelTransitionKey(transition)
+			survivor, exists := survivors[key]
+			if !exists {
+				survivors[key] = transition
+				return false
+			}
+			for _, trigger := range transition.Triggers {
+				if trigger != nil && !hasTriggerForEvent(survivor, trigger.EventKey()) {
+					survivor.Triggers = append(survivor.Triggers, trigger)
+				}
+			}
+			removed = append(removed, transition.ID)
+			return true
+		})
+	})
+	return removed
+}
+
+// parallelTransitionKey identifies transitions that differ only in their triggers
+func parallelTransitionKey(t *Transition) string {
+	guard, effect := "", ""
+	if t.Guard != nil {
+		guard = t.Guard.Language + "\x00" + t.Guard.Specification
+	}
+	if t.Effect != nil {
+		effect = t.Effect.Language + "\x00" + t.Effect.Specification
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", t.Source.ID, t.Target.ID, t.Kind, guard, effect)
+}
+
+// hasTriggerForEvent reports whether the transition has a trigger for the event
+func hasTriggerForEvent(t *Transition, eventID string) bool {
+	for _, trigger := range t.Triggers {
+		if trigger != nil && trigger.EventKey() == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// findTransition returns the transition with the given ID and its region
+func findTransition(sm *StateMachine, id string) (*Region, *Transition) {
+	var foundRegion *Region
+	var found *Transition
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		if found != nil {
+			return
+		}
+		for _, transition := range region.Transitions {
+			if transition != nil && transition.ID == id {
+				foundRegion, found = region, transition
+				return
+			}
+		}
+	})
+	return foundRegion, found
+}
+
+// findVertex returns the canonical vertex with the given ID: the embedded
+// Vertex of a state, or the entry in a region's Vertices collection
+func findVertex(sm *StateMachine, id string) *Vertex {
+	return canonicalVertices(sm)[id]
+}
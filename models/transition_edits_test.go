@@ -0,0 +1,157 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRetargetTransition(t *testing.T) {
+	tests := []struct {
+		name         string
+		transitionID string
+		targetID     string
+		wantErr      bool
+		errContains  string
+	}{
+		{name: "retarget to state", transitionID: "t1", targetID: "state2"},
+		{name: "retarget to final state", transitionID: "t2", targetID: "final1"},
+		{name: "self-transition is refused", transitionID: "t2", targetID: "state1", wantErr: true, errContains: "result would not validate"},
+		{name: "unknown transition", transitionID: "missing", targetID: "state1", wantErr: true, errContains: "transition 'missing' not found"},
+		{name: "unknown vertex", transitionID: "t2", targetID: "missing", wantErr: true, errContains: "vertex 'missing' not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			err := RetargetTransition(sm, tt.transitionID, tt.targetID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RetargetTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("RetargetTransition() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if _, transition := findTransition(sm, tt.transitionID); transition.Target.ID != tt.targetID {
+				t.Errorf("transition target = %s, want %s", transition.Target.ID, tt.targetID)
+			}
+		})
+	}
+}
+
+func TestSplitTransition(t *testing.T) {
+	sm := createValidStateMachine()
+
+	branch, err := SplitTransition(sm, "t2", "choice1")
+	if err != nil {
+		t.Fatalf("SplitTransition() error = %v", err)
+	}
+
+	region, original := findTransition(sm, "t2")
+	if original.Target.ID != "choice1" || original.Guard != nil || original.Effect != nil {
+		t.Errorf("original transition not rerouted: %v", original)
+	}
+	if branch.ID != "t2_choice1" || branch.Source.ID != "choice1" || branch.Target.ID != "state2" {
+		t.Errorf("branch transition = %v", branch)
+	}
+	if branch.Guard == nil || branch.Guard.ID != "guard1" || branch.Effect == nil {
+		t.Error("branch transition should carry the guard and effect")
+	}
+	if !slices.ContainsFunc(region.Vertices, func(v *Vertex) bool { return v.ID == "choice1" }) {
+		t.Error("choice pseudostate should be added to the region")
+	}
+	if err := sm.Validate(); err != nil {
+		t.Errorf("split model should validate: %v", err)
+	}
+
+	if _, err := SplitTransition(sm, "t3", "choice1"); err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("SplitTransition() with duplicate choice ID error = %v", err)
+	}
+}
+
+func TestMergeDuplicateTransitions(t *testing.T) {
+	// duplicate returns a transition parallel to t2 of the valid fixture,
+	// triggered by event a
+	duplicate := func(id string) *Transition {
+		return &Transition{
+			ID:     id,
+			Name:   "Duplicate",
+			Source: &Vertex{ID: "state1", Name: "State1", Type: "state"},
+			Target: &Vertex{ID: "state2", Name: "State2", Type: "state"},
+			Kind:   TransitionKindExternal,
+			Guard:  &Constraint{ID: id + "_guard", Specification: "x > 0", Language: "Java"},
+			Effect: &Behavior{ID: id + "_effect", Specification: "updateCounter()", Language: "Java"},
+			Triggers: []*Trigger{
+				{ID: id + "_a", Name: "A", Event: &Event{ID: "a", Name: "A", Type: EventTypeSignal}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		triggered    bool // t2 has a trigger for event go
+		modify       func(dup *Transition)
+		wantRemoved  []string
+		wantTriggers []string // Trigger IDs of t2 afterwards
+	}{
+		{
+			name:         "triggered duplicates are merged",
+			triggered:    true,
+			wantRemoved:  []string{"dup"},
+			wantTriggers: []string{"tr_go", "dup_a"},
+		},
+		{
+			name:         "completion transitions are kept",
+			wantTriggers: []string{},
+		},
+		{
+			name:         "different guards are kept",
+			triggered:    true,
+			modify:       func(dup *Transition) { dup.Guard.Specification = "x < 0" },
+			wantTriggers: []string{"tr_go"},
+		},
+		{
+			name:         "guards in different languages are kept",
+			triggered:    true,
+			modify:       func(dup *Transition) { dup.Guard.Language = "OCL" },
+			wantTriggers: []string{"tr_go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			region := sm.Regions[0]
+			if tt.triggered {
+				region.Transitions[1].Triggers = []*Trigger{{ID: "tr_go", Name: "Go", Event: &Event{ID: "go", Name: "Go", Type: EventTypeSignal}}}
+			}
+			dup := duplicate("dup")
+			if tt.modify != nil {
+				tt.modify(dup)
+			}
+			region.Transitions = append(region.Transitions, dup)
+
+			removed, err := MergeDuplicateTransitions(sm)
+			if err != nil {
+				t.Fatalf("MergeDuplicateTransitions() error = %v", err)
+			}
+			if !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("MergeDuplicateTransitions() = %v, want %v", removed, tt.wantRemoved)
+			}
+			_, survivor := findTransition(sm, "t2")
+			triggers := []string{}
+			for _, trigger := range survivor.Triggers {
+				triggers = append(triggers, trigger.ID)
+			}
+			if !slices.Equal(triggers, tt.wantTriggers) {
+				t.Errorf("t2 triggers = %v, want %v", triggers, tt.wantTriggers)
+			}
+		})
+	}
+
+	if _, err := MergeDuplicateTransitions(nil); err == nil {
+		t.Error("MergeDuplicateTransitions(nil) error = nil, want an error")
+	}
+}
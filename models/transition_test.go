@@ -1,6 +1,9 @@
 package models
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestTransitionKind_IsValid(t *testing.T) {
 	tests := []struct {
@@ -123,7 +126,7 @@ func TestTransition_Validate(t *testing.T) {
 				Kind:   TransitionKind("invalid"),
 			},
 			wantErr: true,
-			errMsg:  "[Invalid] Transition.Kind: invalid TransitionKind: invalid",
+			errMsg:  "[Invalid] Transition.Kind: invalid TransitionKind: invalid (valid values: [internal, local, external])",
 		},
 		{
 			name: "invalid trigger",
@@ -709,3 +712,187 @@ func TestTransition_PseudostateHelpers(t *testing.T) {
 		})
 	}
 }
+
+func TestTransition_String(t *testing.T) {
+	tests := []struct {
+		name       string
+		transition *Transition
+		want       string
+	}{
+		{
+			name: "complete transition",
+			transition: &Transition{
+				ID:     "t1",
+				Kind:   TransitionKindExternal,
+				Source: &Vertex{ID: "a", Name: "A", Type: "state"},
+				Target: &Vertex{ID: "b", Name: "B", Type: "state"},
+			},
+			want: "Transition(id=t1, kind=external, a -> b)",
+		},
+		{
+			name:       "missing endpoints",
+			transition: &Transition{ID: "t2", Kind: TransitionKindLocal},
+			want:       "Transition(id=t2, kind=local, <nil> -> <nil>)",
+		},
+		{
+			name:       "nil transition",
+			transition: nil,
+			want:       "Transition(<nil>)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transition.String(); got != tt.want {
+				t.Errorf("Transition.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransition_ValidateTriggerPlacement(t *testing.T) {
+	trigger := &Trigger{ID: "trig", Name: "Go", Event: &Event{ID: "ev", Name: "Go", Type: EventTypeSignal}}
+	pseudostate := func(id string) *Vertex { return &Vertex{ID: id, Name: id, Type: "pseudostate"} }
+	state := &Vertex{ID: "s1", Name: "S1", Type: "state"}
+
+	tests := []struct {
+		name        string
+		source      *Vertex
+		triggers    []*Trigger
+		nested      bool
+		wantError   string
+		wantWarning string
+	}{
+		{name: "triggered choice", source: pseudostate("choice"), triggers: []*Trigger{trigger}, wantError: "transition leaving choice pseudostate 'choice' cannot have triggers"},
+		{name: "triggered fork", source: pseudostate("fork"), triggers: []*Trigger{trigger}, wantError: "transition leaving fork pseudostate 'fork' cannot have triggers"},
+		{name: "triggered join", source: pseudostate("Join"), triggers: []*Trigger{trigger}, wantError: "transition leaving join pseudostate 'Join' cannot have triggers"},
+		{name: "triggered history", source: pseudostate("H"), triggers: []*Trigger{trigger}, wantError: "transition leaving shallowHistory pseudostate 'H' cannot have triggers"},
+		{name: "triggered initial in top-level region", source: pseudostate("initial"), triggers: []*Trigger{trigger}},
+		{name: "triggered initial in nested region", source: pseudostate("initial"), triggers: []*Trigger{trigger}, nested: true, wantError: "transition leaving initial pseudostate 'initial' cannot have triggers"},
+		{name: "untriggered junction", source: pseudostate("junction")},
+		{name: "unknown pseudostate kind", source: pseudostate("gateway"), triggers: []*Trigger{trigger}},
+		{name: "triggered state", source: state, triggers: []*Trigger{trigger}},
+		{name: "completion transition", source: state, wantWarning: "transition 't' leaving state 's1' has no triggers and is a completion transition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition := &Transition{ID: "t", Source: tt.source, Target: state, Kind: TransitionKindExternal, Triggers: tt.triggers}
+			region := &Region{ID: "r", Name: "R", Transitions: []*Transition{transition}}
+			sm := &StateMachine{ID: "sm", Regions: []*Region{region}}
+			if tt.nested {
+				sm.Regions = []*Region{{ID: "top", Name: "Top", States: []*State{{Vertex: Vertex{ID: "c", Name: "C", Type: "state"}, IsComposite: true, Regions: []*Region{region}}}}}
+			}
+
+			errors := &ValidationErrors{}
+			transition.validateTriggerPlacement(NewValidationContext().WithStateMachine(sm).WithRegion(region), errors)
+
+			if tt.wantError == "" && len(errors.Errors) > 0 {
+				t.Errorf("unexpected errors: %v", errors.Errors)
+			}
+			if tt.wantError != "" && (len(errors.Errors) != 1 || !strings.Contains(errors.Errors[0].Message, tt.wantError)) {
+				t.Errorf("errors = %v, want %q", errors.Errors, tt.wantError)
+			}
+			if tt.wantWarning == "" && len(errors.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", errors.Warnings)
+			}
+			if tt.wantWarning != "" && (len(errors.Warnings) != 1 || !strings.Contains(errors.Warnings[0].Message, tt.wantWarning)) {
+				t.Errorf("warnings = %v, want %q", errors.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestStateMachineValidate_NestedInitialTrigger(t *testing.T) {
+	sm := createValidStateMachine()
+	nestedInitial := &Vertex{ID: "nested_initial", Name: "Initial", Type: "pseudostate"}
+	inner := &State{Vertex: Vertex{ID: "inner", Name: "Inner", Type: "state"}, IsSimple: true}
+	composite := sm.Regions[0].States[1]
+	composite.IsComposite = true
+	composite.IsSimple = false
+	composite.Regions = []*Region{{
+		ID:       "nested",
+		Name:     "Nested",
+		States:   []*State{inner},
+		Vertices: []*Vertex{nestedInitial},
+		Transitions: []*Transition{{
+			ID: "nested_t", Source: nestedInitial, Target: &inner.Vertex, Kind: TransitionKindExternal,
+			Triggers: []*Trigger{{ID: "nested_trigger", Name: "Go", Event: &Event{ID: "go", Name: "Go", Type: EventTypeSignal}}},
+		}},
+	}}
+
+	err := sm.Validate()
+	if err == nil || !strings.Contains(err.Error(), "transition leaving initial pseudostate 'nested_initial' cannot have triggers") {
+		t.Errorf("Validate() error = %v, want a nested initial trigger violation", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "pseudostate 'initial1'") {
+		t.Errorf("Validate() should allow the trigger on the top-level initial transition: %v", err)
+	}
+}
+
+func TestTransition_ValidateSegmentGuards(t *testing.T) {
+	guard := &Constraint{ID: "g", Specification: "ready"}
+	trigger := &Trigger{ID: "trig", Name: "Go", Event: &Event{ID: "ev", Name: "Go", Type: EventTypeSignal}}
+	pseudostate := func(id string) *Vertex { return &Vertex{ID: id, Name: id, Type: "pseudostate"} }
+	state := &Vertex{ID: "s1", Name: "S1", Type: "state"}
+
+	tests := []struct {
+		name       string
+		transition *Transition
+		wantErrors []string
+		wantClause string
+	}{
+		{
+			name:       "guarded initial transition",
+			transition: &Transition{ID: "t", Source: pseudostate("initial"), Target: state, Guard: guard},
+			wantErrors: []string{"transition leaving initial pseudostate 'initial' cannot have a guard"},
+			wantClause: ClauseInitialVertex,
+		},
+		{
+			name:       "guarded fork segment",
+			transition: &Transition{ID: "t", Source: pseudostate("fork"), Target: state, Guard: guard},
+			wantErrors: []string{"fork segment leaving 'fork' cannot have a guard"},
+			wantClause: ClauseForkSegmentGuards,
+		},
+		{
+			name:       "guarded and triggered join segment",
+			transition: &Transition{ID: "t", Source: state, Target: pseudostate("join"), Guard: guard, Triggers: []*Trigger{trigger}},
+			wantErrors: []string{"join segment entering 'join' cannot have a guard", "join segment entering 'join' cannot have triggers"},
+			wantClause: ClauseJoinSegmentGuards,
+		},
+		{
+			name:       "guarded choice branch is allowed",
+			transition: &Transition{ID: "t", Source: pseudostate("choice"), Target: state, Guard: guard},
+		},
+		{
+			name:       "plain fork and join segments are allowed",
+			transition: &Transition{ID: "t", Source: pseudostate("fork"), Target: pseudostate("join")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := &ValidationErrors{}
+			tt.transition.validateSegmentGuards(NewValidationContext(), errors)
+
+			if len(errors.Errors) != len(tt.wantErrors) {
+				t.Fatalf("errors = %v, want %d", errors.Errors, len(tt.wantErrors))
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(errors.Errors[i].Message, want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errors.Errors[i].Message, want)
+				}
+				if errors.Errors[i].Clause != tt.wantClause {
+					t.Errorf("error %d clause = %q, want %q", i, errors.Errors[i].Clause, tt.wantClause)
+				}
+			}
+		})
+	}
+
+	// Join segments are not reported as completion transitions
+	errors := &ValidationErrors{}
+	(&Transition{ID: "t", Source: state, Target: pseudostate("join")}).validateTriggerPlacement(NewValidationContext(), errors)
+	if errors.HasWarnings() {
+		t.Errorf("join segment reported as a completion transition: %v", errors.Warnings)
+	}
+}
@@ -15,14 +15,7 @@ const (
 
 // IsValid checks if the EventType is valid
 func (et EventType) IsValid() bool {
-	validTypes := map[EventType]bool{
-		EventTypeCall:       true,
-		EventTypeSignal:     true,
-		EventTypeChange:     true,
-		EventTypeTime:       true,
-		EventTypeAnyReceive: true,
-	}
-	return validTypes[et]
+	return EventTypes.IsValid(et)
 }
 
 // Event represents an event that can trigger a transition
@@ -30,6 +23,11 @@ type Event struct {
 	ID   string    `json:"id" validate:"required"`
 	Name string    `json:"name" validate:"required"`
 	Type EventType `json:"type" validate:"required"`
+	// Properties carries type-specific settings for custom event types,
+	// e.g. the URL of a webhook or the schedule of a cron event
+	Properties map[string]string `json:"properties,omitempty"`
+	Deprecated bool              `json:"deprecated,omitempty"`  // The event is being phased out; see ReplacedBy
+	ReplacedBy string            `json:"replaced_by,omitempty"` // ID of the event that replaces a deprecated event
 }
 
 // Validate validates the Event data integrity
@@ -60,7 +58,9 @@ func (e *Event) ValidateWithErrors(context *ValidationContext, errors *Validatio
 
 	// Validate required fields
 	helper.ValidateRequired(e.ID, "ID", "Event", context, errors)
+	helper.ValidateID(e.ID, "Event", context, errors)
 	helper.ValidateRequired(e.Name, "Name", "Event", context, errors)
+	helper.ValidateName(e.Name, "Event", context, errors)
 
 	// Validate type
 	if !e.Type.IsValid() {
@@ -68,17 +68,54 @@ func (e *Event) ValidateWithErrors(context *ValidationContext, errors *Validatio
 			ErrorTypeInvalid,
 			"Event",
 			"Type",
-			fmt.Sprintf("invalid EventType: %s", e.Type),
+			EventTypes.InvalidValueMessage(e.Type),
 			context.Path,
 		)
+		return
+	}
+
+	// Apply the validator registered for custom event types
+	if validator := eventTypeValidator(e.Type); validator != nil {
+		validator(e, context, errors)
 	}
 }
 
-// Trigger represents a trigger for a transition
+// Trigger represents a trigger for a transition. A trigger either embeds its
+// Event or references an event in the state machine's Events catalog by
+// EventID; referencing the catalog avoids duplicating identical events on
+// every transition.
 type Trigger struct {
-	ID    string `json:"id" validate:"required"`
-	Name  string `json:"name" validate:"required"`
-	Event *Event `json:"event" validate:"required"`
+	ID      string `json:"id" validate:"required"`
+	Name    string `json:"name" validate:"required"`
+	Event   *Event `json:"event,omitempty"`
+	EventID string `json:"event_id,omitempty"` // reference into StateMachine.Events
+}
+
+// EventKey returns the ID of the event this trigger refers to, whether it is
+// referenced from the catalog or embedded
+func (tr *Trigger) EventKey() string {
+	if tr == nil {
+		return ""
+	}
+	if tr.EventID != "" {
+		return tr.EventID
+	}
+	if tr.Event != nil {
+		return tr.Event.ID
+	}
+	return ""
+}
+
+// ResolveEvent returns the event this trigger fires on, looking up EventID in
+// the state machine's catalog when the event is not embedded
+func (tr *Trigger) ResolveEvent(sm *StateMachine) *Event {
+	if tr == nil {
+		return nil
+	}
+	if tr.Event != nil {
+		return tr.Event
+	}
+	return sm.FindEvent(tr.EventID)
 }
 
 // Validate validates the Trigger data integrity
@@ -109,8 +146,39 @@ func (tr *Trigger) ValidateWithErrors(context *ValidationContext, errors *Valida
 
 	// Validate required fields
 	helper.ValidateRequired(tr.ID, "ID", "Trigger", context, errors)
+	helper.ValidateID(tr.ID, "Trigger", context, errors)
 	helper.ValidateRequired(tr.Name, "Name", "Trigger", context, errors)
+	helper.ValidateName(tr.Name, "Trigger", context, errors)
 
-	// Validate required reference
-	helper.ValidateReference(tr.Event, "Event", "Trigger", context, errors, true)
+	// Validate the event reference: an embedded event, or a catalog reference
+	helper.ValidateReference(tr.Event, "Event", "Trigger", context, errors, tr.EventID == "")
+	tr.validateEventReference(context, errors)
+}
+
+// validateEventReference checks that a catalog reference resolves against the
+// state machine in context and agrees with any embedded event
+func (tr *Trigger) validateEventReference(context *ValidationContext, errors *ValidationErrors) {
+	if tr.EventID == "" {
+		return
+	}
+
+	if tr.Event != nil && tr.Event.ID != tr.EventID {
+		errors.AddError(
+			ErrorTypeReference,
+			"Trigger",
+			"EventID",
+			fmt.Sprintf("event_id '%s' does not match embedded event '%s' (structural integrity violation)", tr.EventID, tr.Event.ID),
+			context.Path,
+		)
+	}
+
+	if context.StateMachine != nil && context.StateMachine.FindEvent(tr.EventID) == nil {
+		errors.AddError(
+			ErrorTypeReference,
+			"Trigger",
+			"EventID",
+			fmt.Sprintf("event '%s' is not defined in the state machine's event catalog", tr.EventID),
+			context.Path,
+		)
+	}
 }
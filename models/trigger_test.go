@@ -67,7 +67,7 @@ func TestEvent_Validate(t *testing.T) {
 				Type: EventType("invalid"),
 			},
 			wantErr: true,
-			errMsg:  "[Invalid] Event.Type: invalid EventType: invalid",
+			errMsg:  "[Invalid] Event.Type: invalid EventType: invalid (valid values: [call, signal, change, time, anyReceive])",
 		},
 	}
 
@@ -150,7 +150,7 @@ func TestTrigger_Validate(t *testing.T) {
 				},
 			},
 			wantErr: true,
-			errMsg:  "multiple validation errors:\n  - [Required] Event.ID: field is required and cannot be empty at Event\n  - [Required] Event.Name: field is required and cannot be empty at Event\n  - [Invalid] Event.Type: invalid EventType:  at Event",
+			errMsg:  "multiple validation errors:\n  - [Required] Event.ID: field is required and cannot be empty at Event\n  - [Required] Event.Name: field is required and cannot be empty at Event\n  - [Invalid] Event.Type: invalid EventType:  (valid values: [call, signal, change, time, anyReceive]) at Event",
 		},
 	}
 
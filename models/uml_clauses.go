@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UML 2.5.1 specification clauses referenced by constraint errors. Checks
+// derived from the specification attach the clause when they report a
+// finding, with AddErrorWithClause or AddWarningWithClause.
+const (
+	ClauseConnectionPointReference = "§14.5.1 ConnectionPointReference"
+	ClauseCPREntryPseudostates     = "§14.5.1 Constraint entry_pseudostates"
+	ClauseCPRExitPseudostates      = "§14.5.1 Constraint exit_pseudostates"
+	ClauseFinalState               = "§14.5.2 FinalState"
+	ClauseFinalStateNoOutgoing     = "§14.5.2 Constraint no_outgoing_transitions"
+	ClausePseudostate              = "§14.5.6 Pseudostate"
+	ClauseInitialVertex            = "§14.5.6.7 Constraint initial_vertex"
+	ClauseHistoryVertices          = "§14.5.6.7 Constraint history_vertices"
+	ClauseJoinVertex               = "§14.5.6.7 Constraint join_vertex"
+	ClauseForkVertex               = "§14.5.6.7 Constraint fork_vertex"
+	ClauseJunctionVertex           = "§14.5.6.7 Constraint junction_vertex"
+	ClauseChoiceVertex             = "§14.5.6.7 Constraint choice_vertex"
+	ClauseTerminate                = "§14.5.7 PseudostateKind terminate"
+	ClauseRegion                   = "§14.5.8 Region"
+	ClauseRegionContainment        = "§14.5.8 Association ends subvertex, transition"
+	ClauseState                    = "§14.5.9 State"
+	ClauseStateKindAttributes      = "§14.5.9 Attributes isComposite, isOrthogonal, isSimple, isSubmachineState"
+	ClauseStateBehaviors           = "§14.5.9 Association ends entry, exit, doActivity"
+	ClauseEntryOrExit              = "§14.5.9 Constraint entry_or_exit"
+	ClauseSubmachineOrRegions      = "§14.5.9 Constraint submachine_or_regions"
+	ClauseSubmachineStates         = "§14.5.9 Constraint submachine_states"
+	ClauseStateMachine             = "§14.5.10 StateMachine"
+	ClauseConnectionPoints         = "§14.5.10 Constraint connection_points"
+	ClauseMethod                   = "§14.5.10 Constraint method"
+	ClauseTransition               = "§14.5.11 Transition"
+	ClauseStateIsExternal          = "§14.5.11 Constraint state_is_external"
+	ClauseStateIsInternal          = "§14.5.11 Constraint state_is_internal"
+	ClauseStateIsLocal             = "§14.5.11 Constraint state_is_local"
+	ClauseForkSegmentGuards        = "§14.5.11 Constraint fork_segment_guards"
+	ClauseJoinSegmentGuards        = "§14.5.11 Constraint join_segment_guards"
+	ClauseOutgoingPseudostates     = "§14.5.11 Constraint outgoing_pseudostates"
+	ClauseTransitionGuard          = "§14.5.11 Association end guard"
+	ClauseTransitionEffect         = "§14.5.11 Association end effect"
+	ClauseVertex                   = "§14.5.13 Vertex"
+	ClauseBehaviors                = "§13.2 Behaviors"
+	ClauseEvents                   = "§13.3 Events"
+	ClauseConstraints              = "§7.6 Constraints"
+)
+
+// GroupByClause returns the errors keyed by UML clause. Errors without a
+// clause are grouped under the empty string.
+func (ve *ValidationErrors) GroupByClause() map[string][]*ValidationError {
+	groups := make(map[string][]*ValidationError)
+	for _, err := range ve.Errors {
+		groups[err.Clause] = append(groups[err.Clause], err)
+	}
+	return groups
+}
+
+// GetClauseReport returns a report of all errors grouped by UML clause in
+// clause order, followed by the errors that have no clause. Errors within a
+// clause are listed in the canonical report order.
+func (ve *ValidationErrors) GetClauseReport() string {
+	if len(ve.Errors) == 0 {
+		return "No validation errors"
+	}
+
+	groups := ve.GroupByClause()
+	clauses := make([]string, 0, len(groups))
+	for clause := range groups {
+		if clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	sort.Slice(clauses, func(i, j int) bool {
+		return compareClauses(clauses[i], clauses[j]) < 0
+	})
+	if _, exists := groups[""]; exists {
+		clauses = append(clauses, "")
+	}
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("Validation Report by UML Clause: %d error(s) found\n", len(ve.Errors)))
+	report.WriteString(strings.Repeat("=", 50) + "\n")
+	for _, clause := range clauses {
+		title := clause
+		if title == "" {
+			title = "No UML clause"
+		}
+		report.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(groups[clause])))
+		report.WriteString(strings.Repeat("-", 30) + "\n")
+		for i, err := range sortedValidationErrors(groups[clause]) {
+			report.WriteString(fmt.Sprintf("%d. %s\n", i+1, err.Error()))
+		}
+	}
+	return report.String()
+}
+
+// compareClauses orders clauses by their numeric section, so that §7.6 sorts
+// before §13.2 and §14.5.9 before §14.5.10
+func compareClauses(a, b string) int {
+	sectionA, sectionB := clauseSection(a), clauseSection(b)
+	for i := 0; i < len(sectionA) && i < len(sectionB); i++ {
+		if sectionA[i] != sectionB[i] {
+			return sectionA[i] - sectionB[i]
+		}
+	}
+	if len(sectionA) != len(sectionB) {
+		return len(sectionA) - len(sectionB)
+	}
+	return strings.Compare(a, b)
+}
+
+// clauseSection parses the numeric section of a clause such as "§14.5.6.7 ..."
+func clauseSection(clause string) []int {
+	number, _, _ := strings.Cut(strings.TrimPrefix(clause, "§"), " ")
+	var section []int
+	for _, part := range strings.Split(number, ".") {
+		var n int
+		if _, err := fmt.Sscanf(part, "%d", &n); err != nil {
+			break
+		}
+		section = append(section, n)
+	}
+	return section
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidation_AttachesClausesWhereReported(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(sm *StateMachine)
+		message string
+		want    string
+	}{
+		{
+			name: "method with connection points",
+			modify: func(sm *StateMachine) {
+				sm.IsMethod = true
+				sm.ConnectionPoints = []*Pseudostate{{Vertex: Vertex{ID: "entry1", Name: "Entry", Type: "pseudostate"}, Kind: PseudostateKindEntryPoint}}
+			},
+			message: "used as method cannot have connection points",
+			want:    ClauseMethod,
+		},
+		{
+			name: "final state as source",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[2].Source = sm.Regions[0].Transitions[2].Target
+				sm.Regions[0].Transitions[2].Target = &sm.Regions[0].States[0].Vertex
+			},
+			message: "final state cannot be the source of a transition",
+			want:    ClauseFinalStateNoOutgoing,
+		},
+		{
+			name: "non-composite state with regions",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].States[0].Regions = []*Region{{ID: "r2", Name: "Nested"}}
+			},
+			message: "non-composite state cannot have regions",
+			want:    ClauseStateKindAttributes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			tt.modify(sm)
+			errors := &ValidationErrors{}
+			sm.ValidateWithErrors(NewValidationContext(), errors)
+			found := false
+			for _, err := range errors.Errors {
+				if strings.Contains(err.Message, tt.message) {
+					found = true
+					if err.Clause != tt.want {
+						t.Errorf("clause of %q = %q, want %q", err.Message, err.Clause, tt.want)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("no error contains %q:\n%s", tt.message, errors.GetClauseReport())
+			}
+		})
+	}
+
+	t.Run("findings are not classified by their message", func(t *testing.T) {
+		errors := &ValidationErrors{}
+		errors.AddError(ErrorTypeConstraint, "Pseudostate", "Name", "initial pseudostate should have a descriptive name (UML best practice)", nil)
+		if got := errors.Errors[0].Clause; got != "" {
+			t.Errorf("AddError() attached clause %q", got)
+		}
+	})
+}
+
+func TestValidationErrors_GroupByClause(t *testing.T) {
+	errors := &ValidationErrors{}
+	errors.AddErrorWithClause(ErrorTypeConstraint, "State", "Regions", "non-composite state cannot have regions (UML constraint)", nil, ClauseStateKindAttributes)
+	errors.AddErrorWithClause(ErrorTypeConstraint, "Pseudostate", "Name", "initial pseudostate should have a descriptive name (UML best practice)", nil, ClauseInitialVertex)
+	errors.AddWarningWithClause(ErrorTypeConstraint, "Transition", "Triggers", "completion transition (UML semantics)", nil, ClauseTransition)
+	errors.AddErrorWithClause(ErrorTypeConstraint, "Transition", "Target", "initial pseudostate cannot be the target of a transition within the same region (UML constraint)", nil, ClauseInitialVertex)
+	errors.AddError(ErrorTypeRequired, "State", "ID", "field is required and cannot be empty", nil)
+	errors.Add(&ValidationError{Type: ErrorTypeConstraint, Object: "Region", Message: "custom (UML constraint)", Clause: "§14.5.8 Constraint initial_vertex"})
+
+	if got := errors.Errors[4].Clause; got != "§14.5.8 Constraint initial_vertex" {
+		t.Errorf("Add() lost an explicit clause: got %q", got)
+	}
+	if got := errors.Warnings[0].Clause; got != ClauseTransition {
+		t.Errorf("AddWarningWithClause() clause = %q, want %q", got, ClauseTransition)
+	}
+
+	groups := errors.GroupByClause()
+	if got := len(groups[ClauseInitialVertex]); got != 2 {
+		t.Errorf("GroupByClause()[%q] has %d errors, want 2", ClauseInitialVertex, got)
+	}
+	if got := len(groups[""]); got != 1 {
+		t.Errorf("GroupByClause()[\"\"] has %d errors, want 1", got)
+	}
+
+	report := errors.GetClauseReport()
+	order := []string{
+		"§14.5.6.7 Constraint initial_vertex (2):",
+		"§14.5.8 Constraint initial_vertex (1):",
+		"§14.5.9 Attributes isComposite, isOrthogonal, isSimple, isSubmachineState (1):",
+		"No UML clause (1):",
+	}
+	last := -1
+	for _, heading := range order {
+		index := strings.Index(report, heading)
+		if index < 0 {
+			t.Fatalf("GetClauseReport() missing heading %q\ngot:\n%s", heading, report)
+		}
+		if index < last {
+			t.Errorf("GetClauseReport() heading %q is out of order\ngot:\n%s", heading, report)
+		}
+		last = index
+	}
+
+	if !strings.Contains(errors.GetDetailedReport(), "Clause: "+ClauseInitialVertex) {
+		t.Error("GetDetailedReport() should include the clause of constraint errors")
+	}
+	if got := (&ValidationErrors{}).GetClauseReport(); got != "No validation errors" {
+		t.Errorf("GetClauseReport() on empty errors = %q", got)
+	}
+}
+
+func TestStateMachineValidation_AttachesClauses(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Regions[0].States[0].Regions = []*Region{{ID: "r2", Name: "Nested"}}
+
+	err := sm.Validate()
+	validationErrors, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() error = %v, want *ValidationErrors", err)
+	}
+	if _, exists := validationErrors.GroupByClause()[ClauseStateKindAttributes]; !exists {
+		t.Errorf("Validate() errors should include clause %q\ngot:\n%s", ClauseStateKindAttributes, validationErrors.GetClauseReport())
+	}
+}
@@ -0,0 +1,282 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// UpgradeImpact classifies a change by its effect on instances of the old
+// version that keep running on the new one
+type UpgradeImpact string
+
+const (
+	// UpgradeBreaking changes can leave running instances in a configuration
+	// the new version cannot represent, such as a state that was removed
+	UpgradeBreaking UpgradeImpact = "breaking"
+	// UpgradeNonBreaking changes only affect what instances do from now on
+	UpgradeNonBreaking UpgradeImpact = "non-breaking"
+)
+
+// UpgradeRule names the class of a change for upgrade checks
+type UpgradeRule string
+
+const (
+	UpgradeStateRemoved           UpgradeRule = "state-removed"            // A state or final state instances could be in was removed
+	UpgradeStateMoved             UpgradeRule = "state-moved"              // A state moved to another region
+	UpgradeStateKindChanged       UpgradeRule = "state-kind-changed"       // A state became composite, orthogonal, simple or a submachine state, or changed submachine
+	UpgradeRegionAdded            UpgradeRule = "region-added"             // A region was added to an existing state or to the machine
+	UpgradeRegionRemoved          UpgradeRule = "region-removed"           // A region instances could be active in was removed
+	UpgradeRegionMoved            UpgradeRule = "region-moved"             // A region moved to another state
+	UpgradeHistoryRemoved         UpgradeRule = "history-removed"          // A history pseudostate and the configuration it records were removed
+	UpgradeEventRemoved           UpgradeRule = "event-removed"            // An event queued, deferred or sent by clients was removed
+	UpgradeEventChanged           UpgradeRule = "event-changed"            // The type or properties of an event changed
+	UpgradeConnectionPointRemoved UpgradeRule = "connection-point-removed" // An entry or exit point used by containing machines was removed
+	UpgradeTransitionRemoved      UpgradeRule = "transition-removed"       // A transition was removed
+	UpgradeBehaviorChanged        UpgradeRule = "behavior-changed"         // Transitions, behaviors, deferrals, transient pseudostates or machine settings changed
+	UpgradeElementAdded           UpgradeRule = "element-added"            // An element was added
+	UpgradeCosmetic               UpgradeRule = "cosmetic"                 // Names, documentation, deprecations and annotations changed
+)
+
+// defaultUpgradeImpacts is the impact of each rule unless a policy overrides it
+var defaultUpgradeImpacts = map[UpgradeRule]UpgradeImpact{
+	UpgradeStateRemoved:           UpgradeBreaking,
+	UpgradeStateMoved:             UpgradeBreaking,
+	UpgradeStateKindChanged:       UpgradeBreaking,
+	UpgradeRegionAdded:            UpgradeBreaking,
+	UpgradeRegionRemoved:          UpgradeBreaking,
+	UpgradeRegionMoved:            UpgradeBreaking,
+	UpgradeHistoryRemoved:         UpgradeBreaking,
+	UpgradeEventRemoved:           UpgradeBreaking,
+	UpgradeEventChanged:           UpgradeBreaking,
+	UpgradeConnectionPointRemoved: UpgradeBreaking,
+	UpgradeTransitionRemoved:      UpgradeNonBreaking,
+	UpgradeBehaviorChanged:        UpgradeNonBreaking,
+	UpgradeElementAdded:           UpgradeNonBreaking,
+	UpgradeCosmetic:               UpgradeNonBreaking,
+}
+
+// UpgradePolicy decides which changes between two versions of a machine are
+// breaking for running instances
+type UpgradePolicy struct {
+	Name string
+
+	// Impacts overrides the default impact of rules; rules not listed keep
+	// their default
+	Impacts map[UpgradeRule]UpgradeImpact
+
+	// AllowDeprecatedRemoval treats removing a state or event that was
+	// deprecated in the old version as non-breaking, for deployments that
+	// drain instances from deprecated states before upgrading
+	AllowDeprecatedRemoval bool
+}
+
+// Built-in upgrade policies
+var (
+	// DefaultUpgradePolicy breaks on changes that can strand running
+	// instances and accepts changes to behavior
+	DefaultUpgradePolicy = &UpgradePolicy{Name: "default"}
+
+	// StrictUpgradePolicy also breaks on removed transitions and changed
+	// behavior, for deployments that require instances to finish under the
+	// behavior they started with
+	StrictUpgradePolicy = &UpgradePolicy{Name: "strict", Impacts: map[UpgradeRule]UpgradeImpact{
+		UpgradeTransitionRemoved: UpgradeBreaking,
+		UpgradeBehaviorChanged:   UpgradeBreaking,
+	}}
+
+	// DrainingUpgradePolicy is DefaultUpgradePolicy for deployments that move
+	// instances out of deprecated states before upgrading
+	DrainingUpgradePolicy = &UpgradePolicy{Name: "draining", AllowDeprecatedRemoval: true}
+)
+
+// Impact returns the impact the policy assigns to a rule
+func (p *UpgradePolicy) Impact(rule UpgradeRule) UpgradeImpact {
+	if impact, ok := p.Impacts[rule]; ok {
+		return impact
+	}
+	return defaultUpgradeImpacts[rule]
+}
+
+// UpgradeFinding is a change between two versions with its classification
+type UpgradeFinding struct {
+	Change Change        `json:"change"`
+	Rule   UpgradeRule   `json:"rule"`
+	Impact UpgradeImpact `json:"impact"`
+	Reason string        `json:"reason"` // Why the change affects running instances, or why it does not
+}
+
+// String returns a concise one-line description of the finding
+func (f UpgradeFinding) String() string {
+	return fmt.Sprintf("%s [%s] %s: %s", f.Impact, f.Rule, f.Change, f.Reason)
+}
+
+// UpgradeReport classifies every change between two versions of a machine
+type UpgradeReport struct {
+	Policy   string           `json:"policy"`
+	Findings []UpgradeFinding `json:"findings"` // In the order of Diff
+}
+
+// Breaking returns the findings that are breaking
+func (r *UpgradeReport) Breaking() []UpgradeFinding {
+	var breaking []UpgradeFinding
+	for _, finding := range r.Findings {
+		if finding.Impact == UpgradeBreaking {
+			breaking = append(breaking, finding)
+		}
+	}
+	return breaking
+}
+
+// IsBreaking reports whether any change is breaking
+func (r *UpgradeReport) IsBreaking() bool {
+	return len(r.Breaking()) > 0
+}
+
+// String returns one line per finding
+func (r *UpgradeReport) String() string {
+	if len(r.Findings) == 0 {
+		return "no changes"
+	}
+	lines := make([]string, len(r.Findings))
+	for i, finding := range r.Findings {
+		lines[i] = finding.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckUpgrade classifies the changes Diff finds between the old and new
+// version of a machine as breaking or non-breaking for instances of the old
+// version that are upgraded to the new one while running, under policy
+// (DefaultUpgradePolicy if nil). An upgrade is safe when the report is not
+// breaking.
+func CheckUpgrade(old, new *StateMachine, policy *UpgradePolicy) *UpgradeReport {
+	if policy == nil {
+		policy = DefaultUpgradePolicy
+	}
+	before, after := upgradeIndex(old), upgradeIndex(new)
+
+	report := &UpgradeReport{Policy: policy.Name}
+	for _, change := range Diff(old, new) {
+		rule, reason := classifyUpgradeChange(change, before, after)
+		impact := policy.Impact(rule)
+		if policy.AllowDeprecatedRemoval && change.Kind == ChangeRemoved && before.deprecated[change.Element+"\x00"+change.ID] {
+			impact = UpgradeNonBreaking
+			reason += "; it was deprecated, so instances were drained from it"
+		}
+		report.Findings = append(report.Findings, UpgradeFinding{Change: change, Rule: rule, Impact: impact, Reason: reason})
+	}
+	return report
+}
+
+// upgradeModel indexes what classifying changes needs to know about one
+// version of a machine
+type upgradeModel struct {
+	regionOwners map[string]string // Owning state ID by region ID, "" for top-level regions
+	states       map[string]bool   // IDs of states
+	history      map[string]bool   // IDs of history pseudostates
+	deprecated   map[string]bool   // Deprecated states and events by Diff element kind and ID
+}
+
+// upgradeIndex indexes a version of a machine; sm may be nil
+func upgradeIndex(sm *StateMachine) *upgradeModel {
+	model := &upgradeModel{regionOwners: make(map[string]string), states: make(map[string]bool), history: make(map[string]bool), deprecated: make(map[string]bool)}
+	if sm == nil {
+		return model
+	}
+	for _, event := range sm.Events {
+		if event != nil && event.Deprecated {
+			model.deprecated["Event\x00"+event.ID] = true
+		}
+	}
+	owners := map[*Region]string{}
+	for _, region := range sm.Regions {
+		owners[region] = ""
+	}
+	walkRegionTree(sm.Regions, "", func(region *Region, _ string) {
+		model.regionOwners[region.ID] = owners[region]
+		for _, state := range region.States {
+			if state == nil {
+				continue
+			}
+			model.states[state.ID] = true
+			if state.Deprecated {
+				model.deprecated["State\x00"+state.ID] = true
+			}
+			for _, nested := range state.Regions {
+				owners[nested] = state.ID
+			}
+		}
+		for _, vertex := range region.Vertices {
+			switch pseudostateKindOf(vertex) {
+			case PseudostateKindShallowHistory, PseudostateKindDeepHistory:
+				model.history[vertex.ID] = true
+			}
+		}
+	})
+	return model
+}
+
+// classifyUpgradeChange returns the rule a change falls under and the reason
+// it matters to running instances
+func classifyUpgradeChange(change Change, before, after *upgradeModel) (UpgradeRule, string) {
+	if !change.Semantic {
+		return UpgradeCosmetic, "does not affect behavior"
+	}
+	id := change.ID
+	switch change.Kind {
+	case ChangeAdded:
+		if change.Element == "Region" {
+			owner := after.regionOwners[id]
+			switch {
+			case owner == "":
+				return UpgradeRegionAdded, "running instances have no active state in the new top-level region"
+			case before.states[owner]:
+				return UpgradeRegionAdded, fmt.Sprintf("instances in state '%s' have no active state in the new region", owner)
+			}
+			return UpgradeElementAdded, fmt.Sprintf("its state '%s' is new, so no instance is in it", owner)
+		}
+		return UpgradeElementAdded, "running instances are not affected until they use it"
+
+	case ChangeRemoved:
+		switch change.Element {
+		case "State", "FinalState":
+			return UpgradeStateRemoved, fmt.Sprintf("instances in '%s' would be left in a state that no longer exists", id)
+		case "Region":
+			return UpgradeRegionRemoved, fmt.Sprintf("instances active in region '%s' would lose that part of their configuration", id)
+		case "Pseudostate":
+			if before.history[id] {
+				return UpgradeHistoryRemoved, fmt.Sprintf("the configuration recorded by history '%s' would be lost", id)
+			}
+			return UpgradeBehaviorChanged, "instances do not rest in transient pseudostates"
+		case "Event":
+			return UpgradeEventRemoved, fmt.Sprintf("occurrences of '%s' that are queued, deferred or still sent could no longer be handled", id)
+		case "ConnectionPoint":
+			return UpgradeConnectionPointRemoved, fmt.Sprintf("machines entering or leaving through '%s' would no longer resolve it", id)
+		case "Transition":
+			return UpgradeTransitionRemoved, "instances stay in valid states but can no longer take it"
+		}
+		return UpgradeBehaviorChanged, "changes what instances do from now on"
+	}
+
+	switch change.Element + "." + change.Field {
+	case "State.region":
+		return UpgradeStateMoved, fmt.Sprintf("instances in '%s' would have an inconsistent configuration in region '%s'", id, change.New)
+	case "State.kind", "State.submachine":
+		return UpgradeStateKindChanged, fmt.Sprintf("the substates instances in '%s' are in would no longer match its %s", id, change.Field)
+	case "Region.owner":
+		return UpgradeRegionMoved, fmt.Sprintf("instances active in region '%s' would have an inconsistent configuration", id)
+	case "Pseudostate.kind", "Pseudostate.region":
+		if before.history[id] {
+			return UpgradeHistoryRemoved, fmt.Sprintf("the configuration recorded by history '%s' would no longer apply", id)
+		}
+	case "Event.type", "Event.properties":
+		return UpgradeEventChanged, fmt.Sprintf("occurrences of '%s' already queued or deferred were created for its old %s", id, change.Field)
+	}
+	return UpgradeBehaviorChanged, "changes what instances do from now on"
+}
+
+// UpgradeRules lists every rule with its default impact
+func UpgradeRules() map[UpgradeRule]UpgradeImpact {
+	return maps.Clone(defaultUpgradeImpacts)
+}
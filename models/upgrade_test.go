@@ -0,0 +1,172 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckUpgrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(sm *StateMachine)
+		policy *UpgradePolicy
+		want   []string // "<impact> <rule> <element> <id>" of every finding
+	}{
+		{
+			name:   "renames are cosmetic",
+			modify: func(sm *StateMachine) { sm.Regions[0].States[0].Name = "Idle" },
+			want:   []string{"non-breaking cosmetic State idle"},
+		},
+		{
+			name: "removed state",
+			modify: func(sm *StateMachine) {
+				main := sm.Regions[0]
+				main.States = main.States[1:]
+				main.Transitions = main.Transitions[:0]
+			},
+			want: []string{
+				"breaking state-removed State idle",
+				"non-breaking transition-removed Transition start",
+				"non-breaking transition-removed Transition play",
+				"non-breaking transition-removed Transition stop",
+			},
+		},
+		{
+			name: "region added to an existing state",
+			modify: func(sm *StateMachine) {
+				playing := sm.Regions[0].States[1]
+				playing.Regions = append(playing.Regions, &Region{ID: "subtitles"})
+			},
+			want: []string{"breaking region-added Region subtitles"},
+		},
+		{
+			name: "region of a new state",
+			modify: func(sm *StateMachine) {
+				paused := execState("paused", &Region{ID: "paused-main"})
+				sm.Regions[0].States = append(sm.Regions[0].States, paused)
+			},
+			want: []string{"non-breaking element-added State paused", "non-breaking element-added Region paused-main"},
+		},
+		{
+			name: "state moved between regions",
+			modify: func(sm *StateMachine) {
+				audio, video := sm.Regions[0].States[1].Regions[0], sm.Regions[0].States[1].Regions[1]
+				video.States = append(video.States, audio.States[1])
+				audio.States = audio.States[:1]
+			},
+			want: []string{"breaking state-moved State streaming"},
+		},
+		{
+			name: "state became composite",
+			modify: func(sm *StateMachine) {
+				idle := sm.Regions[0].States[0]
+				idle.IsSimple, idle.IsComposite = false, true
+			},
+			want: []string{"breaking state-kind-changed State idle"},
+		},
+		{
+			name: "event removed and changed",
+			modify: func(sm *StateMachine) {
+				sm.Events = sm.Events[1:]
+				sm.Events[0].Type = EventTypeCall
+			},
+			want: []string{"breaking event-removed Event play", "breaking event-changed Event stop"},
+		},
+		{
+			name:   "history removed",
+			modify: func(sm *StateMachine) { sm.Regions[0].Vertices = sm.Regions[0].Vertices[:1] },
+			want:   []string{"breaking history-removed Pseudostate resume"},
+		},
+		{
+			name: "changed guard",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Guard = &Constraint{ID: "ready", Specification: "ready"}
+			},
+			want: []string{"non-breaking behavior-changed Transition play"},
+		},
+		{
+			name: "strict policy breaks on behavior changes",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Transitions[1].Guard = &Constraint{ID: "ready", Specification: "ready"}
+			},
+			policy: StrictUpgradePolicy,
+			want:   []string{"breaking behavior-changed Transition play"},
+		},
+		{
+			name:   "policy overrides a rule",
+			modify: func(sm *StateMachine) { sm.Events = sm.Events[1:] },
+			policy: &UpgradePolicy{Name: "custom", Impacts: map[UpgradeRule]UpgradeImpact{UpgradeEventRemoved: UpgradeNonBreaking}},
+			want:   []string{"non-breaking event-removed Event play"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := newUpgradeMachine()
+			new := old.Clone()
+			tt.modify(new)
+
+			report := CheckUpgrade(old, new, tt.policy)
+			var got []string
+			for _, finding := range report.Findings {
+				got = append(got, strings.Join([]string{string(finding.Impact), string(finding.Rule), finding.Change.Element, finding.Change.ID}, " "))
+				if finding.Reason == "" {
+					t.Errorf("finding %v has no reason", finding)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("CheckUpgrade() findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			breaking := false
+			for _, want := range tt.want {
+				breaking = breaking || strings.HasPrefix(want, "breaking ")
+			}
+			if report.IsBreaking() != breaking {
+				t.Errorf("IsBreaking() = %v, want %v", report.IsBreaking(), breaking)
+			}
+		})
+	}
+}
+
+func TestUpgradeRules(t *testing.T) {
+	rules := UpgradeRules()
+	if len(rules) != 14 || rules[UpgradeStateRemoved] != UpgradeBreaking || rules[UpgradeCosmetic] != UpgradeNonBreaking {
+		t.Errorf("UpgradeRules() = %v", rules)
+	}
+	rules[UpgradeStateRemoved] = UpgradeNonBreaking
+	if DefaultUpgradePolicy.Impact(UpgradeStateRemoved) != UpgradeBreaking {
+		t.Error("UpgradeRules() should return a copy")
+	}
+}
+
+func TestCheckUpgrade_DeprecatedRemoval(t *testing.T) {
+	old := newUpgradeMachine()
+	old.Regions[0].States[0].Deprecated = true
+	new := old.Clone()
+	main := new.Regions[0]
+	main.States = main.States[1:]
+	main.Transitions = main.Transitions[2:]
+
+	if report := CheckUpgrade(old, new, nil); !report.IsBreaking() || report.Policy != "default" {
+		t.Errorf("default policy report:\n%s\nwant the removed state to break", report)
+	}
+	report := CheckUpgrade(old, new, DrainingUpgradePolicy)
+	if report.IsBreaking() {
+		t.Errorf("draining policy report:\n%s\nwant no breaking changes", report)
+	}
+	if finding := report.Findings[0]; finding.Rule != UpgradeStateRemoved || !strings.Contains(finding.Reason, "it was deprecated") {
+		t.Errorf("finding = %v, want the drained state removal", finding)
+	}
+}
+
+// newUpgradeMachine returns the player machine with an event catalog and a
+// history pseudostate
+func newUpgradeMachine() *StateMachine {
+	sm := newPlayerMachine()
+	sm.Events = []*Event{
+		{ID: "play", Name: "play", Type: EventTypeSignal},
+		{ID: "stop", Name: "stop", Type: EventTypeSignal},
+	}
+	sm.Regions[0].Vertices = append(sm.Regions[0].Vertices, &Vertex{ID: "resume", Name: "history", Type: "pseudostate"})
+	return sm
+}
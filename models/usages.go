@@ -0,0 +1,185 @@
+package models
+
+import (
+	"fmt"
+	"iter"
+)
+
+// UsageKind describes how an element is referenced
+type UsageKind string
+
+const (
+	UsageTransitionSource     UsageKind = "transition_source"
+	UsageTransitionTarget     UsageKind = "transition_target"
+	UsageTransitionGuard      UsageKind = "transition_guard"
+	UsageTransitionEffect     UsageKind = "transition_effect"
+	UsageTriggerEvent         UsageKind = "trigger_event"
+	UsageStateEntry           UsageKind = "state_entry"
+	UsageStateExit            UsageKind = "state_exit"
+	UsageStateDoActivity      UsageKind = "state_do_activity"
+	UsageSubmachine           UsageKind = "submachine"
+	UsageConnectionPointEntry UsageKind = "connection_point_entry"
+	UsageConnectionPointExit  UsageKind = "connection_point_exit"
+)
+
+// Usage describes a single place where a model element is referenced
+type Usage struct {
+	Kind      UsageKind `json:"kind"`
+	ElementID string    `json:"element_id"` // ID of the referenced element
+	OwnerType string    `json:"owner_type"` // Type of the referencing element, e.g. "Transition"
+	OwnerID   string    `json:"owner_id"`   // ID of the referencing element
+	Path      string    `json:"path"`       // Path of the referencing element within the state machine
+}
+
+// String returns a concise one-line description of the Usage
+func (u Usage) String() string {
+	return fmt.Sprintf("%s %s references %s as %s at %s", u.OwnerType, u.OwnerID, u.ElementID, u.Kind, u.Path)
+}
+
+// Usages returns every place in the state machine where the element with the
+// given ID is referenced: transitions using a vertex, triggers using an
+// event, states using a behavior or submachine, and connection point
+// references using an entry or exit point. Definitions of the element itself
+// are not reported. Usages are returned in model traversal order.
+func Usages(sm *StateMachine, elementID string) []Usage {
+	if sm == nil || elementID == "" {
+		return nil
+	}
+
+	var usages []Usage
+	add := func(kind UsageKind, ownerType, ownerID, path string) {
+		usages = append(usages, Usage{Kind: kind, ElementID: elementID, OwnerType: ownerType, OwnerID: ownerID, Path: path})
+	}
+
+	walkRegionTree(sm.Regions, "", func(region *Region, regionPath string) {
+		for i, state := range region.States {
+			if state == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s.States[%d]", regionPath, i)
+			if state.Entry != nil && state.Entry.ID == elementID {
+				add(UsageStateEntry, "State", state.ID, path)
+			}
+			if state.Exit != nil && state.Exit.ID == elementID {
+				add(UsageStateExit, "State", state.ID, path)
+			}
+			if state.DoActivity != nil && state.DoActivity.ID == elementID {
+				add(UsageStateDoActivity, "State", state.ID, path)
+			}
+			if state.Submachine != nil && state.Submachine.ID == elementID {
+				add(UsageSubmachine, "State", state.ID, path)
+			}
+			for j, connection := range state.Connections {
+				if connection == nil {
+					continue
+				}
+				connectionPath := fmt.Sprintf("%s.Connections[%d]", path, j)
+				for _, entry := range connection.Entry {
+					if entry != nil && entry.ID == elementID {
+						add(UsageConnectionPointEntry, "ConnectionPointReference", connection.ID, connectionPath)
+					}
+				}
+				for _, exit := range connection.Exit {
+					if exit != nil && exit.ID == elementID {
+						add(UsageConnectionPointExit, "ConnectionPointReference", connection.ID, connectionPath)
+					}
+				}
+			}
+		}
+
+		for i, transition := range region.Transitions {
+			if transition == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s.Transitions[%d]", regionPath, i)
+			if transition.Source != nil && transition.Source.ID == elementID {
+				add(UsageTransitionSource, "Transition", transition.ID, path)
+			}
+			if transition.Target != nil && transition.Target.ID == elementID {
+				add(UsageTransitionTarget, "Transition", transition.ID, path)
+			}
+			if transition.Guard != nil && transition.Guard.ID == elementID {
+				add(UsageTransitionGuard, "Transition", transition.ID, path)
+			}
+			if transition.Effect != nil && transition.Effect.ID == elementID {
+				add(UsageTransitionEffect, "Transition", transition.ID, path)
+			}
+			for j, trigger := range transition.Triggers {
+				if trigger != nil && trigger.EventKey() == elementID {
+					add(UsageTriggerEvent, "Trigger", trigger.ID, fmt.Sprintf("%s.Triggers[%d]", path, j))
+				}
+			}
+		}
+	})
+
+	return usages
+}
+
+// walkRegionTree calls fn for every non-nil region, depth first, together
+// with its path (e.g. "Regions[0].States[1].Regions[0]"); see regionTree
+func walkRegionTree(regions []*Region, prefix string, fn func(region *Region, path string)) {
+	for region, path := range regionTree(regions, prefix) {
+		fn(region, path)
+	}
+}
+
+// regionTree returns an iterator over every non-nil region, depth first,
+// together with its path. It keeps pending regions on an explicit stack, so
+// deep nesting does not grow the call stack, reads the states of a region
+// only when the iteration moves past it, and yields a region listed more
+// than once only the first time. A region with a Content provider is
+// yielded as a transient copy holding the elements the provider supplies,
+// which is not stored on the region and which edits do not reach; a region
+// whose content fails to load is yielded with its own elements, and
+// Region.ValidateWithErrors reports the failure.
+func regionTree(regions []*Region, prefix string) iter.Seq2[*Region, string] {
+	return loadedRegionTree(regions, prefix, func(region *Region, _ string) *Region {
+		return contentOf(region)
+	})
+}
+
+// loadedRegionTree is regionTree reading each region through load, which
+// returns the region to yield and whose states to descend into
+func loadedRegionTree(regions []*Region, prefix string, load func(region *Region, path string) *Region) iter.Seq2[*Region, string] {
+	return func(yield func(*Region, string) bool) {
+		type pendingRegion struct {
+			region *Region
+			path   string
+		}
+
+		var stack []pendingRegion
+		push := func(regions []*Region, prefix string) {
+			for i := len(regions) - 1; i >= 0; i-- {
+				if regions[i] == nil {
+					continue
+				}
+				path := fmt.Sprintf("Regions[%d]", i)
+				if prefix != "" {
+					path = prefix + "." + path
+				}
+				stack = append(stack, pendingRegion{region: regions[i], path: path})
+			}
+		}
+
+		seen := make(map[*Region]bool)
+		push(regions, prefix)
+		for len(stack) > 0 {
+			next := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[next.region] {
+				continue
+			}
+			seen[next.region] = true
+
+			region := load(next.region, next.path)
+			if !yield(region, next.path) {
+				return
+			}
+			for j := len(region.States) - 1; j >= 0; j-- {
+				if state := region.States[j]; state != nil {
+					push(state.Regions, fmt.Sprintf("%s.States[%d]", next.path, j))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import "testing"
+
+func TestUsages(t *testing.T) {
+	sm := createValidStateMachine()
+	sm.Events = []*Event{{ID: "go", Name: "Go", Type: EventTypeSignal}}
+	sm.Regions[0].Transitions[1].Triggers = []*Trigger{{ID: "tr_go", Name: "Go", EventID: "go"}}
+
+	child := &State{Vertex: Vertex{ID: "child", Name: "Child", Type: "state"}, IsSimple: true}
+	sm.Regions[0].States[1].Regions = []*Region{{
+		ID:     "inner",
+		Name:   "Inner",
+		States: []*State{child},
+		Transitions: []*Transition{
+			{ID: "t_inner", Source: &Vertex{ID: "child"}, Target: &Vertex{ID: "state1"}, Kind: TransitionKindExternal},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		elementID string
+		want      []Usage
+	}{
+		{
+			name:      "vertex used as source and target",
+			elementID: "state1",
+			want: []Usage{
+				{Kind: UsageTransitionTarget, ElementID: "state1", OwnerType: "Transition", OwnerID: "t1", Path: "Regions[0].Transitions[0]"},
+				{Kind: UsageTransitionSource, ElementID: "state1", OwnerType: "Transition", OwnerID: "t2", Path: "Regions[0].Transitions[1]"},
+				{Kind: UsageTransitionTarget, ElementID: "state1", OwnerType: "Transition", OwnerID: "t_inner", Path: "Regions[0].States[1].Regions[0].Transitions[0]"},
+			},
+		},
+		{
+			name:      "behavior used as entry action",
+			elementID: "entry1",
+			want: []Usage{
+				{Kind: UsageStateEntry, ElementID: "entry1", OwnerType: "State", OwnerID: "state1", Path: "Regions[0].States[0]"},
+			},
+		},
+		{
+			name:      "event referenced from catalog",
+			elementID: "go",
+			want: []Usage{
+				{Kind: UsageTriggerEvent, ElementID: "go", OwnerType: "Trigger", OwnerID: "tr_go", Path: "Regions[0].Transitions[1].Triggers[0]"},
+			},
+		},
+		{
+			name:      "constraint used as guard",
+			elementID: "guard1",
+			want: []Usage{
+				{Kind: UsageTransitionGuard, ElementID: "guard1", OwnerType: "Transition", OwnerID: "t2", Path: "Regions[0].Transitions[1]"},
+			},
+		},
+		{
+			name:      "unused element",
+			elementID: "nothing",
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Usages(sm, tt.elementID)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Usages(%q) = %v, want %v", tt.elementID, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Usages(%q)[%d] = %+v, want %+v", tt.elementID, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if got := Usages(nil, "state1"); got != nil {
+		t.Errorf("Usages(nil) = %v, want nil", got)
+	}
+}
@@ -1,6 +1,11 @@
 package models
 
-import "fmt"
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 // Validator interface defines the contract for objects that can be validated
 type Validator interface {
@@ -23,7 +28,13 @@ type ValidatorWithErrors interface {
 	ValidateWithErrors(context *ValidationContext, errors *ValidationErrors)
 }
 
-// ValidationHelper provides utility functions for common validation patterns
+// ValidationHelper provides utility functions for common validation patterns.
+// All methods record failures into the supplied ValidationErrors using the path
+// of the supplied ValidationContext, so custom rule authors and embedding
+// applications can reuse them to produce errors consistent with the built-in
+// validators. Generic, package-level variants (ValidateRequiredValue,
+// ValidateEnumValue, ValidateCollectionLength, ValidateRange,
+// ValidateOptionalReference and ValidateSlice) are provided for typed values.
 type ValidationHelper struct {
 	visited map[interface{}]bool // Track visited objects to prevent infinite recursion
 }
@@ -429,8 +440,15 @@ func (vh *ValidationHelper) ValidateCollectionSize(collection interface{}, colle
 	case []*Pseudostate:
 		size = len(v)
 	default:
-		// For unknown types, skip validation
-		return
+		// Fall back to reflection for any other slice, array or map type
+		rv := reflect.ValueOf(collection)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			size = rv.Len()
+		default:
+			// Not a collection, skip validation
+			return
+		}
 	}
 
 	if minSize > 0 && size < minSize {
@@ -491,3 +509,177 @@ func formatStringSlice(values []string) string {
 	result += "]"
 	return result
 }
+
+// contextPath returns the path of a possibly nil validation context
+func contextPath(context *ValidationContext) []string {
+	if context == nil {
+		return nil
+	}
+	return context.Path
+}
+
+// ValidateRequiredValue records an ErrorTypeRequired error when value is the
+// zero value of its type. It is the generic counterpart of
+// ValidationHelper.ValidateRequired and accepts a nil context.
+func ValidateRequiredValue[T comparable](value T, fieldName, objectName string, context *ValidationContext, errors *ValidationErrors) bool {
+	var zero T
+	if value != zero {
+		return true
+	}
+	if errors != nil {
+		errors.AddError(
+			ErrorTypeRequired,
+			objectName,
+			fieldName,
+			"field is required and cannot be empty",
+			contextPath(context),
+		)
+	}
+	return false
+}
+
+// ValidateEnumValue records an ErrorTypeInvalid error when value is not one of
+// allowedValues. It works with any string-based enum type such as
+// PseudostateKind, TransitionKind or EventType.
+func ValidateEnumValue[T ~string](value T, allowedValues []T, fieldName, objectName string, context *ValidationContext, errors *ValidationErrors) bool {
+	for _, allowed := range allowedValues {
+		if value == allowed {
+			return true
+		}
+	}
+	if errors != nil {
+		names := make([]string, len(allowedValues))
+		for i, allowed := range allowedValues {
+			names[i] = string(allowed)
+		}
+		errors.AddError(
+			ErrorTypeInvalid,
+			objectName,
+			fieldName,
+			"invalid value: must be one of "+formatStringSlice(names),
+			contextPath(context),
+		)
+	}
+	return false
+}
+
+// multiplicityOffenders collects the elements involved in a multiplicity
+// violation, so the violation is reported once with all of them rather than
+// once per element
+type multiplicityOffenders struct {
+	ids   []string
+	paths []string
+}
+
+// add records an offending element and its path
+func (o *multiplicityOffenders) add(id string, path []string) {
+	o.ids = append(o.ids, id)
+	o.paths = append(o.paths, strings.Join(path, "."))
+}
+
+// context returns the structured error context listing the offenders:
+// "count", "element_ids" and "element_paths"
+func (o *multiplicityOffenders) context() map[string]interface{} {
+	return map[string]interface{}{
+		"count":         len(o.ids),
+		"element_ids":   o.ids,
+		"element_paths": o.paths,
+	}
+}
+
+// ValidateCollectionLength records an ErrorTypeMultiplicity error when the
+// collection has fewer than minSize or more than maxSize elements. A bound of
+// zero or less disables that side of the check.
+func ValidateCollectionLength[T any](collection []T, collectionName, objectName string, minSize, maxSize int, context *ValidationContext, errors *ValidationErrors) bool {
+	valid := true
+	size := len(collection)
+
+	if minSize > 0 && size < minSize {
+		valid = false
+		if errors != nil {
+			errors.AddError(
+				ErrorTypeMultiplicity,
+				objectName,
+				collectionName,
+				fmt.Sprintf("collection must have at least %d elements, got %d", minSize, size),
+				contextPath(context),
+			)
+		}
+	}
+
+	if maxSize > 0 && size > maxSize {
+		valid = false
+		if errors != nil {
+			errors.AddError(
+				ErrorTypeMultiplicity,
+				objectName,
+				collectionName,
+				fmt.Sprintf("collection must have at most %d elements, got %d", maxSize, size),
+				contextPath(context),
+			)
+		}
+	}
+
+	return valid
+}
+
+// ValidateRange records an ErrorTypeConstraint error when value lies outside
+// the inclusive range [minValue, maxValue].
+func ValidateRange[T cmp.Ordered](value, minValue, maxValue T, fieldName, objectName string, context *ValidationContext, errors *ValidationErrors) bool {
+	if value >= minValue && value <= maxValue {
+		return true
+	}
+	if errors != nil {
+		errors.AddError(
+			ErrorTypeConstraint,
+			objectName,
+			fieldName,
+			fmt.Sprintf("value %v must be between %v and %v", value, minValue, maxValue),
+			contextPath(context),
+		)
+	}
+	return false
+}
+
+// ValidateOptionalReference validates ref when it is non-nil, collecting its
+// errors under fieldName, and records an ErrorTypeRequired error when a
+// required reference is nil. It is the typed counterpart of
+// ValidationHelper.ValidateReference.
+func ValidateOptionalReference[T Validator](ref T, fieldName, objectName string, required bool, context *ValidationContext, errors *ValidationErrors) {
+	if errors == nil {
+		return
+	}
+	if context == nil {
+		context = NewValidationContext()
+	}
+	NewValidationHelper().ValidateReference(ref, fieldName, objectName, context, errors, required)
+}
+
+// ValidateSlice validates each element of a typed collection, collecting
+// errors under collectionName[i] and recording an ErrorTypeReference error
+// for nil elements. It is the typed counterpart of
+// ValidationHelper.ValidateCollection, so rule authors need not copy their
+// elements into a []Validator first.
+func ValidateSlice[T Validator](items []T, collectionName, objectName string, context *ValidationContext, errors *ValidationErrors) {
+	if errors == nil {
+		return
+	}
+	if context == nil {
+		context = NewValidationContext()
+	}
+	validators := MapSlice(items, func(item T) Validator { return item })
+	NewValidationHelper().ValidateCollection(validators, collectionName, objectName, context, errors)
+}
+
+// MapSlice returns the results of applying fn to each element of items, in
+// order. A nil slice maps to a nil slice.
+func MapSlice[T, U any](items []T, fn func(T) U) []U {
+	if items == nil {
+		return nil
+	}
+	mapped := make([]U, len(items))
+	for i, item := range items {
+		mapped[i] = fn(item)
+	}
+	return mapped
+}
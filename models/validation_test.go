@@ -1,6 +1,7 @@
 package models
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -173,3 +174,147 @@ func TestValidationContextNilHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestGenericValidationHelpers(t *testing.T) {
+	ctx := NewValidationContext().WithPath("Custom")
+
+	t.Run("ValidateRequiredValue", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		if !ValidateRequiredValue("x", "Name", "Custom", ctx, errs) {
+			t.Error("expected non-empty string to be valid")
+		}
+		if ValidateRequiredValue(0, "Count", "Custom", nil, errs) {
+			t.Error("expected zero int to be invalid")
+		}
+		if errs.Count() != 1 || errs.Errors[0].Type != ErrorTypeRequired {
+			t.Errorf("expected one Required error, got %v", errs.Errors)
+		}
+	})
+
+	t.Run("ValidateEnumValue", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		allowed := []TransitionKind{TransitionKindInternal, TransitionKindExternal}
+		if !ValidateEnumValue(TransitionKindExternal, allowed, "Kind", "Custom", ctx, errs) {
+			t.Error("expected external to be valid")
+		}
+		if ValidateEnumValue(TransitionKindLocal, allowed, "Kind", "Custom", ctx, errs) {
+			t.Error("expected local to be invalid")
+		}
+		if errs.Count() != 1 || !strings.Contains(errs.Errors[0].Message, "[internal, external]") {
+			t.Errorf("unexpected errors: %v", errs.Errors)
+		}
+		if strings.Join(errs.Errors[0].Path, ".") != "Custom" {
+			t.Errorf("expected error path Custom, got %v", errs.Errors[0].Path)
+		}
+	})
+
+	t.Run("ValidateCollectionLength", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		if !ValidateCollectionLength([]int{1, 2}, "Items", "Custom", 1, 3, ctx, errs) {
+			t.Error("expected 2 items to be within [1,3]")
+		}
+		if ValidateCollectionLength([]string{}, "Items", "Custom", 1, 0, ctx, errs) {
+			t.Error("expected empty collection to violate minimum")
+		}
+		if ValidateCollectionLength([]int{1, 2, 3, 4}, "Items", "Custom", 0, 3, ctx, errs) {
+			t.Error("expected 4 items to violate maximum")
+		}
+		if len(errs.GetErrorsByType(ErrorTypeMultiplicity)) != 2 {
+			t.Errorf("expected 2 multiplicity errors, got %v", errs.Errors)
+		}
+	})
+
+	t.Run("ValidateRange", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		if !ValidateRange(0.5, 0.0, 1.0, "Probability", "Custom", ctx, errs) {
+			t.Error("expected 0.5 to be within [0,1]")
+		}
+		if ValidateRange(11, 1, 10, "Depth", "Custom", ctx, errs) {
+			t.Error("expected 11 to be outside [1,10]")
+		}
+		if errs.Count() != 1 || !strings.Contains(errs.Errors[0].Message, "value 11 must be between 1 and 10") {
+			t.Errorf("unexpected errors: %v", errs.Errors)
+		}
+	})
+
+	t.Run("ValidateOptionalReference", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		ValidateOptionalReference((*Behavior)(nil), "Effect", "Custom", false, ctx, errs)
+		if errs.HasErrors() {
+			t.Errorf("optional nil reference should not produce errors, got %v", errs.Errors)
+		}
+		ValidateOptionalReference((*Behavior)(nil), "Effect", "Custom", true, ctx, errs)
+		ValidateOptionalReference(&Behavior{ID: "b1"}, "Effect", "Custom", false, nil, errs)
+		if errs.Count() != 2 {
+			t.Errorf("expected required error and nested specification error, got %v", errs.Errors)
+		}
+	})
+
+	t.Run("ValidateSlice", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		ValidateSlice([]*Behavior{{ID: "b1", Specification: "run()"}, nil, {ID: "b3"}}, "Behaviors", "Custom", ctx, errs)
+		if errs.Count() != 2 {
+			t.Fatalf("expected nil element and missing specification errors, got %v", errs.Errors)
+		}
+		if got := strings.Join(errs.Errors[0].Path, "."); got != "Custom.Behaviors[1]" {
+			t.Errorf("nil element path = %q, want Custom.Behaviors[1]", got)
+		}
+		if got := strings.Join(errs.Errors[1].Path, "."); !strings.HasPrefix(got, "Custom.Behaviors[2]") {
+			t.Errorf("nested error path = %q, want it under Custom.Behaviors[2]", got)
+		}
+
+		ValidateSlice([]*Behavior{nil}, "Behaviors", "Custom", nil, nil) // No errors to collect into
+		ValidateSlice([]*Behavior(nil), "Behaviors", "Custom", nil, errs)
+		if errs.Count() != 2 {
+			t.Errorf("empty collection should not produce errors, got %v", errs.Errors)
+		}
+	})
+
+	t.Run("MapSlice", func(t *testing.T) {
+		ids := MapSlice([]*State{{Vertex: Vertex{ID: "a"}}, {Vertex: Vertex{ID: "b"}}}, (*State).GetID)
+		if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+			t.Errorf("MapSlice() = %v, want [a b]", ids)
+		}
+		if got := MapSlice([]int(nil), func(i int) int { return i }); got != nil {
+			t.Errorf("MapSlice(nil) = %v, want nil", got)
+		}
+	})
+
+	t.Run("ValidateCollectionSize with arbitrary slice types", func(t *testing.T) {
+		errs := &ValidationErrors{}
+		helper := NewValidationHelper()
+		helper.ValidateCollectionSize([]*Behavior{}, "Behaviors", "Custom", 1, 0, ctx, errs)
+		helper.ValidateCollectionSize(map[string]string{"a": "b"}, "Entities", "Custom", 0, 0, ctx, errs)
+		helper.ValidateCollectionSize(42, "NotACollection", "Custom", 1, 0, ctx, errs)
+		if errs.Count() != 1 {
+			t.Errorf("expected exactly one multiplicity error, got %v", errs.Errors)
+		}
+	})
+}
+
+func TestValidationErrors_Warnings(t *testing.T) {
+	errors := &ValidationErrors{}
+	errors.AddWarning(ErrorTypeReference, "StateMachine", "ConnectionPoints", "unused", []string{"ConnectionPoints[0]"})
+
+	if errors.HasErrors() || errors.ToError() != nil {
+		t.Errorf("warnings should not count as errors: %v", errors.Errors)
+	}
+	if !errors.HasWarnings() || errors.Warnings[0].Severity != SeverityWarning {
+		t.Errorf("Warnings = %v, want one warning", errors.Warnings)
+	}
+
+	merged := &ValidationErrors{}
+	merged.AddError(ErrorTypeRequired, "State", "ID", "missing", nil)
+	merged.Merge(errors)
+	if len(merged.Errors) != 1 || len(merged.Warnings) != 1 {
+		t.Errorf("Merge() errors/warnings = %d/%d, want 1/1", len(merged.Errors), len(merged.Warnings))
+	}
+	if report := merged.GetDetailedReport(); !strings.Contains(report, "Warnings (1):") {
+		t.Errorf("GetDetailedReport() should list warnings\ngot:\n%s", report)
+	}
+
+	merged.Clear()
+	if merged.HasErrors() || merged.HasWarnings() {
+		t.Error("Clear() should remove errors and warnings")
+	}
+}
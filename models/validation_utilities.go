@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -20,30 +22,38 @@ func NewValidationUtilities() *ValidationUtilities {
 	}
 }
 
-// StateMachineTraverser provides utilities for traversing state machine hierarchies
+// StateMachineTraverser provides utilities for traversing state machine
+// hierarchies. Traversals keep their state per call, so one traverser may be
+// used by several goroutines at once, provided the Content providers of the
+// regions traversed are safe for concurrent use. Regions with a provider
+// are visited as transient copies holding the content it loads.
 type StateMachineTraverser struct {
-	visited map[string]bool
+	// MaxDepth is the deepest traversal depth visited; deeper objects stop
+	// the traversal with a ResourceLimitError. Zero means DefaultMaxDepth.
+	MaxDepth int
 }
 
 // NewStateMachineTraverser creates a new state machine traverser
 func NewStateMachineTraverser() *StateMachineTraverser {
-	return &StateMachineTraverser{
-		visited: make(map[string]bool),
-	}
+	return &StateMachineTraverser{}
 }
 
 // TraversalCallback defines the callback function for traversal operations
 type TraversalCallback func(obj interface{}, path []string, depth int) error
 
+// traversalItem is an object waiting to be visited
+type traversalItem struct {
+	obj   interface{}
+	path  []string
+	depth int
+}
+
 // TraverseStateMachine performs a depth-first traversal of a state machine hierarchy
 func (smt *StateMachineTraverser) TraverseStateMachine(sm *StateMachine, callback TraversalCallback) error {
 	if sm == nil {
 		return fmt.Errorf("state machine cannot be nil")
 	}
 
-	// Reset visited map for new traversal
-	smt.visited = make(map[string]bool)
-
 	return smt.traverseObject(sm, []string{"StateMachine"}, 0, callback)
 }
 
@@ -53,225 +63,145 @@ func (smt *StateMachineTraverser) TraverseRegion(region *Region, callback Traver
 		return fmt.Errorf("region cannot be nil")
 	}
 
-	// Reset visited map for new traversal
-	smt.visited = make(map[string]bool)
-
 	return smt.traverseObject(region, []string{"Region"}, 0, callback)
 }
 
-// traverseObject recursively traverses an object hierarchy
+// traverseObject traverses an object hierarchy depth-first in pre-order. It
+// keeps pending objects on an explicit stack, so nesting depth is bounded by
+// MaxDepth rather than by the goroutine stack.
 func (smt *StateMachineTraverser) traverseObject(obj interface{}, path []string, depth int, callback TraversalCallback) error {
-	if obj == nil {
-		return nil
+	maxDepth := smt.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
 	}
 
-	// Get object ID to prevent infinite loops
-	objID := smt.getObjectID(obj)
-	if objID != "" {
-		if smt.visited[objID] {
-			return nil // Already visited this object
+	visited := make(map[string]bool)
+	stack := []traversalItem{{obj: obj, path: path, depth: depth}}
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if item.obj == nil {
+			continue
 		}
-		smt.visited[objID] = true
-	}
-
-	// Call the callback for this object
-	if err := callback(obj, path, depth); err != nil {
-		return err
-	}
 
-	// Traverse child objects based on type
-	switch v := obj.(type) {
-	case *StateMachine:
-		return smt.traverseStateMachine(v, path, depth, callback)
-	case *Region:
-		return smt.traverseRegion(v, path, depth, callback)
-	case *State:
-		return smt.traverseState(v, path, depth, callback)
-	case *Transition:
-		return smt.traverseTransition(v, path, depth, callback)
-	case *Pseudostate:
-		return smt.traversePseudostate(v, path, depth, callback)
-	case *FinalState:
-		return smt.traverseFinalState(v, path, depth, callback)
-	case *ConnectionPointReference:
-		return smt.traverseConnectionPointReference(v, path, depth, callback)
-	}
+		// Get object ID to prevent infinite loops
+		objID := smt.getObjectID(item.obj)
+		if objID != "" {
+			if visited[objID] {
+				continue // Already visited this object
+			}
+			visited[objID] = true
+		}
 
-	return nil
-}
+		if item.depth > maxDepth {
+			return &ResourceLimitError{Limit: LimitDepth, Max: int64(maxDepth), Actual: int64(item.depth), Path: strings.Join(item.path, ".")}
+		}
 
-// traverseStateMachine traverses StateMachine children
-func (smt *StateMachineTraverser) traverseStateMachine(sm *StateMachine, path []string, depth int, callback TraversalCallback) error {
-	// Traverse regions
-	for i, region := range sm.Regions {
-		if region != nil {
-			childPath := append(path, fmt.Sprintf("Regions[%d]", i))
-			if err := smt.traverseObject(region, childPath, depth+1, callback); err != nil {
-				return err
+		// Visit lazily loaded regions through a transient copy holding their
+		// content, leaving the region itself unmodified
+		if region, ok := item.obj.(*Region); ok {
+			loaded, err := region.withContent()
+			if err != nil {
+				return fmt.Errorf("%s: %w", strings.Join(item.path, "."), err)
 			}
+			item.obj = loaded
 		}
-	}
 
-	// Traverse connection points
-	for i, cp := range sm.ConnectionPoints {
-		if cp != nil {
-			childPath := append(path, fmt.Sprintf("ConnectionPoints[%d]", i))
-			if err := smt.traverseObject(cp, childPath, depth+1, callback); err != nil {
-				return err
-			}
+		// Call the callback for this object
+		if err := callback(item.obj, item.path, item.depth); err != nil {
+			return err
+		}
+
+		// Push children in reverse so that they are visited in order
+		children := smt.children(item)
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
 		}
 	}
 
 	return nil
 }
 
-// traverseRegion traverses Region children
-func (smt *StateMachineTraverser) traverseRegion(region *Region, path []string, depth int, callback TraversalCallback) error {
-	// Traverse states
-	for i, state := range region.States {
-		if state != nil {
-			childPath := append(path, fmt.Sprintf("States[%d]", i))
-			if err := smt.traverseObject(state, childPath, depth+1, callback); err != nil {
-				return err
-			}
-		}
+// children returns the child objects of an item in traversal order
+func (smt *StateMachineTraverser) children(item traversalItem) []traversalItem {
+	var children []traversalItem
+	add := func(obj interface{}, element string) {
+		path := make([]string, len(item.path), len(item.path)+1)
+		copy(path, item.path)
+		children = append(children, traversalItem{obj: obj, path: append(path, element), depth: item.depth + 1})
 	}
 
-	// Traverse vertices
-	for i, vertex := range region.Vertices {
-		if vertex != nil {
-			childPath := append(path, fmt.Sprintf("Vertices[%d]", i))
-			if err := smt.traverseObject(vertex, childPath, depth+1, callback); err != nil {
-				return err
+	switch v := item.obj.(type) {
+	case *StateMachine:
+		for i, region := range v.Regions {
+			if region != nil {
+				add(region, fmt.Sprintf("Regions[%d]", i))
 			}
 		}
-	}
-
-	// Traverse transitions
-	for i, transition := range region.Transitions {
-		if transition != nil {
-			childPath := append(path, fmt.Sprintf("Transitions[%d]", i))
-			if err := smt.traverseObject(transition, childPath, depth+1, callback); err != nil {
-				return err
+		for i, cp := range v.ConnectionPoints {
+			if cp != nil {
+				add(cp, fmt.Sprintf("ConnectionPoints[%d]", i))
 			}
 		}
-	}
-
-	return nil
-}
-
-// traverseState traverses State children
-func (smt *StateMachineTraverser) traverseState(state *State, path []string, depth int, callback TraversalCallback) error {
-	// Traverse regions in composite states
-	for i, region := range state.Regions {
-		if region != nil {
-			childPath := append(path, fmt.Sprintf("Regions[%d]", i))
-			if err := smt.traverseObject(region, childPath, depth+1, callback); err != nil {
-				return err
+	case *Region:
+		for i, state := range v.States {
+			if state != nil {
+				add(state, fmt.Sprintf("States[%d]", i))
 			}
 		}
-	}
-
-	// Traverse submachine if present
-	if state.Submachine != nil {
-		childPath := append(path, "Submachine")
-		if err := smt.traverseObject(state.Submachine, childPath, depth+1, callback); err != nil {
-			return err
+		for i, vertex := range v.Vertices {
+			if vertex != nil {
+				add(vertex, fmt.Sprintf("Vertices[%d]", i))
+			}
 		}
-	}
-
-	// Traverse connection point references
-	for i, conn := range state.Connections {
-		if conn != nil {
-			childPath := append(path, fmt.Sprintf("Connections[%d]", i))
-			if err := smt.traverseObject(conn, childPath, depth+1, callback); err != nil {
-				return err
+		for i, transition := range v.Transitions {
+			if transition != nil {
+				add(transition, fmt.Sprintf("Transitions[%d]", i))
 			}
 		}
-	}
-
-	return nil
-}
-
-// traverseTransition traverses Transition children
-func (smt *StateMachineTraverser) traverseTransition(transition *Transition, path []string, depth int, callback TraversalCallback) error {
-	// Note: We don't traverse source/target vertices to avoid cycles
-	// Those are handled by reference validation
-
-	// Traverse triggers
-	for i, trigger := range transition.Triggers {
-		if trigger != nil {
-			childPath := append(path, fmt.Sprintf("Triggers[%d]", i))
-			if err := smt.traverseObject(trigger, childPath, depth+1, callback); err != nil {
-				return err
+	case *State:
+		// Regions in composite states, then the submachine and connection point references
+		for i, region := range v.Regions {
+			if region != nil {
+				add(region, fmt.Sprintf("Regions[%d]", i))
 			}
 		}
-	}
-
-	return nil
-}
-
-// traversePseudostate traverses Pseudostate children (usually none)
-func (smt *StateMachineTraverser) traversePseudostate(ps *Pseudostate, path []string, depth int, callback TraversalCallback) error {
-	// Pseudostates typically don't have child objects
-	return nil
-}
-
-// traverseFinalState traverses FinalState children (usually none)
-func (smt *StateMachineTraverser) traverseFinalState(fs *FinalState, path []string, depth int, callback TraversalCallback) error {
-	// Final states typically don't have child objects
-	return nil
-}
-
-// traverseConnectionPointReference traverses ConnectionPointReference children
-func (smt *StateMachineTraverser) traverseConnectionPointReference(cpr *ConnectionPointReference, path []string, depth int, callback TraversalCallback) error {
-	// Traverse entry pseudostates
-	for i, entry := range cpr.Entry {
-		if entry != nil {
-			childPath := append(path, fmt.Sprintf("Entry[%d]", i))
-			if err := smt.traverseObject(entry, childPath, depth+1, callback); err != nil {
-				return err
+		if v.Submachine != nil {
+			add(v.Submachine, "Submachine")
+		}
+		for i, conn := range v.Connections {
+			if conn != nil {
+				add(conn, fmt.Sprintf("Connections[%d]", i))
 			}
 		}
-	}
-
-	// Traverse exit pseudostates
-	for i, exit := range cpr.Exit {
-		if exit != nil {
-			childPath := append(path, fmt.Sprintf("Exit[%d]", i))
-			if err := smt.traverseObject(exit, childPath, depth+1, callback); err != nil {
-				return err
+	case *Transition:
+		// Note: We don't traverse source/target vertices to avoid cycles
+		// Those are handled by reference validation
+		for i, trigger := range v.Triggers {
+			if trigger != nil {
+				add(trigger, fmt.Sprintf("Triggers[%d]", i))
+			}
+		}
+	case *ConnectionPointReference:
+		for i, entry := range v.Entry {
+			if entry != nil {
+				add(entry, fmt.Sprintf("Entry[%d]", i))
+			}
+		}
+		for i, exit := range v.Exit {
+			if exit != nil {
+				add(exit, fmt.Sprintf("Exit[%d]", i))
 			}
 		}
 	}
+	// Pseudostates and final states have no child objects
 
-	return nil
+	return children
 }
 
-// getObjectID extracts the ID from an object using reflection
+// getObjectID extracts the ID from an object; see objectID
 func (smt *StateMachineTraverser) getObjectID(obj interface{}) string {
-	if obj == nil {
-		return ""
-	}
-
-	v := reflect.ValueOf(obj)
-	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return ""
-		}
-		v = v.Elem()
-	}
-
-	if v.Kind() != reflect.Struct {
-		return ""
-	}
-
-	idField := v.FieldByName("ID")
-	if !idField.IsValid() || idField.Kind() != reflect.String {
-		return ""
-	}
-
-	return idField.String()
+	return objectID(obj)
 }
 
 // ValidationResultAggregator provides utilities for aggregating and reporting validation results
@@ -320,7 +250,18 @@ func (vra *ValidationResultAggregator) HasErrors() bool {
 	return len(vra.results) > 0
 }
 
-// GetSummaryReport returns a summary report of all validation results
+// objectIDs returns the IDs of the objects with results in sorted order
+func (vra *ValidationResultAggregator) objectIDs() []string {
+	objectIDs := make([]string, 0, len(vra.results))
+	for objectID := range vra.results {
+		objectIDs = append(objectIDs, objectID)
+	}
+	sort.Strings(objectIDs)
+	return objectIDs
+}
+
+// GetSummaryReport returns a summary report of all validation results, with
+// objects in ID order and their errors in the canonical report order
 func (vra *ValidationResultAggregator) GetSummaryReport() string {
 	if !vra.HasErrors() {
 		return "No validation errors found."
@@ -332,19 +273,12 @@ func (vra *ValidationResultAggregator) GetSummaryReport() string {
 	report.WriteString(fmt.Sprintf("Validation Summary: %d error(s) found across %d object(s)\n", totalErrors, len(vra.results)))
 	report.WriteString(strings.Repeat("=", 60) + "\n\n")
 
-	// Sort object IDs for consistent output
-	objectIDs := make([]string, 0, len(vra.results))
-	for objectID := range vra.results {
-		objectIDs = append(objectIDs, objectID)
-	}
-	sort.Strings(objectIDs)
-
-	for _, objectID := range objectIDs {
+	for _, objectID := range vra.objectIDs() {
 		errors := vra.results[objectID]
 		report.WriteString(fmt.Sprintf("Object: %s (%d error(s))\n", objectID, len(errors.Errors)))
 		report.WriteString(strings.Repeat("-", 40) + "\n")
 
-		for i, err := range errors.Errors {
+		for i, err := range sortedValidationErrors(errors.Errors) {
 			report.WriteString(fmt.Sprintf("  %d. %s\n", i+1, err.Error()))
 		}
 		report.WriteString("\n")
@@ -353,7 +287,9 @@ func (vra *ValidationResultAggregator) GetSummaryReport() string {
 	return report.String()
 }
 
-// GetDetailedReport returns a detailed report of all validation results
+// GetDetailedReport returns a detailed report of all validation results.
+// Error types, objects and errors appear in the canonical report order;
+// only the generation time differs between reports of the same results.
 func (vra *ValidationResultAggregator) GetDetailedReport() string {
 	if !vra.HasErrors() {
 		return "No validation errors found."
@@ -367,27 +303,23 @@ func (vra *ValidationResultAggregator) GetDetailedReport() string {
 	report.WriteString(fmt.Sprintf("Total Errors: %d across %d object(s)\n", totalErrors, len(vra.results)))
 	report.WriteString(strings.Repeat("=", 80) + "\n\n")
 
-	// Group errors by type across all objects
-	errorsByType := make(map[ValidationErrorType][]*ValidationError)
-	for _, errors := range vra.results {
-		for _, err := range errors.Errors {
-			errorsByType[err.Type] = append(errorsByType[err.Type], err)
-		}
+	// Group errors by type across all objects, taking objects in ID order
+	var allErrors []*ValidationError
+	for _, objectID := range vra.objectIDs() {
+		allErrors = append(allErrors, vra.results[objectID].Errors...)
 	}
 
 	// Report by error type
-	for errorType, errors := range errorsByType {
+	errorTypes, errorsByType := groupByType(allErrors)
+	for _, errorType := range errorTypes {
+		errors := errorsByType[errorType]
 		report.WriteString(fmt.Sprintf("%s Errors (%d)\n", errorType.String(), len(errors)))
 		report.WriteString(strings.Repeat("-", 50) + "\n")
 
 		for i, err := range errors {
 			report.WriteString(fmt.Sprintf("  %d. %s\n", i+1, err.Error()))
 			if len(err.Context) > 0 {
-				report.WriteString("     Context: ")
-				for k, v := range err.Context {
-					report.WriteString(fmt.Sprintf("%s=%v ", k, v))
-				}
-				report.WriteString("\n")
+				report.WriteString("     Context: " + formatErrorContext(err.Context) + "\n")
 			}
 		}
 		report.WriteString("\n")
@@ -397,17 +329,11 @@ func (vra *ValidationResultAggregator) GetDetailedReport() string {
 	report.WriteString("Errors by Object\n")
 	report.WriteString(strings.Repeat("-", 50) + "\n")
 
-	objectIDs := make([]string, 0, len(vra.results))
-	for objectID := range vra.results {
-		objectIDs = append(objectIDs, objectID)
-	}
-	sort.Strings(objectIDs)
-
-	for _, objectID := range objectIDs {
+	for _, objectID := range vra.objectIDs() {
 		errors := vra.results[objectID]
 		report.WriteString(fmt.Sprintf("\n%s (%d error(s))\n", objectID, len(errors.Errors)))
 
-		for i, err := range errors.Errors {
+		for i, err := range sortedValidationErrors(errors.Errors) {
 			report.WriteString(fmt.Sprintf("  %d. [%s] %s.%s: %s\n", i+1, err.Type.String(), err.Object, err.Field, err.Message))
 			if len(err.Path) > 0 {
 				report.WriteString(fmt.Sprintf("     Path: %s\n", strings.Join(err.Path, ".")))
@@ -581,7 +507,8 @@ type ObjectDebugInfo struct {
 	Properties map[string]interface{} `json:"properties"`
 }
 
-// GetSummary returns a summary of the debug report
+// GetSummary returns a summary of the debug report with object types and
+// object IDs in sorted order
 func (vdr *ValidationDebugReport) GetSummary() string {
 	var summary strings.Builder
 
@@ -598,14 +525,14 @@ func (vdr *ValidationDebugReport) GetSummary() string {
 	}
 
 	summary.WriteString("Object Distribution:\n")
-	for objType, count := range typeCount {
-		summary.WriteString(fmt.Sprintf("  %s: %d\n", objType, count))
+	for _, objType := range slices.Sorted(maps.Keys(typeCount)) {
+		summary.WriteString(fmt.Sprintf("  %s: %d\n", objType, typeCount[objType]))
 	}
 
 	if vdr.TotalErrors > 0 {
 		summary.WriteString("\nValidation Issues Found:\n")
-		for objectID, errors := range vdr.ValidationResults {
-			summary.WriteString(fmt.Sprintf("  %s: %d error(s)\n", objectID, len(errors.Errors)))
+		for _, objectID := range slices.Sorted(maps.Keys(vdr.ValidationResults)) {
+			summary.WriteString(fmt.Sprintf("  %s: %d error(s)\n", objectID, len(vdr.ValidationResults[objectID].Errors)))
 		}
 	}
 
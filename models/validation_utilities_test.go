@@ -177,6 +177,43 @@ func TestStateMachineTraverser(t *testing.T) {
 			t.Errorf("Expected %d types, got %d: %v", len(expectedTypes), len(visitedTypes), visitedTypes)
 		}
 	})
+
+	t.Run("TraverseRegion visits deep nesting in order", func(t *testing.T) {
+		traverser := NewStateMachineTraverser()
+		traverser.MaxDepth = 5000
+
+		var ids []string
+		maxDepth := 0
+		err := traverser.TraverseRegion(deepRegion(2000), func(obj interface{}, path []string, depth int) error {
+			if region, ok := obj.(*Region); ok && len(ids) < 3 {
+				ids = append(ids, region.ID)
+			}
+			maxDepth = max(maxDepth, depth)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("TraverseRegion failed: %v", err)
+		}
+		if strings.Join(ids, ",") != "r0,r1,r2" || maxDepth != 4000 {
+			t.Errorf("TraverseRegion visited %v first and reached depth %d, want r0,r1,r2 and 4000", ids, maxDepth)
+		}
+	})
+
+	t.Run("TraverseRegion stops at the depth limit", func(t *testing.T) {
+		traverser := NewStateMachineTraverser()
+		traverser.MaxDepth = 4
+
+		err := traverser.TraverseRegion(deepRegion(10), func(obj interface{}, path []string, depth int) error {
+			return nil
+		})
+		limitErr, ok := err.(*ResourceLimitError)
+		if !ok {
+			t.Fatalf("TraverseRegion error = %v, want *ResourceLimitError", err)
+		}
+		if limitErr.Limit != LimitDepth || limitErr.Actual != 5 || limitErr.Path != "Region.States[0].Regions[0].States[0].Regions[0].States[0]" {
+			t.Errorf("TraverseRegion error = %+v", limitErr)
+		}
+	})
 }
 
 func TestValidationResultAggregator(t *testing.T) {
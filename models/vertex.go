@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -11,6 +12,20 @@ type Vertex struct {
 	Name string `json:"name" validate:"required"`
 	Type string `json:"type" validate:"required"` // "state", "pseudostate", "finalstate"
 	// Container *Region `json:"-"` // Parent region (not serialized)
+
+	Suppressions []Suppression `json:"suppressions,omitempty"` // Accepted findings about the vertex; see Suppression
+	Tags         []string      `json:"tags,omitempty"`         // Labels or stereotypes grouping the vertex with related elements; see View
+
+	incoming []*Transition // Transitions entering the vertex; see RecordAdjacency
+	outgoing []*Transition // Transitions leaving the vertex; see RecordAdjacency
+}
+
+// String returns a concise one-line description of the Vertex
+func (v *Vertex) String() string {
+	if v == nil {
+		return "Vertex(<nil>)"
+	}
+	return fmt.Sprintf("Vertex(id=%s, name=%q, type=%s)", v.ID, v.Name, v.Type)
 }
 
 // Validate validates the Vertex data integrity
@@ -36,12 +51,18 @@ func (v *Vertex) ValidateWithErrors(context *ValidationContext, errors *Validati
 	if errors == nil {
 		return
 	}
+	defer context.timeElement("Vertex", v.ID)()
 
 	helper := NewValidationHelper()
 
 	// Validate required fields
 	helper.ValidateRequired(v.ID, "ID", "Vertex", context, errors)
+	helper.ValidateID(v.ID, "Vertex", context, errors)
 	helper.ValidateRequired(v.Name, "Name", "Vertex", context, errors)
+	helper.ValidateName(v.Name, vertexObjectName(v.Type), context, errors)
+	if v.Type == "finalstate" {
+		helper.ValidateNameKeywords(v.Name, FinalStateKeywordKey, "FinalState", context, errors)
+	}
 	helper.ValidateRequired(v.Type, "Type", "Vertex", context, errors)
 
 	// Validate type is one of the allowed values
@@ -49,7 +70,7 @@ func (v *Vertex) ValidateWithErrors(context *ValidationContext, errors *Validati
 	helper.ValidateEnum(v.Type, "Type", "Vertex", validTypes, context, errors)
 
 	// Enhanced validation for vertex-specific constraints
-	v.validateVertexConstraints(context, errors)
+	context.check("vertex.constraints", v.validateVertexConstraints, errors)
 }
 
 // State represents a state in a state machine
@@ -64,7 +85,38 @@ type State struct {
 	Exit              *Behavior                   `json:"exit,omitempty"`
 	DoActivity        *Behavior                   `json:"do_activity,omitempty"`
 	Submachine        *StateMachine               `json:"submachine,omitempty"`
+	SubmachineDigest  string                      `json:"submachine_digest,omitempty"` // SHA-256 of the submachine's bundle document, pinning the exact submachine in content-addressed bundles; see Bundle
 	Connections       []*ConnectionPointReference `json:"connections,omitempty"`
+	Features          []string                    `json:"features,omitempty"`    // Feature flags that must all be enabled for this state to be included
+	Cost              *Cost                       `json:"cost,omitempty"`        // Optional expected time spent in and cost of the state; see AnalyzeLatency
+	Deprecated        bool                        `json:"deprecated,omitempty"`  // The state is being phased out; see ReplacedBy
+	ReplacedBy        string                      `json:"replaced_by,omitempty"` // ID of the state that replaces a deprecated state
+	Owner             string                      `json:"owner,omitempty"`       // Person or role responsible for the state; see Ownership
+	Team              string                      `json:"team,omitempty"`        // Team allowed to edit the state; see Editor
+
+	DeferrableTriggers []*Trigger `json:"deferrable_triggers,omitempty"` // Events the state defers while active; see Interpreter
+}
+
+// String returns a concise one-line description of the State including its kind
+func (s *State) String() string {
+	if s == nil {
+		return "State(<nil>)"
+	}
+	return fmt.Sprintf("State(id=%s, name=%q, kind=%s)", s.ID, s.Name, s.kindDescription())
+}
+
+// kindDescription describes which kind of state this is based on its flags
+func (s *State) kindDescription() string {
+	switch {
+	case s.IsSubmachineState:
+		return "submachine"
+	case s.IsOrthogonal:
+		return "orthogonal"
+	case s.IsComposite:
+		return "composite"
+	default:
+		return "simple"
+	}
 }
 
 // Validate validates the State data integrity
@@ -90,6 +142,7 @@ func (s *State) ValidateWithErrors(context *ValidationContext, errors *Validatio
 	if errors == nil {
 		return
 	}
+	defer context.timeElement("State", s.ID)()
 
 	helper := NewValidationHelper()
 
@@ -109,11 +162,9 @@ func (s *State) ValidateWithErrors(context *ValidationContext, errors *Validatio
 
 	// Validate regions if composite
 	if s.IsComposite {
-		regionValidators := make([]Validator, len(s.Regions))
-		for i, region := range s.Regions {
-			regionValidators[i] = region
-		}
-		helper.ValidateCollection(regionValidators, "Regions", "State", context, errors)
+		context.descend(errors, func(context *ValidationContext) {
+			ValidateSlice(s.Regions, "Regions", "State", context, errors)
+		})
 	}
 
 	// Validate behaviors
@@ -122,22 +173,24 @@ func (s *State) ValidateWithErrors(context *ValidationContext, errors *Validatio
 	helper.ValidateReference(s.DoActivity, "DoActivity", "State", context, errors, false)
 
 	// Validate submachine if present
-	helper.ValidateReference(s.Submachine, "Submachine", "State", context, errors, false)
+	if s.Submachine != nil {
+		context.descend(errors, func(context *ValidationContext) {
+			helper.ValidateReference(s.Submachine, "Submachine", "State", context, errors, false)
+		})
+	}
 
 	// Validate connections
-	connectionValidators := make([]Validator, len(s.Connections))
-	for i, conn := range s.Connections {
-		connectionValidators[i] = conn
-	}
-	helper.ValidateCollection(connectionValidators, "Connections", "State", context, errors)
+	ValidateSlice(s.Connections, "Connections", "State", context, errors)
+	ValidateSlice(s.DeferrableTriggers, "DeferrableTriggers", "State", context, errors)
+	s.Cost.validate("State", context, errors)
 
 	// UML constraint validations
-	s.validateCompositeConstraints(context, errors)
-	s.validateSubmachineConstraints(context, errors)
-	s.validateBehaviorConsistency(context, errors)
+	context.check("state.composite", s.validateCompositeConstraints, errors)
+	context.check("state.submachine", s.validateSubmachineConstraints, errors)
+	context.check("state.behaviors", s.validateBehaviorConsistency, errors)
 
 	// Enhanced structural integrity validation
-	s.validateStateStructuralIntegrity(context, errors)
+	context.check("state.structural_integrity", s.validateStateStructuralIntegrity, errors)
 }
 
 // PseudostateKind represents the kind of pseudostate
@@ -158,19 +211,7 @@ const (
 
 // IsValid checks if the PseudostateKind is valid
 func (pk PseudostateKind) IsValid() bool {
-	validKinds := map[PseudostateKind]bool{
-		PseudostateKindInitial:        true,
-		PseudostateKindDeepHistory:    true,
-		PseudostateKindShallowHistory: true,
-		PseudostateKindJoin:           true,
-		PseudostateKindFork:           true,
-		PseudostateKindJunction:       true,
-		PseudostateKindChoice:         true,
-		PseudostateKindEntryPoint:     true,
-		PseudostateKindExitPoint:      true,
-		PseudostateKindTerminate:      true,
-	}
-	return validKinds[pk]
+	return PseudostateKinds.IsValid(pk)
 }
 
 // Pseudostate represents a pseudostate in a state machine
@@ -179,6 +220,14 @@ type Pseudostate struct {
 	Kind   PseudostateKind `json:"kind" validate:"required"`
 }
 
+// String returns a concise one-line description of the Pseudostate
+func (ps *Pseudostate) String() string {
+	if ps == nil {
+		return "Pseudostate(<nil>)"
+	}
+	return fmt.Sprintf("Pseudostate(id=%s, name=%q, kind=%s)", ps.ID, ps.Name, ps.Kind)
+}
+
 // Validate validates the Pseudostate data integrity
 func (ps *Pseudostate) Validate() error {
 	context := NewValidationContext()
@@ -202,6 +251,7 @@ func (ps *Pseudostate) ValidateWithErrors(context *ValidationContext, errors *Va
 	if errors == nil {
 		return
 	}
+	defer context.timeElement("Pseudostate", ps.ID)()
 
 	// Validate embedded vertex
 	ps.Vertex.ValidateWithErrors(context.WithPath("Vertex"), errors)
@@ -223,17 +273,17 @@ func (ps *Pseudostate) ValidateWithErrors(context *ValidationContext, errors *Va
 			ErrorTypeInvalid,
 			"Pseudostate",
 			"Kind",
-			fmt.Sprintf("invalid PseudostateKind: %s", ps.Kind),
+			PseudostateKinds.InvalidValueMessage(ps.Kind),
 			context.Path,
 		)
 	}
 
 	// UML constraint validations
-	ps.validateKindConstraints(context, errors)
-	ps.validateMultiplicity(context, errors)
+	context.check("pseudostate.kind", ps.validateKindConstraints, errors)
+	context.check("pseudostate.multiplicity", ps.validateMultiplicity, errors)
 
 	// Enhanced structural integrity validation
-	ps.validatePseudostateStructuralIntegrity(context, errors)
+	context.check("pseudostate.structural_integrity", ps.validatePseudostateStructuralIntegrity, errors)
 }
 
 // FinalState represents a final state in a state machine
@@ -241,6 +291,14 @@ type FinalState struct {
 	Vertex // Embedded vertex
 }
 
+// String returns a concise one-line description of the FinalState
+func (fs *FinalState) String() string {
+	if fs == nil {
+		return "FinalState(<nil>)"
+	}
+	return fmt.Sprintf("FinalState(id=%s, name=%q)", fs.ID, fs.Name)
+}
+
 // Validate validates the FinalState data integrity
 func (fs *FinalState) Validate() error {
 	context := NewValidationContext()
@@ -264,6 +322,7 @@ func (fs *FinalState) ValidateWithErrors(context *ValidationContext, errors *Val
 	if errors == nil {
 		return
 	}
+	defer context.timeElement("FinalState", fs.ID)()
 
 	// Validate embedded vertex
 	fs.Vertex.ValidateWithErrors(context.WithPath("Vertex"), errors)
@@ -280,7 +339,7 @@ func (fs *FinalState) ValidateWithErrors(context *ValidationContext, errors *Val
 	}
 
 	// Enhanced structural integrity validation
-	fs.validateFinalStateStructuralIntegrity(context, errors)
+	context.check("finalstate.structural_integrity", fs.validateFinalStateStructuralIntegrity, errors)
 }
 
 // ConnectionPointReference represents a connection point reference
@@ -315,24 +374,14 @@ func (cpr *ConnectionPointReference) ValidateWithErrors(context *ValidationConte
 		return
 	}
 
-	helper := NewValidationHelper()
-
 	// Validate embedded vertex
 	cpr.Vertex.ValidateWithErrors(context.WithPath("Vertex"), errors)
 
 	// Validate entry pseudostates
-	entryValidators := make([]Validator, len(cpr.Entry))
-	for i, entry := range cpr.Entry {
-		entryValidators[i] = entry
-	}
-	helper.ValidateCollection(entryValidators, "Entry", "ConnectionPointReference", context, errors)
+	ValidateSlice(cpr.Entry, "Entry", "ConnectionPointReference", context, errors)
 
 	// Validate exit pseudostates
-	exitValidators := make([]Validator, len(cpr.Exit))
-	for i, exit := range cpr.Exit {
-		exitValidators[i] = exit
-	}
-	helper.ValidateCollection(exitValidators, "Exit", "ConnectionPointReference", context, errors)
+	ValidateSlice(cpr.Exit, "Exit", "ConnectionPointReference", context, errors)
 }
 
 // validateKindConstraints validates kind-specific UML constraints for pseudostates
@@ -385,12 +434,13 @@ func (ps *Pseudostate) validateInitialConstraints(context *ValidationContext, er
 	// Initial pseudostates must have exactly one outgoing transition (validated elsewhere)
 	// Name should be appropriate for initial pseudostate
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"initial pseudostate should have a descriptive name (UML best practice)",
 			context.Path,
+			ClauseInitialVertex,
 		)
 	}
 }
@@ -400,12 +450,13 @@ func (ps *Pseudostate) validateHistoryConstraints(context *ValidationContext, er
 	// History pseudostates must be contained in composite states
 	// This would require access to the containing state, which we validate through region context
 	if context.Region == nil {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Kind",
 			"history pseudostate must be contained within a region of a composite state (UML constraint)",
 			context.Path,
+			ClauseHistoryVertices,
 		)
 	}
 }
@@ -415,12 +466,13 @@ func (ps *Pseudostate) validateJoinConstraints(context *ValidationContext, error
 	// Join pseudostates must have multiple incoming transitions and one outgoing transition
 	// This is typically validated at the transition level, but we can add basic checks here
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"join pseudostate should have a descriptive name (UML best practice)",
 			context.Path,
+			ClauseJoinVertex,
 		)
 	}
 }
@@ -430,12 +482,13 @@ func (ps *Pseudostate) validateForkConstraints(context *ValidationContext, error
 	// Fork pseudostates must have one incoming transition and multiple outgoing transitions
 	// This is typically validated at the transition level, but we can add basic checks here
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"fork pseudostate should have a descriptive name (UML best practice)",
 			context.Path,
+			ClauseForkVertex,
 		)
 	}
 }
@@ -445,12 +498,13 @@ func (ps *Pseudostate) validateJunctionConstraints(context *ValidationContext, e
 	// Junction pseudostates are static conditional branches
 	// They must have at least one incoming and one outgoing transition
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"junction pseudostate should have a descriptive name (UML best practice)",
 			context.Path,
+			ClauseJunctionVertex,
 		)
 	}
 }
@@ -460,12 +514,13 @@ func (ps *Pseudostate) validateChoiceConstraints(context *ValidationContext, err
 	// Choice pseudostates are dynamic conditional branches
 	// They must have at least one incoming and one outgoing transition
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"choice pseudostate should have a descriptive name (UML best practice)",
 			context.Path,
+			ClauseChoiceVertex,
 		)
 	}
 }
@@ -491,12 +546,13 @@ func (ps *Pseudostate) validateConnectionPointConstraints(context *ValidationCon
 		// This is a more nuanced check - only flag if we're clearly not in a connection point context
 		// and we're directly in a region (which would be inappropriate for entry/exit points)
 		if context.Region != nil && strings.Contains(pathStr, "Vertices") {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Pseudostate",
 				"Kind",
 				fmt.Sprintf("%s pseudostate should be used as a connection point, not as a regular vertex in a region (UML constraint)", ps.Kind),
 				context.Path,
+				ClauseEntryOrExit,
 			)
 		}
 	}
@@ -507,12 +563,13 @@ func (ps *Pseudostate) validateTerminateConstraints(context *ValidationContext,
 	// Terminate pseudostates should not have outgoing transitions
 	// This is validated at the transition level, but we can add basic checks here
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"terminate pseudostate should have a descriptive name (UML best practice)",
 			context.Path,
+			ClauseTerminate,
 		)
 	}
 }
@@ -523,37 +580,12 @@ func (ps *Pseudostate) validateInitialMultiplicity(region *Region, context *Vali
 		return
 	}
 
-	initialCount := 0
-	var initialIndices []int
-
-	// Count initial pseudostates in the region's vertices
-	for i, vertex := range region.Vertices {
-		if vertex == nil {
-			continue
-		}
-
-		// Check if this is an initial pseudostate
-		if vertex.Type == "pseudostate" {
-			// We need to check if this vertex represents an initial pseudostate
-			// Since we don't have direct access to the Pseudostate object from Vertex,
-			// we use the same logic as in the region validation
-			if ps.isInitialPseudostateVertex(vertex) {
-				initialCount++
-				initialIndices = append(initialIndices, i)
-			}
-		}
-	}
-
-	// If this pseudostate is initial and there are others, report error
-	if initialCount > 1 {
-		errors.AddError(
-			ErrorTypeMultiplicity,
-			"Pseudostate",
-			"Kind",
-			fmt.Sprintf("region can have at most one initial pseudostate, found %d initial pseudostates (UML constraint)", initialCount),
-			context.Path,
-		)
-	}
+	// We don't have direct access to the Pseudostate objects from the
+	// region's vertices, so we use the same naming logic as the region
+	// validation
+	ps.reportRegionMultiplicity(region, ps.isInitialPseudostateVertex, func(count int) string {
+		return fmt.Sprintf("region can have at most one initial pseudostate, found %d initial pseudostates (UML constraint)", count)
+	}, context, errors)
 }
 
 // validateHistoryMultiplicity validates history pseudostate multiplicity constraints
@@ -562,32 +594,13 @@ func (ps *Pseudostate) validateHistoryMultiplicity(region *Region, context *Vali
 		return
 	}
 
-	// Count history pseudostates of the same kind in the region
-	historyCount := 0
-	for _, vertex := range region.Vertices {
-		if vertex == nil {
-			continue
-		}
-
-		if vertex.Type == "pseudostate" {
-			// We would need access to the actual Pseudostate object to check the kind
-			// For now, we use naming conventions as a heuristic
-			if ps.isHistoryPseudostateVertex(vertex, ps.Kind) {
-				historyCount++
-			}
-		}
-	}
-
-	// A region should typically have at most one history pseudostate of each kind
-	if historyCount > 1 {
-		errors.AddError(
-			ErrorTypeMultiplicity,
-			"Pseudostate",
-			"Kind",
-			fmt.Sprintf("region should have at most one %s pseudostate, found %d (UML best practice)", ps.Kind, historyCount),
-			context.Path,
-		)
-	}
+	// A region should typically have at most one history pseudostate of
+	// each kind; naming conventions identify the kind of a vertex
+	ps.reportRegionMultiplicity(region, func(vertex *Vertex) bool {
+		return ps.isHistoryPseudostateVertex(vertex, ps.Kind)
+	}, func(count int) string {
+		return fmt.Sprintf("region should have at most one %s pseudostate, found %d (UML best practice)", ps.Kind, count)
+	}, context, errors)
 }
 
 // validateTerminateMultiplicity validates terminate pseudostate multiplicity constraints
@@ -596,30 +609,53 @@ func (ps *Pseudostate) validateTerminateMultiplicity(region *Region, context *Va
 		return
 	}
 
-	// Count terminate pseudostates in the region
-	terminateCount := 0
-	for _, vertex := range region.Vertices {
-		if vertex == nil {
-			continue
-		}
-
-		if vertex.Type == "pseudostate" {
-			if ps.isTerminatePseudostateVertex(vertex) {
-				terminateCount++
-			}
+	// Multiple terminate pseudostates in a region might indicate design issues
+	ps.reportRegionMultiplicity(region, ps.isTerminatePseudostateVertex, func(count int) string {
+		return fmt.Sprintf("region has %d terminate pseudostates, consider if this is intended (UML design consideration)", count)
+	}, context, errors)
+}
+
+// reportRegionMultiplicity records a multiplicity error for the region when
+// more than one of its pseudostate vertices matches. Every offending
+// pseudostate sees the same violation, so only the first one reports it,
+// as a Region error listing all offenders in its context; a pseudostate
+// validated against a region it is not part of reports it as well.
+func (ps *Pseudostate) reportRegionMultiplicity(region *Region, matches func(*Vertex) bool, message func(count int) string, context *ValidationContext, errors *ValidationErrors) {
+	path := regionPath(context)
+	var offenders multiplicityOffenders
+	for i, vertex := range region.Vertices {
+		if vertex != nil && vertex.Type == "pseudostate" && matches(vertex) {
+			offenders.add(vertex.ID, append(slices.Clone(path), fmt.Sprintf("Vertices[%d]", i)))
 		}
 	}
+	if len(offenders.ids) <= 1 {
+		return
+	}
+	if slices.Contains(offenders.ids, ps.ID) && offenders.ids[0] != ps.ID {
+		return // Reported by the first offender
+	}
 
-	// Multiple terminate pseudostates in a region might indicate design issues
-	if terminateCount > 1 {
-		errors.AddError(
-			ErrorTypeMultiplicity,
-			"Pseudostate",
-			"Kind",
-			fmt.Sprintf("region has %d terminate pseudostates, consider if this is intended (UML design consideration)", terminateCount),
-			context.Path,
-		)
+	errorContext := offenders.context()
+	errorContext["region_id"] = region.ID
+	errors.AddErrorWithContext(
+		ErrorTypeMultiplicity,
+		"Region",
+		"Vertices",
+		message(len(offenders.ids)),
+		path,
+		errorContext,
+	)
+}
+
+// regionPath returns the path of the region a vertex is validated in: the
+// context path without its last segment when that names the vertex in the
+// region's Vertices or States, and the context path otherwise
+func regionPath(context *ValidationContext) []string {
+	path := contextPath(context)
+	if n := len(path); n > 0 && (strings.HasPrefix(path[n-1], "Vertices[") || strings.HasPrefix(path[n-1], "States[")) {
+		return slices.Clone(path[:n-1])
 	}
+	return slices.Clone(path)
 }
 
 // Helper methods for identifying pseudostate types from vertex information
@@ -713,23 +749,25 @@ func (s *State) validateCompositeConstraints(context *ValidationContext, errors
 	if s.IsComposite {
 		// Composite states must have at least one region
 		if len(s.Regions) == 0 {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Regions",
 				"composite state must have at least one region (UML constraint)",
 				context.Path,
+				ClauseStateKindAttributes,
 			)
 		}
 
 		// Composite states cannot be simple states
 		if s.IsSimple {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"IsSimple",
 				"state cannot be both composite and simple (UML constraint)",
 				context.Path,
+				ClauseStateKindAttributes,
 			)
 		}
 
@@ -743,57 +781,62 @@ func (s *State) validateCompositeConstraints(context *ValidationContext, errors
 
 			// Ensure region has proper identification
 			if region.ID == "" {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"State",
 					"Regions",
 					fmt.Sprintf("region at index %d must have a valid ID (UML constraint)", i),
 					regionContext.Path,
+					ClauseRegion,
 				)
 			}
 
 			// Ensure region name is meaningful
 			if region.Name == "" {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"State",
 					"Regions",
 					fmt.Sprintf("region at index %d should have a descriptive name (UML best practice)", i),
 					regionContext.Path,
+					ClauseRegion,
 				)
 			}
 		}
 
 		// If orthogonal, must have multiple regions
 		if s.IsOrthogonal && len(s.Regions) < 2 {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Regions",
 				"orthogonal composite state must have at least two regions (UML constraint)",
 				context.Path,
+				ClauseStateKindAttributes,
 			)
 		}
 	} else {
 		// Non-composite states should not have regions
 		if len(s.Regions) > 0 {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Regions",
 				"non-composite state cannot have regions (UML constraint)",
 				context.Path,
+				ClauseStateKindAttributes,
 			)
 		}
 
 		// Non-composite states cannot be orthogonal
 		if s.IsOrthogonal {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"IsOrthogonal",
 				"non-composite state cannot be orthogonal (UML constraint)",
 				context.Path,
+				ClauseStateKindAttributes,
 			)
 		}
 	}
@@ -805,12 +848,13 @@ func (s *State) validateSubmachineConstraints(context *ValidationContext, errors
 	if s.IsSubmachineState {
 		// Submachine states must reference a state machine
 		if s.Submachine == nil {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Submachine",
 				"submachine state must reference a valid state machine (UML constraint)",
 				context.Path,
+				ClauseSubmachineStates,
 			)
 		} else {
 			// Validate the referenced submachine
@@ -818,23 +862,25 @@ func (s *State) validateSubmachineConstraints(context *ValidationContext, errors
 
 			// Ensure submachine has proper identification
 			if s.Submachine.ID == "" {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"State",
 					"Submachine",
 					"referenced submachine must have a valid ID (UML constraint)",
 					submachineContext.Path,
+					ClauseSubmachineStates,
 				)
 			}
 
 			// Ensure submachine name is meaningful
 			if s.Submachine.Name == "" {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"State",
 					"Submachine",
 					"referenced submachine should have a descriptive name (UML best practice)",
 					submachineContext.Path,
+					ClauseSubmachineStates,
 				)
 			}
 
@@ -844,45 +890,49 @@ func (s *State) validateSubmachineConstraints(context *ValidationContext, errors
 
 		// Submachine states should not be composite in the traditional sense
 		if s.IsComposite {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"IsComposite",
 				"submachine state should not be marked as composite (use submachine reference instead) (UML constraint)",
 				context.Path,
+				ClauseSubmachineOrRegions,
 			)
 		}
 
 		// Submachine states should not have their own regions
 		if len(s.Regions) > 0 {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Regions",
 				"submachine state should not have its own regions (use submachine reference instead) (UML constraint)",
 				context.Path,
+				ClauseSubmachineOrRegions,
 			)
 		}
 	} else {
 		// Non-submachine states should not reference a submachine
 		if s.Submachine != nil {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Submachine",
 				"non-submachine state should not reference a submachine (UML constraint)",
 				context.Path,
+				ClauseSubmachineStates,
 			)
 		}
 
 		// Non-submachine states should not have connection point references
 		if len(s.Connections) > 0 {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Connections",
 				"non-submachine state should not have connection point references (UML constraint)",
 				context.Path,
+				ClauseSubmachineStates,
 			)
 		}
 	}
@@ -926,12 +976,13 @@ func (s *State) validateConnectionPointCompatibility(context *ValidationContext,
 			}
 
 			if _, exists := submachineEntryPoints[entry.ID]; !exists {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"State",
 					"Connections",
-					fmt.Sprintf("connection point reference at index %d references entry point '%s' that does not exist in submachine (UML constraint)", i, entry.ID),
+					fmt.Sprintf("connection point reference at index %d references entry point '%s' that does not exist in submachine (UML constraint)%s", i, entry.ID, didYouMean(entry.ID, submachineEntryPoints)),
 					connContext.WithPathIndex("Entry", j).Path,
+					ClauseCPREntryPseudostates,
 				)
 			}
 		}
@@ -943,12 +994,13 @@ func (s *State) validateConnectionPointCompatibility(context *ValidationContext,
 			}
 
 			if _, exists := submachineExitPoints[exit.ID]; !exists {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"State",
 					"Connections",
-					fmt.Sprintf("connection point reference at index %d references exit point '%s' that does not exist in submachine (UML constraint)", i, exit.ID),
+					fmt.Sprintf("connection point reference at index %d references exit point '%s' that does not exist in submachine (UML constraint)%s", i, exit.ID, didYouMean(exit.ID, submachineExitPoints)),
 					connContext.WithPathIndex("Exit", j).Path,
+					ClauseCPRExitPseudostates,
 				)
 			}
 		}
@@ -988,45 +1040,49 @@ func (s *State) validateBehavior(behavior *Behavior, behaviorType string, contex
 
 	// Validate behavior has proper identification
 	if behavior.ID == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"State",
 			"Behavior",
 			fmt.Sprintf("%s behavior must have a valid ID (UML constraint)", behaviorType),
 			context.Path,
+			ClauseStateBehaviors,
 		)
 	}
 
 	// Validate behavior name is meaningful (optional but recommended)
 	if behavior.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"State",
 			"Behavior",
 			fmt.Sprintf("%s behavior should have a descriptive name (UML best practice)", behaviorType),
 			context.Path,
+			ClauseStateBehaviors,
 		)
 	}
 
 	// Validate behavior specification exists
-	if behavior.Specification == "" {
-		errors.AddError(
+	if !behavior.hasSpecification() {
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"State",
 			"Behavior",
 			fmt.Sprintf("%s behavior must have a valid specification (UML constraint)", behaviorType),
 			context.Path,
+			ClauseStateBehaviors,
 		)
 	}
 
 	// Validate behavior language consistency
-	if behavior.Language != "" && behavior.Specification == "" {
-		errors.AddError(
+	if behavior.Language != "" && !behavior.hasSpecification() {
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"State",
 			"Behavior",
 			fmt.Sprintf("%s behavior specifies language '%s' but has no specification content (UML constraint)", behaviorType, behavior.Language),
 			context.Path,
+			ClauseStateBehaviors,
 		)
 	}
 }
@@ -1037,34 +1093,37 @@ func (s *State) validateBehaviorInteractions(context *ValidationContext, errors
 	if s.Entry != nil && s.Exit != nil {
 		// Check for potential naming conflicts
 		if s.Entry.Name == s.Exit.Name && s.Entry.Name != "" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				"entry and exit behaviors should have distinct names to avoid confusion (UML best practice)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 
 		// Check for language consistency
 		if s.Entry.Language != "" && s.Exit.Language != "" && s.Entry.Language != s.Exit.Language {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				fmt.Sprintf("entry behavior uses language '%s' while exit behavior uses '%s', consider consistency (UML best practice)", s.Entry.Language, s.Exit.Language),
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 
 		// Check for specification conflicts (same ID but different specifications)
 		if s.Entry.ID == s.Exit.ID && s.Entry.Specification != s.Exit.Specification {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				"entry and exit behaviors have the same ID but different specifications, which may cause confusion (UML best practice)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 	}
@@ -1072,43 +1131,47 @@ func (s *State) validateBehaviorInteractions(context *ValidationContext, errors
 	// Do activity should be compatible with entry/exit behaviors
 	if s.DoActivity != nil {
 		if s.Entry != nil && s.Entry.Language != "" && s.DoActivity.Language != "" && s.Entry.Language != s.DoActivity.Language {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				fmt.Sprintf("entry behavior uses language '%s' while do activity uses '%s', consider consistency (UML best practice)", s.Entry.Language, s.DoActivity.Language),
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 
 		if s.Exit != nil && s.Exit.Language != "" && s.DoActivity.Language != "" && s.Exit.Language != s.DoActivity.Language {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				fmt.Sprintf("exit behavior uses language '%s' while do activity uses '%s', consider consistency (UML best practice)", s.Exit.Language, s.DoActivity.Language),
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 
 		// Check for ID conflicts between do activity and entry/exit behaviors
 		if s.Entry != nil && s.DoActivity.ID == s.Entry.ID {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				"do activity and entry behavior have the same ID, which may cause confusion (UML best practice)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 
 		if s.Exit != nil && s.DoActivity.ID == s.Exit.ID {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Behaviors",
 				"do activity and exit behavior have the same ID, which may cause confusion (UML best practice)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 	}
@@ -1124,12 +1187,13 @@ func (s *State) validateBehaviorSemantics(context *ValidationContext, errors *Va
 		// Check if entry behavior specification suggests it's doing cleanup (which should be in exit)
 		entrySpec := strings.ToLower(s.Entry.Specification)
 		if strings.Contains(entrySpec, "cleanup") || strings.Contains(entrySpec, "destroy") || strings.Contains(entrySpec, "finalize") {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Entry",
 				"entry behavior specification suggests cleanup operations, which should typically be in exit behavior (UML semantics)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 	}
@@ -1139,12 +1203,13 @@ func (s *State) validateBehaviorSemantics(context *ValidationContext, errors *Va
 		// Check if exit behavior specification suggests it's doing initialization (which should be in entry)
 		exitSpec := strings.ToLower(s.Exit.Specification)
 		if strings.Contains(exitSpec, "initialize") || strings.Contains(exitSpec, "setup") || strings.Contains(exitSpec, "create") {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"Exit",
 				"exit behavior specification suggests initialization operations, which should typically be in entry behavior (UML semantics)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 	}
@@ -1154,12 +1219,13 @@ func (s *State) validateBehaviorSemantics(context *ValidationContext, errors *Va
 		doSpec := strings.ToLower(s.DoActivity.Specification)
 		// Check if do activity suggests one-time operations (which should be in entry/exit)
 		if strings.Contains(doSpec, "initialize") || strings.Contains(doSpec, "setup") || strings.Contains(doSpec, "cleanup") || strings.Contains(doSpec, "destroy") {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"DoActivity",
 				"do activity specification suggests one-time operations, which should typically be in entry or exit behaviors (UML semantics)",
 				context.Path,
+				ClauseStateBehaviors,
 			)
 		}
 	}
@@ -1167,84 +1233,10 @@ func (s *State) validateBehaviorSemantics(context *ValidationContext, errors *Va
 
 // validateVertexConstraints performs enhanced validation for vertex-specific constraints
 func (v *Vertex) validateVertexConstraints(context *ValidationContext, errors *ValidationErrors) {
-	// Validate vertex naming conventions
-	v.validateNamingConventions(context, errors)
-
 	// Validate vertex type consistency
 	v.validateTypeConsistency(context, errors)
 }
 
-// validateNamingConventions validates vertex naming conventions
-func (v *Vertex) validateNamingConventions(context *ValidationContext, errors *ValidationErrors) {
-	// Validate ID format (should not contain spaces or special characters that could cause issues)
-	if v.ID != "" {
-		// Check for potentially problematic characters in ID
-		problematicChars := []string{" ", "\t", "\n", "\r", ".", "/", "\\", ":", ";", ",", "\"", "'"}
-		for _, char := range problematicChars {
-			if strings.Contains(v.ID, char) {
-				errors.AddError(
-					ErrorTypeConstraint,
-					"Vertex",
-					"ID",
-					fmt.Sprintf("vertex ID contains potentially problematic character '%s' which may cause issues (best practice)", char),
-					context.Path,
-				)
-				break
-			}
-		}
-	}
-
-	// Validate name is meaningful for the vertex type
-	if v.Name != "" && v.Type != "" {
-		// Check if name suggests a different type than what's specified
-		nameUpper := strings.ToUpper(v.Name)
-
-		switch v.Type {
-		case "state":
-			// State names suggesting pseudostate functionality
-			pseudostateKeywords := []string{"INITIAL", "FINAL", "CHOICE", "JUNCTION", "FORK", "JOIN", "ENTRY", "EXIT", "TERMINATE"}
-			for _, keyword := range pseudostateKeywords {
-				if strings.Contains(nameUpper, keyword) {
-					errors.AddError(
-						ErrorTypeConstraint,
-						"Vertex",
-						"Name",
-						fmt.Sprintf("state name '%s' suggests pseudostate functionality but vertex type is 'state' (may cause confusion)", v.Name),
-						context.Path,
-					)
-					break
-				}
-			}
-		case "pseudostate":
-			// Pseudostate names suggesting regular state functionality
-			stateKeywords := []string{"ACTIVE", "INACTIVE", "RUNNING", "STOPPED", "WAITING", "PROCESSING"}
-			for _, keyword := range stateKeywords {
-				if strings.Contains(nameUpper, keyword) {
-					errors.AddError(
-						ErrorTypeConstraint,
-						"Vertex",
-						"Name",
-						fmt.Sprintf("pseudostate name '%s' suggests regular state functionality but vertex type is 'pseudostate' (may cause confusion)", v.Name),
-						context.Path,
-					)
-					break
-				}
-			}
-		case "finalstate":
-			// Final state names should suggest completion
-			if !strings.Contains(nameUpper, "FINAL") && !strings.Contains(nameUpper, "END") && !strings.Contains(nameUpper, "COMPLETE") && !strings.Contains(nameUpper, "DONE") {
-				errors.AddError(
-					ErrorTypeConstraint,
-					"Vertex",
-					"Name",
-					fmt.Sprintf("final state name '%s' should suggest completion or finality (UML best practice)", v.Name),
-					context.Path,
-				)
-			}
-		}
-	}
-}
-
 // validateTypeConsistency validates vertex type consistency
 func (v *Vertex) validateTypeConsistency(context *ValidationContext, errors *ValidationErrors) {
 	// Validate type is not empty and is one of the valid types
@@ -1258,34 +1250,37 @@ func (v *Vertex) validateTypeConsistency(context *ValidationContext, errors *Val
 	case "state":
 		// States should have meaningful names
 		if v.Name == "" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Vertex",
 				"Name",
 				"state vertices should have meaningful names (UML best practice)",
 				context.Path,
+				ClauseVertex,
 			)
 		}
 	case "pseudostate":
 		// Pseudostates should have names that indicate their purpose
 		if v.Name == "" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Vertex",
 				"Name",
 				"pseudostate vertices should have names that indicate their purpose (UML best practice)",
 				context.Path,
+				ClauseVertex,
 			)
 		}
 	case "finalstate":
 		// Final states should have names that indicate completion
 		if v.Name == "" {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Vertex",
 				"Name",
 				"final state vertices should have names that indicate completion (UML best practice)",
 				context.Path,
+				ClauseVertex,
 			)
 		}
 	}
@@ -1337,12 +1332,13 @@ func (s *State) validateStateFlagConsistency(context *ValidationContext, errors
 
 	// Validate orthogonal flag consistency
 	if s.IsOrthogonal && !s.IsComposite {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"State",
 			"IsOrthogonal",
 			"state cannot be orthogonal without being composite (UML constraint)",
 			context.Path,
+			ClauseStateKindAttributes,
 		)
 	}
 }
@@ -1413,7 +1409,7 @@ func (s *State) validateOrthogonalRegionConsistency(context *ValidationContext,
 		hasInitial := false
 
 		// Check if region has an initial pseudostate
-		for _, vertex := range region.Vertices {
+		for _, vertex := range contentOf(region).Vertices {
 			if vertex != nil && vertex.Type == "pseudostate" && s.isInitialPseudostateVertex(vertex) {
 				hasInitial = true
 				break
@@ -1422,12 +1418,13 @@ func (s *State) validateOrthogonalRegionConsistency(context *ValidationContext,
 
 		if !hasInitial {
 			regionsWithoutInitial++
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"State",
 				"OrthogonalRegions",
 				fmt.Sprintf("orthogonal region at index %d should have an initial pseudostate (UML best practice)", i),
 				regionContext.Path,
+				ClauseInitialVertex,
 			)
 		}
 	}
@@ -1439,18 +1436,8 @@ func (s *State) validateSubmachineReferenceIntegrity(context *ValidationContext,
 		return
 	}
 
-	submachineContext := context.WithPath("Submachine")
-
-	// Validate submachine is not the same as the containing state machine
-	if context.StateMachine != nil && s.Submachine.ID == context.StateMachine.ID {
-		errors.AddError(
-			ErrorTypeConstraint,
-			"State",
-			"Submachine",
-			"submachine state cannot reference the same state machine that contains it (circular reference)",
-			submachineContext.Path,
-		)
-	}
+	// Direct and indirect recursion through submachines is reported by
+	// StateMachine.validateSubmachineRecursion
 
 	// Validate submachine has compatible connection points with this state's connections
 	s.validateSubmachineConnectionPointCompatibility(context, errors)
@@ -1498,7 +1485,7 @@ func (s *State) validateSubmachineConnectionPointCompatibility(context *Validati
 					ErrorTypeConstraint,
 					"State",
 					"Connections",
-					fmt.Sprintf("connection point reference at index %d references entry point '%s' that does not exist in submachine (structural integrity violation)", i, entry.ID),
+					fmt.Sprintf("connection point reference at index %d references entry point '%s' that does not exist in submachine (structural integrity violation)%s", i, entry.ID, didYouMean(entry.ID, submachineEntryPoints)),
 					connContext.WithPathIndex("Entry", j).Path,
 				)
 			}
@@ -1515,7 +1502,7 @@ func (s *State) validateSubmachineConnectionPointCompatibility(context *Validati
 					ErrorTypeConstraint,
 					"State",
 					"Connections",
-					fmt.Sprintf("connection point reference at index %d references exit point '%s' that does not exist in submachine (structural integrity violation)", i, exit.ID),
+					fmt.Sprintf("connection point reference at index %d references exit point '%s' that does not exist in submachine (structural integrity violation)%s", i, exit.ID, didYouMean(exit.ID, submachineExitPoints)),
 					connContext.WithPathIndex("Exit", j).Path,
 				)
 			}
@@ -1582,19 +1569,13 @@ func (ps *Pseudostate) validatePseudostateStructuralIntegrity(context *Validatio
 
 // validateKindSpecificStructuralConstraints validates structural constraints specific to pseudostate kinds
 func (ps *Pseudostate) validateKindSpecificStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
+	NewValidationHelper().ValidateNameKeywords(ps.Name, string(ps.Kind), "Pseudostate", context, errors)
+
 	switch ps.Kind {
 	case PseudostateKindInitial:
 		ps.validateInitialStructuralConstraints(context, errors)
 	case PseudostateKindDeepHistory, PseudostateKindShallowHistory:
 		ps.validateHistoryStructuralConstraints(context, errors)
-	case PseudostateKindJoin, PseudostateKindFork:
-		ps.validateJoinForkStructuralConstraints(context, errors)
-	case PseudostateKindJunction, PseudostateKindChoice:
-		ps.validateJunctionChoiceStructuralConstraints(context, errors)
-	case PseudostateKindEntryPoint, PseudostateKindExitPoint:
-		ps.validateConnectionPointStructuralConstraints(context, errors)
-	case PseudostateKindTerminate:
-		ps.validateTerminateStructuralConstraints(context, errors)
 	}
 }
 
@@ -1602,34 +1583,14 @@ func (ps *Pseudostate) validateKindSpecificStructuralConstraints(context *Valida
 func (ps *Pseudostate) validateInitialStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
 	// Initial pseudostates should have names that clearly indicate their purpose
 	if ps.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Name",
 			"initial pseudostate should have a name that clearly indicates its purpose (UML best practice)",
 			context.Path,
+			ClauseInitialVertex,
 		)
-	} else {
-		// Check if name suggests initial purpose
-		nameUpper := strings.ToUpper(ps.Name)
-		initialKeywords := []string{"INITIAL", "INIT", "START", "BEGIN"}
-		hasInitialKeyword := false
-		for _, keyword := range initialKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasInitialKeyword = true
-				break
-			}
-		}
-
-		if !hasInitialKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"Pseudostate",
-				"Name",
-				fmt.Sprintf("initial pseudostate name '%s' should suggest initial purpose (UML best practice)", ps.Name),
-				context.Path,
-			)
-		}
 	}
 }
 
@@ -1637,162 +1598,15 @@ func (ps *Pseudostate) validateInitialStructuralConstraints(context *ValidationC
 func (ps *Pseudostate) validateHistoryStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
 	// History pseudostates should be in composite states
 	if context.Region == nil {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"Pseudostate",
 			"Kind",
 			"history pseudostate should be contained within a region of a composite state (UML constraint)",
 			context.Path,
+			ClauseHistoryVertices,
 		)
 	}
-
-	// History pseudostates should have names that indicate their type
-	if ps.Name != "" {
-		nameUpper := strings.ToUpper(ps.Name)
-		historyKeywords := []string{"HISTORY", "H"}
-		hasHistoryKeyword := false
-		for _, keyword := range historyKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasHistoryKeyword = true
-				break
-			}
-		}
-
-		if !hasHistoryKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"Pseudostate",
-				"Name",
-				fmt.Sprintf("history pseudostate name '%s' should suggest history purpose (UML best practice)", ps.Name),
-				context.Path,
-			)
-		}
-	}
-}
-
-// validateJoinForkStructuralConstraints validates structural constraints for join/fork pseudostates
-func (ps *Pseudostate) validateJoinForkStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
-	// Join/fork pseudostates should have names that indicate their purpose
-	if ps.Name != "" {
-		nameUpper := strings.ToUpper(ps.Name)
-		var expectedKeywords []string
-
-		if ps.Kind == PseudostateKindJoin {
-			expectedKeywords = []string{"JOIN", "MERGE", "SYNC"}
-		} else {
-			expectedKeywords = []string{"FORK", "SPLIT", "PARALLEL"}
-		}
-
-		hasExpectedKeyword := false
-		for _, keyword := range expectedKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasExpectedKeyword = true
-				break
-			}
-		}
-
-		if !hasExpectedKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"Pseudostate",
-				"Name",
-				fmt.Sprintf("%s pseudostate name '%s' should suggest %s purpose (UML best practice)", ps.Kind, ps.Name, strings.ToLower(string(ps.Kind))),
-				context.Path,
-			)
-		}
-	}
-}
-
-// validateJunctionChoiceStructuralConstraints validates structural constraints for junction/choice pseudostates
-func (ps *Pseudostate) validateJunctionChoiceStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
-	// Junction/choice pseudostates should have names that indicate their purpose
-	if ps.Name != "" {
-		nameUpper := strings.ToUpper(ps.Name)
-		var expectedKeywords []string
-
-		if ps.Kind == PseudostateKindJunction {
-			expectedKeywords = []string{"JUNCTION", "BRANCH", "DECISION"}
-		} else {
-			expectedKeywords = []string{"CHOICE", "DECIDE", "SELECT"}
-		}
-
-		hasExpectedKeyword := false
-		for _, keyword := range expectedKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasExpectedKeyword = true
-				break
-			}
-		}
-
-		if !hasExpectedKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"Pseudostate",
-				"Name",
-				fmt.Sprintf("%s pseudostate name '%s' should suggest %s purpose (UML best practice)", ps.Kind, ps.Name, strings.ToLower(string(ps.Kind))),
-				context.Path,
-			)
-		}
-	}
-}
-
-// validateConnectionPointStructuralConstraints validates structural constraints for connection point pseudostates
-func (ps *Pseudostate) validateConnectionPointStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
-	// Connection points should have names that indicate their direction
-	if ps.Name != "" {
-		nameUpper := strings.ToUpper(ps.Name)
-		var expectedKeywords []string
-
-		if ps.Kind == PseudostateKindEntryPoint {
-			expectedKeywords = []string{"ENTRY", "ENTER", "IN"}
-		} else {
-			expectedKeywords = []string{"EXIT", "OUT", "LEAVE"}
-		}
-
-		hasExpectedKeyword := false
-		for _, keyword := range expectedKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasExpectedKeyword = true
-				break
-			}
-		}
-
-		if !hasExpectedKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"Pseudostate",
-				"Name",
-				fmt.Sprintf("%s pseudostate name '%s' should suggest %s purpose (UML best practice)", ps.Kind, ps.Name, strings.ToLower(string(ps.Kind))),
-				context.Path,
-			)
-		}
-	}
-}
-
-// validateTerminateStructuralConstraints validates structural constraints for terminate pseudostates
-func (ps *Pseudostate) validateTerminateStructuralConstraints(context *ValidationContext, errors *ValidationErrors) {
-	// Terminate pseudostates should have names that indicate termination
-	if ps.Name != "" {
-		nameUpper := strings.ToUpper(ps.Name)
-		terminateKeywords := []string{"TERMINATE", "END", "STOP", "KILL"}
-		hasTerminateKeyword := false
-		for _, keyword := range terminateKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasTerminateKeyword = true
-				break
-			}
-		}
-
-		if !hasTerminateKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"Pseudostate",
-				"Name",
-				fmt.Sprintf("terminate pseudostate name '%s' should suggest termination purpose (UML best practice)", ps.Name),
-				context.Path,
-			)
-		}
-	}
 }
 
 // validatePlacementConsistency validates pseudostate placement consistency
@@ -1805,12 +1619,13 @@ func (ps *Pseudostate) validatePlacementConsistency(context *ValidationContext,
 		if !strings.Contains(pathStr, "ConnectionPoints") && !strings.Contains(pathStr, "Connections") {
 			// Check if we're in a region's vertices collection, which would be inappropriate
 			if strings.Contains(pathStr, "Vertices") && context.Region != nil {
-				errors.AddError(
+				errors.AddErrorWithClause(
 					ErrorTypeConstraint,
 					"Pseudostate",
 					"Placement",
 					fmt.Sprintf("%s pseudostate should be used as a connection point, not as a regular vertex in a region (UML constraint)", ps.Kind),
 					context.Path,
+					ClauseEntryOrExit,
 				)
 			}
 		}
@@ -1819,12 +1634,13 @@ func (ps *Pseudostate) validatePlacementConsistency(context *ValidationContext,
 	// Other pseudostates should typically be in region vertices
 	if ps.Kind != PseudostateKindEntryPoint && ps.Kind != PseudostateKindExitPoint {
 		if strings.Contains(pathStr, "ConnectionPoints") {
-			errors.AddError(
+			errors.AddErrorWithClause(
 				ErrorTypeConstraint,
 				"Pseudostate",
 				"Placement",
 				fmt.Sprintf("%s pseudostate should not be used as a connection point (UML constraint)", ps.Kind),
 				context.Path,
+				ClauseConnectionPoints,
 			)
 		}
 	}
@@ -1843,34 +1659,14 @@ func (fs *FinalState) validateFinalStateStructuralIntegrity(context *ValidationC
 func (fs *FinalState) validateFinalStateNaming(context *ValidationContext, errors *ValidationErrors) {
 	// Final states should have names that indicate completion
 	if fs.Name == "" {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"FinalState",
 			"Name",
 			"final state should have a name that indicates completion (UML best practice)",
 			context.Path,
+			ClauseFinalState,
 		)
-	} else {
-		// Check if name suggests completion
-		nameUpper := strings.ToUpper(fs.Name)
-		completionKeywords := []string{"FINAL", "END", "COMPLETE", "DONE", "FINISH", "TERMINATE"}
-		hasCompletionKeyword := false
-		for _, keyword := range completionKeywords {
-			if strings.Contains(nameUpper, keyword) {
-				hasCompletionKeyword = true
-				break
-			}
-		}
-
-		if !hasCompletionKeyword {
-			errors.AddError(
-				ErrorTypeConstraint,
-				"FinalState",
-				"Name",
-				fmt.Sprintf("final state name '%s' should suggest completion or finality (UML best practice)", fs.Name),
-				context.Path,
-			)
-		}
 	}
 }
 
@@ -1880,18 +1676,58 @@ func (fs *FinalState) validateFinalStatePlacement(context *ValidationContext, er
 	pathStr := strings.Join(context.Path, ".")
 
 	if strings.Contains(pathStr, "ConnectionPoints") {
-		errors.AddError(
+		errors.AddErrorWithClause(
 			ErrorTypeConstraint,
 			"FinalState",
 			"Placement",
 			"final state should not be used as a connection point (UML constraint)",
 			context.Path,
+			ClauseConnectionPoints,
 		)
 	}
 
 	// Final states should be in regions that can actually reach them
 	if context.Region != nil {
-		// This is a more complex validation that would require analyzing the transition graph
-		// For now, we just ensure the final state is properly contained
+		fs.validateFinalStateReachability(context, errors)
+	}
+}
+
+// validateFinalStateReachability warns when the final state has no incoming
+// transitions, or when none of its incoming transitions can be reached from
+// the initial pseudostate of its region. Incoming transitions are looked up
+// in the transition index of the whole state machine when the context has
+// one, since a transition may be declared in an enclosing region;
+// reachability follows the transitions declared in the final state's region.
+func (fs *FinalState) validateFinalStateReachability(context *ValidationContext, errors *ValidationErrors) {
+	var index *TransitionIndex
+	if context.StateMachine != nil {
+		index = context.transitionsOf(context.StateMachine)
+	} else {
+		index = IndexTransitions(&StateMachine{Regions: []*Region{context.Region}})
+	}
+	if len(index.incoming[fs.ID]) == 0 {
+		errors.AddWarning(
+			ErrorTypeConstraint,
+			"FinalState",
+			"Placement",
+			fmt.Sprintf("final state '%s' has no incoming transitions and can never be reached", fs.ID),
+			context.Path,
+		)
+		return
+	}
+
+	initials := regionInitialIDs(context.Region)
+	if len(initials) == 0 {
+		// Regions without an initial pseudostate are only entered explicitly
+		return
+	}
+	if !regionReaches(context.Region, initials)[fs.ID] {
+		errors.AddWarning(
+			ErrorTypeConstraint,
+			"FinalState",
+			"Placement",
+			fmt.Sprintf("final state '%s' cannot be reached from the initial pseudostate of region '%s'", fs.ID, context.Region.ID),
+			context.Path,
+		)
 	}
 }
@@ -1,6 +1,10 @@
 package models
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestVertex_Validate(t *testing.T) {
 	tests := []struct {
@@ -310,6 +314,128 @@ func TestFinalState_Validate(t *testing.T) {
 	}
 }
 
+func TestFinalState_Reachability(t *testing.T) {
+	initial := &Vertex{ID: "initial", Name: "initial", Type: "pseudostate"}
+	s1 := &Vertex{ID: "s1", Name: "S1", Type: "state"}
+	s2 := &Vertex{ID: "s2", Name: "S2", Type: "state"}
+	final := &Vertex{ID: "done", Name: "Done", Type: "finalstate"}
+	transition := func(id string, source, target *Vertex) *Transition {
+		return &Transition{ID: id, Source: source, Target: target, Kind: TransitionKindExternal}
+	}
+
+	tests := []struct {
+		name        string
+		region      *Region
+		sm          bool // Validate with the state machine in the context
+		outer       []*Transition
+		wantWarning string
+	}{
+		{
+			name: "reached from the initial pseudostate",
+			region: &Region{ID: "r", Vertices: []*Vertex{initial, final}, Transitions: []*Transition{
+				transition("t1", initial, s1), transition("t2", s1, final),
+			}},
+		},
+		{
+			name:        "no incoming transitions",
+			region:      &Region{ID: "r", Vertices: []*Vertex{initial, final}, Transitions: []*Transition{transition("t1", initial, s1)}},
+			wantWarning: "final state 'done' has no incoming transitions and can never be reached",
+		},
+		{
+			name: "unreachable from the initial pseudostate",
+			region: &Region{ID: "r", Vertices: []*Vertex{initial, final}, Transitions: []*Transition{
+				transition("t1", initial, s1), transition("t2", s2, final),
+			}},
+			wantWarning: "final state 'done' cannot be reached from the initial pseudostate of region 'r'",
+		},
+		{
+			name:   "region without an initial pseudostate",
+			region: &Region{ID: "r", Vertices: []*Vertex{final}, Transitions: []*Transition{transition("t2", s2, final)}},
+		},
+		{
+			name:   "incoming transition declared in another region",
+			region: &Region{ID: "r", Vertices: []*Vertex{final}},
+			sm:     true,
+			outer:  []*Transition{transition("t3", s2, final)},
+		},
+		{
+			name:        "other regions are ignored without a state machine",
+			region:      &Region{ID: "r", Vertices: []*Vertex{final}},
+			outer:       []*Transition{transition("t3", s2, final)},
+			wantWarning: "has no incoming transitions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := NewValidationContext()
+			if tt.sm || tt.outer != nil {
+				sm := &StateMachine{ID: "sm", Regions: []*Region{tt.region, {ID: "outer", Transitions: tt.outer}}}
+				if tt.sm {
+					context = context.WithStateMachine(sm)
+				}
+			}
+			errors := &ValidationErrors{}
+			(&FinalState{Vertex: *final}).ValidateWithErrors(context.WithRegion(tt.region), errors)
+
+			if len(errors.Errors) > 0 {
+				t.Fatalf("unexpected errors: %v", errors.Errors)
+			}
+			if tt.wantWarning == "" {
+				if len(errors.Warnings) > 0 {
+					t.Errorf("unexpected warnings: %v", errors.Warnings)
+				}
+				return
+			}
+			if len(errors.Warnings) != 1 || !strings.Contains(errors.Warnings[0].Message, tt.wantWarning) {
+				t.Errorf("warnings = %v, want one containing %q", errors.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestStateMachine_ValidateFinalStateReachability(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(sm *StateMachine)
+		wantPath string // Path of the one reachability warning, if any
+	}{
+		{name: "reached final state"},
+		{
+			name: "isolated final state",
+			modify: func(sm *StateMachine) {
+				sm.Regions[0].Vertices = append(sm.Regions[0].Vertices, &Vertex{ID: "orphan", Name: "Orphan", Type: "finalstate"})
+			},
+			wantPath: fmt.Sprintf("Regions[0].Vertices[%d]", len(createValidStateMachine().Regions[0].Vertices)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := createValidStateMachine()
+			if tt.modify != nil {
+				tt.modify(sm)
+			}
+			errors := &ValidationErrors{}
+			sm.ValidateWithErrors(NewValidationContext().WithStateMachine(sm), errors)
+
+			var paths []string
+			for _, warning := range errors.Warnings {
+				if warning.Rule == "finalstate.reachable" {
+					paths = append(paths, strings.Join(warning.Path, "."))
+				}
+			}
+			want := []string{}
+			if tt.wantPath != "" {
+				want = append(want, tt.wantPath)
+			}
+			if len(paths) != len(want) || (len(want) > 0 && paths[0] != want[0]) {
+				t.Errorf("reachability warnings at %v, want %v", paths, want)
+			}
+		})
+	}
+}
+
 func TestConnectionPointReference_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -1442,6 +1568,61 @@ func TestState_UMLConstraintValidation(t *testing.T) {
 					"connection point reference at index 0 references entry point 'nonexistent' that does not exist in submachine (UML constraint)",
 				},
 			},
+			{
+				name: "misspelled connection point reference suggests the entry point",
+				state: &State{
+					Vertex: Vertex{
+						ID:   "s1",
+						Name: "SubmachineState",
+						Type: "state",
+					},
+					IsSubmachineState: true,
+					Submachine: &StateMachine{
+						ID:      "sm1",
+						Name:    "ReferencedStateMachine",
+						Version: "1.0",
+						Regions: []*Region{
+							{
+								ID:   "r1",
+								Name: "DefaultRegion",
+							},
+						},
+						ConnectionPoints: []*Pseudostate{
+							{
+								Vertex: Vertex{
+									ID:   "entry1",
+									Name: "EntryPoint",
+									Type: "pseudostate",
+								},
+								Kind: PseudostateKindEntryPoint,
+							},
+						},
+					},
+					Connections: []*ConnectionPointReference{
+						{
+							Vertex: Vertex{
+								ID:   "cpr1",
+								Name: "ConnectionRef",
+								Type: "pseudostate",
+							},
+							Entry: []*Pseudostate{
+								{
+									Vertex: Vertex{
+										ID:   "entyr1", // Misspells entry1
+										Name: "NonExistentEntry",
+										Type: "pseudostate",
+									},
+									Kind: PseudostateKindEntryPoint,
+								},
+							},
+						},
+					},
+				},
+				wantErr: true,
+				errMsgs: []string{
+					"connection point reference at index 0 references entry point 'entyr1' that does not exist in submachine (UML constraint); did you mean 'entry1'?",
+				},
+			},
 		}
 
 		for _, tt := range tests {
@@ -1869,3 +2050,30 @@ func TestState_UMLConstraintValidation(t *testing.T) {
 		}
 	})
 }
+
+func TestVertexTypes_String(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"vertex", (&Vertex{ID: "v1", Name: "Idle", Type: "state"}).String(), `Vertex(id=v1, name="Idle", type=state)`},
+		{"simple state", (&State{Vertex: Vertex{ID: "s1", Name: "Idle", Type: "state"}}).String(), `State(id=s1, name="Idle", kind=simple)`},
+		{"composite state", (&State{Vertex: Vertex{ID: "s2", Name: "Busy", Type: "state"}, IsComposite: true}).String(), `State(id=s2, name="Busy", kind=composite)`},
+		{"orthogonal state", (&State{Vertex: Vertex{ID: "s3", Name: "Par", Type: "state"}, IsComposite: true, IsOrthogonal: true}).String(), `State(id=s3, name="Par", kind=orthogonal)`},
+		{"submachine state", (&State{Vertex: Vertex{ID: "s4", Name: "Sub", Type: "state"}, IsSubmachineState: true}).String(), `State(id=s4, name="Sub", kind=submachine)`},
+		{"pseudostate", (&Pseudostate{Vertex: Vertex{ID: "p1", Name: "Initial", Type: "pseudostate"}, Kind: PseudostateKindInitial}).String(), `Pseudostate(id=p1, name="Initial", kind=initial)`},
+		{"final state", (&FinalState{Vertex: Vertex{ID: "f1", Name: "Done", Type: "finalstate"}}).String(), `FinalState(id=f1, name="Done")`},
+		{"nil vertex", (*Vertex)(nil).String(), "Vertex(<nil>)"},
+		{"nil state", (*State)(nil).String(), "State(<nil>)"},
+		{"nil pseudostate", (*Pseudostate)(nil).String(), "Pseudostate(<nil>)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("String() = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package models
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// vsdxPageContents is the subset of a Visio page part read by ImportVSDX
+type vsdxPageContents struct {
+	Shapes   []vsdxShape   `xml:"Shapes>Shape"`
+	Connects []vsdxConnect `xml:"Connects>Connect"`
+}
+
+type vsdxShape struct {
+	ID     string      `xml:"ID,attr"`
+	NameU  string      `xml:"NameU,attr"`
+	Master string      `xml:"Master,attr"`
+	Text   *vsdxText   `xml:"Text"`
+	Shapes []vsdxShape `xml:"Shapes>Shape"`
+}
+
+type vsdxText struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+type vsdxConnect struct {
+	FromSheet string `xml:"FromSheet,attr"`
+	FromCell  string `xml:"FromCell,attr"`
+	ToSheet   string `xml:"ToSheet,attr"`
+}
+
+type vsdxMasters struct {
+	Masters []struct {
+		ID    string `xml:"ID,attr"`
+		NameU string `xml:"NameU,attr"`
+	} `xml:"Master"`
+}
+
+type vsdxPages struct {
+	Pages []struct {
+		Name string `xml:"Name,attr"`
+		Rel  struct {
+			ID string `xml:"id,attr"`
+		} `xml:"Rel"`
+	} `xml:"Page"`
+}
+
+type vsdxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// vsdxMasterKinds maps words in Visio master names, checked in order, to the
+// label the diagram importer understands. UML stencils use one master for
+// forks and joins, which is resolved from the connectors.
+var vsdxMasterKinds = []struct {
+	word  string
+	label string
+}{
+	{"deep history", "h*"},
+	{"history", "history"},
+	{"initial", "initial"},
+	{"final", "final"},
+	{"terminate", "terminate"},
+	{"choice", "choice"},
+	{"decision", "choice"},
+	{"junction", "junction"},
+	{"fork", ""},
+	{"join", ""},
+	{"synchronization", ""},
+}
+
+// ImportVSDX reads the UML state diagrams of a Visio VSDX file, one state
+// machine per page in page order. Shapes are mapped by their master, e.g.
+// "Initial State", "Final State", "Choice" or "Fork/Join", and otherwise by
+// their text like ImportGraphML; group shapes become composite states.
+// Connectors become transitions, labeled "events [guard] / effect".
+func ImportVSDX(r io.ReaderAt, size int64) ([]*StateMachine, *ImportReport, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open VSDX package: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	masters := make(map[string]string)
+	if file := files["visio/masters/masters.xml"]; file != nil {
+		var doc vsdxMasters
+		if err := decodeZipXML(file, &doc); err != nil {
+			return nil, nil, err
+		}
+		for _, master := range doc.Masters {
+			masters[master.ID] = normalizeVisioName(master.NameU)
+		}
+	}
+
+	pages, err := vsdxPageParts(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pages) == 0 {
+		return nil, nil, fmt.Errorf("VSDX package contains no pages")
+	}
+
+	report := &ImportReport{Format: "VSDX"}
+	var machines []*StateMachine
+	for i, page := range pages {
+		var contents vsdxPageContents
+		if err := decodeZipXML(files[page.part], &contents); err != nil {
+			return nil, nil, err
+		}
+		id := fmt.Sprintf("page-%d", i+1)
+		nodes, edges := vsdxDiagram(id, contents, masters, report)
+		machines = append(machines, buildDiagramMachine(id, displayName(page.name, id), nodes, edges, report))
+	}
+	return machines, report, nil
+}
+
+// vsdxPage is a page part and its display name
+type vsdxPage struct {
+	part string
+	name string
+}
+
+// vsdxPageParts lists the page parts in page order, using the page index and
+// its relationships when present and the part names otherwise
+func vsdxPageParts(files map[string]*zip.File) ([]vsdxPage, error) {
+	var pages []vsdxPage
+	index, rels := files["visio/pages/pages.xml"], files["visio/pages/_rels/pages.xml.rels"]
+	if index != nil && rels != nil {
+		var doc vsdxPages
+		var relationships vsdxRelationships
+		if err := decodeZipXML(index, &doc); err != nil {
+			return nil, err
+		}
+		if err := decodeZipXML(rels, &relationships); err != nil {
+			return nil, err
+		}
+		targets := make(map[string]string)
+		for _, rel := range relationships.Relationships {
+			targets[rel.ID] = path.Join("visio/pages", rel.Target)
+		}
+		for _, page := range doc.Pages {
+			if part := targets[page.Rel.ID]; files[part] != nil {
+				pages = append(pages, vsdxPage{part: part, name: page.Name})
+			}
+		}
+		if len(pages) > 0 {
+			return pages, nil
+		}
+	}
+
+	for name := range files {
+		if strings.HasPrefix(name, "visio/pages/page") && strings.HasSuffix(name, ".xml") && name != "visio/pages/pages.xml" {
+			pages = append(pages, vsdxPage{part: name})
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool { return naturalLess(pages[i].part, pages[j].part) })
+	return pages, nil
+}
+
+// vsdxDiagram converts the shapes and connections of a page into diagram
+// nodes and edges
+func vsdxDiagram(pageID string, contents vsdxPageContents, masters map[string]string, report *ImportReport) ([]diagramNode, []diagramEdge) {
+	nodeID := func(shapeID string) string { return pageID + "-shape-" + shapeID }
+
+	// Shapes glued to others by their begin or end points are connectors
+	type connector struct{ source, target string }
+	connectors := make(map[string]*connector)
+	var connectorOrder []string
+	for _, connect := range contents.Connects {
+		c := connectors[connect.FromSheet]
+		if c == nil {
+			c = &connector{}
+			connectors[connect.FromSheet] = c
+			connectorOrder = append(connectorOrder, connect.FromSheet)
+		}
+		switch connect.FromCell {
+		case "BeginX":
+			c.source = connect.ToSheet
+		case "EndX":
+			c.target = connect.ToSheet
+		}
+	}
+
+	var nodes []diagramNode
+	labels := make(map[string]string)
+	type pendingShape struct {
+		shape  vsdxShape
+		parent string
+	}
+	var stack []pendingShape
+	for i := len(contents.Shapes) - 1; i >= 0; i-- {
+		stack = append(stack, pendingShape{shape: contents.Shapes[i]})
+	}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		shape := next.shape
+		text := ""
+		if shape.Text != nil {
+			text = stripMarkup(xmlText(shape.Text.Inner))
+		}
+		labels[shape.ID] = text
+		if connectors[shape.ID] != nil {
+			continue
+		}
+
+		master := masters[shape.Master]
+		if master == "" {
+			master = normalizeVisioName(shape.NameU)
+		}
+		if strings.Contains(master, "connector") || strings.Contains(master, "transition") {
+			report.add("node", nodeID(shape.ID), text, "connector is not glued to shapes at both ends; skipped")
+			continue
+		}
+
+		node := diagramNode{ID: nodeID(shape.ID), Label: text, Parent: next.parent}
+		for _, kind := range vsdxMasterKinds {
+			if strings.Contains(master, kind.word) {
+				node.Label = kind.label
+				if kind.label == "" {
+					node.Shape = "bar"
+				}
+				break
+			}
+		}
+		nodes = append(nodes, node)
+
+		for i := len(shape.Shapes) - 1; i >= 0; i-- {
+			stack = append(stack, pendingShape{shape: shape.Shapes[i], parent: node.ID})
+		}
+	}
+
+	var edges []diagramEdge
+	for _, id := range connectorOrder {
+		c := connectors[id]
+		if c.source == "" || c.target == "" {
+			report.add("edge", nodeID(id), labels[id], "connector is not glued to shapes at both ends; skipped")
+			continue
+		}
+		edges = append(edges, diagramEdge{ID: nodeID(id), Source: nodeID(c.source), Target: nodeID(c.target), Label: labels[id]})
+	}
+	return nodes, edges
+}
+
+// normalizeVisioName lowercases a Visio shape or master name and removes the
+// ".<n>" suffix Visio adds to copies, e.g. "Initial State.12"
+func normalizeVisioName(name string) string {
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		if _, err := strconv.Atoi(name[dot+1:]); err == nil {
+			name = name[:dot]
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// naturalLess orders names with embedded numbers numerically, so that
+// page10.xml sorts after page9.xml
+func naturalLess(a, b string) bool {
+	trim := func(s string) (string, int) {
+		end := len(s) - len(".xml")
+		start := end
+		for start > 0 && s[start-1] >= '0' && s[start-1] <= '9' {
+			start--
+		}
+		n, _ := strconv.Atoi(s[start:end])
+		return s[:start], n
+	}
+	prefixA, numberA := trim(a)
+	prefixB, numberB := trim(b)
+	if prefixA != prefixB {
+		return a < b
+	}
+	return numberA < numberB
+}
+
+// decodeZipXML decodes an XML part of a zip package
+func decodeZipXML(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// buildVSDX packages the given parts as a VSDX file
+func buildVSDX(t *testing.T, parts map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range parts {
+		part, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatalf("Write(%s) error = %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+const vsdxMastersPart = `<Masters xmlns="http://schemas.microsoft.com/office/visio/2012/main">
+  <Master ID="1" NameU="Initial State"/>
+  <Master ID="2" NameU="State"/>
+  <Master ID="3" NameU="Final State"/>
+  <Master ID="4" NameU="Transition"/>
+  <Master ID="5" NameU="Fork/Join"/>
+</Masters>`
+
+// vsdxOrderPage has initial -> Received -> fork -> (Packing, Billing);
+// Shipping is a group containing Loading
+const vsdxOrderPage = `<PageContents xmlns="http://schemas.microsoft.com/office/visio/2012/main">
+  <Shapes>
+    <Shape ID="1" Master="1"/>
+    <Shape ID="2" Master="2"><Text><cp IX="0"/>Received</Text></Shape>
+    <Shape ID="3" Master="5"/>
+    <Shape ID="4" NameU="State.7"><Text>Packing</Text></Shape>
+    <Shape ID="5" Master="2"><Text>Billing</Text></Shape>
+    <Shape ID="6" Master="2" Type="Group"><Text>Shipping</Text>
+      <Shapes><Shape ID="7" Master="2"><Text>Loading</Text></Shape></Shapes>
+    </Shape>
+    <Shape ID="8" Master="3"/>
+    <Shape ID="10" Master="4"/>
+    <Shape ID="11" Master="4"><Text>pay [valid] / charge</Text></Shape>
+    <Shape ID="12" Master="4"/>
+    <Shape ID="13" Master="4"/>
+    <Shape ID="14" Master="4"><Text>dangling</Text></Shape>
+  </Shapes>
+  <Connects>
+    <Connect FromSheet="10" FromCell="BeginX" ToSheet="1"/>
+    <Connect FromSheet="10" FromCell="EndX" ToSheet="2"/>
+    <Connect FromSheet="11" FromCell="BeginX" ToSheet="2"/>
+    <Connect FromSheet="11" FromCell="EndX" ToSheet="3"/>
+    <Connect FromSheet="12" FromCell="BeginX" ToSheet="3"/>
+    <Connect FromSheet="12" FromCell="EndX" ToSheet="4"/>
+    <Connect FromSheet="13" FromCell="BeginX" ToSheet="3"/>
+    <Connect FromSheet="13" FromCell="EndX" ToSheet="5"/>
+    <Connect FromSheet="14" FromCell="BeginX" ToSheet="5"/>
+  </Connects>
+</PageContents>`
+
+func TestImportVSDX(t *testing.T) {
+	archive := buildVSDX(t, map[string]string{
+		"visio/masters/masters.xml": vsdxMastersPart,
+		"visio/pages/page1.xml":     vsdxOrderPage,
+		"visio/pages/page2.xml":     `<PageContents><Shapes><Shape ID="1"><Text>Alone</Text></Shape></Shapes></PageContents>`,
+		"visio/pages/pages.xml": `<Pages xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <Page ID="0" Name="Orders"><Rel r:id="rId2"/></Page>
+  <Page ID="1" Name="Extra"><Rel r:id="rId1"/></Page>
+</Pages>`,
+		"visio/pages/_rels/pages.xml.rels": `<Relationships>
+  <Relationship Id="rId1" Target="page2.xml"/>
+  <Relationship Id="rId2" Target="page1.xml"/>
+</Relationships>`,
+	})
+
+	machines, report, err := ImportVSDX(archive, archive.Size())
+	if err != nil {
+		t.Fatalf("ImportVSDX() error = %v", err)
+	}
+	if len(machines) != 2 || machines[0].Name != "Orders" || machines[1].Name != "Extra" {
+		t.Fatalf("machines = %v, want Orders and Extra in page order", machines)
+	}
+
+	orders := machines[0].Regions[0]
+	var names []string
+	for _, state := range orders.States {
+		names = append(names, state.Name)
+	}
+	if got := strings.Join(names, ","); got != "Received,Packing,Billing,Shipping" {
+		t.Errorf("states = %s", got)
+	}
+	if shipping := orders.States[3]; !shipping.IsComposite || shipping.Regions[0].States[0].Name != "Loading" {
+		t.Errorf("Shipping = %+v, want a composite state containing Loading", shipping)
+	}
+
+	kinds := make(map[string]string)
+	for _, vertex := range orders.Vertices {
+		kinds[vertex.ID] = vertex.Type + ":" + string(vertexPseudostateKind(vertex))
+	}
+	want := map[string]string{
+		"page-1-shape-1": "pseudostate:initial",
+		"page-1-shape-3": "pseudostate:fork",
+		"page-1-shape-8": "finalstate:",
+	}
+	for id, kind := range want {
+		if kinds[id] != kind {
+			t.Errorf("vertex %s = %q, want %q", id, kinds[id], kind)
+		}
+	}
+
+	if len(orders.Transitions) != 4 {
+		t.Fatalf("transitions = %d, want 4", len(orders.Transitions))
+	}
+	pay := orders.Transitions[1]
+	if pay.Triggers[0].EventID != "pay" || pay.Guard.Specification != "valid" || pay.Effect.Name != "charge" {
+		t.Errorf("pay transition = %+v", pay)
+	}
+	if !strings.Contains(report.String(), "edge 'page-1-shape-14'") {
+		t.Errorf("report = %s, want the dangling connector", report)
+	}
+}
+
+func TestImportVSDX_PageOrderWithoutIndex(t *testing.T) {
+	page := func(name string) string {
+		return `<PageContents><Shapes><Shape ID="1"><Text>` + name + `</Text></Shape></Shapes></PageContents>`
+	}
+	archive := buildVSDX(t, map[string]string{
+		"visio/pages/page10.xml": page("ten"),
+		"visio/pages/page2.xml":  page("two"),
+	})
+	machines, _, err := ImportVSDX(archive, archive.Size())
+	if err != nil {
+		t.Fatalf("ImportVSDX() error = %v", err)
+	}
+	if len(machines) != 2 || machines[0].Regions[0].States[0].Name != "two" {
+		t.Errorf("machines = %v, want page2 before page10", machines)
+	}
+}
+
+func TestImportVSDX_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input *bytes.Reader
+		want  string
+	}{
+		{name: "not a zip", input: bytes.NewReader([]byte("plain text")), want: "failed to open VSDX package"},
+		{name: "no pages", input: buildVSDX(t, map[string]string{"docProps/app.xml": "<Properties/>"}), want: "contains no pages"},
+		{name: "malformed page", input: buildVSDX(t, map[string]string{"visio/pages/page1.xml": "<PageContents>"}), want: "failed to parse visio/pages/page1.xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ImportVSDX(tt.input, tt.input.Size())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ImportVSDX() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeVisioName(t *testing.T) {
+	tests := map[string]string{
+		"Initial State.12": "initial state",
+		"State":            "state",
+		"v1.0 State":       "v1.0 state",
+	}
+	for input, want := range tests {
+		if got := normalizeVisioName(input); got != want {
+			t.Errorf("normalizeVisioName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
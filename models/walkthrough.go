@@ -0,0 +1,306 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Walkthrough returns a plain-text narrative of the state machine for
+// documentation and for readers using screen readers, e.g. "From state
+// Active, on event Cancel when amount > 0, go to Cancelled and run
+// refund()." It describes where each region starts, then each state in
+// model order with its behaviors, deferred events and outgoing transitions,
+// followed by the regions of composite states and the transitions leaving
+// pseudostates. It uses no symbols or layout that only make sense visually;
+// paragraphs are separated by blank lines.
+func Walkthrough(sm *StateMachine) string {
+	if sm == nil {
+		return ""
+	}
+	w := &walkthroughWriter{sm: sm, index: IndexTransitions(sm)}
+
+	counts := CountElements(sm)
+	w.paragraph(fmt.Sprintf("%s is a state machine with %s, %s and %s.",
+		displayName(sm.Name, sm.ID), plural(counts.States, "state"), plural(counts.Transitions, "transition"), plural(counts.Regions, "region")))
+	regions := nonNilRegions(sm.Regions)
+	if len(regions) > 1 {
+		w.paragraph(fmt.Sprintf("Its %d regions are active at the same time: %s.", len(regions), regionList(regions)))
+	}
+	for _, region := range regions {
+		w.region(region, nil)
+	}
+	return strings.TrimSuffix(w.out.String(), "\n")
+}
+
+// walkthroughWriter accumulates the paragraphs of a walkthrough
+type walkthroughWriter struct {
+	sm    *StateMachine
+	out   strings.Builder
+	index *TransitionIndex
+}
+
+// paragraph writes a paragraph followed by a blank line
+func (w *walkthroughWriter) paragraph(text string) {
+	w.out.WriteString(text + "\n\n")
+}
+
+// region describes a region, its states and its pseudostates; owner is the
+// composite state containing it, if any
+func (w *walkthroughWriter) region(region *Region, owner *State) {
+	intro := "Region " + displayName(region.Name, region.ID)
+	if owner != nil {
+		intro = "Inside state " + displayName(owner.Name, owner.ID) + ", region " + displayName(region.Name, region.ID)
+	}
+	var starts []string
+	for _, id := range regionInitialIDs(region) {
+		for _, transition := range w.index.Outgoing(id) {
+			if transition.Target != nil {
+				starts = append(starts, walkthroughTarget(transition.Target))
+			}
+		}
+	}
+	switch {
+	case len(starts) > 0:
+		w.paragraph(intro + " starts in " + strings.Join(starts, " or ") + ".")
+	case len(region.States) > 0:
+		w.paragraph(intro + " has no initial pseudostate, so it is only entered directly.")
+	default:
+		w.paragraph(intro + " is empty.")
+	}
+
+	for _, state := range region.States {
+		if state != nil {
+			w.state(state)
+		}
+	}
+	initials := make(map[string]bool)
+	for _, id := range regionInitialIDs(region) {
+		initials[id] = true
+	}
+	for _, vertex := range region.Vertices {
+		if vertex == nil || initials[vertex.ID] {
+			continue
+		}
+		if vertex.Type == "finalstate" {
+			w.paragraph(capitalize(walkthroughVertex(vertex)) + " completes region " + displayName(region.Name, region.ID) + ".")
+			continue
+		}
+		if sentences := w.transitions(vertex.ID); len(sentences) > 0 {
+			w.paragraph(strings.Join(sentences, " "))
+		}
+	}
+	for _, state := range region.States {
+		if state == nil {
+			continue
+		}
+		for _, nested := range nonNilRegions(state.Regions) {
+			w.region(nested, state)
+		}
+	}
+}
+
+// state describes a state, its behaviors, deferred events and outgoing
+// transitions
+func (w *walkthroughWriter) state(state *State) {
+	name := displayName(state.Name, state.ID)
+	sentences := []string{"State " + name + "."}
+	if state.Deprecated {
+		sentences[0] = "State " + name + " is deprecated."
+	}
+	if state.Entry != nil {
+		sentences = append(sentences, "On entry, run "+behaviorText(state.Entry)+".")
+	}
+	if state.DoActivity != nil {
+		sentences = append(sentences, "While in "+name+", do "+behaviorText(state.DoActivity)+".")
+	}
+	if state.Exit != nil {
+		sentences = append(sentences, "On exit, run "+behaviorText(state.Exit)+".")
+	}
+	var deferred []string
+	for _, trigger := range state.DeferrableTriggers {
+		if trigger != nil {
+			deferred = append(deferred, w.eventName(trigger))
+		}
+	}
+	if len(deferred) == 1 {
+		sentences = append(sentences, "While in "+name+", event "+deferred[0]+" is deferred.")
+	} else if len(deferred) > 1 {
+		sentences = append(sentences, "While in "+name+", events "+joinWords(deferred, "and")+" are deferred.")
+	}
+	if regions := nonNilRegions(state.Regions); len(regions) == 1 {
+		sentences = append(sentences, "It contains region "+regionList(regions)+".")
+	} else if len(regions) > 1 {
+		sentences = append(sentences, fmt.Sprintf("It contains %d regions that are active at the same time: %s.", len(regions), regionList(regions)))
+	}
+	if state.Submachine != nil {
+		sentences = append(sentences, "It runs the state machine "+displayName(state.Submachine.Name, state.Submachine.ID)+".")
+	}
+	transitions := w.transitions(state.ID)
+	if len(transitions) == 0 && len(state.Regions) == 0 && state.Submachine == nil {
+		transitions = []string{"No transitions leave it."}
+	}
+	w.paragraph(strings.Join(append(sentences, transitions...), " "))
+}
+
+// transitions describes the transitions leaving a vertex, one sentence each
+func (w *walkthroughWriter) transitions(sourceID string) []string {
+	var sentences []string
+	for _, transition := range w.index.Outgoing(sourceID) {
+		sentences = append(sentences, w.transition(transition))
+	}
+	return sentences
+}
+
+// transition describes a transition in one sentence
+func (w *walkthroughWriter) transition(transition *Transition) string {
+	var events []string
+	for _, trigger := range transition.Triggers {
+		if trigger != nil {
+			events = append(events, w.eventName(trigger))
+		}
+	}
+	var condition []string
+	if len(events) == 1 {
+		condition = append(condition, "on event "+events[0])
+	} else if len(events) > 1 {
+		condition = append(condition, "on events "+eventList(events))
+	}
+	if guard := transition.Guard; guard != nil {
+		if spec := strings.TrimSpace(displayName(guard.Specification, guard.ID)); spec == "else" {
+			condition = append(condition, "otherwise")
+		} else {
+			condition = append(condition, "when "+spec)
+		}
+	}
+	if len(condition) == 0 && transition.Source.Type == "state" {
+		condition = append(condition, "when it completes")
+	}
+
+	source := walkthroughVertex(transition.Source)
+	if transition.Kind == TransitionKindInternal || transition.Target == nil {
+		sentence := "While in " + source
+		if len(condition) > 0 {
+			sentence += ", " + strings.Join(condition, " ")
+		}
+		if transition.Effect != nil {
+			return sentence + ", run " + behaviorText(transition.Effect) + " without leaving it."
+		}
+		return sentence + ", nothing happens."
+	}
+
+	sentence := "From " + source
+	if len(condition) > 0 {
+		sentence += ", " + strings.Join(condition, " ")
+	}
+	if transition.Target.ID == transition.Source.ID {
+		sentence += ", leave and re-enter it"
+	} else {
+		sentence += ", go to " + walkthroughTarget(transition.Target)
+	}
+	if transition.Effect != nil {
+		sentence += " and run " + behaviorText(transition.Effect)
+	}
+	if transition.Deprecated {
+		sentence += "; this transition is deprecated"
+	}
+	return sentence + "."
+}
+
+// eventName names the event of a trigger, preferring the catalog name
+func (w *walkthroughWriter) eventName(trigger *Trigger) string {
+	if event := trigger.ResolveEvent(w.sm); event != nil {
+		return displayName(event.Name, event.ID)
+	}
+	return displayName(trigger.EventKey(), displayName(trigger.Name, trigger.ID))
+}
+
+// walkthroughVertex names a vertex with its kind, e.g. "state Idle" or
+// "choice point route"
+func walkthroughVertex(vertex *Vertex) string {
+	name := displayName(vertex.Name, vertex.ID)
+	switch vertex.Type {
+	case "state":
+		return "state " + name
+	case "finalstate":
+		return "final state " + name
+	}
+	kind := pseudostateKindOf(vertex)
+	if strings.EqualFold(name, string(kind)) {
+		name = vertex.ID
+	}
+	switch kind {
+	case PseudostateKindChoice:
+		return "choice point " + name
+	case PseudostateKindJunction:
+		return "junction " + name
+	case PseudostateKindFork:
+		return "fork " + name
+	case PseudostateKindJoin:
+		return "join " + name
+	case PseudostateKindShallowHistory:
+		return "shallow history " + name
+	case PseudostateKindDeepHistory:
+		return "deep history " + name
+	case PseudostateKindEntryPoint:
+		return "entry point " + name
+	case PseudostateKindExitPoint:
+		return "exit point " + name
+	case PseudostateKindTerminate:
+		return "terminate pseudostate " + name
+	case PseudostateKindInitial:
+		return "initial pseudostate " + name
+	}
+	return "pseudostate " + name
+}
+
+// walkthroughTarget names the target of a transition; states go by their
+// name alone, as in "go to Cancelled"
+func walkthroughTarget(vertex *Vertex) string {
+	if vertex.Type == "state" {
+		return displayName(vertex.Name, vertex.ID)
+	}
+	return walkthroughVertex(vertex)
+}
+
+// behaviorText names a behavior by its name, specification or ID
+func behaviorText(behavior *Behavior) string {
+	return displayName(behavior.Name, displayName(behavior.Specification, behavior.ID))
+}
+
+// regionList lists the names of regions, e.g. "Audio and Video"
+func regionList(regions []*Region) string {
+	names := make([]string, len(regions))
+	for i, region := range regions {
+		names[i] = displayName(region.Name, region.ID)
+	}
+	return joinWords(names, "and")
+}
+
+// eventList lists event names, e.g. "play, resume or restart"
+func eventList(events []string) string {
+	return joinWords(events, "or")
+}
+
+// joinWords joins words as in a sentence: "a", "a and b", "a, b and c"
+func joinWords(words []string, conjunction string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " " + conjunction + " " + words[len(words)-1]
+}
+
+// plural formats a count with a noun, e.g. "1 state" or "2 states"
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// capitalize upper-cases the first letter of a sentence
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWalkthrough(t *testing.T) {
+	active, cancelled := execState("active"), execState("cancelled")
+	active.Name, cancelled.Name = "Active", "Cancelled"
+	active.Entry = &Behavior{ID: "open", Specification: "openOrder()"}
+	active.DeferrableTriggers = []*Trigger{{ID: "defer-ship", EventID: "ship"}, {ID: "defer-bill", EventID: "bill"}}
+	initial := execPseudostate("initial", "initial")
+	route := execPseudostate("route", "choice")
+	done := &Vertex{ID: "done", Name: "Done", Type: "finalstate"}
+
+	cancel := execTransition("cancel", &active.Vertex, &cancelled.Vertex, "cancel")
+	cancel.Guard = &Constraint{ID: "positive", Specification: "amount>0"}
+	cancel.Effect = &Behavior{ID: "refund", Specification: "refund()"}
+	log := execTransition("log", &active.Vertex, &active.Vertex, "ping", "poll")
+	log.Kind = TransitionKindInternal
+	log.Effect = &Behavior{ID: "log", Name: "log activity"}
+	toRoute := execTransition("finish", &cancelled.Vertex, route)
+	toDone := execTransition("to-done", route, done)
+	toDone.Guard = &Constraint{ID: "refunded", Specification: "refunded"}
+	back := execTransition("retry", route, &active.Vertex)
+	back.Guard = &Constraint{ID: "else", Specification: "else"}
+
+	sm := &StateMachine{
+		ID:     "order",
+		Name:   "Order",
+		Events: []*Event{{ID: "cancel", Name: "Cancel", Type: EventTypeSignal}},
+		Regions: []*Region{{
+			ID:          "main",
+			Name:        "Main",
+			States:      []*State{active, cancelled},
+			Vertices:    []*Vertex{initial, route, done},
+			Transitions: []*Transition{execTransition("start", initial, &active.Vertex), cancel, log, toRoute, toDone, back},
+		}},
+	}
+	text := Walkthrough(sm)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "overview", want: "Order is a state machine with 2 states, 6 transitions and 1 region.\n\n"},
+		{name: "start", want: "Region Main starts in Active.\n\n"},
+		{name: "entry behavior", want: "State Active. On entry, run openOrder()."},
+		{name: "deferred events", want: "While in Active, events ship and bill are deferred."},
+		{name: "catalog event name with guard and effect", want: "From state Active, on event Cancel when amount>0, go to Cancelled and run refund()."},
+		{name: "internal transition", want: "While in state Active, on events ping or poll, run log activity without leaving it."},
+		{name: "completion transition", want: "From state Cancelled, when it completes, go to choice point route."},
+		{name: "choice branches", want: "From choice point route, when refunded, go to final state Done. From choice point route, otherwise, go to Active."},
+		{name: "final state", want: "Final state Done completes region Main."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("Walkthrough() missing %q in\n%s", tt.want, text)
+			}
+		})
+	}
+	if strings.ContainsAny(text, "→[]") {
+		t.Errorf("Walkthrough() should not use diagram notation:\n%s", text)
+	}
+}
+
+func TestWalkthrough_Nested(t *testing.T) {
+	text := Walkthrough(newPlayerMachine())
+	want := []string{
+		"State playing. It contains 2 regions that are active at the same time: audio and video. From state playing, on event stop, go to idle.",
+		"Inside state playing, region audio starts in loading.",
+		"From state loading, on event tick, leave and re-enter it.",
+		"State streaming. No transitions leave it.",
+	}
+	previous := -1
+	for _, sentence := range want {
+		index := strings.Index(text, sentence)
+		if index < 0 || index < previous {
+			t.Errorf("Walkthrough() missing %q after the previous sentence in\n%s", sentence, text)
+		}
+		previous = index
+	}
+	if Walkthrough(nil) != "" {
+		t.Error("Walkthrough(nil) should be empty")
+	}
+}